@@ -376,6 +376,14 @@ func newDocsShowCmd(app *App) *cobra.Command {
 	return cmd
 }
 
+// docsStepTypeNames are the built-in step types with a reference page; each
+// resolves through docsFieldsSlug.
+var docsStepTypeNames = []string{
+	"agent", "breyta", "db", "db-bigquery", "db-firestore", "db-sql", "fanout",
+	"files", "function", "http", "job", "kv", "llm", "notify", "search", "sleep",
+	"ssh", "table", "wait",
+}
+
 func docsFieldsSlug(input string) string {
 	raw := strings.TrimSpace(input)
 	normalized := normalizeDocsFieldName(raw)
@@ -99,6 +99,11 @@ func newDocsSyncCmd(app *App) *cobra.Command {
 			}
 
 			fmt.Fprintf(cmd.OutOrStdout(), "Downloaded %d docs pages to %s\n", len(pages), filepath.Join(rootOut, "pages"))
+			if bundle, ok, err := writeStepSchemaBundle(rootOut, strings.TrimRight(strings.TrimSpace(client.BaseURL), "/"), pages); err != nil {
+				return writeErr(cmd, err)
+			} else if ok {
+				fmt.Fprintf(cmd.OutOrStdout(), "Wrote step schemas for %d step types to %s (version %s)\n", len(bundle.Steps), filepath.Join(rootOut, stepSchemaBundleFileName), bundle.Version)
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "Ready for grep: rg -n \"<query>\" %s\n", rootOut)
 			return nil
 		},
@@ -585,6 +585,23 @@ func readParamsJSON(paramsJSON, paramsFile string) (map[string]any, error) {
 }
 
 func pushLocalFlowLiteral(cmd *cobra.Command, app *App, sourcePath, source string) (map[string]any, int, error) {
+	if err := requireAPI(app); err != nil {
+		return nil, 0, err
+	}
+	if _, err := parseSingleTopLevelMapEntries(source); err != nil {
+		return nil, 0, fmt.Errorf("read local flow before push: %w", err)
+	}
+	expanded, err := expandFlowSourceIncludes(sourcePath, source)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pushExpandedFlowLiteral(cmd, app, source, expanded)
+}
+
+// pushExpandedFlowLiteral pushes an already include-expanded flow literal as
+// the draft and validates it. source is the root file text the slug is read
+// from.
+func pushExpandedFlowLiteral(cmd *cobra.Command, app *App, source, expanded string) (map[string]any, int, error) {
 	if err := requireAPI(app); err != nil {
 		return nil, 0, err
 	}
@@ -596,10 +613,6 @@ func pushLocalFlowLiteral(cmd *cobra.Command, app *App, sourcePath, source strin
 	if err != nil {
 		return nil, 0, fmt.Errorf("read local flow slug before push: %w", err)
 	}
-	expanded, err := expandFlowSourceIncludes(sourcePath, source)
-	if err != nil {
-		return nil, 0, err
-	}
 	out, status, err := apiClient(app).DoCommand(cmd.Context(), "flows.put_draft", map[string]any{
 		"flowLiteral": expanded,
 	})
@@ -650,6 +663,12 @@ func annotatePostPushValidationFailure(out map[string]any, flowSlug string) {
 }
 
 func runLocalFlowStep(cmd *cobra.Command, app *App, slug, sourcePath, source, stepID string, params map[string]any, idempotencyKey, profileID string, timeout time.Duration) (map[string]any, int, error) {
+	return runLocalFlowStepOfType(cmd, app, slug, sourcePath, source, "", stepID, params, idempotencyKey, profileID, timeout)
+}
+
+// runLocalFlowStepOfType runs a step of the local source through steps.run.
+// stepType is set for inline flow/step ids and empty for :steps entries.
+func runLocalFlowStepOfType(cmd *cobra.Command, app *App, slug, sourcePath, source, stepType, stepID string, params map[string]any, idempotencyKey, profileID string, timeout time.Duration) (map[string]any, int, error) {
 	if err := requireAPI(app); err != nil {
 		return nil, 0, err
 	}
@@ -666,6 +685,9 @@ func runLocalFlowStep(cmd *cobra.Command, app *App, slug, sourcePath, source, st
 		"params":      params,
 		"flowLiteral": expanded,
 	}
+	if stepType != "" {
+		payload["stepType"] = stepType
+	}
 	if key := strings.TrimSpace(idempotencyKey); key != "" {
 		payload["idempotencyKey"] = key
 	}
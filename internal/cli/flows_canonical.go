@@ -1046,9 +1046,10 @@ func newFlowsDiffCmd(app *App) *cobra.Command {
 	var toVersion int
 	var full bool
 	var file string
+	var local bool
 
 	cmd := &cobra.Command{
-		Use:   "diff <flow-slug>",
+		Use:   "diff <flow-slug> | diff --local <from.clj> <to.clj>",
 		Short: "Show a source diff between draft, live, or released versions",
 		Long: strings.TrimSpace(`
 Show a unified diff for flow source.
@@ -1058,13 +1059,37 @@ additions to the draft. If a
 draft-only flow has pushed draft history but no live version, the server can
 compare the current draft to the previous pushed draft version.
 
+Pass --local with two flow files to compare them offline. Both sides are
+parsed with includes expanded, and the result lists added, removed, and
+modified steps by id, schedule and interface changes, other top-level field
+changes, and a focused diff of each modified form. Formatting-only and
+comment-only edits are ignored.
+
 - breyta flows diff my-flow
 - breyta flows diff my-flow --file ./flows/my-flow.clj
 - breyta flows diff my-flow --from draft --to version --to-version 7
 - breyta flows diff my-flow --from version --from-version 6 --to version --to-version 7
+- breyta flows diff --local ./flows/my-flow.clj ./pulled/my-flow.clj
 		`),
-		Args: cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if local {
+				return cobra.ExactArgs(2)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if local {
+				for _, name := range []string{"from", "to", "from-version", "to-version", "file", "full"} {
+					if cmd.Flags().Changed(name) {
+						return writeErr(cmd, fmt.Errorf("--%s cannot be combined with --local", name))
+					}
+				}
+				diff, err := buildLocalFlowDiff(args[0], args[1])
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				return writeData(cmd, app, nil, diff)
+			}
 			if !isAPIMode(app) {
 				return writeNotImplemented(cmd, app, "diff requires --api/BREYTA_API_URL")
 			}
@@ -1116,6 +1141,7 @@ compare the current draft to the previous pushed draft version.
 	cmd.Flags().IntVar(&toVersion, "to-version", 0, "Version number when --to=version")
 	cmd.Flags().BoolVar(&full, "full", false, "Include the full unified diff")
 	cmd.Flags().StringVar(&file, "file", "", "Compare a local .clj file against one side of the diff (default: draft to file)")
+	cmd.Flags().BoolVar(&local, "local", false, "Compare two local flow files offline without calling the API")
 	return cmd
 }
 
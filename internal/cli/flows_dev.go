@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// flowDevWatcher polls a set of files for changes. Polling keeps the watch
+// loop dependency-free and behaves the same on every platform and editor
+// (including editors that save by renaming a temp file over the original).
+type flowDevWatcher struct {
+	poll     time.Duration
+	debounce time.Duration
+	files    func() []string
+}
+
+// snapshot records size and modification time for every watched file; a
+// missing file is recorded as such so deleting and recreating it counts as a
+// change.
+func (w flowDevWatcher) snapshot() map[string]string {
+	out := map[string]string{}
+	for _, path := range w.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			out[path] = "missing"
+			continue
+		}
+		out[path] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+	}
+	return out
+}
+
+func changedFlowDevFiles(before, after map[string]string) []string {
+	var changed []string
+	for path, stamp := range after {
+		if before[path] != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// wait blocks until a watched file changes and then stays unchanged for the
+// debounce interval, so one editor save (or a burst of saves) triggers one
+// iteration. It returns the settled snapshot and the files that changed.
+func (w flowDevWatcher) wait(ctx context.Context, last map[string]string) (map[string]string, []string, error) {
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+	var pending map[string]string
+	var settleAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return last, nil, ctx.Err()
+		case <-ticker.C:
+		}
+		current := w.snapshot()
+		if pending == nil {
+			if len(changedFlowDevFiles(last, current)) == 0 {
+				continue
+			}
+			pending, settleAt = current, time.Now().Add(w.debounce)
+			continue
+		}
+		if len(changedFlowDevFiles(pending, current)) > 0 {
+			pending, settleAt = current, time.Now().Add(w.debounce)
+			continue
+		}
+		if !time.Now().Before(settleAt) {
+			return current, changedFlowDevFiles(last, current), nil
+		}
+	}
+}
+
+// flowDevWatchFiles returns the flow file, every include it currently
+// resolves, and the params/input file, so edits to any of them rerun the loop.
+func flowDevWatchFiles(path string, extra ...string) []string {
+	files := []string{flowLintRootPath(path)}
+	if b, err := os.ReadFile(path); err == nil {
+		_, _, _ = expandFlowSourceIncludesObserved(path, string(b), func(event flowIncludeEvent) {
+			if event.Resolved != "" && !event.Cycle {
+				files = append(files, event.Resolved)
+			}
+		})
+	}
+	for _, file := range extra {
+		if strings.TrimSpace(file) != "" {
+			files = append(files, flowLintRootPath(file))
+		}
+	}
+	sort.Strings(files)
+	return slices.Compact(files)
+}
+
+type flowDevOptions struct {
+	slug           string
+	flowFile       string
+	stepID         string
+	run            bool
+	noPush         bool
+	paramsJSON     string
+	paramsFile     string
+	idempotencyKey string
+	profileID      string
+	timeout        time.Duration
+	poll           time.Duration
+	preview        stepResultPreviewOptions
+}
+
+// flowDevPushDraft pushes the literal that lint checked, so an include saved
+// between lint and push is never pushed unlinted.
+var flowDevPushDraft = pushExpandedFlowLiteral
+
+// runFlowDevIteration lints the flow, pushes the draft when lint is clean,
+// and reruns the step or flow. It writes exactly one envelope to stdout for
+// lint, push, and step results and returns false when any stage failed. A
+// file that fails local lint is never pushed or run.
+func runFlowDevIteration(cmd *cobra.Command, app *App, opts flowDevOptions, iteration int, changed []string) bool {
+	devMeta := map[string]any{"iteration": iteration}
+	if len(changed) > 0 {
+		devMeta["changed"] = changed
+	}
+	stderr := cmd.ErrOrStderr()
+	path, source, err := readLocalFlowSource(opts.slug, opts.flowFile)
+	if err != nil {
+		_ = writeErr(cmd, err)
+		return false
+	}
+
+	local, err := runFlowLintLocalStage(path, source, "")
+	if err != nil {
+		_ = writeErr(cmd, err)
+		return false
+	}
+	diagnostics := local.diagnostics
+	if local.scanned {
+		diagnostics = append(diagnostics, local.policy.apply(local.policy.unusedSuppressionDiagnostics(false))...)
+	}
+	newFlowLintLocator(path, source, local.expandedLiteral, local.sourceMap).annotate(diagnostics)
+	errorsCount, warnings := countFlowLintSeverities(diagnostics)
+	devMeta["lint"] = map[string]any{"errors": errorsCount, "warnings": warnings}
+	if errorsCount > 0 {
+		_, _ = fmt.Fprintf(stderr, "[%d] lint: %d error(s); not pushing\n", iteration, errorsCount)
+		devMeta["pushed"] = false
+		_ = writeOut(cmd, app, map[string]any{
+			"ok":          false,
+			"workspaceId": app.WorkspaceID,
+			"meta":        map[string]any{"dev": devMeta},
+			"data":        map[string]any{"valid": false, "diagnostics": diagnostics},
+		})
+		return false
+	}
+	_, _ = fmt.Fprintf(stderr, "[%d] lint: clean (%d warning(s))\n", iteration, warnings)
+	if warnings > 0 {
+		devMeta["diagnostics"] = diagnostics
+	}
+
+	devMeta["pushed"] = false
+	if !opts.noPush {
+		remote, status, err := flowDevPushDraft(cmd, app, source, local.expandedLiteral)
+		if err != nil {
+			_ = writeErr(cmd, fmt.Errorf("push draft: %w", err))
+			return false
+		}
+		if status >= 400 || !isOK(remote) {
+			_, _ = fmt.Fprintf(stderr, "[%d] push: rejected\n", iteration)
+			if remote == nil {
+				_ = writeErr(cmd, fmt.Errorf("flows push returned no usable API envelope (status=%d)", status))
+				return false
+			}
+			ensureMeta(remote)["dev"] = devMeta
+			_ = writeAPIResult(cmd, app, remote, status)
+			return false
+		}
+		devMeta["pushed"] = true
+		_, _ = fmt.Fprintf(stderr, "[%d] push: draft saved\n", iteration)
+	}
+
+	params, err := readParamsJSON(opts.paramsJSON, opts.paramsFile)
+	if err != nil {
+		_ = writeErr(cmd, err)
+		return false
+	}
+	if opts.run {
+		payload := map[string]any{"flowSlug": opts.slug, "target": "draft", "input": params}
+		_, _ = fmt.Fprintf(stderr, "[%d] run: starting draft run\n", iteration)
+		return doRunCommandWithOptionalWait(cmd, app, "flows.run", payload, true, opts.timeout, opts.poll) == nil
+	}
+	_, _ = fmt.Fprintf(stderr, "[%d] step %s: running\n", iteration, opts.stepID)
+	out, status, err := runLocalFlowStep(cmd, app, opts.slug, path, source, opts.stepID, params, opts.idempotencyKey, opts.profileID, opts.timeout)
+	if err != nil {
+		_ = writeErr(cmd, err)
+		return false
+	}
+	if out == nil {
+		_ = writeErr(cmd, fmt.Errorf("local step run returned no API response (status=%d)", status))
+		return false
+	}
+	if err := compactStepsRunResult(out, opts.stepID, opts.preview); err != nil {
+		_ = writeErr(cmd, err)
+		return false
+	}
+	ensureMeta(out)["dev"] = devMeta
+	_ = writeAPIResult(cmd, app, out, status)
+	return status < 400 && isOK(out)
+}
+
+func newFlowsDevCmd(app *App) *cobra.Command {
+	opts := flowDevOptions{}
+	var debounce time.Duration
+	var once bool
+	cmd := &cobra.Command{
+		Use:   "dev <flow-slug> (--step <step-id> | --run)",
+		Short: "Watch a local flow and rerun a step or the flow on save",
+		Long: strings.TrimSpace(`
+Run the authoring loop on every save: watch the local flow file, its
+#flow/include files, and the params file; wait until changes settle
+(--debounce); run local lint; push the draft only when lint has no errors; then
+rerun the chosen packaged step (--step) or start a draft run of the whole flow
+and wait for it (--run).
+
+A file that fails local lint is never pushed or run. Each iteration writes one
+JSON envelope to stdout (lint diagnostics, a rejected push, or the step result
+with the compact resultPreview) and short progress lines to stderr. Use --once
+for a single iteration, for example in scripts; its exit status reflects the
+iteration. Otherwise the loop runs until interrupted.
+`),
+		Example: strings.TrimSpace(`
+breyta flows dev order-sync --step tools/fetch-order --params-file ./params.json
+breyta flows dev order-sync --run --params '{"region":"EU"}'
+breyta flows dev order-sync --step tools/fetch-order --params-file ./params.json --no-push --once
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.slug = strings.TrimSpace(args[0])
+			opts.stepID = strings.TrimSpace(opts.stepID)
+			if !isAPIValidFlowSlug(opts.slug) {
+				return writeErr(cmd, fmt.Errorf("invalid flow slug %q", opts.slug))
+			}
+			if (opts.stepID == "") == !opts.run {
+				return writeErr(cmd, errors.New("pass exactly one of --step <step-id> or --run"))
+			}
+			if opts.stepID != "" && !localStepIDValid(opts.stepID) {
+				return writeErr(cmd, fmt.Errorf("invalid step id %q", opts.stepID))
+			}
+			if opts.run && opts.noPush {
+				return writeErr(cmd, errors.New("--run starts a draft run and needs the pushed draft; drop --no-push"))
+			}
+			if opts.timeout <= 0 || opts.poll <= 0 || debounce < 0 {
+				return writeErr(cmd, errors.New("--timeout and --poll must be > 0 and --debounce must not be negative"))
+			}
+			if _, err := readParamsJSON(opts.paramsJSON, opts.paramsFile); err != nil {
+				return writeErr(cmd, err)
+			}
+			if err := requireAPI(app); err != nil {
+				return writeErr(cmd, err)
+			}
+			path := resolveLocalFlowPath(opts.slug, opts.flowFile)
+			if _, err := readExplicitFile(path); err != nil {
+				return writeErr(cmd, err)
+			}
+
+			if once {
+				if !runFlowDevIteration(cmd, app, opts, 1, nil) {
+					return guidedCLIErrorForCommand(cmd, "flows dev iteration failed", nil)
+				}
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			watcher := flowDevWatcher{
+				poll:     opts.poll,
+				debounce: debounce,
+				files:    func() []string { return flowDevWatchFiles(path, opts.paramsFile) },
+			}
+			snapshot := watcher.snapshot()
+			runFlowDevIteration(cmd, app, opts, 1, nil)
+			for iteration := 2; ; iteration++ {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "watching %d file(s); press Ctrl-C to stop\n", len(snapshot))
+				next, changed, err := watcher.wait(ctx, snapshot)
+				if err != nil {
+					return nil
+				}
+				snapshot = next
+				runFlowDevIteration(cmd, app, opts, iteration, changed)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.flowFile, "flow-file", "", "Local flow source path (default: flows/<flow-slug>.clj)")
+	cmd.Flags().StringVar(&opts.stepID, "step", "", "Packaged step to rerun after each clean push")
+	cmd.Flags().BoolVar(&opts.run, "run", false, "Start and wait for a draft run of the whole flow instead of one step")
+	cmd.Flags().BoolVar(&opts.noPush, "no-push", false, "With --step, run the local source without pushing the draft")
+	cmd.Flags().StringVar(&opts.paramsJSON, "params", "", "Step input (or flow input with --run) JSON object")
+	cmd.Flags().StringVar(&opts.paramsFile, "params-file", "", "Read the input JSON from this file; edits to it rerun the loop")
+	cmd.Flags().StringVar(&opts.idempotencyKey, "idempotency-key", "", "Stable key for side-effectful step runs")
+	cmd.Flags().StringVar(&opts.profileID, "profile-id", "", "Optional installation/profile id for step runs")
+	cmd.Flags().DurationVar(&opts.poll, "poll", 300*time.Millisecond, "How often to check watched files (and run status with --run)")
+	cmd.Flags().DurationVar(&debounce, "debounce", 400*time.Millisecond, "How long changes must settle before an iteration starts")
+	cmd.Flags().BoolVar(&once, "once", false, "Run a single iteration and exit")
+	addLocalStepRunTimeoutFlag(cmd, &opts.timeout)
+	addLocalStepRunPreviewFlags(cmd, &opts.preview)
+	return cmd
+}
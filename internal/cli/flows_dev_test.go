@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const devFlow = `{:slug :order-sync
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :steps [{:id :tools/fetch :type :http :defaults {:method :get :url "https://example.com"}}]
+ :flow #flow/include "body.clj"}
+`
+
+func writeDevTestFlow(t *testing.T, flow string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "order-sync.clj")
+	if err := os.WriteFile(path, []byte(flow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "body.clj"), []byte("'(let [input (flow/input)] (flow/step :tools/fetch {}))\n"), 0o644); err != nil {
+		t.Fatalf("write body: %v", err)
+	}
+	return dir, path
+}
+
+func newDevTestServer(t *testing.T, commands *[]string) *App {
+	t.Helper()
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		command, _ := body["command"].(string)
+		*commands = append(*commands, command)
+		switch command {
+		case "flows.put_draft", "flows.validate":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"flowSlug": "order-sync"}})
+		case "steps.run":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"stepId": "tools/fetch", "result": map[string]any{"rows": []any{1, 2, 3}}}})
+		default:
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": map[string]any{"message": "unexpected command"}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+}
+
+func runDevOnce(t *testing.T, app *App, args ...string) (map[string]any, string, error) {
+	t.Helper()
+	cmd := newFlowsDevCmd(app)
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(append(args, "--once"))
+	err := cmd.Execute()
+	var body map[string]any
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+		t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+	}
+	return body, stderr.String(), err
+}
+
+func TestFlowsDevPushesAndRerunsStepWhenLintIsClean(t *testing.T) {
+	_, path := writeDevTestFlow(t, devFlow)
+	var commands []string
+	app := newDevTestServer(t, &commands)
+	body, progress, err := runDevOnce(t, app, "order-sync", "--flow-file", path, "--step", "tools/fetch", "--params", `{"id":1}`)
+	if err != nil {
+		t.Fatalf("dev iteration failed: %v\n%s", err, progress)
+	}
+	if strings.Join(commands, ",") != "flows.put_draft,flows.validate,steps.run" {
+		t.Fatalf("unexpected API sequence %v", commands)
+	}
+	data := body["data"].(map[string]any)
+	if _, ok := data["resultPreview"]; !ok {
+		t.Fatalf("expected compact result preview: %#v", data)
+	}
+	dev := body["meta"].(map[string]any)["dev"].(map[string]any)
+	if dev["pushed"] != true || dev["iteration"] != float64(1) {
+		t.Fatalf("unexpected dev meta %#v", dev)
+	}
+	if !strings.Contains(progress, "[1] push: draft saved") {
+		t.Fatalf("missing progress lines:\n%s", progress)
+	}
+}
+
+func TestFlowsDevPushesTheLintedLiteralWhenAnIncludeChangesAfterLint(t *testing.T) {
+	dir, path := writeDevTestFlow(t, devFlow)
+	var pushed string
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["command"] == "flows.put_draft" {
+			pushed, _ = body["args"].(map[string]any)["flowLiteral"].(string)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"flowSlug": "order-sync"}})
+	}))
+	t.Cleanup(srv.Close)
+	app := &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+
+	original := flowDevPushDraft
+	t.Cleanup(func() { flowDevPushDraft = original })
+	flowDevPushDraft = func(cmd *cobra.Command, app *App, source, expanded string) (map[string]any, int, error) {
+		if err := os.WriteFile(filepath.Join(dir, "body.clj"), []byte("'(let [input (flow/input)] (unlinted-edit input))\n"), 0o644); err != nil {
+			t.Fatalf("rewrite include: %v", err)
+		}
+		return original(cmd, app, source, expanded)
+	}
+
+	cmd := newFlowsDevCmd(app)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"order-sync", "--flow-file", path, "--run", "--once"})
+	_ = cmd.Execute()
+	if !strings.Contains(pushed, "(flow/step :tools/fetch {})") || strings.Contains(pushed, "unlinted-edit") {
+		t.Fatalf("expected the linted literal to be pushed, got %q", pushed)
+	}
+}
+
+func TestFlowsDevNeverPushesWhenLintFails(t *testing.T) {
+	_, path := writeDevTestFlow(t, strings.Replace(devFlow, " :concurrency {:type :singleton :on-new-version :coexist}\n", "", 1))
+	var commands []string
+	app := newDevTestServer(t, &commands)
+	body, _, err := runDevOnce(t, app, "order-sync", "--flow-file", path, "--step", "tools/fetch")
+	if err == nil {
+		t.Fatalf("expected lint failure")
+	}
+	if len(commands) != 0 {
+		t.Fatalf("a flow that fails lint must not reach the API, got %v", commands)
+	}
+	if body["ok"] != false || !strings.Contains(mustJSON(t, body), "missing_required_field") {
+		t.Fatalf("expected lint diagnostics, got %#v", body)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestFlowDevWatcherWaitsForIncludeChangesToSettle(t *testing.T) {
+	dir, path := writeDevTestFlow(t, devFlow)
+	params := filepath.Join(dir, "params.json")
+	if err := os.WriteFile(params, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("write params: %v", err)
+	}
+	files := flowDevWatchFiles(path, params)
+	if len(files) != 3 {
+		t.Fatalf("expected flow, include, and params files, got %v", files)
+	}
+	watcher := flowDevWatcher{poll: 5 * time.Millisecond, debounce: 20 * time.Millisecond, files: func() []string { return flowDevWatchFiles(path, params) }}
+	before := watcher.snapshot()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = os.WriteFile(filepath.Join(dir, "body.clj"), []byte("'(flow/input)\n"), 0o644)
+	}()
+	_, changed, err := watcher.wait(ctx, before)
+	if err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if len(changed) != 1 || filepath.Base(changed[0]) != "body.clj" {
+		t.Fatalf("expected the include change, got %v", changed)
+	}
+
+	quiet, cancelQuiet := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelQuiet()
+	if _, _, err := watcher.wait(quiet, watcher.snapshot()); err == nil {
+		t.Fatalf("expected no change without edits")
+	}
+}
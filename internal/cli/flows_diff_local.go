@@ -0,0 +1,282 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// localFlowDiffSections are the top-level keys compared entry-by-entry; every
+// other top-level key is compared as a whole field.
+var localFlowDiffSections = map[string]bool{
+	"steps":      true,
+	"schedules":  true,
+	"interfaces": true,
+	"flow":       true,
+}
+
+type localFlowDiffSide struct {
+	path    string
+	source  string
+	entries map[string]clojureMapEntry
+	keys    []string
+}
+
+func loadLocalFlowDiffSide(path string) (localFlowDiffSide, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return localFlowDiffSide{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	expanded, err := expandFlowSourceIncludes(path, string(b))
+	if err != nil {
+		return localFlowDiffSide{}, fmt.Errorf("expand includes in %s: %w", path, err)
+	}
+	entries, err := parseSingleTopLevelMapEntries(expanded)
+	if err != nil {
+		return localFlowDiffSide{}, fmt.Errorf("%s: %w", path, err)
+	}
+	side := localFlowDiffSide{path: path, source: expanded, entries: map[string]clojureMapEntry{}}
+	for _, entry := range entries {
+		name := entry.KeyName
+		if name == "" {
+			name = strings.TrimSpace(entry.KeyToken)
+		}
+		if _, seen := side.entries[name]; !seen {
+			side.keys = append(side.keys, name)
+		}
+		side.entries[name] = entry
+	}
+	return side, nil
+}
+
+func (side localFlowDiffSide) value(key string) (string, bool) {
+	entry, ok := side.entries[key]
+	if !ok {
+		return "", false
+	}
+	return side.source[entry.ValueStart:entry.ValueEnd], true
+}
+
+// namedForms returns the :steps or :schedules vector entries keyed by id, in
+// source order.
+func (side localFlowDiffSide) namedForms(key string) (map[string]string, []string, error) {
+	entry, ok := side.entries[key]
+	if !ok {
+		return map[string]string{}, nil, nil
+	}
+	var spans []clojureFormSpan
+	var err error
+	if key == "steps" {
+		spans, err = localFlowStepVector(side.source, entry)
+	} else {
+		spans, err = localFlowScheduleVector(side.source, entry)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s :%s: %w", side.path, key, err)
+	}
+	forms := map[string]string{}
+	var order []string
+	for _, span := range spans {
+		var id string
+		if key == "steps" {
+			id, err = localStepIDFromMap(side.source, span)
+		} else {
+			id, err = localScheduleIDFromMap(side.source, span)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s :%s: %w", side.path, key, err)
+		}
+		if _, dup := forms[id]; !dup {
+			order = append(order, id)
+		}
+		forms[id] = side.source[span.Start:span.End]
+	}
+	return forms, order, nil
+}
+
+// interfaceForms returns interface maps keyed by "<category>/<id>".
+func (side localFlowDiffSide) interfaceForms() (map[string]string, []string) {
+	forms := map[string]string{}
+	var order []string
+	entry, ok := side.entries["interfaces"]
+	if !ok || !clojureFormStartsWith(side.source, entry.ValueStart, '{') {
+		return forms, order
+	}
+	categories, _, err := parseClojureMapEntries(side.source, entry.ValueStart)
+	if err != nil {
+		return forms, order
+	}
+	for _, category := range categories {
+		if !clojureFormStartsWith(side.source, category.ValueStart, '[') {
+			continue
+		}
+		items, _, err := parseClojureVectorElements(side.source, category.ValueStart)
+		if err != nil {
+			continue
+		}
+		for idx, item := range items {
+			id := fmt.Sprintf("[%d]", idx)
+			if clojureFormStartsWith(side.source, item.Start, '{') {
+				if fields, _, err := parseClojureMapEntries(side.source, item.Start); err == nil {
+					if idEntry, found := mapEntryByKey(fields, "id"); found {
+						if name, ok := clojureIdentifierFromForm(side.source, idEntry.ValueStart); ok {
+							id = name
+						}
+					}
+				}
+			}
+			key := category.KeyName + "/" + id
+			if _, dup := forms[key]; !dup {
+				order = append(order, key)
+			}
+			forms[key] = side.source[item.Start:item.End]
+		}
+	}
+	return forms, order
+}
+
+func diffLocalFlowNamedForms(section string, from, to localFlowDiffSide, a map[string]string, aOrder []string, b map[string]string, bOrder []string) map[string]any {
+	added := []string{}
+	removed := []string{}
+	modified := []map[string]any{}
+	for _, id := range aOrder {
+		if _, ok := b[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range bOrder {
+		before, ok := a[id]
+		if !ok {
+			added = append(added, id)
+			continue
+		}
+		after := b[id]
+		if clojureFormsEquivalent(before, after) {
+			continue
+		}
+		label := ":" + section + " " + id
+		modified = append(modified, map[string]any{
+			"id":   id,
+			"diff": localFlowFormDiff(from.path, to.path, label, before, after),
+		})
+	}
+	return map[string]any{"added": added, "removed": removed, "modified": modified}
+}
+
+func localFlowFormDiff(fromPath, toPath, label, before, after string) string {
+	diff := unifiedSourceDiff(fromPath+" "+label, toPath+" "+label, normalizedClojureSourceLines(before), normalizedClojureSourceLines(after))
+	if diff == "" {
+		// Only in-line spacing moved tokens between lines; show the canonical
+		// single-line forms instead of an empty hunk.
+		diff = unifiedSourceDiff(fromPath+" "+label, toPath+" "+label, []string{canonicalClojureFormText(before)}, []string{canonicalClojureFormText(after)})
+	}
+	return diff
+}
+
+func diffLocalFlowFields(from, to localFlowDiffSide) map[string]any {
+	added := []string{}
+	removed := []string{}
+	modified := []map[string]any{}
+	for _, key := range from.keys {
+		if localFlowDiffSections[key] {
+			continue
+		}
+		if _, ok := to.entries[key]; !ok {
+			removed = append(removed, ":"+key)
+		}
+	}
+	for _, key := range to.keys {
+		if localFlowDiffSections[key] {
+			continue
+		}
+		after, _ := to.value(key)
+		before, ok := from.value(key)
+		if !ok {
+			added = append(added, ":"+key)
+			continue
+		}
+		if clojureFormsEquivalent(before, after) {
+			continue
+		}
+		modified = append(modified, map[string]any{
+			"key":  ":" + key,
+			"diff": localFlowFormDiff(from.path, to.path, ":"+key, before, after),
+		})
+	}
+	return map[string]any{"added": added, "removed": removed, "modified": modified}
+}
+
+func localFlowDiffCounts(section map[string]any) (int, int, int) {
+	added, _ := section["added"].([]string)
+	removed, _ := section["removed"].([]string)
+	modified, _ := section["modified"].([]map[string]any)
+	return len(added), len(removed), len(modified)
+}
+
+// buildLocalFlowDiff compares two local flow files structurally after include
+// expansion. Formatting-only edits are not reported.
+func buildLocalFlowDiff(fromPath, toPath string) (map[string]any, error) {
+	from, err := loadLocalFlowDiffSide(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadLocalFlowDiffSide(toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fromSteps, fromStepOrder, err := from.namedForms("steps")
+	if err != nil {
+		return nil, err
+	}
+	toSteps, toStepOrder, err := to.namedForms("steps")
+	if err != nil {
+		return nil, err
+	}
+	fromSchedules, fromScheduleOrder, err := from.namedForms("schedules")
+	if err != nil {
+		return nil, err
+	}
+	toSchedules, toScheduleOrder, err := to.namedForms("schedules")
+	if err != nil {
+		return nil, err
+	}
+	fromInterfaces, fromInterfaceOrder := from.interfaceForms()
+	toInterfaces, toInterfaceOrder := to.interfaceForms()
+
+	steps := diffLocalFlowNamedForms("steps", from, to, fromSteps, fromStepOrder, toSteps, toStepOrder)
+	schedules := diffLocalFlowNamedForms("schedules", from, to, fromSchedules, fromScheduleOrder, toSchedules, toScheduleOrder)
+	interfaces := diffLocalFlowNamedForms("interfaces", from, to, fromInterfaces, fromInterfaceOrder, toInterfaces, toInterfaceOrder)
+	fields := diffLocalFlowFields(from, to)
+
+	fromBody, _ := from.value("flow")
+	toBody, _ := to.value("flow")
+	body := map[string]any{"changed": false}
+	if !clojureFormsEquivalent(fromBody, toBody) {
+		body["changed"] = true
+		body["diff"] = localFlowFormDiff(from.path, to.path, ":flow", fromBody, toBody)
+	}
+
+	summary := map[string]any{}
+	changed := body["changed"] == true
+	for name, section := range map[string]map[string]any{"steps": steps, "schedules": schedules, "interfaces": interfaces, "fields": fields} {
+		added, removed, modified := localFlowDiffCounts(section)
+		summary[name] = map[string]int{"added": added, "removed": removed, "modified": modified}
+		if added+removed+modified > 0 {
+			changed = true
+		}
+	}
+	summary["flowBodyChanged"] = body["changed"]
+
+	return map[string]any{
+		"mode":       "local",
+		"from":       from.path,
+		"to":         to.path,
+		"changed":    changed,
+		"summary":    summary,
+		"steps":      steps,
+		"schedules":  schedules,
+		"interfaces": interfaces,
+		"fields":     fields,
+		"flow":       body,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const localDiffBaseFlow = `{:slug :order-sync
+ :name "Order sync"
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :steps [{:id :tools/fetch :type :http :url "https://example.com/orders" :method :get}
+         {:id :tools/old :type :function :code "(fn [x] x)"}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules [{:id :nightly :cron "0 2 * * *" :invocation :default}]
+ :flow '(let [orders (flow/step :tools/fetch :fetch {})] orders)}
+`
+
+func writeLocalDiffFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFlowsDiffLocalReportsStepScheduleInterfaceAndBodyChanges(t *testing.T) {
+	dir := t.TempDir()
+	from := writeLocalDiffFile(t, dir, "a.clj", localDiffBaseFlow)
+	changed := strings.NewReplacer(
+		`:url "https://example.com/orders"`, `:url "https://example.com/v2/orders"`,
+		`{:id :tools/old :type :function :code "(fn [x] x)"}`, `{:id :tools/new :type :function :code "(fn [x] (inc x))"}`,
+		`:label "Run"`, `:label "Run now"`,
+		`:cron "0 2 * * *"`, `:cron "0 3 * * *"`,
+		`orders)}`, `{:orders orders})}`,
+		`:name "Order sync"`, `:name "Order sync v2"`,
+	).Replace(localDiffBaseFlow)
+	to := writeLocalDiffFile(t, dir, "b.clj", changed)
+
+	body := executeLocalAuthoringJSON(t, newFlowsDiffCmd(&App{WorkspaceID: "ws-test"}), "--local", from, to)
+	data, _ := body["data"].(map[string]any)
+	if data["changed"] != true {
+		t.Fatalf("expected changed diff, got %#v", data)
+	}
+	steps, _ := data["steps"].(map[string]any)
+	if got := steps["added"].([]any); len(got) != 1 || got[0] != "tools/new" {
+		t.Fatalf("unexpected added steps %#v", got)
+	}
+	if got := steps["removed"].([]any); len(got) != 1 || got[0] != "tools/old" {
+		t.Fatalf("unexpected removed steps %#v", got)
+	}
+	modified := steps["modified"].([]any)
+	if len(modified) != 1 {
+		t.Fatalf("expected one modified step, got %#v", modified)
+	}
+	item := modified[0].(map[string]any)
+	if item["id"] != "tools/fetch" || !strings.Contains(item["diff"].(string), `+{:id :tools/fetch :type :http :url "https://example.com/v2/orders" :method :get}`) {
+		t.Fatalf("unexpected modified step %#v", item)
+	}
+	schedules, _ := data["schedules"].(map[string]any)
+	if got := schedules["modified"].([]any); len(got) != 1 || got[0].(map[string]any)["id"] != "nightly" {
+		t.Fatalf("unexpected schedule changes %#v", schedules)
+	}
+	interfaces, _ := data["interfaces"].(map[string]any)
+	if got := interfaces["modified"].([]any); len(got) != 1 || got[0].(map[string]any)["id"] != "manual/run" {
+		t.Fatalf("unexpected interface changes %#v", interfaces)
+	}
+	flowBody, _ := data["flow"].(map[string]any)
+	if flowBody["changed"] != true || !strings.Contains(flowBody["diff"].(string), "{:orders orders}") {
+		t.Fatalf("unexpected flow body diff %#v", flowBody)
+	}
+	fields, _ := data["fields"].(map[string]any)
+	if got := fields["modified"].([]any); len(got) != 1 || got[0].(map[string]any)["key"] != ":name" {
+		t.Fatalf("unexpected field changes %#v", fields)
+	}
+}
+
+func TestFlowsDiffLocalIgnoresFormattingAndExpandsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	from := writeLocalDiffFile(t, dir, "a.clj", localDiffBaseFlow)
+	writeLocalDiffFile(t, dir, "fetch.edn", `{:id :tools/fetch
+  :type :http
+  ;; same step, reformatted
+  :url "https://example.com/orders",
+  :method :get}`)
+	reformatted := strings.Replace(localDiffBaseFlow,
+		`{:id :tools/fetch :type :http :url "https://example.com/orders" :method :get}`,
+		`#flow/include "fetch.edn"`, 1)
+	reformatted = strings.Replace(reformatted, `:flow '(let [orders (flow/step :tools/fetch :fetch {})] orders)}`,
+		":flow '(let [orders (flow/step :tools/fetch :fetch {})]\n         ;; comment only\n         orders)}", 1)
+	to := writeLocalDiffFile(t, dir, "b.clj", reformatted)
+
+	body := executeLocalAuthoringJSON(t, newFlowsDiffCmd(&App{WorkspaceID: "ws-test"}), "--local", from, to)
+	data, _ := body["data"].(map[string]any)
+	if data["changed"] != false {
+		t.Fatalf("expected formatting-only changes to be ignored, got %#v", data)
+	}
+}
+
+func TestFlowsDiffLocalRejectsRemoteFlags(t *testing.T) {
+	cmd := newFlowsDiffCmd(&App{WorkspaceID: "ws-test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--local", "--from", "draft", "a.clj", "b.clj"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--from cannot be combined with --local") {
+		t.Fatalf("expected --from rejection, got %v\n%s", err, out.String())
+	}
+}
+
+func TestUnifiedSourceDiffProducesHunks(t *testing.T) {
+	diff := unifiedSourceDiff("a", "b", []string{"one", "two", "three"}, []string{"one", "2", "three"})
+	want := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+	if diff != want {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if got := unifiedSourceDiff("a", "b", []string{"x"}, []string{"x"}); got != "" {
+		t.Fatalf("expected empty diff for identical input, got %q", got)
+	}
+}
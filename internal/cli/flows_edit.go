@@ -0,0 +1,596 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"olympos.io/encoding/edn"
+)
+
+// flowEditPathElement is one step of a flows edit path. In a map it names a
+// key; in a vector an Index selects by position and a Name selects the
+// element map whose :id (or :slot) matches, so [:steps tools/fetch :timeout]
+// addresses a packaged step by id.
+type flowEditPathElement struct {
+	Token string
+	Name  string
+	Index int
+	// IsIndex marks integer elements.
+	IsIndex bool
+	// IsString marks string map keys such as "Authorization".
+	IsString bool
+}
+
+// parseFlowEditPath reads an EDN vector path such as
+// [:steps tools/fetch-order :timeout]. The brackets may be left off.
+func parseFlowEditPath(raw string) ([]flowEditPathElement, error) {
+	text := strings.TrimSpace(raw)
+	if !strings.HasPrefix(text, "[") {
+		text = "[" + text + "]"
+	}
+	var items []any
+	if err := edn.UnmarshalString(text, &items); err != nil {
+		return nil, fmt.Errorf("invalid path %s: %w", raw, err)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("path must name at least one key")
+	}
+	out := make([]flowEditPathElement, 0, len(items))
+	for _, item := range items {
+		switch typed := item.(type) {
+		case edn.Keyword:
+			out = append(out, flowEditPathElement{Token: ":" + string(typed), Name: string(typed)})
+		case edn.Symbol:
+			out = append(out, flowEditPathElement{Token: string(typed), Name: string(typed)})
+		case string:
+			out = append(out, flowEditPathElement{Token: strconv.Quote(typed), Name: typed, IsString: true})
+		case int64:
+			out = append(out, flowEditPathElement{Token: strconv.FormatInt(typed, 10), Index: int(typed), IsIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid path element %v: use keywords, step ids, strings, or indexes", item)
+		}
+	}
+	return out, nil
+}
+
+func formatFlowEditPath(path []flowEditPathElement) string {
+	tokens := make([]string, 0, len(path))
+	for _, element := range path {
+		tokens = append(tokens, element.Token)
+	}
+	return "[" + strings.Join(tokens, " ") + "]"
+}
+
+// keyToken renders the element as a map key for a newly inserted entry.
+func (e flowEditPathElement) keyToken() string {
+	switch {
+	case e.IsString, e.IsIndex:
+		return e.Token
+	default:
+		return ":" + e.Name
+	}
+}
+
+// flowEditLocation is where a path resolves in the flow source. When Found,
+// Start/End delimit the value and RemoveStart/RemoveEnd the whole map entry
+// or vector element holding it. Otherwise Depth is the number of leading path
+// elements that exist and Container is the offset of the map or vector the
+// next element is missing from.
+type flowEditLocation struct {
+	Found       bool
+	Start       int
+	End         int
+	RemoveStart int
+	RemoveEnd   int
+	Depth       int
+	Container   int
+}
+
+// locateFlowEditPath walks path through the literal maps and vectors of the
+// flow source. It refuses to descend into #flow/include forms, lists, and
+// other code, which have no stable data path.
+func locateFlowEditPath(src string, path []flowEditPathElement) (flowEditLocation, error) {
+	start, err := topLevelFlowMapStart(src)
+	if err != nil {
+		return flowEditLocation{}, err
+	}
+	if start < 0 {
+		return flowEditLocation{}, errors.New("source must contain a top-level map")
+	}
+	for depth, element := range path {
+		node, ok := clojureActiveFormStart(src, start)
+		if !ok || node >= len(src) {
+			return flowEditLocation{}, fmt.Errorf("cannot read the value at %s", formatFlowEditPath(path[:depth]))
+		}
+		if isFlowIncludeFormStart(src, node) {
+			return flowEditLocation{}, fmt.Errorf("%s is a #flow/include; edit the included file directly", formatFlowEditPath(path[:depth]))
+		}
+		var child flowEditLocation
+		var found bool
+		switch src[node] {
+		case '{':
+			child, found, err = flowEditMapChild(src, node, element)
+		case '[':
+			child, found, err = flowEditVectorChild(src, node, element)
+		default:
+			return flowEditLocation{}, fmt.Errorf("%s is not a map or vector, so %s cannot be addressed inside it", formatFlowEditPath(path[:depth]), element.Token)
+		}
+		if err != nil {
+			return flowEditLocation{}, err
+		}
+		if !found {
+			return flowEditLocation{Depth: depth, Container: node}, nil
+		}
+		if depth == len(path)-1 {
+			child.Found = true
+			child.Depth = len(path)
+			return child, nil
+		}
+		start = child.Start
+	}
+	return flowEditLocation{}, errors.New("path is empty")
+}
+
+func flowEditMapChild(src string, node int, element flowEditPathElement) (flowEditLocation, bool, error) {
+	entries, _, err := parseClojureMapEntries(src, node)
+	if err != nil {
+		return flowEditLocation{}, false, err
+	}
+	for _, entry := range entries {
+		if !flowEditKeyMatches(entry.KeyToken, element) {
+			continue
+		}
+		end, err := readClojureFormEnd(src, entry.ValueStart)
+		if err != nil {
+			return flowEditLocation{}, false, err
+		}
+		return flowEditLocation{Start: entry.ValueStart, End: end, RemoveStart: entry.KeyStart, RemoveEnd: entry.ValueEnd}, true, nil
+	}
+	return flowEditLocation{}, false, nil
+}
+
+func flowEditKeyMatches(keyToken string, element flowEditPathElement) bool {
+	token := strings.TrimSpace(keyToken)
+	switch {
+	case element.IsString:
+		if !strings.HasPrefix(token, "\"") {
+			return false
+		}
+		_, value, _, err := readClojureStringToken(token, 0)
+		return err == nil && value == element.Name
+	case element.IsIndex:
+		return token == element.Token
+	default:
+		return token == ":"+element.Name
+	}
+}
+
+func flowEditVectorChild(src string, node int, element flowEditPathElement) (flowEditLocation, bool, error) {
+	elements, _, err := parseClojureVectorElements(src, node)
+	if err != nil {
+		return flowEditLocation{}, false, err
+	}
+	locationOf := func(span clojureFormSpan) flowEditLocation {
+		return flowEditLocation{Start: span.Start, End: span.End, RemoveStart: span.FormStart, RemoveEnd: span.FormEnd}
+	}
+	if element.IsIndex {
+		if element.Index < 0 || element.Index >= len(elements) {
+			return flowEditLocation{}, false, nil
+		}
+		return locationOf(elements[element.Index]), true, nil
+	}
+	for _, span := range elements {
+		if span.Start >= len(src) || src[span.Start] != '{' {
+			continue
+		}
+		entries, _, err := parseClojureMapEntries(src, span.Start)
+		if err != nil {
+			return flowEditLocation{}, false, err
+		}
+		for _, key := range []string{"id", "slot"} {
+			if entry, ok := mapEntryByKey(entries, key); ok && strings.TrimPrefix(readFunctionLabel(src, entry.ValueStart, ""), ":") == strings.TrimPrefix(element.Name, ":") {
+				return locationOf(span), true, nil
+			}
+		}
+	}
+	return flowEditLocation{}, false, nil
+}
+
+// setFlowEditPath replaces the value at path, or inserts it when only the
+// trailing map keys are missing (creating nested maps as needed) or when the
+// last element is the next vector index.
+func setFlowEditPath(src string, path []flowEditPathElement, value string) (string, error) {
+	loc, err := locateFlowEditPath(src, path)
+	if err != nil {
+		return "", err
+	}
+	if loc.Found {
+		return src[:loc.Start] + value + src[loc.End:], nil
+	}
+	missing := path[loc.Depth:]
+	if src[loc.Container] == '[' {
+		elements, end, err := parseClojureVectorElements(src, loc.Container)
+		if err != nil {
+			return "", err
+		}
+		if !missing[0].IsIndex {
+			return "", flowEditMissingIDError(src, path, loc)
+		}
+		if len(missing) != 1 || missing[0].Index != len(elements) {
+			return "", fmt.Errorf("%s does not exist; vectors can only grow by setting index %d", formatFlowEditPath(path[:loc.Depth+1]), len(elements))
+		}
+		if len(elements) == 0 {
+			return src[:end-1] + value + src[end-1:], nil
+		}
+		last := elements[len(elements)-1]
+		return src[:last.FormEnd] + flowEditSeparator(src, loc.Container, last.FormStart) + value + src[last.FormEnd:], nil
+	}
+	for _, element := range missing {
+		if element.IsIndex && element != missing[0] {
+			return "", fmt.Errorf("%s does not exist; create the vector before addressing index %d", formatFlowEditPath(path[:loc.Depth+1]), element.Index)
+		}
+	}
+	entryValue := value
+	for i := len(missing) - 1; i > 0; i-- {
+		entryValue = "{" + missing[i].keyToken() + " " + entryValue + "}"
+	}
+	entry := missing[0].keyToken() + " " + entryValue
+	entries, end, err := parseClojureMapEntries(src, loc.Container)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return src[:end-1] + entry + src[end-1:], nil
+	}
+	last := entries[len(entries)-1]
+	return src[:last.ValueEnd] + flowEditSeparator(src, loc.Container, last.KeyStart) + entry + src[last.ValueEnd:], nil
+}
+
+// flowEditMissingIDError reports a path element naming a vector entry by
+// :id or :slot that no entry has, listing the closest ids in the vector.
+func flowEditMissingIDError(src string, path []flowEditPathElement, loc flowEditLocation) error {
+	element := path[loc.Depth]
+	kind := "entry"
+	if loc.Depth > 0 {
+		switch path[loc.Depth-1].Name {
+		case "steps":
+			kind = "step"
+		case "requires":
+			kind = "slot"
+		case "templates":
+			kind = "template"
+		case "functions":
+			kind = "function"
+		}
+	}
+	name := strings.TrimPrefix(element.Name, ":")
+	msg := fmt.Sprintf("%s :%s not found in %s", kind, name, strings.Trim(formatFlowEditPath(path[:loc.Depth]), "[]"))
+	ids := flowEditVectorIDs(src, loc.Container)
+	if len(ids) == 0 {
+		return errors.New(msg + " (it has no entries with an id)")
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		di, dj := levenshteinDistance(name, ids[i]), levenshteinDistance(name, ids[j])
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > 3 {
+		ids = ids[:3]
+	}
+	for i, id := range ids {
+		ids[i] = ":" + id
+	}
+	return fmt.Errorf("%s; nearby ids: %s", msg, strings.Join(ids, ", "))
+}
+
+// flowEditVectorIDs returns the :id (or :slot) of each map in the vector at
+// node.
+func flowEditVectorIDs(src string, node int) []string {
+	elements, _, err := parseClojureVectorElements(src, node)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, span := range elements {
+		if span.Start >= len(src) || src[span.Start] != '{' {
+			continue
+		}
+		entries, _, err := parseClojureMapEntries(src, span.Start)
+		if err != nil {
+			continue
+		}
+		for _, key := range []string{"id", "slot"} {
+			if entry, ok := mapEntryByKey(entries, key); ok {
+				if id := strings.TrimPrefix(readFunctionLabel(src, entry.ValueStart, ""), ":"); id != "" {
+					ids = append(ids, id)
+					break
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// flowEditSeparator returns the text to put before a new last item of a
+// collection: a newline indented like the last item when the collection
+// already spans lines, otherwise a space.
+func flowEditSeparator(src string, container, last int) string {
+	lineStart := strings.LastIndexByte(src[:last], '\n') + 1
+	if lineStart <= container || strings.TrimSpace(src[lineStart:last]) != "" {
+		return " "
+	}
+	return "\n" + src[lineStart:last]
+}
+
+// deleteFlowEditPath removes the map entry or vector element at path. An
+// item alone on its lines is removed with those lines; otherwise the
+// whitespace joining it to a neighbour goes with it.
+func deleteFlowEditPath(src string, path []flowEditPathElement) (string, error) {
+	loc, err := locateFlowEditPath(src, path)
+	if err != nil {
+		return "", err
+	}
+	if !loc.Found {
+		if src[loc.Container] == '[' && !path[loc.Depth].IsIndex {
+			return "", flowEditMissingIDError(src, path, loc)
+		}
+		return "", fmt.Errorf("%s does not exist", formatFlowEditPath(path))
+	}
+	if loc.Depth == 1 {
+		switch path[0].Name {
+		case "slug", "flow":
+			return "", fmt.Errorf("%s is required and cannot be deleted", path[0].Token)
+		}
+	}
+	start, end := loc.RemoveStart, loc.RemoveEnd
+	lineStart := strings.LastIndexByte(src[:start], '\n') + 1
+	lineEnd := strings.IndexByte(src[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src) - end
+	}
+	lineEnd += end
+	before := strings.Trim(src[lineStart:start], " \t,")
+	after := strings.Trim(src[end:lineEnd], " \t,\r")
+	if before == "" && after == "" && lineEnd < len(src) {
+		return src[:lineStart] + src[lineEnd+1:], nil
+	}
+	next := end
+	for next < len(src) && isClojureWhitespaceOrComma(src[next]) {
+		next++
+	}
+	if next < len(src) && strings.IndexByte("}])", src[next]) < 0 {
+		return src[:start] + src[next:], nil
+	}
+	prev := start
+	for prev > 0 && isClojureWhitespaceOrComma(src[prev-1]) {
+		prev--
+	}
+	return src[:prev] + src[end:], nil
+}
+
+func newFlowsEditCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Read or change one value in the local flow source by path",
+		Long: strings.TrimSpace(`
+Read or change one value in the local flow source by an EDN path, leaving
+comments and formatting outside the edited value untouched.
+
+A path is a vector of map keys (keywords or strings) and vector selectors.
+Inside a vector, an index selects by position and a step id or keyword selects
+the element map with that :id (or :slot), so [:steps tools/fetch-order
+:timeout] addresses a packaged step's timeout and [:requires :github :base-url]
+a connection slot.
+
+set and delete re-lint the result locally and refuse to write a change that
+introduces lint errors unless --force is given. --dry-run prints the diff
+instead of writing.
+`),
+		Example: strings.TrimSpace(`
+breyta flows edit get order-sync '[:steps tools/fetch-order :timeout]'
+breyta flows edit set order-sync '[:steps tools/fetch-order :timeout]' 60000
+breyta flows edit set order-sync '[:steps tools/fetch-order :headers "X-Trace"]' trace --string --dry-run
+breyta flows edit delete order-sync '[:steps tools/fetch-order :retry]'
+`),
+	}
+	cmd.AddCommand(newFlowsEditGetCmd(app))
+	cmd.AddCommand(newFlowsEditSetCmd(app))
+	cmd.AddCommand(newFlowsEditDeleteCmd(app))
+	return cmd
+}
+
+func newFlowsEditGetCmd(app *App) *cobra.Command {
+	var flowFile string
+	cmd := &cobra.Command{
+		Use:   "get <flow-slug> <path>",
+		Short: "Print the source of the value at a path",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := parseFlowEditPath(args[1])
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			flowPath, source, err := readLocalFlowSource(strings.TrimSpace(args[0]), flowFile)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			loc, err := locateFlowEditPath(source, path)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if !loc.Found {
+				if source[loc.Container] == '[' && !path[loc.Depth].IsIndex {
+					return writeErr(cmd, flowEditMissingIDError(source, path, loc))
+				}
+				return writeErr(cmd, fmt.Errorf("%s does not exist in %s", formatFlowEditPath(path), flowPath))
+			}
+			line, column := sourceLineColumn(source, loc.Start)
+			data := map[string]any{
+				"flowSlug": strings.TrimSpace(args[0]),
+				"path":     formatFlowEditPath(path),
+				"source":   source[loc.Start:loc.End],
+				"file":     flowPath,
+				"line":     line,
+				"column":   column,
+			}
+			var raw any
+			if err := edn.UnmarshalString(source[loc.Start:loc.End], &raw); err == nil {
+				data["value"] = plainEDNValue(raw)
+			}
+			return writeData(cmd, app, nil, data)
+		},
+	}
+	cmd.Flags().StringVar(&flowFile, "flow-file", "", "Local flow source path (default: flows/<flow-slug>.clj)")
+	return cmd
+}
+
+func newFlowsEditSetCmd(app *App) *cobra.Command {
+	var opts flowEditWriteOptions
+	var asString bool
+	cmd := &cobra.Command{
+		Use:   "set <flow-slug> <path> <edn-value>",
+		Short: "Set the value at a path, creating missing map keys",
+		Long: strings.TrimSpace(`
+Set the value at a path to an EDN form. Missing trailing map keys are created,
+with nested maps as needed, and setting the index one past the end of a
+vector appends to it. Use --string to pass the value as a plain string instead
+of EDN.
+`),
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value := strings.TrimSpace(args[2])
+			if asString {
+				value = strconv.Quote(args[2])
+			}
+			if err := validateSingleClojureForm(value); err != nil {
+				return writeErr(cmd, fmt.Errorf("value must be one EDN form (use --string for plain text): %w", err))
+			}
+			return runFlowEdit(cmd, app, "set", args[0], args[1], opts, func(source string, path []flowEditPathElement) (string, error) {
+				return setFlowEditPath(source, path, value)
+			})
+		},
+	}
+	opts.addFlags(cmd)
+	cmd.Flags().BoolVar(&asString, "string", false, "Treat the value as a plain string rather than EDN")
+	return cmd
+}
+
+func newFlowsEditDeleteCmd(app *App) *cobra.Command {
+	var opts flowEditWriteOptions
+	cmd := &cobra.Command{
+		Use:     "delete <flow-slug> <path>",
+		Aliases: []string{"rm"},
+		Short:   "Remove the map entry or vector element at a path",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFlowEdit(cmd, app, "delete", args[0], args[1], opts, deleteFlowEditPath)
+		},
+	}
+	opts.addFlags(cmd)
+	return cmd
+}
+
+type flowEditWriteOptions struct {
+	flowFile   string
+	configPath string
+	dryRun     bool
+	force      bool
+}
+
+func (o *flowEditWriteOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.flowFile, "flow-file", "", "Local flow source path (default: flows/<flow-slug>.clj)")
+	cmd.Flags().StringVar(&o.configPath, "config", "", "Project lint config (default: nearest "+projectConfigFileName+" above the flow file)")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Print the diff without writing the file")
+	cmd.Flags().BoolVar(&o.force, "force", false, "Write the change even if it introduces lint errors")
+}
+
+func runFlowEdit(cmd *cobra.Command, app *App, op, slug, rawPath string, opts flowEditWriteOptions, edit func(string, []flowEditPathElement) (string, error)) error {
+	slug = strings.TrimSpace(slug)
+	path, err := parseFlowEditPath(rawPath)
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	flowPath, source, err := readLocalFlowSource(slug, opts.flowFile)
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	updated, err := edit(source, path)
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	if _, err := parseSingleTopLevelMapEntries(updated); err != nil {
+		return writeErr(cmd, fmt.Errorf("the edit would leave the flow unreadable: %w", err))
+	}
+
+	before, err := runFlowLintLocalStage(flowPath, source, opts.configPath)
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	after, err := runFlowLintLocalStage(flowPath, updated, opts.configPath)
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	newFlowLintLocator(flowPath, updated, after.expandedLiteral, after.sourceMap).annotate(after.diagnostics)
+	diagnostics := after.diagnostics
+	if diagnostics == nil {
+		diagnostics = []flowLintDiagnostic{}
+	}
+	introduced := introducedFlowLintErrors(before.diagnostics, diagnostics)
+
+	result := map[string]any{
+		"flowSlug": slug,
+		"op":       op,
+		"path":     formatFlowEditPath(path),
+		"diff":     unifiedSourceFileDiff(flowPath, flowPath, source, updated),
+		"lint":     map[string]any{"ok": !lintHasErrors(diagnostics), "diagnostics": diagnostics},
+	}
+	if opts.dryRun {
+		result["dryRun"] = true
+		result["saved"] = false
+		return writeData(cmd, app, nil, result)
+	}
+	if len(introduced) > 0 && !opts.force {
+		result["saved"] = false
+		result["introducedErrors"] = introduced
+		out := map[string]any{"ok": false, "workspaceId": app.WorkspaceID, "data": result}
+		if err := writeOut(cmd, app, out); err != nil {
+			return err
+		}
+		return guidedCLIErrorForCommand(cmd, fmt.Sprintf("the edit introduces %d lint error(s); the local flow file was not modified", len(introduced)), []string{"Fix the value, or rerun with --force to write it anyway."})
+	}
+	if err := atomicWriteFile(flowPath, []byte(updated), publicFileMode); err != nil {
+		return writeErr(cmd, fmt.Errorf("write local flow: %w", err))
+	}
+	return writeLocalAuthoringResult(cmd, app, flowPath, nil, 0, result)
+}
+
+// introducedFlowLintErrors returns the error diagnostics in after whose code
+// and message do not appear in before, so pre-existing errors do not block
+// an unrelated edit.
+func introducedFlowLintErrors(before, after []flowLintDiagnostic) []flowLintDiagnostic {
+	existing := map[string]int{}
+	for _, diag := range before {
+		if severity, _ := diag["severity"].(string); strings.EqualFold(severity, "error") {
+			existing[fmt.Sprint(diag["code"], "\x00", diag["message"])]++
+		}
+	}
+	var out []flowLintDiagnostic
+	for _, diag := range after {
+		if severity, _ := diag["severity"].(string); !strings.EqualFold(severity, "error") {
+			continue
+		}
+		key := fmt.Sprint(diag["code"], "\x00", diag["message"])
+		if existing[key] > 0 {
+			existing[key]--
+			continue
+		}
+		out = append(out, diag)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const flowsEditFlow = `{:slug :order-sync
+ ;; fetches one order
+ :steps [{:id :tools/fetch-order
+          :type :http
+          :title "Fetch order" ; shown in the UI
+          :defaults {:url "https://api.example.com/orders"
+                     :headers {"Accept" "application/json"}
+                     :retry {:max-attempts 3}}}]
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :flow '(flow/step :tools/fetch-order :fetch {})}
+`
+
+func TestSetFlowEditPathPreservesFormatting(t *testing.T) {
+	path, err := parseFlowEditPath(`[:steps tools/fetch-order :timeout]`)
+	if err != nil {
+		t.Fatalf("parse path: %v", err)
+	}
+	updated, err := setFlowEditPath(flowsEditFlow, path, "60000")
+	if err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	want := strings.Replace(flowsEditFlow, `                     :retry {:max-attempts 3}}}]`, `                     :retry {:max-attempts 3}}
+          :timeout 60000}]`, 1)
+	if updated != want {
+		t.Fatalf("unexpected source:\n%s", updated)
+	}
+
+	path, _ = parseFlowEditPath(`:steps :tools/fetch-order :defaults :headers "X-Trace"`)
+	updated, err = setFlowEditPath(updated, path, `"abc"`)
+	if err != nil {
+		t.Fatalf("set header: %v", err)
+	}
+	if !strings.Contains(updated, `:headers {"Accept" "application/json" "X-Trace" "abc"}`) {
+		t.Fatalf("expected inline insert:\n%s", updated)
+	}
+
+	path, _ = parseFlowEditPath(`[:steps 0 :defaults :auth :type]`)
+	updated, err = setFlowEditPath(updated, path, ":bearer")
+	if err != nil {
+		t.Fatalf("set nested: %v", err)
+	}
+	if !strings.Contains(updated, "                     :retry {:max-attempts 3}\n                     :auth {:type :bearer}}") {
+		t.Fatalf("expected nested map insert:\n%s", updated)
+	}
+	if !strings.Contains(updated, `:title "Fetch order" ; shown in the UI`) {
+		t.Fatalf("comments must be preserved:\n%s", updated)
+	}
+}
+
+func TestDeleteFlowEditPathRemovesWholeLines(t *testing.T) {
+	path, _ := parseFlowEditPath(`[:steps tools/fetch-order :title]`)
+	updated, err := deleteFlowEditPath(flowsEditFlow, path)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if updated != strings.Replace(flowsEditFlow, "          :title \"Fetch order\" ; shown in the UI\n", "          ; shown in the UI\n", 1) {
+		t.Fatalf("unexpected source:\n%s", updated)
+	}
+
+	path, _ = parseFlowEditPath(`[:steps tools/fetch-order :defaults :retry]`)
+	updated, err = deleteFlowEditPath(flowsEditFlow, path)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !strings.Contains(updated, `:headers {"Accept" "application/json"}}}]`) {
+		t.Fatalf("expected trailing entry removed with its line break:\n%s", updated)
+	}
+
+	path, _ = parseFlowEditPath(`[:concurrency :type]`)
+	updated, err = deleteFlowEditPath(flowsEditFlow, path)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !strings.Contains(updated, ` :concurrency {:on-new-version :coexist}`) {
+		t.Fatalf("expected inline entry removed:\n%s", updated)
+	}
+}
+
+func TestLocateFlowEditPathRefusesCode(t *testing.T) {
+	path, _ := parseFlowEditPath(`[:flow :fetch]`)
+	if _, err := locateFlowEditPath(flowsEditFlow, path); err == nil || !strings.Contains(err.Error(), "[:flow] is not a map or vector") {
+		t.Fatalf("expected refusal, got %v", err)
+	}
+	path, _ = parseFlowEditPath(`[:steps tools/missing :timeout]`)
+	if _, err := setFlowEditPath(flowsEditFlow, path, "1"); err == nil || !strings.Contains(err.Error(), "step :tools/missing not found in :steps; nearby ids: :tools/fetch-order") {
+		t.Fatalf("expected missing step error, got %v", err)
+	}
+	path, _ = parseFlowEditPath(`[:steps tools/fetch-ordr]`)
+	if _, err := deleteFlowEditPath(flowsEditFlow, path); err == nil || !strings.Contains(err.Error(), "step :tools/fetch-ordr not found in :steps; nearby ids: :tools/fetch-order") {
+		t.Fatalf("expected missing step error on delete, got %v", err)
+	}
+}
+
+func runFlowsEdit(t *testing.T, flowPath string, args ...string) (map[string]any, error) {
+	t.Helper()
+	cmd := newFlowsEditCmd(&App{WorkspaceID: "ws-test"})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(append(args, "--flow-file", flowPath))
+	err := cmd.Execute()
+	var body map[string]any
+	if stdout.Len() > 0 {
+		if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+			t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+		}
+	}
+	return body, err
+}
+
+func TestFlowsEditCommands(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	flowPath := filepath.Join(t.TempDir(), "order-sync.clj")
+	if err := os.WriteFile(flowPath, []byte(flowsEditFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+
+	body, err := runFlowsEdit(t, flowPath, "get", "order-sync", "[:steps tools/fetch-order :defaults :retry]")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data := body["data"].(map[string]any)
+	if data["source"] != "{:max-attempts 3}" || mustJSON(t, data["value"]) != `{"max-attempts":3}` || data["line"] != float64(8) {
+		t.Fatalf("unexpected get result %s", mustJSON(t, data))
+	}
+
+	body, err = runFlowsEdit(t, flowPath, "set", "order-sync", "[:steps tools/fetch-order :title]", "Fetch one order", "--string", "--dry-run")
+	if err != nil {
+		t.Fatalf("dry-run: %v", err)
+	}
+	data = body["data"].(map[string]any)
+	if data["saved"] != false || !strings.Contains(data["diff"].(string), `+          :title "Fetch one order" ; shown in the UI`) {
+		t.Fatalf("unexpected dry-run %s", mustJSON(t, data))
+	}
+	if b, _ := os.ReadFile(flowPath); string(b) != flowsEditFlow {
+		t.Fatalf("--dry-run must not write")
+	}
+
+	body, err = runFlowsEdit(t, flowPath, "set", "order-sync", "[:steps tools/fetch-order :timeout]", "60000")
+	if err != nil {
+		t.Fatalf("set: %v\n%s", err, mustJSON(t, body))
+	}
+	if b, _ := os.ReadFile(flowPath); !strings.Contains(string(b), ":timeout 60000}]") {
+		t.Fatalf("expected write:\n%s", b)
+	}
+
+	if _, err = runFlowsEdit(t, flowPath, "delete", "order-sync", "[:slug]"); err == nil || !strings.Contains(err.Error(), "cannot be deleted") {
+		t.Fatalf("expected :slug delete refusal, got %v", err)
+	}
+
+	body, err = runFlowsEdit(t, flowPath, "set", "order-sync", "[:steps tools/fetch-order :id]", ":tools/fetch-orders")
+	if err == nil || body["ok"] != false || len(body["data"].(map[string]any)["introducedErrors"].([]any)) == 0 {
+		t.Fatalf("expected the dangling step reference to block the write, got %v %s", err, mustJSON(t, body))
+	}
+	if b, _ := os.ReadFile(flowPath); strings.Contains(string(b), ":tools/fetch-orders") {
+		t.Fatalf("blocked edit must not write:\n%s", b)
+	}
+}
@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/breyta/breyta-cli/internal/clojure/interp"
+	"github.com/spf13/cobra"
+)
+
+// localFlowFunction is one :functions entry found in a local flow source.
+type localFlowFunction struct {
+	// Label is the id as written, e.g. ":normalize"; it is what lint uses in
+	// diagnostic paths.
+	Label string
+	Code  string
+	// CodeOffset is the byte offset of the code in the flow source: the
+	// opening quote of a string, or the first byte of a quoted form.
+	CodeOffset int
+	// Quoted is true when Code is a form copied verbatim from the source,
+	// so positions inside it map straight back onto the file.
+	Quoted bool
+}
+
+func (f localFlowFunction) ID() string {
+	return strings.TrimPrefix(f.Label, ":")
+}
+
+func (f localFlowFunction) path() []string {
+	return []string{":functions", f.Label, ":code"}
+}
+
+// findLocalFlowFunctions reads :functions in an include-expanded flow
+// literal as either a vector of {:id ... :code ...} maps or a map of id to
+// code (or to such a map). String code is read the way flows lint reads it;
+// quoted forms and entries lint does not check come from the source walk.
+func findLocalFlowFunctions(src string) ([]localFlowFunction, error) {
+	codes, err := extractTopLevelFunctionCodeStrings(src)
+	if err != nil {
+		return nil, err
+	}
+	forms, err := findLocalFlowFunctionForms(src)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]localFlowFunction, 0, len(forms))
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code.Path) < 2 {
+			continue
+		}
+		out = append(out, localFlowFunction{Label: code.Path[1], Code: code.Code, CodeOffset: code.ByteOffset})
+		seen[code.Path[1]] = true
+	}
+	for _, fn := range forms {
+		if !seen[fn.Label] {
+			out = append(out, fn)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CodeOffset < out[j].CodeOffset })
+	return out, nil
+}
+
+func findLocalFlowFunctionForms(src string) ([]localFlowFunction, error) {
+	entries, err := extractTopLevelMapEntries(src)
+	if err != nil {
+		return nil, err
+	}
+	functionsEntry, found := mapEntryByKey(entries, "functions")
+	if !found {
+		return nil, nil
+	}
+	start, ok := clojureActiveFormStart(src, functionsEntry.ValueStart)
+	if !ok || start >= len(src) {
+		return nil, nil
+	}
+	var out []localFlowFunction
+	switch src[start] {
+	case '[':
+		elements, _, err := parseClojureVectorElements(src, start)
+		if err != nil {
+			return nil, err
+		}
+		for index, element := range elements {
+			if element.Start >= len(src) || src[element.Start] != '{' {
+				continue
+			}
+			fn, found, err := localFlowFunctionFromEntry(src, element.Start, fmt.Sprintf("[%d]", index))
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				out = append(out, fn)
+			}
+		}
+	case '{':
+		fnEntries, _, err := parseClojureMapEntries(src, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range fnEntries {
+			label := functionLabelFromToken(entry.KeyToken, "")
+			valueStart, ok := clojureActiveFormStart(src, entry.ValueStart)
+			if !ok || valueStart >= len(src) {
+				continue
+			}
+			if src[valueStart] == '{' {
+				fn, found, err := localFlowFunctionFromEntry(src, valueStart, label)
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					fn.Label = label
+					out = append(out, fn)
+				}
+				continue
+			}
+			fn, err := localFlowFunctionCode(src, valueStart)
+			if err != nil {
+				return nil, err
+			}
+			fn.Label = label
+			out = append(out, fn)
+		}
+	}
+	return out, nil
+}
+
+func localFlowFunctionFromEntry(src string, start int, fallbackLabel string) (localFlowFunction, bool, error) {
+	entries, _, err := parseClojureMapEntries(src, start)
+	if err != nil {
+		return localFlowFunction{}, false, err
+	}
+	label := fallbackLabel
+	for _, key := range []string{"name", "id"} {
+		if entry, ok := mapEntryByKey(entries, key); ok {
+			label = readFunctionLabel(src, entry.ValueStart, label)
+		}
+	}
+	codeEntry, ok := mapEntryByKey(entries, "code")
+	if !ok {
+		return localFlowFunction{}, false, nil
+	}
+	codeStart, ok := clojureActiveFormStart(src, codeEntry.ValueStart)
+	if !ok || codeStart >= len(src) {
+		return localFlowFunction{}, false, nil
+	}
+	fn, err := localFlowFunctionCode(src, codeStart)
+	fn.Label = label
+	return fn, err == nil, err
+}
+
+// localFlowFunctionCode reads function code at start: a string, a quoted
+// form ('(fn ...) or (quote (fn ...))) or a bare form.
+func localFlowFunctionCode(src string, start int) (localFlowFunction, error) {
+	if src[start] == '"' {
+		_, value, _, err := readClojureStringToken(src, start)
+		return localFlowFunction{Code: value, CodeOffset: start}, err
+	}
+	formStart := start
+	if src[start] == '\'' {
+		formStart = skipClojureWhitespaceCommaAndComments(src, start+1)
+	} else if strings.HasPrefix(src[start:], "(quote") {
+		after := start + len("(quote")
+		if after < len(src) && (src[after] == ' ' || src[after] == '\n' || src[after] == '\t' || src[after] == '\r' || src[after] == ',') {
+			formStart = skipClojureWhitespaceCommaAndComments(src, after)
+		}
+	}
+	end, err := readClojureFormEnd(src, formStart)
+	if err != nil {
+		return localFlowFunction{}, err
+	}
+	if end <= formStart {
+		return localFlowFunction{}, fmt.Errorf("could not read function :code near byte %d", start)
+	}
+	return localFlowFunction{Code: src[formStart:end], CodeOffset: formStart, Quoted: true}, nil
+}
+
+func newFlowsFunctionsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "functions",
+		Short: "Work with a flow's :functions locally",
+	}
+	cmd.AddCommand(newFlowsFunctionsEvalCmd(app))
+	return cmd
+}
+
+func newFlowsFunctionsEvalCmd(app *App) *cobra.Command {
+	var flowFile string
+	var inputFile string
+	var inputJSON string
+	var maxSteps int
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "eval <flow-slug> <function-id>",
+		Short: "Evaluate a :functions entry locally against a JSON input",
+		Long: strings.TrimSpace(`
+Evaluate one of the flow's :functions entries offline, without pushing or
+running the flow. The function's :code is read from the local flow source, with
+#flow/include files expanded, and applied to the input (JSON object keys become keywords, as in a run).
+
+The local evaluator covers the data-transformation subset of Clojure used in
+function code: let, fn, loop/recur, destructuring, the threading and
+conditional macros, for, try/ex-info, and the core seq, map, string
+(clojure.string) and set functions. Host interop, def and side effects are not
+available. Evaluation stops after --max-steps evaluation steps or --timeout.
+
+Failures are reported as diagnostics with the same shape as flows lint, so a
+code string that does not read fails with function_code_string_invalid and a
+runtime error fails with function_eval_failed, located in the flow file.
+`),
+		Example: strings.TrimSpace(`
+breyta flows functions eval order-sync normalize --input-file fixtures/order.json
+breyta flows functions eval order-sync :normalize --input '{"items":[]}'
+`),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slug := strings.TrimSpace(args[0])
+			if !isAPIValidFlowSlug(slug) {
+				return writeErr(cmd, fmt.Errorf("invalid flow slug %q", slug))
+			}
+			fnID := strings.TrimPrefix(strings.TrimSpace(args[1]), ":")
+			if fnID == "" {
+				return writeErr(cmd, errors.New("function id is required"))
+			}
+			if maxSteps <= 0 || timeout <= 0 {
+				return writeErr(cmd, errors.New("--max-steps and --timeout must be > 0"))
+			}
+			if strings.TrimSpace(inputFile) != "" && strings.TrimSpace(inputJSON) != "" {
+				return writeErr(cmd, errors.New("use either --input-file or --input, not both"))
+			}
+			input, err := readFlowFunctionInput(inputFile, inputJSON)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			flowPath, source, err := readLocalFlowSource(slug, flowFile)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			expanded, sourceMap, err := expandFlowSourceIncludesWithMap(flowPath, source)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			locator := newFlowLintLocator(flowPath, source, expanded, sourceMap)
+			functions, err := findLocalFlowFunctions(expanded)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("read :functions in %s: %w", flowPath, err))
+			}
+			var fn *localFlowFunction
+			ids := make([]string, 0, len(functions))
+			for i := range functions {
+				ids = append(ids, functions[i].ID())
+				if functions[i].ID() == fnID {
+					fn = &functions[i]
+				}
+			}
+			if fn == nil {
+				sort.Strings(ids)
+				available := "none"
+				if len(ids) > 0 {
+					available = strings.Join(ids, ", ")
+				}
+				return writeErr(cmd, fmt.Errorf("function %q not found in %s (available: %s)", fnID, flowPath, available))
+			}
+
+			meta := map[string]any{"flowFile": flowPath, "maxSteps": maxSteps, "timeoutMs": timeout.Milliseconds()}
+			if err := validateFunctionCodeString(fn.Code); err != nil {
+				diag := lintDiagnostic(
+					"error",
+					"function_code_string_invalid",
+					fn.path(),
+					fmt.Sprintf("Function :code string is not readable: %v", err),
+					"Fix the string code or use a directly quoted form, for example :code '(fn [input] ...).",
+					"local",
+				)
+				locateFlowFunctionDiagnostic(diag, locator, *fn, nil)
+				return writeFlowFunctionEvalFailure(cmd, app, meta, slug, fnID, diag)
+			}
+
+			result, stats, evalErr := interp.EvalFunction(fn.Code, []any{input}, interp.Options{MaxSteps: maxSteps, Timeout: timeout})
+			meta["steps"] = stats.Steps
+			meta["durationMs"] = stats.Elapsed.Milliseconds()
+			var out any
+			if evalErr == nil {
+				out, evalErr = interp.ToJSON(result)
+				if evalErr != nil {
+					evalErr = fmt.Errorf("result is not JSON-compatible: %w", evalErr)
+				}
+			}
+			if evalErr != nil {
+				diag := lintDiagnostic(
+					"error",
+					"function_eval_failed",
+					fn.path(),
+					fmt.Sprintf("Function %s failed: %s", fn.Label, flowFunctionErrorMessage(evalErr)),
+					"Local evaluation supports pure Clojure data transformations; host interop and side effects only run on the server.",
+					"local",
+				)
+				locateFlowFunctionDiagnostic(diag, locator, *fn, flowFunctionErrorPosition(evalErr))
+				return writeFlowFunctionEvalFailure(cmd, app, meta, slug, fnID, diag)
+			}
+			return writeData(cmd, app, meta, map[string]any{
+				"flowSlug":   slug,
+				"functionId": fnID,
+				"result":     out,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&flowFile, "flow-file", "", "Local flow file (default flows/<slug>.clj)")
+	cmd.Flags().StringVar(&inputFile, "input-file", "", "JSON file passed as the function input")
+	cmd.Flags().StringVar(&inputJSON, "input", "", "Inline JSON passed as the function input")
+	cmd.Flags().IntVar(&maxSteps, "max-steps", interp.DefaultMaxSteps, "Maximum evaluation steps before giving up")
+	cmd.Flags().DurationVar(&timeout, "timeout", interp.DefaultTimeout, "Maximum evaluation time")
+	return cmd
+}
+
+func readFlowFunctionInput(inputFile, inputJSON string) (any, error) {
+	var b []byte
+	switch {
+	case strings.TrimSpace(inputFile) != "":
+		raw, err := readExplicitFile(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --input-file: %w", err)
+		}
+		b = raw
+	case strings.TrimSpace(inputJSON) != "":
+		b = []byte(inputJSON)
+	default:
+		return nil, nil
+	}
+	if !json.Valid(b) {
+		return nil, errors.New("function input must be valid JSON")
+	}
+	return interp.DecodeJSON(b)
+}
+
+func flowFunctionErrorMessage(err error) string {
+	var evalErr *interp.Error
+	if errors.As(err, &evalErr) {
+		return evalErr.Message
+	}
+	var readErr *interp.ReadError
+	if errors.As(err, &readErr) {
+		return readErr.Message
+	}
+	return err.Error()
+}
+
+func flowFunctionErrorPosition(err error) *interp.Position {
+	var evalErr *interp.Error
+	if errors.As(err, &evalErr) {
+		return evalErr.Position
+	}
+	var readErr *interp.ReadError
+	if errors.As(err, &readErr) {
+		pos := readErr.Position
+		return &pos
+	}
+	return nil
+}
+
+// locateFlowFunctionDiagnostic points diag at the failing form in the flow
+// file or the include that defines it. Quoted code maps exactly; string code
+// points at the string and keeps the position inside the code as
+// codeLine/codeColumn.
+func locateFlowFunctionDiagnostic(diag flowLintDiagnostic, locator *flowLintLocator, fn localFlowFunction, pos *interp.Position) {
+	offset := fn.CodeOffset
+	if pos != nil {
+		diag["codeLine"] = pos.Line
+		diag["codeColumn"] = pos.Column
+		if fn.Quoted {
+			offset += codeByteOffset(fn.Code, *pos)
+		}
+	}
+	diag["byteOffset"] = offset
+	locator.annotate([]flowLintDiagnostic{diag})
+}
+
+// codeByteOffset converts a 1-based line and character column into a byte
+// offset within code.
+func codeByteOffset(code string, pos interp.Position) int {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		next := strings.IndexByte(code[offset:], '\n')
+		if next < 0 {
+			return len(code)
+		}
+		offset += next + 1
+	}
+	for col := 1; col < pos.Column && offset < len(code); col++ {
+		_, size := utf8.DecodeRuneInString(code[offset:])
+		offset += size
+	}
+	return offset
+}
+
+func writeFlowFunctionEvalFailure(cmd *cobra.Command, app *App, meta map[string]any, slug, fnID string, diag flowLintDiagnostic) error {
+	out := map[string]any{
+		"ok":          false,
+		"workspaceId": app.WorkspaceID,
+		"meta":        meta,
+		"data": map[string]any{
+			"flowSlug":    slug,
+			"functionId":  fnID,
+			"diagnostics": []flowLintDiagnostic{diag},
+		},
+	}
+	if err := writeOut(cmd, app, out); err != nil {
+		return err
+	}
+	return guidedCLIErrorForCommand(cmd, fmt.Sprint(diag["message"]), nil)
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const functionsEvalFlow = `{:slug :order-sync
+ :functions [{:id :totals
+              :language :clojure
+              :code '(fn [{:keys [items]}]
+                       {:count (count items)
+                        :total (->> items
+                                    (map (fn [{:keys [qty price]}] (* qty price)))
+                                    (reduce + 0))})}
+             {:id :broken
+              :language :clojure
+              :code '(fn [input]
+                       (let [n (:n input)]
+                         (inc n)))}
+             {:id :unreadable :language :clojure :code "(fn [input] (inc input)"}]
+ :flow '(flow/step :function :totals {:ref :totals :input (flow/input)})}
+`
+
+func runFlowsFunctionsEval(t *testing.T, args ...string) (map[string]any, string, error) {
+	t.Helper()
+	flowPath := filepath.Join(t.TempDir(), "order-sync.clj")
+	if err := os.WriteFile(flowPath, []byte(functionsEvalFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	cmd := newFlowsFunctionsCmd(&App{WorkspaceID: "ws-test"})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(append([]string{"eval", "order-sync"}, append(args, "--flow-file", flowPath)...))
+	err := cmd.Execute()
+	var body map[string]any
+	if stdout.Len() > 0 {
+		if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+			t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+		}
+	}
+	return body, stderr.String(), err
+}
+
+func TestFlowsFunctionsEvalRunsQuotedFunction(t *testing.T) {
+	body, _, err := runFlowsFunctionsEval(t, "totals", "--input", `{"items":[{"qty":2,"price":1.5},{"qty":1,"price":4}]}`)
+	if err != nil {
+		t.Fatalf("eval failed: %v\n%s", err, mustJSON(t, body))
+	}
+	data := body["data"].(map[string]any)
+	if got := mustJSON(t, data["result"]); got != `{"count":2,"total":7}` {
+		t.Fatalf("unexpected result %s", got)
+	}
+	if steps, _ := body["meta"].(map[string]any)["steps"].(float64); steps <= 0 {
+		t.Fatalf("expected step count in meta: %s", mustJSON(t, body["meta"]))
+	}
+}
+
+func TestFlowsFunctionsEvalLocatesRuntimeErrors(t *testing.T) {
+	body, _, err := runFlowsFunctionsEval(t, ":broken", "--input", `{}`)
+	if err == nil {
+		t.Fatalf("expected eval failure: %s", mustJSON(t, body))
+	}
+	diag := body["data"].(map[string]any)["diagnostics"].([]any)[0].(map[string]any)
+	if diag["code"] != "function_eval_failed" || mustJSON(t, diag["path"]) != `[":functions",":broken",":code"]` {
+		t.Fatalf("unexpected diagnostic %s", mustJSON(t, diag))
+	}
+	if !strings.Contains(diag["message"].(string), "inc: nil is not a number") {
+		t.Fatalf("unexpected message %q", diag["message"])
+	}
+	if diag["line"] != float64(13) || diag["column"] != float64(26) {
+		t.Fatalf("expected the (inc n) form at 13:26, got %v:%v", diag["line"], diag["column"])
+	}
+}
+
+func TestFlowsFunctionsEvalReportsUnreadableCodeLikeLint(t *testing.T) {
+	body, _, err := runFlowsFunctionsEval(t, "unreadable")
+	if err == nil {
+		t.Fatalf("expected read failure: %s", mustJSON(t, body))
+	}
+	diag := body["data"].(map[string]any)["diagnostics"].([]any)[0].(map[string]any)
+	if diag["code"] != "function_code_string_invalid" || !strings.HasPrefix(diag["message"].(string), "Function :code string is not readable:") {
+		t.Fatalf("unexpected diagnostic %s", mustJSON(t, diag))
+	}
+}
+
+func TestFlowsFunctionsEvalListsAvailableFunctions(t *testing.T) {
+	_, stderr, err := runFlowsFunctionsEval(t, "missing")
+	if err == nil || !strings.Contains(stderr, "available: broken, totals, unreadable") {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+}
+
+func TestFlowsFunctionsEvalReadsIncludedFunctions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"order-sync.clj":       "{:slug :order-sync\n :functions [#flow/include \"functions/double.edn\"]\n :flow '(flow/input)}\n",
+		"functions/double.edn": "{:id :double\n :language :clojure\n :code '(fn [input]\n         (* 2 (:n input)))}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	run := func(input string) (map[string]any, error) {
+		cmd := newFlowsFunctionsCmd(&App{WorkspaceID: "ws-test"})
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SilenceUsage = true
+		cmd.SetArgs([]string{"eval", "order-sync", "double", "--input", input, "--flow-file", filepath.Join(dir, "order-sync.clj")})
+		err := cmd.Execute()
+		var body map[string]any
+		if decodeErr := json.NewDecoder(&stdout).Decode(&body); decodeErr != nil {
+			t.Fatalf("decode: %v\n%s", decodeErr, stderr.String())
+		}
+		return body, err
+	}
+
+	body, err := run(`{"n":21}`)
+	if err != nil || mustJSON(t, body["data"].(map[string]any)["result"]) != "42" {
+		t.Fatalf("expected the included function to run: %v %s", err, mustJSON(t, body))
+	}
+	body, err = run(`{}`)
+	if err == nil {
+		t.Fatalf("expected eval failure: %s", mustJSON(t, body))
+	}
+	diag := body["data"].(map[string]any)["diagnostics"].([]any)[0].(map[string]any)
+	if !strings.HasSuffix(diag["file"].(string), filepath.Join("functions", "double.edn")) || diag["line"] != float64(4) || diag["column"] != float64(10) {
+		t.Fatalf("expected the (* 2 ...) form in the include at 4:10, got %s", mustJSON(t, diag))
+	}
+}
+
+func TestFindLocalFlowFunctionsReadsMapForm(t *testing.T) {
+	src := `{:slug :x :functions {:a '(fn [x] x) :b {:code "(fn [y] y)"}} :flow '(identity)}`
+	fns, err := findLocalFlowFunctions(src)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(fns) != 2 || fns[0].ID() != "a" || fns[0].Code != "(fn [x] x)" || !fns[0].Quoted ||
+		fns[1].ID() != "b" || fns[1].Code != "(fn [y] y)" || fns[1].Quoted {
+		t.Fatalf("unexpected functions %+v", fns)
+	}
+}
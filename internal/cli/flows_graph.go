@@ -0,0 +1,841 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var flowGraphFormats = []string{"json", "dot", "mermaid"}
+
+const flowGraphMaxLabelRunes = 40
+
+// flowGraphNode is one vertex of a flow graph: an entry point (interface or
+// schedule), a step, or a child flow called from a step.
+type flowGraphNode struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Label      string `json:"label"`
+	Type       string `json:"type,omitempty"`
+	Referenced *bool  `json:"referenced,omitempty"`
+	Failed     bool   `json:"failed,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// flowGraphEdge connects two nodes. Kind is entry, sequence, branch, data, or
+// child-flow; Label carries the branch guard for branch edges.
+type flowGraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+type flowGraph struct {
+	Slug  string
+	nodes []*flowGraphNode
+	byID  map[string]*flowGraphNode
+	edges []flowGraphEdge
+	seen  map[string]bool
+}
+
+func newFlowGraph(slug string) *flowGraph {
+	return &flowGraph{Slug: slug, byID: map[string]*flowGraphNode{}, seen: map[string]bool{}}
+}
+
+func (g *flowGraph) node(id, kind, label, stepType string) *flowGraphNode {
+	if existing, ok := g.byID[id]; ok {
+		return existing
+	}
+	n := &flowGraphNode{ID: id, Kind: kind, Label: label, Type: stepType}
+	g.nodes = append(g.nodes, n)
+	g.byID[id] = n
+	return n
+}
+
+func (g *flowGraph) edge(from, to, kind, label string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	key := from + "\x00" + to + "\x00" + kind + "\x00" + label
+	if g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+	g.edges = append(g.edges, flowGraphEdge{From: from, To: to, Kind: kind, Label: label})
+}
+
+func (g *flowGraph) connected(from, to string) bool {
+	for _, e := range g.edges {
+		if e.From == from && e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// flowGraphPred is a pending incoming edge: the node control last passed
+// through, and the kind/label the next edge out of it should carry.
+type flowGraphPred struct {
+	id    string
+	kind  string
+	label string
+}
+
+func flowGraphRelabel(preds []flowGraphPred, kind, label string) []flowGraphPred {
+	out := make([]flowGraphPred, 0, len(preds))
+	for _, p := range preds {
+		if p.kind == "entry" {
+			// Keep entry edges recognisable; the guard is still shown.
+			out = append(out, flowGraphPred{id: p.id, kind: p.kind, label: label})
+			continue
+		}
+		out = append(out, flowGraphPred{id: p.id, kind: kind, label: label})
+	}
+	return out
+}
+
+func flowGraphUnion(sets ...[]flowGraphPred) []flowGraphPred {
+	seen := map[flowGraphPred]bool{}
+	var out []flowGraphPred
+	for _, set := range sets {
+		for _, p := range set {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// flowGraphWalker follows control flow through the quoted :flow body. Only
+// forms that localFlowStepReferences recognises as executable flow/step calls
+// become step nodes, so quoted data and syntax-quoted templates never do.
+type flowGraphWalker struct {
+	src        string
+	base       int
+	refs       map[int]localFlowStepReference
+	graph      *flowGraph
+	stepForms  map[string]string
+	visited    []string
+	unresolved int
+}
+
+type flowGraphEnv map[string][]string
+
+func (env flowGraphEnv) with(symbols []string, ids []string) flowGraphEnv {
+	next := flowGraphEnv{}
+	for k, v := range env {
+		next[k] = v
+	}
+	for _, sym := range symbols {
+		if len(ids) == 0 {
+			delete(next, sym)
+			continue
+		}
+		next[sym] = ids
+	}
+	return next
+}
+
+func (w *flowGraphWalker) walkSpans(spans []clojureFormSpan, preds []flowGraphPred, env flowGraphEnv) []flowGraphPred {
+	for _, span := range spans {
+		preds = w.walk(span.Start, preds, env)
+	}
+	return preds
+}
+
+func (w *flowGraphWalker) walk(start int, preds []flowGraphPred, env flowGraphEnv) []flowGraphPred {
+	i := skipClojureWhitespaceCommaAndComments(w.src, start)
+	if i >= len(w.src) {
+		return preds
+	}
+	switch {
+	case strings.HasPrefix(w.src[i:], "#_"), w.src[i] == '\'', w.src[i] == '"':
+		return preds
+	case strings.HasPrefix(w.src[i:], "#?"):
+		formStart, _, _, ok := activeReaderConditionalForm(w.src, i)
+		if !ok || formStart < 0 {
+			return preds
+		}
+		return w.walk(formStart, preds, env)
+	case w.src[i] == '^':
+		metaEnd, err := readClojureFormEnd(w.src, i+1)
+		if err != nil {
+			return preds
+		}
+		return w.walk(metaEnd, preds, env)
+	case w.src[i] == '`' || w.src[i] == '@' || w.src[i] == '~':
+		next := i + 1
+		if next < len(w.src) && w.src[i] == '~' && w.src[next] == '@' {
+			next++
+		}
+		return w.walk(next, preds, env)
+	case strings.HasPrefix(w.src[i:], "#("):
+		return w.walkList(i+1, preds, env)
+	case strings.HasPrefix(w.src[i:], "#{"):
+		elements, _, err := parseClojureSetElements(w.src, i)
+		if err != nil {
+			return preds
+		}
+		return w.walkSpans(elements, preds, env)
+	}
+	switch w.src[i] {
+	case '(':
+		return w.walkList(i, preds, env)
+	case '[':
+		elements, _, err := parseClojureVectorElements(w.src, i)
+		if err != nil {
+			return preds
+		}
+		return w.walkSpans(elements, preds, env)
+	case '{':
+		entries, _, err := parseClojureMapEntries(w.src, i)
+		if err != nil {
+			return preds
+		}
+		for _, entry := range entries {
+			preds = w.walk(entry.KeyStart, preds, env)
+			preds = w.walk(entry.ValueStart, preds, env)
+		}
+		return preds
+	}
+	return preds
+}
+
+func (w *flowGraphWalker) walkList(listStart int, preds []flowGraphPred, env flowGraphEnv) []flowGraphPred {
+	elements, _, err := parseClojureListElements(w.src, listStart)
+	if err != nil || len(elements) == 0 {
+		return preds
+	}
+	head := clojureFormToken(w.src, elements[0])
+	args := elements[1:]
+	switch head {
+	case "quote", "clojure.core/quote", "comment", "clojure.core/comment":
+		return preds
+	case "flow/step":
+		return w.walkStep(elements, preds, env)
+	case "let", "let*", "loop", "binding", "clojure.core/let":
+		if len(args) == 0 {
+			return preds
+		}
+		preds, env = w.walkBindings(args[0], preds, env)
+		return w.walkSpans(args[1:], preds, env)
+	case "if", "if-not", "if-let", "if-some":
+		if len(args) < 2 {
+			return w.walkSpans(args, preds, env)
+		}
+		label, preds, branchEnv := w.walkTest(head, args[0], preds, env)
+		before := len(w.visited)
+		then := w.walk(args[1].Start, flowGraphRelabel(preds, "branch", label), branchEnv)
+		otherwise := flowGraphRelabel(preds, "branch", "else")
+		if len(args) > 2 {
+			otherwise = w.walkSpans(args[2:], otherwise, env)
+		}
+		if len(w.visited) == before {
+			return preds
+		}
+		return flowGraphUnion(then, otherwise)
+	case "when", "when-not", "when-let", "when-some", "when-first":
+		if len(args) == 0 {
+			return preds
+		}
+		label, preds, branchEnv := w.walkTest(head, args[0], preds, env)
+		before := len(w.visited)
+		body := w.walkSpans(args[1:], flowGraphRelabel(preds, "branch", label), branchEnv)
+		if len(w.visited) == before {
+			return preds
+		}
+		return flowGraphUnion(body, flowGraphRelabel(preds, "branch", "else"))
+	case "cond":
+		var outs [][]flowGraphPred
+		fallthroughPreds := preds
+		exhaustive := false
+		for idx := 0; idx+1 < len(args); idx += 2 {
+			test := args[idx]
+			token := clojureFormToken(w.src, test)
+			label := flowGraphLabel(w.src[test.Start:test.End])
+			if token == ":else" || token == "true" || token == ":default" {
+				label = "else"
+				exhaustive = true
+			} else {
+				fallthroughPreds = w.walk(test.Start, fallthroughPreds, env)
+			}
+			outs = append(outs, w.walk(args[idx+1].Start, flowGraphRelabel(fallthroughPreds, "branch", label), env))
+			if exhaustive {
+				break
+			}
+		}
+		if !exhaustive {
+			outs = append(outs, flowGraphRelabel(fallthroughPreds, "branch", "else"))
+		}
+		return flowGraphUnion(outs...)
+	case "case":
+		if len(args) == 0 {
+			return preds
+		}
+		preds = w.walk(args[0].Start, preds, env)
+		var outs [][]flowGraphPred
+		clauses := args[1:]
+		for idx := 0; idx+1 < len(clauses); idx += 2 {
+			label := "case " + flowGraphLabel(w.src[clauses[idx].Start:clauses[idx].End])
+			outs = append(outs, w.walk(clauses[idx+1].Start, flowGraphRelabel(preds, "branch", label), env))
+		}
+		if len(clauses)%2 == 1 {
+			outs = append(outs, w.walk(clauses[len(clauses)-1].Start, flowGraphRelabel(preds, "branch", "default"), env))
+		} else {
+			outs = append(outs, flowGraphRelabel(preds, "branch", "default"))
+		}
+		return flowGraphUnion(outs...)
+	}
+	return w.walkSpans(elements, preds, env)
+}
+
+// walkTest walks the test (or binding vector) of a conditional and returns
+// the guard label and the environment for the taken branch.
+func (w *flowGraphWalker) walkTest(head string, test clojureFormSpan, preds []flowGraphPred, env flowGraphEnv) (string, []flowGraphPred, flowGraphEnv) {
+	text := w.src[test.Start:test.End]
+	if strings.HasSuffix(head, "-let") || strings.HasSuffix(head, "-some") || head == "when-first" {
+		preds, branchEnv := w.walkBindings(test, preds, env)
+		if elements, _, err := parseClojureVectorElements(w.src, test.Start); err == nil && len(elements) >= 2 {
+			text = w.src[elements[1].Start:elements[1].End]
+		}
+		return flowGraphLabel(text), preds, branchEnv
+	}
+	preds = w.walk(test.Start, preds, env)
+	label := flowGraphLabel(text)
+	if strings.HasSuffix(head, "-not") {
+		label = "not " + label
+	}
+	return label, preds, env
+}
+
+// walkBindings walks a let-style binding vector in order. Each bound symbol
+// remembers the steps its init form ran so later step configs that use the
+// symbol get a data edge back to them.
+func (w *flowGraphWalker) walkBindings(span clojureFormSpan, preds []flowGraphPred, env flowGraphEnv) ([]flowGraphPred, flowGraphEnv) {
+	if !clojureFormStartsWith(w.src, span.Start, '[') {
+		return w.walk(span.Start, preds, env), env
+	}
+	elements, _, err := parseClojureVectorElements(w.src, span.Start)
+	if err != nil {
+		return preds, env
+	}
+	for idx := 0; idx+1 < len(elements); idx += 2 {
+		before := len(w.visited)
+		preds = w.walk(elements[idx+1].Start, preds, env)
+		produced := append([]string(nil), w.visited[before:]...)
+		for _, sym := range flowGraphSymbols(w.src[elements[idx+1].Start:elements[idx+1].End]) {
+			produced = append(produced, env[sym]...)
+		}
+		env = env.with(flowGraphSymbols(w.src[elements[idx].Start:elements[idx].End]), produced)
+	}
+	return preds, env
+}
+
+func (w *flowGraphWalker) walkStep(elements []clojureFormSpan, preds []flowGraphPred, env flowGraphEnv) []flowGraphPred {
+	preds = w.walkSpans(elements[1:], preds, env)
+	ref, ok := w.refs[w.base+elements[0].Start]
+	if !ok && len(elements) > 1 {
+		ref, ok = w.refs[w.base+elements[1].Start]
+	}
+	if !ok {
+		return preds
+	}
+	id, stepType := ref.StepID, ref.TypeToken
+	if id == "" {
+		id = strings.TrimPrefix(ref.PathID, ":")
+	} else {
+		stepType = flowGraphStepFormType(w.stepForms[id])
+	}
+	if id == "" {
+		w.unresolved++
+		id = fmt.Sprintf("flow/step@%d", ref.ByteOffset)
+	}
+	kind := "step"
+	if stepType == ":fanout" {
+		kind = "fanout"
+	}
+	node := w.graph.node(id, kind, id, stepType)
+	for _, p := range preds {
+		w.graph.edge(p.id, id, p.kind, p.label)
+	}
+	var configs []string
+	if len(elements) > 2 {
+		last := elements[len(elements)-1]
+		if clojureFormStartsWith(w.src, last.Start, '{') {
+			configs = append(configs, w.src[last.Start:last.End])
+		}
+	}
+	if ref.StepID != "" {
+		configs = append(configs, w.stepForms[ref.StepID])
+	}
+	for _, child := range flowGraphChildFlows(configs) {
+		childID := "flow:" + child
+		w.graph.node(childID, "flow", child, "")
+		w.graph.edge(id, childID, "child-flow", "")
+	}
+	for _, element := range elements[1:] {
+		for _, sym := range flowGraphSymbols(w.src[element.Start:element.End]) {
+			for _, from := range env[sym] {
+				if !w.graph.connected(from, id) {
+					w.graph.edge(from, id, "data", sym)
+				}
+			}
+		}
+	}
+	node.Kind = kind
+	w.visited = append(w.visited, id)
+	return []flowGraphPred{{id: id, kind: "sequence"}}
+}
+
+// flowGraphChildFlows returns flow slugs named by :flow, :flow-slug or
+// :child-flow keys in step config maps, including one level of :defaults.
+func flowGraphChildFlows(configs []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	var visit func(src string, depth int)
+	visit = func(src string, depth int) {
+		if !clojureFormStartsWith(src, 0, '{') {
+			return
+		}
+		entries, _, err := parseClojureMapEntries(src, skipClojureWhitespaceCommaAndComments(src, 0))
+		if err != nil {
+			return
+		}
+		for _, key := range []string{"flow", "flow-slug", "child-flow"} {
+			entry, ok := mapEntryByKey(entries, key)
+			if !ok {
+				continue
+			}
+			value := strings.TrimSpace(src[entry.ValueStart:entry.ValueEnd])
+			slug := ""
+			if strings.HasPrefix(value, ":") {
+				slug = strings.TrimPrefix(value, ":")
+			} else if strings.HasPrefix(value, "\"") {
+				if _, decoded, _, err := readClojureStringToken(src, entry.ValueStart); err == nil {
+					slug = decoded
+				}
+			}
+			if isAPIValidFlowSlug(slug) && !seen[slug] {
+				seen[slug] = true
+				out = append(out, slug)
+			}
+		}
+		if depth == 0 {
+			if entry, ok := mapEntryByKey(entries, "defaults"); ok {
+				visit(src[entry.ValueStart:entry.ValueEnd], depth+1)
+			}
+		}
+	}
+	for _, config := range configs {
+		visit(strings.TrimSpace(config), 0)
+	}
+	return out
+}
+
+func flowGraphStepFormType(form string) string {
+	form = strings.TrimSpace(form)
+	if !clojureFormStartsWith(form, 0, '{') {
+		return ""
+	}
+	entries, _, err := parseClojureMapEntries(form, 0)
+	if err != nil {
+		return ""
+	}
+	entry, ok := mapEntryByKey(entries, "type")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(form[entry.ValueStart:entry.ValueEnd])
+}
+
+// flowGraphSymbols lists the plain symbols in a source fragment, skipping
+// keywords, strings, comments, and the contents of quoted forms.
+func flowGraphSymbols(src string) []string {
+	clean := stripClojureStringLiterals(src)
+	var out []string
+	seen := map[string]bool{}
+	for i := 0; i < len(clean); {
+		c := clean[i]
+		if c == ':' || c == '\\' || isClojureSymbolStart(c) {
+			end := readClojureTokenEnd(clean, i)
+			if end <= i {
+				end = i + 1
+			}
+			token := clean[i:end]
+			if isClojureSymbolStart(c) && !seen[token] {
+				switch token {
+				case "nil", "true", "false", "&":
+				default:
+					seen[token] = true
+					out = append(out, token)
+				}
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+	return out
+}
+
+func isClojureSymbolStart(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return true
+	case strings.IndexByte("*+!-_?<>=", c) >= 0:
+		return true
+	}
+	return false
+}
+
+// flowGraphLabel renders a guard form on one line, shortened for display.
+func flowGraphLabel(src string) string {
+	label := canonicalClojureFormText(strings.TrimSpace(src))
+	if runes := []rune(label); len(runes) > flowGraphMaxLabelRunes {
+		label = string(runes[:flowGraphMaxLabelRunes-1]) + "…"
+	}
+	return label
+}
+
+// buildLocalFlowGraph derives the step graph of a local flow file after
+// include expansion. Interfaces and schedules are entry nodes feeding the
+// first steps of the :flow body; :steps entries the body never calls are
+// kept as unreferenced nodes.
+func buildLocalFlowGraph(path string) (*flowGraph, map[string]any, error) {
+	side, err := loadLocalFlowDiffSide(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	slug := ""
+	if entry, ok := side.entries["slug"]; ok {
+		slug = strings.TrimPrefix(strings.Trim(strings.TrimSpace(side.source[entry.ValueStart:entry.ValueEnd]), `"`), ":")
+	}
+	graph := newFlowGraph(slug)
+
+	var entryPreds []flowGraphPred
+	_, interfaceOrder := side.interfaceForms()
+	for _, key := range interfaceOrder {
+		id := "interface:" + key
+		graph.node(id, "interface", key, "")
+		entryPreds = append(entryPreds, flowGraphPred{id: id, kind: "entry"})
+	}
+	_, scheduleOrder, err := side.namedForms("schedules")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range scheduleOrder {
+		id := "schedule:" + key
+		graph.node(id, "schedule", key, "")
+		entryPreds = append(entryPreds, flowGraphPred{id: id, kind: "entry"})
+	}
+	stepForms, stepOrder, err := side.namedForms("steps")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	references, err := localFlowStepReferences(side.source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s :flow: %w", path, err)
+	}
+	flowSource, baseOffset, ok := topLevelFlowValueSource(side.source)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: top-level :flow value could not be located", path)
+	}
+	flowSource, readerOffset := unwrapTopLevelReaderConditionalFlowSource(flowSource)
+	baseOffset += readerOffset
+	flowSource, quotedOffset := unwrapTopLevelQuotedFlowSource(flowSource)
+	baseOffset += quotedOffset
+
+	walker := &flowGraphWalker{
+		src:       flowSource,
+		base:      baseOffset,
+		refs:      map[int]localFlowStepReference{},
+		graph:     graph,
+		stepForms: stepForms,
+	}
+	for _, ref := range references {
+		walker.refs[ref.ByteOffset] = ref
+	}
+	walker.walk(0, entryPreds, flowGraphEnv{})
+
+	called := map[string]bool{}
+	for _, id := range walker.visited {
+		called[id] = true
+	}
+	for _, id := range stepOrder {
+		stepType := flowGraphStepFormType(stepForms[id])
+		kind := "step"
+		if stepType == ":fanout" {
+			kind = "fanout"
+		}
+		n := graph.node(id, kind, id, stepType)
+		referenced := called[id]
+		n.Referenced = &referenced
+	}
+	meta := map[string]any{
+		"file":       path,
+		"stepCalls":  len(walker.visited),
+		"entryNodes": len(entryPreds),
+	}
+	if walker.unresolved > 0 {
+		meta["unresolvedStepIds"] = walker.unresolved
+		meta["hint"] = "Some flow/step calls use a computed step id; they are shown as flow/step@<offset> nodes."
+	}
+	return graph, meta, nil
+}
+
+// markFailedSteps flags step nodes whose run step entry has an error.
+func (g *flowGraph) markFailedSteps(run map[string]any) []string {
+	failed := []string{}
+	for _, n := range g.nodes {
+		if n.Kind != "step" && n.Kind != "fanout" {
+			continue
+		}
+		step := findRunStep(run, n.ID)
+		if step == nil {
+			continue
+		}
+		n.Status = firstNonBlankString(step["status"])
+		if runStepHasError(step) {
+			n.Failed = true
+			failed = append(failed, n.ID)
+		}
+	}
+	return failed
+}
+
+func (g *flowGraph) data() map[string]any {
+	adjacency := map[string][]string{}
+	for _, n := range g.nodes {
+		adjacency[n.ID] = []string{}
+	}
+	for _, e := range g.edges {
+		if !slices.Contains(adjacency[e.From], e.To) {
+			adjacency[e.From] = append(adjacency[e.From], e.To)
+		}
+	}
+	return map[string]any{
+		"flowSlug":  g.Slug,
+		"nodes":     g.nodes,
+		"edges":     g.edges,
+		"adjacency": adjacency,
+	}
+}
+
+func writeFlowGraphDOT(w io.Writer, g *flowGraph) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(firstNonBlankString(g.Slug, "flow")))
+	b.WriteString("  rankdir=TB;\n  node [fontname=\"Helvetica\"];\n")
+	for _, n := range g.nodes {
+		attrs := []string{"label=" + dotQuote(flowGraphNodeText(n, "\\n"))}
+		switch n.Kind {
+		case "interface", "schedule":
+			attrs = append(attrs, "shape=oval")
+		case "fanout":
+			attrs = append(attrs, "shape=box3d")
+		case "flow":
+			attrs = append(attrs, "shape=component")
+		default:
+			attrs = append(attrs, "shape=box")
+		}
+		if n.Referenced != nil && !*n.Referenced {
+			attrs = append(attrs, "style=dashed")
+		}
+		if n.Failed {
+			attrs = append(attrs, `style=filled`, `fillcolor="#f8d7da"`, `color="#c0392b"`)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, " "))
+	}
+	for _, e := range g.edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		switch e.Kind {
+		case "data":
+			attrs = append(attrs, "style=dashed")
+		case "child-flow":
+			attrs = append(attrs, "style=bold")
+		}
+		suffix := ""
+		if len(attrs) > 0 {
+			suffix = " [" + strings.Join(attrs, " ") + "]"
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(e.From), dotQuote(e.To), suffix)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func writeFlowGraphMermaid(w io.Writer, g *flowGraph) error {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	ids := map[string]string{}
+	var failed []string
+	for idx, n := range g.nodes {
+		ref := fmt.Sprintf("n%d", idx)
+		ids[n.ID] = ref
+		label := mermaidQuote(flowGraphNodeText(n, "<br/>"))
+		switch n.Kind {
+		case "interface", "schedule":
+			fmt.Fprintf(&b, "  %s([%s])\n", ref, label)
+		case "fanout":
+			fmt.Fprintf(&b, "  %s[[%s]]\n", ref, label)
+		case "flow":
+			fmt.Fprintf(&b, "  %s[/%s/]\n", ref, label)
+		default:
+			fmt.Fprintf(&b, "  %s[%s]\n", ref, label)
+		}
+		if n.Failed {
+			failed = append(failed, ref)
+		}
+	}
+	for _, e := range g.edges {
+		arrow := "-->"
+		switch e.Kind {
+		case "data":
+			arrow = "-.->"
+		case "child-flow":
+			arrow = "==>"
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.From], arrow, mermaidQuote(e.Label), ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+		}
+	}
+	if len(failed) > 0 {
+		b.WriteString("  classDef failed fill:#f8d7da,stroke:#c0392b\n")
+		fmt.Fprintf(&b, "  class %s failed\n", strings.Join(failed, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + s + `"`
+}
+
+func flowGraphNodeText(n *flowGraphNode, br string) string {
+	text := n.Label
+	switch n.Kind {
+	case "interface":
+		text = "interface " + text
+	case "schedule":
+		text = "schedule " + text
+	case "flow":
+		text = "flow " + text
+	}
+	if n.Type != "" {
+		text += br + n.Type
+	}
+	if n.Failed {
+		text += br + "FAILED"
+	}
+	return text
+}
+
+func newFlowsGraphCmd(app *App) *cobra.Command {
+	var file string
+	var format string
+	var runID string
+
+	cmd := &cobra.Command{
+		Use:   "graph [flow-slug]",
+		Short: "Export the step graph of a local flow file",
+		Long: strings.TrimSpace(`
+Derive the step graph from a local flow file without calling the API.
+
+The quoted :flow body is walked with includes expanded. Steps become nodes;
+edges follow let bindings in order (sequence), if/when/cond/case guards
+(branch, labelled with the condition), and let-bound results used by later
+steps (data). Fanout steps are marked, steps whose config names a :flow or
+:flow-slug get a child-flow node, and interfaces and schedules are entry nodes
+feeding the first steps. :steps entries the body never calls are kept as
+unreferenced nodes.
+
+Pass a flow slug to read flows/<slug>.clj, or --file for any path. Use
+--format dot or mermaid for diagrams, or the default json for an adjacency
+list. --run marks the steps that failed in that run (requires API access).
+`),
+		Example: strings.TrimSpace(`
+breyta flows graph order-ingest
+breyta flows graph --file ./flows/order-ingest.clj --format dot | dot -Tsvg > graph.svg
+breyta flows graph order-ingest --format mermaid
+breyta flows graph order-ingest --run wf-123 --format dot
+`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format = strings.ToLower(strings.TrimSpace(format))
+			if !slices.Contains(flowGraphFormats, format) {
+				return writeErr(cmd, fmt.Errorf("unsupported --format %q (use %s)", format, strings.Join(flowGraphFormats, ", ")))
+			}
+			path := strings.TrimSpace(file)
+			if len(args) == 1 {
+				resolved, _, err := readLocalFlowSource(args[0], file)
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				path = resolved
+			}
+			if path == "" {
+				return writeErr(cmd, errors.New("pass a flow slug or --file"))
+			}
+			graph, meta, err := buildLocalFlowGraph(path)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+
+			if runID = strings.TrimSpace(runID); runID != "" {
+				out, status, err := runAPICommand(app, "runs.get", map[string]any{"workflowId": runID, "includeSteps": true})
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				if status >= 400 {
+					return writeAPIResult(cmd, app, out, status)
+				}
+				run := mapStringAny(mapStringAny(out["data"])["run"])
+				if run == nil {
+					return writeErr(cmd, fmt.Errorf("run %s returned no step data", runID))
+				}
+				failed := graph.markFailedSteps(run)
+				sort.Strings(failed)
+				meta["run"] = map[string]any{"workflowId": runID, "failedSteps": failed}
+			}
+
+			switch format {
+			case "dot":
+				if err := writeFlowGraphDOT(cmd.OutOrStdout(), graph); err != nil {
+					return writeErr(cmd, err)
+				}
+				return nil
+			case "mermaid":
+				if err := writeFlowGraphMermaid(cmd.OutOrStdout(), graph); err != nil {
+					return writeErr(cmd, err)
+				}
+				return nil
+			}
+			return writeData(cmd, app, meta, graph.data())
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Path to local .clj flow source (default: flows/<slug>.clj)")
+	cmd.Flags().StringVar(&format, "format", "json", "Output format: json, dot, or mermaid")
+	cmd.Flags().StringVar(&runID, "run", "", "Mark steps that failed in this run (workflow id)")
+	return cmd
+}
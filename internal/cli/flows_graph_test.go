@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const graphFlow = `{:slug :orders
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules [{:id :nightly :cron "0 2 * * *" :invocation :default}]
+ :steps [{:id :orders/fetch :type :http :defaults {:method :get :url "https://example.com/orders"}}
+         {:id :orders/fan :type :fanout :defaults {:flow :process-order}}
+         {:id :orders/unused :type :http}]
+ :flow '(let [input (flow/input)
+              orders (flow/step :orders/fetch {})
+              total (count orders)]
+          (if (pos? total)
+            (flow/step :orders/fan {:items orders})
+            (flow/step :notify :alert {:text "none"}))
+          (flow/step :function :summarize {:input {:orders orders} :code '(fn [x] (flow/step :http :quoted {}))}))}
+`
+
+func writeGraphTestFlow(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "orders.clj")
+	if err := os.WriteFile(path, []byte(graphFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	return path
+}
+
+func flowGraphEdgeSet(t *testing.T, data map[string]any) map[string]bool {
+	t.Helper()
+	set := map[string]bool{}
+	for _, item := range data["edges"].([]any) {
+		edge := item.(map[string]any)
+		label, _ := edge["label"].(string)
+		set[edge["from"].(string)+" -> "+edge["to"].(string)+" "+edge["kind"].(string)+" "+label] = true
+	}
+	return set
+}
+
+func TestFlowsGraphDerivesEdgesFromFlowBody(t *testing.T) {
+	path := writeGraphTestFlow(t)
+	cmd := newFlowsGraphCmd(&App{WorkspaceID: "ws-acme"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--file", path})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("graph failed: %v\n%s", err, out.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(out.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	data := body["data"].(map[string]any)
+	edges := flowGraphEdgeSet(t, data)
+	for _, want := range []string{
+		"interface:manual/run -> orders/fetch entry ",
+		"schedule:nightly -> orders/fetch entry ",
+		"orders/fetch -> orders/fan branch (pos? total)",
+		"orders/fetch -> alert branch else",
+		"orders/fan -> summarize sequence ",
+		"alert -> summarize sequence ",
+		"orders/fetch -> summarize data orders",
+		"orders/fan -> flow:process-order child-flow ",
+	} {
+		if !edges[want] {
+			t.Fatalf("missing edge %q in %#v", want, edges)
+		}
+	}
+
+	kinds := map[string]string{}
+	for _, item := range data["nodes"].([]any) {
+		node := item.(map[string]any)
+		kinds[node["id"].(string)] = node["kind"].(string)
+		if node["id"] == "orders/unused" && node["referenced"] != false {
+			t.Fatalf("uncalled :steps entry should be unreferenced: %#v", node)
+		}
+	}
+	if kinds["orders/fan"] != "fanout" || kinds["flow:process-order"] != "flow" {
+		t.Fatalf("unexpected node kinds %#v", kinds)
+	}
+	if _, ok := kinds["quoted"]; ok {
+		t.Fatalf("quoted flow/step forms must not become nodes: %#v", kinds)
+	}
+	adjacency := data["adjacency"].(map[string]any)
+	if next := adjacency["orders/fetch"].([]any); len(next) != 3 {
+		t.Fatalf("unexpected adjacency %#v", adjacency)
+	}
+}
+
+func TestFlowsGraphRendersDOTAndMermaid(t *testing.T) {
+	path := writeGraphTestFlow(t)
+	for format, wants := range map[string][]string{
+		"dot": {
+			`digraph "orders" {`,
+			`"orders/fan" [label="orders/fan\n:fanout" shape=box3d];`,
+			`"orders/fetch" -> "orders/fan" [label="(pos? total)"];`,
+			`"orders/fetch" -> "summarize" [label="orders" style=dashed];`,
+		},
+		"mermaid": {
+			"flowchart TD",
+			`[["orders/fan<br/>:fanout"]]`,
+			`-->|"(pos? total)"|`,
+			"-.->",
+		},
+	} {
+		cmd := newFlowsGraphCmd(&App{WorkspaceID: "ws-acme"})
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs([]string{"--file", path, "--format", format})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%s graph failed: %v\n%s", format, err, out.String())
+		}
+		for _, want := range wants {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("%s output missing %q:\n%s", format, want, out.String())
+			}
+		}
+	}
+}
+
+func TestFlowsGraphMarksFailedRunSteps(t *testing.T) {
+	path := writeGraphTestFlow(t)
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		args, _ := body["args"].(map[string]any)
+		if body["command"] != "runs.get" || args["workflowId"] != "wf-9" || args["includeSteps"] != true {
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": map[string]any{"message": "unexpected request"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok": true,
+			"data": map[string]any{"run": map[string]any{
+				"workflowId": "wf-9",
+				"steps": []any{
+					map[string]any{"stepId": "orders/fetch", "status": "completed"},
+					map[string]any{"stepId": "alert", "status": "failed", "error": map[string]any{"message": "boom"}},
+				},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	app := &App{WorkspaceID: "ws-acme", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+	cmd := newFlowsGraphCmd(app)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--file", path, "--run", "wf-9", "--format", "mermaid"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("graph --run failed: %v\n%s", err, out.String())
+	}
+	stdout := out.String()
+	if !strings.Contains(stdout, `"alert<br/>:notify<br/>FAILED"`) || !strings.Contains(stdout, "classDef failed") {
+		t.Fatalf("expected failed step to be marked:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "orders/fetch<br/>:http<br/>FAILED") {
+		t.Fatalf("completed step must not be marked failed:\n%s", stdout)
+	}
+}
@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The Zapier and Make importers adapt their exports to n8nWorkflow so they
+// share the n8n converter's rendering, input planning, branch guards, TODO
+// collection and validation. Steps they can express as n8n nodes use the
+// n8n node types; everything else gets a "zapier." or "make." type, which the
+// converter always turns into a TODO function step.
+
+var n8nForeignTypePrefixes = map[string]string{
+	"zapier.": "Zapier step",
+	"make.":   "Make module",
+}
+
+// n8nIsForeignNode reports whether an importer kept the node in its source
+// tool's type because no n8n equivalent was found.
+func n8nIsForeignNode(node n8nNode) bool {
+	return n8nNodeSourceLabel(node) != "n8n node"
+}
+
+func n8nNodeSourceLabel(node n8nNode) string {
+	for prefix, label := range n8nForeignTypePrefixes {
+		if strings.HasPrefix(node.Type, prefix) {
+			return label
+		}
+	}
+	return "n8n node"
+}
+
+// n8nAdaptedWorkflow collects nodes and main connections while an importer
+// builds an n8nWorkflow.
+type n8nAdaptedWorkflow struct {
+	wf        n8nWorkflow
+	usedNames map[string]bool
+}
+
+func newN8NAdaptedWorkflow(name, source string) *n8nAdaptedWorkflow {
+	return &n8nAdaptedWorkflow{
+		wf:        n8nWorkflow{Name: name, Source: source, Connections: map[string]map[string][][]n8nConnection{}},
+		usedNames: map[string]bool{},
+	}
+}
+
+// uniqueName returns base, or base with a numeric suffix when another node
+// already has that name.
+func (a *n8nAdaptedWorkflow) uniqueName(base string) string {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		base = "Step"
+	}
+	name := base
+	for i := 2; a.usedNames[name]; i++ {
+		name = base + " " + strconv.Itoa(i)
+	}
+	a.usedNames[name] = true
+	return name
+}
+
+func (a *n8nAdaptedWorkflow) add(node n8nNode) {
+	a.wf.Nodes = append(a.wf.Nodes, node)
+}
+
+func (a *n8nAdaptedWorkflow) connect(source, target string, output int) {
+	if a.wf.Connections[source] == nil {
+		a.wf.Connections[source] = map[string][][]n8nConnection{}
+	}
+	outputs := a.wf.Connections[source]["main"]
+	for len(outputs) <= output {
+		outputs = append(outputs, nil)
+	}
+	outputs[output] = append(outputs[output], n8nConnection{Node: target, Type: "main"})
+	a.wf.Connections[source]["main"] = outputs
+}
+
+var n8nAdaptedTemplateRe = regexp.MustCompile(`\{\{(.+?)\}\}`)
+
+// n8nAdaptTemplate rewrites a Zapier or Make mapping string to an n8n value:
+// each {{...}} reference becomes an n8n {{ }} expression built by expr, and a
+// value that is a single reference becomes an "={{ }}" expression.
+func n8nAdaptTemplate(value string, expr func(string) string) string {
+	matches := n8nAdaptedTemplateRe.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value
+	}
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value) {
+		return "={{ " + expr(value[matches[0][2]:matches[0][3]]) + " }}"
+	}
+	var b strings.Builder
+	cursor := 0
+	for _, match := range matches {
+		b.WriteString(value[cursor:match[0]])
+		b.WriteString("{{ " + expr(value[match[2]:match[3]]) + " }}")
+		cursor = match[1]
+	}
+	b.WriteString(value[cursor:])
+	return b.String()
+}
+
+// n8nAdaptTemplateJS is n8nAdaptTemplate as one JavaScript expression:
+// literal text is quoted and mixed text is concatenated.
+func n8nAdaptTemplateJS(value string, expr func(string) string) string {
+	matches := n8nAdaptedTemplateRe.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return n8nJSString(value)
+	}
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value) {
+		return expr(value[matches[0][2]:matches[0][3]])
+	}
+	var parts []string
+	cursor := 0
+	for _, match := range matches {
+		if match[0] > cursor {
+			parts = append(parts, n8nJSString(value[cursor:match[0]]))
+		}
+		parts = append(parts, "String("+expr(value[match[2]:match[3]])+")")
+		cursor = match[1]
+	}
+	if cursor < len(value) {
+		parts = append(parts, n8nJSString(value[cursor:]))
+	}
+	return "(" + strings.Join(parts, " + ") + ")"
+}
+
+func n8nJSString(value string) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// n8nJSNodePath renders $node["name"].json followed by path as JavaScript.
+func n8nJSNodePath(name string, path []string) string {
+	var b strings.Builder
+	b.WriteString("$node[" + n8nJSString(name) + "].json")
+	for _, part := range path {
+		switch {
+		case part == "":
+		case n8nJSIdentRe.MatchString(part):
+			b.WriteString("." + part)
+		default:
+			if _, err := strconv.Atoi(part); err == nil {
+				b.WriteString("[" + part + "]")
+			} else {
+				b.WriteString("[" + n8nJSString(part) + "]")
+			}
+		}
+	}
+	return b.String()
+}
+
+var n8nJSIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// n8nAdaptedComparison renders one filter condition as a JavaScript boolean
+// expression. left and right are JavaScript expressions; op is one of the
+// normalized operators below. It reports false for operators with no
+// translation.
+func n8nAdaptedComparison(left, op, right string) (string, bool) {
+	str := "String(" + left + ")"
+	lower := str + ".toLowerCase()"
+	num := func(value string) string {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(unquoted), 64); err == nil {
+				return strings.TrimSpace(unquoted)
+			}
+		}
+		return "Number(" + value + ")"
+	}
+	switch op {
+	case "exists":
+		return "(" + left + " ?? \"\") !== \"\"", true
+	case "notexists":
+		return "(" + left + " ?? \"\") === \"\"", true
+	case "equal":
+		return str + " === " + right, true
+	case "notequal":
+		return str + " !== " + right, true
+	case "equalci":
+		return lower + " === String(" + right + ").toLowerCase()", true
+	case "notequalci":
+		return lower + " !== String(" + right + ").toLowerCase()", true
+	case "contains":
+		return str + ".includes(" + right + ")", true
+	case "notcontains":
+		return "!" + str + ".includes(" + right + ")", true
+	case "containsci":
+		return lower + ".includes(String(" + right + ").toLowerCase())", true
+	case "notcontainsci":
+		return "!" + lower + ".includes(String(" + right + ").toLowerCase())", true
+	case "startswith":
+		return str + ".startsWith(" + right + ")", true
+	case "endswith":
+		return str + ".endsWith(" + right + ")", true
+	case "gt", "lt", "gte", "lte", "numequal", "numnotequal":
+		operator := map[string]string{"gt": ">", "lt": "<", "gte": ">=", "lte": "<=", "numequal": "===", "numnotequal": "!=="}[op]
+		return "Number(" + left + ") " + operator + " " + num(right), true
+	case "true":
+		return str + " === \"true\"", true
+	case "false":
+		return str + " !== \"true\"", true
+	}
+	return "", false
+}
+
+// n8nAdaptedIFNode builds an n8n IF node whose true output continues the
+// workflow. groups are ORed and the expressions in each group ANDed. An IF
+// node without conditions converts with a TODO.
+func n8nAdaptedIFNode(name string, groups [][]string) n8nNode {
+	node := n8nNode{Name: name, Type: "n8n-nodes-base.if", Parameters: map[string]any{}}
+	var ors []string
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if len(group) == 1 {
+			ors = append(ors, group[0])
+			continue
+		}
+		ors = append(ors, "("+strings.Join(group, " && ")+")")
+	}
+	if len(ors) == 0 {
+		return node
+	}
+	node.Parameters["conditions"] = map[string]any{
+		"conditions": []any{map[string]any{
+			"leftValue": "={{ " + strings.Join(ors, " || ") + " }}",
+			"operator":  map[string]any{"operation": "true"},
+		}},
+	}
+	return node
+}
+
+// n8nAdaptedPairs renders a name/value map as sorted n8n parameter pairs,
+// adapting each value with template.
+func n8nAdaptedPairs(values map[string]string, template func(string) string) map[string]any {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, map[string]any{"name": key, "value": template(values[key])})
+	}
+	return map[string]any{"parameters": pairs}
+}
+
+// n8nAdaptedTimeOfDay parses a time of day such as "9", "9am", "14:30" or
+// "2:15 PM" into hour and minute; "12am" and unreadable values are midnight.
+func n8nAdaptedTimeOfDay(value string) (int, int) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	pm := strings.HasSuffix(value, "pm")
+	am := strings.HasSuffix(value, "am")
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(value, "pm"), "am"))
+	hourText, minuteText, _ := strings.Cut(value, ":")
+	hour, err := strconv.Atoi(strings.TrimSpace(hourText))
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0
+	}
+	minute, err := strconv.Atoi(strings.TrimSpace(minuteText))
+	if err != nil || minute < 0 || minute > 59 {
+		minute = 0
+	}
+	switch {
+	case pm && hour < 12:
+		hour += 12
+	case am && hour == 12:
+		hour = 0
+	}
+	return hour, minute
+}
+
+// n8nAdaptedScheduleNode builds a Schedule Trigger that fires on cron.
+func n8nAdaptedScheduleNode(name, cron string) n8nNode {
+	return n8nNode{Name: name, Type: "n8n-nodes-base.scheduleTrigger", Parameters: map[string]any{
+		"rule": map[string]any{"interval": []any{map[string]any{"field": "cronExpression", "expression": cron}}},
+	}}
+}
+
+// n8nAdaptedCredential returns node credentials for a source-tool account,
+// keyed by its app so the n8n credential slots dedupe by app and ID.
+func n8nAdaptedCredential(app, id, label string) map[string]any {
+	if strings.TrimSpace(id) == "" {
+		return nil
+	}
+	return map[string]any{app: map[string]any{"id": id, "name": label}}
+}
+
+// n8nTitleWords turns "google-sheets", "GoogleSheets" or "new_row" into
+// "Google Sheets" / "New Row".
+func n8nTitleWords(value string) string {
+	value = strings.NewReplacer("-", " ", "_", " ").Replace(n8nSplitCamel(value))
+	words := strings.Fields(value)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// n8nAdaptedID renders a JSON ID (number or string) as text.
+func n8nAdaptedID(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case string:
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+type n8nAdaptedImportOptions struct {
+	slug           string
+	out            string
+	credentialsMap string
+	bindingsOut    string
+	serverValidate bool
+	deployKey      string
+}
+
+func bindN8NAdaptedImportFlags(cmd *cobra.Command, opts *n8nAdaptedImportOptions) {
+	cmd.Flags().StringVar(&opts.slug, "slug", "", "Breyta flow slug (defaults to normalized workflow name)")
+	cmd.Flags().StringVar(&opts.out, "out", "", "Output flow file (defaults to ./tmp/flows/<slug>.clj)")
+	cmd.Flags().BoolVar(&opts.serverValidate, "server-validate", false, "Push generated flow to the configured Breyta API draft and run flows.validate")
+	cmd.Flags().StringVar(&opts.deployKey, "deploy-key", "", "Deploy key for guarded flows when using --server-validate (default: BREYTA_FLOW_DEPLOY_KEY)")
+	cmd.Flags().StringVar(&opts.credentialsMap, "credentials-map", "", "JSON file mapping account IDs or names to Breyta connection IDs")
+	cmd.Flags().StringVar(&opts.bindingsOut, "bindings-out", "", "Bindings profile path when using --credentials-map (defaults to <out>.bindings.edn)")
+}
+
+// runN8NAdaptedImport converts an adapted export through the n8n pipeline and
+// prints the same result as flows import n8n.
+func runN8NAdaptedImport(cmd *cobra.Command, app *App, path string, wf n8nWorkflow, opts n8nAdaptedImportOptions) error {
+	code := strings.ToLower(wf.Source)
+	var credentials map[string]n8nCredentialMapping
+	if strings.TrimSpace(opts.credentialsMap) != "" {
+		loaded, err := loadN8NCredentialsMap(opts.credentialsMap)
+		if err != nil {
+			return writeFailure(cmd, app, code+"_credentials_map_invalid", err, `Use a JSON object keyed by account ID or name, e.g. {"123": "conn-123"}.`, map[string]any{"path": opts.credentialsMap})
+		}
+		credentials = loaded
+	}
+	result, err := importN8NWorkflow(wf, path, opts.slug, opts.out, credentials)
+	if err != nil {
+		return writeFailure(cmd, app, code+"_import_failed", err, fmt.Sprintf("Check that the input is a %s export.", wf.Source), map[string]any{"path": path})
+	}
+	return writeN8NImportResult(cmd, app, result, opts.bindingsOut, opts.serverValidate, opts.deployKey)
+}
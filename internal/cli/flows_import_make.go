@@ -0,0 +1,574 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// makeBlueprint is a Make.com scenario blueprint export.
+type makeBlueprint struct {
+	Name       string         `json:"name"`
+	Flow       []makeModule   `json:"flow"`
+	Scheduling map[string]any `json:"scheduling"`
+}
+
+type makeModule struct {
+	ID         any            `json:"id"`
+	Module     string         `json:"module"`
+	Parameters map[string]any `json:"parameters"`
+	Mapper     map[string]any `json:"mapper"`
+	Metadata   map[string]any `json:"metadata"`
+	Filter     *makeFilter    `json:"filter"`
+	Routes     []makeRoute    `json:"routes"`
+}
+
+type makeRoute struct {
+	Flow []makeModule `json:"flow"`
+}
+
+type makeFilter struct {
+	Name       string             `json:"name"`
+	Conditions [][]map[string]any `json:"conditions"`
+}
+
+func newFlowsImportMakeCmd(app *App) *cobra.Command {
+	var opts n8nAdaptedImportOptions
+	cmd := &cobra.Command{
+		Use:   "make <blueprint.json>",
+		Short: "Convert a Make.com scenario blueprint to a Breyta flow file",
+		Long: strings.TrimSpace(`
+Convert a Make.com scenario blueprint to a best-effort Breyta EDN flow file.
+
+The import runs through the same converter as flows import n8n: Custom Webhook
+triggers become webhooks, HTTP modules become HTTP steps, routers and route
+filters become branch guards, Set variable, Text composer and Switch modules
+become function steps, Sleep becomes a wait step, and the blueprint's
+scheduling becomes a schedule. Every other module becomes a TODO function step,
+and each connection becomes a :requires slot that --credentials-map (keyed by
+Make connection ID) can bind.
+
+  breyta flows import make blueprint.json --slug order-sync
+  breyta flows push --file ./tmp/flows/order-sync.clj
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := readMakeBlueprintFile(args[0])
+			if err != nil {
+				return writeFailure(cmd, app, "make_import_failed", err, "Check that the input is a Make.com scenario blueprint export.", map[string]any{"path": args[0]})
+			}
+			return runN8NAdaptedImport(cmd, app, args[0], wf, opts)
+		},
+	}
+	bindN8NAdaptedImportFlags(cmd, &opts)
+	return cmd
+}
+
+func readMakeBlueprintFile(path string) (n8nWorkflow, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return n8nWorkflow{}, err
+	}
+	var blueprint makeBlueprint
+	if err := json.Unmarshal(b, &blueprint); err != nil {
+		return n8nWorkflow{}, err
+	}
+	return adaptMakeBlueprint(blueprint)
+}
+
+// adaptMakeBlueprint adapts the scenario's modules to n8n nodes. Each module
+// follows the previous one in its flow; router routes start from the router,
+// and a module's filter becomes an IF node in front of it.
+func adaptMakeBlueprint(blueprint makeBlueprint) (n8nWorkflow, error) {
+	if len(blueprint.Flow) == 0 {
+		return n8nWorkflow{}, errors.New("make blueprint has no modules")
+	}
+	adapted := newN8NAdaptedWorkflow(blueprint.Name, "Make")
+	names := map[string]string{}
+	var nameModules func(flow []makeModule)
+	nameModules = func(flow []makeModule) {
+		for _, module := range flow {
+			names[n8nAdaptedID(module.ID)] = adapted.uniqueName(makeModuleLabel(module))
+			for _, route := range module.Routes {
+				nameModules(route.Flow)
+			}
+		}
+	}
+	nameModules(blueprint.Flow)
+	expr := func(inner string) string { return makeExprJS(inner, names) }
+
+	first := blueprint.Flow[0]
+	if first.Module != "gateway:CustomWebHook" {
+		if cron, todo, ok := makeSchedulingCron(blueprint.Scheduling); ok {
+			schedule := adapted.uniqueName("Schedule")
+			node := n8nAdaptedScheduleNode(schedule, cron)
+			if todo != "" {
+				node.Todos = []string{todo}
+			}
+			adapted.add(node)
+			adapted.connect(schedule, names[n8nAdaptedID(first.ID)], 0)
+		}
+	}
+	var addFlow func(parent string, flow []makeModule)
+	addFlow = func(parent string, flow []makeModule) {
+		for _, module := range flow {
+			name := names[n8nAdaptedID(module.ID)]
+			if module.Filter != nil && parent != "" {
+				filter := adapted.uniqueName(firstNonEmpty(strings.TrimSpace(module.Filter.Name), name+" filter"))
+				adapted.add(n8nAdaptedIFNode(filter, makeFilterGroups(module.Filter, expr)))
+				adapted.connect(parent, filter, 0)
+				parent = filter
+			}
+			adapted.add(adaptMakeModule(module, name, expr))
+			if parent != "" {
+				adapted.connect(parent, name, 0)
+			}
+			for _, route := range module.Routes {
+				addFlow(name, route.Flow)
+			}
+			parent = name
+		}
+	}
+	addFlow("", blueprint.Flow)
+	return adapted.wf, nil
+}
+
+var makeModuleLabels = map[string]string{
+	"gateway:CustomWebHook":         "Webhook",
+	"gateway:WebhookRespond":        "Webhook response",
+	"http:ActionSendData":           "HTTP request",
+	"http:ActionSendDataBasicAuth":  "HTTP request",
+	"http:ActionSendDataAPIKeyAuth": "HTTP request",
+	"http:MakeRequest":              "HTTP request",
+	"builtin:BasicRouter":           "Router",
+	"util:SetVariable":              "Set variable",
+	"util:SetVariable2":             "Set variable",
+	"util:SetVariables":             "Set variables",
+	"util:ComposeTransformer":       "Compose text",
+	"util:Switcher":                 "Switch",
+	"util:FunctionSleep":            "Sleep",
+}
+
+// makeModuleLabel names a module by its designer label, or by app and action,
+// e.g. "Google Sheets Add Row" for google-sheets:ActionAddRow.
+func makeModuleLabel(module makeModule) string {
+	if designer, ok := module.Metadata["designer"].(map[string]any); ok {
+		if name := strings.TrimSpace(stringParam(designer, "name")); name != "" {
+			return name
+		}
+	}
+	if label, ok := makeModuleLabels[module.Module]; ok {
+		return label
+	}
+	app, action, _ := strings.Cut(module.Module, ":")
+	return strings.TrimSpace(n8nTitleWords(app) + " " + n8nTitleWords(strings.TrimPrefix(action, "Action")))
+}
+
+func adaptMakeModule(module makeModule, name string, expr func(string) string) n8nNode {
+	mapper := module.Mapper
+	if mapper == nil {
+		mapper = map[string]any{}
+	}
+	template := func(value string) string { return n8nAdaptTemplate(value, expr) }
+	templateJS := func(value string) string { return n8nAdaptTemplateJS(value, expr) }
+	out := n8nNode{ID: n8nAdaptedID(module.ID), Name: name, Parameters: map[string]any{}}
+	setNode := func(keepOnlySet bool, fields []any) n8nNode {
+		out.Type = "n8n-nodes-base.set"
+		out.Parameters = map[string]any{"keepOnlySet": keepOnlySet, "values": map[string]any{"string": fields}}
+		return out
+	}
+	field := func(name, value string) any {
+		return map[string]any{"name": name, "value": value}
+	}
+
+	app, _, _ := strings.Cut(module.Module, ":")
+	switch module.Module {
+	case "gateway:CustomWebHook":
+		out.Type = "n8n-nodes-base.webhook"
+		return out
+	case "gateway:WebhookRespond":
+		out.Type = "n8n-nodes-base.respondToWebhook"
+		out.Parameters = map[string]any{
+			"responseCode": float64(intParam(mapper, 200, "status")),
+			"responseBody": template(stringParam(mapper, "body")),
+		}
+		return out
+	case "http:ActionSendData", "http:ActionSendDataBasicAuth", "http:ActionSendDataAPIKeyAuth", "http:MakeRequest":
+		out.Type = "n8n-nodes-base.httpRequest"
+		out.Parameters = makeHTTPParameters(mapper, template)
+		switch module.Module {
+		case "http:ActionSendDataBasicAuth":
+			out.Credentials = n8nAdaptedCredential("httpBasicAuth", n8nAdaptedID(module.Parameters["key"]), "HTTP basic auth "+n8nAdaptedID(module.Parameters["key"]))
+		case "http:ActionSendDataAPIKeyAuth":
+			out.Credentials = n8nAdaptedCredential("httpHeaderAuth", n8nAdaptedID(module.Parameters["key"]), "HTTP API key "+n8nAdaptedID(module.Parameters["key"]))
+		}
+		return out
+	case "builtin:BasicRouter":
+		out.Type = "n8n-nodes-base.noOp"
+		return out
+	case "util:SetVariable", "util:SetVariable2":
+		return setNode(false, []any{field(stringParam(mapper, "name"), template(stringParam(mapper, "value")))})
+	case "util:SetVariables":
+		var fields []any
+		for _, variable := range n8nParamList(mapper, "variables", "") {
+			fields = append(fields, field(stringParam(variable, "name"), template(stringParam(variable, "value"))))
+		}
+		return setNode(false, fields)
+	case "util:ComposeTransformer":
+		return setNode(true, []any{field("text", template(stringParam(mapper, "value")))})
+	case "util:Switcher":
+		if !boolParam(mapper, "useRegExpMatch") {
+			input := templateJS(stringParam(mapper, "input"))
+			value := templateJS(stringParam(mapper, "elseOutput"))
+			cases := n8nParamList(mapper, "casesTable", "")
+			for i := len(cases) - 1; i >= 0; i-- {
+				value = "(String(" + input + ") === " + templateJS(stringParam(cases[i], "pattern")) + " ? " + templateJS(stringParam(cases[i], "output")) + " : " + value + ")"
+			}
+			return setNode(true, []any{field("output", "={{ "+value+" }}")})
+		}
+	case "util:FunctionSleep":
+		out.Type = "n8n-nodes-base.wait"
+		out.Parameters = map[string]any{"amount": float64(intParam(mapper, 1, "duration")), "unit": "seconds"}
+		return out
+	}
+	out.Type = "make." + module.Module
+	out.Parameters = mapper
+	if conn := n8nAdaptedID(module.Parameters["__IMTCONN__"]); conn != "" {
+		out.Credentials = n8nAdaptedCredential(app, conn, n8nTitleWords(app)+" connection "+conn)
+	}
+	return out
+}
+
+func makeHTTPParameters(mapper map[string]any, template func(string) string) map[string]any {
+	out := map[string]any{
+		"method": strings.ToUpper(firstNonEmpty(stringParam(mapper, "method"), "get")),
+		"url":    template(stringParam(mapper, "url")),
+	}
+	pairs := func(key, nameKey string) map[string]string {
+		values := map[string]string{}
+		for _, item := range n8nParamList(mapper, key, "") {
+			if name := strings.TrimSpace(stringParam(item, nameKey)); name != "" {
+				values[name] = stringParam(item, "value")
+			}
+		}
+		return values
+	}
+	if headers := pairs("headers", "name"); len(headers) > 0 {
+		out["headerParameters"] = n8nAdaptedPairs(headers, template)
+	}
+	if query := pairs("qs", "name"); len(query) > 0 {
+		out["queryParameters"] = n8nAdaptedPairs(query, template)
+	}
+	if data := stringParam(mapper, "data", "body"); strings.TrimSpace(data) != "" {
+		out["body"] = template(data)
+	} else if fields := pairs("formFields", "key"); len(fields) > 0 {
+		out["bodyParameters"] = n8nAdaptedPairs(fields, template)
+	}
+	return out
+}
+
+// makeFilterOps maps Make filter operators to n8nAdaptedComparison operators.
+var makeFilterOps = map[string]string{
+	"exist":                 "exists",
+	"notexist":              "notexists",
+	"text:equal":            "equal",
+	"text:equal:ci":         "equalci",
+	"text:notequal":         "notequal",
+	"text:notequal:ci":      "notequalci",
+	"text:contain":          "contains",
+	"text:contain:ci":       "containsci",
+	"text:notcontain":       "notcontains",
+	"text:notcontain:ci":    "notcontainsci",
+	"text:startswith":       "startswith",
+	"text:endswith":         "endswith",
+	"number:equal":          "numequal",
+	"number:notequal":       "numnotequal",
+	"number:greater":        "gt",
+	"number:less":           "lt",
+	"number:greaterorequal": "gte",
+	"number:lessorequal":    "lte",
+	"boolean:equal":         "equal",
+	"boolean:notequal":      "notequal",
+}
+
+// makeFilterGroups translates filter conditions: OR groups of ANDed {a, o, b}
+// rules. It returns nil when any rule has no translation.
+func makeFilterGroups(filter *makeFilter, expr func(string) string) [][]string {
+	var out [][]string
+	for _, rules := range filter.Conditions {
+		var group []string
+		for _, rule := range rules {
+			op, ok := makeFilterOps[stringParam(rule, "o")]
+			if !ok {
+				return nil
+			}
+			condition, ok := n8nAdaptedComparison(n8nAdaptTemplateJS(stringParam(rule, "a"), expr), op, n8nAdaptTemplateJS(stringParam(rule, "b"), expr))
+			if !ok {
+				return nil
+			}
+			group = append(group, condition)
+		}
+		if len(group) > 0 {
+			out = append(out, group)
+		}
+	}
+	return out
+}
+
+var makeWeekdays = map[string]string{"1": "1", "2": "2", "3": "3", "4": "4", "5": "5", "6": "6", "7": "0"}
+
+// makeSchedulingCron returns the cron expression for a blueprint's
+// scheduling and a TODO when cron only approximates it; on-demand and
+// instant scenarios have none.
+func makeSchedulingCron(scheduling map[string]any) (string, string, bool) {
+	hour, minute := n8nAdaptedTimeOfDay(stringParam(scheduling, "time"))
+	at := strconv.Itoa(minute) + " " + strconv.Itoa(hour)
+	list := func(key string, mapping map[string]string) string {
+		raw, _ := scheduling[key].([]any)
+		var values []string
+		for _, value := range raw {
+			text := n8nAdaptedID(value)
+			if mapped, ok := mapping[text]; ok {
+				text = mapped
+			}
+			if mapping == nil || text != "" {
+				values = append(values, text)
+			}
+		}
+		return strings.Join(values, ",")
+	}
+	switch stringParam(scheduling, "type") {
+	case "indefinitely", "interval":
+		cron, todo := makeIntervalCron(intParam(scheduling, 900, "interval"))
+		return cron, todo, true
+	case "daily":
+		return at + " * * *", "", true
+	case "weekly":
+		return at + " * * " + firstNonEmpty(list("days", makeWeekdays), "1"), "", true
+	case "monthly":
+		return at + " " + firstNonEmpty(list("dates", nil), "1") + " * *", "", true
+	}
+	return "", "", false
+}
+
+// makeIntervalCron returns the cron expression for a run every seconds. A
+// cron step only repeats evenly when it divides the field above it, so other
+// intervals are clamped to the closest shorter step cron can express (at most
+// daily, at least every minute) and come back with a TODO.
+func makeIntervalCron(seconds int) (string, string) {
+	minutes := max(seconds/60, 1)
+	var cron string
+	var every int
+	switch {
+	case minutes >= 24*60:
+		cron, every = "0 0 * * *", 24*3600
+	case minutes >= 60:
+		hours := makeCronStep(24, minutes/60)
+		cron, every = "0 "+makeCronField(hours)+" * * *", hours*3600
+	default:
+		step := makeCronStep(60, minutes)
+		cron, every = makeCronField(step)+" * * * *", step*60
+	}
+	if every == seconds {
+		return cron, ""
+	}
+	return cron, fmt.Sprintf("Make scenario runs every %s; cron cannot express that interval, so the schedule runs every %s", time.Duration(seconds)*time.Second, time.Duration(every)*time.Second)
+}
+
+// makeCronStep returns the largest step up to limit that divides span.
+func makeCronStep(span, limit int) int {
+	for step := min(limit, span); step > 1; step-- {
+		if span%step == 0 {
+			return step
+		}
+	}
+	return 1
+}
+
+func makeCronField(step int) string {
+	if step == 1 {
+		return "*"
+	}
+	return "*/" + strconv.Itoa(step)
+}
+
+var makeCallRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)\(`)
+var makePathRe = regexp.MustCompile(`^(\d+)\.`)
+var makeNumberRe = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// makeExprJS translates the inside of a Make {{ }} mapping, such as
+// "1.items[1].sku" or "lower(2.name)", to JavaScript. Make arrays are
+// 1-based; function arguments are separated by ";" and bare words are text.
+func makeExprJS(inner string, names map[string]string) string {
+	term := strings.TrimSpace(inner)
+	switch {
+	case term == "":
+		return `""`
+	case term == "now":
+		return "$now"
+	case term == "emptystring":
+		return `""`
+	case term == "emptyarray":
+		return "[]"
+	case term == "true", term == "false", term == "null", makeNumberRe.MatchString(term):
+		return term
+	case strings.HasPrefix(term, `"`) && strings.HasSuffix(term, `"`) && len(term) > 1:
+		return term
+	}
+	if match := makeCallRe.FindStringSubmatch(term); match != nil && makeClosingParen(term, len(match[0])-1) == len(term)-1 {
+		var args []string
+		for _, arg := range makeSplitArgs(term[len(match[0]) : len(term)-1]) {
+			args = append(args, makeExprJS(arg, names))
+		}
+		return makeCallJS(match[1], args)
+	}
+	if match := makePathRe.FindStringSubmatch(term); match != nil {
+		if path, ok := makePath(term[len(match[0]):]); ok {
+			name, ok := names[match[1]]
+			if !ok {
+				name = "Make module " + match[1]
+			}
+			return n8nJSNodePath(name, path)
+		}
+	}
+	return n8nJSString(term)
+}
+
+// makeClosingParen returns the index of the parenthesis closing the one at
+// open, or -1.
+func makeClosingParen(value string, open int) int {
+	depth := 0
+	inString := false
+	for i := open; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func makeSplitArgs(value string) []string {
+	var args []string
+	depth, start := 0, 0
+	inString := false
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			args = append(args, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(args, value[start:])
+}
+
+// makePath splits "items[1].`first name`" into n8n path parts, converting
+// Make's 1-based indexes.
+func makePath(value string) ([]string, bool) {
+	var parts []string
+	for value != "" {
+		switch {
+		case value[0] == '`':
+			end := strings.IndexByte(value[1:], '`')
+			if end < 0 {
+				return nil, false
+			}
+			parts = append(parts, value[1:end+1])
+			value = value[end+2:]
+		case value[0] == '[':
+			end := strings.IndexByte(value, ']')
+			if end < 0 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(value[1:end]))
+			if err != nil || index < 1 {
+				return nil, false
+			}
+			parts = append(parts, strconv.Itoa(index-1))
+			value = value[end+1:]
+		case value[0] == '.':
+			value = value[1:]
+		default:
+			end := strings.IndexAny(value, ".[`")
+			if end < 0 {
+				end = len(value)
+			}
+			segment := value[:end]
+			if !makeSegmentRe.MatchString(segment) {
+				return nil, false
+			}
+			parts = append(parts, segment)
+			value = value[end:]
+		}
+	}
+	return parts, len(parts) > 0
+}
+
+var makeSegmentRe = regexp.MustCompile(`^[A-Za-z0-9_$-]+$`)
+
+// makeCallJS maps Make functions to the JavaScript the n8n expression
+// lowering understands. Unknown functions are kept as calls, which the
+// lowering reports as untranslated.
+func makeCallJS(name string, args []string) string {
+	get := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return `""`
+	}
+	switch name {
+	case "lower":
+		return "String(" + get(0) + ").toLowerCase()"
+	case "upper":
+		return "String(" + get(0) + ").toUpperCase()"
+	case "trim":
+		return "String(" + get(0) + ").trim()"
+	case "length":
+		return "(" + get(0) + ").length"
+	case "toString":
+		return "String(" + get(0) + ")"
+	case "parseNumber":
+		return "Number(" + get(0) + ")"
+	case "ifempty":
+		return "(" + get(0) + " || " + get(1) + ")"
+	case "if":
+		return "(" + get(0) + " ? " + get(1) + " : " + get(2) + ")"
+	case "replace":
+		return "String(" + get(0) + ").replaceAll(" + get(1) + ", " + get(2) + ")"
+	case "substring":
+		if len(args) > 2 {
+			return "String(" + get(0) + ").substring(" + get(1) + ", " + get(2) + ")"
+		}
+		return "String(" + get(0) + ").substring(" + get(1) + ")"
+	case "contains":
+		return "(" + get(0) + ").includes(" + get(1) + ")"
+	case "split":
+		return "String(" + get(0) + ").split(" + get(1) + ")"
+	case "join":
+		return "(" + get(0) + ").join(" + get(1) + ")"
+	case "round", "floor", "ceil":
+		return "Math." + name + "(" + get(0) + ")"
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
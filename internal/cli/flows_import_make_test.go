@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const makeOrderBlueprint = `{"name": "Order Router", "flow": [
+  {"id": 1, "module": "gateway:CustomWebHook", "parameters": {"hook": 55}},
+  {"id": 2, "module": "util:SetVariable2", "mapper": {"name": "email", "value": "{{lower(1.customer.email)}}"}},
+  {"id": 7, "module": "util:SetVariables", "mapper": {"variables": [{"name": "sku", "value": "{{ifempty(1.items[1].sku; unknown)}}"}]}},
+  {"id": 3, "module": "builtin:BasicRouter", "routes": [
+    {"flow": [
+      {"id": 4, "module": "http:ActionSendData", "filter": {"name": "Big orders", "conditions": [[{"a": "{{1.total}}", "o": "number:greater", "b": "100"}]]},
+       "mapper": {"url": "https://erp.example.com/orders/{{1.id}}", "method": "post", "data": "{\"sku\": \"{{7.sku}}\"}",
+                  "headers": [{"name": "X-Source", "value": "make"}]}},
+      {"id": 5, "module": "util:FunctionSleep", "mapper": {"duration": "30"}}]},
+    {"flow": [
+      {"id": 6, "module": "slack:CreateMessage", "parameters": {"__IMTCONN__": 812}, "metadata": {"designer": {"name": "Tell sales"}},
+       "filter": {"name": "Small orders", "conditions": [[{"a": "{{1.total}}", "o": "number:lessorequal", "b": "100"}]]},
+       "mapper": {"channel": "C1", "text": "{{formatNumber(1.total; 2)}}"}}]}]}]}`
+
+func TestAdaptMakeBlueprint_MapsModulesToN8NNodes(t *testing.T) {
+	tmp := t.TempDir()
+	input := filepath.Join(tmp, "blueprint.json")
+	if err := os.WriteFile(input, []byte(makeOrderBlueprint), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	wf, err := readMakeBlueprintFile(input)
+	if err != nil {
+		t.Fatalf("read blueprint: %v", err)
+	}
+	result, err := convertN8NWorkflowWithCredentials(wf, "order-router", filepath.Join(tmp, "out.clj"), nil)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	for _, want := range []string{
+		`:description "Imported from Make JSON.`,
+		`:webhook [{:id :webhook`,
+		`:email (clojure.string/lower-case (str (get-in input [:customer :email])))`,
+		`:sku (or (get-in (get input :webhook) [:items 0 :sku]) \"unknown\")`,
+		`(assoc input :branch (\u003e (Double/parseDouble (str (get (get input :webhook) :total))) 100))`,
+		`http_request (if (true? (:branch big_orders))`,
+		`:request {:path "/orders/{{webhook.id}}"`,
+		`:body "{\"sku\": \"{{set-variables.sku}}\"}"`,
+		`:timeout 30`,
+		`tell_sales (if (true? (:branch small_orders))`,
+		`{:slot :slack-connection-812`,
+		`Custom or unsupported Make module \"Tell sales\" (make.slack:CreateMessage)`,
+	} {
+		assertContains(t, result.EDN, want)
+	}
+	if result.Report.Fallback != 1 || len(result.Credentials) != 1 || result.Credentials[0].ID != "812" {
+		t.Fatalf("unexpected report %+v credentials %s", result.Report, mustJSON(t, result.Credentials))
+	}
+	if !result.Validation.BalancedDelimiters || !result.Validation.EDNReadable {
+		t.Fatalf("expected structural validation to pass: %+v", result.Validation)
+	}
+}
+
+func TestFlowsImportMakeCommand_SchedulingAndUntranslatedFunctions(t *testing.T) {
+	tmp := t.TempDir()
+	input := filepath.Join(tmp, "blueprint.json")
+	outPath := filepath.Join(tmp, "report.clj")
+	blueprint := `{"name": "Weekly Report", "scheduling": {"type": "weekly", "days": [1, 7], "time": "07:30"}, "flow": [
+	  {"id": 1, "module": "util:ComposeTransformer", "mapper": {"value": "Week of {{formatDate(now; YYYY-MM-DD)}}"}}]}`
+	if err := os.WriteFile(input, []byte(blueprint), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	cmd := newFlowsImportMakeCmd(&App{WorkspaceID: "ws-test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{input, "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute failed: %v\n%s", err, out.String())
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	assertContains(t, string(b), `:slug :weekly-report`)
+	assertContains(t, string(b), `"30 7 * * 1,0"`)
+	assertContains(t, out.String(), `formatDate() is not translated`)
+}
+
+func TestMakeSchedulingCron(t *testing.T) {
+	cases := []struct {
+		scheduling map[string]any
+		cron, todo string
+	}{
+		{map[string]any{"type": "indefinitely", "interval": 900.0}, "*/15 * * * *", ""},
+		{map[string]any{"type": "interval", "interval": 7200.0}, "0 */2 * * *", ""},
+		{map[string]any{"type": "interval", "interval": 86400.0}, "0 0 * * *", ""},
+		{map[string]any{"type": "interval", "interval": 172800.0}, "0 0 * * *", "runs every 48h0m0s; cron cannot express that interval, so the schedule runs every 24h0m0s"},
+		{map[string]any{"type": "interval", "interval": 5400.0}, "0 * * * *", "runs every 1h30m0s; cron cannot express that interval, so the schedule runs every 1h0m0s"},
+		{map[string]any{"type": "interval", "interval": 18000.0}, "0 */4 * * *", "runs every 5h0m0s"},
+		{map[string]any{"type": "interval", "interval": 420.0}, "*/6 * * * *", "runs every 7m0s"},
+		{map[string]any{"type": "interval", "interval": 30.0}, "* * * * *", "runs every 30s"},
+		{map[string]any{"type": "daily", "time": "12am"}, "0 0 * * *", ""},
+		{map[string]any{"type": "daily", "time": "12:15 pm"}, "15 12 * * *", ""},
+		{map[string]any{"type": "daily", "time": "9:30pm"}, "30 21 * * *", ""},
+	}
+	for _, tc := range cases {
+		cron, todo, ok := makeSchedulingCron(tc.scheduling)
+		if !ok || cron != tc.cron || (tc.todo == "") != (todo == "") || !strings.Contains(todo, tc.todo) {
+			t.Fatalf("makeSchedulingCron(%v) = %q, %q, %v; want %q with TODO %q", tc.scheduling, cron, todo, ok, tc.cron, tc.todo)
+		}
+	}
+}
+
+func TestFlowsImportMakeCommand_ReportsApproximatedInterval(t *testing.T) {
+	wf, err := adaptMakeBlueprint(makeBlueprint{
+		Name:       "Every Two Days",
+		Scheduling: map[string]any{"type": "interval", "interval": 172800.0},
+		Flow:       []makeModule{{ID: 1.0, Module: "util:SetVariable2"}},
+	})
+	if err != nil {
+		t.Fatalf("adapt: %v", err)
+	}
+	result, err := convertN8NWorkflow(wf, "every-two-days", "every-two-days.clj")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	assertContains(t, result.EDN, `:cron "0 0 * * *"`)
+	assertContains(t, strings.Join(result.Todos, "\n"), "schedule: Make scenario runs every 48h0m0s")
+}
+
+func TestMakeExprJS(t *testing.T) {
+	names := map[string]string{"1": "Webhook", "2": "Search"}
+	cases := map[string]string{
+		"1.id":                             `$node["Webhook"].json.id`,
+		"2.rows[2].`first name`":           `$node["Search"].json.rows[1]["first name"]`,
+		"upper(trim(1.name))":              `String(String($node["Webhook"].json.name).trim()).toUpperCase()`,
+		"if(1.vip; gold; silver)":          `($node["Webhook"].json.vip ? "gold" : "silver")`,
+		"replace(1.phone; -; emptystring)": `String($node["Webhook"].json.phone).replaceAll("-", "")`,
+		"9.id":                             `$node["Make module 9"].json.id`,
+		"42":                               "42",
+	}
+	for expr, want := range cases {
+		if got := makeExprJS(expr, names); got != want {
+			t.Fatalf("makeExprJS(%q) = %s, want %s", expr, got, want)
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,6 +24,11 @@ type n8nWorkflow struct {
 	Name        string                                  `json:"name"`
 	Nodes       []n8nNode                               `json:"nodes"`
 	Connections map[string]map[string][][]n8nConnection `json:"connections"`
+	Settings    map[string]any                          `json:"settings"`
+	PinData     map[string][]any                        `json:"pinData"`
+	// Source names the tool the workflow was exported from when an importer
+	// adapted it to this shape; empty means n8n.
+	Source string `json:"-"`
 }
 
 type n8nNode struct {
@@ -30,6 +37,13 @@ type n8nNode struct {
 	Type        string         `json:"type"`
 	Parameters  map[string]any `json:"parameters"`
 	Credentials map[string]any `json:"credentials"`
+	// Credential is the :requires slot assigned to the node's credential.
+	Credential *n8nCredentialSlot `json:"-"`
+	// Todos are notes an importer raised while adapting the node to n8n; the
+	// converter reports them with its own.
+	Todos []string `json:"-"`
+	// ChildFlow is the slug of the child flow a loop node's body was moved to.
+	ChildFlow string `json:"-"`
 }
 
 type n8nConnection struct {
@@ -52,6 +66,7 @@ type n8nConvertedNode struct {
 	InputExpr string
 	Binding   string
 	Todos     []string
+	Converter string
 }
 
 type n8nBranchGuard struct {
@@ -74,6 +89,43 @@ type n8nImportResult struct {
 	Todos      []string
 	EDN        string
 	Validation n8nFlowValidation
+	Report     n8nImportReport
+	// Credentials lists one slot per distinct n8n credential. BindingsProfile
+	// is set when a credentials map was given.
+	Credentials     []*n8nCredentialSlot
+	UnusedMappings  []string
+	BindingsProfile string
+	// PinnedCases are step tests and examples built from the export's pinData.
+	// TestsPath is the flows test suite written from the cases with an input.
+	PinnedCases []n8nPinnedCase
+	TestsPath   string
+	// Children are the child flows generated for loop bodies, written next to
+	// OutputPath.
+	Children []*n8nImportResult
+}
+
+// n8nImportReport counts how the import handled each node. Fallback nodes
+// were kept as TODO function steps; Types lists every node type with the
+// converter that handled it.
+type n8nImportReport struct {
+	Nodes         int                 `json:"nodes"`
+	Triggers      int                 `json:"triggers"`
+	Converted     int                 `json:"converted"`
+	Fallback      int                 `json:"fallback"`
+	WithTodos     int                 `json:"withTodos"`
+	Types         []n8nImportTypeStat `json:"types"`
+	FallbackNodes []n8nImportNodeRef  `json:"fallbackNodes,omitempty"`
+}
+
+type n8nImportTypeStat struct {
+	Type      string `json:"type"`
+	Converter string `json:"converter"`
+	Count     int    `json:"count"`
+}
+
+type n8nImportNodeRef struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 type n8nServerValidationResult struct {
@@ -120,6 +172,8 @@ func newFlowsImportCmd(app *App) *cobra.Command {
 		Short: "Import external workflow definitions as Breyta flow files",
 	}
 	cmd.AddCommand(newFlowsImportN8NCmd(app))
+	cmd.AddCommand(newFlowsImportZapierCmd(app))
+	cmd.AddCommand(newFlowsImportMakeCmd(app))
 	return cmd
 }
 
@@ -128,9 +182,14 @@ func newFlowsImportN8NCmd(app *App) *cobra.Command {
 	var outPath string
 	var serverValidate bool
 	var deployKey string
+	var credentialsMapPath string
+	var bindingsOut string
+	var dir string
+	var reportPath string
+	var jobs int
 
 	cmd := &cobra.Command{
-		Use:   "n8n <workflow.json>",
+		Use:   "n8n <workflow.json> | --dir <exports>",
 		Short: "Convert an n8n workflow JSON export to a Breyta flow file",
 		Long: strings.TrimSpace(`
 Convert an n8n workflow JSON export to a best-effort Breyta EDN flow file.
@@ -143,45 +202,160 @@ values from credentials. The output is intended for the normal authoring loop:
   breyta flows push --file ./tmp/flows/imported-flow.clj
   breyta flows configure check imported-flow
   breyta flows run imported-flow --target draft --invocation default --input '{}' --wait
+
+Each distinct n8n credential becomes one :requires connection slot. Pass
+--credentials-map with a JSON object keyed by n8n credential ID or name to bind
+those slots to existing connections (see breyta connections list); the import
+then writes a bindings profile for breyta flows bindings apply:
+
+  {"Stripe account": "conn-123",
+   "Slack bot": {"connection": "conn-456", "slot": "slack", "type": "http-api"}}
+
+Nodes with pinData in the export get pinnedCases: a steps tests add command
+(the upstream node's pinned output as input, the node's own as expected) and a
+steps examples add command to run after flows push. Cases with a pinned input
+are also written as a flows test suite next to the flow (<slug>.tests.edn), so
+breyta flows test <slug> runs them against the local source.
+
+With --dir, every *.json export under the directory is converted concurrently
+into --out (default ./tmp/flows). Slugs that collide get a numeric suffix, and
+a migration report is written as JSON and Markdown next to the flows: status
+per workflow, TODO counts by category, unsupported node types by frequency,
+and the credential slots to bind.
+
+  breyta flows import n8n --dir exports/ --out flows/ --credentials-map creds.json
 `),
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			result, err := importN8NWorkflowFile(args[0], slug, outPath)
-			if err != nil {
-				return writeFailure(cmd, app, "n8n_import_failed", err, "Check that the input is an n8n workflow JSON export.", map[string]any{"path": args[0]})
-			}
-			data := map[string]any{
-				"slug":         result.Slug,
-				"name":         result.Name,
-				"path":         result.OutputPath,
-				"todoCount":    len(result.Todos),
-				"todos":        result.Todos,
-				"validation":   result.Validation,
-				"pushCommand":  fmt.Sprintf("breyta flows push --file %s", shellQuotePath(result.OutputPath)),
-				"checkCommand": fmt.Sprintf("breyta flows configure check %s", result.Slug),
-				"runCommand":   fmt.Sprintf("breyta flows run %s --target draft --invocation default --input '{}' --wait", result.Slug),
+			if strings.TrimSpace(dir) != "" {
+				if len(args) > 0 {
+					return writeErr(cmd, errors.New("--dir cannot be combined with a workflow file argument"))
+				}
+				if strings.TrimSpace(slug) != "" || strings.TrimSpace(bindingsOut) != "" {
+					return writeErr(cmd, errors.New("--slug and --bindings-out are not supported with --dir"))
+				}
+			} else if len(args) == 0 {
+				return writeErr(cmd, errors.New("pass an n8n workflow JSON file or --dir <exports>"))
 			}
-			if serverValidate {
-				serverResult, err := validateImportedN8NFlowOnServer(app, result, deployKey)
+			var credentials map[string]n8nCredentialMapping
+			if strings.TrimSpace(credentialsMapPath) != "" {
+				loaded, err := loadN8NCredentialsMap(credentialsMapPath)
 				if err != nil {
-					return writeFailure(cmd, app, "n8n_server_validation_failed", err, "Check --api/--workspace/--token and inspect the generated flow file.", map[string]any{
-						"path": result.OutputPath,
-						"slug": result.Slug,
-					})
+					return writeFailure(cmd, app, "n8n_credentials_map_invalid", err, `Use a JSON object keyed by n8n credential ID or name, e.g. {"Stripe account": "conn-123"}.`, map[string]any{"path": credentialsMapPath})
 				}
-				data["serverValidation"] = serverResult
+				credentials = loaded
+			}
+			if strings.TrimSpace(dir) != "" {
+				return runN8NBatchImport(cmd, app, n8nBatchOptions{
+					dir:            dir,
+					out:            outPath,
+					report:         reportPath,
+					jobs:           jobs,
+					credentials:    credentials,
+					serverValidate: serverValidate,
+					deployKey:      deployKey,
+				})
+			}
+			result, err := importN8NWorkflowFile(args[0], slug, outPath, credentials)
+			if err != nil {
+				return writeFailure(cmd, app, "n8n_import_failed", err, "Check that the input is an n8n workflow JSON export.", map[string]any{"path": args[0]})
 			}
-			return writeData(cmd, app, nil, data)
+			return writeN8NImportResult(cmd, app, result, bindingsOut, serverValidate, deployKey)
 		},
 	}
 
 	cmd.Flags().StringVar(&slug, "slug", "", "Breyta flow slug (defaults to normalized workflow name)")
-	cmd.Flags().StringVar(&outPath, "out", "", "Output flow file (defaults to ./tmp/flows/<slug>.clj)")
+	cmd.Flags().StringVar(&outPath, "out", "", "Output flow file (defaults to ./tmp/flows/<slug>.clj); with --dir, the output directory")
 	cmd.Flags().BoolVar(&serverValidate, "server-validate", false, "Push generated flow to the configured Breyta API draft and run flows.validate")
 	cmd.Flags().StringVar(&deployKey, "deploy-key", "", "Deploy key for guarded flows when using --server-validate (default: BREYTA_FLOW_DEPLOY_KEY)")
+	cmd.Flags().StringVar(&credentialsMapPath, "credentials-map", "", "JSON file mapping n8n credential IDs or names to Breyta connection IDs")
+	cmd.Flags().StringVar(&bindingsOut, "bindings-out", "", "Bindings profile path when using --credentials-map (defaults to <out>.bindings.edn)")
+	cmd.Flags().StringVar(&dir, "dir", "", "Convert every n8n workflow JSON export under this directory")
+	cmd.Flags().StringVar(&reportPath, "report", "", "With --dir, migration report path without extension (defaults to <out>/n8n-migration-report)")
+	cmd.Flags().IntVar(&jobs, "jobs", runtime.GOMAXPROCS(0), "With --dir, number of workflows to convert concurrently")
 	return cmd
 }
 
+// writeN8NImportResult writes the bindings profile, optionally pushes and
+// validates the flow on the server, and prints the import summary.
+func writeN8NImportResult(cmd *cobra.Command, app *App, result *n8nImportResult, bindingsOut string, serverValidate bool, deployKey string) error {
+	data := map[string]any{
+		"slug":         result.Slug,
+		"name":         result.Name,
+		"path":         result.OutputPath,
+		"todoCount":    len(result.Todos),
+		"todos":        result.Todos,
+		"validation":   result.Validation,
+		"report":       result.Report,
+		"pushCommand":  fmt.Sprintf("breyta flows push --file %s", shellQuotePath(result.OutputPath)),
+		"checkCommand": fmt.Sprintf("breyta flows configure check %s", result.Slug),
+		"runCommand":   fmt.Sprintf("breyta flows run %s --target draft --invocation default --input '{}' --wait", result.Slug),
+		"credentials":  result.Credentials,
+	}
+	if len(result.PinnedCases) > 0 {
+		data["pinnedCases"] = result.PinnedCases
+	}
+	if result.TestsPath != "" {
+		data["testsPath"] = result.TestsPath
+		data["testCommand"] = fmt.Sprintf("breyta flows test %s", result.Slug)
+	}
+	if result.BindingsProfile != "" {
+		if strings.TrimSpace(bindingsOut) == "" {
+			bindingsOut = n8nDefaultBindingsPath(result.OutputPath)
+		}
+		if err := atomicWriteFile(bindingsOut, []byte(result.BindingsProfile), 0o644); err != nil {
+			return writeFailure(cmd, app, "n8n_import_failed", err, "Check that the bindings profile path is writable.", map[string]any{"path": bindingsOut})
+		}
+		data["bindingsProfile"] = bindingsOut
+		data["bindingsCommand"] = fmt.Sprintf("breyta flows bindings apply %s @%s", result.Slug, shellQuotePath(bindingsOut))
+		if len(result.UnusedMappings) > 0 {
+			data["unusedMappings"] = result.UnusedMappings
+		}
+	}
+	childFlows := make([]map[string]any, 0, len(result.Children))
+	for _, child := range result.Children {
+		entry := map[string]any{
+			"slug":        child.Slug,
+			"path":        child.OutputPath,
+			"pushCommand": fmt.Sprintf("breyta flows push --file %s", shellQuotePath(child.OutputPath)),
+		}
+		if child.TestsPath != "" {
+			entry["testsPath"] = child.TestsPath
+			entry["testCommand"] = fmt.Sprintf("breyta flows test %s", child.Slug)
+		}
+		if child.BindingsProfile != "" {
+			bindingsPath := n8nDefaultBindingsPath(child.OutputPath)
+			entry["bindingsProfile"] = bindingsPath
+			entry["bindingsCommand"] = fmt.Sprintf("breyta flows bindings apply %s @%s", child.Slug, shellQuotePath(bindingsPath))
+		}
+		if serverValidate {
+			serverResult, err := validateImportedN8NFlowOnServer(app, child, deployKey)
+			if err != nil {
+				return writeFailure(cmd, app, "n8n_server_validation_failed", err, "Check --api/--workspace/--token and inspect the generated child flow file.", map[string]any{
+					"path": child.OutputPath,
+					"slug": child.Slug,
+				})
+			}
+			entry["serverValidation"] = serverResult
+		}
+		childFlows = append(childFlows, entry)
+	}
+	if len(childFlows) > 0 {
+		data["childFlows"] = childFlows
+	}
+	if serverValidate {
+		serverResult, err := validateImportedN8NFlowOnServer(app, result, deployKey)
+		if err != nil {
+			return writeFailure(cmd, app, "n8n_server_validation_failed", err, "Check --api/--workspace/--token and inspect the generated flow file.", map[string]any{
+				"path": result.OutputPath,
+				"slug": result.Slug,
+			})
+		}
+		data["serverValidation"] = serverResult
+	}
+	return writeData(cmd, app, nil, data)
+}
+
 func validateImportedN8NFlowOnServer(app *App, result *n8nImportResult, deployKey string) (*n8nServerValidationResult, error) {
 	if !isAPIMode(app) {
 		return nil, errors.New("--server-validate requires --api/BREYTA_API_URL")
@@ -245,57 +419,131 @@ func apiEnvelopeError(command string, status int, envelope map[string]any) error
 	return fmt.Errorf("%s failed (status=%d)", command, status)
 }
 
-func importN8NWorkflowFile(path, slug, outPath string) (*n8nImportResult, error) {
-	b, err := readExplicitFile(path)
+func importN8NWorkflowFile(path, slug, outPath string, credentials map[string]n8nCredentialMapping) (*n8nImportResult, error) {
+	wf, err := readN8NWorkflowFile(path)
 	if err != nil {
 		return nil, err
 	}
-	var wf n8nWorkflow
-	if err := json.Unmarshal(b, &wf); err != nil {
-		return nil, err
-	}
-	if len(wf.Nodes) == 0 {
-		return nil, errors.New("n8n workflow has no nodes")
-	}
+	return importN8NWorkflow(wf, path, slug, outPath, credentials)
+}
+
+// importN8NWorkflow converts wf, read from path, and writes the flow file.
+// The Zapier and Make importers adapt their exports to n8nWorkflow and
+// import through here.
+func importN8NWorkflow(wf n8nWorkflow, path, slug, outPath string, credentials map[string]n8nCredentialMapping) (*n8nImportResult, error) {
 	if strings.TrimSpace(slug) == "" {
-		slug = n8nKebab(firstNonEmpty(wf.Name, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))), "flow")
+		slug = n8nWorkflowSlug(wf, path)
 	} else {
 		slug = n8nKebab(slug, "flow")
 	}
 	if strings.TrimSpace(outPath) == "" {
 		outPath = filepath.Join("tmp", "flows", slug+".clj")
 	}
-	result, err := convertN8NWorkflow(wf, slug, outPath)
+	result, err := convertN8NWorkflowWithCredentials(wf, slug, outPath, credentials)
 	if err != nil {
 		return nil, err
 	}
 	if err := atomicWriteFile(outPath, []byte(result.EDN), 0o644); err != nil {
 		return nil, err
 	}
+	if err := writeN8NChildFlows(result); err != nil {
+		return nil, err
+	}
+	if err := writeN8NPinnedTestSuites(result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// writeN8NChildFlows writes the loop body flows generated for result, each
+// with its bindings profile when a credentials map was given.
+func writeN8NChildFlows(result *n8nImportResult) error {
+	for _, child := range result.Children {
+		if err := atomicWriteFile(child.OutputPath, []byte(child.EDN), 0o644); err != nil {
+			return err
+		}
+		if child.BindingsProfile != "" {
+			if err := atomicWriteFile(n8nDefaultBindingsPath(child.OutputPath), []byte(child.BindingsProfile), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func n8nDefaultBindingsPath(flowPath string) string {
+	return strings.TrimSuffix(flowPath, filepath.Ext(flowPath)) + ".bindings.edn"
+}
+
+func readN8NWorkflowFile(path string) (n8nWorkflow, error) {
+	var wf n8nWorkflow
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return wf, err
+	}
+	if err := json.Unmarshal(b, &wf); err != nil {
+		return wf, err
+	}
+	if len(wf.Nodes) == 0 {
+		return wf, errors.New("n8n workflow has no nodes")
+	}
+	return wf, nil
+}
+
+// n8nWorkflowSlug derives a flow slug from the workflow name, falling back to
+// the export's file name.
+func n8nWorkflowSlug(wf n8nWorkflow, path string) string {
+	return n8nKebab(firstNonEmpty(wf.Name, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))), "flow")
+}
+
 func convertN8NWorkflow(wf n8nWorkflow, slug, outPath string) (*n8nImportResult, error) {
-	edges := n8nEdges(wf)
+	return convertN8NWorkflowWithCredentials(wf, slug, outPath, nil)
+}
+
+// convertN8NWorkflowWithCredentials converts wf, binding its credentials to
+// connections through mappings (nil when no credentials map was given).
+func convertN8NWorkflowWithCredentials(wf n8nWorkflow, slug, outPath string, mappings map[string]n8nCredentialMapping) (*n8nImportResult, error) {
+	wf.Nodes = append([]n8nNode(nil), wf.Nodes...)
+	wf, loopChildren := n8nSplitLoopBodies(wf, slug)
+	children := make([]*n8nImportResult, 0, len(loopChildren))
+	for _, child := range loopChildren {
+		childResult, err := convertN8NWorkflowWithCredentials(child.Workflow, child.Slug, filepath.Join(filepath.Dir(outPath), child.Slug+".clj"), mappings)
+		if err != nil {
+			return nil, fmt.Errorf("convert loop body flow %s: %w", child.Slug, err)
+		}
+		children = append(children, childResult)
+	}
+	credentialSlots, unusedMappings := n8nCredentialSlots(wf.Nodes, mappings)
+	edges := n8nWithoutLoopBackEdges(wf.Nodes, n8nEdges(wf))
 	ordered := n8nTopologicalOrder(wf.Nodes, edges)
+	timezone := firstNonEmpty(stringParam(wf.Settings, "timezone"), "UTC")
 	usedIDs := map[string]bool{}
 	usedVars := map[string]bool{}
-	requires := make([]string, 0)
+	requires := make([]string, 0, len(credentialSlots))
+	for _, slot := range credentialSlots {
+		requires = append(requires, renderN8NCredentialRequire(slot))
+	}
 	templates := make([]string, 0)
 	functions := make([]string, 0)
 	schedules := make([]string, 0)
 	webhooks := make([]string, 0)
 	todos := make([]string, 0)
 	converted := make([]n8nConvertedNode, 0)
+	triggers := make([]n8nConvertedNode, 0)
 	convertedByName := map[string]n8nConvertedNode{}
 	upstreams := n8nUpstreamsByTarget(edges)
 
 	for _, node := range ordered {
 		if n8nIsTrigger(node) {
-			triggerRequires, triggerWebhooks, triggerSchedules := convertN8NTrigger(node, usedIDs)
+			triggerRequires, triggerWebhooks, triggerSchedules, triggerTodos := convertN8NTrigger(node, usedIDs, timezone)
+			triggerTodos = append(triggerTodos, node.Todos...)
 			requires = appendUniqueStrings(requires, triggerRequires)
 			webhooks = append(webhooks, triggerWebhooks...)
 			schedules = append(schedules, triggerSchedules...)
+			for _, todo := range triggerTodos {
+				todos = append(todos, n8nKebab(node.Name, "trigger")+": "+todo)
+			}
+			triggers = append(triggers, n8nConvertedNode{Node: node, Todos: triggerTodos, Converter: "trigger"})
 			if strings.TrimSpace(node.Name) != "" {
 				convertedByName[node.Name] = n8nConvertedNode{Node: node, StepID: n8nKebab(node.Name, "input"), VarName: "input", InputExpr: "input"}
 			}
@@ -304,38 +552,152 @@ func convertN8NWorkflow(wf n8nWorkflow, slug, outPath string) (*n8nImportResult,
 		stepID := uniqueN8NID(n8nKebab(node.Name, "step"), usedIDs)
 		varName := uniqueN8NID(strings.ReplaceAll(stepID, "-", "_"), usedVars)
 		inputPlan := n8nBuildInputPlan(upstreams[node.Name], n8nReferencedNodeNames(node.Parameters), convertedByName)
-		binding, nodeRequires, nodeTemplates, nodeFunctions, nodeTodos := convertN8NNode(node, stepID, inputPlan, convertedByName)
+		converterName, converter := n8nNodeConverter(node)
+		binding, nodeRequires, nodeTemplates, nodeFunctions, nodeTodos := converter(node, stepID, inputPlan, convertedByName)
+		if n8nIsFallbackConversion(nodeTodos) {
+			converterName = "fallback"
+		}
+		nodeTodos = append(nodeTodos, node.Todos...)
 		requires = appendUniqueStrings(requires, nodeRequires)
 		templates = append(templates, nodeTemplates...)
 		functions = append(functions, nodeFunctions...)
 		for _, todo := range nodeTodos {
 			todos = append(todos, stepID+": "+todo)
 		}
-		item := n8nConvertedNode{Node: node, StepID: stepID, VarName: varName, InputExpr: inputPlan.Expr, Binding: binding, Todos: nodeTodos}
+		item := n8nConvertedNode{Node: node, StepID: stepID, VarName: varName, InputExpr: inputPlan.Expr, Binding: binding, Todos: nodeTodos, Converter: converterName}
 		converted = append(converted, item)
 		if strings.TrimSpace(node.Name) != "" {
 			convertedByName[node.Name] = item
 		}
 	}
 
-	name := firstNonEmpty(wf.Name, "Imported n8n Flow")
+	source := firstNonEmpty(wf.Source, "n8n")
+	name := firstNonEmpty(wf.Name, "Imported "+source+" Flow")
 	body := renderN8NFlowBody(converted, edges, upstreams, convertedByName, n8nBranchGuards(edges, convertedByName))
-	edn := renderN8NFlowEDN(slug, name, requires, templates, functions, webhooks, schedules, body)
+	edn := renderN8NFlowEDN(slug, name, source, requires, templates, functions, webhooks, schedules, body)
 	validation, err := validateGeneratedN8NFlowEDN(edn)
 	if err != nil {
 		return nil, err
 	}
-	return &n8nImportResult{
-		Slug:       slug,
-		Name:       name,
-		OutputPath: outPath,
-		Todos:      todos,
-		EDN:        edn,
-		Validation: validation,
-	}, nil
+	result := &n8nImportResult{
+		Slug:           slug,
+		Name:           name,
+		OutputPath:     outPath,
+		Todos:          todos,
+		EDN:            edn,
+		Validation:     validation,
+		Report:         buildN8NImportReport(triggers, converted),
+		Credentials:    credentialSlots,
+		UnusedMappings: unusedMappings,
+		PinnedCases:    buildN8NPinnedCases(slug, wf.PinData, converted, upstreams),
+	}
+	for _, child := range children {
+		result.Todos = append(result.Todos, prefixStrings(child.Todos, child.Slug+"/")...)
+		result.Report = mergeN8NImportReports(result.Report, child.Report)
+		result.UnusedMappings = intersectStrings(result.UnusedMappings, child.UnusedMappings)
+		result.Children = append(result.Children, child)
+		result.Children = append(result.Children, child.Children...)
+		child.Children = nil
+	}
+	if mappings != nil {
+		profile, err := renderN8NBindingsProfile(slug, credentialSlots)
+		if err != nil {
+			return nil, err
+		}
+		result.BindingsProfile = profile
+	}
+	return result, nil
+}
+
+func buildN8NImportReport(triggers, converted []n8nConvertedNode) n8nImportReport {
+	report := n8nImportReport{Types: []n8nImportTypeStat{}}
+	stats := map[[2]string]int{}
+	for _, item := range append(append([]n8nConvertedNode{}, triggers...), converted...) {
+		report.Nodes++
+		switch item.Converter {
+		case "trigger":
+			report.Triggers++
+		case "fallback":
+			report.Fallback++
+			report.FallbackNodes = append(report.FallbackNodes, n8nImportNodeRef{Name: item.Node.Name, Type: item.Node.Type})
+		default:
+			report.Converted++
+		}
+		if len(item.Todos) > 0 {
+			report.WithTodos++
+		}
+		stats[[2]string{item.Node.Type, item.Converter}]++
+	}
+	for key, count := range stats {
+		report.Types = append(report.Types, n8nImportTypeStat{Type: key[0], Converter: key[1], Count: count})
+	}
+	sortN8NImportTypeStats(report.Types)
+	return report
+}
+
+func sortN8NImportTypeStats(types []n8nImportTypeStat) {
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].Count != types[j].Count {
+			return types[i].Count > types[j].Count
+		}
+		return types[i].Type < types[j].Type
+	})
+}
+
+// mergeN8NImportReports adds a child flow's report to its parent's so the
+// totals cover every node of the export.
+func mergeN8NImportReports(parent, child n8nImportReport) n8nImportReport {
+	parent.Nodes += child.Nodes
+	parent.Triggers += child.Triggers
+	parent.Converted += child.Converted
+	parent.Fallback += child.Fallback
+	parent.WithTodos += child.WithTodos
+	parent.FallbackNodes = append(parent.FallbackNodes, child.FallbackNodes...)
+	for _, stat := range child.Types {
+		found := false
+		for i := range parent.Types {
+			if parent.Types[i].Type == stat.Type && parent.Types[i].Converter == stat.Converter {
+				parent.Types[i].Count += stat.Count
+				found = true
+				break
+			}
+		}
+		if !found {
+			parent.Types = append(parent.Types, stat)
+		}
+	}
+	sortN8NImportTypeStats(parent.Types)
+	return parent
+}
+
+func prefixStrings(values []string, prefix string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		out = append(out, prefix+value)
+	}
+	return out
 }
 
-func convertN8NTrigger(node n8nNode, usedIDs map[string]bool) ([]string, []string, []string) {
+// intersectStrings keeps the values of left that are also in right.
+func intersectStrings(left, right []string) []string {
+	out := make([]string, 0, len(left))
+	for _, value := range left {
+		if slices.Contains(right, value) {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+func quoteStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		out = append(out, strconv.Quote(value))
+	}
+	return out
+}
+
+func convertN8NTrigger(node n8nNode, usedIDs map[string]bool, defaultTimezone string) ([]string, []string, []string, []string) {
 	typ := strings.ToLower(node.Type)
 	switch {
 	case strings.Contains(typ, "webhook"):
@@ -347,51 +709,81 @@ func convertN8NTrigger(node n8nNode, usedIDs map[string]bool) ([]string, []strin
    :invocation :default
    :enabled true
    :event-name %s
-   :auth {:type :api-key :secret-ref :webhook-secret}}`, id, ednQuote(strings.ReplaceAll(id, "-", ".")))}, nil
+   :auth {:type :api-key :secret-ref :webhook-secret}}`, id, ednQuote(strings.ReplaceAll(id, "-", ".")))}, nil, nil
 	case strings.Contains(typ, "cron"), strings.Contains(typ, "schedule"), strings.Contains(typ, "interval"):
-		id := uniqueN8NID(n8nKebab(firstNonEmpty(node.Name, "schedule"), "schedule"), usedIDs)
-		cron := stringParam(node.Parameters, "cronExpression", "expression")
-		if cron == "" {
-			cron = "0 * * * *"
-		}
-		timezone := stringParam(node.Parameters, "timezone")
-		if timezone == "" {
-			timezone = "UTC"
-		}
-		return nil, nil, []string{fmt.Sprintf(`{:id :%s
+		crons, todos := n8nScheduleCrons(node)
+		timezone := firstNonEmpty(stringParam(node.Parameters, "timezone"), defaultTimezone, "UTC")
+		schedules := make([]string, 0, len(crons))
+		for _, cron := range crons {
+			id := uniqueN8NID(n8nKebab(firstNonEmpty(node.Name, "schedule"), "schedule"), usedIDs)
+			schedules = append(schedules, fmt.Sprintf(`{:id :%s
   :label %s
   :invocation :default
   :enabled true
   :cron %s
-  :timezone %s}`, id, ednQuote(firstNonEmpty(node.Name, "Schedule")), ednQuote(cron), ednQuote(timezone))}
+  :timezone %s}`, id, ednQuote(firstNonEmpty(node.Name, "Schedule")), ednQuote(cron), ednQuote(timezone)))
+		}
+		return nil, nil, schedules, todos
 	default:
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 }
 
 func convertN8NNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	_, converter := n8nNodeConverter(node)
+	return converter(node, stepID, inputPlan, convertedByName)
+}
+
+// n8nNodeConverter picks the converter for a node and names it for the
+// import report.
+func n8nNodeConverter(node n8nNode) (string, n8nNodeConverterFunc) {
+	if n8nIsForeignNode(node) {
+		return "fallback", convertN8NFallbackNode
+	}
+	switch n8nNodeKind(node) {
+	case "splitinbatches":
+		return "loop", convertN8NLoopNode
+	case "executeworkflow":
+		return "execute-workflow", convertN8NExecuteWorkflowNode
+	case "filter":
+		return "filter", convertN8NFilterNode
+	case "sort":
+		return "sort", convertN8NSortNode
+	case "limit":
+		return "limit", convertN8NLimitNode
+	case "removeduplicates":
+		return "remove-duplicates", convertN8NRemoveDuplicatesNode
+	case "aggregate":
+		return "aggregate", convertN8NAggregateNode
+	case "datetime":
+		return "date-time", convertN8NDateTimeNode
+	case "crypto":
+		return "crypto", convertN8NCryptoNode
+	case "noop":
+		return "no-op", convertN8NNoOpNode
+	}
 	typ := strings.ToLower(node.Type)
 	switch {
 	case strings.Contains(typ, "httprequest"):
-		return convertN8NHTTPNode(node, stepID, inputPlan, convertedByName)
+		return "http", convertN8NHTTPNode
 	case strings.HasSuffix(typ, ".if") || strings.Contains(typ, ".switch"):
-		return convertN8NBranchNode(node, stepID, inputPlan, convertedByName)
+		return "branch", convertN8NBranchNode
 	case strings.Contains(typ, "webhookresponse") || strings.Contains(typ, "respondtowebhook"):
-		return convertN8NWebhookResponseNode(node, stepID, inputPlan, convertedByName)
+		return "webhook-response", convertN8NWebhookResponseNode
 	case strings.HasSuffix(typ, ".wait") || strings.Contains(typ, ".wait"):
-		return convertN8NWaitNode(node, stepID, inputPlan, convertedByName)
+		return "wait", convertN8NWaitNode
 	case strings.HasSuffix(typ, ".set") || strings.Contains(typ, "set"):
-		return convertN8NSetNode(node, stepID, inputPlan, convertedByName)
+		return "set", convertN8NSetNode
 	case strings.Contains(typ, "itemlists"):
-		return convertN8NItemListsNode(node, stepID, inputPlan, convertedByName)
+		return "item-lists", convertN8NItemListsNode
 	case strings.Contains(typ, "htmlextract"):
-		return convertN8NHTMLExtractNode(node, stepID, inputPlan, convertedByName)
+		return "html-extract", convertN8NHTMLExtractNode
 	case strings.Contains(typ, "code") || strings.Contains(typ, "function"):
-		return convertN8NCodeNode(node, stepID, inputPlan, convertedByName)
+		return "code", convertN8NCodeNode
 	case strings.Contains(typ, "merge"):
-		return convertN8NMergeNode(node, stepID, inputPlan, convertedByName)
+		return "merge", convertN8NMergeNode
 	default:
-		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+		return "fallback", convertN8NFallbackNode
 	}
 }
 
@@ -412,16 +804,22 @@ func convertN8NHTTPNode(node n8nNode, stepID string, inputPlan n8nInputPlan, con
 	if rendered, ok := translateN8NHandlebarsTemplate(path, templateRefs); ok {
 		path = rendered
 	}
-	slot := n8nKebab(firstNonEmpty(n8nCredentialLabel(node), node.Name, "api"), "api")
-	auth := ":none"
-	if n8nCredentialLabel(node) != "" {
-		auth = ":api-key"
-	}
-	require := fmt.Sprintf(`{:slot :%s
+	headers, inlineAuth, inlineAuthHeaders := n8nExtractAuthHeaders(n8nParameterPairs(node.Parameters, "headers", "headerParameters", "headerParameter"), node.Credential != nil)
+	var requires []string
+	slot := ""
+	if node.Credential != nil {
+		slot = node.Credential.Slot
+	} else {
+		slot = n8nKebab(firstNonEmpty(node.Name, "api"), "api")
+		requires = []string{fmt.Sprintf(`{:slot :%s
   :type :http-api
   :label %s
   :base-url %s
-  :auth {:type %s}}`, slot, ednQuote(firstNonEmpty(n8nCredentialLabel(node), node.Name, "Imported API")), ednQuote(baseURL), auth)
+  :auth %s}`, slot, ednQuote(firstNonEmpty(node.Name, "Imported API")), ednQuote(baseURL), firstNonEmpty(inlineAuth, "{:type :none}"))}
+		if len(inlineAuthHeaders) > 0 {
+			todos = append(todos, fmt.Sprintf("bind the %s header value through slot :%s; the inline value was not copied", strings.Join(inlineAuthHeaders, ", "), slot))
+		}
+	}
 
 	requestParts := []string{":path " + ednQuote(firstNonEmpty(path, "/")), ":method :" + method}
 	if queryParams := n8nParameterPairs(node.Parameters, "queryParameters", "queryParameter"); len(queryParams) > 0 {
@@ -435,12 +833,22 @@ func convertN8NHTTPNode(node n8nNode, stepID string, inputPlan n8nInputPlan, con
 	if len(query) > 0 {
 		requestParts = append(requestParts, ":query "+renderStringMap(query))
 	}
-	if headers := n8nParameterPairs(node.Parameters, "headers", "headerParameters", "headerParameter"); len(headers) > 0 {
+	if len(headers) > 0 {
 		requestParts = append(requestParts, ":headers "+renderStringMap(headers))
 	}
 	if body, ok := firstParam(node.Parameters, "body", "jsonBody"); ok && body != nil && body != "" {
+		if text, isText := body.(string); isText {
+			if rendered, ok := translateN8NHandlebarsTemplate(text, templateRefs); ok {
+				body = rendered
+			}
+		}
 		requestParts = append(requestParts, ":body "+ednValue(body))
 	} else if bodyParams := n8nParameterPairs(node.Parameters, "bodyParameters", "bodyParameter"); len(bodyParams) > 0 {
+		for key, value := range bodyParams {
+			if rendered, ok := translateN8NHandlebarsTemplate(value, templateRefs); ok {
+				bodyParams[key] = rendered
+			}
+		}
 		requestParts = append(requestParts, ":body "+renderStringMap(bodyParams))
 	}
 	template := fmt.Sprintf(`{:id :%s-request
@@ -453,35 +861,37 @@ func convertN8NHTTPNode(node n8nNode, stepID string, inputPlan n8nInputPlan, con
             :template :%s-request
             :persist {:type :blob}
             :data %s})`, stepID, ednQuote(firstNonEmpty(node.Name, stepID)), slot, stepID, inputPlan.Expr)
-	return binding, []string{require}, []string{template}, nil, todos
+	return binding, requires, []string{template}, nil, todos
 }
 
 func convertN8NBranchNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
-	condition, todos := n8nBranchCondition(node)
+	condition, todos := n8nBranchCondition(node, inputPlan.FunctionRefs)
 	code := fmt.Sprintf("(fn [input]\n  (assoc input :branch %s))", condition)
 	fn := renderFunction(stepID, code)
 	binding := renderFunctionStep(node, stepID, inputPlan.Expr)
 	return binding, nil, nil, []string{fn}, todos
 }
 
-func n8nBranchCondition(node n8nNode) (string, []string) {
+func n8nBranchCondition(node n8nNode, nodeRefs map[string]string) (string, []string) {
 	typ := strings.ToLower(node.Type)
 	if strings.Contains(typ, ".switch") || strings.Contains(typ, "switch") {
 		return "0", []string{fmt.Sprintf("translate Switch node %q rules to a branch output index", node.Name)}
 	}
-	if expr, ok := n8nIFCondition(node.Parameters); ok {
+	if expr, ok := n8nIFCondition(node.Parameters, nodeRefs); ok {
 		return expr, nil
 	}
 	return "false", []string{fmt.Sprintf("translate IF node %q conditions", node.Name)}
 }
 
-func n8nIFCondition(params map[string]any) (string, bool) {
+// n8nIFCondition renders IF/Filter conditions. nodeRefs resolves $json and
+// $node references in the compared values; nil reads them from input.
+func n8nIFCondition(params map[string]any, nodeRefs map[string]string) (string, bool) {
 	conditions, ok := params["conditions"].(map[string]any)
 	if !ok {
 		return "", false
 	}
 	if rawItems, ok := conditions["conditions"].([]any); ok {
-		return n8nIFConditionItems(rawItems, stringParam(conditions, "combinator"))
+		return n8nIFConditionItems(rawItems, stringParam(conditions, "combinator"), nodeRefs)
 	}
 	parts := make([]string, 0)
 	for groupName, rawGroup := range conditions {
@@ -494,7 +904,7 @@ func n8nIFCondition(params map[string]any) (string, bool) {
 			if !ok {
 				continue
 			}
-			part, ok := n8nConditionItem(groupName, item)
+			part, ok := n8nConditionItem(groupName, item, nodeRefs)
 			if !ok {
 				return "", false
 			}
@@ -510,14 +920,14 @@ func n8nIFCondition(params map[string]any) (string, bool) {
 	return "(and " + strings.Join(parts, " ") + ")", true
 }
 
-func n8nIFConditionItems(rawItems []any, combinator string) (string, bool) {
+func n8nIFConditionItems(rawItems []any, combinator string, nodeRefs map[string]string) (string, bool) {
 	parts := make([]string, 0, len(rawItems))
 	for _, rawItem := range rawItems {
 		item, ok := rawItem.(map[string]any)
 		if !ok {
 			continue
 		}
-		part, ok := n8nConditionItem("", item)
+		part, ok := n8nConditionItem("", item, nodeRefs)
 		if !ok {
 			return "", false
 		}
@@ -535,12 +945,12 @@ func n8nIFConditionItems(rawItems []any, combinator string) (string, bool) {
 	return "(and " + strings.Join(parts, " ") + ")", true
 }
 
-func n8nConditionItem(groupName string, item map[string]any) (string, bool) {
+func n8nConditionItem(groupName string, item map[string]any, nodeRefs map[string]string) (string, bool) {
 	leftRaw, ok := firstParam(item, "value1", "leftValue")
 	if !ok {
 		return "", false
 	}
-	left, ok := n8nConditionValue(leftRaw)
+	left, ok := n8nConditionValue(leftRaw, nodeRefs)
 	if !ok {
 		return "", false
 	}
@@ -548,7 +958,7 @@ func n8nConditionItem(groupName string, item map[string]any) (string, bool) {
 	rightRaw, hasRightRaw := firstParam(item, "value2", "rightValue")
 	right, hasRight := "", false
 	if hasRightRaw {
-		right, hasRight = n8nConditionValue(rightRaw)
+		right, hasRight = n8nConditionValue(rightRaw, nodeRefs)
 	}
 	switch op {
 	case "isempty", "empty":
@@ -602,7 +1012,7 @@ func n8nNormalizeConditionOperation(op string) string {
 	return op
 }
 
-func n8nConditionValue(raw any) (string, bool) {
+func n8nConditionValue(raw any, nodeRefs map[string]string) (string, bool) {
 	switch v := raw.(type) {
 	case nil:
 		return "nil", true
@@ -616,14 +1026,9 @@ func n8nConditionValue(raw any) (string, bool) {
 	case string:
 		value := strings.TrimSpace(v)
 		value = strings.TrimPrefix(value, "=")
-		if expr, ok := translateSimpleN8NExpression(value); ok {
-			return expr, true
-		}
-		if expr, ok := translateN8NTemplateString(value); ok {
-			return expr, true
-		}
 		if strings.Contains(value, "{{") {
-			return "", false
+			expr, err := lowerN8NValue(value, nodeRefs)
+			return expr, err == nil
 		}
 		return ednQuote(v), true
 	default:
@@ -669,16 +1074,14 @@ func renderN8NWebhookResponseBody(node n8nNode, nodeRefs map[string]string) (str
 		return "input", ""
 	}
 	value = normalizeN8NTemplateLiteral(value)
-	if expr, ok := translateSimpleN8NExpressionWithRefs(value, nodeRefs); ok {
-		return expr, ""
-	}
-	if expr, ok := translateN8NTemplateStringWithRefs(value, nodeRefs); ok {
-		return expr, ""
+	if !strings.Contains(value, "{{") {
+		return ednQuote(value), ""
 	}
-	if strings.Contains(value, "{{") {
-		return ednQuote(value), fmt.Sprintf("translate n8n webhook response expression for node %q", node.Name)
+	expr, err := lowerN8NValue(value, nodeRefs)
+	if err != nil {
+		return ednQuote(value), fmt.Sprintf("translate n8n webhook response expression for node %q: %v", node.Name, err)
 	}
-	return ednQuote(value), ""
+	return expr, ""
 }
 
 func convertN8NSetNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
@@ -828,13 +1231,11 @@ func renderN8NSetValue(fieldName string, value any, nodeRefs map[string]string)
 		return "", "", false
 	}
 	s = normalizeN8NExpressionString(normalizeN8NTemplateLiteral(s))
-	if expr, ok := translateSimpleN8NExpressionWithRefs(s, nodeRefs); ok {
-		return expr, "", true
-	}
-	if expr, ok := translateN8NTemplateStringWithRefs(s, nodeRefs); ok {
-		return expr, "", true
+	expr, err := lowerN8NValue(s, nodeRefs)
+	if err != nil {
+		return ednQuote(s), fmt.Sprintf("translate n8n expression for Set field %q: %v", fieldName, err), true
 	}
-	return ednQuote(s), fmt.Sprintf("translate n8n expression for Set field %q", fieldName), true
+	return expr, "", true
 }
 
 func normalizeN8NExpressionString(value string) string {
@@ -857,78 +1258,19 @@ func translateSimpleN8NExpression(value string) (string, bool) {
 	return translateSimpleN8NExpressionWithRefs(value, nil)
 }
 
+// translateSimpleN8NExpressionWithRefs translates a value that is exactly one
+// {{ }} expression.
 func translateSimpleN8NExpressionWithRefs(value string, nodeRefs map[string]string) (string, bool) {
-	value = normalizeN8NExpressionString(value)
-	trimmed := strings.TrimSpace(value)
-	if !strings.HasPrefix(trimmed, "{{") || !strings.HasSuffix(trimmed, "}}") {
+	segments, err := splitN8NTemplate(strings.TrimSpace(normalizeN8NExpressionString(value)))
+	if err != nil || len(segments) != 1 || !segments[0].Expr {
 		return "", false
 	}
-	expr := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(trimmed, "{{"), "}}"))
-	return translateN8NInnerExpressionWithRefs(expr, nodeRefs)
+	return translateN8NInnerExpressionWithRefs(segments[0].Text, nodeRefs)
 }
 
 func translateN8NInnerExpressionWithRefs(expr string, nodeRefs map[string]string) (string, bool) {
-	expr = strings.TrimSpace(expr)
-	jsonRoot := n8nJSONRootRef(nodeRefs)
-	switch expr {
-	case "$json":
-		return jsonRoot, true
-	case "$now":
-		return "(flow/now-ms)", true
-	}
-	if rendered, ok := translateN8NTernaryExpressionWithRefs(expr, nodeRefs); ok {
-		return rendered, true
-	}
-	if rendered, ok := translateN8NBinaryExpressionWithRefs(expr, nodeRefs); ok {
-		return rendered, true
-	}
-	if rendered, ok := translateN8NIncrementExpressionWithRefs(expr, nodeRefs); ok {
-		return rendered, true
-	}
-	if rendered, ok := translateN8NPathExpressionWithRefs(expr, nodeRefs); ok {
-		return rendered, true
-	}
-	const jsonPrefix = "$json."
-	if strings.HasPrefix(expr, jsonPrefix) {
-		path := strings.TrimSpace(strings.TrimPrefix(expr, jsonPrefix))
-		if path == "" || strings.ContainsAny(path, " []()+-*/?:") {
-			return "", false
-		}
-		parts := strings.Split(path, ".")
-		keywords := make([]string, 0, len(parts))
-		for _, part := range parts {
-			key := n8nKebab(part, "field")
-			if key == "" {
-				return "", false
-			}
-			keywords = append(keywords, ":"+key)
-		}
-		if len(keywords) == 1 {
-			return "(get " + jsonRoot + " " + keywords[0] + ")", true
-		}
-		return "(get-in " + jsonRoot + " [" + strings.Join(keywords, " ") + "])", true
-	}
-	return "", false
-}
-
-func translateN8NPathExpressionWithRefs(expr string, nodeRefs map[string]string) (string, bool) {
-	root, path, ok := parseN8NPathExpression(expr)
-	if !ok {
-		return "", false
-	}
-	switch root {
-	case "$json":
-		return renderN8NGetPath(n8nJSONRootRef(nodeRefs), path), true
-	default:
-		const nodePrefix = "$node:"
-		if strings.HasPrefix(root, nodePrefix) {
-			nodeName := strings.TrimPrefix(root, nodePrefix)
-			if ref, ok := nodeRefs[nodeName]; ok {
-				return renderN8NGetPath(ref, path), true
-			}
-		}
-		return "", false
-	}
+	rendered, err := lowerN8NExpression(expr, nodeRefs)
+	return rendered, err == nil
 }
 
 func n8nJSONRootRef(nodeRefs map[string]string) string {
@@ -940,19 +1282,6 @@ func n8nJSONRootRef(nodeRefs map[string]string) string {
 	return "input"
 }
 
-func translateN8NIncrementExpressionWithRefs(expr string, nodeRefs map[string]string) (string, bool) {
-	expr = strings.TrimSpace(expr)
-	if !strings.HasSuffix(expr, "++") {
-		return "", false
-	}
-	target := strings.TrimSpace(strings.TrimSuffix(expr, "++"))
-	value, ok := translateN8NInnerExpressionWithRefs(target, nodeRefs)
-	if !ok {
-		return "", false
-	}
-	return "(inc (or " + value + " 0))", true
-}
-
 func parseN8NPathExpression(expr string) (string, []string, bool) {
 	expr = strings.TrimSpace(expr)
 	root := ""
@@ -1052,31 +1381,8 @@ func translateN8NTemplateString(value string) (string, bool) {
 }
 
 func translateN8NTemplateStringWithRefs(value string, nodeRefs map[string]string) (string, bool) {
-	matches := n8nTemplateExprRe.FindAllStringSubmatchIndex(value, -1)
-	if len(matches) == 0 {
-		return "", false
-	}
-	parts := make([]string, 0, len(matches)*2+1)
-	cursor := 0
-	for _, match := range matches {
-		if match[0] > cursor {
-			parts = append(parts, ednQuote(value[cursor:match[0]]))
-		}
-		inner := value[match[2]:match[3]]
-		rendered, ok := translateN8NInnerExpressionWithRefs(inner, nodeRefs)
-		if !ok {
-			return "", false
-		}
-		parts = append(parts, rendered)
-		cursor = match[1]
-	}
-	if cursor < len(value) {
-		parts = append(parts, ednQuote(value[cursor:]))
-	}
-	if len(parts) == 1 {
-		return parts[0], true
-	}
-	return "(str " + strings.Join(parts, " ") + ")", true
+	rendered, err := lowerN8NValue(value, nodeRefs)
+	return rendered, err == nil
 }
 
 func translateN8NHandlebarsTemplate(value string, nodeRefs map[string]string) (string, bool) {
@@ -1137,69 +1443,6 @@ func translateN8NHandlebarsPath(expr string, nodeRefs map[string]string) (string
 	return strings.Join(out, "."), true
 }
 
-func translateN8NTernaryExpressionWithRefs(expr string, nodeRefs map[string]string) (string, bool) {
-	q := strings.Index(expr, "?")
-	colon := strings.LastIndex(expr, ":")
-	if q <= 0 || colon <= q {
-		return "", false
-	}
-	condition, ok := translateN8NOperandWithRefs(expr[:q], nodeRefs)
-	if !ok {
-		return "", false
-	}
-	yes, ok := translateN8NStringLiteral(expr[q+1 : colon])
-	if !ok {
-		return "", false
-	}
-	no, ok := translateN8NStringLiteral(expr[colon+1:])
-	if !ok {
-		return "", false
-	}
-	return "(if " + condition + " " + yes + " " + no + ")", true
-}
-
-func translateN8NBinaryExpressionWithRefs(expr string, nodeRefs map[string]string) (string, bool) {
-	for _, op := range []string{" + ", " - ", " * ", " / "} {
-		if idx := strings.Index(expr, op); idx > 0 {
-			left, leftOK := translateN8NOperandWithRefs(expr[:idx], nodeRefs)
-			right, rightOK := translateN8NOperandWithRefs(expr[idx+len(op):], nodeRefs)
-			if !leftOK || !rightOK {
-				return "", false
-			}
-			return "(" + strings.TrimSpace(op) + " " + left + " " + right + ")", true
-		}
-	}
-	return "", false
-}
-
-func translateN8NOperandWithRefs(raw string, nodeRefs map[string]string) (string, bool) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return "", false
-	}
-	if strings.HasPrefix(raw, "$json") || raw == "$now" {
-		return translateN8NInnerExpressionWithRefs(raw, nodeRefs)
-	}
-	if _, err := strconv.ParseFloat(raw, 64); err == nil {
-		return raw, true
-	}
-	if s, ok := translateN8NStringLiteral(raw); ok {
-		return s, true
-	}
-	return "", false
-}
-
-func translateN8NStringLiteral(raw string) (string, bool) {
-	raw = strings.TrimSpace(raw)
-	if len(raw) < 2 {
-		return "", false
-	}
-	if (raw[0] == '"' && raw[len(raw)-1] == '"') || (raw[0] == '\'' && raw[len(raw)-1] == '\'') {
-		return ednQuote(raw[1 : len(raw)-1]), true
-	}
-	return "", false
-}
-
 func convertN8NCodeNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
 	source := stringParam(node.Parameters, "jsCode", "pythonCode", "code")
 	commented := commentBlock(source, "  ;; ")
@@ -1232,10 +1475,25 @@ func convertN8NFallbackNode(node n8nNode, stepID string, inputPlan n8nInputPlan,
 	if idx := strings.LastIndex(service, "."); idx >= 0 {
 		service = service[idx+1:]
 	}
-	code := fmt.Sprintf("(fn [input]\n  ;; TODO(n8n-import): Custom or unsupported n8n node %s (%s).\n  ;; TODO(n8n-import): Search the web for %s API docs and rebuild this node as HTTP if it has side effects.\n  input)", ednQuote(firstNonEmpty(node.Name, stepID)), node.Type, service)
+	service, _, _ = strings.Cut(service, ":")
+	code := fmt.Sprintf("(fn [input]\n  ;; TODO(n8n-import): Custom or unsupported "+n8nNodeSourceLabel(node)+" %s (%s).\n  ;; TODO(n8n-import): Search the web for %s API docs and rebuild this node as HTTP if it has side effects.\n  input)", ednQuote(firstNonEmpty(node.Name, stepID)), node.Type, service)
 	fn := renderFunction(stepID, code)
 	binding := renderFunctionStep(node, stepID, inputPlan.Expr)
-	return binding, nil, nil, []string{fn}, []string{fmt.Sprintf("implement unsupported node %q (%s)", node.Name, node.Type)}
+	return binding, nil, nil, []string{fn}, []string{fmt.Sprintf(n8nFallbackTodoPrefix+" %q (%s)", node.Name, node.Type)}
+}
+
+const n8nFallbackTodoPrefix = "implement unsupported node"
+
+// n8nIsFallbackConversion reports whether a converter handed the node to
+// convertN8NFallbackNode, which converters do for parameter shapes they
+// cannot translate.
+func n8nIsFallbackConversion(todos []string) bool {
+	for _, todo := range todos {
+		if strings.HasPrefix(todo, n8nFallbackTodoPrefix) {
+			return true
+		}
+	}
+	return false
 }
 
 func renderFunction(stepID, code string) string {
@@ -1259,11 +1517,11 @@ func n8nFunctionStepInputExpr(input string) string {
 	return trimmed
 }
 
-func renderN8NFlowEDN(slug, name string, requires, templates, functions, webhooks, schedules []string, body string) string {
+func renderN8NFlowEDN(slug, name, source string, requires, templates, functions, webhooks, schedules []string, body string) string {
 	var b strings.Builder
 	b.WriteString("{:slug :" + slug + "\n")
 	b.WriteString(" :name " + ednQuote(name) + "\n")
-	b.WriteString(" :description \"Imported from n8n JSON. TODO(n8n-import): review unsupported nodes and expression translations.\"\n")
+	b.WriteString(" :description \"Imported from " + source + " JSON. TODO(n8n-import): review unsupported nodes and expression translations.\"\n")
 	b.WriteString(" :icon :workflow\n")
 	b.WriteString(" :tags [:n8n-import]\n")
 	b.WriteString(" :concurrency {:type :singleton :on-new-version :supersede}\n")
@@ -1737,10 +1995,10 @@ func n8nCurrentInput(upstreams []string, convertedByName map[string]n8nConverted
 
 func n8nIsTrigger(node n8nNode) bool {
 	typ := strings.ToLower(node.Type)
-	if strings.Contains(typ, "respondtowebhook") || strings.Contains(typ, "webhookresponse") {
+	if n8nIsForeignNode(node) || strings.Contains(typ, "respondtowebhook") || strings.Contains(typ, "webhookresponse") {
 		return false
 	}
-	for _, marker := range []string{"manualtrigger", "webhook", "cron", "scheduletrigger", "interval"} {
+	for _, marker := range []string{"manualtrigger", "webhook", "cron", "scheduletrigger", "interval", "executeworkflowtrigger"} {
 		if strings.Contains(typ, marker) {
 			return true
 		}
@@ -1748,22 +2006,6 @@ func n8nIsTrigger(node n8nNode) bool {
 	return false
 }
 
-func n8nCredentialLabel(node n8nNode) string {
-	for _, raw := range node.Credentials {
-		switch value := raw.(type) {
-		case map[string]any:
-			if label := firstNonEmpty(fmt.Sprint(value["name"]), fmt.Sprint(value["id"])); label != "<nil>" {
-				return label
-			}
-		case string:
-			if strings.TrimSpace(value) != "" {
-				return value
-			}
-		}
-	}
-	return ""
-}
-
 func splitN8NURL(raw string) (string, string, map[string]string, bool) {
 	if strings.Contains(raw, "{{") || !strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://") {
 		return "", "", nil, false
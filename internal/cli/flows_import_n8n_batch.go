@@ -0,0 +1,503 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+type n8nBatchOptions struct {
+	dir            string
+	out            string
+	report         string
+	jobs           int
+	credentials    map[string]n8nCredentialMapping
+	serverValidate bool
+	deployKey      string
+}
+
+// n8nMigrationReport summarizes a --dir import across every workflow.
+type n8nMigrationReport struct {
+	Dir                  string                   `json:"dir"`
+	Out                  string                   `json:"out"`
+	Summary              n8nMigrationSummary      `json:"summary"`
+	Workflows            []n8nMigrationWorkflow   `json:"workflows"`
+	TodoCategories       []n8nMigrationCount      `json:"todoCategories"`
+	UnsupportedNodeTypes []n8nMigrationCount      `json:"unsupportedNodeTypes"`
+	Credentials          []n8nMigrationCredential `json:"credentials"`
+}
+
+type n8nMigrationSummary struct {
+	Workflows        int `json:"workflows"`
+	Converted        int `json:"converted"`
+	Failed           int `json:"failed"`
+	Validated        int `json:"validated,omitempty"`
+	ValidationFailed int `json:"validationFailed,omitempty"`
+	Nodes            int `json:"nodes"`
+	FallbackNodes    int `json:"fallbackNodes"`
+	Todos            int `json:"todos"`
+}
+
+// n8nMigrationWorkflow is one export's row in the report. Status is
+// converted, validated, validation_failed (converted but rejected by the
+// server), or failed (not converted).
+type n8nMigrationWorkflow struct {
+	File             string                     `json:"file"`
+	Name             string                     `json:"name,omitempty"`
+	Slug             string                     `json:"slug,omitempty"`
+	Status           string                     `json:"status"`
+	Error            string                     `json:"error,omitempty"`
+	Path             string                     `json:"path,omitempty"`
+	BindingsProfile  string                     `json:"bindingsProfile,omitempty"`
+	ChildFlows       []string                   `json:"childFlows,omitempty"`
+	TestsPath        string                     `json:"testsPath,omitempty"`
+	Nodes            int                        `json:"nodes"`
+	Converted        int                        `json:"converted"`
+	Fallback         int                        `json:"fallback"`
+	TodoCount        int                        `json:"todoCount"`
+	TodoCategories   map[string]int             `json:"todoCategories,omitempty"`
+	Credentials      []string                   `json:"credentials,omitempty"`
+	ServerValidation *n8nServerValidationResult `json:"serverValidation,omitempty"`
+
+	fallback    []n8nImportNodeRef
+	credentials []*n8nCredentialSlot
+}
+
+type n8nMigrationCount struct {
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	Workflows int    `json:"workflows"`
+}
+
+// n8nMigrationCredential is one distinct n8n credential and the slot the
+// workflows that use it require.
+type n8nMigrationCredential struct {
+	Credential string   `json:"credential"`
+	N8NType    string   `json:"n8nType"`
+	Slot       string   `json:"slot"`
+	Type       string   `json:"type"`
+	Connection string   `json:"connection,omitempty"`
+	Workflows  []string `json:"workflows"`
+}
+
+// n8nTodoCategories maps import TODO prefixes to report categories. TODOs
+// that match none are counted as other.
+var n8nTodoCategories = []struct {
+	prefix   string
+	category string
+}{
+	{n8nFallbackTodoPrefix, "unsupported-node"},
+	{"translate n8n expression", "expression"},
+	{"translate n8n webhook response expression", "expression"},
+	{"port Code node", "code"},
+	{"port Set node", "node-parameters"},
+	{"port HTML Extract node", "node-parameters"},
+	{"translate HTML Extract selector", "node-parameters"},
+	{"translate IF node", "conditions"},
+	{"translate Switch node", "conditions"},
+	{"translate Filter node", "conditions"},
+	{"fill base URL", "http"},
+	{"bind the ", "credentials"},
+	{"pass the HMAC secret", "credentials"},
+	{"move loop body", "loop"},
+	{"import n8n workflow", "child-workflow"},
+	{"Execute Workflow node", "child-workflow"},
+	{"schedule ", "schedule"},
+	{"Schedule Trigger", "schedule"},
+	{"Interval node", "schedule"},
+	{"set the schedule", "schedule"},
+	{"verify Wait node", "wait"},
+	{"check DateTime node", "date-format"},
+}
+
+// n8nTodoCategory classifies a "step-id: message" import TODO.
+func n8nTodoCategory(todo string) string {
+	if _, message, ok := strings.Cut(todo, ": "); ok {
+		todo = message
+	}
+	for _, entry := range n8nTodoCategories {
+		if strings.HasPrefix(todo, entry.prefix) {
+			return entry.category
+		}
+	}
+	return "other"
+}
+
+// discoverN8NWorkflowFiles walks dir for .json exports in path order. The
+// import's own output directory and report are skipped so a rerun with --out
+// inside --dir does not re-import them, and JSON documents without a "nodes"
+// array (credential maps, package manifests) are not treated as exports.
+// Unparseable JSON is kept so it is reported as a failed workflow.
+func discoverN8NWorkflowFiles(dir, out, report string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || sameN8NBatchPath(path, out)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".json") || sameN8NBatchPath(path, report) {
+			return nil
+		}
+		if n8nExportCandidate(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func sameN8NBatchPath(path, other string) bool {
+	if strings.TrimSpace(other) == "" {
+		return false
+	}
+	a, errA := filepath.Abs(path)
+	b, errB := filepath.Abs(other)
+	return errA == nil && errB == nil && a == b
+}
+
+// n8nExportCandidate reports whether path could be an n8n workflow export:
+// either a JSON object with a "nodes" array, or a file that does not parse.
+func n8nExportCandidate(path string) bool {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(b, &doc); err != nil {
+		var probe any
+		return json.Unmarshal(b, &probe) != nil
+	}
+	nodes := bytes.TrimSpace(doc["nodes"])
+	return len(nodes) > 0 && nodes[0] == '['
+}
+
+// importN8NWorkflowDir converts every export under opts.dir. Slugs are
+// assigned in file order before conversion starts so collisions resolve the
+// same way on every run; conversion and server validation then run on a
+// bounded worker pool.
+func importN8NWorkflowDir(app *App, opts n8nBatchOptions) (*n8nMigrationReport, error) {
+	files, err := discoverN8NWorkflowFiles(opts.dir, opts.out, opts.report+".json")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .json files found under %s", opts.dir)
+	}
+	rows := make([]n8nMigrationWorkflow, len(files))
+	workflows := make([]n8nWorkflow, len(files))
+	usedSlugs := map[string]bool{}
+	for i, path := range files {
+		rows[i].File = path
+		wf, err := readN8NWorkflowFile(path)
+		if err != nil {
+			rows[i].Status = "failed"
+			rows[i].Error = err.Error()
+			continue
+		}
+		workflows[i] = wf
+		rows[i].Name = wf.Name
+		rows[i].Slug = uniqueN8NID(n8nWorkflowSlug(wf, path), usedSlugs)
+	}
+
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				importN8NBatchWorkflow(app, opts, workflows[idx], &rows[idx])
+			}
+		}()
+	}
+	for idx := range rows {
+		if rows[idx].Status == "" {
+			indexes <- idx
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return buildN8NMigrationReport(opts, rows), nil
+}
+
+func importN8NBatchWorkflow(app *App, opts n8nBatchOptions, wf n8nWorkflow, row *n8nMigrationWorkflow) {
+	fail := func(err error) {
+		row.Status = "failed"
+		row.Error = err.Error()
+		row.Path, row.BindingsProfile, row.ChildFlows, row.TestsPath = "", "", nil, ""
+	}
+	row.Path = filepath.Join(opts.out, row.Slug+".clj")
+	result, err := convertN8NWorkflowWithCredentials(wf, row.Slug, row.Path, opts.credentials)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if err := atomicWriteFile(row.Path, []byte(result.EDN), 0o644); err != nil {
+		fail(err)
+		return
+	}
+	if result.BindingsProfile != "" {
+		row.BindingsProfile = filepath.Join(opts.out, row.Slug+".bindings.edn")
+		if err := atomicWriteFile(row.BindingsProfile, []byte(result.BindingsProfile), 0o644); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if err := writeN8NChildFlows(result); err != nil {
+		fail(err)
+		return
+	}
+	for _, child := range result.Children {
+		row.ChildFlows = append(row.ChildFlows, child.OutputPath)
+	}
+	if err := writeN8NPinnedTestSuites(result); err != nil {
+		fail(err)
+		return
+	}
+	row.TestsPath = result.TestsPath
+	row.Status = "converted"
+	row.Nodes = result.Report.Nodes
+	row.Converted = result.Report.Converted
+	row.Fallback = result.Report.Fallback
+	row.TodoCount = len(result.Todos)
+	row.fallback = result.Report.FallbackNodes
+	row.credentials = result.Credentials
+	for _, todo := range result.Todos {
+		if row.TodoCategories == nil {
+			row.TodoCategories = map[string]int{}
+		}
+		row.TodoCategories[n8nTodoCategory(todo)]++
+	}
+	for _, slot := range result.Credentials {
+		row.Credentials = append(row.Credentials, slot.Slot)
+	}
+	for _, child := range result.Children {
+		row.credentials = append(row.credentials, child.Credentials...)
+		for _, slot := range child.Credentials {
+			row.Credentials = append(row.Credentials, child.Slug+"/"+slot.Slot)
+		}
+	}
+	if opts.serverValidate {
+		for _, child := range result.Children {
+			if serverResult, err := validateImportedN8NFlowOnServer(app, child, opts.deployKey); err != nil || serverResult == nil || !serverResult.Valid {
+				row.Status = "validation_failed"
+				row.Error = fmt.Sprintf("child flow %s did not validate", child.Slug)
+				if err != nil {
+					row.Error = fmt.Sprintf("child flow %s: %v", child.Slug, err)
+				}
+				return
+			}
+		}
+		serverResult, err := validateImportedN8NFlowOnServer(app, result, opts.deployKey)
+		row.ServerValidation = serverResult
+		switch {
+		case err != nil:
+			row.Status = "validation_failed"
+			row.Error = err.Error()
+		case serverResult != nil && serverResult.Valid:
+			row.Status = "validated"
+		default:
+			row.Status = "validation_failed"
+		}
+	}
+}
+
+func buildN8NMigrationReport(opts n8nBatchOptions, rows []n8nMigrationWorkflow) *n8nMigrationReport {
+	report := &n8nMigrationReport{Dir: opts.dir, Out: opts.out, Workflows: rows}
+	report.Summary.Workflows = len(rows)
+	todoCounts := map[string]*n8nMigrationCount{}
+	typeCounts := map[string]*n8nMigrationCount{}
+	credentials := map[string]*n8nMigrationCredential{}
+	var credentialOrder []string
+	for _, row := range rows {
+		switch row.Status {
+		case "failed":
+			report.Summary.Failed++
+			continue
+		case "validated":
+			report.Summary.Validated++
+		case "validation_failed":
+			report.Summary.ValidationFailed++
+		}
+		report.Summary.Converted++
+		report.Summary.Nodes += row.Nodes
+		report.Summary.FallbackNodes += row.Fallback
+		report.Summary.Todos += row.TodoCount
+		for category, count := range row.TodoCategories {
+			addN8NMigrationCount(todoCounts, category, count)
+		}
+		seenTypes := map[string]int{}
+		for _, node := range row.fallback {
+			seenTypes[node.Type]++
+		}
+		for typ, count := range seenTypes {
+			addN8NMigrationCount(typeCounts, typ, count)
+		}
+		for _, slot := range row.credentials {
+			key := slot.N8NType + "\x00" + firstNonEmpty(slot.ID, slot.Credential)
+			entry := credentials[key]
+			if entry == nil {
+				entry = &n8nMigrationCredential{Credential: slot.Credential, N8NType: slot.N8NType, Slot: slot.Slot, Type: slot.Type, Connection: slot.Connection}
+				credentials[key] = entry
+				credentialOrder = append(credentialOrder, key)
+			}
+			entry.Workflows = append(entry.Workflows, row.Slug)
+		}
+	}
+	report.TodoCategories = sortedN8NMigrationCounts(todoCounts)
+	report.UnsupportedNodeTypes = sortedN8NMigrationCounts(typeCounts)
+	report.Credentials = []n8nMigrationCredential{}
+	for _, key := range credentialOrder {
+		report.Credentials = append(report.Credentials, *credentials[key])
+	}
+	sort.SliceStable(report.Credentials, func(i, j int) bool {
+		return len(report.Credentials[i].Workflows) > len(report.Credentials[j].Workflows)
+	})
+	return report
+}
+
+func addN8NMigrationCount(counts map[string]*n8nMigrationCount, name string, count int) {
+	entry := counts[name]
+	if entry == nil {
+		entry = &n8nMigrationCount{Name: name}
+		counts[name] = entry
+	}
+	entry.Count += count
+	entry.Workflows++
+}
+
+// sortedN8NMigrationCounts ranks counts by frequency, then name.
+func sortedN8NMigrationCounts(counts map[string]*n8nMigrationCount) []n8nMigrationCount {
+	out := make([]n8nMigrationCount, 0, len(counts))
+	for _, entry := range counts {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func renderN8NMigrationMarkdown(report *n8nMigrationReport) string {
+	var b strings.Builder
+	s := report.Summary
+	b.WriteString("# n8n migration report\n\n")
+	fmt.Fprintf(&b, "Converted %d of %d workflows from `%s` into `%s`", s.Converted, s.Workflows, report.Dir, report.Out)
+	if s.Validated > 0 || s.ValidationFailed > 0 {
+		fmt.Fprintf(&b, "; %d passed server validation, %d did not", s.Validated, s.ValidationFailed)
+	}
+	fmt.Fprintf(&b, ". %d failed. %d nodes, %d kept as unsupported, %d TODOs.\n\n", s.Failed, s.Nodes, s.FallbackNodes, s.Todos)
+
+	b.WriteString("## Workflows\n\n")
+	b.WriteString("| Workflow | Slug | Status | Nodes | Unsupported | TODOs | Credentials |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, row := range report.Workflows {
+		status := row.Status
+		if row.Error != "" {
+			status += ": " + row.Error
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %d | %s |\n",
+			markdownCell(firstNonEmpty(row.Name, row.File)), markdownCell(row.Slug), markdownCell(status),
+			row.Nodes, row.Fallback, row.TodoCount, markdownCell(strings.Join(row.Credentials, ", ")))
+	}
+
+	writeCounts := func(title, column string, counts []n8nMigrationCount) {
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		if len(counts) == 0 {
+			b.WriteString("None.\n")
+			return
+		}
+		fmt.Fprintf(&b, "| %s | Count | Workflows |\n|---|---|---|\n", column)
+		for _, entry := range counts {
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", markdownCell(entry.Name), entry.Count, entry.Workflows)
+		}
+	}
+	writeCounts("TODOs by category", "Category", report.TodoCategories)
+	writeCounts("Unsupported node types", "Node type", report.UnsupportedNodeTypes)
+
+	b.WriteString("\n## Credential slots\n\n")
+	if len(report.Credentials) == 0 {
+		b.WriteString("None.\n")
+		return b.String()
+	}
+	b.WriteString("| n8n credential | n8n type | Slot | Type | Connection | Workflows |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, entry := range report.Credentials {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCell(entry.Credential), markdownCell(entry.N8NType), markdownCell(entry.Slot), markdownCell(entry.Type),
+			markdownCell(firstNonEmpty(entry.Connection, "unmapped")), markdownCell(strings.Join(entry.Workflows, ", ")))
+	}
+	return b.String()
+}
+
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func runN8NBatchImport(cmd *cobra.Command, app *App, opts n8nBatchOptions) error {
+	if opts.serverValidate && !isAPIMode(app) {
+		return writeErr(cmd, errors.New("--server-validate requires --api/BREYTA_API_URL"))
+	}
+	if strings.TrimSpace(opts.out) == "" {
+		opts.out = filepath.Join("tmp", "flows")
+	}
+	if info, err := os.Stat(opts.out); err == nil && !info.IsDir() {
+		return writeErr(cmd, errors.New("with --dir, --out must be a directory"))
+	}
+	if err := os.MkdirAll(opts.out, 0o755); err != nil {
+		return writeErr(cmd, err)
+	}
+	if strings.TrimSpace(opts.report) == "" {
+		opts.report = filepath.Join(opts.out, "n8n-migration-report")
+	}
+	opts.report = strings.TrimSuffix(strings.TrimSuffix(opts.report, ".json"), ".md")
+	report, err := importN8NWorkflowDir(app, opts)
+	if err != nil {
+		return writeFailure(cmd, app, "n8n_import_failed", err, "Point --dir at a directory of n8n workflow JSON exports.", map[string]any{"dir": opts.dir})
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return writeErr(cmd, err)
+	}
+	jsonPath, markdownPath := opts.report+".json", opts.report+".md"
+	if err := atomicWriteFile(jsonPath, append(b, '\n'), 0o644); err != nil {
+		return writeErr(cmd, err)
+	}
+	if err := atomicWriteFile(markdownPath, []byte(renderN8NMigrationMarkdown(report)), 0o644); err != nil {
+		return writeErr(cmd, err)
+	}
+	s := report.Summary
+	ok := s.Failed == 0 && s.ValidationFailed == 0
+	meta := map[string]any{"summary": report.Summary, "report": jsonPath, "reportMarkdown": markdownPath}
+	if err := writeOut(cmd, app, map[string]any{"ok": ok, "workspaceId": app.WorkspaceID, "meta": meta, "data": report}); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%d workflows: %d converted, %d failed, %d TODOs; report at %s\n", s.Workflows, s.Converted, s.Failed, s.Todos, markdownPath)
+	if !ok {
+		return guidedCLIErrorForCommand(cmd, fmt.Sprintf("n8n import: %d failed, %d failed server validation", s.Failed, s.ValidationFailed), nil)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlowsImportN8NCommand_DirWritesMigrationReport(t *testing.T) {
+	tmp := t.TempDir()
+	exports := filepath.Join(tmp, "exports")
+	outDir := filepath.Join(tmp, "flows")
+	if err := os.MkdirAll(filepath.Join(exports, "team"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	notion := `{"name": "Sync Pages", "nodes": [
+	  {"name": "Start", "type": "n8n-nodes-base.manualTrigger", "parameters": {}},
+	  {"name": "Pages", "type": "n8n-nodes-base.notion", "parameters": {}},
+	  {"name": "Pages Again", "type": "n8n-nodes-base.notion", "parameters": {}},
+	  {"name": "Table", "type": "n8n-nodes-base.airtable", "parameters": {}}],
+	  "connections": {
+	    "Start": {"main": [[{"node": "Pages", "type": "main", "index": 0}]]},
+	    "Pages": {"main": [[{"node": "Pages Again", "type": "main", "index": 0}]]},
+	    "Pages Again": {"main": [[{"node": "Table", "type": "main", "index": 0}]]}}}`
+	files := map[string]string{
+		"a-billing.json":      n8nCredentialsWorkflow,
+		"b-sync.json":         notion,
+		"team/c-sync.json":    strings.Replace(notion, `"type": "n8n-nodes-base.airtable"`, `"type": "n8n-nodes-base.trello"`, 1),
+		"team/d-broken.json":  `{"name": "Broken"`,
+		"team/notes.txt":      "ignored",
+		"team/e-billing.json": strings.Replace(n8nCredentialsWorkflow, "Billing Sync", "Billing Copy", 1),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(exports, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	mapPath := filepath.Join(tmp, "map.json")
+	if err := os.WriteFile(mapPath, []byte(`{"Stripe account": "conn-stripe"}`), 0o644); err != nil {
+		t.Fatalf("write map: %v", err)
+	}
+
+	cmd := newFlowsImportN8NCmd(&App{WorkspaceID: "ws-test"})
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	cmd.SetArgs([]string{"--dir", exports, "--out", outDir, "--credentials-map", mapPath, "--jobs", "3"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "1 failed") {
+		t.Fatalf("expected the broken export to fail the batch, got %v\n%s", err, errOut.String())
+	}
+	var envelope map[string]any
+	if err := json.NewDecoder(&out).Decode(&envelope); err != nil || envelope["ok"] != false {
+		t.Fatalf("expected ok=false for a failed batch, got %v %s", err, out.String())
+	}
+
+	var report n8nMigrationReport
+	b, err := os.ReadFile(filepath.Join(outDir, "n8n-migration-report.json"))
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Summary.Workflows != 5 || report.Summary.Converted != 4 || report.Summary.Failed != 1 {
+		t.Fatalf("unexpected summary %+v", report.Summary)
+	}
+	var slugs []string
+	for _, row := range report.Workflows {
+		slugs = append(slugs, row.Slug+"="+row.Status)
+	}
+	if got := strings.Join(slugs, ","); got != "billing-sync=converted,sync-pages=converted,sync-pages-2=converted,=failed,billing-copy=converted" {
+		t.Fatalf("unexpected workflows %s", got)
+	}
+	for _, name := range []string{"sync-pages.clj", "sync-pages-2.clj", "billing-sync.bindings.edn"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	if types := report.UnsupportedNodeTypes; len(types) != 4 || types[1].Name != "n8n-nodes-base.slack" || types[0] != (n8nMigrationCount{Name: "n8n-nodes-base.notion", Count: 4, Workflows: 2}) {
+		t.Fatalf("unexpected unsupported node ranking %+v", types)
+	}
+	if categories := report.TodoCategories; len(categories) == 0 || categories[0].Name != "unsupported-node" || categories[0].Count != 8 {
+		t.Fatalf("unexpected todo categories %+v", categories)
+	}
+	if len(report.Credentials) != 2 || report.Credentials[0].Slot != "stripe-account" || report.Credentials[0].Connection != "conn-stripe" ||
+		strings.Join(report.Credentials[0].Workflows, ",") != "billing-sync,billing-copy" {
+		t.Fatalf("unexpected credentials %s", mustJSON(t, report.Credentials))
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(outDir, "n8n-migration-report.md"))
+	if err != nil {
+		t.Fatalf("read markdown: %v", err)
+	}
+	assertContains(t, string(markdown), "Converted 4 of 5 workflows")
+	assertContains(t, string(markdown), "| n8n-nodes-base.notion | 4 | 2 |")
+	assertContains(t, string(markdown), "| Slack bot | slackApi | slack-bot | http-api | unmapped | billing-sync, billing-copy |")
+}
+
+func TestFlowsImportN8NCommand_DirSkipsOwnOutputAndNonExports(t *testing.T) {
+	exports := t.TempDir()
+	outDir := filepath.Join(exports, "flows")
+	files := map[string]string{
+		"billing.json":     n8nCredentialsWorkflow,
+		"credentials.json": `{"Stripe account": "conn-stripe"}`,
+		"package.json":     `{"name": "exports", "nodes": "not-an-array"}`,
+		"list.json":        `[1, 2, 3]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(exports, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	for run := 1; run <= 2; run++ {
+		cmd := newFlowsImportN8NCmd(&App{WorkspaceID: "ws-test"})
+		var out, errOut bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		cmd.SetArgs([]string{"--dir", exports, "--out", outDir, "--credentials-map", filepath.Join(exports, "credentials.json")})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("run %d: %v\n%s", run, err, errOut.String())
+		}
+		var envelope struct {
+			OK   bool               `json:"ok"`
+			Data n8nMigrationReport `json:"data"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+			t.Fatalf("run %d: decode: %v\n%s", run, err, out.String())
+		}
+		if !envelope.OK || envelope.Data.Summary.Workflows != 1 || envelope.Data.Summary.Failed != 0 {
+			t.Fatalf("run %d: expected only billing.json to be imported, got %s", run, out.String())
+		}
+	}
+}
+
+func TestN8NTodoCategory(t *testing.T) {
+	cases := map[string]string{
+		`http: implement unsupported node "X" (n8n-nodes-base.notion)`:                   "unsupported-node",
+		`shape: translate n8n expression for Set field "a": assignment is not supported`: "expression",
+		`code: port Code node "Code" to Clojure`:                                         "code",
+		`route: translate Switch node "Route" rules to a branch output index`:            "conditions",
+		`something new`: "other",
+	}
+	for todo, want := range cases {
+		if got := n8nTodoCategory(todo); got != want {
+			t.Fatalf("n8nTodoCategory(%q) = %q, want %q", todo, got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"olympos.io/encoding/edn"
+)
+
+// n8nCredentialMapping binds one n8n credential to a Breyta connection. A
+// --credentials-map file is a JSON object keyed by n8n credential ID or name;
+// each value is a connection ID or an object with these fields.
+type n8nCredentialMapping struct {
+	Connection string `json:"connection"`
+	Slot       string `json:"slot,omitempty"`
+	Type       string `json:"type,omitempty"`
+}
+
+// n8nCredentialSlot is the :requires slot generated for one distinct n8n
+// credential.
+type n8nCredentialSlot struct {
+	Credential string   `json:"credential"`
+	ID         string   `json:"id,omitempty"`
+	N8NType    string   `json:"n8nType"`
+	Slot       string   `json:"slot"`
+	Type       string   `json:"type"`
+	Auth       string   `json:"auth"`
+	BaseURL    string   `json:"baseUrl,omitempty"`
+	Connection string   `json:"connection,omitempty"`
+	Nodes      []string `json:"nodes"`
+}
+
+func loadN8NCredentialsMap(path string) (map[string]n8nCredentialMapping, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("credentials map must be a JSON object: %w", err)
+	}
+	out := make(map[string]n8nCredentialMapping, len(raw))
+	for key, value := range raw {
+		var mapping n8nCredentialMapping
+		var conn string
+		if err := json.Unmarshal(value, &conn); err == nil {
+			mapping.Connection = conn
+		} else if err := json.Unmarshal(value, &mapping); err != nil {
+			return nil, fmt.Errorf("credentials map entry %q must be a connection ID or {\"connection\": ...}", key)
+		}
+		mapping.Connection = strings.TrimSpace(mapping.Connection)
+		if mapping.Connection == "" && strings.TrimSpace(mapping.Slot) == "" {
+			return nil, fmt.Errorf("credentials map entry %q has no connection", key)
+		}
+		out[strings.TrimSpace(key)] = mapping
+	}
+	if len(out) == 0 {
+		return nil, errors.New("credentials map is empty")
+	}
+	return out, nil
+}
+
+// n8nNodeCredential returns the n8n credential type, ID and name a node uses.
+// Nodes normally carry one credential; with several, the first by type wins.
+func n8nNodeCredential(node n8nNode) (string, string, string, bool) {
+	types := make([]string, 0, len(node.Credentials))
+	for typ := range node.Credentials {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		switch value := node.Credentials[typ].(type) {
+		case map[string]any:
+			id := strings.TrimSpace(toString(value["id"]))
+			name := strings.TrimSpace(toString(value["name"]))
+			if id != "" || name != "" {
+				return typ, id, name, true
+			}
+		case string:
+			if strings.TrimSpace(value) != "" {
+				return typ, "", strings.TrimSpace(value), true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+// n8nCredentialKind maps an n8n credential type to a Breyta connection type
+// and auth type.
+func n8nCredentialKind(n8nType string) (string, string) {
+	lower := strings.ToLower(n8nType)
+	switch {
+	case lower == "httpbasicauth":
+		return "http-api", "basic"
+	case lower == "httpheaderauth", lower == "httpqueryauth", lower == "httpcustomauth":
+		return "http-api", "api-key"
+	case strings.HasPrefix(lower, "postgres"), strings.HasPrefix(lower, "mysql"), strings.HasPrefix(lower, "microsoftsql"), strings.HasPrefix(lower, "mongodb"), strings.HasPrefix(lower, "redis"):
+		return "database", "none"
+	case strings.HasPrefix(lower, "openai"), strings.HasPrefix(lower, "anthropic"), strings.HasPrefix(lower, "groq"), strings.HasPrefix(lower, "mistral"), strings.HasPrefix(lower, "googlepalm"), strings.HasPrefix(lower, "googlegemini"):
+		return "llm-provider", "api-key"
+	}
+	return "http-api", "bearer"
+}
+
+// n8nCredentialSlots gives every distinct credential in the workflow one
+// :requires slot and attaches it to the nodes that use it. Mappings keyed by
+// credential ID or name supply the connection and may rename the slot or
+// override its type. It returns the slots in first-use order and the mapping
+// keys that matched no credential.
+func n8nCredentialSlots(nodes []n8nNode, mappings map[string]n8nCredentialMapping) ([]*n8nCredentialSlot, []string) {
+	var slots []*n8nCredentialSlot
+	byKey := map[string]*n8nCredentialSlot{}
+	usedSlots := map[string]bool{"webhook-secret": true}
+	usedMappings := map[string]bool{}
+	for i := range nodes {
+		node := &nodes[i]
+		n8nType, id, name, ok := n8nNodeCredential(*node)
+		if !ok {
+			continue
+		}
+		key := n8nType + "\x00" + firstNonEmpty(id, name)
+		slot := byKey[key]
+		if slot == nil {
+			connType, auth := n8nCredentialKind(n8nType)
+			slot = &n8nCredentialSlot{Credential: firstNonEmpty(name, id), ID: id, N8NType: n8nType, Type: connType, Auth: auth}
+			slotName := firstNonEmpty(name, n8nType)
+			for _, lookup := range []string{id, name} {
+				mapping, ok := mappings[lookup]
+				if lookup == "" || !ok {
+					continue
+				}
+				usedMappings[lookup] = true
+				slot.Connection = mapping.Connection
+				slotName = firstNonEmpty(mapping.Slot, slotName)
+				if typ := strings.TrimPrefix(strings.TrimSpace(mapping.Type), ":"); typ != "" {
+					slot.Type = typ
+				}
+				break
+			}
+			slot.Slot = uniqueN8NID(n8nKebab(slotName, "api"), usedSlots)
+			byKey[key] = slot
+			slots = append(slots, slot)
+		}
+		if slot.BaseURL == "" && n8nNodeKind(*node) == "httprequest" {
+			if baseURL, _, _, ok := splitN8NURL(stringParam(node.Parameters, "url", "endpoint")); ok {
+				slot.BaseURL = baseURL
+			}
+		}
+		slot.Nodes = append(slot.Nodes, node.Name)
+		node.Credential = slot
+	}
+	var unused []string
+	for key := range mappings {
+		if !usedMappings[key] {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(unused)
+	return slots, unused
+}
+
+func renderN8NCredentialRequire(slot *n8nCredentialSlot) string {
+	parts := []string{
+		":slot :" + slot.Slot,
+		":type :" + slot.Type,
+		":label " + ednQuote(slot.Credential),
+	}
+	if slot.BaseURL != "" {
+		parts = append(parts, ":base-url "+ednQuote(slot.BaseURL))
+	}
+	switch {
+	case slot.Type == "database":
+	case slot.Auth == "api-key" && strings.EqualFold(slot.N8NType, "httpQueryAuth"):
+		parts = append(parts, ":auth {:type :api-key :location :query}")
+	default:
+		parts = append(parts, ":auth {:type :"+slot.Auth+"}")
+	}
+	return "{" + strings.Join(parts, "\n  ") + "}"
+}
+
+// n8nInlineAuthHeaders are request headers that carry credentials; HTTP
+// nodes drop them in favour of the slot's auth.
+var n8nInlineAuthHeaders = map[string]bool{
+	"authorization": true, "x-api-key": true, "api-key": true, "apikey": true,
+	"x-auth-token": true, "x-access-token": true, "x-api-token": true,
+}
+
+// n8nExtractAuthHeaders removes credential headers from headers and returns
+// the auth a slot needs to replace them: bearer or basic for Authorization
+// schemes, otherwise an api-key header. Headers computed from {{ }}
+// expressions carry per-request values and are kept unless all is set (the
+// node has a credential that supplies auth).
+func n8nExtractAuthHeaders(headers map[string]string, all bool) (map[string]string, string, []string) {
+	auth := ""
+	var removed []string
+	for name, value := range headers {
+		if !n8nInlineAuthHeaders[strings.ToLower(strings.TrimSpace(name))] || !all && strings.Contains(value, "{{") {
+			continue
+		}
+		removed = append(removed, name)
+		delete(headers, name)
+		scheme, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+		switch {
+		case !strings.EqualFold(name, "authorization"):
+			if auth == "" {
+				auth = "{:type :api-key :header " + ednQuote(name) + "}"
+			}
+		case strings.EqualFold(scheme, "basic"):
+			auth = "{:type :basic}"
+		default:
+			auth = "{:type :bearer}"
+		}
+	}
+	sort.Strings(removed)
+	return headers, auth, removed
+}
+
+// renderN8NBindingsProfile renders a profile for flows bindings apply that
+// binds each mapped slot to its connection. Unmapped slots are listed in a
+// comment header.
+func renderN8NBindingsProfile(slug string, slots []*n8nCredentialSlot) (string, error) {
+	bindings := map[string]any{}
+	var unmapped []string
+	for _, slot := range slots {
+		if slot.Connection == "" {
+			unmapped = append(unmapped, fmt.Sprintf("%s (%s, n8n credential %q)", slot.Slot, slot.Type, slot.Credential))
+			continue
+		}
+		bindings[slot.Slot] = map[string]any{"conn": slot.Connection}
+	}
+	out, err := edn.Marshal(ednifyKeys(map[string]any{"bindings": bindings}))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(";; Apply with: breyta flows bindings apply %s @<this file>\n", slug))
+	for _, line := range unmapped {
+		b.WriteString(";; Unmapped slot: " + line + "; pick one from breyta connections list\n")
+	}
+	b.Write(out)
+	b.WriteString("\n")
+	return b.String(), nil
+}
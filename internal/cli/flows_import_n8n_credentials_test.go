@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const n8nCredentialsWorkflow = `{
+  "name": "Billing Sync",
+  "nodes": [
+    {"name": "Start", "type": "n8n-nodes-base.manualTrigger", "parameters": {}},
+    {"name": "List Charges", "type": "n8n-nodes-base.httpRequest",
+     "parameters": {"url": "https://api.stripe.com/v1/charges",
+                    "headerParameters": {"parameters": [{"name": "Authorization", "value": "Bearer {{$json.token}}"},
+                                                        {"name": "Accept", "value": "application/json"}]}},
+     "credentials": {"httpHeaderAuth": {"id": "7", "name": "Stripe account"}}},
+    {"name": "List Refunds", "type": "n8n-nodes-base.httpRequest",
+     "parameters": {"url": "https://api.stripe.com/v1/refunds"},
+     "credentials": {"httpHeaderAuth": {"id": "7", "name": "Stripe account"}}},
+    {"name": "Ping Status", "type": "n8n-nodes-base.httpRequest",
+     "parameters": {"url": "https://status.example.com/ping",
+                    "headerParameters": {"parameters": [{"name": "X-API-Key", "value": "sk_live_inline"}]}}},
+    {"name": "Notify", "type": "n8n-nodes-base.slack", "parameters": {},
+     "credentials": {"slackApi": {"id": "9", "name": "Slack bot"}}}
+  ],
+  "connections": {
+    "Start": {"main": [[{"node": "List Charges", "type": "main", "index": 0}]]},
+    "List Charges": {"main": [[{"node": "List Refunds", "type": "main", "index": 0}]]},
+    "List Refunds": {"main": [[{"node": "Ping Status", "type": "main", "index": 0}]]},
+    "Ping Status": {"main": [[{"node": "Notify", "type": "main", "index": 0}]]}
+  }
+}`
+
+func TestConvertN8NWorkflow_CredentialsBecomeSlots(t *testing.T) {
+	var wf n8nWorkflow
+	if err := json.Unmarshal([]byte(n8nCredentialsWorkflow), &wf); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	mappings := map[string]n8nCredentialMapping{
+		"7":         {Connection: "conn-stripe", Slot: "stripe"},
+		"Slack bot": {Connection: "conn-slack"},
+		"Unused":    {Connection: "conn-other"},
+	}
+	result, err := convertN8NWorkflowWithCredentials(wf, "billing-sync", "tmp/flows/billing-sync.clj", mappings)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	assertContains(t, result.EDN, "{:slot :stripe\n  :type :http-api\n  :label \"Stripe account\"\n  :base-url \"https://api.stripe.com\"\n  :auth {:type :api-key}}")
+	assertContains(t, result.EDN, "{:slot :slack-bot\n  :type :http-api\n  :label \"Slack bot\"\n  :auth {:type :bearer}}")
+	assertContains(t, result.EDN, ":auth {:type :api-key :header \"X-API-Key\"}")
+	if strings.Count(result.EDN, ":slot :stripe") != 1 || strings.Count(result.EDN, ":connection :stripe") != 2 {
+		t.Fatalf("expected one shared stripe slot used by both requests:\n%s", result.EDN)
+	}
+	if strings.Contains(result.EDN, "Bearer {{$json.token}}") || strings.Contains(result.EDN, "sk_live_inline") {
+		t.Fatalf("inline auth headers should be replaced by slot auth:\n%s", result.EDN)
+	}
+	assertContains(t, result.EDN, `:headers {"Accept" "application/json"}`)
+	assertContains(t, strings.Join(result.Todos, "\n"), "bind the X-API-Key header value through slot :ping-status")
+	if len(result.Credentials) != 2 || strings.Join(result.Credentials[0].Nodes, ",") != "List Charges,List Refunds" {
+		t.Fatalf("unexpected credential slots %s", mustJSON(t, result.Credentials))
+	}
+	if strings.Join(result.UnusedMappings, ",") != "Unused" {
+		t.Fatalf("unexpected unused mappings %v", result.UnusedMappings)
+	}
+
+	payload, err := decodeProfilePayload([]byte(result.BindingsProfile), "edn")
+	if err != nil {
+		t.Fatalf("bindings profile should parse like flows bindings apply input: %v\n%s", err, result.BindingsProfile)
+	}
+	if payload.Inputs["conn-stripe"] != "conn-stripe" || payload.Inputs["conn-slack-bot"] != "conn-slack" {
+		t.Fatalf("unexpected bindings inputs %#v", payload.Inputs)
+	}
+}
+
+func TestFlowsImportN8NCommand_CredentialsMapWritesBindingsProfile(t *testing.T) {
+	tmp := t.TempDir()
+	input := filepath.Join(tmp, "workflow.json")
+	mapPath := filepath.Join(tmp, "map.json")
+	outPath := filepath.Join(tmp, "billing.clj")
+	if err := os.WriteFile(input, []byte(n8nCredentialsWorkflow), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	if err := os.WriteFile(mapPath, []byte(`{"Stripe account": "conn-stripe"}`), 0o644); err != nil {
+		t.Fatalf("write map: %v", err)
+	}
+
+	cmd := newFlowsImportN8NCmd(&App{WorkspaceID: "ws-test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{input, "--slug", "billing", "--out", outPath, "--credentials-map", mapPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute failed: %v\n%s", err, out.String())
+	}
+	var envelope map[string]any
+	if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode envelope: %v\n%s", err, out.String())
+	}
+	data := envelope["data"].(map[string]any)
+	profilePath := filepath.Join(tmp, "billing.bindings.edn")
+	if data["bindingsProfile"] != profilePath {
+		t.Fatalf("unexpected bindings profile path %v", data["bindingsProfile"])
+	}
+	if got, _ := data["bindingsCommand"].(string); !strings.HasPrefix(got, "breyta flows bindings apply billing @") {
+		t.Fatalf("unexpected bindings command %q", got)
+	}
+	payload, err := parseProfileArg("@" + profilePath)
+	if err != nil {
+		t.Fatalf("parse profile: %v", err)
+	}
+	if payload.Inputs["conn-stripe-account"] != "conn-stripe" || len(payload.Inputs) != 1 {
+		t.Fatalf("unexpected profile inputs %#v", payload.Inputs)
+	}
+	b, _ := os.ReadFile(profilePath)
+	assertContains(t, string(b), ";; Unmapped slot: slack-bot (http-api, n8n credential \"Slack bot\")")
+
+	if err := os.WriteFile(mapPath, []byte(`{"Stripe account": {"slot": ""}}`), 0o644); err != nil {
+		t.Fatalf("write map: %v", err)
+	}
+	cmd = newFlowsImportN8NCmd(&App{WorkspaceID: "ws-test"})
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{input, "--out", outPath, "--credentials-map", mapPath})
+	if err := cmd.Execute(); err == nil || !strings.Contains(out.String(), "n8n_credentials_map_invalid") {
+		t.Fatalf("expected invalid map to fail, got %v\n%s", err, out.String())
+	}
+}
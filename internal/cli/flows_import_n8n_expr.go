@@ -0,0 +1,1264 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// n8n {{ }} expressions are JavaScript. This file tokenizes and parses the
+// subset workflows use in practice (paths, operators, ternaries, template
+// literals, arrow functions, and common string/array/Math methods) and lowers
+// the AST to Clojure. Anything outside the subset fails with an
+// n8nExprError naming the sub-expression, which callers turn into a TODO.
+
+type n8nExprError struct {
+	Snippet string
+	Reason  string
+}
+
+func (e *n8nExprError) Error() string {
+	if e.Snippet == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s in %q", e.Reason, strings.Join(strings.Fields(e.Snippet), " "))
+}
+
+type n8nJSToken struct {
+	Kind  string // ident, num, str, tmpl, punct, eof
+	Text  string
+	Value string
+	Pos   int
+	End   int
+}
+
+var n8nJSPunctuators = []string{
+	"===", "!==", "...", "?.", "??", "=>", "==", "!=", "<=", ">=", "&&", "||", "++", "--",
+	"(", ")", "[", "]", "{", "}", ".", ",", "?", ":", "+", "-", "*", "/", "%", "!", "<", ">", "=",
+}
+
+func tokenizeN8NExpression(src string) ([]n8nJSToken, error) {
+	var tokens []n8nJSToken
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, n8nJSToken{Kind: "ident", Text: src[start:i], Pos: start, End: i})
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == '_') {
+				i++
+			}
+			text := strings.ReplaceAll(src[start:i], "_", "")
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, &n8nExprError{Snippet: src[start:i], Reason: "invalid number"}
+			}
+			tokens = append(tokens, n8nJSToken{Kind: "num", Text: text, Pos: start, End: i})
+		case c == '"' || c == '\'' || c == '`':
+			start := i
+			var b strings.Builder
+			i++
+			for ; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					if c == '`' {
+						b.WriteByte('\\')
+						b.WriteByte(src[i])
+						continue
+					}
+					switch src[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case 'r':
+						b.WriteByte('\r')
+					default:
+						b.WriteByte(src[i])
+					}
+					continue
+				}
+				if c == '`' && src[i] == '$' && i+1 < len(src) && src[i+1] == '{' {
+					end, err := n8nTemplateExprEnd(src, i+2)
+					if err != nil {
+						return nil, err
+					}
+					b.WriteString(src[i:end])
+					i = end - 1
+					continue
+				}
+				b.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, &n8nExprError{Snippet: src[start:], Reason: "unterminated string"}
+			}
+			i++
+			kind := "str"
+			if c == '`' {
+				kind = "tmpl"
+			}
+			tokens = append(tokens, n8nJSToken{Kind: kind, Text: src[start:i], Value: b.String(), Pos: start, End: i})
+		default:
+			matched := false
+			for _, p := range n8nJSPunctuators {
+				if strings.HasPrefix(src[i:], p) {
+					// "?." followed by a digit is a ternary, not optional chaining.
+					if p == "?." && i+2 < len(src) && src[i+2] >= '0' && src[i+2] <= '9' {
+						continue
+					}
+					tokens = append(tokens, n8nJSToken{Kind: "punct", Text: p, Pos: i, End: i + len(p)})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &n8nExprError{Snippet: string(c), Reason: "unexpected character"}
+			}
+		}
+	}
+	return append(tokens, n8nJSToken{Kind: "eof", Pos: len(src), End: len(src)}), nil
+}
+
+// n8nTemplateExprEnd returns the index just past the "}" that closes a
+// template-literal ${ started before start.
+func n8nTemplateExprEnd(src string, start int) (int, error) {
+	depth := 1
+	for i := start; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		case '"', '\'', '`':
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return 0, &n8nExprError{Snippet: src[start-2:], Reason: "unterminated ${ } in template literal"}
+}
+
+type n8nJSNode struct {
+	Kind     string // ident, num, str, tmpl, member, call, unary, binary, cond, arrow, array, object, postfix
+	Op       string
+	Name     string
+	Computed bool
+	Kids     []*n8nJSNode
+	Params   []string
+	Keys     []string
+	Quasis   []string
+	Pos, End int
+}
+
+type n8nJSParser struct {
+	src    string
+	tokens []n8nJSToken
+	pos    int
+}
+
+func parseN8NExpression(src string) (*n8nJSNode, error) {
+	tokens, err := tokenizeN8NExpression(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &n8nJSParser{src: src, tokens: tokens}
+	node, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.Kind != "eof" {
+		return nil, p.errorAt(tok, "unexpected "+strconv.Quote(tok.Text))
+	}
+	return node, nil
+}
+
+func (p *n8nJSParser) peek() n8nJSToken { return p.tokens[p.pos] }
+
+func (p *n8nJSParser) next() n8nJSToken {
+	tok := p.tokens[p.pos]
+	if tok.Kind != "eof" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *n8nJSParser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.Kind == "punct" && tok.Text == text
+}
+
+func (p *n8nJSParser) expect(text string) error {
+	if !p.isPunct(text) {
+		return p.errorAt(p.peek(), "expected "+strconv.Quote(text))
+	}
+	p.next()
+	return nil
+}
+
+func (p *n8nJSParser) errorAt(tok n8nJSToken, reason string) error {
+	snippet := strings.TrimSpace(p.src[tok.Pos:])
+	if len(snippet) > 24 {
+		snippet = snippet[:24] + "..."
+	}
+	if tok.Kind == "eof" {
+		snippet = ""
+		reason += " at end of expression"
+	}
+	return &n8nExprError{Snippet: snippet, Reason: "syntax error: " + reason}
+}
+
+func (p *n8nJSParser) expression() (*n8nJSNode, error) {
+	if arrow, ok, err := p.arrow(); ok || err != nil {
+		return arrow, err
+	}
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("=") {
+		return nil, p.errorAt(p.peek(), "assignment is not supported")
+	}
+	if !p.isPunct("?") {
+		return cond, nil
+	}
+	p.next()
+	yes, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	no, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &n8nJSNode{Kind: "cond", Kids: []*n8nJSNode{cond, yes, no}, Pos: cond.Pos, End: no.End}, nil
+}
+
+// arrow parses x => body and (a, b) => body.
+func (p *n8nJSParser) arrow() (*n8nJSNode, bool, error) {
+	start := p.pos
+	var params []string
+	switch tok := p.peek(); {
+	case tok.Kind == "ident" && p.tokens[start+1].Kind == "punct" && p.tokens[start+1].Text == "=>":
+		params = []string{tok.Text}
+		p.pos += 2
+	case tok.Kind == "punct" && tok.Text == "(":
+		i := start + 1
+		for ; p.tokens[i].Kind == "ident" || p.tokens[i].Kind == "punct" && p.tokens[i].Text == ","; i++ {
+			if p.tokens[i].Kind == "ident" {
+				params = append(params, p.tokens[i].Text)
+			}
+		}
+		if !(p.tokens[i].Kind == "punct" && p.tokens[i].Text == ")" && p.tokens[i+1].Kind == "punct" && p.tokens[i+1].Text == "=>") {
+			return nil, false, nil
+		}
+		p.pos = i + 2
+	default:
+		return nil, false, nil
+	}
+	if p.isPunct("{") {
+		return nil, true, p.errorAt(p.peek(), "arrow functions with a block body are not supported")
+	}
+	body, err := p.expression()
+	if err != nil {
+		return nil, true, err
+	}
+	return &n8nJSNode{Kind: "arrow", Params: params, Kids: []*n8nJSNode{body}, Pos: p.tokens[start].Pos, End: body.End}, true, nil
+}
+
+var n8nJSBinaryPrecedence = map[string]int{
+	"??": 1, "||": 2, "&&": 3,
+	"==": 7, "!=": 7, "===": 7, "!==": 7,
+	"<": 8, "<=": 8, ">": 8, ">=": 8,
+	"+": 10, "-": 10,
+	"*": 11, "/": 11, "%": 11,
+}
+
+func (p *n8nJSParser) binary(minPrec int) (*n8nJSNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := n8nJSBinaryPrecedence[tok.Text]
+		if tok.Kind != "punct" || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = &n8nJSNode{Kind: "binary", Op: tok.Text, Kids: []*n8nJSNode{left, right}, Pos: left.Pos, End: right.End}
+	}
+}
+
+func (p *n8nJSParser) unary() (*n8nJSNode, error) {
+	tok := p.peek()
+	if tok.Kind == "punct" && (tok.Text == "!" || tok.Text == "-" || tok.Text == "+") || tok.Kind == "ident" && tok.Text == "typeof" {
+		p.next()
+		arg, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &n8nJSNode{Kind: "unary", Op: tok.Text, Kids: []*n8nJSNode{arg}, Pos: tok.Pos, End: arg.End}, nil
+	}
+	node, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("++") || p.isPunct("--") {
+		op := p.next()
+		node = &n8nJSNode{Kind: "postfix", Op: op.Text, Kids: []*n8nJSNode{node}, Pos: node.Pos, End: op.End}
+	}
+	return node, nil
+}
+
+func (p *n8nJSParser) postfix() (*n8nJSNode, error) {
+	node, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.Kind != "punct" {
+			return node, nil
+		}
+		switch tok.Text {
+		case ".", "?.":
+			p.next()
+			if p.isPunct("(") || p.isPunct("[") {
+				continue
+			}
+			name := p.next()
+			if name.Kind != "ident" {
+				return nil, p.errorAt(name, "expected a property name")
+			}
+			node = &n8nJSNode{Kind: "member", Name: name.Text, Kids: []*n8nJSNode{node}, Pos: node.Pos, End: name.End}
+		case "[":
+			p.next()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			end := p.peek()
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &n8nJSNode{Kind: "member", Computed: true, Kids: []*n8nJSNode{node, index}, Pos: node.Pos, End: end.End}
+		case "(":
+			p.next()
+			args, end, err := p.list(")")
+			if err != nil {
+				return nil, err
+			}
+			node = &n8nJSNode{Kind: "call", Kids: append([]*n8nJSNode{node}, args...), Pos: node.Pos, End: end}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// list parses comma-separated expressions up to and including close.
+func (p *n8nJSParser) list(close string) ([]*n8nJSNode, int, error) {
+	var items []*n8nJSNode
+	for !p.isPunct(close) {
+		if p.isPunct("...") {
+			return nil, 0, p.errorAt(p.peek(), "spread syntax is not supported")
+		}
+		item, err := p.expression()
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	end := p.peek().End
+	return items, end, p.expect(close)
+}
+
+func (p *n8nJSParser) primary() (*n8nJSNode, error) {
+	tok := p.next()
+	switch tok.Kind {
+	case "ident":
+		if tok.Text == "new" || tok.Text == "function" || tok.Text == "this" {
+			return nil, &n8nExprError{Snippet: tok.Text, Reason: "JavaScript " + tok.Text + " is not supported"}
+		}
+		return &n8nJSNode{Kind: "ident", Name: tok.Text, Pos: tok.Pos, End: tok.End}, nil
+	case "num":
+		return &n8nJSNode{Kind: "num", Name: tok.Text, Pos: tok.Pos, End: tok.End}, nil
+	case "str":
+		return &n8nJSNode{Kind: "str", Name: tok.Value, Pos: tok.Pos, End: tok.End}, nil
+	case "tmpl":
+		return p.template(tok)
+	case "punct":
+		switch tok.Text {
+		case "(":
+			inner, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			items, end, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return &n8nJSNode{Kind: "array", Kids: items, Pos: tok.Pos, End: end}, nil
+		case "{":
+			return p.object(tok)
+		case "/":
+			return nil, p.errorAt(tok, "regular expression literals are not supported")
+		}
+	}
+	if tok.Kind == "eof" {
+		return nil, p.errorAt(tok, "missing operand")
+	}
+	return nil, p.errorAt(tok, "unexpected "+strconv.Quote(tok.Text))
+}
+
+func (p *n8nJSParser) object(open n8nJSToken) (*n8nJSNode, error) {
+	node := &n8nJSNode{Kind: "object", Pos: open.Pos}
+	for !p.isPunct("}") {
+		key := p.next()
+		switch key.Kind {
+		case "ident", "num":
+			node.Keys = append(node.Keys, key.Text)
+		case "str":
+			node.Keys = append(node.Keys, key.Value)
+		default:
+			return nil, p.errorAt(key, "unsupported object key")
+		}
+		if key.Kind == "ident" && (p.isPunct(",") || p.isPunct("}")) {
+			node.Kids = append(node.Kids, &n8nJSNode{Kind: "ident", Name: key.Text, Pos: key.Pos, End: key.End})
+		} else {
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			node.Kids = append(node.Kids, value)
+		}
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	node.End = p.peek().End
+	return node, p.expect("}")
+}
+
+// template splits a template literal into quasis and parsed ${} parts.
+func (p *n8nJSParser) template(tok n8nJSToken) (*n8nJSNode, error) {
+	node := &n8nJSNode{Kind: "tmpl", Pos: tok.Pos, End: tok.End}
+	raw := tok.Value
+	var text strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+			switch raw[i] {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			default:
+				text.WriteByte(raw[i])
+			}
+			continue
+		}
+		if raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '{' {
+			end, err := n8nTemplateExprEnd(raw, i+2)
+			if err != nil {
+				return nil, err
+			}
+			inner, err := parseN8NExpression(raw[i+2 : end-1])
+			if err != nil {
+				return nil, err
+			}
+			node.Quasis = append(node.Quasis, text.String())
+			text.Reset()
+			node.Kids = append(node.Kids, inner)
+			i = end - 1
+			continue
+		}
+		text.WriteByte(raw[i])
+	}
+	node.Quasis = append(node.Quasis, text.String())
+	return node, nil
+}
+
+// n8nExprLowering lowers a parsed expression. root is the Clojure form for
+// $json, refs maps upstream node names to their Clojure forms, and scope
+// maps arrow-function parameters to their Clojure symbols.
+type n8nExprLowering struct {
+	src   string
+	root  string
+	refs  map[string]string
+	scope map[string]string
+}
+
+func lowerN8NExpression(src string, nodeRefs map[string]string) (string, error) {
+	node, err := parseN8NExpression(src)
+	if err != nil {
+		return "", err
+	}
+	l := &n8nExprLowering{src: src, root: n8nJSONRootRef(nodeRefs), refs: nodeRefs, scope: map[string]string{}}
+	return l.lower(node)
+}
+
+func (l *n8nExprLowering) fail(node *n8nJSNode, reason string) error {
+	return &n8nExprError{Snippet: strings.TrimSpace(l.src[node.Pos:node.End]), Reason: reason}
+}
+
+func (l *n8nExprLowering) lower(node *n8nJSNode) (string, error) {
+	switch node.Kind {
+	case "num":
+		return node.Name, nil
+	case "str":
+		return ednQuote(node.Name), nil
+	case "tmpl":
+		parts := make([]string, 0, len(node.Quasis)+len(node.Kids))
+		for i, quasi := range node.Quasis {
+			if quasi != "" {
+				parts = append(parts, ednQuote(quasi))
+			}
+			if i < len(node.Kids) {
+				part, err := l.lower(node.Kids[i])
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, part)
+			}
+		}
+		return n8nStrForm(parts), nil
+	case "ident":
+		return l.ident(node)
+	case "member":
+		return l.member(node)
+	case "call":
+		return l.call(node)
+	case "unary":
+		arg, err := l.lower(node.Kids[0])
+		if err != nil {
+			return "", err
+		}
+		switch node.Op {
+		case "!":
+			return "(not " + arg + ")", nil
+		case "-":
+			return "(- " + arg + ")", nil
+		case "+":
+			return "(Double/parseDouble (str " + arg + "))", nil
+		}
+		return "", l.fail(node, "operator "+node.Op+" is not supported")
+	case "postfix":
+		arg, err := l.lower(node.Kids[0])
+		if err != nil {
+			return "", err
+		}
+		if node.Op == "++" {
+			return "(inc (or " + arg + " 0))", nil
+		}
+		return "(dec (or " + arg + " 0))", nil
+	case "binary":
+		return l.binary(node)
+	case "cond":
+		parts, err := l.lowerAll(node.Kids)
+		if err != nil {
+			return "", err
+		}
+		return "(if " + strings.Join(parts, " ") + ")", nil
+	case "array":
+		parts, err := l.lowerAll(node.Kids)
+		if err != nil {
+			return "", err
+		}
+		return "[" + strings.Join(parts, " ") + "]", nil
+	case "object":
+		parts := make([]string, 0, len(node.Keys))
+		for i, key := range node.Keys {
+			value, err := l.lower(node.Kids[i])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, ":"+n8nKebab(key, "field")+" "+value)
+		}
+		return "{" + strings.Join(parts, " ") + "}", nil
+	case "arrow":
+		params, body, err := l.fn(node, len(node.Params))
+		if err != nil {
+			return "", err
+		}
+		return n8nFnForm(params, body), nil
+	}
+	return "", l.fail(node, "unsupported expression")
+}
+
+func (l *n8nExprLowering) lowerAll(nodes []*n8nJSNode) ([]string, error) {
+	out := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		part, err := l.lower(node)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, part)
+	}
+	return out, nil
+}
+
+var n8nJSUnsupportedGlobals = map[string]string{
+	"$env":       "n8n environment variables have no equivalent; bind the value through a :requires slot",
+	"$vars":      "n8n variables have no equivalent; bind the value through a :requires slot",
+	"$execution": "n8n execution metadata is not available",
+	"$workflow":  "n8n workflow metadata is not available",
+	"$prevNode":  "$prevNode is not available",
+	"$runIndex":  "$runIndex is not available",
+	"$itemIndex": "$itemIndex is not available; use map-indexed in a function step",
+	"$binary":    "binary data is not available in expressions",
+	"$items":     "$items() is not translated; reference the upstream step directly",
+	"$today":     "$today is not translated; use $now",
+}
+
+func (l *n8nExprLowering) ident(node *n8nJSNode) (string, error) {
+	if symbol, ok := l.scope[node.Name]; ok {
+		return symbol, nil
+	}
+	switch name := node.Name; {
+	case name == "$json":
+		return l.root, nil
+	case name == "$now":
+		return "(flow/now-ms)", nil
+	case name == "true" || name == "false":
+		return name, nil
+	case name == "null" || name == "undefined":
+		return "nil", nil
+	default:
+		if reason, ok := n8nJSUnsupportedGlobals[name]; ok {
+			return "", l.fail(node, reason)
+		}
+		return "", l.fail(node, "unknown identifier "+name)
+	}
+}
+
+// staticKey returns the keyword or index form for a member property.
+func (l *n8nExprLowering) staticKey(node *n8nJSNode) (string, bool) {
+	if !node.Computed {
+		return ":" + n8nKebab(node.Name, "field"), true
+	}
+	switch index := node.Kids[1]; index.Kind {
+	case "str":
+		return ":" + n8nKebab(index.Name, "field"), true
+	case "num":
+		if _, err := strconv.Atoi(index.Name); err == nil {
+			return index.Name, true
+		}
+	}
+	return "", false
+}
+
+// nodeRef matches the n8n handles for another node's item JSON:
+// $node["X"].json, $("X").item.json, $("X").first().json and
+// $input.item.json. found is false when node is none of these.
+func (l *n8nExprLowering) nodeRef(node *n8nJSNode) (string, bool, error) {
+	if node.Kind != "member" || node.Computed || node.Name != "json" {
+		return "", false, nil
+	}
+	handle := node.Kids[0]
+	name := ""
+	switch {
+	case handle.Kind == "member" && handle.Computed && handle.Kids[0].Kind == "ident" && handle.Kids[0].Name == "$node" && handle.Kids[1].Kind == "str":
+		name = handle.Kids[1].Name
+	case handle.Kind == "member" && !handle.Computed && handle.Name == "item":
+		name = l.handleName(handle.Kids[0])
+	case handle.Kind == "call" && len(handle.Kids) == 1 && handle.Kids[0].Kind == "member" && (handle.Kids[0].Name == "first" || handle.Kids[0].Name == "last"):
+		name = l.handleName(handle.Kids[0].Kids[0])
+	}
+	switch name {
+	case "":
+		return "", false, nil
+	case "$input":
+		return l.root, true, nil
+	}
+	if ref, ok := l.refs[name]; ok {
+		return ref, true, nil
+	}
+	return "", true, l.fail(node, fmt.Sprintf("node %q is not an input of this step", name))
+}
+
+// handleName returns "X" for $("X") and "$input" for $input.
+func (l *n8nExprLowering) handleName(node *n8nJSNode) string {
+	if node.Kind == "ident" && node.Name == "$input" {
+		return "$input"
+	}
+	if node.Kind == "call" && len(node.Kids) == 2 && node.Kids[0].Kind == "ident" && node.Kids[0].Name == "$" && node.Kids[1].Kind == "str" {
+		return node.Kids[1].Name
+	}
+	return ""
+}
+
+// path flattens a chain of static member accesses into a base form and
+// keys, so $json.a.b becomes (get-in input [:a :b]).
+func (l *n8nExprLowering) path(node *n8nJSNode) (string, []string, error) {
+	if ref, found, err := l.nodeRef(node); found || err != nil {
+		return ref, nil, err
+	}
+	if node.Kind == "member" && !(node.Name == "length" && !node.Computed) {
+		if key, ok := l.staticKey(node); ok {
+			base, keys, err := l.path(node.Kids[0])
+			if err != nil {
+				return "", nil, err
+			}
+			return base, append(keys, key), nil
+		}
+	}
+	form, err := l.lower(node)
+	return form, nil, err
+}
+
+func (l *n8nExprLowering) member(node *n8nJSNode) (string, error) {
+	if !node.Computed && node.Name == "length" {
+		target, err := l.lower(node.Kids[0])
+		if err != nil {
+			return "", err
+		}
+		return "(count " + target + ")", nil
+	}
+	if _, ok := l.staticKey(node); !ok {
+		target, err := l.lower(node.Kids[0])
+		if err != nil {
+			return "", err
+		}
+		index, err := l.lower(node.Kids[1])
+		if err != nil {
+			return "", err
+		}
+		return "(get " + target + " " + index + ")", nil
+	}
+	base, keys, err := l.path(node)
+	if err != nil {
+		return "", err
+	}
+	switch len(keys) {
+	case 0:
+		return base, nil
+	case 1:
+		return "(get " + base + " " + keys[0] + ")", nil
+	}
+	return "(get-in " + base + " [" + strings.Join(keys, " ") + "])", nil
+}
+
+func (l *n8nExprLowering) binary(node *n8nJSNode) (string, error) {
+	if node.Op == "+" && n8nJSStringy(node) {
+		var parts []string
+		for _, operand := range n8nJSConcatOperands(node) {
+			part, err := l.lower(operand)
+			if err != nil {
+				return "", err
+			}
+			if strings.HasPrefix(part, "(str ") && operand.Kind == "tmpl" {
+				parts = append(parts, n8nStrArgs(part)...)
+				continue
+			}
+			parts = append(parts, part)
+		}
+		return n8nStrForm(parts), nil
+	}
+	left, err := l.lower(node.Kids[0])
+	if err != nil {
+		return "", err
+	}
+	right, err := l.lower(node.Kids[1])
+	if err != nil {
+		return "", err
+	}
+	switch node.Op {
+	case "+", "-", "*", "<", "<=", ">", ">=":
+		return "(" + node.Op + " " + left + " " + right + ")", nil
+	case "/":
+		// JS division is floating point; Clojure's / returns a Ratio for integers.
+		return "(/ (double " + left + ") " + right + ")", nil
+	case "%":
+		// JS % keeps the sign of the dividend, like rem and unlike mod.
+		return "(rem " + left + " " + right + ")", nil
+	case "==", "===":
+		return "(= " + left + " " + right + ")", nil
+	case "!=", "!==":
+		return "(not= " + left + " " + right + ")", nil
+	case "&&":
+		return "(and " + left + " " + right + ")", nil
+	case "||":
+		return "(or " + left + " " + right + ")", nil
+	case "??":
+		return "(if (some? " + left + ") " + left + " " + right + ")", nil
+	}
+	return "", l.fail(node, "operator "+node.Op+" is not supported")
+}
+
+var n8nJSStringMethods = map[string]bool{
+	"toLowerCase": true, "toUpperCase": true, "trim": true, "toString": true, "join": true,
+	"substring": true, "replace": true, "replaceAll": true, "toFixed": true, "toISO": true,
+}
+
+// n8nJSStringy reports whether an expression is statically a string, which
+// decides whether + concatenates or adds.
+func n8nJSStringy(node *n8nJSNode) bool {
+	switch node.Kind {
+	case "str", "tmpl":
+		return true
+	case "binary":
+		return node.Op == "+" && (n8nJSStringy(node.Kids[0]) || n8nJSStringy(node.Kids[1]))
+	case "call":
+		callee := node.Kids[0]
+		if callee.Kind == "ident" && callee.Name == "String" {
+			return true
+		}
+		return callee.Kind == "member" && !callee.Computed && n8nJSStringMethods[callee.Name]
+	}
+	return false
+}
+
+func n8nJSConcatOperands(node *n8nJSNode) []*n8nJSNode {
+	if node.Kind == "binary" && node.Op == "+" && n8nJSStringy(node) {
+		return append(n8nJSConcatOperands(node.Kids[0]), n8nJSConcatOperands(node.Kids[1])...)
+	}
+	return []*n8nJSNode{node}
+}
+
+func n8nStrForm(parts []string) string {
+	switch len(parts) {
+	case 0:
+		return `""`
+	case 1:
+		if strings.HasPrefix(parts[0], `"`) {
+			return parts[0]
+		}
+	}
+	return "(str " + strings.Join(parts, " ") + ")"
+}
+
+// n8nStrArgs splits a (str ...) form produced by n8nStrForm back into its
+// top-level arguments.
+func n8nStrArgs(form string) []string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(form, "(str "), ")")
+	var out []string
+	depth, start := 0, 0
+	inString := false
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ' ' && depth == 0:
+			out = append(out, inner[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, inner[start:])
+}
+
+// n8nJSReservedSymbols are clojure.core names the lowering emits; callback
+// parameters with these names are renamed so they cannot shadow them.
+var n8nJSReservedSymbols = map[string]bool{
+	"str": true, "get": true, "count": true, "name": true, "keys": true, "vals": true, "first": true,
+	"last": true, "subs": true, "format": true, "double": true, "long": true, "boolean": true,
+	"some": true, "filter": true, "reduce": true, "not": true, "inc": true, "dec": true, "mod": true,
+	"vec": true, "min": true, "max": true, "v": true,
+}
+
+// fn lowers an arrow function argument; arity is the parameter count the
+// caller expects (extra JavaScript callback parameters are rejected). It
+// returns the Clojure parameter symbols and body.
+func (l *n8nExprLowering) fn(node *n8nJSNode, arity int) ([]string, string, error) {
+	if node.Kind != "arrow" {
+		return nil, "", l.fail(node, "only arrow function callbacks are supported")
+	}
+	if len(node.Params) > arity {
+		return nil, "", l.fail(node, fmt.Sprintf("callback takes %d parameters; only %d are supported here", len(node.Params), arity))
+	}
+	saved := l.scope
+	l.scope = make(map[string]string, len(saved)+len(node.Params))
+	for name, symbol := range saved {
+		l.scope[name] = symbol
+	}
+	symbols := make([]string, 0, len(node.Params))
+	for _, param := range node.Params {
+		symbol := param
+		if n8nJSReservedSymbols[symbol] {
+			symbol += "'"
+		}
+		l.scope[param] = symbol
+		symbols = append(symbols, symbol)
+	}
+	body, err := l.lower(node.Kids[0])
+	l.scope = saved
+	if err != nil {
+		return nil, "", err
+	}
+	return symbols, body, nil
+}
+
+func n8nFnForm(params []string, body string) string {
+	return "(fn [" + strings.Join(params, " ") + "] " + body + ")"
+}
+
+func (l *n8nExprLowering) call(node *n8nJSNode) (string, error) {
+	callee := node.Kids[0]
+	args := node.Kids[1:]
+	if callee.Kind == "ident" {
+		return l.globalCall(node, callee.Name, args)
+	}
+	if callee.Kind != "member" || callee.Computed {
+		return "", l.fail(node, "unsupported call")
+	}
+	method := callee.Name
+	target := callee.Kids[0]
+	if _, local := l.scope[target.Name]; target.Kind == "ident" && !local {
+		switch target.Name {
+		case "Math", "JSON", "Object", "Array", "Number", "String", "DateTime":
+			return l.globalCall(node, target.Name+"."+method, args)
+		}
+	}
+	if n8nJSIsNow(target) {
+		switch method {
+		case "toMillis", "valueOf":
+			return "(flow/now-ms)", nil
+		case "toISO":
+			return "(str (java.time.Instant/ofEpochMilli (flow/now-ms)))", nil
+		}
+		return "", l.fail(node, "Luxon method ."+method+"() is not translated")
+	}
+	obj, err := l.lower(target)
+	if err != nil {
+		return "", err
+	}
+	lowered, err := l.lowerAll(n8nJSNonFnArgs(args))
+	if err != nil {
+		return "", err
+	}
+	text := obj
+	if !n8nJSStringy(target) {
+		text = "(str " + obj + ")"
+	}
+	want := func(n int) error {
+		if len(args) != n {
+			return l.fail(node, fmt.Sprintf(".%s() with %d arguments is not supported", method, len(args)))
+		}
+		return nil
+	}
+	switch method {
+	case "toLowerCase", "toUpperCase", "trim", "toString":
+		if err := want(0); err != nil {
+			return "", err
+		}
+		if method == "toString" {
+			return text, nil
+		}
+		fn := map[string]string{"toLowerCase": "clojure.string/lower-case", "toUpperCase": "clojure.string/upper-case", "trim": "clojure.string/trim"}[method]
+		return "(" + fn + " " + text + ")", nil
+	case "startsWith", "endsWith":
+		if err := want(1); err != nil {
+			return "", err
+		}
+		fn := map[string]string{"startsWith": "clojure.string/starts-with?", "endsWith": "clojure.string/ends-with?"}[method]
+		return "(" + fn + " " + text + " (str " + lowered[0] + "))", nil
+	case "includes":
+		if err := want(1); err != nil {
+			return "", err
+		}
+		return "(let [v " + obj + "] (if (string? v) (clojure.string/includes? v (str " + lowered[0] + ")) (boolean (some #(= % " + lowered[0] + ") v))))", nil
+	case "split":
+		if err := want(1); err != nil {
+			return "", err
+		}
+		return "(clojure.string/split " + text + " (re-pattern (java.util.regex.Pattern/quote " + lowered[0] + ")))", nil
+	case "replace", "replaceAll":
+		if err := want(2); err != nil {
+			return "", err
+		}
+		if args[0].Kind != "str" {
+			return "", l.fail(node, "."+method+"() with a regular expression or computed pattern is not supported")
+		}
+		fn := "clojure.string/replace-first"
+		if method == "replaceAll" {
+			fn = "clojure.string/replace"
+		}
+		return "(" + fn + " " + text + " " + lowered[0] + " (str " + lowered[1] + "))", nil
+	case "substring":
+		switch len(args) {
+		case 1:
+			return "(subs " + text + " " + lowered[0] + ")", nil
+		case 2:
+			return "(subs " + text + " " + lowered[0] + " " + lowered[1] + ")", nil
+		}
+		return "", want(2)
+	case "slice":
+		for _, a := range args {
+			if a.Kind == "unary" && a.Op == "-" {
+				return "", l.fail(node, ".slice() with a negative index is not supported")
+			}
+		}
+		switch len(args) {
+		case 1:
+			return "(let [v " + obj + "] (if (string? v) (subs v " + lowered[0] + ") (vec (drop " + lowered[0] + " v))))", nil
+		case 2:
+			return "(let [v " + obj + "] (if (string? v) (subs v " + lowered[0] + " " + lowered[1] + ") (subvec (vec v) " + lowered[0] + " " + lowered[1] + ")))", nil
+		}
+		return "", want(2)
+	case "indexOf":
+		if err := want(1); err != nil {
+			return "", err
+		}
+		return "(let [v " + obj + "] (if (string? v) (or (clojure.string/index-of v (str " + lowered[0] + ")) -1) (.indexOf (vec v) " + lowered[0] + ")))", nil
+	case "join":
+		sep := `","`
+		if len(args) == 1 {
+			sep = lowered[0]
+		} else if err := want(0); err != nil {
+			return "", err
+		}
+		return "(clojure.string/join " + sep + " " + obj + ")", nil
+	case "concat":
+		return "(into (vec " + obj + ") cat [" + strings.Join(lowered, " ") + "])", nil
+	case "toFixed":
+		if err := want(1); err != nil {
+			return "", err
+		}
+		if args[0].Kind != "num" {
+			return "", l.fail(node, ".toFixed() needs a literal digit count")
+		}
+		return "(format " + ednQuote("%."+args[0].Name+"f") + " (double " + obj + "))", nil
+	case "map", "filter", "find", "some", "every":
+		if len(args) != 1 {
+			return "", want(1)
+		}
+		arity := 1
+		if method == "map" {
+			arity = 2
+		}
+		params, body, err := l.fn(args[0], arity)
+		if err != nil {
+			return "", err
+		}
+		fn := n8nFnForm(params, body)
+		switch method {
+		case "map":
+			if len(params) == 2 {
+				// map-indexed passes the index first; JavaScript passes it second.
+				return "(vec (map-indexed " + n8nFnForm([]string{params[1], params[0]}, body) + " " + obj + "))", nil
+			}
+			return "(mapv " + fn + " " + obj + ")", nil
+		case "filter":
+			return "(filterv " + fn + " " + obj + ")", nil
+		case "find":
+			return "(first (filter " + fn + " " + obj + "))", nil
+		case "some":
+			return "(boolean (some " + fn + " " + obj + "))", nil
+		default:
+			return "(every? " + fn + " " + obj + ")", nil
+		}
+	case "reduce":
+		if len(args) < 1 || len(args) > 2 {
+			return "", want(2)
+		}
+		params, body, err := l.fn(args[0], 2)
+		if err != nil {
+			return "", err
+		}
+		fn := n8nFnForm(params, body)
+		if len(args) == 2 {
+			return "(reduce " + fn + " " + lowered[0] + " " + obj + ")", nil
+		}
+		return "(reduce " + fn + " " + obj + ")", nil
+	}
+	return "", l.fail(node, "method ."+method+"() is not translated")
+}
+
+// n8nJSNonFnArgs drops arrow-function arguments, which callers lower with
+// their own scope.
+func n8nJSNonFnArgs(args []*n8nJSNode) []*n8nJSNode {
+	out := make([]*n8nJSNode, 0, len(args))
+	for _, arg := range args {
+		if arg.Kind != "arrow" {
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+func n8nJSIsNow(node *n8nJSNode) bool {
+	if node.Kind == "ident" && node.Name == "$now" {
+		return true
+	}
+	return node.Kind == "call" && len(node.Kids) == 1 && node.Kids[0].Kind == "member" && node.Kids[0].Name == "now" &&
+		node.Kids[0].Kids[0].Kind == "ident" && node.Kids[0].Kids[0].Name == "DateTime"
+}
+
+func (l *n8nExprLowering) globalCall(node *n8nJSNode, name string, args []*n8nJSNode) (string, error) {
+	if name == "DateTime.now" && len(args) == 0 {
+		return "(flow/now-ms)", nil
+	}
+	if _, local := l.scope[name]; local {
+		return "", l.fail(node, "calling a callback parameter is not supported")
+	}
+	if name == "$" {
+		return "", l.fail(node, "$() node handles are only translated as $(\"Node\").item.json")
+	}
+	lowered, err := l.lowerAll(args)
+	if err != nil {
+		return "", err
+	}
+	one := func(format string) (string, error) {
+		if len(lowered) != 1 {
+			return "", l.fail(node, fmt.Sprintf("%s() with %d arguments is not supported", name, len(lowered)))
+		}
+		return fmt.Sprintf(format, lowered[0]), nil
+	}
+	switch name {
+	case "String":
+		return one("(str %s)")
+	case "Number", "parseFloat", "Number.parseFloat":
+		return one("(Double/parseDouble (str %s))")
+	case "parseInt", "Number.parseInt":
+		if len(lowered) == 2 && args[1].Kind == "num" {
+			return "(Long/parseLong (str " + lowered[0] + ") " + args[1].Name + ")", nil
+		}
+		return one("(Long/parseLong (str %s))")
+	case "Boolean":
+		return one("(boolean %s)")
+	case "Math.round":
+		return one("(Math/round (double %s))")
+	case "Math.floor":
+		return one("(long (Math/floor (double %s)))")
+	case "Math.ceil":
+		return one("(long (Math/ceil (double %s)))")
+	case "Math.abs":
+		return one("(Math/abs %s)")
+	case "Math.max", "Math.min":
+		if len(lowered) == 0 {
+			break
+		}
+		return "(" + strings.TrimPrefix(name, "Math.") + " " + strings.Join(lowered, " ") + ")", nil
+	case "Object.keys":
+		return one("(mapv name (keys %s))")
+	case "Object.values":
+		return one("(vec (vals %s))")
+	case "Array.isArray":
+		return one("(sequential? %s)")
+	case "JSON.stringify", "JSON.parse":
+		return "", l.fail(node, name+"() is not translated; use a JSON library in a function step")
+	}
+	return "", l.fail(node, name+"() is not translated")
+}
+
+// n8nTemplateSegment is literal text or one {{ }} expression of an n8n
+// parameter value.
+type n8nTemplateSegment struct {
+	Text string
+	Expr bool
+}
+
+// splitN8NTemplate splits a parameter value into text and {{ }} segments.
+// The closing }} is found outside strings and braces, so object literals
+// and template literals inside an expression are kept whole.
+func splitN8NTemplate(value string) ([]n8nTemplateSegment, error) {
+	var segments []n8nTemplateSegment
+	cursor := 0
+	for {
+		start := strings.Index(value[cursor:], "{{")
+		if start < 0 {
+			break
+		}
+		start += cursor
+		if start > cursor {
+			segments = append(segments, n8nTemplateSegment{Text: value[cursor:start]})
+		}
+		depth := 0
+		end := -1
+		for i := start + 2; i < len(value) && end < 0; i++ {
+			switch c := value[i]; c {
+			case '{':
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				} else if i+1 < len(value) && value[i+1] == '}' {
+					end = i
+				}
+			case '"', '\'', '`':
+				for i++; i < len(value) && value[i] != c; i++ {
+					if value[i] == '\\' {
+						i++
+					}
+				}
+			}
+		}
+		if end < 0 {
+			return nil, &n8nExprError{Snippet: value[start:], Reason: "unterminated {{ }}"}
+		}
+		segments = append(segments, n8nTemplateSegment{Text: value[start+2 : end], Expr: true})
+		cursor = end + 2
+	}
+	if cursor < len(value) {
+		segments = append(segments, n8nTemplateSegment{Text: value[cursor:]})
+	}
+	return segments, nil
+}
+
+// lowerN8NValue lowers a parameter value containing {{ }} expressions: a
+// value that is exactly one expression becomes that expression, anything
+// else a (str ...) of its parts.
+func lowerN8NValue(value string, nodeRefs map[string]string) (string, error) {
+	value = normalizeN8NExpressionString(normalizeN8NTemplateLiteral(value))
+	segments, err := splitN8NTemplate(value)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(segments))
+	exprs := 0
+	for _, segment := range segments {
+		if !segment.Expr {
+			parts = append(parts, ednQuote(segment.Text))
+			continue
+		}
+		exprs++
+		form, err := lowerN8NExpression(segment.Text, nodeRefs)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, form)
+	}
+	if exprs == 0 {
+		return "", &n8nExprError{Reason: "no {{ }} expression"}
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(str " + strings.Join(parts, " ") + ")", nil
+}
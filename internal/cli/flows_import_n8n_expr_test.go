@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLowerN8NValue(t *testing.T) {
+	refs := map[string]string{"Lookup": "(get input :lookup)"}
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`{{ $json.email.toLowerCase().trim() }}`, `(clojure.string/trim (clojure.string/lower-case (str (get input :email))))`},
+		{`{{ $json.items.map(item => item.id) }}`, `(mapv (fn [item] (get item :id)) (get input :items))`},
+		{`{{ $json.items.map((item, i) => ({id: item.id, position: i})) }}`, `(vec (map-indexed (fn [i item] {:id (get item :id) :position i}) (get input :items)))`},
+		{`{{ $json.items.filter(name => name.active).length }}`, `(count (filterv (fn [name'] (get name' :active)) (get input :items)))`},
+		{`{{ $json.user?.profile?.city ?? "unknown" }}`, `(if (some? (get-in input [:user :profile :city])) (get-in input [:user :profile :city]) "unknown")`},
+		{"{{ `Hi ${$json.name.toUpperCase()}, ${$json.items.length} items` }}", `(str "Hi " (clojure.string/upper-case (str (get input :name))) ", " (count (get input :items)) " items")`},
+		{`{{ "Order " + $json.id + " is " + ($json.paid ? "paid" : "open") }}`, `(str "Order " (get input :id) " is " (if (get input :paid) "paid" "open"))`},
+		{`{{ $json.total > 100 && $json.status !== "void" }}`, `(and (> (get input :total) 100) (not= (get input :status) "void"))`},
+		{`{{ $json.lines[0].sku }}`, `(get-in input [:lines 0 :sku])`},
+		{`{{ $("Lookup").item.json.page + 1 }}`, `(+ (get (get input :lookup) :page) 1)`},
+		{`{{ $now.toISO() }}`, `(str (java.time.Instant/ofEpochMilli (flow/now-ms)))`},
+		{`{{ DateTime.now().toMillis() }}`, `(flow/now-ms)`},
+		{`Total: {{ Math.round($json.total * 100) / 100 }} EUR`, `(str "Total: " (/ (double (Math/round (double (* (get input :total) 100)))) 100) " EUR")`},
+		{`{{ $json.offset % 7 }}`, `(rem (get input :offset) 7)`},
+		{`{{ {a: {b: 1}}.a }}`, `(get {:a {:b 1}} :a)`},
+	}
+	for _, tc := range cases {
+		got, err := lowerN8NValue(tc.in, refs)
+		if err != nil {
+			t.Fatalf("lowerN8NValue(%q): %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("lowerN8NValue(%q)\n got %s\nwant %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestLowerN8NValueReportsUntranslatableSubexpression(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`{{ $json.name.padStart(5, "0") }}`, `method .padStart() is not translated in "$json.name.padStart(5, \"0\")"`},
+		{`{{ $json.text.replace(/a/g, "b") }}`, `regular expression literals are not supported`},
+		{`{{ $node["Missing"].json.id }}`, `node "Missing" is not an input of this step`},
+		{`{{ $env.API_KEY }}`, `n8n environment variables have no equivalent`},
+		{`{{ $now.toFormat("yyyy-MM-dd") }}`, `Luxon method .toFormat() is not translated`},
+		{`{{ $json.items.map(function (i) { return i }) }}`, `JavaScript function is not supported`},
+		{`{{ $json.a = 1 }}`, `assignment is not supported`},
+		{`{{ $json.a + }}`, `syntax error: missing operand at end of expression`},
+	}
+	for _, tc := range cases {
+		_, err := lowerN8NValue(tc.in, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("lowerN8NValue(%q) error = %v, want %q", tc.in, err, tc.want)
+		}
+	}
+}
+
+func TestRenderN8NSetValueTodoNamesReason(t *testing.T) {
+	expr, todo, ok := renderN8NSetValue("slug", `={{ $json.title.normalize("NFD") }}`, nil)
+	if !ok || expr != `"{{ $json.title.normalize(\"NFD\") }}"` {
+		t.Fatalf("unexpected fallback %q %v", expr, ok)
+	}
+	if want := `translate n8n expression for Set field "slug": method .normalize() is not translated`; !strings.HasPrefix(todo, want) {
+		t.Fatalf("todo = %q, want prefix %q", todo, want)
+	}
+}
+
+func TestSplitN8NTemplateKeepsBracesInsideExpressions(t *testing.T) {
+	segments, err := splitN8NTemplate("a {{ {x: 1}.x }} b {{ `}}` }}")
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if len(segments) != 4 || segments[1].Text != " {x: 1}.x " || segments[3].Text != " `}}` " {
+		t.Fatalf("unexpected segments %+v", segments)
+	}
+	if _, err := splitN8NTemplate("{{ $json.a"); err == nil {
+		t.Fatal("expected unterminated expression to be rejected")
+	}
+}
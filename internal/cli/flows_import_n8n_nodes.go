@@ -0,0 +1,675 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type n8nNodeConverterFunc func(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string)
+
+// n8nNodeKind is the lower-cased node type without its package prefix, e.g.
+// "scheduletrigger" for n8n-nodes-base.scheduleTrigger.
+func n8nNodeKind(node n8nNode) string {
+	typ := strings.ToLower(strings.TrimSpace(node.Type))
+	if idx := strings.LastIndex(typ, "."); idx >= 0 {
+		typ = typ[idx+1:]
+	}
+	return typ
+}
+
+func n8nIsLoopNode(node n8nNode) bool {
+	return n8nNodeKind(node) == "splitinbatches"
+}
+
+// n8nParamMap returns the nested parameter object at key, or nil.
+func n8nParamMap(values map[string]any, key string) map[string]any {
+	m, _ := values[key].(map[string]any)
+	return m
+}
+
+// n8nParamList returns the list under values[group][item], the shape n8n
+// uses for fixedCollection parameters such as sortFieldsUi.sortField.
+func n8nParamList(values map[string]any, group, item string) []map[string]any {
+	var raw []any
+	switch v := values[group].(type) {
+	case []any:
+		raw = v
+	case map[string]any:
+		raw, _ = v[item].([]any)
+	}
+	out := make([]map[string]any, 0, len(raw))
+	for _, entry := range raw {
+		if m, ok := entry.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// n8nFieldNames splits a comma-separated n8n field list.
+func n8nFieldNames(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// renderN8NItemField reads a dotted n8n field name from an item, using the
+// same kebab-case keywords as translated $json paths.
+func renderN8NItemField(item, field string) string {
+	return renderN8NGetPath(item, strings.Split(field, "."))
+}
+
+// renderN8NItemsFunction wraps an item-list transform. n8n nodes receive a
+// list of items; imported flows pass a map, so :items is used when present
+// and the map itself is treated as the only item otherwise.
+func renderN8NItemsFunction(inputPlan n8nInputPlan, bindings []string, result string) string {
+	return renderN8NItemsFunctionFrom(inputPlan, nil, bindings, result)
+}
+
+// renderN8NItemsFunctionFrom is renderN8NItemsFunction with bindings that
+// run before input is narrowed to the current item list's source.
+func renderN8NItemsFunctionFrom(inputPlan n8nInputPlan, pre, bindings []string, result string) string {
+	lines := make([]string, 0, len(pre)+len(bindings)+2)
+	lines = append(lines, pre...)
+	if root := n8nJSONRootRef(inputPlan.FunctionRefs); root != "input" {
+		lines = append(lines, "input "+root)
+	}
+	lines = append(lines, "items (let [v (:items input)] (if (sequential? v) (vec v) [input]))")
+	lines = append(lines, bindings...)
+	return "(fn [input]\n  (let [" + strings.Join(lines, "\n        ") + "]\n    " + result + "))"
+}
+
+// n8nPerItemResult applies update-item to every item, or to the input map
+// when there is no item list.
+const n8nPerItemResult = "(if (sequential? (:items input))\n      (assoc input :items (mapv update-item items))\n      (update-item input))"
+
+// renderN8NParamExpr renders a node parameter as a Clojure expression over
+// the current item, translating n8n {{ }} expressions where possible.
+func renderN8NParamExpr(node n8nNode, label string, value any) (string, []string) {
+	s, ok := value.(string)
+	if !ok || !strings.Contains(s, "{{") {
+		return ednValue(value), nil
+	}
+	s = normalizeN8NExpressionString(normalizeN8NTemplateLiteral(s))
+	expr, err := lowerN8NValue(s, nil)
+	if err != nil {
+		return ednQuote(s), []string{fmt.Sprintf("translate n8n expression for %s of node %q: %v", label, node.Name, err)}
+	}
+	return expr, nil
+}
+
+func renderN8NFunctionNode(node n8nNode, stepID string, inputPlan n8nInputPlan, code string, todos []string) (string, []string, []string, []string, []string) {
+	fn := renderFunction(stepID, code)
+	binding := renderFunctionStep(node, stepID, inputPlan.Expr)
+	return binding, nil, nil, []string{fn}, todos
+}
+
+func convertN8NFilterNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	refs, pre := n8nFilterItemRefs(inputPlan.FunctionRefs, convertedByName)
+	condition, ok := n8nIFCondition(node.Parameters, refs)
+	var todos []string
+	if !ok {
+		condition = "true"
+		todos = append(todos, fmt.Sprintf("translate Filter node %q conditions", node.Name))
+	}
+	code := renderN8NItemsFunctionFrom(inputPlan, pre, []string{
+		"keep? (fn [input] " + condition + ")",
+		"kept (filterv keep? items)",
+	}, "(cond\n      (sequential? (:items input)) (assoc input :items kept)\n      (seq kept) input\n      :else (assoc input :n8n-import/skipped true))")
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, todos)
+}
+
+// n8nFilterItemRefs adapts a step's function refs to the Filter's keep?
+// predicate, whose input is one item: $json and a sole input read that item,
+// and other inputs read the step's input, bound as source before it is
+// shadowed.
+func n8nFilterItemRefs(functionRefs map[string]string, convertedByName map[string]n8nConvertedNode) (map[string]string, []string) {
+	if len(functionRefs) == 0 {
+		return nil, nil
+	}
+	refs := map[string]string{}
+	var pre []string
+	for name, ref := range functionRefs {
+		item, ok := convertedByName[name]
+		switch {
+		case name == "$json":
+		case ref == "input":
+			refs[name] = "input"
+		case ok:
+			refs[name] = "(get source :" + item.StepID + ")"
+			pre = []string{"source input"}
+		}
+	}
+	return refs, pre
+}
+
+func convertN8NSortNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	switch strings.ToLower(firstNonEmpty(stringParam(node.Parameters, "type"), "simple")) {
+	case "random":
+		code := renderN8NItemsFunction(inputPlan, nil, "(assoc input :items (vec (shuffle items)))")
+		return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+	case "simple":
+	default:
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	fields := n8nParamList(node.Parameters, "sortFieldsUi", "sortField")
+	if len(fields) == 0 {
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	compares := make([]string, 0, len(fields))
+	for _, field := range fields {
+		name := stringParam(field, "fieldName")
+		left, right := renderN8NItemField("a", name), renderN8NItemField("b", name)
+		if strings.EqualFold(stringParam(field, "order"), "descending") {
+			left, right = right, left
+		}
+		compares = append(compares, "(compare "+left+" "+right+")")
+	}
+	code := renderN8NItemsFunction(inputPlan, []string{
+		"by-fields (fn [a b]\n                    (or (first (remove zero? [" + strings.Join(compares, "\n                                              ") + "]))\n                        0))",
+	}, "(assoc input :items (vec (sort by-fields items)))")
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+}
+
+func convertN8NLimitNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	limit := intParam(node.Parameters, 1, "maxItems")
+	take := "take"
+	if strings.EqualFold(stringParam(node.Parameters, "keep"), "lastItems") {
+		take = "take-last"
+	}
+	code := renderN8NItemsFunction(inputPlan, nil, fmt.Sprintf("(assoc input :items (vec (%s %d items)))", take, limit))
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+}
+
+func convertN8NRemoveDuplicatesNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	if op := stringParam(node.Parameters, "operation"); op != "" && op != "removeDuplicateInputItems" {
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	keyFn := "identity"
+	switch stringParam(node.Parameters, "compare") {
+	case "selectedFields":
+		fieldList, _ := node.Parameters["fieldsToCompare"].(string)
+		names := n8nFieldNames(fieldList)
+		if len(names) == 0 {
+			for _, field := range n8nParamList(node.Parameters, "fieldsToCompare", "fields") {
+				names = append(names, stringParam(field, "fieldName"))
+			}
+		}
+		if len(names) == 0 {
+			return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+		}
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, renderN8NItemField("item", name))
+		}
+		keyFn = "(fn [item] [" + strings.Join(parts, " ") + "])"
+	case "allFieldsExcept":
+		names := n8nFieldNames(stringParam(node.Parameters, "fieldsToExclude"))
+		keys := make([]string, 0, len(names))
+		for _, name := range names {
+			keys = append(keys, ":"+n8nKebab(name, "field"))
+		}
+		keyFn = "(fn [item] (dissoc item " + strings.Join(keys, " ") + "))"
+	}
+	code := renderN8NItemsFunction(inputPlan, []string{
+		"item-key " + keyFn,
+		"unique (second (reduce (fn [[seen out] item]\n                                  (let [k (item-key item)]\n                                    (if (contains? seen k)\n                                      [seen out]\n                                      [(conj seen k) (conj out item)])))\n                                [#{} []]\n                                items))",
+	}, "(assoc input :items unique)")
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+}
+
+func convertN8NAggregateNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	if stringParam(node.Parameters, "aggregate") == "aggregateAllItemData" {
+		dest := n8nKebab(firstNonEmpty(stringParam(node.Parameters, "destinationFieldName"), "data"), "data")
+		code := renderN8NItemsFunction(inputPlan, nil, "{:"+dest+" items}")
+		return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+	}
+	fields := n8nParamList(node.Parameters, "fieldsToAggregate", "fieldToAggregate")
+	if len(fields) == 0 {
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	options := n8nParamMap(node.Parameters, "options")
+	collect := "(vec (keep (fn [item] %s) items))"
+	if boolParam(options, "keepMissing") {
+		collect = "(mapv (fn [item] %s) items)"
+	}
+	if boolParam(options, "mergeLists") {
+		collect = "(vec (mapcat (fn [item] (let [v %s] (if (sequential? v) v (when (some? v) [v])))) items))"
+	}
+	entries := make([]string, 0, len(fields))
+	for _, field := range fields {
+		name := stringParam(field, "fieldToAggregate")
+		out := name
+		if boolParam(field, "renameField") {
+			out = firstNonEmpty(stringParam(field, "outputFieldName"), name)
+		}
+		entries = append(entries, ":"+n8nKebab(out, "field")+" "+fmt.Sprintf(collect, renderN8NItemField("item", name)))
+	}
+	code := renderN8NItemsFunction(inputPlan, nil, "{"+strings.Join(entries, "\n     ")+"}")
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, nil)
+}
+
+var n8nChronoUnits = map[string]string{
+	"years":        "YEARS",
+	"months":       "MONTHS",
+	"weeks":        "WEEKS",
+	"days":         "DAYS",
+	"hours":        "HOURS",
+	"minutes":      "MINUTES",
+	"seconds":      "SECONDS",
+	"milliseconds": "MILLIS",
+}
+
+// n8nParseDateBinding parses ISO dates and date-times; a bare date is taken
+// as midnight UTC.
+const n8nParseDateBinding = `parse-date (fn [v]
+                     (let [s (str v)]
+                       (if (re-find #"T" s)
+                         (java.time.OffsetDateTime/parse s)
+                         (.atStartOfDay (java.time.LocalDate/parse s) java.time.ZoneOffset/UTC))))`
+
+func convertN8NDateTimeNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	params := node.Parameters
+	operation := firstNonEmpty(stringParam(params, "operation"), "getCurrentDate")
+	var bindings []string
+	var value string
+	var todos []string
+	output := ""
+	switch operation {
+	case "getCurrentDate":
+		output = firstNonEmpty(stringParam(params, "outputFieldName"), "currentDate")
+		value = "(str (java.time.Instant/now))"
+		if include, ok := params["includeTime"].(bool); ok && !include {
+			value = "(str (java.time.LocalDate/now java.time.ZoneOffset/UTC))"
+		}
+	case "addToDate", "subtractFromDate":
+		unit, ok := n8nChronoUnits[strings.ToLower(firstNonEmpty(stringParam(params, "timeUnit"), "days"))]
+		if !ok {
+			return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+		}
+		output = firstNonEmpty(stringParam(params, "outputFieldName"), "newDate")
+		date, dateTodos := renderN8NParamExpr(node, "magnitude", params["magnitude"])
+		todos = append(todos, dateTodos...)
+		method := ".plus"
+		if operation == "subtractFromDate" {
+			method = ".minus"
+		}
+		bindings = append(bindings, n8nParseDateBinding)
+		value = fmt.Sprintf("(str (%s (parse-date %s) %d java.time.temporal.ChronoUnit/%s))", method, date, intParam(params, 0, "duration"), unit)
+	case "formatDate":
+		output = firstNonEmpty(stringParam(params, "outputFieldName"), "formattedDate")
+		date, dateTodos := renderN8NParamExpr(node, "date", params["date"])
+		todos = append(todos, dateTodos...)
+		format := stringParam(params, "format")
+		if format == "" || format == "custom" {
+			format = firstNonEmpty(stringParam(params, "customFormat"), "yyyy-MM-dd")
+			todos = append(todos, fmt.Sprintf("check DateTime node %q format %q; n8n uses Luxon tokens, the import uses java.time", node.Name, format))
+		}
+		bindings = append(bindings, n8nParseDateBinding)
+		value = fmt.Sprintf("(.format (java.time.format.DateTimeFormatter/ofPattern %s) (parse-date %s))", ednQuote(format), date)
+	default:
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	bindings = append(bindings, "update-item (fn [input] (assoc input :"+n8nKebab(output, "date")+" "+value+"))")
+	code := renderN8NItemsFunction(inputPlan, bindings, n8nPerItemResult)
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, todos)
+}
+
+var n8nDigestAlgorithms = map[string]string{
+	"MD5":      "MD5",
+	"SHA1":     "SHA-1",
+	"SHA256":   "SHA-256",
+	"SHA384":   "SHA-384",
+	"SHA512":   "SHA-512",
+	"SHA3-256": "SHA3-256",
+	"SHA3-384": "SHA3-384",
+	"SHA3-512": "SHA3-512",
+}
+
+func convertN8NCryptoNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	params := node.Parameters
+	output := n8nKebab(firstNonEmpty(stringParam(params, "dataPropertyName"), "data"), "data")
+	var bindings []string
+	var value string
+	var todos []string
+	encode := "(apply str (map #(format \"%02x\" (bit-and % 0xff)) bytes))"
+	if strings.EqualFold(stringParam(params, "encoding"), "base64") {
+		encode = "(.encodeToString (java.util.Base64/getEncoder) bytes)"
+	}
+	switch action := firstNonEmpty(stringParam(params, "action"), "hash"); action {
+	case "hash", "hmac":
+		algorithm, ok := n8nDigestAlgorithms[strings.ToUpper(firstNonEmpty(stringParam(params, "type"), "MD5"))]
+		if !ok {
+			return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+		}
+		input, inputTodos := renderN8NParamExpr(node, "value", params["value"])
+		todos = append(todos, inputTodos...)
+		bytes := fmt.Sprintf("(.digest (java.security.MessageDigest/getInstance %s) (.getBytes (str v) \"UTF-8\"))", ednQuote(algorithm))
+		if action == "hmac" {
+			mac := "Hmac" + strings.ReplaceAll(algorithm, "-", "")
+			bytes = fmt.Sprintf("(.doFinal (doto (javax.crypto.Mac/getInstance %[1]s)\n                                    (.init (javax.crypto.spec.SecretKeySpec. (.getBytes (str (:hmac-secret input)) \"UTF-8\") %[1]s)))\n                                  (.getBytes (str v) \"UTF-8\"))", ednQuote(mac))
+			todos = append(todos, fmt.Sprintf("pass the HMAC secret for Crypto node %q as :hmac-secret; the n8n secret was not copied", node.Name))
+		}
+		bindings = append(bindings, "digest (fn [input v]\n                 (let [bytes "+bytes+"]\n                   "+encode+"))")
+		value = "(digest input " + input + ")"
+	case "generate":
+		if enc := stringParam(params, "encodingType"); enc != "" && enc != "uuid" {
+			return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+		}
+		value = "(str (java.util.UUID/randomUUID))"
+	default:
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	bindings = append(bindings, "update-item (fn [input] (assoc input :"+output+" "+value+"))")
+	code := renderN8NItemsFunction(inputPlan, bindings, n8nPerItemResult)
+	return renderN8NFunctionNode(node, stepID, inputPlan, code, todos)
+}
+
+// n8nItemsExpr is the item list of a flow expression, as a vector.
+func n8nItemsExpr(expr string) string {
+	return fmt.Sprintf("(let [v (:items %s)] (if (sequential? v) (vec v) [%s]))", expr, expr)
+}
+
+// convertN8NLoopNode maps Split In Batches / Loop Over Items to a fanout over
+// batches. n8nSplitLoopBodies has moved the loop body into the child flow the
+// node fans out to; a loop without a body passes its items through.
+func convertN8NLoopNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	if node.ChildFlow == "" {
+		return inputPlan.Expr, nil, nil, nil, nil
+	}
+	size := intParam(node.Parameters, 10, "batchSize")
+	binding := fmt.Sprintf(`(flow/step :fanout :%s
+           {:title %s
+            :flow :%s
+            :items (vec (for [batch (partition-all %d %s)]
+                          {:items (vec batch)}))})`, stepID, ednQuote(firstNonEmpty(node.Name, stepID)), node.ChildFlow, size, n8nItemsExpr(inputPlan.Expr))
+	return binding, nil, nil, nil, nil
+}
+
+func convertN8NExecuteWorkflowNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	workflowID := ""
+	childName := ""
+	switch v := node.Parameters["workflowId"].(type) {
+	case string:
+		workflowID = strings.TrimPrefix(v, "=")
+	case map[string]any:
+		workflowID = stringParam(v, "value")
+		childName = stringParam(v, "cachedResultName")
+	}
+	if workflowID == "" && childName == "" {
+		return convertN8NFallbackNode(node, stepID, inputPlan, convertedByName)
+	}
+	child := n8nKebab(firstNonEmpty(childName, "n8n-workflow-"+workflowID), "child-flow")
+	items := "[" + inputPlan.Expr + "]"
+	if strings.EqualFold(stringParam(node.Parameters, "mode"), "each") {
+		items = n8nItemsExpr(inputPlan.Expr)
+	}
+	binding := fmt.Sprintf(`(flow/step :fanout :%s
+           {:title %s
+            :flow :%s
+            :items %s})`, stepID, ednQuote(firstNonEmpty(node.Name, stepID)), child, items)
+	todos := []string{fmt.Sprintf("import n8n workflow %s as flow :%s (breyta flows import n8n <workflow.json> --slug %s)", firstNonEmpty(workflowID, childName), child, child)}
+	if options := n8nParamMap(node.Parameters, "options"); options != nil {
+		if wait, ok := options["waitForSubWorkflow"].(bool); ok && !wait {
+			todos = append(todos, fmt.Sprintf("Execute Workflow node %q did not wait for the child workflow; the fanout waits", node.Name))
+		}
+	}
+	return binding, nil, nil, nil, todos
+}
+
+// convertN8NNoOpNode passes its input through without a step.
+func convertN8NNoOpNode(node n8nNode, stepID string, inputPlan n8nInputPlan, convertedByName map[string]n8nConvertedNode) (string, []string, []string, []string, []string) {
+	return inputPlan.Expr, nil, nil, nil, nil
+}
+
+// n8nLoopBodyNodes returns the nodes reachable from a loop node's "loop"
+// output (index 1) before the flow returns to the loop node, sorted by name.
+func n8nLoopBodyNodes(loop string, edges []n8nEdge) []string {
+	seen := map[string]bool{}
+	queue := []string{}
+	for _, edge := range edges {
+		if edge.Source == loop && edge.SourceOutput == 1 && edge.Target != loop && !seen[edge.Target] {
+			seen[edge.Target] = true
+			queue = append(queue, edge.Target)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges {
+			if edge.Source == name && edge.Target != loop && !seen[edge.Target] {
+				seen[edge.Target] = true
+				queue = append(queue, edge.Target)
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for name := range seen {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// n8nLoopChild is a loop body split out of a workflow, to be converted as
+// the child flow Slug.
+type n8nLoopChild struct {
+	Slug     string
+	Workflow n8nWorkflow
+}
+
+// n8nSplitLoopBodies moves each loop node's body into a child workflow and
+// points the loop node at it through ChildFlow. Body nodes keep the
+// connections among themselves; edges back to the loop node are dropped, so
+// the body's entry nodes read the batch ({:items batch}) as their input.
+// Loops nested in a body move with it and are split when the child converts.
+func n8nSplitLoopBodies(wf n8nWorkflow, slug string) (n8nWorkflow, []n8nLoopChild) {
+	edges := n8nEdges(wf)
+	moved := map[string]bool{}
+	childFlows := map[string]string{}
+	usedSlugs := map[string]bool{slug: true}
+	var children []n8nLoopChild
+	for _, node := range n8nTopologicalOrder(wf.Nodes, n8nWithoutLoopBackEdges(wf.Nodes, edges)) {
+		if !n8nIsLoopNode(node) || moved[node.Name] {
+			continue
+		}
+		body := n8nLoopBodyNodes(node.Name, edges)
+		if len(body) == 0 {
+			continue
+		}
+		members := map[string]bool{}
+		for _, name := range body {
+			members[name] = true
+			moved[name] = true
+		}
+		childSlug := uniqueN8NID(slug+"-"+n8nKebab(node.Name, "loop")+"-batch", usedSlugs)
+		childFlows[node.Name] = childSlug
+		children = append(children, n8nLoopChild{Slug: childSlug, Workflow: n8nSubWorkflow(wf, firstNonEmpty(wf.Name, slug)+" / "+firstNonEmpty(node.Name, "Loop"), func(name string) bool { return members[name] })})
+	}
+	if len(children) == 0 {
+		return wf, nil
+	}
+	parent := n8nSubWorkflow(wf, wf.Name, func(name string) bool { return !moved[name] })
+	for i := range parent.Nodes {
+		parent.Nodes[i].ChildFlow = childFlows[parent.Nodes[i].Name]
+	}
+	return parent, children
+}
+
+// n8nSubWorkflow returns the part of wf made of the nodes keep accepts and
+// the connections and pinned data among them.
+func n8nSubWorkflow(wf n8nWorkflow, name string, keep func(string) bool) n8nWorkflow {
+	out := n8nWorkflow{
+		Name:        name,
+		Settings:    wf.Settings,
+		Source:      wf.Source,
+		Connections: map[string]map[string][][]n8nConnection{},
+	}
+	for _, node := range wf.Nodes {
+		if keep(node.Name) {
+			out.Nodes = append(out.Nodes, node)
+		}
+	}
+	for source, byType := range wf.Connections {
+		if !keep(source) {
+			continue
+		}
+		kept := map[string][][]n8nConnection{}
+		for typ, outputs := range byType {
+			filtered := make([][]n8nConnection, len(outputs))
+			for i, conns := range outputs {
+				for _, conn := range conns {
+					if keep(conn.Node) {
+						filtered[i] = append(filtered[i], conn)
+					}
+				}
+			}
+			kept[typ] = filtered
+		}
+		out.Connections[source] = kept
+	}
+	for nodeName, items := range wf.PinData {
+		if keep(nodeName) {
+			if out.PinData == nil {
+				out.PinData = map[string][]any{}
+			}
+			out.PinData[nodeName] = items
+		}
+	}
+	return out
+}
+
+// n8nWithoutLoopBackEdges drops edges that return into a loop node from its
+// own body, so the loop node orders before its body.
+func n8nWithoutLoopBackEdges(nodes []n8nNode, edges []n8nEdge) []n8nEdge {
+	body := map[string]map[string]bool{}
+	for _, node := range nodes {
+		if !n8nIsLoopNode(node) {
+			continue
+		}
+		body[node.Name] = map[string]bool{}
+		for _, name := range n8nLoopBodyNodes(node.Name, edges) {
+			body[node.Name][name] = true
+		}
+	}
+	if len(body) == 0 {
+		return edges
+	}
+	out := make([]n8nEdge, 0, len(edges))
+	for _, edge := range edges {
+		if members, ok := body[edge.Target]; ok && members[edge.Source] {
+			continue
+		}
+		out = append(out, edge)
+	}
+	return out
+}
+
+// n8nScheduleCrons returns five-field cron expressions for a Schedule
+// Trigger, Cron or Interval node.
+func n8nScheduleCrons(node n8nNode) ([]string, []string) {
+	params := node.Parameters
+	var crons, todos []string
+	add := func(cron string) {
+		fields := strings.Fields(cron)
+		if len(fields) == 6 {
+			if fields[0] != "0" && fields[0] != "*" {
+				todos = append(todos, fmt.Sprintf("schedule %q fires on seconds; it now runs once per minute at most", cron))
+			}
+			fields = fields[1:]
+		}
+		crons = append(crons, strings.Join(fields, " "))
+	}
+	every := func(n int) string {
+		if n <= 1 {
+			return "*"
+		}
+		return "*/" + strconv.Itoa(n)
+	}
+	for _, rule := range n8nParamList(n8nParamMap(params, "rule"), "interval", "") {
+		minute := strconv.Itoa(intParam(rule, 0, "triggerAtMinute"))
+		hour := strconv.Itoa(intParam(rule, 0, "triggerAtHour"))
+		switch firstNonEmpty(stringParam(rule, "field"), "days") {
+		case "cronExpression":
+			add(stringParam(rule, "expression"))
+		case "seconds":
+			todos = append(todos, fmt.Sprintf("Schedule Trigger %q runs every %d seconds; the import runs it every minute", node.Name, intParam(rule, 30, "secondsInterval")))
+			add("* * * * *")
+		case "minutes":
+			add(every(intParam(rule, 5, "minutesInterval")) + " * * * *")
+		case "hours":
+			add(minute + " " + every(intParam(rule, 1, "hoursInterval")) + " * * *")
+		case "days":
+			add(minute + " " + hour + " " + every(intParam(rule, 1, "daysInterval")) + " * *")
+		case "weeks":
+			if n := intParam(rule, 1, "weeksInterval"); n > 1 {
+				todos = append(todos, fmt.Sprintf("Schedule Trigger %q runs every %d weeks; cron runs it weekly", node.Name, n))
+			}
+			days := []string{}
+			if raw, ok := rule["triggerAtDay"].([]any); ok {
+				for _, day := range raw {
+					days = append(days, fmt.Sprint(day))
+				}
+			}
+			if len(days) == 0 {
+				days = []string{"0"}
+			}
+			add(minute + " " + hour + " * * " + strings.Join(days, ","))
+		case "months":
+			add(minute + " " + hour + " " + strconv.Itoa(intParam(rule, 1, "triggerAtDayOfMonth")) + " " + every(intParam(rule, 1, "monthsInterval")) + " *")
+		}
+	}
+	for _, item := range n8nParamList(params, "triggerTimes", "item") {
+		minute := strconv.Itoa(intParam(item, 0, "minute"))
+		hour := strconv.Itoa(intParam(item, 0, "hour"))
+		switch stringParam(item, "mode") {
+		case "everyMinute":
+			add("* * * * *")
+		case "everyHour":
+			add(minute + " * * * *")
+		case "everyDay":
+			add(minute + " " + hour + " * * *")
+		case "everyWeek":
+			add(minute + " " + hour + " * * " + strconv.Itoa(intParam(item, 1, "weekday")))
+		case "everyMonth":
+			add(minute + " " + hour + " " + strconv.Itoa(intParam(item, 1, "dayOfMonth")) + " * *")
+		case "everyX":
+			n := intParam(item, 1, "value")
+			if strings.EqualFold(stringParam(item, "unit"), "hours") {
+				add("0 " + every(n) + " * * *")
+			} else {
+				add(every(n) + " * * * *")
+			}
+		case "custom":
+			add(stringParam(item, "cronExpression"))
+		}
+	}
+	if len(crons) == 0 && n8nNodeKind(node) == "interval" {
+		n := intParam(params, 1, "interval")
+		switch strings.ToLower(firstNonEmpty(stringParam(params, "unit"), "seconds")) {
+		case "hours":
+			add("0 " + every(n) + " * * *")
+		case "minutes":
+			add(every(n) + " * * * *")
+		default:
+			todos = append(todos, fmt.Sprintf("Interval node %q runs every %d seconds; the import runs it every minute", node.Name, n))
+			add("* * * * *")
+		}
+	}
+	if len(crons) == 0 {
+		if cron := stringParam(params, "cronExpression", "expression"); cron != "" {
+			add(cron)
+		}
+	}
+	if len(crons) == 0 {
+		add("0 * * * *")
+		todos = append(todos, fmt.Sprintf("set the schedule for trigger %q; the import defaults to hourly", node.Name))
+	}
+	return crons, todos
+}
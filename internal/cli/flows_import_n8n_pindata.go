@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// n8nPinnedCase is a step test case and example built from n8n pinData: the
+// upstream node's pinned output is the input and the node's own pinned output
+// is the expected output. Nodes whose upstream has no pinned data only get an
+// example.
+type n8nPinnedCase struct {
+	Node           string `json:"node"`
+	StepID         string `json:"stepId"`
+	StepType       string `json:"stepType"`
+	InputFrom      string `json:"inputFrom,omitempty"`
+	Input          any    `json:"input,omitempty"`
+	Expected       any    `json:"expected"`
+	TestCommand    string `json:"testCommand,omitempty"`
+	ExampleCommand string `json:"exampleCommand"`
+}
+
+var n8nStepTypeRe = regexp.MustCompile(`^\(flow/step :([a-z][a-z0-9-]*) :`)
+
+// n8nPinnedValue converts pinned n8n items to the value the imported step
+// sees: one item is its JSON map, several become {:items [...]}, matching how
+// the item converters read their input. asItems keeps a single item wrapped,
+// since item converters answer {:items [...]} input in the same shape.
+func n8nPinnedValue(items []any, asItems bool) any {
+	values := make([]any, 0, len(items))
+	for _, raw := range items {
+		if item, ok := raw.(map[string]any); ok {
+			if value, ok := item["json"]; ok {
+				values = append(values, value)
+				continue
+			}
+		}
+		values = append(values, raw)
+	}
+	if len(values) == 1 && !asItems {
+		return values[0]
+	}
+	return map[string]any{"items": values}
+}
+
+func buildN8NPinnedCases(slug string, pinData map[string][]any, converted []n8nConvertedNode, upstreams map[string][]string) []n8nPinnedCase {
+	var cases []n8nPinnedCase
+	for _, item := range converted {
+		pinned, ok := pinData[item.Node.Name]
+		if !ok || len(pinned) == 0 {
+			continue
+		}
+		match := n8nStepTypeRe.FindStringSubmatch(item.Binding)
+		if match == nil {
+			continue
+		}
+		c := n8nPinnedCase{Node: item.Node.Name, StepID: item.StepID, StepType: match[1]}
+		var sources []string
+		for _, upstream := range upstreams[item.Node.Name] {
+			if len(pinData[upstream]) > 0 {
+				sources = append(sources, upstream)
+			}
+		}
+		c.Expected = n8nPinnedValue(pinned, false)
+		if len(sources) == 1 {
+			upstream := pinData[sources[0]]
+			c.InputFrom = sources[0]
+			c.Input = n8nPinnedValue(upstream, false)
+			c.Expected = n8nPinnedValue(pinned, len(upstream) > 1)
+		}
+		expected := compactN8NJSON(c.Expected)
+		note := fmt.Sprintf("n8n pinned data for node %q", item.Node.Name)
+		if c.Input != nil {
+			input := compactN8NJSON(c.Input)
+			c.TestCommand = fmt.Sprintf("breyta steps tests add %s %s --type %s --name %s --input %s --expected %s --note %s",
+				slug, c.StepID, c.StepType, shellQuotePath("n8n pinned data"), shellQuotePath(input), shellQuotePath(expected), shellQuotePath(note))
+			c.ExampleCommand = fmt.Sprintf("breyta steps examples add %s %s --input %s --output %s --note %s",
+				slug, c.StepID, shellQuotePath(input), shellQuotePath(expected), shellQuotePath(note))
+		} else {
+			c.ExampleCommand = fmt.Sprintf("breyta steps examples add %s %s --output %s --note %s",
+				slug, c.StepID, shellQuotePath(expected), shellQuotePath(note))
+		}
+		cases = append(cases, c)
+	}
+	return cases
+}
+
+// renderN8NPinnedTestSuite renders the cases that have a pinned input as a
+// flows test suite for <slug>.tests.edn. Imported steps are inline flow/step
+// ids, so each case names its step :type. It returns "" when no case has an
+// input map.
+func renderN8NPinnedTestSuite(slug string, cases []n8nPinnedCase) string {
+	var steps []string
+	for _, c := range cases {
+		if _, ok := c.Input.(map[string]any); !ok {
+			continue
+		}
+		steps = append(steps, fmt.Sprintf(`:%s
+  [{:name %s
+    :type :%s
+    :params %s
+    :expect {:equals %s}}]`, c.StepID, ednQuote(fmt.Sprintf("n8n pinned data for %s", c.Node)), c.StepType, ednValue(c.Input), ednValue(c.Expected)))
+	}
+	if len(steps) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(";; Step tests from the n8n export's pinned data. Run with: breyta flows test %s\n{:steps\n {%s}}\n", slug, strings.Join(steps, "\n  "))
+}
+
+// writeN8NPinnedTestSuites writes <slug>.tests.edn next to the imported flow
+// and each loop body child flow that has pinned cases with an input.
+func writeN8NPinnedTestSuites(result *n8nImportResult) error {
+	for _, flow := range append([]*n8nImportResult{result}, result.Children...) {
+		suite := renderN8NPinnedTestSuite(flow.Slug, flow.PinnedCases)
+		if suite == "" {
+			continue
+		}
+		path := defaultFlowStepTestsPath(flow.OutputPath, flow.Slug)
+		if err := atomicWriteFile(path, []byte(suite), 0o644); err != nil {
+			return err
+		}
+		flow.TestsPath = path
+	}
+	return nil
+}
+
+func compactN8NJSON(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
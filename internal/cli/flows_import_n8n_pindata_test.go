@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertN8NWorkflow_PinDataBecomesStepCases(t *testing.T) {
+	var wf n8nWorkflow
+	if err := json.Unmarshal([]byte(`{
+  "name": "Pinned",
+  "nodes": [
+    {"name": "Start", "type": "n8n-nodes-base.manualTrigger", "parameters": {}},
+    {"name": "Keep Active", "type": "n8n-nodes-base.filter",
+     "parameters": {"conditions": {"boolean": [{"value1": "={{$json.active}}", "value2": true}]}}},
+    {"name": "Label", "type": "n8n-nodes-base.set",
+     "parameters": {"values": {"string": [{"name": "label", "value": "={{$json.name}}"}]}}},
+    {"name": "Done", "type": "n8n-nodes-base.noOp", "parameters": {}}
+  ],
+  "connections": {
+    "Start": {"main": [[{"node": "Keep Active", "type": "main", "index": 0}]]},
+    "Keep Active": {"main": [[{"node": "Label", "type": "main", "index": 0}]]},
+    "Label": {"main": [[{"node": "Done", "type": "main", "index": 0}]]}
+  },
+  "pinData": {
+    "Start": [{"json": {"name": "Ada", "active": true}}, {"json": {"name": "Bob", "active": false}}],
+    "Keep Active": [{"json": {"name": "Ada", "active": true}}],
+    "Done": [{"json": {"label": "Ada"}}]
+  }
+}`), &wf); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	result, err := convertN8NWorkflow(wf, "pinned", "tmp/flows/pinned.clj")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(result.PinnedCases) != 1 {
+		t.Fatalf("expected only the filter step to get a case (Done is a pass-through), got %s", mustJSON(t, result.PinnedCases))
+	}
+	c := result.PinnedCases[0]
+	if c.StepID != "keep-active" || c.StepType != "function" || c.InputFrom != "Start" {
+		t.Fatalf("unexpected case %s", mustJSON(t, c))
+	}
+	if got := compactN8NJSON(c.Input); got != `{"items":[{"active":true,"name":"Ada"},{"active":false,"name":"Bob"}]}` {
+		t.Fatalf("unexpected input %s", got)
+	}
+	if got := compactN8NJSON(c.Expected); got != `{"items":[{"active":true,"name":"Ada"}]}` {
+		t.Fatalf("unexpected expected %s", got)
+	}
+	assertContains(t, c.TestCommand, `breyta steps tests add pinned keep-active --type function --name 'n8n pinned data' --input '{"items":[`)
+	assertContains(t, c.ExampleCommand, `breyta steps examples add pinned keep-active --input '{"items":[`)
+	assertContains(t, c.ExampleCommand, `--output '{"items":[{"active":true,"name":"Ada"}]}'`)
+
+	suite, err := parseFlowStepTestSuite([]byte(renderN8NPinnedTestSuite("pinned", result.PinnedCases)))
+	if err != nil {
+		t.Fatalf("pinned cases should render a flows test suite: %v", err)
+	}
+	if len(suite.Cases) != 1 || suite.Cases[0].Step != "keep-active" || suite.Cases[0].Type != "function" {
+		t.Fatalf("unexpected suite %#v", suite.Cases)
+	}
+	if got := compactN8NJSON(suite.Cases[0].Params); got != compactN8NJSON(c.Input) {
+		t.Fatalf("expected the pinned input as params, got %s", got)
+	}
+	if got := compactN8NJSON(suite.Cases[0].Expect.Equals); !suite.Cases[0].Expect.HasEquals || got != compactN8NJSON(c.Expected) {
+		t.Fatalf("expected the pinned output as :equals, got %s", got)
+	}
+
+	wf.PinData["Label"] = []any{map[string]any{"json": map[string]any{"label": "Ada"}}}
+	delete(wf.PinData, "Keep Active")
+	result, err = convertN8NWorkflow(wf, "pinned", "tmp/flows/pinned.clj")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(result.PinnedCases) != 1 || result.PinnedCases[0].StepID != "label" || result.PinnedCases[0].TestCommand != "" {
+		t.Fatalf("expected an example-only case for label, got %s", mustJSON(t, result.PinnedCases))
+	}
+	if suite := renderN8NPinnedTestSuite("pinned", result.PinnedCases); suite != "" {
+		t.Fatalf("example-only cases must not become tests:\n%s", suite)
+	}
+	if !strings.HasPrefix(result.PinnedCases[0].ExampleCommand, "breyta steps examples add pinned label --output ") {
+		t.Fatalf("unexpected example command %q", result.PinnedCases[0].ExampleCommand)
+	}
+}
+
+func TestImportN8NWorkflow_WritesPinnedTestSuite(t *testing.T) {
+	wf := n8nWorkflow{
+		Name: "Pinned",
+		Nodes: []n8nNode{
+			{Name: "Start", Type: "n8n-nodes-base.manualTrigger"},
+			{Name: "Sort", Type: "n8n-nodes-base.sort", Parameters: map[string]any{"sortFieldsUi": map[string]any{"sortField": []any{map[string]any{"fieldName": "n"}}}}},
+		},
+		Connections: map[string]map[string][][]n8nConnection{
+			"Start": {"main": {{{Node: "Sort", Type: "main"}}}},
+		},
+		PinData: map[string][]any{
+			"Start": {map[string]any{"json": map[string]any{"n": 2}}, map[string]any{"json": map[string]any{"n": 1}}},
+			"Sort":  {map[string]any{"json": map[string]any{"n": 1}}, map[string]any{"json": map[string]any{"n": 2}}},
+		},
+	}
+	outPath := filepath.Join(t.TempDir(), "flows", "pinned.clj")
+	result, err := importN8NWorkflow(wf, "", "pinned", outPath, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	want := filepath.Join(filepath.Dir(outPath), "pinned.tests.edn")
+	if result.TestsPath != want {
+		t.Fatalf("expected the suite next to the flow, got %q", result.TestsPath)
+	}
+	b, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("read suite: %v", err)
+	}
+	suite, err := parseFlowStepTestSuite(b)
+	if err != nil || len(suite.Cases) != 1 || suite.Cases[0].Step != "sort" {
+		t.Fatalf("unexpected suite %v %#v\n%s", err, suite.Cases, b)
+	}
+}
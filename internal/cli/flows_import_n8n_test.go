@@ -74,11 +74,11 @@ func TestImportN8NWorkflowFile_WritesDefaultShape(t *testing.T) {
 	tmp := t.TempDir()
 	input := filepath.Join(tmp, "workflow.json")
 	out := filepath.Join(tmp, "tiny.clj")
-	if err := os.WriteFile(input, []byte(`{"name":"Tiny","nodes":[{"name":"NoOp","type":"n8n-nodes-base.noOp","parameters":{}}],"connections":{}}`), 0o644); err != nil {
+	if err := os.WriteFile(input, []byte(`{"name":"Tiny","nodes":[{"name":"Notion","type":"n8n-nodes-base.notion","parameters":{}}],"connections":{}}`), 0o644); err != nil {
 		t.Fatalf("write input: %v", err)
 	}
 
-	result, err := importN8NWorkflowFile(input, "tiny", out)
+	result, err := importN8NWorkflowFile(input, "tiny", out, nil)
 	if err != nil {
 		t.Fatalf("import failed: %v", err)
 	}
@@ -586,6 +586,95 @@ func TestConvertN8NWorkflow_HTTPTranslatesNodeRefTemplates(t *testing.T) {
 	}
 }
 
+func TestConvertN8NWorkflow_HTTPTranslatesBodyTemplates(t *testing.T) {
+	seed := n8nNode{
+		Name: "Seed",
+		Type: "n8n-nodes-base.set",
+		Parameters: map[string]any{"values": map[string]any{
+			"string": []any{map[string]any{"name": "githubUser", "value": "octocat"}},
+		}},
+	}
+	wf := n8nWorkflow{
+		Name: "HTTP Body Import",
+		Nodes: []n8nNode{
+			{Name: "Manual Trigger", Type: "n8n-nodes-base.manualTrigger", Parameters: map[string]any{}},
+			seed,
+			{
+				Name: "Post Raw",
+				Type: "n8n-nodes-base.httpRequest",
+				Parameters: map[string]any{
+					"method": "POST",
+					"url":    "https://api.example.com/raw",
+					"body":   `={{$node["Seed"].json["githubUser"]}}`,
+				},
+			},
+			{
+				Name: "Post Form",
+				Type: "n8n-nodes-base.httpRequest",
+				Parameters: map[string]any{
+					"method": "POST",
+					"url":    "https://api.example.com/form",
+					"bodyParameters": map[string]any{"parameters": []any{
+						map[string]any{"name": "user", "value": `={{$node["Seed"].json["githubUser"]}}`},
+						map[string]any{"name": "source", "value": "n8n"},
+					}},
+				},
+			},
+		},
+		Connections: map[string]map[string][][]n8nConnection{
+			"Manual Trigger": {"main": {{{Node: "Seed", Type: "main", Index: 0}}}},
+			"Seed":           {"main": {{{Node: "Post Raw", Type: "main", Index: 0}, {Node: "Post Form", Type: "main", Index: 0}}}},
+		},
+	}
+
+	result, err := convertN8NWorkflow(wf, "http-body-import", "tmp/flows/http-body-import.clj")
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	assertContains(t, result.EDN, `:body "{{seed.githubuser}}"`)
+	assertContains(t, result.EDN, `:body {"source" "n8n" "user" "{{seed.githubuser}}"}`)
+}
+
+func TestConvertN8NWorkflow_IFAndFilterResolveNodeRefs(t *testing.T) {
+	condition := map[string]any{"conditions": map[string]any{
+		"conditions": []any{map[string]any{
+			"leftValue":  `={{$json.total}}`,
+			"rightValue": `={{$node["Limits"].json["max"]}}`,
+			"operator":   map[string]any{"type": "number", "operation": "equals"},
+		}},
+	}}
+	wf := n8nWorkflow{
+		Name: "Node Ref Conditions",
+		Nodes: []n8nNode{
+			{Name: "Manual Trigger", Type: "n8n-nodes-base.manualTrigger", Parameters: map[string]any{}},
+			{Name: "Limits", Type: "n8n-nodes-base.set", Parameters: map[string]any{"values": map[string]any{
+				"number": []any{map[string]any{"name": "max", "value": float64(100)}},
+			}}},
+			{Name: "Orders", Type: "n8n-nodes-base.set", Parameters: map[string]any{"values": map[string]any{
+				"number": []any{map[string]any{"name": "total", "value": float64(42)}},
+			}}},
+			{Name: "Under Limit", Type: "n8n-nodes-base.if", Parameters: condition},
+			{Name: "Keep Small", Type: "n8n-nodes-base.filter", Parameters: condition},
+		},
+		Connections: map[string]map[string][][]n8nConnection{
+			"Manual Trigger": {"main": {{{Node: "Limits", Type: "main", Index: 0}}}},
+			"Limits":         {"main": {{{Node: "Orders", Type: "main", Index: 0}}}},
+			"Orders":         {"main": {{{Node: "Under Limit", Type: "main", Index: 0}, {Node: "Keep Small", Type: "main", Index: 0}}}},
+		},
+	}
+
+	result, err := convertN8NWorkflow(wf, "node-ref-conditions", "tmp/flows/node-ref-conditions.clj")
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	if todos := strings.Join(result.Todos, "\n"); strings.Contains(todos, "conditions") {
+		t.Fatalf("expected IF and Filter conditions to translate, got %s", todos)
+	}
+	assertContains(t, result.EDN, `(assoc input :branch (= (get (get input :input) :total) (get (get input :limits) :max)))`)
+	assertContains(t, result.EDN, `(let [source input\n        input (get input :input)`)
+	assertContains(t, result.EDN, `keep? (fn [input] (= (get input :total) (get (get source :limits) :max)))`)
+}
+
 func TestConvertN8NWorkflow_DataTransformNodes(t *testing.T) {
 	wf := n8nWorkflow{
 		Name: "Transform Import",
@@ -630,7 +719,7 @@ func TestFlowsImportN8NCommand_WritesEnvelopeAndFile(t *testing.T) {
 	tmp := t.TempDir()
 	input := filepath.Join(tmp, "workflow.json")
 	outPath := filepath.Join(tmp, "imported.clj")
-	if err := os.WriteFile(input, []byte(`{"name":"Imported","nodes":[{"name":"NoOp","type":"n8n-nodes-base.noOp","parameters":{}}],"connections":{}}`), 0o644); err != nil {
+	if err := os.WriteFile(input, []byte(`{"name":"Imported","nodes":[{"name":"Notion","type":"n8n-nodes-base.notion","parameters":{}}],"connections":{}}`), 0o644); err != nil {
 		t.Fatalf("write input: %v", err)
 	}
 
@@ -683,7 +772,7 @@ func TestFlowsImportN8NCommand_ServerValidatePushesAndValidatesDraft(t *testing.
 	tmp := t.TempDir()
 	input := filepath.Join(tmp, "workflow.json")
 	outPath := filepath.Join(tmp, "imported.clj")
-	if err := os.WriteFile(input, []byte(`{"name":"Imported","nodes":[{"name":"NoOp","type":"n8n-nodes-base.noOp","parameters":{}}],"connections":{}}`), 0o644); err != nil {
+	if err := os.WriteFile(input, []byte(`{"name":"Imported","nodes":[{"name":"Notion","type":"n8n-nodes-base.notion","parameters":{}}],"connections":{}}`), 0o644); err != nil {
 		t.Fatalf("write input: %v", err)
 	}
 	commands := make([]string, 0, 2)
@@ -780,7 +869,7 @@ func TestImportN8NWorkflow_RealPublicTemplateFixtures(t *testing.T) {
 		fixture := fixture
 		t.Run(filepath.Base(fixture), func(t *testing.T) {
 			slug := "fixture-" + strings.TrimSuffix(filepath.Base(fixture), ".json")
-			result, err := importN8NWorkflowFile(fixture, slug, filepath.Join(t.TempDir(), slug+".clj"))
+			result, err := importN8NWorkflowFile(fixture, slug, filepath.Join(t.TempDir(), slug+".clj"), nil)
 			if err != nil {
 				t.Fatalf("import fixture: %v", err)
 			}
@@ -797,6 +886,117 @@ func TestImportN8NWorkflow_RealPublicTemplateFixtures(t *testing.T) {
 	}
 }
 
+func TestImportN8NWorkflow_LoopBodyBecomesChildFlow(t *testing.T) {
+	wf, err := readN8NWorkflowFile(filepath.Join("testdata", "n8n", "nodes", "schedule-loop.json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "nightly.clj")
+	result, err := importN8NWorkflow(wf, "", "nightly", outPath, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(result.Children) != 1 {
+		t.Fatalf("expected one loop body child flow, got %#v", result.Children)
+	}
+	child := result.Children[0]
+	if child.Slug != "nightly-loop-over-items-batch" || child.OutputPath != filepath.Join(filepath.Dir(outPath), child.Slug+".clj") {
+		t.Fatalf("unexpected child flow: %s %s", child.Slug, child.OutputPath)
+	}
+	assertContains(t, result.EDN, "(flow/step :fanout :loop-over-items")
+	assertContains(t, result.EDN, ":flow :nightly-loop-over-items-batch")
+	assertContains(t, result.EDN, "(partition-all 25 ")
+	if strings.Contains(result.EDN, "Process Batch") {
+		t.Fatalf("loop body should move to the child flow:\n%s", result.EDN)
+	}
+	assertContains(t, child.EDN, `;; n8n node: "Process Batch"`)
+	written, err := os.ReadFile(child.OutputPath)
+	if err != nil || string(written) != child.EDN {
+		t.Fatalf("expected child flow written next to the parent: %v", err)
+	}
+	if result.Report.Nodes != len(wf.Nodes) || result.Report.Fallback != 0 {
+		t.Fatalf("expected the report to cover child flow nodes, got %#v", result.Report)
+	}
+}
+
+// TestConvertN8NWorkflow_NodeGoldens compares each testdata/n8n/nodes/*.json
+// import against the .clj next to it. Regenerate with BREYTA_UPDATE_GOLDEN=1.
+func TestConvertN8NWorkflow_NodeGoldens(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "n8n", "nodes", "*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("expected node fixtures, got %v %v", fixtures, err)
+	}
+	for _, fixture := range fixtures {
+		fixture := fixture
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			b, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+			var wf n8nWorkflow
+			if err := json.Unmarshal(b, &wf); err != nil {
+				t.Fatalf("decode fixture: %v", err)
+			}
+			slug := strings.TrimSuffix(filepath.Base(fixture), ".json")
+			result, err := convertN8NWorkflow(wf, slug, "tmp/flows/"+slug+".clj")
+			if err != nil {
+				t.Fatalf("convert: %v", err)
+			}
+			if result.Report.Fallback != 0 || result.Report.Nodes != len(wf.Nodes) {
+				t.Fatalf("expected every node converted, got %#v", result.Report)
+			}
+			// Loop body child flows are compared against <child-slug>.clj.
+			for _, flow := range append([]*n8nImportResult{result}, result.Children...) {
+				golden := filepath.Join(filepath.Dir(fixture), flow.Slug+".clj")
+				if os.Getenv("BREYTA_UPDATE_GOLDEN") == "1" {
+					if err := os.WriteFile(golden, []byte(flow.EDN), 0o644); err != nil {
+						t.Fatalf("write golden: %v", err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("read golden (BREYTA_UPDATE_GOLDEN=1 creates it): %v", err)
+				}
+				if flow.EDN != string(want) {
+					t.Fatalf("generated EDN differs from %s:\n%s", golden, unifiedSourceFileDiff(golden, "generated", string(want), flow.EDN))
+				}
+				if _, lintErr, stdout := runFlowLintLocalOnlyForLiteral(t, flow.EDN); lintErr != nil {
+					t.Fatalf("generated flow %s should pass local lint: %v\n%s", flow.Slug, lintErr, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertN8NWorkflow_ReportCountsFallbackNodes(t *testing.T) {
+	wf := n8nWorkflow{
+		Name: "Report",
+		Nodes: []n8nNode{
+			{Name: "Manual Trigger", Type: "n8n-nodes-base.manualTrigger", Parameters: map[string]any{}},
+			{Name: "Limit", Type: "n8n-nodes-base.limit", Parameters: map[string]any{"maxItems": float64(3)}},
+			{Name: "Notion", Type: "n8n-nodes-base.notion", Parameters: map[string]any{}},
+			{Name: "Sort by Code", Type: "n8n-nodes-base.sort", Parameters: map[string]any{"type": "code"}},
+		},
+		Connections: map[string]map[string][][]n8nConnection{
+			"Manual Trigger": {"main": {{{Node: "Limit", Type: "main", Index: 0}}}},
+			"Limit":          {"main": {{{Node: "Notion", Type: "main", Index: 0}}}},
+			"Notion":         {"main": {{{Node: "Sort by Code", Type: "main", Index: 0}}}},
+		},
+	}
+	result, err := convertN8NWorkflow(wf, "report", "tmp/flows/report.clj")
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	report := result.Report
+	if report.Nodes != 4 || report.Triggers != 1 || report.Converted != 1 || report.Fallback != 2 || report.WithTodos != 2 {
+		t.Fatalf("unexpected report %#v", report)
+	}
+	if len(report.FallbackNodes) != 2 || report.FallbackNodes[0].Name != "Notion" || report.FallbackNodes[1].Name != "Sort by Code" {
+		t.Fatalf("unexpected fallback nodes %#v", report.FallbackNodes)
+	}
+	assertContains(t, result.EDN, "(vec (take 3 items))")
+}
+
 func TestConvertN8NWorkflow_DedupesRequirementsAndRendersNestedValues(t *testing.T) {
 	wf := n8nWorkflow{
 		Name: "Nested Import",
@@ -0,0 +1,448 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// zapierExport is a Zapier zap export: either the account export with a
+// "zaps" list or a single zap object.
+type zapierExport struct {
+	Zaps []zapierZap `json:"zaps"`
+	zapierZap
+}
+
+type zapierZap struct {
+	ID    any             `json:"id"`
+	Title string          `json:"title"`
+	Nodes json.RawMessage `json:"nodes"`
+}
+
+type zapierNode struct {
+	ID               any            `json:"id"`
+	ParentID         any            `json:"parent_id"`
+	TypeOf           string         `json:"type_of"`
+	SelectedAPI      string         `json:"selected_api"`
+	Action           string         `json:"action"`
+	Title            string         `json:"title"`
+	Params           map[string]any `json:"params"`
+	AuthenticationID any            `json:"authentication_id"`
+}
+
+func newFlowsImportZapierCmd(app *App) *cobra.Command {
+	var opts n8nAdaptedImportOptions
+	var zap string
+	cmd := &cobra.Command{
+		Use:   "zapier <export.json>",
+		Short: "Convert a Zapier zap export to a Breyta flow file",
+		Long: strings.TrimSpace(`
+Convert a Zapier zap export to a best-effort Breyta EDN flow file.
+
+The import runs through the same converter as flows import n8n: Catch Hook
+triggers become webhooks, Webhooks by Zapier requests become HTTP steps,
+Filter and Paths steps become branch guards, Formatter text transforms become
+function steps, Delay For becomes a wait step, and Schedule by Zapier becomes a
+schedule. Every other app step becomes a TODO function step, and each
+connected account becomes a :requires slot that --credentials-map (keyed by
+authentication ID) can bind.
+
+  breyta flows import zapier zapfile.json --zap "New order to Slack"
+  breyta flows push --file ./tmp/flows/new-order-to-slack.clj
+
+An account export with several zaps needs --zap with a zap ID or title.
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := readZapierExportFile(args[0], zap)
+			if err != nil {
+				return writeFailure(cmd, app, "zapier_import_failed", err, "Check that the input is a Zapier zap export.", map[string]any{"path": args[0]})
+			}
+			return runN8NAdaptedImport(cmd, app, args[0], wf, opts)
+		},
+	}
+	bindN8NAdaptedImportFlags(cmd, &opts)
+	cmd.Flags().StringVar(&zap, "zap", "", "Zap ID or title to import when the export has several zaps")
+	return cmd
+}
+
+func readZapierExportFile(path, selector string) (n8nWorkflow, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return n8nWorkflow{}, err
+	}
+	var export zapierExport
+	if err := json.Unmarshal(b, &export); err != nil {
+		return n8nWorkflow{}, err
+	}
+	zaps := export.Zaps
+	if len(zaps) == 0 && len(export.Nodes) > 0 {
+		zaps = []zapierZap{export.zapierZap}
+	}
+	zap, err := selectZapierZap(zaps, selector)
+	if err != nil {
+		return n8nWorkflow{}, err
+	}
+	return adaptZapierZap(zap)
+}
+
+func selectZapierZap(zaps []zapierZap, selector string) (zapierZap, error) {
+	selector = strings.TrimSpace(selector)
+	if len(zaps) == 0 {
+		return zapierZap{}, errors.New("zapier export has no zaps")
+	}
+	if selector == "" {
+		if len(zaps) == 1 {
+			return zaps[0], nil
+		}
+		titles := make([]string, 0, len(zaps))
+		for _, zap := range zaps {
+			titles = append(titles, fmt.Sprintf("%s (%s)", zap.Title, n8nAdaptedID(zap.ID)))
+		}
+		return zapierZap{}, fmt.Errorf("zapier export has %d zaps; pass --zap with one of: %s", len(zaps), strings.Join(titles, ", "))
+	}
+	for _, zap := range zaps {
+		if n8nAdaptedID(zap.ID) == selector || strings.EqualFold(strings.TrimSpace(zap.Title), selector) {
+			return zap, nil
+		}
+	}
+	return zapierZap{}, fmt.Errorf("no zap with ID or title %q in the export", selector)
+}
+
+func decodeZapierNodes(raw json.RawMessage) ([]zapierNode, error) {
+	var byID map[string]zapierNode
+	if err := json.Unmarshal(raw, &byID); err == nil {
+		nodes := make([]zapierNode, 0, len(byID))
+		for id, node := range byID {
+			if n8nAdaptedID(node.ID) == "" {
+				node.ID = id
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	}
+	var nodes []zapierNode
+	if err := json.Unmarshal(raw, &nodes); err != nil {
+		return nil, errors.New("zap nodes must be an object keyed by step ID or a list of steps")
+	}
+	return nodes, nil
+}
+
+// zapierAppName strips the version and API suffixes from a selected_api,
+// e.g. "GoogleSheetsV2API@2.1.0" becomes "GoogleSheets".
+func zapierAppName(selectedAPI string) string {
+	app, _, _ := strings.Cut(strings.TrimSpace(selectedAPI), "@")
+	app = strings.TrimSuffix(app, "API")
+	app = strings.TrimSuffix(app, "CLI")
+	return zapierVersionSuffixRe.ReplaceAllString(app, "")
+}
+
+var zapierVersionSuffixRe = regexp.MustCompile(`V\d+$`)
+
+// adaptZapierZap orders the zap's steps from the trigger down the parent
+// links and adapts each to an n8n node.
+func adaptZapierZap(zap zapierZap) (n8nWorkflow, error) {
+	nodes, err := decodeZapierNodes(zap.Nodes)
+	if err != nil {
+		return n8nWorkflow{}, err
+	}
+	if len(nodes) == 0 {
+		return n8nWorkflow{}, errors.New("zap has no steps")
+	}
+	children := map[string][]zapierNode{}
+	for _, node := range nodes {
+		parent := n8nAdaptedID(node.ParentID)
+		children[parent] = append(children[parent], node)
+	}
+	for _, list := range children {
+		sort.Slice(list, func(i, j int) bool { return zapierIDLess(n8nAdaptedID(list[i].ID), n8nAdaptedID(list[j].ID)) })
+	}
+	var ordered []zapierNode
+	var visit func(parent string)
+	visit = func(parent string) {
+		for _, node := range children[parent] {
+			ordered = append(ordered, node)
+			visit(n8nAdaptedID(node.ID))
+		}
+	}
+	visit("")
+
+	adapted := newN8NAdaptedWorkflow(zap.Title, "Zapier")
+	names := map[string]string{}
+	for _, node := range ordered {
+		label := strings.TrimSpace(node.Title)
+		if label == "" {
+			app, action := n8nTitleWords(zapierAppName(node.SelectedAPI)), n8nTitleWords(node.Action)
+			label = strings.TrimSpace(app + " " + action)
+			if strings.EqualFold(app, action) {
+				label = app
+			}
+		}
+		names[n8nAdaptedID(node.ID)] = adapted.uniqueName(label)
+	}
+	expr := func(inner string) string { return zapierExprJS(inner, names) }
+	for _, node := range ordered {
+		id := n8nAdaptedID(node.ID)
+		adapted.add(adaptZapierNode(node, names[id], expr))
+		if parent := n8nAdaptedID(node.ParentID); parent != "" && names[parent] != "" {
+			adapted.connect(names[parent], names[id], 0)
+		}
+	}
+	return adapted.wf, nil
+}
+
+func zapierIDLess(left, right string) bool {
+	l, lerr := strconv.ParseFloat(left, 64)
+	r, rerr := strconv.ParseFloat(right, 64)
+	if lerr == nil && rerr == nil {
+		return l < r
+	}
+	return left < right
+}
+
+// zapierExprJS translates a Zapier field reference such as
+// "123__items__0__sku" (step 123's items[0].sku) to JavaScript.
+func zapierExprJS(inner string, names map[string]string) string {
+	parts := strings.Split(strings.TrimSpace(inner), "__")
+	name, ok := names[parts[0]]
+	if !ok {
+		name = "Zapier step " + parts[0]
+	}
+	return n8nJSNodePath(name, parts[1:])
+}
+
+func adaptZapierNode(node zapierNode, name string, expr func(string) string) n8nNode {
+	app := zapierAppName(node.SelectedAPI)
+	action := strings.ToLower(strings.TrimSpace(node.Action))
+	params := node.Params
+	if params == nil {
+		params = map[string]any{}
+	}
+	template := func(value string) string { return n8nAdaptTemplate(value, expr) }
+	templateJS := func(value string) string { return n8nAdaptTemplateJS(value, expr) }
+	out := n8nNode{ID: n8nAdaptedID(node.ID), Name: name, Parameters: map[string]any{}}
+
+	switch strings.ToLower(app) {
+	case "webhook":
+		switch {
+		case node.TypeOf == "read" && strings.Contains(action, "hook"):
+			out.Type = "n8n-nodes-base.webhook"
+			return out
+		case action == "get", action == "post", action == "put", action == "custom_request":
+			out.Type = "n8n-nodes-base.httpRequest"
+			out.Parameters = zapierHTTPParameters(action, params, template)
+			return out
+		}
+	case "filter":
+		return n8nAdaptedIFNode(name, zapierFilterGroups(params, templateJS))
+	case "branching":
+		if _, ok := firstParam(params, "filter_criteria", "filters", "criteria"); ok {
+			return n8nAdaptedIFNode(name, zapierFilterGroups(params, templateJS))
+		}
+		out.Type = "n8n-nodes-base.noOp"
+		return out
+	case "formatter":
+		if value, ok := zapierFormatterJS(action, params, templateJS); ok {
+			out.Type = "n8n-nodes-base.set"
+			out.Parameters = map[string]any{
+				"keepOnlySet": true,
+				"values":      map[string]any{"string": []any{map[string]any{"name": "output", "value": "={{ " + value + " }}"}}},
+			}
+			return out
+		}
+		out.Type = zapierNodeType(node) + "/" + stringParam(params, "transform")
+		out.Parameters = params
+		return out
+	case "delay":
+		if action == "delay_for" {
+			out.Type = "n8n-nodes-base.wait"
+			out.Parameters = map[string]any{
+				"amount": float64(intParam(params, 1, "time_delay_for_value", "delay_for_value", "value")),
+				"unit":   strings.ToLower(firstNonEmpty(stringParam(params, "time_delay_for_unit", "delay_for_unit", "unit"), "minutes")),
+			}
+			return out
+		}
+	case "schedule":
+		if cron, ok := zapierScheduleCron(action, params); ok {
+			scheduled := n8nAdaptedScheduleNode(name, cron)
+			scheduled.ID = out.ID
+			return scheduled
+		}
+	case "code":
+		out.Type = "n8n-nodes-base.code"
+		if strings.Contains(action, "python") {
+			out.Parameters["pythonCode"] = stringParam(params, "code")
+		} else {
+			out.Parameters["jsCode"] = stringParam(params, "code")
+		}
+		return out
+	}
+	out.Type = zapierNodeType(node)
+	out.Parameters = params
+	out.Credentials = n8nAdaptedCredential(app, n8nAdaptedID(node.AuthenticationID), n8nTitleWords(app))
+	return out
+}
+
+// zapierNodeType is the report type of a step kept as a TODO, e.g.
+// "zapier.GoogleSheetsV2API:add_row".
+func zapierNodeType(node zapierNode) string {
+	api, _, _ := strings.Cut(strings.TrimSpace(node.SelectedAPI), "@")
+	return "zapier." + api + ":" + strings.TrimSpace(node.Action)
+}
+
+func zapierHTTPParameters(action string, params map[string]any, template func(string) string) map[string]any {
+	method := action
+	if action == "custom_request" {
+		method = firstNonEmpty(stringParam(params, "method"), "get")
+	}
+	out := map[string]any{
+		"method": strings.ToUpper(method),
+		"url":    template(stringParam(params, "url")),
+	}
+	if headers := zapierStringMap(params["headers"]); len(headers) > 0 {
+		out["headerParameters"] = n8nAdaptedPairs(headers, template)
+	}
+	if query := zapierStringMap(params["params"]); len(query) > 0 {
+		out["queryParameters"] = n8nAdaptedPairs(query, template)
+	}
+	switch data := params["data"].(type) {
+	case string:
+		if strings.TrimSpace(data) != "" {
+			out["body"] = template(data)
+		}
+	case map[string]any:
+		if body := zapierStringMap(data); len(body) > 0 {
+			out["bodyParameters"] = n8nAdaptedPairs(body, template)
+		}
+	}
+	return out
+}
+
+func zapierStringMap(raw any) map[string]string {
+	values, ok := raw.(map[string]any)
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for key, value := range values {
+		out[key] = toString(value)
+	}
+	return out
+}
+
+// zapierFilterOps maps Zapier filter rules to n8nAdaptedComparison operators.
+var zapierFilterOps = map[string]string{
+	"exists":                      "exists",
+	"does_not_exist":              "notexists",
+	"text_exactly_matches":        "equal",
+	"text_does_not_exactly_match": "notequal",
+	"text_contains":               "containsci",
+	"text_does_not_contain":       "notcontainsci",
+	"text_starts_with":            "startswith",
+	"text_ends_with":              "endswith",
+	"number_greater_than":         "gt",
+	"number_less_than":            "lt",
+	"number_equals":               "numequal",
+	"number_does_not_equal":       "numnotequal",
+	"boolean_is_true":             "true",
+	"boolean_is_false":            "false",
+}
+
+// zapierFilterGroups translates filter_criteria: a list of OR groups, each a
+// list of ANDed {key, match, value} rules. It returns nil when any rule has
+// no translation so the IF node converts with a TODO.
+func zapierFilterGroups(params map[string]any, templateJS func(string) string) [][]string {
+	raw, _ := firstParam(params, "filter_criteria", "filters", "criteria")
+	groups, _ := raw.([]any)
+	if len(groups) > 0 {
+		if _, flat := groups[0].(map[string]any); flat {
+			groups = []any{groups}
+		}
+	}
+	var out [][]string
+	for _, rawGroup := range groups {
+		rules, _ := rawGroup.([]any)
+		var group []string
+		for _, rawRule := range rules {
+			rule, _ := rawRule.(map[string]any)
+			op, ok := zapierFilterOps[strings.ToLower(stringParam(rule, "match", "operator"))]
+			if !ok {
+				return nil
+			}
+			condition, ok := n8nAdaptedComparison(templateJS(stringParam(rule, "key", "left")), op, templateJS(stringParam(rule, "value", "right")))
+			if !ok {
+				return nil
+			}
+			group = append(group, condition)
+		}
+		if len(group) > 0 {
+			out = append(out, group)
+		}
+	}
+	return out
+}
+
+// zapierFormatterJS translates Formatter text transforms to JavaScript over
+// the step's input value.
+func zapierFormatterJS(action string, params map[string]any, templateJS func(string) string) (string, bool) {
+	if action != "string" && action != "text" {
+		return "", false
+	}
+	input := "String(" + templateJS(stringParam(params, "input", "inputs")) + ")"
+	switch strings.ToLower(stringParam(params, "transform")) {
+	case "lowercase":
+		return input + ".toLowerCase()", true
+	case "uppercase":
+		return input + ".toUpperCase()", true
+	case "trim_whitespace", "trim":
+		return input + ".trim()", true
+	case "replace":
+		return input + ".replaceAll(" + templateJS(stringParam(params, "find")) + ", " + templateJS(stringParam(params, "replace")) + ")", true
+	case "split":
+		return input + ".split(" + templateJS(firstNonEmpty(stringParam(params, "separator"), " ")) + ")", true
+	case "length":
+		return input + ".length", true
+	case "default_value":
+		return "(" + templateJS(stringParam(params, "input", "inputs")) + " || " + templateJS(stringParam(params, "default_value")) + ")", true
+	}
+	return "", false
+}
+
+var zapierWeekdays = map[string]string{
+	"sunday": "0", "monday": "1", "tuesday": "2", "wednesday": "3", "thursday": "4", "friday": "5", "saturday": "6",
+}
+
+// zapierScheduleCron returns the cron expression for a Schedule by Zapier
+// trigger.
+func zapierScheduleCron(action string, params map[string]any) (string, bool) {
+	hour, minute := n8nAdaptedTimeOfDay(stringParam(params, "time_of_day", "hour"))
+	days := "*"
+	if value := strings.ToLower(stringParam(params, "trigger_on_weekends")); value == "no" || value == "false" {
+		days = "1-5"
+	}
+	at := strconv.Itoa(minute) + " " + strconv.Itoa(hour)
+	switch action {
+	case "every_hour":
+		return "0 * * * " + days, true
+	case "every_day":
+		return at + " * * " + days, true
+	case "every_week":
+		day := strings.ToLower(stringParam(params, "day_of_week"))
+		if named, ok := zapierWeekdays[day]; ok {
+			day = named
+		}
+		if _, err := strconv.Atoi(day); err != nil {
+			day = "1"
+		}
+		return at + " * * " + day, true
+	case "every_month":
+		return at + " " + strconv.Itoa(intParam(params, 1, "day_of_month")) + " * *", true
+	}
+	return "", false
+}
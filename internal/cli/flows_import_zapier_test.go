@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const zapierOrderExport = `{"zaps": [
+  {"id": 11, "title": "Other Zap", "nodes": {"1": {"selected_api": "WebHookCLIAPI@1.0.0", "type_of": "read", "action": "hook_v2"}}},
+  {"id": 42, "title": "New Order Sync", "nodes": {
+    "100": {"id": 100, "parent_id": null, "selected_api": "WebHookCLIAPI@1.0.0", "type_of": "read", "action": "hook_v2", "title": "Catch Order"},
+    "101": {"id": 101, "parent_id": 100, "selected_api": "FilterAPI", "type_of": "filter", "action": "filter",
+            "params": {"filter_criteria": [[{"key": "{{100__total}}", "match": "number_greater_than", "value": "50"}]]}},
+    "102": {"id": 102, "parent_id": 101, "selected_api": "FormatterV2CLIAPI@1.0.0", "type_of": "write", "action": "string", "title": "Lower Email",
+            "params": {"transform": "lowercase", "input": "{{100__customer__email}}"}},
+    "103": {"id": 103, "parent_id": 102, "selected_api": "WebHookCLIAPI@1.0.0", "type_of": "write", "action": "post", "title": "Notify ERP",
+            "params": {"url": "https://erp.example.com/orders/{{100__id}}", "data": {"email": "{{102__output}}"}, "headers": {"X-Source": "zapier"}}},
+    "104": {"id": 104, "parent_id": 103, "selected_api": "DelayAPI", "type_of": "write", "action": "delay_for", "title": "Wait",
+            "params": {"time_delay_for_value": "5", "time_delay_for_unit": "minutes"}},
+    "105": {"id": 105, "parent_id": 104, "selected_api": "SlackV2API@1.3.0", "type_of": "write", "action": "channel_message", "title": "Post to Slack",
+            "authentication_id": 9001, "params": {"channel": "C123", "text": "Order {{100__id}}"}}}}]}`
+
+func TestAdaptZapierZap_MapsStepsToN8NNodes(t *testing.T) {
+	tmp := t.TempDir()
+	input := filepath.Join(tmp, "zaps.json")
+	if err := os.WriteFile(input, []byte(zapierOrderExport), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	if _, err := readZapierExportFile(input, ""); err == nil || !strings.Contains(err.Error(), "New Order Sync (42)") {
+		t.Fatalf("expected a zap selection error, got %v", err)
+	}
+	wf, err := readZapierExportFile(input, "new order sync")
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	result, err := convertN8NWorkflowWithCredentials(wf, "new-order-sync", filepath.Join(tmp, "out.clj"), nil)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	for _, want := range []string{
+		`:description "Imported from Zapier JSON.`,
+		`:webhook [{:id :catch-order`,
+		`(assoc input :branch (\u003e (Double/parseDouble (str (get input :total))) 50))`,
+		`lower_email (if (true? (:branch filter))`,
+		`clojure.string/lower-case (str (get-in (get input :catch-order) [:customer :email]))`,
+		`:request {:path "/orders/{{catch-order.id}}"`,
+		`:body {"email" "{{lower-email.output}}"}`,
+		`(flow/step :wait :wait`,
+		`:timeout 300`,
+		`{:slot :slack`,
+		`Custom or unsupported Zapier step \"Post to Slack\" (zapier.SlackV2API:channel_message)`,
+	} {
+		assertContains(t, result.EDN, want)
+	}
+	if result.Report.Fallback != 1 || len(result.Credentials) != 1 || result.Credentials[0].ID != "9001" {
+		t.Fatalf("unexpected report %+v credentials %s", result.Report, mustJSON(t, result.Credentials))
+	}
+	if !result.Validation.BalancedDelimiters || !result.Validation.EDNReadable {
+		t.Fatalf("expected structural validation to pass: %+v", result.Validation)
+	}
+}
+
+func TestFlowsImportZapierCommand_WritesFlowFile(t *testing.T) {
+	tmp := t.TempDir()
+	input := filepath.Join(tmp, "zap.json")
+	outPath := filepath.Join(tmp, "zap.clj")
+	zap := `{"id": 7, "title": "Nightly Report", "nodes": [
+	  {"id": 1, "selected_api": "ScheduleAPI", "type_of": "read", "action": "every_day", "params": {"time_of_day": "8", "trigger_on_weekends": "no"}},
+	  {"id": 2, "parent_id": 1, "selected_api": "WebHookCLIAPI", "type_of": "write", "action": "get", "params": {"url": "https://api.example.com/report"}}]}`
+	if err := os.WriteFile(input, []byte(zap), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	cmd := newFlowsImportZapierCmd(&App{WorkspaceID: "ws-test"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{input, "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute failed: %v\n%s", err, out.String())
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	assertContains(t, string(b), `:slug :nightly-report`)
+	assertContains(t, string(b), `"0 8 * * 1-5"`)
+	assertContains(t, out.String(), `"path":`)
+}
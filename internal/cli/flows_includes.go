@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// flowIncludeNode is one #flow/include form in the include tree.
+type flowIncludeNode struct {
+	Path        string             `json:"path"`
+	File        string             `json:"file,omitempty"`
+	Resolved    string             `json:"resolved,omitempty"`
+	Line        int                `json:"line"`
+	Column      int                `json:"column"`
+	CacheHit    bool               `json:"cacheHit,omitempty"`
+	Cycle       bool               `json:"cycle,omitempty"`
+	EscapesRoot bool               `json:"escapesRoot,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Includes    []*flowIncludeNode `json:"includes,omitempty"`
+}
+
+// flowSourceFiles reads and caches the files an expansion touched, keyed by
+// absolute path, and renders them relative to the root flow file.
+type flowSourceFiles struct {
+	rootPath string
+	rootDir  string
+	contents map[string]string
+}
+
+func newFlowSourceFiles(path, literal string) *flowSourceFiles {
+	rootPath := flowLintRootPath(path)
+	return &flowSourceFiles{rootPath: rootPath, rootDir: filepath.Dir(rootPath), contents: map[string]string{rootPath: literal}}
+}
+
+func (f *flowSourceFiles) content(file string) string {
+	if content, ok := f.contents[file]; ok {
+		return content
+	}
+	b, _ := os.ReadFile(file)
+	f.contents[file] = string(b)
+	return string(b)
+}
+
+func (f *flowSourceFiles) display(file string) string {
+	if file == "" {
+		return ""
+	}
+	dirs := []string{f.rootDir}
+	if real, err := filepath.EvalSymlinks(f.rootDir); err == nil && real != f.rootDir {
+		dirs = append(dirs, real)
+	}
+	for _, dir := range dirs {
+		if pathWithinRoot(dir, file) {
+			if rel, err := filepath.Rel(dir, file); err == nil {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return file
+}
+
+// inspectFlowIncludes expands the flow source with an observer and returns
+// the include tree as the expander saw it. The expansion error, if any, is
+// returned alongside the partial tree.
+func inspectFlowIncludes(path, literal string) ([]*flowIncludeNode, map[string]any, error) {
+	files := newFlowSourceFiles(path, literal)
+	var roots []*flowIncludeNode
+	var open []*flowIncludeNode
+	unique := map[string]bool{}
+	summary := map[string]int{"includes": 0, "files": 0, "cacheHits": 0, "cycles": 0, "maxDepth": 0}
+	_, _, err := expandFlowSourceIncludesObserved(path, literal, func(event flowIncludeEvent) {
+		line, column := sourceLineColumn(files.content(event.From), event.Offset)
+		node := &flowIncludeNode{
+			Path:     event.Path,
+			File:     files.display(event.Resolved),
+			Resolved: event.Resolved,
+			Line:     line,
+			Column:   column,
+			CacheHit: event.CacheHit,
+			Cycle:    event.Cycle,
+		}
+		if event.Err != nil {
+			node.Error = event.Err.Error()
+			attempted := filepath.Clean(filepath.Join(filepath.Dir(event.From), event.Path))
+			node.EscapesRoot = !filepath.IsAbs(event.Path) && !pathWithinRoot(files.rootDir, attempted)
+			node.Resolved = attempted
+			node.File = files.display(attempted)
+		}
+		summary["includes"]++
+		if event.CacheHit {
+			summary["cacheHits"]++
+		}
+		if event.Cycle {
+			summary["cycles"]++
+		}
+		if event.Depth > summary["maxDepth"] {
+			summary["maxDepth"] = event.Depth
+		}
+		if event.Resolved != "" && !unique[event.Resolved] {
+			unique[event.Resolved] = true
+			summary["files"]++
+		}
+		if event.Depth-1 > len(open) {
+			// Depth can only grow one level at a time; guard anyway.
+			event.Depth = len(open) + 1
+		}
+		open = open[:event.Depth-1]
+		if len(open) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := open[len(open)-1]
+			parent.Includes = append(parent.Includes, node)
+		}
+		open = append(open, node)
+	})
+	if err != nil {
+		// Attach expansion failures (a missing file or a cycle detected one
+		// level down) to the include that was being expanded.
+		if len(open) > 0 && open[len(open)-1].Error == "" {
+			open[len(open)-1].Error = err.Error()
+		}
+	}
+	return roots, map[string]any{"includes": summary["includes"], "files": summary["files"], "cacheHits": summary["cacheHits"], "cycles": summary["cycles"], "maxDepth": summary["maxDepth"]}, err
+}
+
+// renderFlowIncludeTree draws the include tree as indented text lines.
+func renderFlowIncludeTree(root string, nodes []*flowIncludeNode) []string {
+	lines := []string{root}
+	var walk func(nodes []*flowIncludeNode, prefix string)
+	walk = func(nodes []*flowIncludeNode, prefix string) {
+		for i, node := range nodes {
+			branch, next := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, next = "└── ", "    "
+			}
+			label := firstNonBlankString(node.File, node.Path)
+			var notes []string
+			if node.CacheHit {
+				notes = append(notes, "cached")
+			}
+			if node.Cycle {
+				notes = append(notes, "cycle")
+			}
+			if node.EscapesRoot {
+				notes = append(notes, "escapes root")
+			}
+			if node.Error != "" && !node.Cycle && !node.EscapesRoot {
+				notes = append(notes, "error")
+			}
+			if len(notes) > 0 {
+				label += " (" + strings.Join(notes, ", ") + ")"
+			}
+			lines = append(lines, prefix+branch+label)
+			walk(node.Includes, prefix+next)
+		}
+	}
+	walk(nodes, "")
+	return lines
+}
+
+// bundleFlowSource expands includes and, when sourceMap is set, marks every
+// switch between files with a ";; source: <file>:<line>" comment. The
+// returned expanded text is exactly what flows push sends.
+func bundleFlowSource(path, literal string, sourceMap bool) (string, string, []string, error) {
+	expanded, smap, err := expandFlowSourceIncludesWithMap(path, literal)
+	if err != nil {
+		return "", "", nil, err
+	}
+	files := newFlowSourceFiles(path, literal)
+	var sources []string
+	seen := map[string]bool{}
+	for _, segment := range smap.Segments {
+		if !seen[segment.Path] {
+			seen[segment.Path] = true
+			sources = append(sources, files.display(segment.Path))
+		}
+	}
+	if !sourceMap {
+		return expanded, expanded, sources, nil
+	}
+	sum := sha256.Sum256([]byte(expanded))
+	var b strings.Builder
+	fmt.Fprintf(&b, ";; Bundled from %s by `breyta flows bundle`.\n", files.display(files.rootPath))
+	fmt.Fprintf(&b, ";; sha256 of the expanded source without these comments: %s\n", hex.EncodeToString(sum[:]))
+	prevPath, prevEnd := "", -1
+	for _, segment := range smap.Segments {
+		if segment.Path != prevPath || segment.Offset != prevEnd {
+			line, _ := sourceLineColumn(files.content(segment.Path), segment.Offset)
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, ";; source: %s:%d\n", files.display(segment.Path), line)
+		}
+		b.WriteString(expanded[segment.Start:segment.End])
+		prevPath, prevEnd = segment.Path, segment.Offset+segment.End-segment.Start
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteByte('\n')
+	}
+	return b.String(), expanded, sources, nil
+}
+
+func newFlowsIncludesCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "includes <file>",
+		Short: "Show the #flow/include tree of a local flow file",
+		Long: strings.TrimSpace(`
+Show how #flow/include forms in a local flow file resolve, without calling the
+API. Each include lists the path as written, the resolved file, where the
+include form sits, and whether it was served from the expansion cache (the
+same file included again). Cycles, missing files, and paths that escape the
+flow file's directory are reported on the include that caused them, and the
+command exits non-zero.
+`),
+		Example: strings.TrimSpace(`
+breyta flows includes ./flows/order-ingest.clj
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			b, err := readExplicitFile(path)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			tree, summary, expandErr := inspectFlowIncludes(path, string(b))
+			data := map[string]any{
+				"file":     path,
+				"includes": tree,
+				"summary":  summary,
+				"tree":     renderFlowIncludeTree(path, tree),
+				"valid":    expandErr == nil,
+			}
+			if expandErr == nil {
+				return writeData(cmd, app, nil, data)
+			}
+			data["error"] = expandErr.Error()
+			if err := writeOut(cmd, app, map[string]any{"ok": false, "workspaceId": app.WorkspaceID, "data": data}); err != nil {
+				return err
+			}
+			return guidedCLIErrorForCommand(cmd, "flow include resolution failed", []string{expandErr.Error()})
+		},
+	}
+	return cmd
+}
+
+func newFlowsBundleCmd(app *App) *cobra.Command {
+	var out string
+	var noSourceMap bool
+	cmd := &cobra.Command{
+		Use:   "bundle <file> -o <out.clj>",
+		Short: "Write a local flow file with all includes expanded",
+		Long: strings.TrimSpace(`
+Expand every #flow/include in a local flow file and write the single-file
+result. By default ";; source: <file>:<line>" comments mark where each part
+came from, and a header records the sha256 of the expanded source as flows
+push sends it. Pass --no-source-map to write exactly the pushed bytes.
+`),
+		Example: strings.TrimSpace(`
+breyta flows bundle ./flows/order-ingest.clj -o ./dist/order-ingest.clj
+breyta flows bundle ./flows/order-ingest.clj -o ./archive/order-ingest.pushed.clj --no-source-map
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if strings.TrimSpace(out) == "" {
+				return writeErr(cmd, errors.New("missing -o/--out"))
+			}
+			if abs, err := filepath.Abs(out); err == nil && abs == flowLintRootPath(path) {
+				return writeErr(cmd, errors.New("-o must not overwrite the source flow file"))
+			}
+			b, err := readExplicitFile(path)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			bundle, expanded, sources, err := bundleFlowSource(path, string(b), !noSourceMap)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if dir := filepath.Dir(out); dir != "." {
+				if err := makePublicDir(dir); err != nil {
+					return writeErr(cmd, err)
+				}
+			}
+			if err := atomicWriteFile(out, []byte(bundle), publicFileMode); err != nil {
+				return writeErr(cmd, err)
+			}
+			sum := sha256.Sum256([]byte(expanded))
+			return writeData(cmd, app, nil, map[string]any{
+				"file":      path,
+				"out":       out,
+				"bytes":     len(bundle),
+				"sha256":    hex.EncodeToString(sum[:]),
+				"sources":   sources,
+				"sourceMap": !noSourceMap,
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Output path for the bundled flow source")
+	cmd.Flags().BoolVar(&noSourceMap, "no-source-map", false, "Write the expanded source exactly as pushed, without comments")
+	return cmd
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeIncludeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFlowsIncludesPrintsTreeWithCacheHits(t *testing.T) {
+	dir := writeIncludeTestFiles(t, map[string]string{
+		"flow.clj":           "{:slug :orders\n :steps [#flow/include \"steps/a.edn\"\n         #flow/include \"steps/a.edn\"]\n :flow #flow/include \"body.clj\"}\n",
+		"steps/a.edn":        "{:id :orders/a :type :http :defaults #flow/include \"defaults.edn\"}",
+		"steps/defaults.edn": "{:method :get}",
+		"body.clj":           "'(flow/step :orders/a {})",
+	})
+	body := executeLocalAuthoringJSON(t, newFlowsIncludesCmd(&App{WorkspaceID: "ws-test"}), filepath.Join(dir, "flow.clj"))
+	data := body["data"].(map[string]any)
+	summary := data["summary"].(map[string]any)
+	if summary["includes"] != float64(4) || summary["files"] != float64(3) || summary["cacheHits"] != float64(1) || summary["maxDepth"] != float64(2) {
+		t.Fatalf("unexpected summary %#v", summary)
+	}
+	includes := data["includes"].([]any)
+	first := includes[0].(map[string]any)
+	if first["file"] != "steps/a.edn" || first["line"] != float64(2) {
+		t.Fatalf("unexpected first include %#v", first)
+	}
+	nested := first["includes"].([]any)[0].(map[string]any)
+	if nested["file"] != "steps/defaults.edn" {
+		t.Fatalf("unexpected nested include %#v", nested)
+	}
+	if second := includes[1].(map[string]any); second["cacheHit"] != true {
+		t.Fatalf("repeated include should be a cache hit: %#v", second)
+	}
+	tree := strings.Join(func() []string {
+		var lines []string
+		for _, line := range data["tree"].([]any) {
+			lines = append(lines, line.(string))
+		}
+		return lines
+	}(), "\n")
+	if !strings.Contains(tree, "│   └── steps/defaults.edn") || !strings.Contains(tree, "├── steps/a.edn (cached)") {
+		t.Fatalf("unexpected tree:\n%s", tree)
+	}
+}
+
+func TestFlowsIncludesReportsCyclesAndEscapes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		files map[string]string
+		want  string
+	}{
+		"cycle": {map[string]string{
+			"flow.clj": "{:slug :orders :flow #flow/include \"a.clj\"}",
+			"a.clj":    "#flow/include \"b.clj\"",
+			"b.clj":    "#flow/include \"a.clj\"",
+		}, "cycle"},
+		"escape": {map[string]string{
+			"flow/flow.clj": "{:slug :orders :flow #flow/include \"../outside.clj\"}",
+			"outside.clj":   "'(flow/input)",
+		}, "escapesRoot"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := writeIncludeTestFiles(t, tc.files)
+			root := filepath.Join(dir, "flow.clj")
+			if _, ok := tc.files["flow/flow.clj"]; ok {
+				root = filepath.Join(dir, "flow", "flow.clj")
+			}
+			cmd := newFlowsIncludesCmd(&App{WorkspaceID: "ws-test"})
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			cmd.SetArgs([]string{root})
+			if err := cmd.Execute(); err == nil {
+				t.Fatalf("expected failure\n%s", out.String())
+			}
+			if !strings.Contains(out.String(), `"`+tc.want+`":true`) || !strings.Contains(out.String(), `"valid":false`) || !strings.Contains(out.String(), `"ok":false`) {
+				t.Fatalf("expected %s to be reported:\n%s", tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestFlowsBundleWritesExpandedSourceWithSourceMap(t *testing.T) {
+	dir := writeIncludeTestFiles(t, map[string]string{
+		"flow.clj": "{:slug :orders\n :flow #flow/include \"body.clj\"}\n",
+		"body.clj": "'(flow/input)",
+	})
+	root := filepath.Join(dir, "flow.clj")
+	out := filepath.Join(dir, "dist", "bundle.clj")
+	body := executeLocalAuthoringJSON(t, newFlowsBundleCmd(&App{WorkspaceID: "ws-test"}), root, "-o", out)
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	bundle := string(b)
+	for _, want := range []string{";; source: flow.clj:1\n{:slug :orders", ";; source: body.clj:1\n'(flow/input)", ";; source: flow.clj:2\n}"} {
+		if !strings.Contains(bundle, want) {
+			t.Fatalf("bundle missing %q:\n%s", want, bundle)
+		}
+	}
+	expanded := "{:slug :orders\n :flow '(flow/input)}\n"
+	sum := sha256.Sum256([]byte(expanded))
+	data := body["data"].(map[string]any)
+	if data["sha256"] != hex.EncodeToString(sum[:]) || !strings.Contains(bundle, hex.EncodeToString(sum[:])) {
+		t.Fatalf("sha256 should cover the pushed source: %#v\n%s", data, bundle)
+	}
+
+	executeLocalAuthoringJSON(t, newFlowsBundleCmd(&App{WorkspaceID: "ws-test"}), root, "-o", out, "--no-source-map")
+	if b, _ := os.ReadFile(out); string(b) != expanded {
+		t.Fatalf("--no-source-map must write the pushed source verbatim:\n%s", b)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -68,9 +69,17 @@ func newFlowsLintCmd(app *App) *cobra.Command {
 	var server bool
 	var localOnly bool
 	var serverTimeout time.Duration
+	var configPath string
+	var output string
+	var fix bool
+	var dryRun bool
+	var all bool
+	var jobs int
+	var noCache bool
+	var allowSecrets bool
 
 	cmd := &cobra.Command{
-		Use:   "lint",
+		Use:   "lint [--all [dir]]",
 		Short: "Lint a local flow file before pushing",
 		Long: strings.TrimSpace(`
 Lint checks a candidate source file before it is written to Breyta.
@@ -80,14 +89,98 @@ Two stages are supported:
 - server lint sends the candidate flow literal for canonical, non-mutating API checks
 
 Use ` + "`flows validate <slug>`" + ` after push to validate stored draft/live state.
+
+Rules can be tuned per project in ` + "`.breyta.json`" + ` (found by walking up from
+the flow file, or passed with --config). Each rule is a diagnostic code mapped
+to a severity (off, info, warning, error), optionally scoped with path globs:
+
+  {"lint": {"rules": {"missing_interfaces": "off",
+                      "hardcoded_workspace_id": {"severity": "error", "paths": ["flows/prod/**"]}}}}
+
+Place ` + "`#_{:breyta/lint-ignore [:unsupported-flow-form]}`" + ` directly before a form
+to suppress matching diagnostics inside it; before a top-level key it covers
+that key, and before the top-level map it covers the whole file. Suppressed
+diagnostics are listed in meta.suppressed, and suppressions that match nothing
+are reported as unused_lint_suppression warnings. Suppressions of codes only
+the server reports are checked only when the server stage ran.
+
+Every diagnostic carries file, line, and column, resolved through
+#flow/include files. Use --output sarif, junit, github, or checkstyle to
+publish findings to code scanning, CI test reports, or pull request
+annotations; the exit status still reflects lint errors.
+
+Diagnostics marked "fixable" have a safe mechanical rewrite. --fix applies
+those rewrites to the flow file (never to #flow/include files), then lints the
+result; meta.fix lists each applied fix with a unified diff. Add --dry-run to
+preview the fixes and the post-fix diagnostics without writing the file.
+
+--all [dir] lints every flow file under dir (default ./flows) concurrently.
+Flow files are .clj files with a top-level :slug; included files are linted
+through the flows that include them. Results are cached under the user cache
+directory, keyed by the expanded source, lint config, and CLI version, so
+unchanged flows are not re-linted; --no-cache ignores the cache. With --all,
+server lint runs only when --server is passed and only for flows whose cached
+result has no server stage. The report lists every file with its exit status.
 `),
 		Example: strings.TrimSpace(`
 breyta flows lint --file ./flows/order-ingest.clj
 breyta flows lint --file ./flows/order-ingest.clj --server
 breyta flows lint --file ./flows/order-ingest.clj --server --timeout 2m
 breyta flows lint --file ./flows/order-ingest.clj --local-only
+breyta flows lint --file ./flows/order-ingest.clj --local-only --output github
+breyta flows lint --file ./flows/order-ingest.clj --output sarif > lint.sarif
+breyta flows lint --file ./flows/order-ingest.clj --fix --dry-run
+breyta flows lint --all
+breyta flows lint --all ./flows --server --jobs 4
 `),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if all || len(args) > 0 {
+				if !all {
+					return writeErr(cmd, errors.New("a directory argument requires --all"))
+				}
+				if strings.TrimSpace(file) != "" {
+					return writeErr(cmd, errors.New("--all cannot be combined with --file"))
+				}
+				if fix {
+					return writeErr(cmd, errors.New("--fix is not supported with --all; fix files one at a time"))
+				}
+				if server && localOnly {
+					return writeErr(cmd, errors.New("--server cannot be combined with --local-only"))
+				}
+				if serverTimeout <= 0 {
+					return writeErr(cmd, errors.New("--timeout must be > 0"))
+				}
+				output = strings.ToLower(strings.TrimSpace(output))
+				if !validFlowLintOutputFormat(output) {
+					return writeErr(cmd, fmt.Errorf("unsupported --output %q (use %s)", output, strings.Join(flowLintOutputFormats, ", ")))
+				}
+				dir := "flows"
+				if len(args) == 1 {
+					dir = args[0]
+				}
+				files, err := discoverLocalFlowFiles(dir)
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				if len(files) == 0 {
+					return writeErr(cmd, fmt.Errorf("no flow files found under %s", dir))
+				}
+				if server {
+					if err := requireAPI(app); err != nil {
+						return writeErr(cmd, err)
+					}
+				}
+				results := runFlowLintAll(cmd.Context(), app, files, flowLintAllOptions{
+					configPath:    configPath,
+					server:        server,
+					serverTimeout: serverTimeout,
+					jobs:          jobs,
+					noCache:       noCache,
+					allowSecrets:  allowSecrets,
+				})
+				return writeFlowLintAllResult(cmd, app, output, dir, results)
+			}
 			if strings.TrimSpace(file) == "" {
 				return writeErr(cmd, errors.New("missing --file"))
 			}
@@ -97,26 +190,53 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 			if serverTimeout <= 0 {
 				return writeErr(cmd, errors.New("--timeout must be > 0"))
 			}
+			if dryRun && !fix {
+				return writeErr(cmd, errors.New("--dry-run requires --fix"))
+			}
+			output = strings.ToLower(strings.TrimSpace(output))
+			if !validFlowLintOutputFormat(output) {
+				return writeErr(cmd, fmt.Errorf("unsupported --output %q (use %s)", output, strings.Join(flowLintOutputFormats, ", ")))
+			}
 			b, err := readExplicitFile(file)
 			if err != nil {
 				return writeErr(cmd, err)
 			}
 
 			flowLiteral := string(b)
-			diagnostics := localFlowLintPreExpansionDiagnostics(file, flowLiteral)
-			expandedLiteral := flowLiteral
-			if !lintHasErrors(diagnostics) {
-				if expanded, err := expandFlowSourceIncludes(file, flowLiteral); err != nil {
-					diagnostics = append(diagnostics, lintDiagnostic("error", "flow_include_invalid", []string{":flow"}, err.Error(), "Fix #flow/include paths before linting or pushing.", "local"))
-				} else {
-					expandedLiteral = expanded
-					diagnostics = append(diagnostics, localFlowLintDiagnostics(file, expandedLiteral, expandedLiteral != flowLiteral)...)
-					diagnostics = append(diagnostics, localUnsupportedFlowFormDiagnostics(expandedLiteral)...)
-					diagnostics = append(diagnostics, localAuthoringShapeDiagnostics(expandedLiteral, flowLiteral, pulledLegacyFunctionInputSteps(flowLiteral))...)
-					diagnostics = append(diagnostics, localFunctionCodeStringDiagnostics(expandedLiteral)...)
+			var fixMeta map[string]any
+			if fix {
+				fixed, fixes, err := fixFlowLintSource(file, flowLiteral, configPath)
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				fixMeta = map[string]any{
+					"dryRun":  dryRun,
+					"written": false,
+					"fixes":   fixes,
+				}
+				if fixed != flowLiteral {
+					fixMeta["diff"] = unifiedSourceFileDiff(file, file, flowLiteral, fixed)
+					if !dryRun {
+						if err := atomicWriteFile(file, []byte(fixed), 0o644); err != nil {
+							return writeErr(cmd, err)
+						}
+						fixMeta["written"] = true
+					}
+					flowLiteral = fixed
 				}
 			}
 
+			local, err := runFlowLintLocalStage(file, flowLiteral, configPath)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			diagnostics := local.diagnostics
+			if allowSecrets {
+				diagnostics = withoutSecretLiteralDiagnostics(diagnostics)
+			}
+			expandedLiteral := local.expandedLiteral
+			policy := local.policy
+
 			meta := map[string]any{
 				"stages": []string{"local"},
 			}
@@ -143,6 +263,7 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 			}
 
 			flowSlug := ""
+			serverRan := false
 			if serverCanRun {
 				out, status, err := runAPICommandWithContextAndTimeout(cmd.Context(), app, "flows.lint", map[string]any{"flowLiteral": expandedLiteral}, serverTimeout)
 				if err != nil {
@@ -160,6 +281,7 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 					meta["serverError"] = formatAPIError(out)
 				} else {
 					meta["stages"] = []string{"local", "server"}
+					serverRan = true
 					if serverMeta, ok := out["meta"].(map[string]any); ok {
 						if next, exists := serverMeta["nextCommands"]; exists {
 							meta["nextCommands"] = next
@@ -169,10 +291,17 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 						if slug, _ := data["flowSlug"].(string); strings.TrimSpace(slug) != "" {
 							flowSlug = strings.TrimSpace(slug)
 						}
-						diagnostics = append(diagnostics, serverFlowLintDiagnostics(data)...)
+						diagnostics = append(diagnostics, policy.apply(serverFlowLintDiagnostics(data))...)
 					}
 				}
 			}
+			if local.scanned {
+				diagnostics = append(diagnostics, policy.apply(policy.unusedSuppressionDiagnostics(serverRan))...)
+			}
+			policy.annotateMeta(meta)
+			if fixMeta != nil {
+				meta["fix"] = fixMeta
+			}
 
 			if !lintHasErrors(diagnostics) {
 				meta["nextCommands"] = []string{"breyta flows push --file " + file}
@@ -181,6 +310,11 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 				}
 			}
 
+			newFlowLintLocator(file, flowLiteral, expandedLiteral, local.sourceMap).annotate(diagnostics)
+			markFlowLintFixable(file, local, diagnostics)
+			if output != "json" {
+				return writeFlowLintFormattedResult(cmd, output, file, diagnostics)
+			}
 			return writeFlowLintResult(cmd, app, meta, flowSlug, diagnostics)
 		},
 	}
@@ -189,9 +323,58 @@ breyta flows lint --file ./flows/order-ingest.clj --local-only
 	cmd.Flags().BoolVar(&server, "server", false, "Require canonical server lint after local lint")
 	cmd.Flags().BoolVar(&localOnly, "local-only", false, "Run only local lint checks; never call the API")
 	cmd.Flags().DurationVar(&serverTimeout, "timeout", defaultFlowLintServerTimeout, "Server lint request timeout")
+	cmd.Flags().StringVar(&output, "output", "json", "Output format: json, sarif, junit, github, or checkstyle")
+	cmd.Flags().StringVar(&configPath, "config", "", "Project lint config (default: nearest "+projectConfigFileName+" above the flow file)")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Apply safe automatic fixes to the flow file before linting")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --fix, report the fixes and diff without writing the file")
+	cmd.Flags().BoolVar(&all, "all", false, "Lint every flow file under a directory (default ./flows)")
+	cmd.Flags().IntVar(&jobs, "jobs", runtime.GOMAXPROCS(0), "With --all, number of flows to lint concurrently")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "With --all, ignore and do not update the lint cache")
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Do not report string literals that look like API keys or tokens")
 	return cmd
 }
 
+// flowLintLocalResult is the outcome of the local lint stage. Diagnostics
+// already have the project policy applied; unused suppressions are reported
+// separately once server diagnostics have had a chance to use them.
+type flowLintLocalResult struct {
+	diagnostics     []flowLintDiagnostic
+	expandedLiteral string
+	sourceMap       flowSourceMap
+	scanned         bool
+	policy          *flowLintPolicy
+}
+
+func runFlowLintLocalStage(file, flowLiteral, configPath string) (flowLintLocalResult, error) {
+	result := flowLintLocalResult{
+		diagnostics:     localFlowLintPreExpansionDiagnostics(file, flowLiteral),
+		expandedLiteral: flowLiteral,
+	}
+	if !lintHasErrors(result.diagnostics) {
+		if expanded, expandedMap, err := expandFlowSourceIncludesWithMap(file, flowLiteral); err != nil {
+			result.diagnostics = append(result.diagnostics, lintDiagnostic("error", "flow_include_invalid", []string{":flow"}, err.Error(), "Fix #flow/include paths before linting or pushing.", "local"))
+		} else {
+			result.expandedLiteral = expanded
+			result.sourceMap = expandedMap
+			result.scanned = true
+			result.diagnostics = append(result.diagnostics, localFlowLintDiagnostics(file, expanded, expanded != flowLiteral)...)
+			result.diagnostics = append(result.diagnostics, localUnsupportedFlowFormDiagnostics(expanded)...)
+			result.diagnostics = append(result.diagnostics, localAuthoringShapeDiagnostics(expanded, flowLiteral, pulledLegacyFunctionInputSteps(flowLiteral))...)
+			result.diagnostics = append(result.diagnostics, localFunctionCodeStringDiagnostics(expanded)...)
+			result.diagnostics = append(result.diagnostics, localStepSchemaDiagnostics(file, configPath, expanded)...)
+			result.diagnostics = append(result.diagnostics, localSecretLiteralDiagnostics(expanded)...)
+			result.diagnostics = append(result.diagnostics, localTemplateVariableDiagnostics(expanded)...)
+		}
+	}
+	policy, err := newFlowLintPolicy(file, configPath, result.expandedLiteral)
+	if err != nil {
+		return flowLintLocalResult{}, err
+	}
+	result.policy = policy
+	result.diagnostics = policy.apply(result.diagnostics)
+	return result, nil
+}
+
 func flowLintServerError(err error, timeout time.Duration) error {
 	if errors.Is(err, context.DeadlineExceeded) {
 		return fmt.Errorf("flows lint server timed out after %s; rerun with --local-only or increase --timeout", timeout)
@@ -2328,6 +2511,16 @@ func clojureFormToken(src string, span clojureFormSpan) string {
 	return strings.TrimSpace(src[span.Start:span.End])
 }
 
+func writeFlowLintFormattedResult(cmd *cobra.Command, output, file string, diagnostics []flowLintDiagnostic) error {
+	if err := writeFlowLintFormatted(cmd.OutOrStdout(), output, file, diagnostics); err != nil {
+		return writeErr(cmd, err)
+	}
+	if lintHasErrors(diagnostics) {
+		return guidedCLIErrorForCommand(cmd, "flow lint found errors", nil)
+	}
+	return nil
+}
+
 // localFlowStepReference records one EXECUTABLE flow/step form found by the
 // quote-aware body walker (nested quoted forms are treated as data and never
 // collected). StepID is set only for the packaged (flow/step :ns/id ...) shape;
@@ -2359,6 +2552,9 @@ type localFlowStepReference struct {
 	// can never be a valid step type or packaged id.
 	FirstArgNeverStepType bool
 	Plain                 bool
+	// ConfigOffset is the absolute offset of a literal {...} config map in
+	// the third argument position, or zero when there is none.
+	ConfigOffset int
 }
 
 // stripClojureStringLiterals returns src with the CONTENTS of double-quoted
@@ -2698,6 +2894,9 @@ func localFlowStepReferencesInListAtDepth(src string, listStart, baseOffset, syn
 		}
 		if len(elements) >= 4 {
 			if start, ok := clojureActiveFormStart(src, elements[3].Start); ok && start < len(src) {
+				if src[start] == '{' {
+					reference.ConfigOffset = baseOffset + start
+				}
 				switch c := src[start]; {
 				case c == '[' || c == '"' || c == ':' || c == '\\':
 					// Vector, string, keyword, or character literal — never a
@@ -3210,13 +3409,24 @@ func localFlowStepArityDiagnostics(src string, sourceExpanded bool) []flowLintDi
 // value was opaque (allKnown=false), the caller cannot know which steps it
 // exposes and must suppress the unreferenced warning entirely.
 func localToolsExposedStepIDs(src string) (map[string]bool, bool) {
-	ids := map[string]bool{}
+	offsets, allKnown := localToolsExposedStepOffsets(src)
+	ids := make(map[string]bool, len(offsets))
+	for id := range offsets {
+		ids[id] = true
+	}
+	return ids, allKnown
+}
+
+// localToolsExposedStepOffsets is localToolsExposedStepIDs keyed to the byte
+// offsets of each exposed step keyword, for callers that rewrite them.
+func localToolsExposedStepOffsets(src string) (map[string][]int, bool) {
+	ids := map[string][]int{}
 	allKnown := true
 	collectToolsExposedStepIDs(src, 0, len(src), ids, &allKnown)
 	return ids, allKnown
 }
 
-func collectToolsExposedStepIDs(src string, start, end int, ids map[string]bool, allKnown *bool) {
+func collectToolsExposedStepIDs(src string, start, end int, ids map[string][]int, allKnown *bool) {
 	i, ok := clojureActiveFormStart(src, start)
 	if !ok || i >= end || i >= len(src) {
 		return
@@ -3278,7 +3488,7 @@ func collectToolsExposedStepIDs(src string, start, end int, ids map[string]bool,
 	}
 }
 
-func collectToolsExposedStepIDsInSpans(src string, ids map[string]bool, spans []clojureFormSpan, allKnown *bool) {
+func collectToolsExposedStepIDsInSpans(src string, ids map[string][]int, spans []clojureFormSpan, allKnown *bool) {
 	for _, span := range spans {
 		collectToolsExposedStepIDs(src, span.Start, span.End, ids, allKnown)
 	}
@@ -3298,7 +3508,7 @@ func parseListSpans(src string, start int) []clojureFormSpan {
 // unwrap is opaque, and callers must then treat every packaged step as
 // potentially exposed (suppress the warning; over-suppression is the accepted
 // direction for this warning-severity dead-code lint).
-func collectToolsStepsVectorIDs(src string, toolsEntry clojureMapEntry, ids map[string]bool) bool {
+func collectToolsStepsVectorIDs(src string, toolsEntry clojureMapEntry, ids map[string][]int) bool {
 	valueStart, ok := unwrapSingleReaderQuote(src, toolsEntry.ValueStart)
 	if !ok || valueStart >= len(src) || src[valueStart] != '{' {
 		return false
@@ -3333,7 +3543,8 @@ func collectToolsStepsVectorIDs(src string, toolsEntry clojureMapEntry, ids map[
 				// the exposure set is incomplete → opaque.
 				return false
 			}
-			ids[id] = true
+			start, _ := clojureActiveFormStart(src, span.Start)
+			ids[id] = append(ids[id], start)
 		}
 	}
 	return true
@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breyta/breyta-cli/internal/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+// flowLintCacheEntry is one cached lint result. Entries are stored per key
+// under the user cache directory, so concurrent workers never share a file.
+type flowLintCacheEntry struct {
+	Diagnostics []flowLintDiagnostic `json:"diagnostics"`
+	Stages      []string             `json:"stages"`
+	FlowSlug    string               `json:"flowSlug,omitempty"`
+}
+
+// flowLintFileResult is the per-file row of a flows lint --all report.
+type flowLintFileResult struct {
+	File        string               `json:"file"`
+	Valid       bool                 `json:"valid"`
+	ExitStatus  int                  `json:"exitStatus"`
+	Cached      bool                 `json:"cached"`
+	Stages      []string             `json:"stages"`
+	FlowSlug    string               `json:"flowSlug,omitempty"`
+	Errors      int                  `json:"errors"`
+	Warnings    int                  `json:"warnings"`
+	Diagnostics []flowLintDiagnostic `json:"diagnostics"`
+	ServerError string               `json:"serverError,omitempty"`
+}
+
+type flowLintAllOptions struct {
+	configPath    string
+	server        bool
+	serverTimeout time.Duration
+	jobs          int
+	noCache       bool
+	allowSecrets  bool
+}
+
+func flowLintCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(dir) == "" {
+		return "", errors.New("missing user cache dir")
+	}
+	return filepath.Join(dir, "breyta", "lint"), nil
+}
+
+// flowLintCacheKey hashes everything that can change a file's diagnostics:
+// the CLI version, the expanded source, the path the diagnostics are reported
+// against, the project lint config, the offline step schemas, and
+// --allow-secrets.
+func flowLintCacheKey(file, expanded string, opts flowLintAllOptions) string {
+	h := sha256.New()
+	cwd, _ := os.Getwd()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%t\x00", buildinfo.DisplayVersion(), buildinfo.Commit, cwd, file, opts.allowSecrets)
+	configPath := opts.configPath
+	config := strings.TrimSpace(configPath)
+	if config == "" {
+		if found, ok, err := findProjectConfigPath(file); err == nil && ok {
+			config = found
+		}
+	}
+	if config != "" {
+		b, _ := os.ReadFile(config)
+		fmt.Fprintf(h, "%s\x00%s\x00", config, b)
+	}
+	if schemas, ok := findStepSchemaBundlePath(file, configPath); ok {
+		b, _ := os.ReadFile(schemas) // #nosec G304 -- bundle path comes from docs sync or the project config.
+		fmt.Fprintf(h, "%s\x00%s\x00", schemas, b)
+	}
+	h.Write([]byte(expanded))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readFlowLintCache(dir, key string) (flowLintCacheEntry, bool) {
+	if dir == "" {
+		return flowLintCacheEntry{}, false
+	}
+	b, err := os.ReadFile(filepath.Join(dir, key+".json")) // #nosec G304 -- cache path is resolved under the user cache directory.
+	if err != nil {
+		return flowLintCacheEntry{}, false
+	}
+	var entry flowLintCacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return flowLintCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func writeFlowLintCache(dir, key string, entry flowLintCacheEntry) {
+	if dir == "" {
+		return
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	_ = atomicWriteFile(filepath.Join(dir, key+".json"), b, 0o600)
+}
+
+// discoverLocalFlowFiles walks dir for .clj files whose top-level map has a
+// :slug. Included body and step files have no :slug and are linted through
+// the flow that includes them.
+func discoverLocalFlowFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || strings.HasPrefix(entry.Name(), "__")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".clj" {
+			return nil
+		}
+		b, err := os.ReadFile(path) // #nosec G304 -- walking the user's flow directory.
+		if err != nil {
+			return err
+		}
+		if _, found, err := localTopLevelEntry(string(b), "slug"); err == nil && found {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func countFlowLintSeverities(diagnostics []flowLintDiagnostic) (int, int) {
+	errorsCount, warnings := 0, 0
+	for _, diag := range diagnostics {
+		switch strings.ToLower(flowLintDiagnosticString(diag, "severity")) {
+		case "error":
+			errorsCount++
+		case "warning":
+			warnings++
+		}
+	}
+	return errorsCount, warnings
+}
+
+// lintFlowFileForAll runs the same local and optional server stages as a
+// single-file lint and consults the cache first. Server lint only runs on
+// cache misses, or when the cached result predates a --server run.
+func lintFlowFileForAll(ctx context.Context, app *App, file string, opts flowLintAllOptions, cacheDir string) flowLintFileResult {
+	result := flowLintFileResult{File: file, Stages: []string{"local"}}
+	finish := func() flowLintFileResult {
+		if result.Diagnostics == nil {
+			result.Diagnostics = []flowLintDiagnostic{}
+		}
+		result.Errors, result.Warnings = countFlowLintSeverities(result.Diagnostics)
+		result.Valid = result.Errors == 0
+		if !result.Valid {
+			result.ExitStatus = 1
+		}
+		return result
+	}
+	b, err := readExplicitFile(file)
+	if err != nil {
+		result.Diagnostics = []flowLintDiagnostic{lintDiagnostic("error", "flow_file_unreadable", nil, err.Error(), "", "local")}
+		return finish()
+	}
+	source := string(b)
+
+	key := ""
+	if cacheDir != "" {
+		if expanded, _, err := expandFlowSourceIncludesWithMap(file, source); err == nil {
+			key = flowLintCacheKey(file, expanded, opts)
+		}
+	}
+	if key != "" {
+		if entry, ok := readFlowLintCache(cacheDir, key); ok && (!opts.server || slices.Contains(entry.Stages, "server")) {
+			result.Cached = true
+			result.Diagnostics = entry.Diagnostics
+			result.Stages = entry.Stages
+			result.FlowSlug = entry.FlowSlug
+			// A local-only run reuses the local part of a server-linted entry.
+			if !opts.server && slices.Contains(entry.Stages, "server") {
+				result.Diagnostics = make([]flowLintDiagnostic, 0, len(entry.Diagnostics))
+				for _, diagnostic := range entry.Diagnostics {
+					if stage, _ := diagnostic["stage"].(string); stage != "server" {
+						result.Diagnostics = append(result.Diagnostics, diagnostic)
+					}
+				}
+				result.Stages = []string{"local"}
+				result.FlowSlug = ""
+			}
+			return finish()
+		}
+	}
+
+	local, err := runFlowLintLocalStage(file, source, opts.configPath)
+	if err != nil {
+		result.Diagnostics = []flowLintDiagnostic{lintDiagnostic("error", "lint_config_invalid", nil, err.Error(), "", "local")}
+		return finish()
+	}
+	diagnostics := local.diagnostics
+	if opts.allowSecrets {
+		diagnostics = withoutSecretLiteralDiagnostics(diagnostics)
+	}
+	cacheable := true
+	if opts.server && !lintHasErrors(diagnostics) {
+		out, status, err := runAPICommandWithContextAndTimeout(ctx, app, "flows.lint", map[string]any{"flowLiteral": local.expandedLiteral}, opts.serverTimeout)
+		switch {
+		case err != nil:
+			result.ServerError = flowLintServerError(err, opts.serverTimeout).Error()
+			cacheable = false
+		case status >= 400:
+			result.ServerError = formatAPIError(out)
+			cacheable = false
+		default:
+			result.Stages = []string{"local", "server"}
+			if data, ok := out["data"].(map[string]any); ok {
+				if slug, _ := data["flowSlug"].(string); strings.TrimSpace(slug) != "" {
+					result.FlowSlug = strings.TrimSpace(slug)
+				}
+				diagnostics = append(diagnostics, local.policy.apply(serverFlowLintDiagnostics(data))...)
+			}
+		}
+		if result.ServerError != "" {
+			diagnostics = append(diagnostics, lintDiagnostic("error", "server_lint_failed", nil, result.ServerError, "Rerun once the API is reachable, or drop --server.", "server"))
+		}
+	}
+	if local.scanned {
+		diagnostics = append(diagnostics, local.policy.apply(local.policy.unusedSuppressionDiagnostics(len(result.Stages) > 1))...)
+	}
+	newFlowLintLocator(file, source, local.expandedLiteral, local.sourceMap).annotate(diagnostics)
+	markFlowLintFixable(file, local, diagnostics)
+	result.Diagnostics = diagnostics
+	if key != "" && cacheable {
+		writeFlowLintCache(cacheDir, key, flowLintCacheEntry{Diagnostics: diagnostics, Stages: result.Stages, FlowSlug: result.FlowSlug})
+	}
+	return finish()
+}
+
+// runFlowLintAll lints every discovered flow with a bounded worker pool and
+// returns the results in file order.
+func runFlowLintAll(ctx context.Context, app *App, files []string, opts flowLintAllOptions) []flowLintFileResult {
+	cacheDir := ""
+	if !opts.noCache {
+		if dir, err := flowLintCacheDir(); err == nil {
+			cacheDir = dir
+		}
+	}
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]flowLintFileResult, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				results[idx] = lintFlowFileForAll(ctx, app, files[idx], opts, cacheDir)
+			}
+		}()
+	}
+	for idx := range files {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func writeFlowLintAllResult(cmd *cobra.Command, app *App, output, dir string, results []flowLintFileResult) error {
+	var all []flowLintDiagnostic
+	failed, cached, errorsCount, warnings := 0, 0, 0, 0
+	for _, result := range results {
+		all = append(all, result.Diagnostics...)
+		if !result.Valid {
+			failed++
+		}
+		if result.Cached {
+			cached++
+		}
+		errorsCount += result.Errors
+		warnings += result.Warnings
+	}
+	valid := failed == 0
+	if output != "json" {
+		if err := writeFlowLintFormatted(cmd.OutOrStdout(), output, dir, all); err != nil {
+			return writeErr(cmd, err)
+		}
+	} else {
+		out := map[string]any{
+			"ok":          valid,
+			"workspaceId": app.WorkspaceID,
+			"meta": map[string]any{
+				"dir": dir,
+				"summary": map[string]any{
+					"files":    len(results),
+					"failed":   failed,
+					"cached":   cached,
+					"errors":   errorsCount,
+					"warnings": warnings,
+				},
+			},
+			"data": map[string]any{
+				"valid": valid,
+				"files": results,
+			},
+		}
+		if err := writeOut(cmd, app, out); err != nil {
+			return err
+		}
+	}
+	if !valid {
+		return guidedCLIErrorForCommand(cmd, fmt.Sprintf("flow lint found errors in %d of %d files", failed, len(results)), nil)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+const lintAllCleanFlow = `{:slug :clean
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :flow #flow/include "shared/body.clj"}
+`
+
+const lintAllBrokenFlow = `{:slug :broken
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :flow '(flow/input)}
+`
+
+func writeLintAllTestFlows(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"clean.clj":         lintAllCleanFlow,
+		"nested/broken.clj": lintAllBrokenFlow,
+		"shared/body.clj":   "'(let [input (flow/input)] input)\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func runLintAll(t *testing.T, app *App, args ...string) (map[string]any, error) {
+	t.Helper()
+	cmd := newFlowsLintCmd(app)
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(append([]string{"--all"}, args...))
+	err := cmd.Execute()
+	var body map[string]any
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+		t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+	}
+	return body, err
+}
+
+func lintAllFileRows(t *testing.T, body map[string]any) map[string]map[string]any {
+	t.Helper()
+	rows := map[string]map[string]any{}
+	for _, item := range body["data"].(map[string]any)["files"].([]any) {
+		row := item.(map[string]any)
+		rows[filepath.Base(row["file"].(string))] = row
+	}
+	return rows
+}
+
+func TestFlowsLintAllReportsEveryFlowAndCachesResults(t *testing.T) {
+	dir := writeLintAllTestFlows(t)
+	body, err := runLintAll(t, &App{WorkspaceID: "ws-test"}, dir, "--jobs", "2")
+	if err == nil {
+		t.Fatalf("expected a non-zero exit for the broken flow")
+	}
+	rows := lintAllFileRows(t, body)
+	if len(rows) != 2 {
+		t.Fatalf("included files must not be linted on their own: %#v", rows)
+	}
+	if rows["clean.clj"]["exitStatus"] != float64(0) || rows["broken.clj"]["exitStatus"] != float64(1) {
+		t.Fatalf("unexpected per-file status %#v", rows)
+	}
+	if rows["clean.clj"]["cached"] != false {
+		t.Fatalf("first run must not be cached: %#v", rows["clean.clj"])
+	}
+
+	body, _ = runLintAll(t, &App{WorkspaceID: "ws-test"}, dir)
+	rows = lintAllFileRows(t, body)
+	if rows["clean.clj"]["cached"] != true || rows["broken.clj"]["cached"] != true || rows["broken.clj"]["valid"] != false {
+		t.Fatalf("second run should reuse cached results: %#v", rows)
+	}
+
+	// Changing an included file changes the expanded source of its flow.
+	if err := os.WriteFile(filepath.Join(dir, "shared", "body.clj"), []byte("'(flow/input)\n"), 0o644); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+	body, _ = runLintAll(t, &App{WorkspaceID: "ws-test"}, dir)
+	rows = lintAllFileRows(t, body)
+	if rows["clean.clj"]["cached"] != false || rows["broken.clj"]["cached"] != true {
+		t.Fatalf("only the flow with a changed include should be re-linted: %#v", rows)
+	}
+	summary := body["meta"].(map[string]any)["summary"].(map[string]any)
+	if summary["files"] != float64(2) || summary["failed"] != float64(1) || summary["cached"] != float64(1) {
+		t.Fatalf("unexpected summary %#v", summary)
+	}
+}
+
+func TestFlowsLintAllRunsServerLintOnlyForChangedFlows(t *testing.T) {
+	dir := writeLintAllTestFlows(t)
+	if err := os.Remove(filepath.Join(dir, "nested", "broken.clj")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	var calls atomic.Int32
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"flowSlug": "clean", "diagnostics": []any{
+			map[string]any{"severity": "warning", "code": "server_only_check", "message": "checked on the server"},
+		}}})
+	}))
+	defer srv.Close()
+	app := &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+
+	if _, err := runLintAll(t, app, dir, "--server"); err != nil {
+		t.Fatalf("lint --all --server failed: %v", err)
+	}
+	body, err := runLintAll(t, app, dir, "--server")
+	if err != nil {
+		t.Fatalf("cached lint --all --server failed: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one server lint call for one changed flow, got %d", calls.Load())
+	}
+	row := lintAllFileRows(t, body)["clean.clj"]
+	if row["cached"] != true || row["flowSlug"] != "clean" {
+		t.Fatalf("unexpected cached server row %#v", row)
+	}
+
+	// A local-only run reuses the entry without the server stage.
+	body, err = runLintAll(t, app, dir)
+	if err != nil {
+		t.Fatalf("cached local lint --all failed: %v", err)
+	}
+	row = lintAllFileRows(t, body)["clean.clj"]
+	if row["cached"] != true || mustJSON(t, row["stages"]) != `["local"]` || mustJSON(t, row["diagnostics"]) != `[]` {
+		t.Fatalf("local-only run must not report server results: %#v", row)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("a local-only run must not call the server, got %d calls", calls.Load())
+	}
+
+	if _, err := runLintAll(t, app, dir, "--server", "--no-cache"); err != nil {
+		t.Fatalf("lint --no-cache failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("--no-cache should lint on the server again, got %d calls", calls.Load())
+	}
+}
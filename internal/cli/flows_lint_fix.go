@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxFlowLintFixPasses bounds the fix loop. Each pass lints the current
+// source and applies every non-overlapping fix; later passes pick up fixes
+// that overlapped or that earlier rewrites exposed.
+const maxFlowLintFixPasses = 5
+
+// flowLintEdit replaces Start..End of the linted source with Text.
+type flowLintEdit struct {
+	Start       int
+	End         int
+	Text        string
+	Description string
+}
+
+// flowLintFixer returns a safe rewrite for one diagnostic, or false when this
+// occurrence has no mechanical fix. Offsets are in the expanded flow source.
+type flowLintFixer func(src string, diag flowLintDiagnostic) (flowLintEdit, bool)
+
+// flowLintFixers declares which lint rules are auto-fixable. Rules not listed
+// here are never rewritten by --fix.
+var flowLintFixers = map[string]flowLintFixer{
+	"clojure_delimiters_invalid":   fixFlowLintDelimiters,
+	"unsupported_visual_flow_form": fixFlowLintThreadingForm,
+	"function_step_arity_invalid":  fixFlowLintFunctionStepInlineConfig,
+	"unused_lint_suppression":      fixFlowLintUnusedSuppression,
+}
+
+// planFlowLintFix returns the fix for diag translated to root file offsets.
+// Fixes that would touch an included file are skipped so --fix only ever
+// edits the file it was pointed at.
+func planFlowLintFix(rootPath string, local flowLintLocalResult, diag flowLintDiagnostic) (flowLintEdit, bool) {
+	code, _ := diag["code"].(string)
+	fixer, ok := flowLintFixers[code]
+	if !ok {
+		return flowLintEdit{}, false
+	}
+	edit, ok := fixer(local.expandedLiteral, diag)
+	if !ok {
+		return flowLintEdit{}, false
+	}
+	start, end, ok := flowSourceRootRange(local.sourceMap, rootPath, edit.Start, edit.End)
+	if !ok {
+		return flowLintEdit{}, false
+	}
+	edit.Start, edit.End = start, end
+	return edit, true
+}
+
+// flowSourceRootRange maps an expanded source range to the root file when the
+// whole range was copied from it.
+func flowSourceRootRange(m flowSourceMap, rootPath string, start, end int) (int, int, bool) {
+	if len(m.Segments) == 0 {
+		return start, end, true
+	}
+	for _, segment := range m.Segments {
+		if segment.Path == rootPath && start >= segment.Start && end <= segment.End {
+			return segment.Offset + start - segment.Start, segment.Offset + end - segment.Start, true
+		}
+	}
+	return 0, 0, false
+}
+
+func flowLintRootPath(file string) string {
+	if abs, err := filepath.Abs(strings.TrimSpace(file)); err == nil {
+		return abs
+	}
+	return file
+}
+
+// markFlowLintFixable flags diagnostics that --fix would rewrite.
+func markFlowLintFixable(file string, local flowLintLocalResult, diagnostics []flowLintDiagnostic) {
+	rootPath := flowLintRootPath(file)
+	for _, diag := range diagnostics {
+		if _, ok := planFlowLintFix(rootPath, local, diag); ok {
+			diag["fixable"] = true
+		}
+	}
+}
+
+// fixFlowLintSource applies safe fixes until the local lint stage has nothing
+// left to fix. It returns the fixed source and one record per applied fix.
+func fixFlowLintSource(file, flowLiteral, configPath string) (string, []map[string]any, error) {
+	rootPath := flowLintRootPath(file)
+	source := flowLiteral
+	fixes := []map[string]any{}
+	for pass := 1; pass <= maxFlowLintFixPasses; pass++ {
+		local, err := runFlowLintLocalStage(file, source, configPath)
+		if err != nil {
+			return "", nil, err
+		}
+		diagnostics := local.diagnostics
+		if local.scanned {
+			diagnostics = append(diagnostics, local.policy.apply(local.policy.unusedSuppressionDiagnostics(false))...)
+		}
+
+		var edits []flowLintEdit
+		var records []map[string]any
+		for _, diag := range diagnostics {
+			edit, ok := planFlowLintFix(rootPath, local, diag)
+			if !ok || flowLintEditOverlaps(edits, edit) {
+				continue
+			}
+			line, column := sourceLineColumn(source, edit.Start)
+			edits = append(edits, edit)
+			records = append(records, map[string]any{
+				"code":        diag["code"],
+				"message":     diag["message"],
+				"description": edit.Description,
+				"line":        line,
+				"column":      column,
+				"pass":        pass,
+			})
+		}
+		if len(edits) == 0 {
+			break
+		}
+		source = applyFlowLintEdits(source, edits)
+		fixes = append(fixes, records...)
+	}
+	return source, fixes, nil
+}
+
+func flowLintEditOverlaps(edits []flowLintEdit, edit flowLintEdit) bool {
+	for _, existing := range edits {
+		if edit.Start < existing.End && existing.Start < edit.End {
+			return true
+		}
+		// Two insertions at the same point would apply in an arbitrary order.
+		if edit.Start == existing.Start && (edit.Start == edit.End || existing.Start == existing.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func applyFlowLintEdits(src string, edits []flowLintEdit) string {
+	sorted := append([]flowLintEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start > sorted[j].Start })
+	for _, edit := range sorted {
+		src = src[:edit.Start] + edit.Text + src[edit.End:]
+	}
+	return src
+}
+
+// fixFlowLintDelimiters rewrites the whole file with the same repair engine
+// as flows paren-repair.
+func fixFlowLintDelimiters(src string, _ flowLintDiagnostic) (flowLintEdit, bool) {
+	repaired, _, _, err := repairClojureDelimiters(src, false)
+	if err != nil || repaired == src {
+		return flowLintEdit{}, false
+	}
+	return flowLintEdit{Start: 0, End: len(src), Text: repaired, Description: "Repaired unbalanced delimiters."}, true
+}
+
+// fixFlowLintThreadingForm expands ->> and as-> into the forms the macros
+// produce: nested calls for ->> and let bindings for as->. Other unsupported
+// forms need judgment and are left alone.
+func fixFlowLintThreadingForm(src string, diag flowLintDiagnostic) (flowLintEdit, bool) {
+	symbol, _ := diag["form"].(string)
+	offset, ok := diag["byteOffset"].(int)
+	if !ok || (symbol != "->>" && symbol != "as->") {
+		return flowLintEdit{}, false
+	}
+	listStart, listEnd, elements, ok := flowLintCallAt(src, offset)
+	if !ok || clojureFormToken(src, elements[0]) != symbol {
+		return flowLintEdit{}, false
+	}
+	if symbol == "->>" {
+		if len(elements) < 2 {
+			return flowLintEdit{}, false
+		}
+		acc := flowLintFormText(src, elements[1])
+		for _, step := range elements[2:] {
+			text := flowLintFormText(src, step)
+			switch {
+			case strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") && len(text) > 2:
+				acc = text[:len(text)-1] + " " + acc + ")"
+			case flowLintPlainToken(text):
+				acc = "(" + text + " " + acc + ")"
+			default:
+				return flowLintEdit{}, false
+			}
+		}
+		return flowLintEdit{Start: listStart, End: listEnd, Text: acc, Description: "Expanded ->> into nested calls."}, true
+	}
+	if len(elements) < 3 {
+		return flowLintEdit{}, false
+	}
+	name := flowLintFormText(src, elements[2])
+	if !flowLintPlainToken(name) || strings.HasPrefix(name, ":") {
+		return flowLintEdit{}, false
+	}
+	bindings := []string{name, flowLintFormText(src, elements[1])}
+	for _, step := range elements[3:] {
+		bindings = append(bindings, name, flowLintFormText(src, step))
+	}
+	text := "(let [" + strings.Join(bindings, " ") + "] " + name + ")"
+	return flowLintEdit{Start: listStart, End: listEnd, Text: text, Description: "Rewrote as-> with explicit let bindings."}, true
+}
+
+// fixFlowLintFunctionStepInlineConfig wraps the legacy inline keyword/value
+// arguments of (flow/step :function :id :code ... :input ...) in the single
+// config map the step requires.
+func fixFlowLintFunctionStepInlineConfig(src string, diag flowLintDiagnostic) (flowLintEdit, bool) {
+	offset, ok := diag["byteOffset"].(int)
+	if !ok || offset >= len(src) || src[offset] != '(' {
+		return flowLintEdit{}, false
+	}
+	elements, _, err := parseClojureListElements(src, offset)
+	if err != nil || len(elements) < 5 || (len(elements)-3)%2 != 0 {
+		return flowLintEdit{}, false
+	}
+	for i := 3; i < len(elements); i += 2 {
+		if !strings.HasPrefix(clojureFormToken(src, elements[i]), ":") {
+			return flowLintEdit{}, false
+		}
+	}
+	start := elements[3].Start
+	end := elements[len(elements)-1].End
+	return flowLintEdit{
+		Start:       start,
+		End:         end,
+		Text:        "{" + src[start:end] + "}",
+		Description: "Moved inline function step fields into a config map.",
+	}, true
+}
+
+// fixFlowLintUnusedSuppression drops a stale rule from an inline
+// #_{:breyta/lint-ignore [...]} marker, or the whole marker when it was the
+// only rule.
+func fixFlowLintUnusedSuppression(src string, diag flowLintDiagnostic) (flowLintEdit, bool) {
+	offset, ok := diag["byteOffset"].(int)
+	rule, _ := diag["rule"].(string)
+	if !ok || rule == "" || !strings.HasPrefix(src[offset:], "#_") {
+		return flowLintEdit{}, false
+	}
+	mapStart := skipClojureWhitespaceCommaAndComments(src, offset+2)
+	if mapStart >= len(src) || src[mapStart] != '{' {
+		return flowLintEdit{}, false
+	}
+	mapEnd, err := readClojureFormEnd(src, mapStart)
+	if err != nil {
+		return flowLintEdit{}, false
+	}
+	entries, _, err := parseClojureMapEntries(src, mapStart)
+	if err != nil || len(entries) != 1 || strings.TrimSpace(entries[0].KeyToken) != flowLintIgnoreKey {
+		return flowLintEdit{}, false
+	}
+	value := entries[0]
+	if strings.HasPrefix(strings.TrimSpace(src[value.ValueStart:value.ValueEnd]), "[") {
+		items, _, err := parseClojureVectorElements(src, value.ValueStart)
+		if err != nil {
+			return flowLintEdit{}, false
+		}
+		if len(items) > 1 {
+			for i, item := range items {
+				if normalizeFlowLintRule(clojureFormToken(src, item)) != normalizeFlowLintRule(rule) {
+					continue
+				}
+				start, end := item.Start, item.End
+				if i > 0 {
+					start = items[i-1].End
+				} else {
+					end = items[1].Start
+				}
+				return flowLintEdit{Start: start, End: end, Description: "Removed unused suppression rule " + rule + "."}, true
+			}
+			return flowLintEdit{}, false
+		}
+	}
+	start, end := offset, mapEnd
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	lineStart := strings.LastIndexByte(src[:start], '\n') + 1
+	if strings.TrimSpace(src[lineStart:start]) == "" && (end == len(src) || src[end] == '\n') {
+		start = lineStart
+		if end < len(src) {
+			end++
+		}
+	}
+	return flowLintEdit{Start: start, End: end, Description: "Removed unused suppression " + flowLintIgnoreKey + " " + rule + "."}, true
+}
+
+// flowLintCallAt returns the bounds and elements of the list whose head
+// symbol starts at offset.
+func flowLintCallAt(src string, offset int) (int, int, []clojureFormSpan, bool) {
+	listStart := offset - 1
+	for listStart >= 0 && isClojureWhitespaceOrComma(src[listStart]) {
+		listStart--
+	}
+	if listStart < 0 || src[listStart] != '(' {
+		return 0, 0, nil, false
+	}
+	elements, listEnd, err := parseClojureListElements(src, listStart)
+	if err != nil || len(elements) == 0 || elements[0].Start != offset {
+		return 0, 0, nil, false
+	}
+	// Comments or discarded forms between elements would be lost by a rewrite.
+	prev := listStart + 1
+	for _, element := range elements {
+		if strings.Trim(src[prev:element.Start], " \t\r\n,") != "" {
+			return 0, 0, nil, false
+		}
+		prev = element.End
+	}
+	if strings.Trim(src[prev:listEnd-1], " \t\r\n,") != "" {
+		return 0, 0, nil, false
+	}
+	return listStart, listEnd, elements, true
+}
+
+func flowLintFormText(src string, span clojureFormSpan) string {
+	return strings.TrimSpace(src[span.Start:span.End])
+}
+
+// flowLintPlainToken reports whether text is a bare symbol or keyword, which a
+// threading macro wraps as (token x).
+func flowLintPlainToken(text string) bool {
+	if text == "" {
+		return false
+	}
+	switch text[0] {
+	case '(', '[', '{', '"', '#', '\'', '`', '~', '@', '^', '\\':
+		return false
+	}
+	return !strings.ContainsAny(text, " \t\r\n,()[]{}\";")
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lintFixFlow = `{:slug :lint-fix
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules []
+ :flow '(let [input (flow/input)
+              rows (->> input :rows (take 10))
+              total (as-> rows r (count r) (inc r))]
+          #_{:breyta/lint-ignore [:missing-interfaces]}
+          (flow/step :function :summarize :input {:rows rows} :code '(fn [input] input)))}
+`
+
+func TestFlowsLintFixRewritesSafeDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	body, err := runFlowLintLocalOnlyInDir(t, dir, "flow.clj", lintFixFlow, "--fix")
+	if err != nil {
+		t.Fatalf("expected fixed flow to lint clean, got %v body=%#v", err, body)
+	}
+	rejectFlowLintDiagnosticCodes(t, body, "unsupported_visual_flow_form", "function_step_arity_invalid", "unused_lint_suppression")
+
+	written, readErr := os.ReadFile(filepath.Join(dir, "flow.clj"))
+	if readErr != nil {
+		t.Fatalf("read fixed flow: %v", readErr)
+	}
+	fixed := string(written)
+	for _, want := range []string{
+		"rows (take 10 (:rows input))",
+		"total (let [r rows r (count r) r (inc r)] r)",
+		"(flow/step :function :summarize {:input {:rows rows} :code '(fn [input] input)})",
+	} {
+		if !strings.Contains(fixed, want) {
+			t.Fatalf("fixed source missing %q:\n%s", want, fixed)
+		}
+	}
+	if strings.Contains(fixed, "lint-ignore") {
+		t.Fatalf("unused suppression should be removed:\n%s", fixed)
+	}
+
+	meta, _ := body["meta"].(map[string]any)
+	fix, _ := meta["fix"].(map[string]any)
+	if fix["written"] != true || fix["dryRun"] != false {
+		t.Fatalf("unexpected fix meta %#v", fix)
+	}
+	fixes, _ := fix["fixes"].([]any)
+	if len(fixes) != 4 {
+		t.Fatalf("expected four fixes, got %#v", fixes)
+	}
+	if diff, _ := fix["diff"].(string); !strings.Contains(diff, "+              rows (take 10 (:rows input))") {
+		t.Fatalf("expected unified diff, got %q", diff)
+	}
+}
+
+func TestFlowsLintFixDryRunLeavesFileUntouched(t *testing.T) {
+	dir := t.TempDir()
+	body, err := runFlowLintLocalOnlyInDir(t, dir, "flow.clj", lintFixFlow, "--fix", "--dry-run")
+	if err != nil {
+		t.Fatalf("dry run should report the post-fix lint, got %v", err)
+	}
+	written, _ := os.ReadFile(filepath.Join(dir, "flow.clj"))
+	if string(written) != lintFixFlow {
+		t.Fatalf("dry run must not write the file")
+	}
+	fix, _ := body["meta"].(map[string]any)["fix"].(map[string]any)
+	if fix["written"] != false || fix["dryRun"] != true || fix["diff"] == "" {
+		t.Fatalf("unexpected dry-run fix meta %#v", fix)
+	}
+}
+
+func TestFlowsLintMarksFixableDiagnostics(t *testing.T) {
+	literal := strings.Replace(lintFixFlow, "(as-> rows r (count r) (inc r))", "(some-> rows count)", 1)
+	body, _, _ := runFlowLintLocalOnlyForLiteral(t, literal)
+	data, _ := body["data"].(map[string]any)
+	fixable := map[string]bool{}
+	for _, itemAny := range data["diagnostics"].([]any) {
+		item := itemAny.(map[string]any)
+		form, _ := item["form"].(string)
+		fixable[form+item["code"].(string)] = item["fixable"] == true
+	}
+	if !fixable["->>unsupported_visual_flow_form"] || fixable["some->unsupported_visual_flow_form"] {
+		t.Fatalf("unexpected fixable flags %#v", fixable)
+	}
+	if !fixable["function_step_arity_invalid"] {
+		t.Fatalf("inline function step config should be fixable: %#v", fixable)
+	}
+}
+
+func TestFlowsLintFixSkipsIncludedFiles(t *testing.T) {
+	_, _, dir := runFlowLintOutput(t, "json")
+	flowFile := filepath.Join(dir, "flow.clj")
+	body, err := runFlowLintLocalOnlyInDir(t, dir, "flow.clj", lintOutputRootFlow, "--fix")
+	if err == nil {
+		t.Fatalf("include diagnostics should remain, body=%#v", body)
+	}
+	requireFlowLintDiagnosticCodes(t, body, "unsupported_visual_flow_form")
+	included, _ := os.ReadFile(filepath.Join(dir, "body.clj"))
+	if string(included) != lintOutputIncludedBody {
+		t.Fatalf("--fix must not edit included files:\n%s", included)
+	}
+	root, _ := os.ReadFile(flowFile)
+	if string(root) != lintOutputRootFlow {
+		t.Fatalf("root file should be unchanged:\n%s", root)
+	}
+}
+
+func TestFlowsLintFixRepairsDelimiters(t *testing.T) {
+	dir := t.TempDir()
+	literal := strings.TrimSuffix(strings.Replace(lintFixFlow, "rows (->> input :rows (take 10))\n", "", 1), "}\n")
+	literal = strings.Replace(literal, "total (as-> rows r (count r) (inc r))]", "total 1]", 1)
+	body, _ := runFlowLintLocalOnlyInDir(t, dir, "flow.clj", literal, "--fix")
+	rejectFlowLintDiagnosticCodes(t, body, "clojure_delimiters_invalid")
+	fix, _ := body["meta"].(map[string]any)["fix"].(map[string]any)
+	first := fix["fixes"].([]any)[0].(map[string]any)
+	if first["code"] != "clojure_delimiters_invalid" {
+		t.Fatalf("expected delimiter repair first, got %#v", fix["fixes"])
+	}
+}
+
+func TestFlowsLintDryRunRequiresFix(t *testing.T) {
+	dir := t.TempDir()
+	flowFile := filepath.Join(dir, "flow.clj")
+	if err := os.WriteFile(flowFile, []byte(lintFixFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-acme"})
+	cmd.SilenceUsage = true
+	cmd.SetOut(&strings.Builder{})
+	cmd.SetErr(&strings.Builder{})
+	cmd.SetArgs([]string{"--file", flowFile, "--local-only", "--dry-run"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--dry-run requires --fix") {
+		t.Fatalf("expected --dry-run to require --fix, got %v", err)
+	}
+}
+
+func TestFlowsLintFixKeepsServerOnlySuppressions(t *testing.T) {
+	dir := t.TempDir()
+	literal := strings.Replace(lintFixFlow, "[:missing-interfaces]", "[:missing-interfaces :draft-binding-missing]", 1)
+	body, err := runFlowLintLocalOnlyInDir(t, dir, "flow.clj", literal, "--fix")
+	if err != nil {
+		t.Fatalf("expected fixed flow to lint clean, got %v body=%#v", err, body)
+	}
+	written, readErr := os.ReadFile(filepath.Join(dir, "flow.clj"))
+	if readErr != nil {
+		t.Fatalf("read fixed flow: %v", readErr)
+	}
+	if !strings.Contains(string(written), "#_{:breyta/lint-ignore [:draft-binding-missing]}") {
+		t.Fatalf("--fix should drop only the stale local rule and keep the server-only one:\n%s", written)
+	}
+}
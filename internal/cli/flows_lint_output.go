@@ -0,0 +1,450 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/breyta/breyta-cli/internal/buildinfo"
+)
+
+var flowLintOutputFormats = []string{"json", "sarif", "junit", "github", "checkstyle"}
+
+func validFlowLintOutputFormat(format string) bool {
+	for _, candidate := range flowLintOutputFormats {
+		if candidate == format {
+			return true
+		}
+	}
+	return false
+}
+
+// flowLintLocator translates expanded-source byte offsets into file, line and
+// column, following the include source map back into included files.
+type flowLintLocator struct {
+	rootFile   string
+	rootAbs    string
+	rootSource string
+	expanded   string
+	sourceMap  flowSourceMap
+	topKeys    map[string]int
+	files      map[string]string
+}
+
+func newFlowLintLocator(rootFile, rootSource, expanded string, sourceMap flowSourceMap) *flowLintLocator {
+	rootAbs, err := filepath.Abs(rootFile)
+	if err != nil {
+		rootAbs = rootFile
+	}
+	if len(sourceMap.Segments) == 0 {
+		sourceMap = flowSourceMap{Segments: []flowSourceSegment{{Start: 0, End: len(rootSource), Path: rootAbs}}}
+		expanded = rootSource
+	}
+	locator := &flowLintLocator{
+		rootFile:   rootFile,
+		rootAbs:    rootAbs,
+		rootSource: rootSource,
+		expanded:   expanded,
+		sourceMap:  sourceMap,
+		topKeys:    map[string]int{},
+		files:      map[string]string{rootAbs: rootSource},
+	}
+	if entries, err := extractTopLevelMapEntries(expanded); err == nil {
+		for _, entry := range entries {
+			key := strings.TrimSpace(entry.KeyToken)
+			if _, seen := locator.topKeys[key]; !seen {
+				locator.topKeys[key] = entry.KeyStart
+			}
+		}
+	}
+	return locator
+}
+
+func (l *flowLintLocator) displayPath(abs string) string {
+	if abs == l.rootAbs {
+		return l.rootFile
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return abs
+}
+
+func (l *flowLintLocator) fileSource(abs string) string {
+	if src, ok := l.files[abs]; ok {
+		return src
+	}
+	b, err := readExplicitFile(abs)
+	if err != nil {
+		l.files[abs] = ""
+		return ""
+	}
+	l.files[abs] = string(b)
+	return string(b)
+}
+
+// locate returns the display path and 1-based line and column for a
+// diagnostic. Diagnostics without a byte offset fall back to their top-level
+// path key, then to the start of the root file.
+func (l *flowLintLocator) locate(diag flowLintDiagnostic) (string, int, int) {
+	path, fileOffset := l.resolve(diag)
+	line, column := sourceLineColumn(l.fileSource(path), fileOffset)
+	return l.displayPath(path), line, column
+}
+
+// resolve returns the absolute file and byte offset a diagnostic points at,
+// using the same fallbacks as locate.
+func (l *flowLintLocator) resolve(diag flowLintDiagnostic) (string, int) {
+	offset, ok := diag["byteOffset"].(int)
+	if !ok {
+		offset, ok = l.topKeys[flowLintDiagnosticPathHead(diag)]
+	}
+	if !ok {
+		return l.rootAbs, 0
+	}
+	path, fileOffset, found := l.sourceMap.Locate(offset)
+	if !found {
+		return l.rootAbs, 0
+	}
+	return path, fileOffset
+}
+
+func (l *flowLintLocator) annotate(diagnostics []flowLintDiagnostic) {
+	for _, diag := range diagnostics {
+		file, line, column := l.locate(diag)
+		diag["file"] = file
+		diag["line"] = line
+		diag["column"] = column
+	}
+}
+
+// sourceLineColumn converts a byte offset into a 1-based line and a 1-based
+// column counted in characters.
+func sourceLineColumn(src string, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := 1 + strings.Count(src[:offset], "\n")
+	lineStart := strings.LastIndex(src[:offset], "\n") + 1
+	return line, 1 + utf8.RuneCountInString(src[lineStart:offset])
+}
+
+func flowLintDiagnosticString(diag flowLintDiagnostic, key string) string {
+	value, _ := diag[key].(string)
+	return value
+}
+
+func flowLintDiagnosticInt(diag flowLintDiagnostic, key string) int {
+	switch value := diag[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return 0
+}
+
+func flowLintDiagnosticPathString(diag flowLintDiagnostic) string {
+	switch path := diag["path"].(type) {
+	case []string:
+		return strings.Join(path, " ")
+	case []any:
+		parts := make([]string, 0, len(path))
+		for _, part := range path {
+			parts = append(parts, fmt.Sprint(part))
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+// flowLintDiagnosticText is the human-readable message with the hint and
+// flow path appended, used by formats that have a single message field.
+func flowLintDiagnosticText(diag flowLintDiagnostic) string {
+	text := flowLintDiagnosticString(diag, "message")
+	if path := flowLintDiagnosticPathString(diag); path != "" {
+		text += " [" + path + "]"
+	}
+	if hint := flowLintDiagnosticString(diag, "hint"); hint != "" {
+		text += " Hint: " + hint
+	}
+	return text
+}
+
+func writeFlowLintFormatted(w io.Writer, format, file string, diagnostics []flowLintDiagnostic) error {
+	switch format {
+	case "sarif":
+		return writeFlowLintSARIF(w, diagnostics)
+	case "junit":
+		return writeFlowLintJUnit(w, file, diagnostics)
+	case "github":
+		return writeFlowLintGitHub(w, diagnostics)
+	case "checkstyle":
+		return writeFlowLintCheckstyle(w, diagnostics)
+	}
+	return fmt.Errorf("unsupported lint output %q (use %s)", format, strings.Join(flowLintOutputFormats, ", "))
+}
+
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func writeFlowLintSARIF(w io.Writer, diagnostics []flowLintDiagnostic) error {
+	ruleIndex := map[string]int{}
+	rules := []map[string]any{}
+	results := make([]map[string]any, 0, len(diagnostics))
+	for _, diag := range diagnostics {
+		code := flowLintDiagnosticString(diag, "code")
+		if _, ok := ruleIndex[code]; !ok {
+			ruleIndex[code] = len(rules)
+			rule := map[string]any{"id": code, "name": code}
+			if hint := flowLintDiagnosticString(diag, "hint"); hint != "" {
+				rule["help"] = map[string]any{"text": hint}
+			}
+			rules = append(rules, rule)
+		}
+		properties := map[string]any{"stage": flowLintDiagnosticString(diag, "stage")}
+		if path := flowLintDiagnosticPathString(diag); path != "" {
+			properties["flowPath"] = path
+		}
+		if hint := flowLintDiagnosticString(diag, "hint"); hint != "" {
+			properties["hint"] = hint
+		}
+		results = append(results, map[string]any{
+			"ruleId":    code,
+			"ruleIndex": ruleIndex[code],
+			"level":     sarifLevel(flowLintDiagnosticString(diag, "severity")),
+			"message":   map[string]any{"text": flowLintDiagnosticText(diag)},
+			"locations": []map[string]any{{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]any{"uri": filepath.ToSlash(flowLintDiagnosticString(diag, "file"))},
+					"region": map[string]any{
+						"startLine":   flowLintDiagnosticInt(diag, "line"),
+						"startColumn": flowLintDiagnosticInt(diag, "column"),
+					},
+				},
+			}},
+			"properties": properties,
+		})
+	}
+	report := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "breyta flows lint",
+				"informationUri": "https://breyta.ai",
+				"version":        buildinfo.DisplayVersion(),
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr,omitempty"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+func writeJUnitReport(w io.Writer, report junitTestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeFlowLintJUnit groups diagnostics into one suite per file. Errors are
+// failures; warnings and info diagnostics are passing cases with output so CI
+// dashboards still show them.
+func writeFlowLintJUnit(w io.Writer, file string, diagnostics []flowLintDiagnostic) error {
+	byFile := map[string][]flowLintDiagnostic{}
+	for _, diag := range diagnostics {
+		name := flowLintDiagnosticString(diag, "file")
+		byFile[name] = append(byFile[name], diag)
+	}
+	if len(byFile) == 0 {
+		byFile[file] = nil
+	}
+	files := make([]string, 0, len(byFile))
+	for name := range byFile {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	report := junitTestSuites{Name: "breyta flows lint"}
+	for _, name := range files {
+		suite := junitTestSuite{Name: name}
+		items := byFile[name]
+		if len(items) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{Name: "lint", ClassName: name})
+		}
+		for _, diag := range items {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s:%d:%d %s", name, flowLintDiagnosticInt(diag, "line"), flowLintDiagnosticInt(diag, "column"), flowLintDiagnosticString(diag, "code")),
+				ClassName: name,
+			}
+			if strings.EqualFold(flowLintDiagnosticString(diag, "severity"), "error") {
+				tc.Failure = &junitMessage{
+					Message: flowLintDiagnosticString(diag, "message"),
+					Type:    flowLintDiagnosticString(diag, "code"),
+					Body:    flowLintDiagnosticText(diag),
+				}
+				suite.Failures++
+			} else {
+				tc.SystemOut = strings.ToUpper(flowLintDiagnosticString(diag, "severity")) + ": " + flowLintDiagnosticText(diag)
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+	return writeJUnitReport(w, report)
+}
+
+func githubAnnotationEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func githubAnnotationEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// writeFlowLintGitHub emits GitHub Actions workflow commands so findings show
+// up as inline pull request annotations.
+func writeFlowLintGitHub(w io.Writer, diagnostics []flowLintDiagnostic) error {
+	for _, diag := range diagnostics {
+		level := "notice"
+		switch strings.ToLower(flowLintDiagnosticString(diag, "severity")) {
+		case "error":
+			level = "error"
+		case "warning":
+			level = "warning"
+		}
+		if _, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+			level,
+			githubAnnotationEscapeProperty(filepath.ToSlash(flowLintDiagnosticString(diag, "file"))),
+			flowLintDiagnosticInt(diag, "line"),
+			flowLintDiagnosticInt(diag, "column"),
+			githubAnnotationEscapeProperty(flowLintDiagnosticString(diag, "code")),
+			githubAnnotationEscapeData(flowLintDiagnosticText(diag)),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeFlowLintCheckstyle(w io.Writer, diagnostics []flowLintDiagnostic) error {
+	byFile := map[string]*checkstyleFile{}
+	var order []string
+	for _, diag := range diagnostics {
+		name := flowLintDiagnosticString(diag, "file")
+		entry, ok := byFile[name]
+		if !ok {
+			entry = &checkstyleFile{Name: name}
+			byFile[name] = entry
+			order = append(order, name)
+		}
+		severity := strings.ToLower(flowLintDiagnosticString(diag, "severity"))
+		if severity != "error" && severity != "warning" {
+			severity = "info"
+		}
+		entry.Errors = append(entry.Errors, checkstyleError{
+			Line:     flowLintDiagnosticInt(diag, "line"),
+			Column:   flowLintDiagnosticInt(diag, "column"),
+			Severity: severity,
+			Message:  flowLintDiagnosticText(diag),
+			Source:   "breyta." + flowLintDiagnosticString(diag, "code"),
+		})
+	}
+	report := checkstyleReport{Version: "4.3"}
+	for _, name := range order {
+		report.Files = append(report.Files, *byFile[name])
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
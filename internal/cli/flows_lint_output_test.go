@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lintOutputRootFlow = `{:slug :lint-output
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules []
+ :flow #flow/include "body.clj"}
+`
+
+const lintOutputIncludedBody = `'(let [input (flow/input)]
+   (->> input :items))
+`
+
+func runFlowLintOutput(t *testing.T, output string) (string, error, string) {
+	t.Helper()
+	dir := t.TempDir()
+	flowFile := filepath.Join(dir, "flow.clj")
+	if err := os.WriteFile(flowFile, []byte(lintOutputRootFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "body.clj"), []byte(lintOutputIncludedBody), 0o644); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-acme"})
+	cmd.SilenceUsage = true
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	cmd.SetArgs([]string{"--file", flowFile, "--local-only", "--output", output})
+	err := cmd.Execute()
+	return out.String(), err, dir
+}
+
+func TestFlowsLintGitHubOutputResolvesIncludedFileLocation(t *testing.T) {
+	out, err, _ := runFlowLintOutput(t, "github")
+	if err == nil {
+		t.Fatalf("expected lint errors to fail the command")
+	}
+	var line string
+	for _, candidate := range strings.Split(out, "\n") {
+		if strings.Contains(candidate, "title=unsupported_visual_flow_form") {
+			line = candidate
+		}
+	}
+	if line == "" {
+		t.Fatalf("missing unsupported form annotation:\n%s", out)
+	}
+	if !strings.HasPrefix(line, "::error file=") || !strings.Contains(line, ",line=2,col=5,") {
+		t.Fatalf("unexpected annotation location: %s", line)
+	}
+	if !strings.Contains(line, "/body.clj,") {
+		t.Fatalf("annotation should point at the included file: %s", line)
+	}
+}
+
+func TestFlowsLintSARIFOutput(t *testing.T) {
+	out, _, _ := runFlowLintOutput(t, "sarif")
+	var report map[string]any
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode sarif: %v\n%s", err, out)
+	}
+	if report["version"] != "2.1.0" {
+		t.Fatalf("unexpected sarif version %#v", report["version"])
+	}
+	run := report["runs"].([]any)[0].(map[string]any)
+	results := run["results"].([]any)
+	found := false
+	for _, resultAny := range results {
+		result := resultAny.(map[string]any)
+		if result["ruleId"] != "unsupported_visual_flow_form" {
+			continue
+		}
+		found = true
+		if result["level"] != "error" {
+			t.Fatalf("expected error level, got %#v", result)
+		}
+		region := result["locations"].([]any)[0].(map[string]any)["physicalLocation"].(map[string]any)["region"].(map[string]any)
+		if region["startLine"].(float64) != 2 || region["startColumn"].(float64) != 5 {
+			t.Fatalf("unexpected region %#v", region)
+		}
+	}
+	if !found {
+		t.Fatalf("missing unsupported form result: %s", out)
+	}
+}
+
+func TestFlowsLintJUnitAndCheckstyleOutputAreValidXML(t *testing.T) {
+	out, _, _ := runFlowLintOutput(t, "junit")
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &suites); err != nil {
+		t.Fatalf("decode junit: %v\n%s", err, out)
+	}
+	if suites.Failures == 0 || suites.Tests == 0 {
+		t.Fatalf("expected failing junit cases, got %+v", suites)
+	}
+
+	out, _, _ = runFlowLintOutput(t, "checkstyle")
+	var report checkstyleReport
+	if err := xml.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode checkstyle: %v\n%s", err, out)
+	}
+	if len(report.Files) == 0 || len(report.Files[0].Errors) == 0 {
+		t.Fatalf("expected checkstyle errors, got %+v", report)
+	}
+}
+
+func TestFlowsLintJSONDiagnosticsIncludeLineAndColumn(t *testing.T) {
+	body, _, _ := runFlowLintLocalOnlyForLiteral(t, `{:slug :line-col
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :schedules []
+ :flow '(let [input (flow/input)] input)}
+`)
+	data, _ := body["data"].(map[string]any)
+	for _, itemAny := range data["diagnostics"].([]any) {
+		item := itemAny.(map[string]any)
+		if item["code"] == "missing_interfaces" {
+			if item["line"].(float64) != 1 || item["column"].(float64) != 1 {
+				t.Fatalf("expected file-start location for missing key, got %#v", item)
+			}
+			return
+		}
+	}
+	t.Fatalf("missing_interfaces diagnostic not found: %#v", data)
+}
+
+func TestSourceLineColumnCountsCharacters(t *testing.T) {
+	line, column := sourceLineColumn("ab\néx", 5)
+	if line != 2 || column != 2 {
+		t.Fatalf("got line=%d column=%d", line, column)
+	}
+}
@@ -0,0 +1,427 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const flowLintIgnoreKey = ":breyta/lint-ignore"
+
+// flowLintRuleGroups lets one rule name in config or inline suppressions cover
+// several diagnostic codes that share a fix.
+var flowLintRuleGroups = map[string][]string{
+	"unsupported_flow_form": {"unsupported_visual_flow_form", "prohibited_orchestration_transform"},
+}
+
+// flowLintLocalCodes lists every diagnostic code the local lint stage can
+// emit. Without a server stage, only suppressions of these codes can be known
+// to be unused. TestFlowLintLocalCodesCoverLocalDiagnostics checks the list
+// against the codes the lint source files build, in both directions.
+var flowLintLocalCodes = map[string]bool{
+	"authoring_shape_scan_incomplete":      true,
+	"clojure_delimiters_invalid":           true,
+	"clojure_reader_eval_disabled":         true,
+	"clojure_reader_invalid":               true,
+	"clojure_syntax_invalid":               true,
+	"deprecated_manual_trigger":            true,
+	"duplicate_interface_id":               true,
+	"duplicate_invocation_input_name":      true,
+	"flow_file_unreadable":                 true,
+	"flow_include_invalid":                 true,
+	"flow_step_arity_invalid":              true,
+	"flow_step_invalid_type":               true,
+	"flow_step_missing_config":             true,
+	"flow_step_missing_step_id":            true,
+	"flow_step_packaged_extra_argument":    true,
+	"function_code_string_invalid":         true,
+	"function_code_string_scan_incomplete": true,
+	"function_step_arity_invalid":          true,
+	"function_step_code_ref_conflict":      true,
+	"function_step_config_invalid":         true,
+	"function_step_input_shape_invalid":    true,
+	"function_step_missing_code_or_ref":    true,
+	"hardcoded_workspace_id":               true,
+	"interfaces_shape_scan_incomplete":     true,
+	"invalid_interface_category_shape":     true,
+	"invalid_interface_id":                 true,
+	"invalid_interface_invocation":         true,
+	"invalid_interface_shape":              true,
+	"invalid_interfaces_shape":             true,
+	"invalid_invocation_id":                true,
+	"invalid_invocation_input_name":        true,
+	"invalid_invocation_input_shape":       true,
+	"invalid_invocation_input_type":        true,
+	"invalid_invocation_inputs_shape":      true,
+	"invalid_invocation_shape":             true,
+	"invalid_invocations_shape":            true,
+	"invalid_required_field":               true,
+	"invocation_inputs_scan_incomplete":    true,
+	"invocations_shape_scan_incomplete":    true,
+	"large_inline_string":                  true,
+	"lint_config_invalid":                  true,
+	"missing_interface_id":                 true,
+	"missing_interface_invocation":         true,
+	"missing_interfaces":                   true,
+	"missing_invocation_input_name":        true,
+	"missing_invocations":                  true,
+	"missing_packaged_step_reference":      true,
+	"missing_required_field":               true,
+	"prohibited_orchestration_transform":   true,
+	"sandbox_unbounded_range":              true,
+	"secret_literal_detected":              true,
+	"step_config_invalid_type":             true,
+	"step_config_missing_required":         true,
+	"step_config_unknown_key":              true,
+	"step_reference_scan_incomplete":       true,
+	"step_schemas_unreadable":              true,
+	"template_variable_unprovided":         true,
+	"too_many_interfaces":                  true,
+	"unknown_interface_invocation":         true,
+	"unreferenced_packaged_step":           true,
+	"unsupported_visual_flow_form":         true,
+	"unused_lint_suppression":              true,
+}
+
+var flowLintSeverities = map[string]bool{"off": true, "info": true, "warning": true, "error": true}
+
+// normalizeFlowLintRule accepts diagnostic codes as written in JSON config
+// (missing_interfaces), kebab-case (missing-interfaces), or keywords
+// (:missing-interfaces).
+func normalizeFlowLintRule(raw string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(strings.TrimSpace(raw), ":"), "-", "_")
+}
+
+func flowLintRuleMatches(rule, code string) bool {
+	if rule == "all" || rule == code {
+		return true
+	}
+	for _, member := range flowLintRuleGroups[rule] {
+		if member == code {
+			return true
+		}
+	}
+	return false
+}
+
+// flowLintSuppression is one inline #_{:breyta/lint-ignore [...]} marker. It
+// covers the form that follows it; before a top-level key it covers that
+// key's value and every diagnostic whose path starts with the key, and before
+// the top-level map it covers the whole file.
+type flowLintSuppression struct {
+	rules    []string
+	offset   int
+	start    int
+	end      int
+	topKey   string
+	fileWide bool
+	used     map[string]bool
+}
+
+func (s *flowLintSuppression) covers(diag flowLintDiagnostic) bool {
+	if s.fileWide {
+		return true
+	}
+	if offset, ok := diag["byteOffset"].(int); ok {
+		return offset >= s.start && offset < s.end
+	}
+	if s.topKey == "" {
+		return false
+	}
+	return flowLintDiagnosticPathHead(diag) == s.topKey
+}
+
+// flowLintDiagnosticPathHead returns the first path element of a local
+// ([]string) or server ([]any) diagnostic.
+func flowLintDiagnosticPathHead(diag flowLintDiagnostic) string {
+	switch path := diag["path"].(type) {
+	case []string:
+		if len(path) > 0 {
+			return path[0]
+		}
+	case []any:
+		if len(path) > 0 {
+			head, _ := path[0].(string)
+			return head
+		}
+	}
+	return ""
+}
+
+// flowLintPolicy applies the project lint config and inline suppressions to a
+// lint run and records what it suppressed for meta.suppressed.
+type flowLintPolicy struct {
+	config       projectConfig
+	file         string
+	suppressions []*flowLintSuppression
+	suppressed   []map[string]any
+}
+
+func newFlowLintPolicy(file, configPath, expandedLiteral string) (*flowLintPolicy, error) {
+	cfg, err := loadProjectConfig(file, configPath)
+	if err != nil {
+		return nil, err
+	}
+	for rule, ruleCfg := range cfg.Lint.Rules {
+		severity := strings.ToLower(strings.TrimSpace(ruleCfg.Severity))
+		if !flowLintSeverities[severity] {
+			return nil, fmt.Errorf("project config %s: lint rule %q has invalid severity %q (use off, info, warning, or error)", cfg.path, rule, ruleCfg.Severity)
+		}
+	}
+	return &flowLintPolicy{
+		config:       cfg,
+		file:         file,
+		suppressions: scanFlowLintSuppressions(expandedLiteral),
+	}, nil
+}
+
+// ruleSeverity returns the configured severity for code when a rule applies
+// to the linted file.
+func (p *flowLintPolicy) ruleSeverity(code string) (string, string, bool) {
+	rules := make([]string, 0, len(p.config.Lint.Rules))
+	for rule := range p.config.Lint.Rules {
+		rules = append(rules, rule)
+	}
+	// Exact codes win over groups so a group can be relaxed with one exception.
+	sort.Slice(rules, func(i, j int) bool {
+		exactI := normalizeFlowLintRule(rules[i]) == code
+		exactJ := normalizeFlowLintRule(rules[j]) == code
+		if exactI != exactJ {
+			return exactI
+		}
+		return rules[i] < rules[j]
+	})
+	for _, rule := range rules {
+		if !flowLintRuleMatches(normalizeFlowLintRule(rule), code) {
+			continue
+		}
+		ruleCfg := p.config.Lint.Rules[rule]
+		if len(ruleCfg.Paths) > 0 && !projectPathMatches(p.config.dir(), p.file, ruleCfg.Paths) {
+			continue
+		}
+		if len(ruleCfg.Exclude) > 0 && projectPathMatches(p.config.dir(), p.file, ruleCfg.Exclude) {
+			continue
+		}
+		return rule, strings.ToLower(strings.TrimSpace(ruleCfg.Severity)), true
+	}
+	return "", "", false
+}
+
+func (p *flowLintPolicy) apply(diagnostics []flowLintDiagnostic) []flowLintDiagnostic {
+	if p == nil {
+		return diagnostics
+	}
+	out := make([]flowLintDiagnostic, 0, len(diagnostics))
+	for _, diag := range diagnostics {
+		code, _ := diag["code"].(string)
+		message, _ := diag["message"].(string)
+		if rule, severity, ok := p.ruleSeverity(code); ok {
+			if severity == "off" {
+				p.suppressed = append(p.suppressed, map[string]any{
+					"code":    code,
+					"message": message,
+					"source":  "config",
+					"rule":    rule,
+					"config":  p.config.path,
+				})
+				continue
+			}
+			if current, _ := diag["severity"].(string); current != severity {
+				diag["defaultSeverity"] = current
+				diag["severity"] = severity
+			}
+		}
+		if suppression, rule := p.inlineSuppression(code, diag); suppression != nil {
+			p.suppressed = append(p.suppressed, map[string]any{
+				"code":       code,
+				"message":    message,
+				"source":     "inline",
+				"rule":       ":" + strings.ReplaceAll(rule, "_", "-"),
+				"byteOffset": suppression.offset,
+			})
+			continue
+		}
+		out = append(out, diag)
+	}
+	return out
+}
+
+func (p *flowLintPolicy) inlineSuppression(code string, diag flowLintDiagnostic) (*flowLintSuppression, string) {
+	for _, suppression := range p.suppressions {
+		for _, rule := range suppression.rules {
+			if flowLintRuleMatches(rule, code) && suppression.covers(diag) {
+				suppression.used[rule] = true
+				return suppression, rule
+			}
+		}
+	}
+	return nil, ""
+}
+
+// flowLintRuleLocal reports whether the local stage alone can produce every
+// code a rule covers. "all" may cover server diagnostics, so it never is.
+func flowLintRuleLocal(rule string) bool {
+	if members, ok := flowLintRuleGroups[rule]; ok {
+		for _, member := range members {
+			if !flowLintLocalCodes[member] {
+				return false
+			}
+		}
+		return true
+	}
+	return flowLintLocalCodes[rule]
+}
+
+// unusedSuppressionDiagnostics flags inline suppressions that matched nothing
+// so stale markers do not silently hide future regressions. Unless the server
+// stage ran, rules for server-only codes are left alone: their diagnostics
+// were never produced, so the suppression may still be needed.
+func (p *flowLintPolicy) unusedSuppressionDiagnostics(serverRan bool) []flowLintDiagnostic {
+	if p == nil {
+		return nil
+	}
+	var diagnostics []flowLintDiagnostic
+	for _, suppression := range p.suppressions {
+		for _, rule := range suppression.rules {
+			if suppression.used[rule] || (!serverRan && !flowLintRuleLocal(rule)) {
+				continue
+			}
+			keyword := ":" + strings.ReplaceAll(rule, "_", "-")
+			diag := lintDiagnostic(
+				"warning",
+				"unused_lint_suppression",
+				nil,
+				fmt.Sprintf("Inline suppression %s %s did not match any diagnostic.", flowLintIgnoreKey, keyword),
+				"Remove the stale suppression or move it directly before the form that triggers the diagnostic.",
+				"local",
+			)
+			diag["rule"] = keyword
+			diag["byteOffset"] = suppression.offset
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	return diagnostics
+}
+
+func (p *flowLintPolicy) annotateMeta(meta map[string]any) {
+	if p == nil {
+		return
+	}
+	if p.config.path != "" {
+		meta["lintConfig"] = p.config.path
+	}
+	if len(p.suppressed) > 0 {
+		meta["suppressed"] = p.suppressed
+	}
+}
+
+// scanFlowLintSuppressions finds #_{:breyta/lint-ignore [...]} markers outside
+// strings and comments. Malformed markers are ignored; the discard reader
+// already keeps them out of the evaluated flow.
+func scanFlowLintSuppressions(src string) []*flowLintSuppression {
+	var out []*flowLintSuppression
+	var topEntries []clojureMapEntry
+	topStart := -1
+	if start, err := topLevelFlowMapStart(src); err == nil && start >= 0 {
+		topStart = start
+		topEntries, _ = extractTopLevelMapEntries(src)
+	}
+	for i := 0; i < len(src); {
+		switch src[i] {
+		case ';':
+			i = readCommentEnd(src, i)
+			continue
+		case '"':
+			end, err := readClojureRegexTokenEnd(src, i)
+			if err != nil {
+				return out
+			}
+			i = end
+			continue
+		case '\\':
+			end, err := readClojureCharLiteralEnd(src, i)
+			if err != nil || end <= i {
+				end = i + 1
+			}
+			i = end
+			continue
+		}
+		if !strings.HasPrefix(src[i:], "#_") {
+			i++
+			continue
+		}
+		suppression, next := readFlowLintSuppression(src, i, topStart, topEntries)
+		if suppression != nil {
+			out = append(out, suppression)
+		}
+		i = next
+	}
+	return out
+}
+
+func readFlowLintSuppression(src string, at, topStart int, topEntries []clojureMapEntry) (*flowLintSuppression, int) {
+	formStart := skipClojureWhitespaceCommaAndComments(src, at+2)
+	if formStart >= len(src) || src[formStart] != '{' {
+		return nil, at + 2
+	}
+	formEnd, err := readClojureFormEnd(src, formStart)
+	if err != nil {
+		return nil, at + 2
+	}
+	entries, _, err := parseClojureMapEntries(src, formStart)
+	if err != nil {
+		return nil, formEnd
+	}
+	var rules []string
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.KeyToken) != flowLintIgnoreKey {
+			continue
+		}
+		value := strings.TrimSpace(src[entry.ValueStart:entry.ValueEnd])
+		if strings.HasPrefix(value, "[") {
+			items, _, err := parseClojureVectorElements(src, entry.ValueStart)
+			if err != nil {
+				return nil, formEnd
+			}
+			for _, item := range items {
+				if token := clojureFormToken(src, item); strings.HasPrefix(token, ":") {
+					rules = append(rules, normalizeFlowLintRule(token))
+				}
+			}
+		} else if strings.HasPrefix(value, ":") {
+			rules = append(rules, normalizeFlowLintRule(value))
+		}
+	}
+	if len(rules) == 0 {
+		return nil, formEnd
+	}
+
+	// The target is the next form that is not itself discarded.
+	target := skipClojureWhitespaceCommaAndComments(src, formEnd)
+	for strings.HasPrefix(src[target:], "#_") {
+		discardEnd, err := readClojureDiscardedFormEnd(src, target+2)
+		if err != nil || discardEnd <= target+2 {
+			break
+		}
+		target = skipClojureWhitespaceCommaAndComments(src, discardEnd)
+	}
+	suppression := &flowLintSuppression{rules: rules, offset: at, start: target, end: target, used: map[string]bool{}}
+	if target >= len(src) {
+		return suppression, formEnd
+	}
+	if target == topStart {
+		suppression.fileWide = true
+		return suppression, formEnd
+	}
+	for _, entry := range topEntries {
+		if entry.KeyStart == target {
+			suppression.topKey = strings.TrimSpace(entry.KeyToken)
+			suppression.end = entry.ValueEnd
+			return suppression, formEnd
+		}
+	}
+	if end, err := readClojureFormEnd(src, target); err == nil {
+		suppression.end = end
+	}
+	return suppression, formEnd
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const lintPolicyFlow = `{:slug :policy-flow
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules []
+ :flow '(let [input (flow/input)]
+          #_{:breyta/lint-ignore [:unsupported-flow-form]}
+          (->> input :items))}
+`
+
+func runFlowLintLocalOnlyInDir(t *testing.T, dir, name, flowLiteral string, extraArgs ...string) (map[string]any, error) {
+	t.Helper()
+	flowFile := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(flowFile), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(flowFile, []byte(flowLiteral), 0o644); err != nil {
+		t.Fatalf("write flow file: %v", err)
+	}
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-acme"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append([]string{"--file", flowFile, "--local-only"}, extraArgs...))
+	err := cmd.Execute()
+	var body map[string]any
+	if decodeErr := json.NewDecoder(bytes.NewReader(out.Bytes())).Decode(&body); decodeErr != nil {
+		t.Fatalf("decode output: %v\n%s", decodeErr, out.String())
+	}
+	return body, err
+}
+
+func TestFlowsLintInlineSuppressionIsReportedInMeta(t *testing.T) {
+	body, err := runFlowLintLocalOnlyInDir(t, t.TempDir(), "flow.clj", lintPolicyFlow)
+	if err != nil {
+		t.Fatalf("expected suppressed lint to pass, got %v body=%#v", err, body)
+	}
+	rejectFlowLintDiagnosticCodes(t, body, "unsupported_visual_flow_form", "unused_lint_suppression")
+	meta, _ := body["meta"].(map[string]any)
+	suppressed, _ := meta["suppressed"].([]any)
+	if len(suppressed) != 1 {
+		t.Fatalf("expected one suppressed diagnostic, got %#v", meta)
+	}
+	item := suppressed[0].(map[string]any)
+	if item["code"] != "unsupported_visual_flow_form" || item["source"] != "inline" || item["rule"] != ":unsupported-flow-form" {
+		t.Fatalf("unexpected suppression record %#v", item)
+	}
+}
+
+func TestFlowsLintFlagsUnusedInlineSuppression(t *testing.T) {
+	literal := strings.Replace(lintPolicyFlow, "(->> input :items)", "(-> input :items)", 1)
+	body, err := runFlowLintLocalOnlyInDir(t, t.TempDir(), "flow.clj", literal)
+	if err != nil {
+		t.Fatalf("unused suppression should only warn, got %v", err)
+	}
+	requireFlowLintDiagnosticCodes(t, body, "unused_lint_suppression")
+}
+
+func TestFlowsLintKeepsServerOnlySuppressionsWithoutServerStage(t *testing.T) {
+	literal := strings.Replace(lintPolicyFlow, "[:unsupported-flow-form]", "[:unsupported-flow-form :draft-binding-missing]", 1)
+	body, err := runFlowLintLocalOnlyInDir(t, t.TempDir(), "flow.clj", literal)
+	if err != nil {
+		t.Fatalf("expected suppressed lint to pass, got %v body=%#v", err, body)
+	}
+	rejectFlowLintDiagnosticCodes(t, body, "unused_lint_suppression")
+}
+
+// TestFlowLintLocalCodesCoverLocalDiagnostics keeps flowLintLocalCodes equal
+// to the codes the local stage emits: every code built in a lint source file
+// is listed, and every listed code is built somewhere.
+func TestFlowLintLocalCodesCoverLocalDiagnostics(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	// Commands that report diagnostics in the lint shape without being part
+	// of the local lint stage.
+	notLintStage := map[string]bool{
+		"flows_functions.go": true, // flows functions eval
+	}
+	codeRe := regexp.MustCompile(`(?:lintDiagnostic\(\s*(?:"[a-z]+"|[a-z][a-zA-Z]*),\s*|appendDiag\(reference, "[a-z]+", |code:\s*|code :?= |Code = )"([a-z][a-z_]+)"`)
+	emitted := map[string]bool{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || notLintStage[file] {
+			continue
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if !bytes.Contains(b, []byte("lintDiagnostic(")) {
+			continue
+		}
+		for _, match := range codeRe.FindAllStringSubmatch(string(b), -1) {
+			if code := match[1]; code != "server_lint_failed" && strings.Contains(code, "_") {
+				emitted[code] = true
+				if !flowLintLocalCodes[code] {
+					t.Errorf("%s: local diagnostic code %q is missing from flowLintLocalCodes", file, code)
+				}
+			}
+		}
+	}
+	if len(emitted) < 50 {
+		t.Fatalf("expected to find the local diagnostic codes, found %d", len(emitted))
+	}
+	for code := range flowLintLocalCodes {
+		if !emitted[code] {
+			t.Errorf("flowLintLocalCodes lists %q, which the local stage does not emit", code)
+		}
+	}
+}
+
+func TestFlowsLintProjectConfigDisablesAndOverridesRules(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"lint": {"rules": {
+  "missing-interfaces": "off",
+  "hardcoded_workspace_id": {"severity": "error", "paths": ["flows/prod/**"]}
+}}}`
+	if err := os.WriteFile(filepath.Join(dir, projectConfigFileName), []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	literal := `{:slug :configured
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :schedules []
+ :flow '(let [ws "ws-hardcoded"] ws)}
+`
+	body, err := runFlowLintLocalOnlyInDir(t, dir, filepath.Join("flows", "dev", "flow.clj"), literal)
+	if err != nil {
+		t.Fatalf("dev flow should only warn, got %v body=%#v", err, body)
+	}
+	rejectFlowLintDiagnosticCodes(t, body, "missing_interfaces")
+	requireFlowLintDiagnosticCodes(t, body, "hardcoded_workspace_id")
+	meta, _ := body["meta"].(map[string]any)
+	suppressed, _ := meta["suppressed"].([]any)
+	if len(suppressed) != 1 || suppressed[0].(map[string]any)["source"] != "config" {
+		t.Fatalf("expected config suppression in meta, got %#v", meta)
+	}
+
+	body, err = runFlowLintLocalOnlyInDir(t, dir, filepath.Join("flows", "prod", "flow.clj"), literal)
+	if err == nil {
+		t.Fatalf("expected prod-scoped severity override to fail lint, body=%#v", body)
+	}
+	data, _ := body["data"].(map[string]any)
+	for _, itemAny := range data["diagnostics"].([]any) {
+		item := itemAny.(map[string]any)
+		if item["code"] == "hardcoded_workspace_id" && (item["severity"] != "error" || item["defaultSeverity"] != "warning") {
+			t.Fatalf("expected overridden severity, got %#v", item)
+		}
+	}
+}
+
+func TestFlowsLintRejectsInvalidConfigSeverity(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "lint.json")
+	if err := os.WriteFile(configPath, []byte(`{"lint":{"rules":{"missing_interfaces":"loud"}}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	flowFile := filepath.Join(dir, "flow.clj")
+	if err := os.WriteFile(flowFile, []byte(lintPolicyFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-acme"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--file", flowFile, "--local-only", "--config", configPath})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid severity") {
+		t.Fatalf("expected invalid severity error, got %v", err)
+	}
+}
+
+func TestProjectGlobRegexpMatchesDoubleStar(t *testing.T) {
+	cases := map[string]bool{
+		"flows/prod/a.clj":       true,
+		"flows/prod/deep/b.clj":  true,
+		"flows/dev/a.clj":        false,
+		"other/flows/prod/a.clj": false,
+	}
+	re := projectGlobRegexp("flows/prod/**")
+	for path, want := range cases {
+		if got := re.MatchString(path); got != want {
+			t.Fatalf("match %q = %v, want %v", path, got, want)
+		}
+	}
+}
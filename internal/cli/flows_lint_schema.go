@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// stepSchemaCheck validates one literal step config map against the synced
+// schema for its step type.
+type stepSchemaCheck struct {
+	src      string
+	bundle   stepSchemaBundle
+	stepType string
+	label    string
+	path     []string
+	offset   int
+	// extraKeys are keys supplied elsewhere (a packaged step's :defaults)
+	// that count towards required keys at a call site.
+	extraKeys []string
+	// required is false for packaged :defaults, which call sites may
+	// complete.
+	required bool
+}
+
+// localStepSchemaDiagnostics checks :steps :defaults maps and inline
+// (flow/step :type :id {...}) configs against the offline step-schema bundle
+// written by docs sync. Without a bundle it reports nothing.
+func localStepSchemaDiagnostics(file, configPath, src string) []flowLintDiagnostic {
+	path, ok := findStepSchemaBundlePath(file, configPath)
+	if !ok {
+		return nil
+	}
+	bundle, err := readStepSchemaBundle(path)
+	if err != nil {
+		return []flowLintDiagnostic{lintDiagnostic(
+			"warning",
+			"step_schemas_unreadable",
+			[]string{":flow"},
+			err.Error(),
+			"Rerun `breyta docs sync` to refresh the offline step schemas.",
+			"local",
+		)}
+	}
+	var diagnostics []flowLintDiagnostic
+	packaged := map[string]struct {
+		typ      string
+		defaults []string
+	}{}
+	if stepsEntry, found, err := localTopLevelEntry(src, "steps"); err == nil && found {
+		spans, _ := localFlowStepVector(src, stepsEntry)
+		for _, span := range spans {
+			if !clojureFormStartsWith(src, span.Start, '{') {
+				continue
+			}
+			entries, _, err := parseClojureMapEntries(src, span.Start)
+			if err != nil {
+				continue
+			}
+			id, err := localStepIDFromMap(src, span)
+			if err != nil || !localStepIDValid(id) {
+				continue
+			}
+			typeEntry, ok := mapEntryByKey(entries, "type")
+			if !ok {
+				continue
+			}
+			typ := strings.TrimSpace(src[typeEntry.ValueStart:typeEntry.ValueEnd])
+			if !strings.HasPrefix(typ, ":") {
+				continue
+			}
+			typ = strings.TrimPrefix(typ, ":")
+			step := packaged[id]
+			step.typ = typ
+			if defaults, ok := mapEntryByKey(entries, "defaults"); ok && clojureFormStartsWith(src, defaults.ValueStart, '{') {
+				defaultEntries, _, err := parseClojureMapEntries(src, defaults.ValueStart)
+				if err == nil {
+					step.defaults = stepSchemaEntryNames(defaultEntries)
+					diagnostics = append(diagnostics, stepSchemaCheck{
+						src:      src,
+						bundle:   bundle,
+						stepType: typ,
+						label:    id,
+						path:     []string{":steps", ":" + id, ":defaults"},
+						offset:   defaults.ValueStart,
+					}.run(defaultEntries)...)
+				}
+			}
+			packaged[id] = step
+		}
+	}
+	references, err := localFlowStepReferences(src)
+	if err != nil {
+		return diagnostics
+	}
+	for _, reference := range references {
+		if !reference.Plain || !reference.FirstArgKeyword || reference.ConfigOffset <= 0 {
+			continue
+		}
+		entries, _, err := parseClojureMapEntries(src, reference.ConfigOffset)
+		if err != nil {
+			continue
+		}
+		check := stepSchemaCheck{
+			src:      src,
+			bundle:   bundle,
+			stepType: strings.TrimPrefix(reference.TypeToken, ":"),
+			label:    strings.TrimPrefix(reference.PathID, ":"),
+			path:     []string{":flow", firstNonBlankString(reference.PathID, "<step>")},
+			offset:   reference.ConfigOffset,
+			required: true,
+		}
+		if reference.StepID != "" {
+			step, ok := packaged[reference.StepID]
+			if !ok {
+				continue
+			}
+			check.stepType = step.typ
+			check.extraKeys = step.defaults
+		}
+		diagnostics = append(diagnostics, check.run(entries)...)
+	}
+	return diagnostics
+}
+
+func (c stepSchemaCheck) run(entries []clojureMapEntry) []flowLintDiagnostic {
+	schema, ok := c.bundle.Steps[docsFieldsSlug(c.stepType)]
+	if !ok {
+		return nil
+	}
+	fields := map[string]stepSchemaField{}
+	for _, field := range schema.Fields {
+		fields[field.Name] = field
+		for _, alias := range field.Aliases {
+			if _, taken := fields[alias]; !taken {
+				fields[alias] = field
+			}
+		}
+	}
+	prefix := fmt.Sprintf("`:%s` step `%s`", c.stepType, c.label)
+	hint := fmt.Sprintf("Documented keys: `breyta docs fields %s` (step schemas %s).", c.stepType, c.bundle.Version)
+	var diagnostics []flowLintDiagnostic
+	present := map[string]bool{}
+	for _, name := range c.extraKeys {
+		present[name] = true
+	}
+	for _, entry := range entries {
+		key := strings.TrimSpace(entry.KeyToken)
+		if !strings.HasPrefix(key, ":") || strings.Contains(key, "/") {
+			// Non-keyword and namespaced keys are outside the documented
+			// config surface.
+			continue
+		}
+		name := normalizeDocsFieldName(key)
+		field, known := fields[name]
+		if !known {
+			message := fmt.Sprintf("%s: unknown key `%s`", prefix, key)
+			if suggestion := stepSchemaSuggestion(name, schema.Fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean `:%s`", suggestion)
+			}
+			diag := lintDiagnostic("warning", "step_config_unknown_key", append(slices.Clone(c.path), key), message, hint, "local")
+			diag["byteOffset"] = entry.KeyStart
+			diagnostics = append(diagnostics, diag)
+			continue
+		}
+		present[field.Name] = true
+		kind := stepSchemaValueKind(c.src, entry.ValueStart)
+		if kind == "" || len(field.Types) == 0 || stepSchemaKindAllowed(kind, field.Types) {
+			continue
+		}
+		diag := lintDiagnostic(
+			"warning",
+			"step_config_invalid_type",
+			append(slices.Clone(c.path), key),
+			fmt.Sprintf("%s: key `%s` expects %s, got %s", prefix, key, strings.Join(field.Types, " or "), kind),
+			hint,
+			"local",
+		)
+		diag["byteOffset"] = entry.ValueStart
+		diagnostics = append(diagnostics, diag)
+	}
+	if !c.required {
+		return diagnostics
+	}
+	for _, field := range schema.Fields {
+		if !field.Required || present[field.Name] || slices.ContainsFunc(field.Aliases, func(alias string) bool { return present[alias] }) {
+			continue
+		}
+		diag := lintDiagnostic(
+			"warning",
+			"step_config_missing_required",
+			append(slices.Clone(c.path), ":"+field.Name),
+			fmt.Sprintf("%s: missing required key `:%s`", prefix, field.Name),
+			hint,
+			"local",
+		)
+		diag["byteOffset"] = c.offset
+		diagnostics = append(diagnostics, diag)
+	}
+	return diagnostics
+}
+
+func stepSchemaEntryNames(entries []clojureMapEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		if key := strings.TrimSpace(entry.KeyToken); strings.HasPrefix(key, ":") {
+			names = append(names, normalizeDocsFieldName(key))
+		}
+	}
+	return names
+}
+
+// stepSchemaValueKind classifies a literal value. Symbols, calls, nil, and
+// reader forms return "" and are never type checked.
+func stepSchemaValueKind(src string, start int) string {
+	i, ok := clojureActiveFormStart(src, start)
+	if !ok || i >= len(src) {
+		return ""
+	}
+	switch c := src[i]; {
+	case c == '{':
+		return "map"
+	case c == '[':
+		return "vector"
+	case c == '"':
+		return "string"
+	case c == ':':
+		return "keyword"
+	case c >= '0' && c <= '9':
+		return "number"
+	case (c == '-' || c == '+') && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+		return "number"
+	}
+	switch src[i:readClojureTokenEnd(src, i)] {
+	case "true", "false":
+		return "boolean"
+	}
+	return ""
+}
+
+// stepSchemaKindAllowed treats keywords and strings as interchangeable, the
+// way step configs coerce them.
+func stepSchemaKindAllowed(kind string, types []string) bool {
+	if slices.Contains(types, kind) {
+		return true
+	}
+	switch kind {
+	case "keyword":
+		return slices.Contains(types, "string")
+	case "string":
+		return slices.Contains(types, "keyword")
+	}
+	return false
+}
+
+// stepSchemaSuggestion returns the closest documented key within two edits.
+func stepSchemaSuggestion(name string, fields []stepSchemaField) string {
+	best, bestDistance := "", 3
+	for _, field := range fields {
+		for _, candidate := range append([]string{field.Name}, field.Aliases...) {
+			if d := levenshteinDistance(name, candidate); d < bestDistance && d < len(name) {
+				best, bestDistance = field.Name, d
+			}
+		}
+	}
+	return best
+}
+
+func levenshteinDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const stepSchemaTestHTTPPage = "# Step HTTP\n\n## Canonical Shape\n\n" +
+	"| Field | Type | Required | Notes |\n| --- | --- | --- | --- |\n" +
+	"| `:url` | string | Yes | Request URL |\n" +
+	"| `:method` | keyword | No | HTTP method |\n" +
+	"| `:headers` | map | No | Request headers |\n" +
+	"| `:connection` | keyword/string | Yes* | Slot or connection id |\n" +
+	"| `:persist.type` | keyword | No | Persist target |\n"
+
+func syncStepSchemasForTest(t *testing.T, outDir string) {
+	t.Helper()
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/docs/pages":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"data": map[string]any{
+					"pages": []map[string]any{
+						{"slug": "start-here", "title": "Start"},
+						{"slug": "reference-step-http", "title": "Step HTTP"},
+					},
+				},
+			})
+		case "/api/docs/pages/start-here":
+			_, _ = w.Write([]byte("# Start\n"))
+		case "/api/docs/pages/reference-step-http":
+			_, _ = w.Write([]byte(stepSchemaTestHTTPPage))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cmd := newDocsSyncCmd(&App{APIURL: srv.URL, Token: "test-token"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--out", outDir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("docs sync: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Wrote step schemas for 1 step types") {
+		t.Fatalf("expected step schema summary, got: %s", out.String())
+	}
+}
+
+func TestDocsSyncWritesVersionedStepSchemaBundle(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	outDir := filepath.Join(t.TempDir(), ".breyta-docs")
+	syncStepSchemasForTest(t, outDir)
+
+	bundle, err := readStepSchemaBundle(filepath.Join(outDir, stepSchemaBundleFileName))
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	if bundle.Version == "" || len(bundle.Steps) != 1 {
+		t.Fatalf("unexpected bundle: %#v", bundle)
+	}
+	fields := map[string]stepSchemaField{}
+	for _, field := range bundle.Steps["reference-step-http"].Fields {
+		fields[field.Name] = field
+	}
+	if !fields["url"].Required || fields["connection"].Required || fields["method"].Required {
+		t.Fatalf("only unqualified Yes cells are required: %#v", fields)
+	}
+	if strings.Join(fields["connection"].Types, ",") != "keyword,string" || strings.Join(fields["headers"].Types, ",") != "map" {
+		t.Fatalf("unexpected field types: %#v", fields)
+	}
+	if _, ok := fields["persist"]; !ok {
+		t.Fatalf("nested fields should register their top-level key: %#v", fields)
+	}
+	cached, err := readStepSchemaBundle(filepath.Join(cacheHome, "breyta", stepSchemaBundleFileName))
+	if err != nil || cached.Version != bundle.Version {
+		t.Fatalf("expected the user cache copy, got %#v err=%v", cached, err)
+	}
+
+	again := buildStepSchemaBundle(map[string]string{"reference-step-http": stepSchemaTestHTTPPage}, "")
+	if again.Version != bundle.Version {
+		t.Fatalf("version should only depend on the schemas: %s vs %s", again.Version, bundle.Version)
+	}
+}
+
+func TestFlowsLintValidatesStepConfigsAgainstSyncedSchemasOffline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	syncStepSchemasForTest(t, filepath.Join(root, ".breyta-docs"))
+
+	flowFile := filepath.Join(root, "flows", "orders.clj")
+	flowLiteral := `{:slug :orders
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :steps [{:id :tools/fetch-order :type :http :description "Fetch"
+          :defaults {:url "https://example.test/orders" :header {"x" "y"}}}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :flow '(let [order (flow/step :tools/fetch-order :fetch-order {:method :get})]
+          (flow/step :http :notify {:method :post :headers "nope" :body order}))}
+`
+	if err := os.MkdirAll(filepath.Dir(flowFile), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(flowFile, []byte(flowLiteral), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+
+	// No API URL: lint must work from the synced bundle alone.
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-test"})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"--file", flowFile, "--local-only"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("schema diagnostics are warnings: %v\n%s\n%s", err, stdout.String(), stderr.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v\n%s", err, stdout.String())
+	}
+	byCode := map[string][]map[string]any{}
+	for _, item := range body["data"].(map[string]any)["diagnostics"].([]any) {
+		diag := item.(map[string]any)
+		code := diag["code"].(string)
+		byCode[code] = append(byCode[code], diag)
+	}
+
+	unknown := byCode["step_config_unknown_key"]
+	if len(unknown) != 2 {
+		t.Fatalf("expected :header and :body unknown keys, got %#v", byCode)
+	}
+	if unknown[0]["message"] != "`:http` step `tools/fetch-order`: unknown key `:header`, did you mean `:headers`" {
+		t.Fatalf("unexpected message: %v", unknown[0]["message"])
+	}
+	if unknown[0]["line"].(float64) != 4 {
+		t.Fatalf("expected the :header key line, got %#v", unknown[0])
+	}
+	if got := strings.Join(stringSlice(unknown[0]["path"]), " "); got != ":steps :tools/fetch-order :defaults :header" {
+		t.Fatalf("unexpected path: %s", got)
+	}
+	if !strings.Contains(unknown[1]["message"].(string), "`:http` step `notify`: unknown key `:body`") {
+		t.Fatalf("unexpected inline message: %v", unknown[1]["message"])
+	}
+
+	invalid := byCode["step_config_invalid_type"]
+	if len(invalid) != 1 || invalid[0]["message"] != "`:http` step `notify`: key `:headers` expects map, got string" {
+		t.Fatalf("unexpected type diagnostics: %#v", invalid)
+	}
+	missing := byCode["step_config_missing_required"]
+	if len(missing) != 1 || missing[0]["message"] != "`:http` step `notify`: missing required key `:url`" {
+		t.Fatalf("defaults should satisfy the packaged call's :url, got %#v", missing)
+	}
+}
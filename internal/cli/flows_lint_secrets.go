@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const flowSecretLiteralCode = "secret_literal_detected"
+
+const flowSecretLiteralHint = "Move the value into a secret binding: declare a :requires slot ({:slot :api :type :http-api :auth {:type :bearer}} or {:slot :api-token :type :secret :secret-ref :api-token}), reference the slot instead of the literal, and set the value with breyta flows configure. For a false positive, add #_{:breyta/lint-ignore :secret-literal-detected} before the form or pass --allow-secrets."
+
+// flowSecretDetectors are provider token shapes matched anywhere inside a
+// string literal. Each literal reports its first matching shape, so specific
+// providers come before the generic bearer header.
+var flowSecretDetectors = []struct {
+	label   string
+	pattern *regexp.Regexp
+}{
+	{"private key block", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Stripe secret key", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}`)},
+	{"OpenAI API key", regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[0-9A-Za-z_-]{20,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}`)},
+	{"Slack webhook URL", regexp.MustCompile(`https://hooks\.slack\.com/services/T[0-9A-Z]+/B[0-9A-Z]+/[0-9A-Za-z]+`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[0-9A-Za-z]{36,}|github_pat_[0-9A-Za-z_]{22,})`)},
+	{"bearer credential", regexp.MustCompile(`(?i)\bbearer\s+[0-9A-Za-z\-._~+/]{16,}=*`)},
+}
+
+const (
+	flowSecretEntropyMinLength = 24
+	flowSecretEntropyThreshold = 4.3
+)
+
+type flowSecretFinding struct {
+	label  string
+	match  string
+	offset int
+	path   []string
+}
+
+// localSecretLiteralDiagnostics reports string literals that look like
+// credentials. String literals inside function :code strings are scanned one
+// by one, so a key inside (fn [_] {:headers {"Authorization" "..."}}) is
+// reported against that function's :code path.
+func localSecretLiteralDiagnostics(flowLiteral string) []flowLintDiagnostic {
+	var diagnostics []flowLintDiagnostic
+	for _, finding := range scanFlowSecretLiterals(flowLiteral) {
+		diag := lintDiagnostic(
+			"error",
+			flowSecretLiteralCode,
+			finding.path,
+			fmt.Sprintf("Possible %s in string literal (%s).", finding.label, redactFlowSecret(finding.match)),
+			flowSecretLiteralHint,
+			"local",
+		)
+		diag["byteOffset"] = finding.offset
+		diag["detector"] = finding.label
+		diagnostics = append(diagnostics, diag)
+	}
+	return diagnostics
+}
+
+func scanFlowSecretLiterals(src string) []flowSecretFinding {
+	codes, err := extractTopLevelFunctionCodeStrings(src)
+	if err != nil {
+		codes = bestEffortFunctionCodeStrings(src)
+	}
+	codeAt := make(map[int]functionCodeString, len(codes))
+	for _, code := range codes {
+		codeAt[code.ByteOffset] = code
+	}
+	entries, _ := extractTopLevelMapEntries(src)
+	pathAt := func(offset int) []string {
+		for _, entry := range entries {
+			if offset >= entry.KeyStart && offset < entry.ValueEnd {
+				return []string{strings.TrimSpace(entry.KeyToken)}
+			}
+		}
+		return nil
+	}
+
+	var findings []flowSecretFinding
+	forEachClojureStringLiteral(src, func(offset int, value string) {
+		if code, ok := codeAt[offset]; ok {
+			forEachClojureStringLiteral(code.Code, func(_ int, inner string) {
+				for _, finding := range scanFlowSecretValue(inner) {
+					finding.offset, finding.path = offset, code.Path
+					findings = append(findings, finding)
+				}
+			})
+			return
+		}
+		for _, finding := range scanFlowSecretValue(value) {
+			finding.offset, finding.path = offset, pathAt(offset)
+			findings = append(findings, finding)
+		}
+	})
+	return findings
+}
+
+// forEachClojureStringLiteral calls fn with the offset and decoded value of
+// every string literal outside comments, character literals and regexes.
+func forEachClojureStringLiteral(src string, fn func(offset int, value string)) {
+	for i := 0; i < len(src); {
+		switch {
+		case src[i] == ';':
+			i = readCommentEnd(src, i)
+		case src[i] == '\\':
+			end, err := readClojureCharLiteralEnd(src, i)
+			if err != nil || end <= i {
+				end = i + 1
+			}
+			i = end
+		case strings.HasPrefix(src[i:], `#"`):
+			end, err := readClojureRegexTokenEnd(src, i+1)
+			if err != nil || end <= i+1 {
+				return
+			}
+			i = end
+		case src[i] == '"':
+			_, value, next, err := readClojureStringToken(src, i)
+			if err != nil || next <= i {
+				return
+			}
+			fn(i, value)
+			i = next
+		default:
+			i++
+		}
+	}
+}
+
+// scanFlowSecretValue returns at most one finding per literal: the first
+// provider shape, or else the first high-entropy token.
+func scanFlowSecretValue(value string) []flowSecretFinding {
+	for _, detector := range flowSecretDetectors {
+		if match := detector.pattern.FindString(value); match != "" {
+			return []flowSecretFinding{{label: detector.label, match: match}}
+		}
+	}
+	tokens := strings.FieldsFunc(value, func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+/=_-", r)))
+	})
+	for _, token := range tokens {
+		if flowSecretHighEntropyToken(token) {
+			return []flowSecretFinding{{label: "high-entropy secret", match: token}}
+		}
+	}
+	return nil
+}
+
+func flowSecretHighEntropyToken(token string) bool {
+	if len(token) < flowSecretEntropyMinLength || strings.Contains(token, "//") {
+		return false
+	}
+	hasLetter := strings.IndexFunc(token, unicode.IsLetter) >= 0
+	hasDigit := strings.IndexFunc(token, unicode.IsDigit) >= 0
+	return hasLetter && hasDigit && shannonEntropy(token) >= flowSecretEntropyThreshold
+}
+
+// shannonEntropy returns bits per character.
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	total := float64(len(s))
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// redactFlowSecret keeps enough of the value to find it in the source
+// without echoing the credential into lint output or CI logs.
+func redactFlowSecret(match string) string {
+	runes := []rune(match)
+	if len(runes) <= 8 {
+		return fmt.Sprintf("%d chars", len(runes))
+	}
+	return fmt.Sprintf("%s…, %d chars", string(runes[:4]), len(runes))
+}
+
+// withoutSecretLiteralDiagnostics drops secret findings for --allow-secrets.
+func withoutSecretLiteralDiagnostics(diagnostics []flowLintDiagnostic) []flowLintDiagnostic {
+	out := diagnostics[:0]
+	for _, diag := range diagnostics {
+		if code, _ := diag["code"].(string); code != flowSecretLiteralCode {
+			out = append(out, diag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// Token fixtures are split so repository secret scanners do not flag them.
+var (
+	secretTestOpenAIKey = "sk-" + "proj-Zq8vR2mXk4Lp9TnW3yHb6DfJ1sGc5AeU"
+	secretTestSlackKey  = "xox" + "b-20481632-8a7Fq2LmZ9xKv"
+	secretTestRandom    = "Qm9h7Tz2Xw4Rk8Vb1Nc6Ly3Pd5Fs0Gj"
+)
+
+func writeSecretTestFlow(t *testing.T) string {
+	t.Helper()
+	src := `{:slug :leaky
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :steps [{:id :tools/fetch :type :http
+          :defaults {:url "https://api.example.com/v1/orders"
+                     :headers {"Authorization" "Bearer ` + secretTestOpenAIKey + `"}}}]
+ :functions [{:id :notify :language :clojure
+              :code "(fn [input] {:token \"` + secretTestSlackKey + `\" :channel \"order-sync-notifications-v2\"})"}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :flow '(let [signing #_{:breyta/lint-ignore :secret-literal-detected} "` + secretTestRandom + `"
+              other "` + secretTestRandom + `"]
+          (flow/step :tools/fetch :fetch {:query {:sig signing :other other}}))}
+`
+	path := filepath.Join(t.TempDir(), "flows", "leaky.clj")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	return path
+}
+
+func lintSecretDiagnostics(t *testing.T, args ...string) []map[string]any {
+	t.Helper()
+	cmd := newFlowsLintCmd(&App{WorkspaceID: "ws-test"})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(append([]string{"--local-only"}, args...))
+	_ = cmd.Execute()
+	if strings.Contains(stdout.String(), secretTestOpenAIKey) || strings.Contains(stdout.String(), secretTestSlackKey) {
+		t.Fatalf("lint output must not echo secrets:\n%s", stdout.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v\n%s\n%s", err, stdout.String(), stderr.String())
+	}
+	var out []map[string]any
+	for _, item := range body["data"].(map[string]any)["diagnostics"].([]any) {
+		diag := item.(map[string]any)
+		if diag["code"] == flowSecretLiteralCode {
+			out = append(out, diag)
+		}
+	}
+	return out
+}
+
+func TestFlowsLintReportsSecretLiterals(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	flowFile := writeSecretTestFlow(t)
+
+	diags := lintSecretDiagnostics(t, "--file", flowFile)
+	if len(diags) != 3 {
+		t.Fatalf("expected bearer, code-string and entropy findings, got %#v", diags)
+	}
+	want := []struct {
+		detector string
+		path     string
+		line     float64
+	}{
+		{"OpenAI API key", ":steps", 5},
+		{"Slack token", ":functions :notify :code", 7},
+		{"high-entropy secret", ":flow", 11},
+	}
+	for i, w := range want {
+		diag := diags[i]
+		if diag["detector"] != w.detector || strings.Join(stringSlice(diag["path"]), " ") != w.path || diag["line"] != w.line || diag["severity"] != "error" {
+			t.Fatalf("finding %d: want %+v, got %#v", i, w, diag)
+		}
+	}
+	if msg := diags[0]["message"]; msg != "Possible OpenAI API key in string literal (sk-p…, 40 chars)." {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+
+	if diags := lintSecretDiagnostics(t, "--file", flowFile, "--allow-secrets"); len(diags) != 0 {
+		t.Fatalf("--allow-secrets should drop secret findings, got %#v", diags)
+	}
+}
+
+func TestFlowsPushRefusesSecretsUnlessAllowed(t *testing.T) {
+	flowFile := writeSecretTestFlow(t)
+	var calls int32
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"flowSlug": "leaky", "saved": true}})
+	}))
+	defer srv.Close()
+
+	app := &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+	push := func(extra ...string) (string, error) {
+		cmd := newFlowsPushCmd(app)
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SilenceUsage = true
+		cmd.SetArgs(append([]string{"--file", flowFile, "--validate=false"}, extra...))
+		err := cmd.Execute()
+		return stderr.String(), err
+	}
+
+	stderr, err := push()
+	if err == nil || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("push must stop before uploading, err=%v calls=%d", err, calls)
+	}
+	if !strings.Contains(stderr, "Push aborted: 3 string literal(s) look like secrets.") || !strings.Contains(stderr, "leaky.clj:5:") {
+		t.Fatalf("unexpected stderr:\n%s", stderr)
+	}
+	if strings.Contains(stderr, secretTestOpenAIKey) {
+		t.Fatalf("push error must not echo secrets:\n%s", stderr)
+	}
+
+	if _, err := push("--allow-secrets"); err != nil || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("--allow-secrets should push, err=%v calls=%d", err, calls)
+	}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// localTemplateVariableDiagnostics warns about {{variables}} in :templates
+// that no step using the template can provide. A template is only judged
+// when every step that uses it passes a literal :data map; variables whose
+// path leads into a computed value count as provided.
+func localTemplateVariableDiagnostics(flowLiteral string) []flowLintDiagnostic {
+	templates, err := findLocalFlowTemplates(flowLiteral)
+	if err != nil || len(templates) == 0 {
+		return nil
+	}
+	configs, unknown, err := localFlowTemplateStepConfigs(flowLiteral)
+	if err != nil || unknown {
+		return nil
+	}
+	var diagnostics []flowLintDiagnostic
+	for _, tmpl := range templates {
+		var uses []flowTemplateStepConfig
+		judged := true
+		for _, config := range configs {
+			if config.Template != tmpl.ID() {
+				continue
+			}
+			start, ok := clojureActiveFormStart(flowLiteral, config.DataStart)
+			if config.DataStart < 0 || !ok || start >= len(flowLiteral) || flowLiteral[start] != '{' {
+				judged = false
+				break
+			}
+			uses = append(uses, config)
+		}
+		if !judged || len(uses) == 0 {
+			continue
+		}
+		reported := map[string]bool{}
+		forEachClojureStringLiteral(flowLiteral[tmpl.Start:tmpl.End], func(offset int, value string) {
+			if !strings.Contains(value, "{{") {
+				return
+			}
+			nodes, err := parseFlowTemplateText(value)
+			if err != nil {
+				return
+			}
+			for _, variable := range flowTemplateRootVariables(nodes) {
+				up, segments := flowTemplatePathSegments(variable.Path)
+				if up > 0 || len(segments) == 0 || reported[variable.Path] {
+					continue
+				}
+				provided := false
+				for _, use := range uses {
+					if literalDataProvides(flowLiteral, use.DataStart, segments) {
+						provided = true
+						break
+					}
+				}
+				if provided {
+					continue
+				}
+				reported[variable.Path] = true
+				steps := make([]string, 0, len(uses))
+				for _, use := range uses {
+					steps = append(steps, use.StepID)
+				}
+				diag := lintDiagnostic(
+					"warning",
+					"template_variable_unprovided",
+					[]string{":templates", tmpl.Label},
+					fmt.Sprintf("Template %s uses {{%s}}, but the :data of %s never provides it.", tmpl.Label, variable.Path, strings.Join(steps, ", ")),
+					"Fix the variable path or add the key to the step :data. Check the result with: breyta flows templates render <slug> "+tmpl.ID()+" --input-file <data.json>",
+					"local",
+				)
+				diag["byteOffset"] = tmpl.Start + offset
+				diagnostics = append(diagnostics, diag)
+			}
+		})
+	}
+	return diagnostics
+}
+
+// literalDataProvides reports whether the literal data form at start can
+// produce a value at path. Anything that is not a literal map or vector,
+// such as a symbol or a call, might produce any value.
+func literalDataProvides(src string, start int, path []string) bool {
+	i, ok := clojureActiveFormStart(src, start)
+	if !ok || i >= len(src) {
+		return true
+	}
+	if len(path) == 0 {
+		return !strings.HasPrefix(src[i:], "nil")
+	}
+	switch src[i] {
+	case '{':
+		entries, _, err := parseClojureMapEntries(src, i)
+		if err != nil {
+			return true
+		}
+		for _, entry := range entries {
+			if literalDataKeyName(entry.KeyToken) == path[0] {
+				return literalDataProvides(src, entry.ValueStart, path[1:])
+			}
+		}
+		return false
+	case '[':
+		elements, _, err := parseClojureVectorElements(src, i)
+		if err != nil {
+			return true
+		}
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(elements) {
+			return false
+		}
+		return literalDataProvides(src, elements[index].Start, path[1:])
+	case '"', ':', '\\', '#':
+		return false
+	}
+	if strings.HasPrefix(src[i:], "nil") || strings.HasPrefix(src[i:], "true") || strings.HasPrefix(src[i:], "false") {
+		return false
+	}
+	if c := src[i]; c == '-' || c == '+' {
+		return i+1 >= len(src) || src[i+1] < '0' || src[i+1] > '9'
+	}
+	return src[i] < '0' || src[i] > '9'
+}
+
+func literalDataKeyName(token string) string {
+	token = strings.TrimSpace(token)
+	if strings.HasPrefix(token, "\"") {
+		_, value, _, err := readClojureStringToken(token, 0)
+		if err == nil {
+			return value
+		}
+	}
+	return strings.TrimPrefix(token, ":")
+}
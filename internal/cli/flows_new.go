@@ -0,0 +1,580 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// flowTemplateManifestName is the manifest file inside a local flow
+// scaffolding template directory.
+const flowTemplateManifestName = "template.json"
+
+// flowTemplateManifest describes a local scaffolding template. Every other
+// file in the template directory is rendered: Flow names the flow source,
+// which is written to the flow path, and the rest are written relative to
+// the flow file's directory so #flow/include paths keep resolving.
+type flowTemplateManifest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Flow        string                 `json:"flow,omitempty"`
+	Variables   []flowTemplateVariable `json:"variables,omitempty"`
+}
+
+// flowTemplateVariable is one typed {{name}} placeholder.
+type flowTemplateVariable struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Default     any      `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+}
+
+type flowTemplate struct {
+	dir      string
+	manifest flowTemplateManifest
+}
+
+var (
+	flowTemplateVariableTypes = []string{"string", "number", "integer", "boolean", "keyword", "enum"}
+	flowTemplateNameRe        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+	flowTemplateKeywordRe     = regexp.MustCompile(`^[A-Za-z*+!_?<>=][A-Za-z0-9*+!_?<>=.-]*(/[A-Za-z*+!_?<>=][A-Za-z0-9*+!_?<>=.-]*)?$`)
+	flowTemplatePlaceholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_-]*)\s*\}\}`)
+)
+
+// flowTemplateBuiltins are always available and cannot be declared.
+var flowTemplateBuiltins = []string{"slug", "name"}
+
+func loadFlowTemplate(dir string) (flowTemplate, error) {
+	path := filepath.Join(dir, flowTemplateManifestName)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return flowTemplate{}, fmt.Errorf("read template manifest: %w", err)
+	}
+	var manifest flowTemplateManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return flowTemplate{}, fmt.Errorf("parse template manifest %s: %w", path, err)
+	}
+	if strings.TrimSpace(manifest.Name) == "" {
+		manifest.Name = filepath.Base(dir)
+	}
+	if strings.TrimSpace(manifest.Flow) == "" {
+		manifest.Flow = "flow.clj"
+	}
+	seen := map[string]bool{}
+	for i, variable := range manifest.Variables {
+		name := strings.TrimSpace(variable.Name)
+		if !flowTemplateNameRe.MatchString(name) {
+			return flowTemplate{}, fmt.Errorf("template %s: invalid variable name %q", manifest.Name, variable.Name)
+		}
+		if slices.Contains(flowTemplateBuiltins, name) {
+			return flowTemplate{}, fmt.Errorf("template %s: variable %q is built in and cannot be declared", manifest.Name, name)
+		}
+		if seen[name] {
+			return flowTemplate{}, fmt.Errorf("template %s: duplicate variable %q", manifest.Name, name)
+		}
+		seen[name] = true
+		if variable.Type == "" {
+			variable.Type = "string"
+		}
+		if !slices.Contains(flowTemplateVariableTypes, variable.Type) {
+			return flowTemplate{}, fmt.Errorf("template %s: variable %q has unsupported type %q (use %s)", manifest.Name, name, variable.Type, strings.Join(flowTemplateVariableTypes, ", "))
+		}
+		if variable.Type == "enum" && len(variable.Choices) == 0 {
+			return flowTemplate{}, fmt.Errorf("template %s: enum variable %q needs choices", manifest.Name, name)
+		}
+		if variable.Pattern != "" {
+			if _, err := regexp.Compile(variable.Pattern); err != nil {
+				return flowTemplate{}, fmt.Errorf("template %s: variable %q has invalid pattern: %w", manifest.Name, name, err)
+			}
+		}
+		variable.Name = name
+		manifest.Variables[i] = variable
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(manifest.Flow))); err != nil {
+		return flowTemplate{}, fmt.Errorf("template %s: flow file %s: %w", manifest.Name, manifest.Flow, err)
+	}
+	return flowTemplate{dir: dir, manifest: manifest}, nil
+}
+
+// discoverFlowTemplates lists the templates named in the project config,
+// keyed by template name. Explicit paths win over scanned directories.
+func discoverFlowTemplates(cfg projectConfig) (map[string]flowTemplate, error) {
+	templates := map[string]flowTemplate{}
+	base := cfg.dir()
+	if base == "" {
+		return templates, nil
+	}
+	for _, dir := range cfg.Templates.Dirs {
+		root := filepath.Join(base, filepath.FromSlash(dir))
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return nil, fmt.Errorf("read template dir %s: %w", root, err)
+		}
+		for _, entry := range entries {
+			candidate := filepath.Join(root, entry.Name())
+			if !entry.IsDir() {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(candidate, flowTemplateManifestName)); err != nil {
+				continue
+			}
+			template, err := loadFlowTemplate(candidate)
+			if err != nil {
+				return nil, err
+			}
+			templates[template.manifest.Name] = template
+		}
+	}
+	for name, dir := range cfg.Templates.Paths {
+		template, err := loadFlowTemplate(filepath.Join(base, filepath.FromSlash(dir)))
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = template
+	}
+	return templates, nil
+}
+
+// resolveFlowTemplate accepts a template directory or a project template name.
+func resolveFlowTemplate(ref string, cfg projectConfig) (flowTemplate, error) {
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		return loadFlowTemplate(ref)
+	}
+	templates, err := discoverFlowTemplates(cfg)
+	if err != nil {
+		return flowTemplate{}, err
+	}
+	if template, ok := templates[ref]; ok {
+		return template, nil
+	}
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return flowTemplate{}, fmt.Errorf("template %q is not a directory and no templates are configured in %s", ref, projectConfigFileName)
+	}
+	return flowTemplate{}, fmt.Errorf("unknown template %q (available: %s)", ref, strings.Join(names, ", "))
+}
+
+func parseFlowTemplateVars(specs []string) (map[string]string, error) {
+	values := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected name=value)", spec)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("duplicate --var %q", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// coerce validates a raw value against the variable type and returns the
+// text substituted into the template. Strings are escaped so they can sit
+// inside a Clojure string literal; keywords are rendered without the colon.
+func (v flowTemplateVariable) coerce(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	switch v.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("variable %q must be a number, got %q", v.Name, raw)
+		}
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", fmt.Errorf("variable %q must be an integer, got %q", v.Name, raw)
+		}
+	case "boolean":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("variable %q must be true or false, got %q", v.Name, raw)
+		}
+		value = strconv.FormatBool(parsed)
+	case "keyword":
+		value = strings.TrimPrefix(value, ":")
+		if !flowTemplateKeywordRe.MatchString(value) {
+			return "", fmt.Errorf("variable %q must be a keyword name, got %q", v.Name, raw)
+		}
+	case "enum":
+		if !slices.Contains(v.Choices, value) {
+			return "", fmt.Errorf("variable %q must be one of %s, got %q", v.Name, strings.Join(v.Choices, ", "), raw)
+		}
+	default:
+		value = raw
+	}
+	if v.Pattern != "" && !regexp.MustCompile(v.Pattern).MatchString(value) {
+		return "", fmt.Errorf("variable %q does not match pattern %s", v.Name, v.Pattern)
+	}
+	if v.Type == "string" {
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	}
+	return value, nil
+}
+
+func (v flowTemplateVariable) defaultText() (string, bool) {
+	if v.Default == nil {
+		return "", false
+	}
+	if f, ok := v.Default.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return fmt.Sprint(v.Default), true
+}
+
+// resolveFlowTemplateValues fills every declared variable from --var values,
+// interactive prompts, or defaults, in that order. prompt is nil when the
+// session is not interactive.
+func resolveFlowTemplateValues(template flowTemplate, given map[string]string, prompt func(flowTemplateVariable, string) (string, error)) (map[string]string, map[string]string, error) {
+	declared := map[string]bool{}
+	for _, variable := range template.manifest.Variables {
+		declared[variable.Name] = true
+	}
+	for key := range given {
+		if !declared[key] {
+			return nil, nil, fmt.Errorf("unknown --var %q for template %s", key, template.manifest.Name)
+		}
+	}
+	rendered := map[string]string{}
+	raw := map[string]string{}
+	for _, variable := range template.manifest.Variables {
+		value, ok := given[variable.Name]
+		fallback, hasDefault := variable.defaultText()
+		if !ok && prompt != nil {
+			answer, err := prompt(variable, fallback)
+			if err != nil {
+				return nil, nil, err
+			}
+			if strings.TrimSpace(answer) != "" {
+				value, ok = answer, true
+			}
+		}
+		if !ok && hasDefault {
+			value, ok = fallback, true
+		}
+		if !ok {
+			if variable.Required {
+				return nil, nil, fmt.Errorf("missing required variable %q; pass --var %s=<value>", variable.Name, variable.Name)
+			}
+			rendered[variable.Name] = ""
+			continue
+		}
+		text, err := variable.coerce(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw[variable.Name] = value
+		rendered[variable.Name] = text
+	}
+	return rendered, raw, nil
+}
+
+// promptFlowTemplateVariable asks for one variable on out and reads the
+// answer from in. An empty answer keeps the default.
+func promptFlowTemplateVariable(in *bufio.Reader, out io.Writer) func(flowTemplateVariable, string) (string, error) {
+	return func(variable flowTemplateVariable, fallback string) (string, error) {
+		label := variable.Name
+		if variable.Description != "" {
+			label += " (" + variable.Description + ")"
+		}
+		if len(variable.Choices) > 0 {
+			label += " [" + strings.Join(variable.Choices, "|") + "]"
+		}
+		if fallback != "" {
+			label += " [default: " + fallback + "]"
+		}
+		fmt.Fprintf(out, "%s: ", label)
+		line, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// renderFlowTemplateText replaces {{name}} placeholders for known variables
+// and leaves any other {{...}} text alone, so runtime templates inside the
+// flow source survive scaffolding.
+func renderFlowTemplateText(text string, values map[string]string) string {
+	return flowTemplatePlaceholderRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := flowTemplatePlaceholderRe.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+type flowTemplateFile struct {
+	path    string
+	content string
+}
+
+// writeFlowTemplateFiles writes the rendered files. The returned rollback
+// restores files that were replaced and removes the files and directories
+// that were created.
+func writeFlowTemplateFiles(files []flowTemplateFile) ([]string, func() error, error) {
+	var undo []func() error
+	rollback := func() error {
+		var errs []error
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		undo = nil
+		return errors.Join(errs...)
+	}
+	written := make([]string, 0, len(files))
+	for _, file := range files {
+		var created []string
+		for dir := filepath.Dir(file.path); ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+				break
+			}
+			created = append(created, dir)
+		}
+		for i := len(created) - 1; i >= 0; i-- {
+			dir := created[i]
+			undo = append(undo, func() error { return os.Remove(dir) })
+		}
+		path := file.path
+		if previous, err := os.ReadFile(path); err == nil {
+			undo = append(undo, func() error { return atomicWriteFile(path, previous, publicFileMode) })
+		} else {
+			undo = append(undo, func() error { return os.Remove(path) })
+		}
+		if err := atomicWriteFile(path, []byte(file.content), publicFileMode); err != nil {
+			rollback()
+			return nil, nil, fmt.Errorf("write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, rollback, nil
+}
+
+// renderFlowTemplateFiles renders every template file. The flow file goes to
+// flowPath; the rest are placed relative to the flow file's directory.
+func renderFlowTemplateFiles(template flowTemplate, flowPath string, values map[string]string) ([]flowTemplateFile, error) {
+	var files []flowTemplateFile
+	flowRel := filepath.Clean(filepath.FromSlash(template.manifest.Flow))
+	err := filepath.WalkDir(template.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(template.dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == flowTemplateManifestName {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := renderFlowTemplateText(string(b), values)
+		if rel == flowRel {
+			files = append([]flowTemplateFile{{path: flowPath, content: content}}, files...)
+			return nil
+		}
+		target := filepath.Clean(filepath.FromSlash(renderFlowTemplateText(filepath.ToSlash(rel), values)))
+		if filepath.IsAbs(target) || target == ".." || strings.HasPrefix(target, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("template file %s renders outside the flow directory", rel)
+		}
+		files = append(files, flowTemplateFile{path: filepath.Join(filepath.Dir(flowPath), target), content: content})
+		return nil
+	})
+	return files, err
+}
+
+func flowTemplateSummary(name string, template flowTemplate) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"description": template.manifest.Description,
+		"dir":         template.dir,
+		"variables":   template.manifest.Variables,
+	}
+}
+
+func newFlowsNewCmd(app *App) *cobra.Command {
+	var templateRef, outPath, name, configPath string
+	var varSpecs []string
+	var list, force, noPrompt bool
+	cmd := &cobra.Command{
+		Use:   "new <flow-slug> --template <dir|name>",
+		Short: "Create a local flow from a scaffolding template",
+		Long: strings.TrimSpace(`
+Create a local flow from a template directory instead of the fixed flows init
+skeleton. A template directory holds a ` + flowTemplateManifestName + ` manifest, the flow file
+(default flow.clj), and any step or body files it includes. {{name}}
+placeholders in file contents and paths are replaced by manifest variables and
+the built-ins {{slug}} and {{name}}; other {{...}} text is left untouched.
+
+Variables are typed (string, number, integer, boolean, keyword, enum) and are
+set with --var name=value, prompted for in an interactive terminal, or taken
+from their defaults. The flow file is written to flows/<flow-slug>.clj (or
+--out) and other files relative to it, then the result is checked with local
+lint. On lint errors the written files are removed (files replaced with
+--force are restored) and the command exits non-zero.
+
+Templates may be named in ` + projectConfigFileName + ` under "templates": "dirs" lists
+directories of templates and "paths" maps names to template directories. Use
+--list to show them.
+`),
+		Example: strings.TrimSpace(`
+breyta flows new --list
+breyta flows new order-ingest --template webhook-ingest --var source=shopify
+breyta flows new csv-import --template ./templates/csv-to-table --var table=orders --no-prompt
+`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if list {
+				cfg, err := loadProjectConfig(cwd, configPath)
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				templates, err := discoverFlowTemplates(cfg)
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				names := make([]string, 0, len(templates))
+				for name := range templates {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				items := make([]map[string]any, 0, len(names))
+				for _, name := range names {
+					items = append(items, flowTemplateSummary(name, templates[name]))
+				}
+				return writeData(cmd, app, nil, map[string]any{"config": cfg.path, "templates": items})
+			}
+			if len(args) != 1 {
+				return writeErr(cmd, errors.New("missing <flow-slug>"))
+			}
+			slug := strings.TrimSpace(args[0])
+			if !isAPIValidFlowSlug(slug) {
+				return writeErr(cmd, fmt.Errorf("invalid flow slug %q", slug))
+			}
+			if strings.TrimSpace(templateRef) == "" {
+				return writeErr(cmd, errors.New("missing --template"))
+			}
+			path := resolveLocalFlowPath(slug, outPath)
+			cfg, err := loadProjectConfig(filepath.Dir(path), configPath)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			template, err := resolveFlowTemplate(strings.TrimSpace(templateRef), cfg)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			given, err := parseFlowTemplateVars(varSpecs)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			var prompt func(flowTemplateVariable, string) (string, error)
+			if f, ok := cmd.InOrStdin().(*os.File); ok && !noPrompt && isatty.IsTerminal(f.Fd()) {
+				prompt = promptFlowTemplateVariable(bufio.NewReader(f), cmd.ErrOrStderr())
+			}
+			values, raw, err := resolveFlowTemplateValues(template, given, prompt)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			flowName := strings.TrimSpace(name)
+			if flowName == "" {
+				flowName = slug
+			}
+			values["slug"] = slug
+			values["name"] = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(flowName)
+
+			files, err := renderFlowTemplateFiles(template, path, values)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if !force {
+				for _, file := range files {
+					if _, err := os.Stat(file.path); err == nil {
+						return writeErr(cmd, fmt.Errorf("%s already exists; pass --force to replace it", file.path))
+					} else if !os.IsNotExist(err) {
+						return writeErr(cmd, err)
+					}
+				}
+			}
+			// Included files must exist for the lint to expand them, so the
+			// files are written first and rolled back when the flow has lint
+			// errors.
+			written, rollback, err := writeFlowTemplateFiles(files)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			local, err := runFlowLintLocalStage(path, files[0].content, configPath)
+			if err != nil {
+				rollback()
+				return writeErr(cmd, err)
+			}
+			newFlowLintLocator(path, files[0].content, local.expandedLiteral, local.sourceMap).annotate(local.diagnostics)
+			diagnostics := local.diagnostics
+			if diagnostics == nil {
+				diagnostics = []flowLintDiagnostic{}
+			}
+			extra := map[string]any{
+				"flowSlug":  slug,
+				"template":  template.manifest.Name,
+				"variables": raw,
+				"files":     written,
+				"lint":      map[string]any{"ok": !lintHasErrors(diagnostics), "diagnostics": diagnostics},
+			}
+			if lintHasErrors(diagnostics) {
+				if err := rollback(); err != nil {
+					return writeErr(cmd, fmt.Errorf("the rendered flow has lint errors and its files could not be removed: %w", err))
+				}
+				extra["files"] = []string{}
+				extra["saved"] = false
+				extra["path"] = path
+				if err := writeOut(cmd, app, map[string]any{"ok": false, "workspaceId": app.WorkspaceID, "data": extra}); err != nil {
+					return err
+				}
+				return guidedCLIErrorForCommand(cmd, "the rendered flow has lint errors; no files were written", []string{"Fix the template or the --var values and rerun breyta flows new."})
+			}
+			if err := writeLocalAuthoringResult(cmd, app, path, nil, 0, extra); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&templateRef, "template", "", "Template directory or template name from "+projectConfigFileName)
+	cmd.Flags().StringArrayVar(&varSpecs, "var", nil, "Template variable as name=value (repeatable)")
+	cmd.Flags().StringVar(&name, "name", "", "Flow display name for {{name}} (default: slug)")
+	cmd.Flags().StringVar(&outPath, "out", "", "Output path for the flow file (default: flows/<flow-slug>.clj)")
+	cmd.Flags().StringVar(&configPath, "config", "", "Project config (default: nearest "+projectConfigFileName+")")
+	cmd.Flags().BoolVar(&list, "list", false, "List templates configured in the project config")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace existing files")
+	cmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "Never prompt; use --var values and defaults only")
+	return cmd
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const newTemplateManifest = `{
+  "name": "webhook-ingest",
+  "description": "Receive a webhook and store it",
+  "variables": [
+    {"name": "source", "type": "keyword", "required": true},
+    {"name": "max-items", "type": "integer", "default": 50},
+    {"name": "mode", "type": "enum", "choices": ["append", "replace"], "default": "append"},
+    {"name": "title", "type": "string", "default": "Ingest"}
+  ]
+}`
+
+const newTemplateFlow = `{:slug :{{slug}}
+ :name "{{name}}"
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :invocations {:default {:label "{{title}}" :inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :steps [#flow/include "{{slug}}/steps/fetch.edn"]
+ :flow '(let [input (flow/input)]
+          (flow/step :{{source}}/fetch {:limit {{max-items}} :mode :{{mode}} :note "{{ unknown }}"}))}
+`
+
+const newTemplateStep = `{:id :{{source}}/fetch :type :http :defaults {:method :get :url "https://example.com"}}
+`
+
+func writeNewTestTemplate(t *testing.T, dir string) string {
+	t.Helper()
+	templateDir := filepath.Join(dir, "templates", "webhook-ingest")
+	for name, content := range map[string]string{
+		"template.json":            newTemplateManifest,
+		"flow.clj":                 newTemplateFlow,
+		"{{slug}}/steps/fetch.edn": newTemplateStep,
+	} {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return templateDir
+}
+
+func TestFlowsNewRendersTemplateAndLints(t *testing.T) {
+	dir := t.TempDir()
+	templateDir := writeNewTestTemplate(t, dir)
+	out := filepath.Join(dir, "flows", "order-ingest.clj")
+	body := executeLocalAuthoringJSON(t, newFlowsNewCmd(&App{WorkspaceID: "ws-test"}),
+		"order-ingest", "--template", templateDir, "--out", out, "--name", `Order "ingest"`,
+		"--var", "source=:shopify", "--var", "mode=replace", "--no-prompt")
+
+	flow, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read flow: %v", err)
+	}
+	for _, want := range []string{
+		":slug :order-ingest",
+		`:name "Order \"ingest\""`,
+		`:label "Ingest"`,
+		`#flow/include "order-ingest/steps/fetch.edn"`,
+		`(flow/step :shopify/fetch {:limit 50 :mode :replace :note "{{ unknown }}"})`,
+	} {
+		if !strings.Contains(string(flow), want) {
+			t.Fatalf("rendered flow missing %q:\n%s", want, flow)
+		}
+	}
+	step, err := os.ReadFile(filepath.Join(dir, "flows", "order-ingest", "steps", "fetch.edn"))
+	if err != nil || !strings.HasPrefix(string(step), "{:id :shopify/fetch") {
+		t.Fatalf("rendered step file wrong: %v\n%s", err, step)
+	}
+	data := body["data"].(map[string]any)
+	if lint := data["lint"].(map[string]any); lint["ok"] != true {
+		t.Fatalf("expected rendered flow to lint cleanly: %#v", lint)
+	}
+	if files := data["files"].([]any); len(files) != 2 || files[0] != out {
+		t.Fatalf("unexpected files %#v", files)
+	}
+}
+
+func TestFlowsNewRollsBackFilesWhenLintFails(t *testing.T) {
+	dir := t.TempDir()
+	templateDir := writeNewTestTemplate(t, dir)
+	broken := strings.Replace(newTemplateFlow, ":flow '(let", ":flow '((let", 1)
+	if err := os.WriteFile(filepath.Join(templateDir, "flow.clj"), []byte(broken), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	out := filepath.Join(dir, "flows", "order-ingest.clj")
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(out, []byte("original"), 0o644); err != nil {
+		t.Fatalf("write existing flow: %v", err)
+	}
+	cmd := newFlowsNewCmd(&App{WorkspaceID: "ws-test"})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs([]string{"order-ingest", "--template", templateDir, "--out", out, "--var", "source=shopify", "--no-prompt", "--force"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(buf.String(), `"ok":false`) || !strings.Contains(buf.String(), "no files were written") {
+		t.Fatalf("expected lint failure, got err=%v\n%s", err, buf.String())
+	}
+	if got, err := os.ReadFile(out); err != nil || string(got) != "original" {
+		t.Fatalf("expected the replaced flow restored, got %q %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "flows", "order-ingest")); !os.IsNotExist(err) {
+		t.Fatalf("expected the created step directory removed, got %v", err)
+	}
+}
+
+func TestFlowsNewValidatesVariables(t *testing.T) {
+	dir := t.TempDir()
+	templateDir := writeNewTestTemplate(t, dir)
+	for name, tc := range map[string]struct {
+		vars []string
+		want string
+	}{
+		"missing required": {nil, `missing required variable "source"`},
+		"bad integer":      {[]string{"source=shopify", "max-items=many"}, "must be an integer"},
+		"bad enum":         {[]string{"source=shopify", "mode=merge"}, "must be one of append, replace"},
+		"unknown var":      {[]string{"source=shopify", "colour=red"}, `unknown --var "colour"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(dir, name, "flow.clj")
+			args := []string{"order-ingest", "--template", templateDir, "--out", out, "--no-prompt"}
+			for _, v := range tc.vars {
+				args = append(args, "--var", v)
+			}
+			cmd := newFlowsNewCmd(&App{WorkspaceID: "ws-test"})
+			var buf bytes.Buffer
+			cmd.SetOut(&buf)
+			cmd.SetErr(&buf)
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err == nil || !strings.Contains(buf.String(), tc.want) {
+				t.Fatalf("expected %q, got err=%v\n%s", tc.want, err, buf.String())
+			}
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Fatalf("invalid variables must not write files")
+			}
+		})
+	}
+}
+
+func TestFlowsNewPromptsForVariables(t *testing.T) {
+	template, err := loadFlowTemplate(writeNewTestTemplate(t, t.TempDir()))
+	if err != nil {
+		t.Fatalf("load template: %v", err)
+	}
+	var prompts bytes.Buffer
+	prompt := promptFlowTemplateVariable(bufio.NewReader(strings.NewReader("stripe\n\nappend\n")), &prompts)
+	values, raw, err := resolveFlowTemplateValues(template, map[string]string{"title": "Hooks"}, prompt)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if values["source"] != "stripe" || values["max-items"] != "50" || values["mode"] != "append" || raw["title"] != "Hooks" {
+		t.Fatalf("unexpected values %#v %#v", values, raw)
+	}
+	if !strings.Contains(prompts.String(), "max-items [default: 50]: ") || strings.Contains(prompts.String(), "title") {
+		t.Fatalf("unexpected prompts %q", prompts.String())
+	}
+}
+
+func TestFlowsNewListsProjectTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeNewTestTemplate(t, dir)
+	config := filepath.Join(dir, projectConfigFileName)
+	if err := os.WriteFile(config, []byte(`{"templates": {"dirs": ["templates"]}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	body := executeLocalAuthoringJSON(t, newFlowsNewCmd(&App{WorkspaceID: "ws-test"}), "--list", "--config", config)
+	templates := body["data"].(map[string]any)["templates"].([]any)
+	if len(templates) != 1 || templates[0].(map[string]any)["name"] != "webhook-ingest" {
+		t.Fatalf("unexpected templates %#v", templates)
+	}
+
+	out := filepath.Join(dir, "flows", "hooks.clj")
+	executeLocalAuthoringJSON(t, newFlowsNewCmd(&App{WorkspaceID: "ws-test"}),
+		"hooks", "--template", "webhook-ingest", "--config", config, "--out", out, "--var", "source=hooks", "--no-prompt")
+	if _, err := os.Stat(out); err != nil {
+		t.Fatalf("named template not rendered: %v", err)
+	}
+}
@@ -25,6 +25,7 @@ func newFlowsPushCmd(app *App) *cobra.Command {
 	var timeout time.Duration
 	var deployKey string
 	var includeProvenance bool
+	var allowSecrets bool
 	cmd := &cobra.Command{
 		Use:   "push",
 		Short: "Push a local .clj flow file as a draft, creating the flow if needed",
@@ -88,10 +89,16 @@ func newFlowsPushCmd(app *App) *cobra.Command {
 					return writeErr(cmd, err)
 				}
 			}
-			flowLiteral, err = expandFlowSourceIncludes(file, flowLiteral)
+			rootSource := flowLiteral
+			flowLiteral, sourceMap, err := expandFlowSourceIncludesWithMap(file, flowLiteral)
 			if err != nil {
 				return writeErr(cmd, err)
 			}
+			if !allowSecrets {
+				if err := flowPushSecretPreflight(cmd, file, rootSource, flowLiteral, sourceMap); err != nil {
+					return writeErr(cmd, err)
+				}
+			}
 			flowSlug := ""
 			if entries, parseErr := parseSingleTopLevelMapEntries(flowLiteral); parseErr == nil {
 				flowSlug, _ = localFlowSlugFromEntries(flowLiteral, entries)
@@ -242,6 +249,7 @@ func newFlowsPushCmd(app *App) *cobra.Command {
 	cmd.Flags().BoolVar(&validate, "validate", true, "Validate the working copy after pushing")
 	cmd.Flags().DurationVar(&timeout, "timeout", defaultFlowPushTimeout, "API request timeout for draft push and validation")
 	cmd.Flags().BoolVar(&includeProvenance, "provenance", false, "Include full consulted provenance candidate list in output")
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Push even when string literals look like API keys or tokens")
 	cmd.Flags().StringVar(&deployKey, "deploy-key", "", "Deploy key for guarded flows (default: BREYTA_FLOW_DEPLOY_KEY)")
 	must(cmd.MarkFlagRequired("file"))
 	return cmd
@@ -418,3 +426,31 @@ func validateDraftFlowWithTimeout(cmd *cobra.Command, app *App, flowSlug string,
 		"source":   "draft",
 	})
 }
+
+// flowPushSecretPreflight refuses to upload flow source whose string literals
+// look like credentials. It honors the same inline suppressions and project
+// lint config as flows lint, so a rule relaxed below error does not block.
+func flowPushSecretPreflight(cmd *cobra.Command, file, rootSource, expanded string, sourceMap flowSourceMap) error {
+	diagnostics := localSecretLiteralDiagnostics(expanded)
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	policy, err := newFlowLintPolicy(file, "", expanded)
+	if err != nil {
+		return err
+	}
+	diagnostics = policy.apply(diagnostics)
+	if !lintHasErrors(diagnostics) {
+		return nil
+	}
+	newFlowLintLocator(file, rootSource, expanded, sourceMap).annotate(diagnostics)
+	var lines []string
+	for _, diag := range diagnostics {
+		if diag["severity"] != "error" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", diag["file"], diag["line"], diag["column"], diag["message"]))
+	}
+	lines = append(lines, flowSecretLiteralHint)
+	return guidedCLIErrorForCommand(cmd, fmt.Sprintf("Push aborted: %d string literal(s) look like secrets.", len(lines)-1), lines)
+}
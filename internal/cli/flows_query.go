@@ -0,0 +1,659 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// flowQuerySelector is one comma-separated alternative of a flows query:
+// optional flow filters, then either step filters or nothing (match flows).
+type flowQuerySelector struct {
+	Flow     []flowQueryFilter
+	Step     bool
+	StepType string
+	Steps    []flowQueryFilter
+}
+
+// flowQueryFilter is one [...] condition. Op is "" for presence, "!" for
+// absence, or one of =, !=, ~=, !~.
+type flowQueryFilter struct {
+	Path  []string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+}
+
+func (f flowQueryFilter) String() string {
+	key := strings.Join(f.Path, ".")
+	switch f.Op {
+	case "":
+		return "[" + key + "]"
+	case "!":
+		return "[!" + key + "]"
+	}
+	return "[" + key + f.Op + strconv.Quote(f.Value) + "]"
+}
+
+// parseFlowQuery parses selectors such as
+//
+//	step:http[url~=api\.stripe\.com]
+//	step:llm[!max-tokens], flow[slug~=^billing] step[timeout>30]
+//	flow[tags=finance]
+func parseFlowQuery(query string) ([]flowQuerySelector, error) {
+	var selectors []flowQuerySelector
+	for _, part := range splitFlowQueryAlternatives(query) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, errors.New("empty selector")
+		}
+		selector, err := parseFlowQuerySelector(part)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	if len(selectors) == 0 {
+		return nil, errors.New("query is empty")
+	}
+	return selectors, nil
+}
+
+// splitFlowQueryAlternatives splits on commas outside [...] and quotes.
+func splitFlowQueryAlternatives(query string) []string {
+	var parts []string
+	depth, start := 0, 0
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case inQuote && c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, query[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, query[start:])
+}
+
+func parseFlowQuerySelector(text string) (flowQuerySelector, error) {
+	var selector flowQuerySelector
+	rest := strings.TrimSpace(text)
+	seenFlow := false
+	for rest != "" {
+		var target string
+		switch {
+		case strings.HasPrefix(rest, "flow"):
+			target, rest = "flow", rest[len("flow"):]
+		case strings.HasPrefix(rest, "step"):
+			target, rest = "step", rest[len("step"):]
+		default:
+			return selector, fmt.Errorf("selector %q: expected flow or step near %q", text, rest)
+		}
+		if target == "flow" && (seenFlow || selector.Step) || target == "step" && selector.Step {
+			return selector, fmt.Errorf("selector %q: use at most one flow part followed by one step part", text)
+		}
+		if target == "step" && strings.HasPrefix(rest, ":") {
+			end := strings.IndexAny(rest, "[ \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			selector.StepType = strings.TrimPrefix(rest[:end], ":")
+			rest = rest[end:]
+			if selector.StepType == "" {
+				return selector, fmt.Errorf("selector %q: step: needs a type", text)
+			}
+		}
+		var filters []flowQueryFilter
+		for strings.HasPrefix(rest, "[") {
+			end, err := flowQueryFilterEnd(rest)
+			if err != nil {
+				return selector, fmt.Errorf("selector %q: %w", text, err)
+			}
+			filter, err := parseFlowQueryFilter(rest[1:end])
+			if err != nil {
+				return selector, fmt.Errorf("selector %q: %w", text, err)
+			}
+			filters = append(filters, filter)
+			rest = rest[end+1:]
+		}
+		if target == "flow" {
+			seenFlow = true
+			selector.Flow = filters
+		} else {
+			selector.Step = true
+			selector.Steps = filters
+		}
+		if rest != "" && !strings.ContainsRune(" \t", rune(rest[0])) {
+			return selector, fmt.Errorf("selector %q: unexpected %q", text, rest)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return selector, nil
+}
+
+func flowQueryFilterEnd(text string) (int, error) {
+	inQuote := false
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; {
+		case inQuote && c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case c == ']' && !inQuote:
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated filter %q", text)
+}
+
+func parseFlowQueryFilter(text string) (flowQueryFilter, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "!") && !strings.ContainsAny(text, "=~") {
+		path, err := parseFlowQueryKey(text[1:])
+		return flowQueryFilter{Path: path, Op: "!"}, err
+	}
+	opAt, op := -1, ""
+	for i := 0; i < len(text); i++ {
+		for _, candidate := range []string{"!=", "~=", "!~", "="} {
+			if strings.HasPrefix(text[i:], candidate) {
+				opAt, op = i, candidate
+				break
+			}
+		}
+		if opAt >= 0 {
+			break
+		}
+	}
+	if opAt < 0 {
+		path, err := parseFlowQueryKey(text)
+		return flowQueryFilter{Path: path}, err
+	}
+	path, err := parseFlowQueryKey(text[:opAt])
+	if err != nil {
+		return flowQueryFilter{}, err
+	}
+	value := strings.TrimSpace(text[opAt+len(op):])
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return flowQueryFilter{}, fmt.Errorf("invalid quoted value %s", value)
+		}
+		value = unquoted
+	}
+	filter := flowQueryFilter{Path: path, Op: op, Value: value}
+	if op == "~=" || op == "!~" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return flowQueryFilter{}, fmt.Errorf("invalid regex %q: %w", value, err)
+		}
+		filter.re = re
+	}
+	return filter, nil
+}
+
+// parseFlowQueryKey reads a dotted key path; a leading colon on each
+// segment is optional, so :defaults.:url and defaults.url are the same.
+func parseFlowQueryKey(text string) ([]string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("filter needs a key")
+	}
+	var path []string
+	for _, segment := range strings.Split(text, ".") {
+		segment = strings.TrimPrefix(strings.TrimSpace(segment), ":")
+		if segment == "" {
+			return nil, fmt.Errorf("invalid key %q", text)
+		}
+		path = append(path, segment)
+	}
+	return path, nil
+}
+
+// flowQueryStep is one step a query can match: a :steps definition or an
+// inline (flow/step :type :id {...}) call. Layers are the literal maps its
+// keys are looked up in, in order; a negative layer is a config that is not
+// a literal map, so key lookups past it are unknown.
+type flowQueryStep struct {
+	ID     string
+	Type   string
+	Kind   string
+	Offset int
+	Layers []int
+}
+
+func (s flowQueryStep) pseudo(key string) (string, bool) {
+	switch key {
+	case "id":
+		return s.ID, true
+	case "type":
+		return s.Type, true
+	case "kind":
+		return s.Kind, true
+	}
+	return "", false
+}
+
+// flowQueryDoc is one scanned flow file.
+type flowQueryDoc struct {
+	File     string
+	Slug     string
+	src      string
+	rootMap  int
+	steps    []flowQueryStep
+	locator  *flowLintLocator
+	stepsErr error
+}
+
+func loadFlowQueryDoc(file string) (*flowQueryDoc, error) {
+	b, err := readExplicitFile(file)
+	if err != nil {
+		return nil, err
+	}
+	source := string(b)
+	expanded, sourceMap, err := expandFlowSourceIncludesWithMap(file, source)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseSingleTopLevelMapEntries(expanded)
+	if err != nil {
+		return nil, err
+	}
+	slug, _ := localFlowSlugFromEntries(expanded, entries)
+	rootMap, err := topLevelFlowMapStart(expanded)
+	if err != nil {
+		return nil, err
+	}
+	doc := &flowQueryDoc{
+		File:    file,
+		Slug:    slug,
+		src:     expanded,
+		rootMap: rootMap,
+		locator: newFlowLintLocator(file, source, expanded, sourceMap),
+	}
+	doc.steps, doc.stepsErr = flowQuerySteps(expanded)
+	return doc, nil
+}
+
+func flowQuerySteps(src string) ([]flowQueryStep, error) {
+	var steps []flowQueryStep
+	if entry, found, err := localTopLevelEntry(src, "steps"); err == nil && found {
+		spans, err := localFlowStepVector(src, entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, span := range spans {
+			if span.Start >= len(src) || src[span.Start] != '{' {
+				continue
+			}
+			id, err := localStepIDFromMap(src, span)
+			if err != nil {
+				continue
+			}
+			step := flowQueryStep{ID: strings.TrimPrefix(id, ":"), Kind: "definition", Offset: span.Start, Layers: []int{span.Start}}
+			if entries, _, err := parseClojureMapEntries(src, span.Start); err == nil {
+				if typeEntry, ok := mapEntryByKey(entries, "type"); ok {
+					step.Type = strings.TrimPrefix(readFunctionLabel(src, typeEntry.ValueStart, ""), ":")
+				}
+				if defaults, ok := mapEntryByKey(entries, "defaults"); ok {
+					step.Layers = append(step.Layers, defaults.ValueStart)
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+	references, err := localFlowStepReferences(src)
+	if err != nil {
+		return steps, err
+	}
+	for _, reference := range references {
+		// Packaged calls are matched at their :steps definition.
+		if !reference.FirstArgKeyword || reference.StepID != "" {
+			continue
+		}
+		step := flowQueryStep{
+			ID:     strings.TrimPrefix(reference.PathID, ":"),
+			Type:   strings.TrimPrefix(reference.TypeToken, ":"),
+			Kind:   "call",
+			Offset: reference.ByteOffset,
+			Layers: []int{-1},
+		}
+		if reference.ConfigOffset > 0 {
+			step.Layers = []int{reference.ConfigOffset}
+		} else if reference.ElementCount < 4 {
+			step.Layers = nil
+		}
+		steps = append(steps, step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Offset < steps[j].Offset })
+	return steps, nil
+}
+
+// flowQueryLookup returns the value offsets at path below the form at start.
+// Vectors fan out over their elements unless the segment is an index. known
+// is false when the path runs into a form that is not literal data.
+func flowQueryLookup(src string, start int, path []string) ([]int, bool) {
+	i, ok := clojureActiveFormStart(src, start)
+	if !ok || i >= len(src) {
+		return nil, false
+	}
+	if len(path) == 0 {
+		return []int{i}, true
+	}
+	switch src[i] {
+	case '{':
+		entries, _, err := parseClojureMapEntries(src, i)
+		if err != nil {
+			return nil, false
+		}
+		for _, entry := range entries {
+			if literalDataKeyName(entry.KeyToken) == path[0] {
+				return flowQueryLookup(src, entry.ValueStart, path[1:])
+			}
+		}
+		return nil, true
+	case '[':
+		elements, _, err := parseClojureVectorElements(src, i)
+		if err != nil {
+			return nil, false
+		}
+		if index, err := strconv.Atoi(path[0]); err == nil {
+			if index < 0 || index >= len(elements) {
+				return nil, true
+			}
+			return flowQueryLookup(src, elements[index].Start, path[1:])
+		}
+		var out []int
+		known := true
+		for _, element := range elements {
+			found, elementKnown := flowQueryLookup(src, element.Start, path)
+			out = append(out, found...)
+			known = known && elementKnown
+		}
+		return out, known
+	}
+	return nil, !literalDataProvides(src, i, path)
+}
+
+// flowQueryValues renders the value at offset for comparison: decoded
+// strings, keyword names, and source text for anything else. Vectors of
+// scalars yield one value per element.
+func flowQueryValues(src string, offset int) []string {
+	end, err := readClojureFormEnd(src, offset)
+	if err != nil || end <= offset {
+		return nil
+	}
+	switch src[offset] {
+	case '"':
+		if _, value, _, err := readClojureStringToken(src, offset); err == nil {
+			return []string{value}
+		}
+	case ':':
+		return []string{strings.TrimPrefix(src[offset:end], ":")}
+	case '[', '#':
+		if src[offset] == '#' && !strings.HasPrefix(src[offset:], "#{") {
+			break
+		}
+		start := offset + 1
+		if src[offset] == '#' {
+			start++
+		}
+		elements, _, err := parseClojureVectorElements(src, offset)
+		if src[offset] == '#' {
+			elements, err = flowQuerySetElements(src, start, end-1)
+		}
+		if err == nil {
+			var out []string
+			for _, element := range elements {
+				out = append(out, flowQueryValues(src, element.Start)...)
+			}
+			return append(out, src[offset:end])
+		}
+	}
+	return []string{src[offset:end]}
+}
+
+func flowQuerySetElements(src string, start, end int) ([]clojureFormSpan, error) {
+	var out []clojureFormSpan
+	for i := skipClojureWhitespaceCommaAndComments(src, start); i < end; i = skipClojureWhitespaceCommaAndComments(src, i) {
+		formEnd, err := readClojureFormEnd(src, i)
+		if err != nil || formEnd <= i {
+			return nil, fmt.Errorf("could not read set element near byte %d", i)
+		}
+		out = append(out, clojureFormSpan{Start: i, End: formEnd})
+		i = formEnd
+	}
+	return out, nil
+}
+
+// matchFlowQueryFilter evaluates one filter against the values found at its
+// path. An unknown lookup never matches, so computed configs are neither
+// reported as having nor as lacking a key.
+func matchFlowQueryFilter(filter flowQueryFilter, values []string, present, known bool) bool {
+	switch filter.Op {
+	case "":
+		return present
+	case "!":
+		return known && !present
+	}
+	if !present {
+		return known && (filter.Op == "!=" || filter.Op == "!~")
+	}
+	any := false
+	for _, value := range values {
+		switch filter.Op {
+		case "=", "!=":
+			any = any || value == filter.Value
+		default:
+			any = any || filter.re.MatchString(value)
+		}
+	}
+	if filter.Op == "!=" || filter.Op == "!~" {
+		return !any
+	}
+	return any
+}
+
+func (d *flowQueryDoc) flowFilterMatches(filter flowQueryFilter) bool {
+	if len(filter.Path) == 1 && filter.Path[0] == "file" {
+		return matchFlowQueryFilter(filter, []string{d.File}, true, true)
+	}
+	offsets, known := flowQueryLookup(d.src, d.rootMap, filter.Path)
+	return matchFlowQueryFilter(filter, d.values(offsets), len(offsets) > 0, known)
+}
+
+func (d *flowQueryDoc) stepFilterMatches(step flowQueryStep, filter flowQueryFilter) bool {
+	if len(filter.Path) == 1 {
+		if value, ok := step.pseudo(filter.Path[0]); ok {
+			return matchFlowQueryFilter(filter, []string{value}, value != "", true)
+		}
+	}
+	known := true
+	for _, layer := range step.Layers {
+		if layer < 0 {
+			known = false
+			break
+		}
+		offsets, layerKnown := flowQueryLookup(d.src, layer, filter.Path)
+		if len(offsets) > 0 {
+			return matchFlowQueryFilter(filter, d.values(offsets), true, true)
+		}
+		if !layerKnown {
+			known = false
+			break
+		}
+	}
+	return matchFlowQueryFilter(filter, nil, false, known)
+}
+
+func (d *flowQueryDoc) values(offsets []int) []string {
+	var out []string
+	for _, offset := range offsets {
+		out = append(out, flowQueryValues(d.src, offset)...)
+	}
+	return out
+}
+
+func (d *flowQueryDoc) match(selector flowQuerySelector) []map[string]any {
+	for _, filter := range selector.Flow {
+		if !d.flowFilterMatches(filter) {
+			return nil
+		}
+	}
+	if !selector.Step {
+		return []map[string]any{d.result(d.rootMap, nil)}
+	}
+	var out []map[string]any
+	for _, step := range d.steps {
+		if selector.StepType != "" && step.Type != selector.StepType {
+			continue
+		}
+		matched := true
+		for _, filter := range selector.Steps {
+			if !d.stepFilterMatches(step, filter) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			step := step
+			out = append(out, d.result(step.Offset, &step))
+		}
+	}
+	return out
+}
+
+func (d *flowQueryDoc) result(offset int, step *flowQueryStep) map[string]any {
+	file, line, column := d.locator.locate(flowLintDiagnostic{"byteOffset": offset})
+	out := map[string]any{
+		"file":     file,
+		"line":     line,
+		"column":   column,
+		"flowSlug": d.Slug,
+	}
+	if step != nil {
+		out["stepId"] = step.ID
+		out["stepType"] = step.Type
+		out["kind"] = step.Kind
+	}
+	return out
+}
+
+// flowQueryFiles expands the query's file arguments: directories are walked
+// for flow files the way flows lint --all does.
+func flowQueryFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"flows"}
+	}
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		found, err := discoverLocalFlowFiles(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+func newFlowsQueryCmd(app *App) *cobra.Command {
+	var failOnMatch bool
+	cmd := &cobra.Command{
+		Use:   "query <selector> [files-or-dirs...]",
+		Short: "Find steps and flows in local source with a structural selector",
+		Long: strings.TrimSpace(`
+Query local flow files offline with a small selector language. Directories are
+searched for flow files like flows lint --all; the default is ./flows.
+
+  step                      every :steps definition and inline flow/step call
+  step:http                 steps of one type
+  [key] [!key]              a key is present / absent
+  [key=v] [key!=v]          a value equals / differs (strings and keyword names)
+  [key~=re] [key!~re]       a value matches / does not match a regex
+  flow[...] step[...]       steps in flows whose top-level keys match
+  a, b                      either selector
+
+Keys are dotted paths (defaults.headers.Authorization); a step's keys are
+looked up in its config, then in its :defaults. id, type and kind (definition
+or call) are available on every step, and file on every flow. Packaged steps
+match at their :steps definition. Values inside a computed config are
+unknown, so neither [key] nor [!key] matches them.
+
+Use --fail-on-match to turn a query into a CI check.
+`),
+		Example: strings.TrimSpace(`
+breyta flows query 'step:http[url~=^https://api\.stripe\.com]'
+breyta flows query 'step:llm[!max-tokens]' --fail-on-match
+breyta flows query 'flow[tags=finance] step[type=http][!timeout]' flows/billing
+`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selectors, err := parseFlowQuery(args[0])
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			files, err := flowQueryFiles(args[1:])
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			matches := []map[string]any{}
+			var skipped []map[string]any
+			for _, file := range files {
+				doc, err := loadFlowQueryDoc(file)
+				if err == nil && doc.stepsErr != nil {
+					err = doc.stepsErr
+				}
+				if err != nil {
+					skipped = append(skipped, map[string]any{"file": file, "error": err.Error()})
+					continue
+				}
+				seen := map[string]bool{}
+				for _, selector := range selectors {
+					for _, match := range doc.match(selector) {
+						key := fmt.Sprint(match["file"], match["line"], match["column"])
+						if !seen[key] {
+							seen[key] = true
+							matches = append(matches, match)
+						}
+					}
+				}
+			}
+			meta := map[string]any{"files": len(files)}
+			data := map[string]any{"query": args[0], "matches": matches, "count": len(matches)}
+			if len(skipped) > 0 {
+				data["skipped"] = skipped
+			}
+			if failOnMatch && len(matches) > 0 {
+				out := map[string]any{"ok": false, "workspaceId": app.WorkspaceID, "meta": meta, "data": data}
+				if err := writeOut(cmd, app, out); err != nil {
+					return err
+				}
+				return guidedCLIErrorForCommand(cmd, fmt.Sprintf("query matched %d location(s)", len(matches)), nil)
+			}
+			return writeData(cmd, app, meta, data)
+		},
+	}
+	cmd.Flags().BoolVar(&failOnMatch, "fail-on-match", false, "Exit non-zero when anything matches")
+	return cmd
+}
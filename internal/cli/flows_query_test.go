@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const flowsQueryBilling = `{:slug :billing-sync
+ :tags [:finance :nightly]
+ :steps [{:id :billing/charges
+          :type :http
+          :defaults {:url "https://api.stripe.com/v1/charges"
+                     :method :get}}
+         {:id :billing/summary
+          :type :llm
+          :defaults {:model "small" :max-tokens 400}}]
+ :flow '(let [charges (flow/step :billing/charges :charges {})
+              note (flow/step :llm :draft {:prompt "Summarize"})
+              misc (flow/step :http :ping {:url (str base "/ping")})]
+          (flow/step :http :notify {:url "https://hooks.example.com/notify"
+                                    :headers {"Authorization" "Bearer x"}}))}
+`
+
+const flowsQueryOther = `{:slug :other
+ :tags [:ops]
+ :flow '(flow/step :llm :classify {:prompt "Classify" :max-tokens 50})}
+`
+
+func TestParseFlowQuery(t *testing.T) {
+	selectors, err := parseFlowQuery(`flow[tags=finance] step:http[url~="^https://api\\.stripe\\.com"][!timeout], step[kind=call]`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(selectors) != 2 {
+		t.Fatalf("expected two selectors, got %d", len(selectors))
+	}
+	first := selectors[0]
+	if !first.Step || first.StepType != "http" || len(first.Flow) != 1 || len(first.Steps) != 2 {
+		t.Fatalf("unexpected selector %+v", first)
+	}
+	if got := first.Steps[0].String(); got != `[url~="^https://api\\.stripe\\.com"]` {
+		t.Fatalf("unexpected filter %s", got)
+	}
+	for _, bad := range []string{"", "steps", "step[url", "step step", "step[url~=(]", "flow step flow"} {
+		if _, err := parseFlowQuery(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func runFlowsQuery(t *testing.T, args ...string) (map[string]any, error) {
+	t.Helper()
+	cmd := newFlowsQueryCmd(&App{WorkspaceID: "ws-test"})
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	var body map[string]any
+	if stdout.Len() > 0 {
+		if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+			t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+		}
+	}
+	return body, err
+}
+
+func flowQueryMatchIDs(t *testing.T, body map[string]any) []string {
+	t.Helper()
+	var ids []string
+	for _, raw := range body["data"].(map[string]any)["matches"].([]any) {
+		match := raw.(map[string]any)
+		id, _ := match["stepId"].(string)
+		if id == "" {
+			id = match["flowSlug"].(string)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestFlowsQueryCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "flows")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	billing := filepath.Join(dir, "billing-sync.clj")
+	for name, src := range map[string]string{"billing-sync.clj": flowsQueryBilling, "other.clj": flowsQueryOther} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`step:http[url~=^https://api\.stripe\.com]`, "billing/charges"},
+		{`step:llm[!max-tokens]`, "draft"},
+		{`step:http[!timeout]`, "billing/charges,ping,notify"},
+		{`step:http[url]`, "billing/charges,ping,notify"},
+		{`step:http[url~=^https://]`, "billing/charges,notify"},
+		{`step[headers.Authorization~=^Bearer]`, "notify"},
+		{`flow[tags=finance] step[kind=call]`, "draft,ping,notify"},
+		{`flow[tags!=finance]`, "other"},
+		{`step:llm[max-tokens], step:llm[prompt=Classify]`, "billing/summary,classify"},
+	}
+	for _, tc := range cases {
+		body, err := runFlowsQuery(t, tc.query, dir)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		if got := strings.Join(flowQueryMatchIDs(t, body), ","); got != tc.want {
+			t.Fatalf("%s: got %s, want %s", tc.query, got, tc.want)
+		}
+	}
+
+	body, err := runFlowsQuery(t, `step:llm[!max-tokens]`, billing)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	match := body["data"].(map[string]any)["matches"].([]any)[0].(map[string]any)
+	if match["line"] != float64(11) || match["stepType"] != "llm" || match["kind"] != "call" || match["flowSlug"] != "billing-sync" {
+		t.Fatalf("unexpected match %s", mustJSON(t, match))
+	}
+
+	body, err = runFlowsQuery(t, `step:llm[!max-tokens]`, dir, "--fail-on-match")
+	if err == nil || body["ok"] != false || body["data"].(map[string]any)["count"] != float64(1) {
+		t.Fatalf("expected --fail-on-match to fail, got %v %s", err, mustJSON(t, body))
+	}
+	if _, err := runFlowsQuery(t, `step:llm[prompt=Nothing]`, dir, "--fail-on-match"); err != nil {
+		t.Fatalf("expected no-match to pass, got %v", err)
+	}
+}
@@ -36,9 +36,6 @@ By default this command is a dry run; pass --write to update files in place.
 			results := make([]map[string]any, 0, len(allFiles))
 			changedAny := false
 
-			parinferPath := tools.FindParinferRust()
-			parinferRunner := parinfer.Runner{BinaryPath: parinferPath}
-
 			for _, path := range allFiles {
 				b, err := readExplicitFile(path)
 				if err != nil {
@@ -46,34 +43,9 @@ By default this command is a dry run; pass --write to update files in place.
 				}
 
 				orig := string(b)
-				engine := "fallback"
-				repaired := orig
-				var report any
-
-				if err := parenrepair.Check(orig); err == nil {
-					engine = "none"
-					report = map[string]any{"balanced": true, "skipped": true}
-				} else if !errors.Is(err, parenrepair.ErrUnbalancedDelimiters) {
-					return writeFailure(cmd, app, "clojure_paren_repair_failed", err, "Fix the underlying syntax issue (e.g. unterminated string), then retry.", map[string]any{"path": path})
-				} else {
-					if parinferPath != "" {
-						engine = "parinfer-rust"
-						if out, ans, err := parinferRunner.RepairIndent(orig); err == nil {
-							repaired = out
-							report = ans
-						} else {
-							engine = "fallback"
-						}
-					}
-
-					if engine == "fallback" {
-						out, rep, err := parenrepair.Repair(orig, verbose)
-						if err != nil {
-							return writeFailure(cmd, app, "clojure_paren_repair_failed", err, "Fix the underlying syntax issue (e.g. unterminated string), then retry.", map[string]any{"path": path, "report": rep})
-						}
-						repaired = out
-						report = rep
-					}
+				repaired, engine, report, err := repairClojureDelimiters(orig, verbose)
+				if err != nil {
+					return writeFailure(cmd, app, "clojure_paren_repair_failed", err, "Fix the underlying syntax issue (e.g. unterminated string), then retry.", map[string]any{"path": path, "report": report})
 				}
 
 				changed := repaired != orig
@@ -110,6 +82,28 @@ By default this command is a dry run; pass --write to update files in place.
 	return cmd
 }
 
+// repairClojureDelimiters repairs unbalanced delimiters with parinfer-rust
+// when it is installed and the built-in fallback otherwise. Balanced source is
+// returned unchanged with engine "none".
+func repairClojureDelimiters(orig string, verbose bool) (string, string, any, error) {
+	if err := parenrepair.Check(orig); err == nil {
+		return orig, "none", map[string]any{"balanced": true, "skipped": true}, nil
+	} else if !errors.Is(err, parenrepair.ErrUnbalancedDelimiters) {
+		return orig, "", nil, err
+	}
+	if parinferPath := tools.FindParinferRust(); parinferPath != "" {
+		runner := parinfer.Runner{BinaryPath: parinferPath}
+		if out, ans, err := runner.RepairIndent(orig); err == nil {
+			return out, "parinfer-rust", ans, nil
+		}
+	}
+	out, rep, err := parenrepair.Repair(orig, verbose)
+	if err != nil {
+		return orig, "fallback", rep, err
+	}
+	return out, "fallback", rep, nil
+}
+
 func newFlowsParenCheckCmd(app *App) *cobra.Command {
 	var file string
 	cmd := &cobra.Command{
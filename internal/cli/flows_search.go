@@ -522,12 +522,14 @@ Search approved reusable templates. This is the copy-from surface formerly reach
 through ` + "`breyta flows search`" + `.
 
 Use ` + "`breyta flows search`" + ` for actual workspace flow metadata and ` + "`breyta flows grep`" + `
-for source/content search.
+for source/content search. ` + "`breyta flows templates render`" + ` renders one of a local
+flow's own :templates entries offline.
 `),
 	}
 	cmd.AddCommand(newFlowsTemplatesSearchCmd(app))
 	cmd.AddCommand(newFlowsTemplatesGrepCmd(app))
 	cmd.AddCommand(newFlowsTemplatesDuplicateCmd(app))
+	cmd.AddCommand(newFlowsTemplatesRenderCmd(app))
 	examples := newFlowsExamplesCmd(app)
 	examples.Short = "Extract primitive examples from approved templates"
 	cmd.AddCommand(examples)
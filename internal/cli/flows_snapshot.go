@@ -0,0 +1,587 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultFlowSnapshotDir = "flows/__snapshots__"
+
+// flowSnapshotFile is the golden record for one flow: the cases that were
+// run and the normalized output of every step in each run.
+type flowSnapshotFile struct {
+	FlowSlug string            `json:"flowSlug"`
+	Target   string            `json:"target"`
+	Ignore   []string          `json:"ignore,omitempty"`
+	Cases    []flowSnapshotRun `json:"cases"`
+}
+
+type flowSnapshotCase struct {
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input"`
+}
+
+type flowSnapshotRun struct {
+	Name       string         `json:"name"`
+	Input      map[string]any `json:"input"`
+	WorkflowID string         `json:"-"`
+	Status     string         `json:"status"`
+	Steps      map[string]any `json:"steps"`
+	Result     any            `json:"result,omitempty"`
+}
+
+// flowSnapshotDiff is one changed value between a recorded run and a rerun.
+// Step is empty for run-level fields (status and result); Path is relative to
+// the step output or run field, in the dotted form flows steps run --path uses.
+type flowSnapshotDiff struct {
+	Step     string `json:"step,omitempty"`
+	Path     string `json:"path"`
+	Change   string `json:"change"`
+	Expected any    `json:"expected,omitempty"`
+	Actual   any    `json:"actual,omitempty"`
+}
+
+type flowSnapshotOptions struct {
+	slug      string
+	target    string
+	inputFile string
+	dir       string
+	ignore    []string
+	timeout   time.Duration
+	poll      time.Duration
+}
+
+func flowSnapshotPath(dir, slug string) string {
+	return filepath.Join(dir, slug+".runs.json")
+}
+
+// parseFlowSnapshotCases reads one case per JSONL line. A line is either the
+// flow input itself or {"name": ..., "input": {...}}; unnamed cases are
+// numbered case-1, case-2, ... in file order.
+func parseFlowSnapshotCases(b []byte) ([]flowSnapshotCase, error) {
+	var cases []flowSnapshotCase
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err != nil || obj == nil {
+			return nil, fmt.Errorf("line %d: each case must be a JSON object", lineNo)
+		}
+		c := flowSnapshotCase{Input: obj}
+		if input, ok := obj["input"].(map[string]any); ok && flowSnapshotCaseEnvelope(obj) {
+			c.Name = strings.TrimSpace(toString(obj["name"]))
+			c.Input = input
+		}
+		if c.Name == "" {
+			c.Name = "case-" + strconv.Itoa(len(cases)+1)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("line %d: duplicate case name %q", lineNo, c.Name)
+		}
+		seen[c.Name] = true
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("no cases found")
+	}
+	return cases, nil
+}
+
+func flowSnapshotCaseEnvelope(obj map[string]any) bool {
+	for key := range obj {
+		if key != "name" && key != "input" {
+			return false
+		}
+	}
+	return true
+}
+
+func readFlowSnapshotFile(path string) (flowSnapshotFile, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return flowSnapshotFile{}, fmt.Errorf("read snapshot %s: %w (record it with flows snapshot record)", path, err)
+	}
+	var file flowSnapshotFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return flowSnapshotFile{}, fmt.Errorf("parse snapshot %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// runFlowSnapshotCase starts one run, polls runs.get until it reaches a
+// terminal status, and collects per-step outputs from the same inspect
+// payload runs show --full uses.
+func runFlowSnapshotCase(ctx context.Context, app *App, opts flowSnapshotOptions, c flowSnapshotCase) (flowSnapshotRun, error) {
+	run := flowSnapshotRun{Name: c.Name, Input: c.Input}
+	payload := map[string]any{"flowSlug": opts.slug, "target": opts.target, "input": c.Input}
+	out, status, err := runAPICommandWithContext(ctx, app, "flows.run", payload)
+	if err != nil {
+		return run, err
+	}
+	if status >= 400 || !isOK(out) {
+		return run, fmt.Errorf("flows.run: %s", formatAPIError(out))
+	}
+	data := mapStringAny(out["data"])
+	run.WorkflowID = workflowIDFromRunData(data)
+	if run.WorkflowID == "" {
+		return run, errors.New("flows.run returned no workflowId")
+	}
+	installationID := installationIDFromRunData(data)
+
+	deadline := time.Now().Add(opts.timeout)
+	inspect := map[string]any{
+		"workflowId":         run.WorkflowID,
+		"includeSteps":       true,
+		"includeResult":      true,
+		"includeStepResults": true,
+	}
+	for {
+		out, status, effectiveInstallationID, err := runsInspectGetWithCommand(
+			func(requestPayload map[string]any) (map[string]any, int, error) {
+				return runAPICommandWithContext(ctx, app, "runs.get", requestPayload)
+			},
+			run.WorkflowID, installationID, inspect)
+		if err != nil {
+			return run, err
+		}
+		if status >= 400 || !isOK(out) {
+			return run, fmt.Errorf("runs.get %s: %s", run.WorkflowID, formatAPIError(out))
+		}
+		installationID = effectiveInstallationID
+		detail := runFromCommandResponse(out)
+		run.Status = canonicalRunStatus(detail["status"])
+		if isTerminalRunStatus(run.Status) {
+			run.Steps = flowSnapshotStepOutputs(detail)
+			run.Result = firstPresent(detail, "result", "output", "resultPreview", "result-preview")
+			return run, nil
+		}
+		if time.Now().After(deadline) {
+			return run, fmt.Errorf("run %s did not finish within %s (status %s)", run.WorkflowID, opts.timeout, firstNonBlankString(run.Status, "unknown"))
+		}
+		select {
+		case <-ctx.Done():
+			return run, ctx.Err()
+		case <-time.After(opts.poll):
+		}
+	}
+}
+
+func flowSnapshotStepOutputs(run map[string]any) map[string]any {
+	steps := map[string]any{}
+	for _, item := range sliceAny(run["steps"]) {
+		step := mapStringAny(item)
+		id := firstNonBlankString(step["stepId"], step["step-id"], step["id"])
+		if id == "" {
+			continue
+		}
+		steps[id] = firstPresent(step, "output", "result", "resultPreview", "result-preview", "outputPreview", "output-preview")
+	}
+	return steps
+}
+
+var (
+	flowSnapshotTimestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?$`)
+	flowSnapshotUUIDPattern      = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	// flowSnapshotVolatileKeys are compared after lowercasing and dropping
+	// '-' and '_', so workflowId, workflow-id and workflow_id all match.
+	flowSnapshotVolatileKeys = map[string]bool{
+		"workflowid": true, "runid": true, "executionid": true, "requestid": true,
+		"traceid": true, "spanid": true, "idempotencykey": true,
+		"createdat": true, "updatedat": true, "startedat": true, "finishedat": true,
+		"completedat": true, "timestamp": true,
+		"duration": true, "durationms": true, "elapsedms": true, "latencyms": true,
+	}
+)
+
+// normalizeFlowSnapshotRun replaces values that differ between otherwise
+// identical runs: timestamps, UUIDs, and well-known id/time/duration keys.
+// Ignore paths are dotted paths from the run ("steps.tools/fetch.headers",
+// "result.generatedAt"); "*" matches any key or index.
+func normalizeFlowSnapshotRun(run *flowSnapshotRun, ignore []string) {
+	for id, output := range run.Steps {
+		run.Steps[id] = normalizeFlowSnapshotValue(normalizeJSONValue(output))
+	}
+	run.Result = normalizeFlowSnapshotValue(normalizeJSONValue(run.Result))
+	root := map[string]any{"steps": run.Steps, "result": run.Result}
+	for _, path := range ignore {
+		if path = strings.TrimSpace(path); path != "" {
+			ignoreFlowSnapshotPath(root, strings.Split(path, "."))
+		}
+	}
+	run.Result = root["result"]
+}
+
+func normalizeFlowSnapshotValue(v any) any {
+	switch typed := v.(type) {
+	case map[string]any:
+		for key, value := range typed {
+			normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+			if flowSnapshotVolatileKeys[normalized] && value != nil {
+				typed[key] = "<volatile>"
+				continue
+			}
+			typed[key] = normalizeFlowSnapshotValue(value)
+		}
+		return typed
+	case []any:
+		for i, item := range typed {
+			typed[i] = normalizeFlowSnapshotValue(item)
+		}
+		return typed
+	case string:
+		switch {
+		case flowSnapshotTimestampPattern.MatchString(typed):
+			return "<timestamp>"
+		case flowSnapshotUUIDPattern.MatchString(typed):
+			return "<uuid>"
+		}
+	}
+	return v
+}
+
+func ignoreFlowSnapshotPath(container any, parts []string) {
+	head, rest := parts[0], parts[1:]
+	switch typed := container.(type) {
+	case map[string]any:
+		for key, value := range typed {
+			if head != "*" && head != key {
+				continue
+			}
+			if len(rest) == 0 {
+				typed[key] = "<ignored>"
+				continue
+			}
+			ignoreFlowSnapshotPath(value, rest)
+		}
+	case []any:
+		for i, value := range typed {
+			if head != "*" && head != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) == 0 {
+				typed[i] = "<ignored>"
+				continue
+			}
+			ignoreFlowSnapshotPath(value, rest)
+		}
+	}
+}
+
+// diffFlowSnapshotRun compares a recorded run with a rerun step by step.
+func diffFlowSnapshotRun(expected, actual flowSnapshotRun) []flowSnapshotDiff {
+	var diffs []flowSnapshotDiff
+	if expected.Status != actual.Status {
+		diffs = append(diffs, flowSnapshotDiff{Path: "status", Change: "changed", Expected: expected.Status, Actual: actual.Status})
+	}
+	ids := make([]string, 0, len(expected.Steps)+len(actual.Steps))
+	for id := range expected.Steps {
+		ids = append(ids, id)
+	}
+	for id := range actual.Steps {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		want, hadStep := expected.Steps[id]
+		got, hasStep := actual.Steps[id]
+		switch {
+		case !hasStep:
+			diffs = append(diffs, flowSnapshotDiff{Step: id, Change: "removed", Expected: want})
+		case !hadStep:
+			diffs = append(diffs, flowSnapshotDiff{Step: id, Change: "added", Actual: got})
+		default:
+			for _, d := range diffJSONValues("", normalizeJSONValue(want), normalizeJSONValue(got)) {
+				d.Step = id
+				diffs = append(diffs, d)
+			}
+		}
+	}
+	return append(diffs, diffJSONValues("result", normalizeJSONValue(expected.Result), normalizeJSONValue(actual.Result))...)
+}
+
+func diffJSONValues(path string, expected, actual any) []flowSnapshotDiff {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	wantMap, wantIsMap := expected.(map[string]any)
+	gotMap, gotIsMap := actual.(map[string]any)
+	if wantIsMap && gotIsMap {
+		keys := sortedMapKeys(wantMap)
+		for _, key := range sortedMapKeys(gotMap) {
+			if _, ok := wantMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		var diffs []flowSnapshotDiff
+		for _, key := range keys {
+			want, hadKey := wantMap[key]
+			got, hasKey := gotMap[key]
+			switch {
+			case !hasKey:
+				diffs = append(diffs, flowSnapshotDiff{Path: join(key), Change: "removed", Expected: want})
+			case !hadKey:
+				diffs = append(diffs, flowSnapshotDiff{Path: join(key), Change: "added", Actual: got})
+			default:
+				diffs = append(diffs, diffJSONValues(join(key), want, got)...)
+			}
+		}
+		return diffs
+	}
+	wantList, wantIsList := expected.([]any)
+	gotList, gotIsList := actual.([]any)
+	if wantIsList && gotIsList {
+		var diffs []flowSnapshotDiff
+		for i := range max(len(wantList), len(gotList)) {
+			key := join(strconv.Itoa(i))
+			switch {
+			case i >= len(gotList):
+				diffs = append(diffs, flowSnapshotDiff{Path: key, Change: "removed", Expected: wantList[i]})
+			case i >= len(wantList):
+				diffs = append(diffs, flowSnapshotDiff{Path: key, Change: "added", Actual: gotList[i]})
+			default:
+				diffs = append(diffs, diffJSONValues(key, wantList[i], gotList[i])...)
+			}
+		}
+		return diffs
+	}
+	if jsonValuesEqual(expected, actual) {
+		return nil
+	}
+	return []flowSnapshotDiff{{Path: path, Change: "changed", Expected: expected, Actual: actual}}
+}
+
+func newFlowsSnapshotCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Record and verify golden whole-flow runs",
+		Long: strings.TrimSpace(`
+Golden tests for whole flows. record runs every case in a JSONL file to
+completion and stores each step's output in flows/__snapshots__/<slug>.runs.json;
+verify reruns the cases and reports every step whose output changed.
+
+Volatile values (timestamps, UUIDs, and keys such as workflowId, createdAt or
+durationMs) are normalized before storing and comparing. Add --ignore for
+anything else that changes between runs.
+`),
+	}
+	cmd.AddCommand(newFlowsSnapshotRecordCmd(app))
+	cmd.AddCommand(newFlowsSnapshotVerifyCmd(app))
+	return cmd
+}
+
+func addFlowSnapshotFlags(cmd *cobra.Command, opts *flowSnapshotOptions) {
+	cmd.Flags().StringVar(&opts.inputFile, "input-file", "", "JSONL file with one case per line: an input object or {\"name\":...,\"input\":{...}}")
+	cmd.Flags().StringVar(&opts.dir, "dir", defaultFlowSnapshotDir, "Snapshot directory")
+	cmd.Flags().StringArrayVar(&opts.ignore, "ignore", nil, "Dotted path to ignore, e.g. steps.tools/fetch.headers.date or steps.*.id (repeatable)")
+	cmd.Flags().StringVar(&opts.target, "target", "draft", "Run target (draft|live)")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", defaultFlowRunWaitTimeout, "Wait timeout per case")
+	cmd.Flags().DurationVar(&opts.poll, "poll", 250*time.Millisecond, "Poll interval while waiting")
+}
+
+func validateFlowSnapshotOptions(app *App, slug string, opts *flowSnapshotOptions) error {
+	if !isAPIValidFlowSlug(slug) {
+		return fmt.Errorf("invalid flow slug %q", slug)
+	}
+	target, err := normalizeInstallTarget(opts.target)
+	if err != nil {
+		return err
+	}
+	opts.target = target
+	opts.slug = slug
+	if opts.timeout <= 0 || opts.poll <= 0 {
+		return errors.New("--timeout and --poll must be > 0")
+	}
+	return requireAPI(app)
+}
+
+func newFlowsSnapshotRecordCmd(app *App) *cobra.Command {
+	var opts flowSnapshotOptions
+	cmd := &cobra.Command{
+		Use:   "record <flow-slug> --input-file cases.jsonl",
+		Short: "Run cases to completion and store normalized step outputs",
+		Example: strings.TrimSpace(`
+breyta flows snapshot record order-sync --input-file flows/order-sync.cases.jsonl
+breyta flows snapshot record order-sync --input-file cases.jsonl --ignore steps.tools/fetch.headers.date
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFlowSnapshotOptions(app, strings.TrimSpace(args[0]), &opts); err != nil {
+				return writeErr(cmd, err)
+			}
+			if strings.TrimSpace(opts.inputFile) == "" {
+				return writeErr(cmd, errors.New("--input-file is required"))
+			}
+			b, err := readExplicitFile(opts.inputFile)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("read --input-file: %w", err))
+			}
+			cases, err := parseFlowSnapshotCases(b)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("%s: %w", opts.inputFile, err))
+			}
+
+			file := flowSnapshotFile{FlowSlug: opts.slug, Target: opts.target, Ignore: opts.ignore}
+			summaries := make([]map[string]any, 0, len(cases))
+			for _, c := range cases {
+				run, err := runFlowSnapshotCase(cmd.Context(), app, opts, c)
+				if err != nil {
+					return writeErr(cmd, fmt.Errorf("case %s: %w", c.Name, err))
+				}
+				normalizeFlowSnapshotRun(&run, file.Ignore)
+				file.Cases = append(file.Cases, run)
+				summaries = append(summaries, map[string]any{
+					"name":       run.Name,
+					"workflowId": run.WorkflowID,
+					"status":     run.Status,
+					"steps":      len(run.Steps),
+				})
+			}
+			path := flowSnapshotPath(opts.dir, opts.slug)
+			out, err := json.MarshalIndent(file, "", "  ")
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if err := atomicWriteFile(path, append(out, '\n'), publicFileMode); err != nil {
+				return writeErr(cmd, err)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Recorded %d cases to %s\n", len(cases), path)
+			return writeData(cmd, app, map[string]any{"snapshotFile": path}, map[string]any{
+				"flowSlug": opts.slug,
+				"cases":    summaries,
+			})
+		},
+	}
+	addFlowSnapshotFlags(cmd, &opts)
+	return cmd
+}
+
+func newFlowsSnapshotVerifyCmd(app *App) *cobra.Command {
+	var opts flowSnapshotOptions
+	cmd := &cobra.Command{
+		Use:   "verify <flow-slug>",
+		Short: "Rerun recorded cases and diff step outputs against the snapshot",
+		Long: strings.TrimSpace(`
+Rerun the cases stored in flows/__snapshots__/<slug>.runs.json (or the cases in
+--input-file, matched by name) and compare each step's normalized output with
+the recording. Differences are reported per step with the dotted path, the
+change (added, removed or changed), and the expected and actual values. Ignore
+paths saved by record apply in addition to --ignore. The command exits non-zero
+when any case differs or fails to run.
+`),
+		Example: strings.TrimSpace(`
+breyta flows snapshot verify order-sync
+breyta flows snapshot verify order-sync --ignore 'steps.*.generatedAt'
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFlowSnapshotOptions(app, strings.TrimSpace(args[0]), &opts); err != nil {
+				return writeErr(cmd, err)
+			}
+			path := flowSnapshotPath(opts.dir, opts.slug)
+			recorded, err := readFlowSnapshotFile(path)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if !cmd.Flags().Changed("target") && recorded.Target != "" {
+				opts.target = recorded.Target
+			}
+			expected := map[string]flowSnapshotRun{}
+			var cases []flowSnapshotCase
+			for _, run := range recorded.Cases {
+				expected[run.Name] = run
+				cases = append(cases, flowSnapshotCase{Name: run.Name, Input: run.Input})
+			}
+			if strings.TrimSpace(opts.inputFile) != "" {
+				b, err := readExplicitFile(opts.inputFile)
+				if err != nil {
+					return writeErr(cmd, fmt.Errorf("read --input-file: %w", err))
+				}
+				if cases, err = parseFlowSnapshotCases(b); err != nil {
+					return writeErr(cmd, fmt.Errorf("%s: %w", opts.inputFile, err))
+				}
+			}
+			ignore := append(slices.Clone(recorded.Ignore), opts.ignore...)
+
+			results := make([]map[string]any, 0, len(cases))
+			changed, errored := 0, 0
+			for _, c := range cases {
+				result := map[string]any{"name": c.Name}
+				want, ok := expected[c.Name]
+				if !ok {
+					errored++
+					result["passed"] = false
+					result["error"] = "no recorded snapshot for this case; rerun flows snapshot record"
+					results = append(results, result)
+					continue
+				}
+				run, err := runFlowSnapshotCase(cmd.Context(), app, opts, c)
+				if run.WorkflowID != "" {
+					result["workflowId"] = run.WorkflowID
+				}
+				if err != nil {
+					errored++
+					result["passed"] = false
+					result["error"] = err.Error()
+					results = append(results, result)
+					continue
+				}
+				normalizeFlowSnapshotRun(&run, ignore)
+				normalizeFlowSnapshotRun(&want, ignore)
+				diffs := diffFlowSnapshotRun(want, run)
+				if len(diffs) > 0 {
+					changed++
+				}
+				result["status"] = run.Status
+				result["passed"] = len(diffs) == 0
+				result["diffs"] = diffs
+				results = append(results, result)
+			}
+
+			ok := changed == 0 && errored == 0
+			summary := map[string]int{"total": len(results), "passed": len(results) - changed - errored, "changed": changed, "errors": errored}
+			if err := writeData(cmd, app, map[string]any{"summary": summary, "snapshotFile": path}, map[string]any{
+				"flowSlug": opts.slug,
+				"passed":   ok,
+				"cases":    results,
+			}); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%d cases: %d unchanged, %d changed, %d errors\n",
+				summary["total"], summary["passed"], summary["changed"], summary["errors"])
+			if !ok {
+				return guidedCLIErrorForCommand(cmd, fmt.Sprintf("flow snapshot verify failed: %d changed, %d errors", changed, errored), []string{
+					"Rerun breyta flows snapshot record " + opts.slug + " --input-file <cases.jsonl> to accept the new outputs.",
+				})
+			}
+			return nil
+		},
+	}
+	addFlowSnapshotFlags(cmd, &opts)
+	return cmd
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newFlowSnapshotServer(t *testing.T, total *int32, polls *int32) *App {
+	t.Helper()
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		args, _ := body["args"].(map[string]any)
+		switch body["command"] {
+		case "flows.run":
+			n := atomic.AddInt32(polls, 0)
+			input, _ := args["input"].(map[string]any)
+			wf := fmt.Sprintf("wf-%v-%d", input["order"], n)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"workflowId": wf}})
+		case "runs.get":
+			if args["includeStepResults"] != true {
+				t.Errorf("runs.get must request step results: %#v", args)
+			}
+			// Every run reports "running" once before completing.
+			if atomic.AddInt32(polls, 1)%2 == 1 {
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"run": map[string]any{"status": "running"}}})
+				return
+			}
+			wf, _ := args["workflowId"].(string)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"run": map[string]any{
+				"workflowId": wf,
+				"status":     "COMPLETED",
+				"steps": []any{
+					map[string]any{"stepId": "fetch", "durationMs": atomic.LoadInt32(polls), "output": map[string]any{
+						"id":        wf,
+						"fetchedAt": fmt.Sprintf("2026-10-18T10:00:%02dZ", atomic.LoadInt32(polls)%60),
+						"requestId": "req-" + wf,
+						"trace":     "0b9c5e2a-4a8e-4d1c-9a55-0f3d1c2b7e6f",
+						"total":     atomic.LoadInt32(total),
+					}},
+					map[string]any{"stepId": "notify", "output": map[string]any{"sent": true}},
+				},
+				"result": map[string]any{"ok": true},
+			}}})
+		default:
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": map[string]any{"message": "unexpected command"}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+}
+
+func runFlowsSnapshot(t *testing.T, app *App, args ...string) (map[string]any, string, error) {
+	t.Helper()
+	cmd := newFlowsSnapshotCmd(app)
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	var body map[string]any
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+		t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+	}
+	return body, stderr.String(), err
+}
+
+func TestFlowsSnapshotRecordAndVerifyReportsChangedSteps(t *testing.T) {
+	root := t.TempDir()
+	casesPath := filepath.Join(root, "cases.jsonl")
+	cases := `{"order": "o-1"}
+
+{"name": "second", "input": {"order": "o-2"}}
+`
+	if err := os.WriteFile(casesPath, []byte(cases), 0o644); err != nil {
+		t.Fatalf("write cases: %v", err)
+	}
+	dir := filepath.Join(root, "flows", "__snapshots__")
+	var total, polls int32 = 3, 0
+	app := newFlowSnapshotServer(t, &total, &polls)
+
+	body, progress, err := runFlowsSnapshot(t, app, "record", "order-sync", "--input-file", casesPath, "--dir", dir, "--poll", "1ms", "--ignore", "steps.notify.sent")
+	if err != nil {
+		t.Fatalf("record: %v\n%s", err, mustJSON(t, body))
+	}
+	if !strings.Contains(progress, "Recorded 2 cases to ") {
+		t.Fatalf("unexpected progress: %s", progress)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "order-sync.runs.json"))
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	var file flowSnapshotFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		t.Fatalf("parse snapshot: %v", err)
+	}
+	if len(file.Cases) != 2 || file.Cases[0].Name != "case-1" || file.Cases[1].Name != "second" {
+		t.Fatalf("unexpected cases: %s", b)
+	}
+	fetch := file.Cases[0].Steps["fetch"].(map[string]any)
+	want := map[string]any{"id": "wf-o-1-0", "fetchedAt": "<timestamp>", "requestId": "<volatile>", "trace": "<uuid>", "total": float64(3)}
+	if !jsonValuesEqual(fetch, want) || file.Cases[0].Status != "completed" {
+		t.Fatalf("unexpected normalized output: %s", b)
+	}
+	if file.Cases[0].Steps["notify"].(map[string]any)["sent"] != "<ignored>" {
+		t.Fatalf("--ignore path should be replaced: %s", b)
+	}
+
+	// Workflow ids, timestamps and durations change between runs but are
+	// volatile; "id" carries the workflow id too, so ignore it at verify time.
+	body, _, err = runFlowsSnapshot(t, app, "verify", "order-sync", "--dir", dir, "--poll", "1ms", "--ignore", "steps.*.id")
+	if err != nil {
+		t.Fatalf("unchanged outputs should verify: %v\n%s", err, mustJSON(t, body))
+	}
+
+	atomic.StoreInt32(&total, 4)
+	body, summaryLine, err := runFlowsSnapshot(t, app, "verify", "order-sync", "--dir", dir, "--poll", "1ms", "--ignore", "steps.*.id")
+	if err == nil {
+		t.Fatalf("expected a diff failure: %s", mustJSON(t, body))
+	}
+	if !strings.Contains(summaryLine, "2 cases: 0 unchanged, 2 changed, 0 errors") {
+		t.Fatalf("unexpected summary line: %s", summaryLine)
+	}
+	results := body["data"].(map[string]any)["cases"].([]any)
+	diffs := results[0].(map[string]any)["diffs"].([]any)
+	wantDiff := map[string]any{"step": "fetch", "path": "total", "change": "changed", "expected": float64(3), "actual": float64(4)}
+	if len(diffs) != 1 || !jsonValuesEqual(diffs[0], wantDiff) {
+		t.Fatalf("unexpected diffs: %s", mustJSON(t, diffs))
+	}
+}
+
+func TestParseFlowSnapshotCasesRejectsDuplicateNames(t *testing.T) {
+	_, err := parseFlowSnapshotCases([]byte(`{"name":"a","input":{}}` + "\n" + `{"name":"a","input":{"x":1}}`))
+	if err == nil || err.Error() != `line 2: duplicate case name "a"` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
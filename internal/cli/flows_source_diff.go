@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+const flowSourceDiffContextLines = 3
+
+// canonicalClojureFormText renders a form as a single line with comments
+// dropped, commas treated as whitespace, and runs of whitespace collapsed.
+// Strings and character literals are copied verbatim so two forms compare
+// equal exactly when they differ only in layout.
+func canonicalClojureFormText(src string) string {
+	var out strings.Builder
+	pendingSpace := false
+	lastOpen := true
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case isClojureWhitespaceOrComma(ch):
+			pendingSpace = true
+			i++
+			continue
+		case ch == ';':
+			i = readCommentEnd(src, i)
+			pendingSpace = true
+			continue
+		}
+
+		closing := ch == ')' || ch == ']' || ch == '}'
+		if pendingSpace && !lastOpen && !closing {
+			out.WriteByte(' ')
+		}
+		pendingSpace = false
+
+		switch {
+		case ch == '"':
+			end, err := readClojureRegexTokenEnd(src, i)
+			if err != nil {
+				out.WriteString(src[i:])
+				return out.String()
+			}
+			out.WriteString(src[i:end])
+			i = end
+			lastOpen = false
+		case ch == '\\':
+			end, err := readClojureCharLiteralEnd(src, i)
+			if err != nil || end <= i {
+				end = i + 1
+			}
+			out.WriteString(src[i:end])
+			i = end
+			lastOpen = false
+		case ch == '(' || ch == '[' || ch == '{':
+			out.WriteByte(ch)
+			i++
+			lastOpen = true
+		case closing:
+			out.WriteByte(ch)
+			i++
+			lastOpen = false
+		case ch == '#' || ch == '\'' || ch == '`' || ch == '~' || ch == '@' || ch == '^':
+			// Reader prefixes bind to the following form; keep them attached.
+			out.WriteByte(ch)
+			i++
+			if ch == '#' && i < len(src) && src[i] == '_' {
+				out.WriteByte('_')
+				i++
+			}
+			lastOpen = true
+		default:
+			end := readClojureTokenEnd(src, i)
+			if end <= i {
+				end = i + 1
+			}
+			out.WriteString(src[i:end])
+			i = end
+			lastOpen = false
+		}
+	}
+	return out.String()
+}
+
+// clojureFormsEquivalent reports whether two source fragments differ only in
+// whitespace, commas, and comments.
+func clojureFormsEquivalent(a, b string) bool {
+	return canonicalClojureFormText(a) == canonicalClojureFormText(b)
+}
+
+// normalizedClojureSourceLines splits a form into lines for display diffs:
+// trailing comments, blank lines, and indentation changes are dropped so the
+// resulting hunks focus on content edits.
+func normalizedClojureSourceLines(src string) []string {
+	var lines []string
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(stripClojureLineComment(line))
+		if line == "" {
+			continue
+		}
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return lines
+}
+
+func stripClojureLineComment(line string) string {
+	inString := false
+	escaped := false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+		switch ch {
+		case '\\':
+			i++
+		case '"':
+			inString = true
+		case ';':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+type sourceDiffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// diffSourceLines computes a minimal line edit script with a classic LCS
+// table. Flow forms are small enough that the quadratic table is cheap.
+func diffSourceLines(a, b []string) []sourceDiffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ops := make([]sourceDiffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, sourceDiffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, sourceDiffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, sourceDiffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, sourceDiffOp{kind: '-', line: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, sourceDiffOp{kind: '+', line: b[j]})
+	}
+	return ops
+}
+
+// unifiedSourceDiff renders a unified diff between two line slices. It returns
+// an empty string when the inputs are identical.
+func unifiedSourceDiff(fromLabel, toLabel string, a, b []string) string {
+	ops := diffSourceLines(a, b)
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+		hunkStart := start - flowSourceDiffContextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := start
+		for idx, quiet := start, 0; idx < len(ops); idx++ {
+			if ops[idx].kind == ' ' {
+				quiet++
+				if quiet > 2*flowSourceDiffContextLines {
+					break
+				}
+				continue
+			}
+			quiet = 0
+			hunkEnd = idx + 1
+		}
+		hunkEnd += flowSourceDiffContextLines
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		aLine, bLine := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aLine--
+		}
+		if bCount == 0 {
+			bLine--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, op := range ops[hunkStart:hunkEnd] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// unifiedSourceFileDiff renders a unified diff between two whole files,
+// preserving exact lines so the output can be applied with patch(1).
+func unifiedSourceFileDiff(fromLabel, toLabel, before, after string) string {
+	return unifiedSourceDiff(fromLabel, toLabel, splitSourceDiffLines(before), splitSourceDiffLines(after))
+}
+
+func splitSourceDiffLines(src string) []string {
+	if src == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+}
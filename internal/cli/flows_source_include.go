@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -264,23 +265,103 @@ func readClojureCharLiteralEnd(src string, start int) (int, error) {
 }
 
 func expandFlowSourceIncludes(sourcePath, flowLiteral string) (string, error) {
+	expanded, _, err := expandFlowSourceIncludesWithMap(sourcePath, flowLiteral)
+	return expanded, err
+}
+
+// flowSourceSegment maps a byte range of expanded flow source back to the file
+// it was copied from. Offset is the byte in Path that corresponds to Start.
+type flowSourceSegment struct {
+	Start  int
+	End    int
+	Path   string
+	Offset int
+}
+
+// flowSourceMap maps expanded flow source offsets back to original files.
+type flowSourceMap struct {
+	Segments []flowSourceSegment
+}
+
+// Locate returns the original file and byte offset for an expanded offset.
+func (m flowSourceMap) Locate(offset int) (string, int, bool) {
+	for _, segment := range m.Segments {
+		if offset >= segment.Start && offset < segment.End {
+			return segment.Path, segment.Offset + offset - segment.Start, true
+		}
+	}
+	if n := len(m.Segments); n > 0 && offset == m.Segments[n-1].End {
+		last := m.Segments[n-1]
+		return last.Path, last.Offset + offset - last.Start, true
+	}
+	return "", 0, false
+}
+
+// flowIncludeExpansion is the expanded text and source map of one include
+// file, cached so repeated includes are read once.
+type flowIncludeExpansion struct {
+	text     string
+	segments []flowSourceSegment
+}
+
+// flowIncludeCache holds include files already expanded, by absolute path.
+// observe, when set, is called for every include form expansion reaches, in
+// source order, before the included file itself is expanded.
+type flowIncludeCache struct {
+	files   map[string]flowIncludeExpansion
+	observe func(flowIncludeEvent)
+}
+
+// flowIncludeEvent describes one #flow/include form. Resolved is empty when
+// the path could not be resolved; Depth is 1 for includes in the root file.
+type flowIncludeEvent struct {
+	From     string
+	Offset   int
+	Path     string
+	Resolved string
+	Depth    int
+	CacheHit bool
+	Cycle    bool
+	Err      error
+}
+
+// expandFlowSourceIncludesWithMap expands includes like
+// expandFlowSourceIncludes and also returns a source map from expanded
+// offsets back to the root file and every included file.
+func expandFlowSourceIncludesWithMap(sourcePath, flowLiteral string) (string, flowSourceMap, error) {
+	return expandFlowSourceIncludesObserved(sourcePath, flowLiteral, nil)
+}
+
+// expandFlowSourceIncludesObserved is expandFlowSourceIncludesWithMap with an
+// observer for each include form, used to inspect include resolution.
+func expandFlowSourceIncludesObserved(sourcePath, flowLiteral string, observe func(flowIncludeEvent)) (string, flowSourceMap, error) {
 	baseDir := "."
 	if trimmed := strings.TrimSpace(sourcePath); trimmed != "" {
 		baseDir = filepath.Dir(trimmed)
 	}
 	baseDir, err := filepath.Abs(baseDir)
 	if err != nil {
-		return "", fmt.Errorf("resolve flow source base dir: %w", err)
+		return "", flowSourceMap{}, fmt.Errorf("resolve flow source base dir: %w", err)
 	}
 	rootDir, err := filepath.EvalSymlinks(baseDir)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			return "", fmt.Errorf("resolve flow source root dir: %w", err)
+			return "", flowSourceMap{}, fmt.Errorf("resolve flow source root dir: %w", err)
 		}
 		rootDir = baseDir
 	}
-	cache := map[string]string{}
-	return expandFlowSourceIncludesFrom(baseDir, rootDir, flowLiteral, nil, cache)
+	rootPath := strings.TrimSpace(sourcePath)
+	if rootPath != "" {
+		if abs, absErr := filepath.Abs(rootPath); absErr == nil {
+			rootPath = abs
+		}
+	}
+	cache := &flowIncludeCache{files: map[string]flowIncludeExpansion{}, observe: observe}
+	expansion, err := expandFlowSourceIncludesFrom(baseDir, rootDir, rootPath, flowLiteral, nil, cache)
+	if err != nil {
+		return "", flowSourceMap{}, err
+	}
+	return expansion.text, flowSourceMap{Segments: expansion.segments}, nil
 }
 
 func pathWithinRoot(rootDir, candidate string) bool {
@@ -313,8 +394,17 @@ func resolveFlowIncludePath(baseDir, rootDir, includePath string) (string, error
 	return includeReal, nil
 }
 
-func expandFlowSourceIncludesFrom(baseDir, rootDir, src string, stack []string, cache map[string]string) (string, error) {
+func expandFlowSourceIncludesFrom(baseDir, rootDir, srcPath, src string, stack []string, cache *flowIncludeCache) (flowIncludeExpansion, error) {
 	var out strings.Builder
+	var segments []flowSourceSegment
+	runStart, outRunStart := 0, 0
+	flushRun := func(srcEnd int) {
+		if out.Len() > outRunStart {
+			segments = append(segments, flowSourceSegment{Start: outRunStart, End: out.Len(), Path: srcPath, Offset: runStart})
+		}
+		runStart = srcEnd
+		outRunStart = out.Len()
+	}
 	inString := false
 	inComment := false
 	escapeNext := false
@@ -362,7 +452,7 @@ func expandFlowSourceIncludesFrom(baseDir, rootDir, src string, stack []string,
 			}
 			next, err := readClojureFormEnd(src, i)
 			if err != nil {
-				return "", fmt.Errorf("parse discarded form near byte %d: %w", i, err)
+				return flowIncludeExpansion{}, fmt.Errorf("parse discarded form near byte %d: %w", i, err)
 			}
 			out.WriteString(src[i:next])
 			i = next
@@ -381,22 +471,37 @@ func expandFlowSourceIncludesFrom(baseDir, rootDir, src string, stack []string,
 			j := i + len(flowIncludeTag)
 			j = skipClojureWhitespaceCommaAndComments(src, j)
 			if j >= len(src) || src[j] != '"' {
-				return "", fmt.Errorf("malformed %s form near byte %d: expected string path", flowIncludeTag, i)
+				return flowIncludeExpansion{}, fmt.Errorf("malformed %s form near byte %d: expected string path", flowIncludeTag, i)
 			}
 			token, includePath, next, err := readClojureStringToken(src, j)
 			if err != nil {
-				return "", fmt.Errorf("parse %s path near byte %d: %w", flowIncludeTag, i, err)
+				return flowIncludeExpansion{}, fmt.Errorf("parse %s path near byte %d: %w", flowIncludeTag, i, err)
 			}
 			_ = token
 			includeAbs, err := resolveFlowIncludePath(baseDir, rootDir, includePath)
+			if cache.observe != nil {
+				event := flowIncludeEvent{From: srcPath, Offset: i, Path: includePath, Resolved: includeAbs, Depth: len(stack) + 1, Err: err}
+				if err == nil {
+					_, event.CacheHit = cache.files[includeAbs]
+					event.Cycle = includeAbs == srcPath || slices.Contains(stack, includeAbs)
+				}
+				cache.observe(event)
+			}
 			if err != nil {
-				return "", err
+				return flowIncludeExpansion{}, err
 			}
 			expanded, err := readAndExpandFlowInclude(includeAbs, rootDir, stack, cache)
 			if err != nil {
-				return "", err
+				return flowIncludeExpansion{}, err
+			}
+			flushRun(i)
+			for _, segment := range expanded.segments {
+				segment.Start += out.Len()
+				segment.End += out.Len()
+				segments = append(segments, segment)
 			}
-			out.WriteString(expanded)
+			out.WriteString(expanded.text)
+			runStart, outRunStart = next, out.Len()
 			i = next
 			continue
 		}
@@ -404,7 +509,7 @@ func expandFlowSourceIncludesFrom(baseDir, rootDir, src string, stack []string,
 		if ch == '\\' {
 			next, err := readClojureCharLiteralEnd(src, i)
 			if err != nil {
-				return "", fmt.Errorf("parse character literal near byte %d: %w", i, err)
+				return flowIncludeExpansion{}, fmt.Errorf("parse character literal near byte %d: %w", i, err)
 			}
 			out.WriteString(src[i:next])
 			i = next
@@ -421,32 +526,33 @@ func expandFlowSourceIncludesFrom(baseDir, rootDir, src string, stack []string,
 		}
 	}
 
-	return out.String(), nil
+	flushRun(len(src))
+	return flowIncludeExpansion{text: out.String(), segments: segments}, nil
 }
 
-func readAndExpandFlowInclude(path, rootDir string, stack []string, cache map[string]string) (string, error) {
+func readAndExpandFlowInclude(path, rootDir string, stack []string, cache *flowIncludeCache) (flowIncludeExpansion, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return "", fmt.Errorf("resolve include path %q: %w", path, err)
+		return flowIncludeExpansion{}, fmt.Errorf("resolve include path %q: %w", path, err)
 	}
-	if expanded, ok := cache[absPath]; ok {
+	if expanded, ok := cache.files[absPath]; ok {
 		return expanded, nil
 	}
 	for _, active := range stack {
 		if active == absPath {
 			chain := append(append([]string{}, stack...), absPath)
-			return "", fmt.Errorf("flow source include cycle: %s", strings.Join(chain, " -> "))
+			return flowIncludeExpansion{}, fmt.Errorf("flow source include cycle: %s", strings.Join(chain, " -> "))
 		}
 	}
 	b, err := readExplicitFile(absPath)
 	if err != nil {
-		return "", fmt.Errorf("read flow source include %q: %w", absPath, err)
+		return flowIncludeExpansion{}, fmt.Errorf("read flow source include %q: %w", absPath, err)
 	}
-	expanded, err := expandFlowSourceIncludesFrom(filepath.Dir(absPath), rootDir, string(b), append(stack, absPath), cache)
+	expanded, err := expandFlowSourceIncludesFrom(filepath.Dir(absPath), rootDir, absPath, string(b), append(stack, absPath), cache)
 	if err != nil {
-		return "", err
+		return flowIncludeExpansion{}, err
 	}
-	cache[absPath] = expanded
+	cache.files[absPath] = expanded
 	return expanded, nil
 }
 
@@ -0,0 +1,682 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"olympos.io/encoding/edn"
+)
+
+// flowStepTestSuite is a parsed flows/<slug>.tests.edn file:
+//
+//	{:timeout "30s"
+//	 :steps {:tools/fetch-order
+//	         [{:name "known order"
+//	           :params {:order-id "o-1"}
+//	           :timeout "10s"
+//	           :expect {:equals {...}
+//	                    :paths [{:path "status" :equals "open"}
+//	                            {:path "items" :contains {:sku "A"}}
+//	                            {:path "id" :matches "^o-"}]
+//	                    :schema [:map [:id :string]]
+//	                    :snapshot true}}]}}
+//
+// A case without :expect is a snapshot case. Steps are :steps entries by
+// qualified id; an inline flow/step id such as :collect-ids names its step
+// type with :type in each case.
+type flowStepTestSuite struct {
+	Timeout time.Duration
+	Cases   []flowStepTestCase
+}
+
+type flowStepTestCase struct {
+	Step string
+	// Type is the step type of an inline flow/step id; empty for :steps
+	// entries.
+	Type    string
+	Name    string
+	Params  map[string]any
+	Timeout time.Duration
+	Expect  flowStepTestExpect
+}
+
+type flowStepTestExpect struct {
+	HasEquals bool
+	Equals    any
+	Paths     []flowStepTestPathMatcher
+	// Schema is kept as raw EDN so Malli keywords survive.
+	Schema   any
+	Snapshot bool
+}
+
+type flowStepTestPathMatcher struct {
+	Path  string
+	Op    string
+	Value any
+	re    *regexp.Regexp
+}
+
+// flowStepTestResult is one case row in the flows test report.
+type flowStepTestResult struct {
+	Step       string   `json:"step"`
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	DurationMs int64    `json:"durationMs"`
+	Failures   []string `json:"failures,omitempty"`
+	Error      string   `json:"error,omitempty"`
+
+	actual any
+}
+
+func defaultFlowStepTestsPath(flowPath, slug string) string {
+	return filepath.Join(filepath.Dir(flowPath), slug+".tests.edn")
+}
+
+func flowStepSnapshotsPath(testsPath, slug string) string {
+	return filepath.Join(filepath.Dir(testsPath), "__snapshots__", slug+".steps.json")
+}
+
+func flowStepSnapshotKey(c flowStepTestCase) string {
+	return c.Step + " " + c.Name
+}
+
+func parseFlowStepTestSuite(b []byte) (flowStepTestSuite, error) {
+	var raw any
+	if err := edn.Unmarshal(b, &raw); err != nil {
+		return flowStepTestSuite{}, fmt.Errorf("invalid edn: %w", err)
+	}
+	top, ok := raw.(map[any]any)
+	if !ok {
+		return flowStepTestSuite{}, errors.New("tests file must be a map with :steps")
+	}
+	var suite flowStepTestSuite
+	for key := range top {
+		switch key {
+		case edn.Keyword("timeout"), edn.Keyword("steps"):
+		default:
+			return flowStepTestSuite{}, fmt.Errorf("unknown top-level key %v (use :timeout and :steps)", key)
+		}
+	}
+	timeout, err := flowStepTestDuration(top[edn.Keyword("timeout")])
+	if err != nil {
+		return flowStepTestSuite{}, fmt.Errorf(":timeout: %w", err)
+	}
+	suite.Timeout = timeout
+	steps, ok := top[edn.Keyword("steps")].(map[any]any)
+	if !ok || len(steps) == 0 {
+		return flowStepTestSuite{}, errors.New(":steps must be a non-empty map of step id to a vector of cases")
+	}
+	for stepKey, casesAny := range steps {
+		step, ok := ednKeyToString(stepKey)
+		if !ok || !(localStepIDValid(step) || validFlowLintSafeIdentifier(step)) {
+			return flowStepTestSuite{}, fmt.Errorf("invalid step id %v in :steps (use qualified ids like :tools/fetch-order)", stepKey)
+		}
+		cases, ok := casesAny.([]any)
+		if !ok {
+			return flowStepTestSuite{}, fmt.Errorf("step %s: cases must be a vector", step)
+		}
+		seen := map[string]bool{}
+		for i, caseAny := range cases {
+			c, err := parseFlowStepTestCase(step, i, caseAny)
+			if err != nil {
+				return flowStepTestSuite{}, fmt.Errorf("step %s case %d: %w", step, i+1, err)
+			}
+			if c.Type == "" && !localStepIDValid(step) {
+				return flowStepTestSuite{}, fmt.Errorf("step %s case %d: inline flow/step ids need a :type such as :function (or use a qualified :steps id like :tools/fetch-order)", step, i+1)
+			}
+			if seen[c.Name] {
+				return flowStepTestSuite{}, fmt.Errorf("step %s: duplicate case name %q", step, c.Name)
+			}
+			seen[c.Name] = true
+			suite.Cases = append(suite.Cases, c)
+		}
+	}
+	// Maps have no order; keep runs and reports stable by step id, then
+	// file order within a step.
+	sort.SliceStable(suite.Cases, func(i, j int) bool { return suite.Cases[i].Step < suite.Cases[j].Step })
+	return suite, nil
+}
+
+func parseFlowStepTestCase(step string, index int, raw any) (flowStepTestCase, error) {
+	m, ok := raw.(map[any]any)
+	if !ok {
+		return flowStepTestCase{}, errors.New("case must be a map")
+	}
+	c := flowStepTestCase{Step: step, Name: fmt.Sprintf("case-%d", index+1), Params: map[string]any{}}
+	for key, value := range m {
+		switch key {
+		case edn.Keyword("name"):
+			name, ok := value.(string)
+			if !ok || strings.TrimSpace(name) == "" {
+				return flowStepTestCase{}, errors.New(":name must be a non-empty string")
+			}
+			c.Name = strings.TrimSpace(name)
+		case edn.Keyword("type"):
+			stepType, ok := ednKeyToString(value)
+			if !ok || !localStepTypeValid(stepType) {
+				return flowStepTestCase{}, errors.New(":type must be a step type keyword such as :function")
+			}
+			c.Type = strings.TrimPrefix(stepType, ":")
+		case edn.Keyword("params"):
+			converted, err := ednToJSONValue(value)
+			if err != nil {
+				return flowStepTestCase{}, fmt.Errorf(":params: %w", err)
+			}
+			params, ok := converted.(map[string]any)
+			if !ok {
+				return flowStepTestCase{}, errors.New(":params must be a map")
+			}
+			c.Params = params
+		case edn.Keyword("timeout"):
+			timeout, err := flowStepTestDuration(value)
+			if err != nil {
+				return flowStepTestCase{}, fmt.Errorf(":timeout: %w", err)
+			}
+			c.Timeout = timeout
+		case edn.Keyword("expect"):
+			expect, err := parseFlowStepTestExpect(value)
+			if err != nil {
+				return flowStepTestCase{}, fmt.Errorf(":expect: %w", err)
+			}
+			c.Expect = expect
+		default:
+			return flowStepTestCase{}, fmt.Errorf("unknown key %v (use :name, :type, :params, :timeout, :expect)", key)
+		}
+	}
+	if _, hasExpect := m[edn.Keyword("expect")]; !hasExpect {
+		c.Expect.Snapshot = true
+	}
+	return c, nil
+}
+
+func parseFlowStepTestExpect(raw any) (flowStepTestExpect, error) {
+	m, ok := raw.(map[any]any)
+	if !ok {
+		return flowStepTestExpect{}, errors.New("must be a map")
+	}
+	var expect flowStepTestExpect
+	for key, value := range m {
+		switch key {
+		case edn.Keyword("equals"):
+			converted, err := ednToJSONValue(value)
+			if err != nil {
+				return flowStepTestExpect{}, fmt.Errorf(":equals: %w", err)
+			}
+			expect.HasEquals = true
+			expect.Equals = converted
+		case edn.Keyword("paths"):
+			items, ok := value.([]any)
+			if !ok {
+				return flowStepTestExpect{}, errors.New(":paths must be a vector of matchers")
+			}
+			for i, item := range items {
+				matcher, err := parseFlowStepTestPathMatcher(item)
+				if err != nil {
+					return flowStepTestExpect{}, fmt.Errorf(":paths %d: %w", i+1, err)
+				}
+				expect.Paths = append(expect.Paths, matcher)
+			}
+		case edn.Keyword("schema"):
+			// Surface unsupported schema syntax at parse time, not per run.
+			if _, err := validateMalliValue(value, nil, ""); err != nil {
+				return flowStepTestExpect{}, fmt.Errorf(":schema: %w", err)
+			}
+			expect.Schema = value
+		case edn.Keyword("snapshot"):
+			snapshot, ok := value.(bool)
+			if !ok {
+				return flowStepTestExpect{}, errors.New(":snapshot must be true or false")
+			}
+			expect.Snapshot = snapshot
+		default:
+			return flowStepTestExpect{}, fmt.Errorf("unknown matcher %v (use :equals, :paths, :schema, :snapshot)", key)
+		}
+	}
+	return expect, nil
+}
+
+func parseFlowStepTestPathMatcher(raw any) (flowStepTestPathMatcher, error) {
+	m, ok := raw.(map[any]any)
+	if !ok {
+		return flowStepTestPathMatcher{}, errors.New("matcher must be a map")
+	}
+	var matcher flowStepTestPathMatcher
+	for key, value := range m {
+		name, _ := ednKeyToString(key)
+		switch name {
+		case "path":
+			switch path := value.(type) {
+			case string:
+				matcher.Path = path
+			case []any:
+				parts := make([]string, 0, len(path))
+				for _, part := range path {
+					converted, err := ednToJSONValue(part)
+					if err != nil {
+						return flowStepTestPathMatcher{}, err
+					}
+					parts = append(parts, strings.TrimSuffix(fmt.Sprint(converted), ".0"))
+				}
+				matcher.Path = strings.Join(parts, ".")
+			default:
+				return flowStepTestPathMatcher{}, errors.New(":path must be a string like \"body.items.0.id\" or a vector")
+			}
+		case "equals", "contains", "matches":
+			if matcher.Op != "" {
+				return flowStepTestPathMatcher{}, errors.New("use exactly one of :equals, :contains, or :matches")
+			}
+			matcher.Op = name
+			converted, err := ednToJSONValue(value)
+			if err != nil {
+				return flowStepTestPathMatcher{}, fmt.Errorf(":%s: %w", name, err)
+			}
+			matcher.Value = converted
+		default:
+			return flowStepTestPathMatcher{}, fmt.Errorf("unknown key %v", key)
+		}
+	}
+	if strings.TrimSpace(matcher.Path) == "" {
+		return flowStepTestPathMatcher{}, errors.New("missing :path")
+	}
+	if matcher.Op == "" {
+		return flowStepTestPathMatcher{}, errors.New("use exactly one of :equals, :contains, or :matches")
+	}
+	if matcher.Op == "matches" {
+		pattern, ok := matcher.Value.(string)
+		if !ok {
+			return flowStepTestPathMatcher{}, errors.New(":matches must be a regex string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return flowStepTestPathMatcher{}, fmt.Errorf(":matches: %w", err)
+		}
+		matcher.re = re
+	}
+	return matcher, nil
+}
+
+func flowStepTestDuration(v any) (time.Duration, error) {
+	switch typed := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(typed))
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid duration %q (use values like \"30s\")", typed)
+		}
+		return d, nil
+	case int64:
+		if typed <= 0 {
+			return 0, errors.New("must be > 0 seconds")
+		}
+		return time.Duration(typed) * time.Second, nil
+	}
+	return 0, errors.New("must be a duration string like \"30s\" or a number of seconds")
+}
+
+// checkFlowStepTestCase applies the case matchers to the step result and
+// returns one message per failed expectation. Snapshot comparison happens
+// separately because it depends on --update.
+func checkFlowStepTestCase(c flowStepTestCase, actual any) []string {
+	var failures []string
+	if c.Expect.HasEquals && !jsonValuesEqual(actual, c.Expect.Equals) {
+		failures = append(failures, fmt.Sprintf("equals: expected %s, got %s", compactJSONString(c.Expect.Equals), compactJSONString(actual)))
+	}
+	for _, matcher := range c.Expect.Paths {
+		got, _, found := selectStepResultPath(actual, matcher.Path)
+		if !found {
+			failures = append(failures, fmt.Sprintf("%s: path not found", matcher.Path))
+			continue
+		}
+		switch matcher.Op {
+		case "equals":
+			if !jsonValuesEqual(got, matcher.Value) {
+				failures = append(failures, fmt.Sprintf("%s: expected %s, got %s", matcher.Path, compactJSONString(matcher.Value), compactJSONString(got)))
+			}
+		case "contains":
+			if !jsonValueContains(normalizeJSONValue(got), normalizeJSONValue(matcher.Value)) {
+				failures = append(failures, fmt.Sprintf("%s: expected to contain %s, got %s", matcher.Path, compactJSONString(matcher.Value), compactJSONString(got)))
+			}
+		case "matches":
+			s, ok := got.(string)
+			if !ok || !matcher.re.MatchString(s) {
+				failures = append(failures, fmt.Sprintf("%s: expected to match /%s/, got %s", matcher.Path, matcher.re.String(), compactJSONString(got)))
+			}
+		}
+	}
+	if c.Expect.Schema != nil {
+		more, err := validateMalliValue(c.Expect.Schema, normalizeJSONValue(actual), "")
+		if err != nil {
+			failures = append(failures, "schema: "+err.Error())
+		}
+		for _, failure := range more {
+			failures = append(failures, "schema: "+failure)
+		}
+	}
+	return failures
+}
+
+type flowStepTestRunOptions struct {
+	slug      string
+	flowPath  string
+	source    string
+	jobs      int
+	timeout   time.Duration
+	profileID string
+}
+
+// runFlowStepTests runs every case through steps.run with at most opts.jobs
+// requests in flight and returns the rows in suite order.
+func runFlowStepTests(cmd *cobra.Command, app *App, suite flowStepTestSuite, opts flowStepTestRunOptions) []flowStepTestResult {
+	results := make([]flowStepTestResult, len(suite.Cases))
+	jobs := max(opts.jobs, 1)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(suite.Cases); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				results[idx] = runFlowStepTestCase(cmd, app, suite, suite.Cases[idx], opts)
+			}
+		}()
+	}
+	for idx := range suite.Cases {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func runFlowStepTestCase(cmd *cobra.Command, app *App, suite flowStepTestSuite, c flowStepTestCase, opts flowStepTestRunOptions) flowStepTestResult {
+	result := flowStepTestResult{Step: c.Step, Name: c.Name}
+	timeout := opts.timeout
+	if suite.Timeout > 0 {
+		timeout = suite.Timeout
+	}
+	if c.Timeout > 0 {
+		timeout = c.Timeout
+	}
+	started := time.Now()
+	out, status, err := runLocalFlowStepOfType(cmd, app, opts.slug, opts.flowPath, opts.source, c.Type, c.Step, c.Params, "", opts.profileID, timeout)
+	result.DurationMs = time.Since(started).Milliseconds()
+	switch {
+	case err != nil:
+		result.Status, result.Error = "error", err.Error()
+		return result
+	case out == nil:
+		result.Status, result.Error = "error", fmt.Sprintf("step run returned no API response (status=%d)", status)
+		return result
+	case status >= 400 || !isOK(out):
+		result.Status, result.Error = "error", formatAPIError(out)
+		return result
+	}
+	actual, _ := stepsRunResultValue(out)
+	result.actual = normalizeJSONValue(actual)
+	result.Failures = checkFlowStepTestCase(c, result.actual)
+	result.Status = "passed"
+	if len(result.Failures) > 0 {
+		result.Status = "failed"
+	}
+	return result
+}
+
+// applyFlowStepSnapshots compares snapshot cases with the stored outputs,
+// or records them with update. It reports whether the file changed.
+func applyFlowStepSnapshots(suite flowStepTestSuite, results []flowStepTestResult, snapshots map[string]any, update bool) bool {
+	changed := false
+	for i, c := range suite.Cases {
+		result := &results[i]
+		if !c.Expect.Snapshot || result.Status == "error" {
+			continue
+		}
+		key := flowStepSnapshotKey(c)
+		stored, exists := snapshots[key]
+		switch {
+		case update && (!exists || !jsonValuesEqual(stored, result.actual)):
+			snapshots[key] = result.actual
+			changed = true
+			if result.Status == "passed" {
+				result.Status = "updated"
+			}
+		case !exists:
+			result.Failures = append(result.Failures, "snapshot: none recorded; rerun with --update to record it")
+			result.Status = "failed"
+		case !jsonValuesEqual(stored, result.actual):
+			result.Failures = append(result.Failures, fmt.Sprintf("snapshot: expected %s, got %s", compactJSONString(stored), compactJSONString(result.actual)))
+			result.Status = "failed"
+		}
+	}
+	return changed
+}
+
+func readFlowStepSnapshots(path string) (map[string]any, error) {
+	snapshots := map[string]any{}
+	b, err := os.ReadFile(path) // #nosec G304 -- snapshot path is derived from the user's tests file.
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &snapshots); err != nil {
+		return nil, fmt.Errorf("parse snapshots %s: %w", path, err)
+	}
+	return snapshots, nil
+}
+
+func writeFlowStepTestsJUnit(path, slug string, results []flowStepTestResult) error {
+	suites := map[string]*junitTestSuite{}
+	var order []string
+	report := junitTestSuites{Name: "breyta flows test " + slug}
+	for _, result := range results {
+		suite, ok := suites[result.Step]
+		if !ok {
+			suite = &junitTestSuite{Name: slug + "/" + result.Step}
+			suites[result.Step] = suite
+			order = append(order, result.Step)
+		}
+		tc := junitTestCase{
+			Name:      result.Name,
+			ClassName: slug + "." + result.Step,
+			Time:      fmt.Sprintf("%.3f", float64(result.DurationMs)/1000),
+		}
+		switch result.Status {
+		case "failed":
+			tc.Failure = &junitMessage{Type: "assertion", Body: strings.Join(result.Failures, "\n")}
+			if len(result.Failures) > 0 {
+				tc.Failure.Message = result.Failures[0]
+			}
+		case "error":
+			tc.Failure = &junitMessage{Message: result.Error, Type: "error", Body: result.Error}
+		}
+		suite.Tests++
+		report.Tests++
+		if tc.Failure != nil {
+			suite.Failures++
+			report.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	for _, step := range order {
+		report.Suites = append(report.Suites, *suites[step])
+	}
+	f, err := os.Create(path) // #nosec G304 -- --junit is an explicit user-provided output path.
+	if err != nil {
+		return err
+	}
+	if err := writeJUnitReport(f, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newFlowsTestCmd(app *App) *cobra.Command {
+	var flowFile string
+	var testsFile string
+	var steps []string
+	var jobs int
+	var timeout time.Duration
+	var update bool
+	var junitPath string
+	var profileID string
+	cmd := &cobra.Command{
+		Use:   "test <flow-slug>",
+		Short: "Run a local step test suite from flows/<slug>.tests.edn",
+		Long: strings.TrimSpace(`
+Run the step test cases in flows/<slug>.tests.edn against the local flow
+source. Each case runs through steps.run (the same call as flows steps run),
+at most --jobs at a time, and is checked with its :expect matchers:
+
+  :equals    the whole step result must equal the value
+  :paths     [{:path "a.b" :equals v} {:path "items" :contains v}
+              {:path "id" :matches "^o-"}]
+  :schema    a Malli schema such as [:map [:id :string] [:total :int]]
+  :snapshot  compare with flows/__snapshots__/<slug>.steps.json
+
+Steps are :steps entries by qualified id; a case for an inline flow/step id
+(such as the steps of an imported n8n flow) sets the step :type, e.g.
+{:collect-ids [{:type :function :params {...} :expect {...}}]}.
+
+Cases without :expect are snapshot cases. --update records new or changed
+snapshots instead of failing on them. Timeouts come from the case, then the
+file's :timeout, then --timeout. The command exits non-zero when any case
+fails or errors; --junit writes a JUnit XML report for CI.
+`),
+		Example: strings.TrimSpace(`
+breyta flows test order-sync
+breyta flows test order-sync --step tools/fetch-order --jobs 2
+breyta flows test order-sync --update
+breyta flows test order-sync --junit reports/order-sync.xml
+`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slug := strings.TrimSpace(args[0])
+			if !isAPIValidFlowSlug(slug) {
+				return writeErr(cmd, fmt.Errorf("invalid flow slug %q", slug))
+			}
+			if jobs < 1 || timeout <= 0 {
+				return writeErr(cmd, errors.New("--jobs and --timeout must be > 0"))
+			}
+			flowPath, source, err := readLocalFlowSource(slug, flowFile)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			if strings.TrimSpace(testsFile) == "" {
+				testsFile = defaultFlowStepTestsPath(flowPath, slug)
+			}
+			b, err := readExplicitFile(testsFile)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("read tests file: %w", err))
+			}
+			suite, err := parseFlowStepTestSuite(b)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("%s: %w", testsFile, err))
+			}
+			if len(steps) > 0 {
+				filtered := suite.Cases[:0]
+				for _, c := range suite.Cases {
+					for _, step := range steps {
+						if c.Step == strings.TrimPrefix(strings.TrimSpace(step), ":") {
+							filtered = append(filtered, c)
+							break
+						}
+					}
+				}
+				suite.Cases = filtered
+				if len(suite.Cases) == 0 {
+					return writeErr(cmd, fmt.Errorf("no cases for --step %s in %s", strings.Join(steps, ", "), testsFile))
+				}
+			}
+			if err := requireAPI(app); err != nil {
+				return writeErr(cmd, err)
+			}
+			snapshotsPath := flowStepSnapshotsPath(testsFile, slug)
+			snapshots, err := readFlowStepSnapshots(snapshotsPath)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+
+			results := runFlowStepTests(cmd, app, suite, flowStepTestRunOptions{
+				slug:      slug,
+				flowPath:  flowPath,
+				source:    source,
+				jobs:      jobs,
+				timeout:   timeout,
+				profileID: profileID,
+			})
+			if applyFlowStepSnapshots(suite, results, snapshots, update) {
+				b, err := json.MarshalIndent(snapshots, "", "  ")
+				if err != nil {
+					return writeErr(cmd, err)
+				}
+				if err := atomicWriteFile(snapshotsPath, append(b, '\n'), publicFileMode); err != nil {
+					return writeErr(cmd, err)
+				}
+			}
+
+			summary := map[string]int{"total": len(results), "passed": 0, "failed": 0, "errors": 0, "updated": 0}
+			for _, result := range results {
+				switch result.Status {
+				case "passed":
+					summary["passed"]++
+				case "updated":
+					summary["updated"]++
+				case "failed":
+					summary["failed"]++
+				case "error":
+					summary["errors"]++
+				}
+			}
+			if junitPath != "" {
+				if err := makePublicDir(filepath.Dir(junitPath)); err != nil {
+					return writeErr(cmd, err)
+				}
+				if err := writeFlowStepTestsJUnit(junitPath, slug, results); err != nil {
+					return writeErr(cmd, fmt.Errorf("write --junit report: %w", err))
+				}
+			}
+			ok := summary["failed"] == 0 && summary["errors"] == 0
+			meta := map[string]any{"summary": summary, "testsFile": testsFile}
+			if junitPath != "" {
+				meta["junit"] = junitPath
+			}
+			if update {
+				meta["snapshots"] = snapshotsPath
+			}
+			if err := writeData(cmd, app, meta, map[string]any{
+				"flowSlug": slug,
+				"passed":   ok,
+				"cases":    results,
+			}); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%d cases: %d passed, %d failed, %d errors, %d snapshots updated\n",
+				summary["total"], summary["passed"], summary["failed"], summary["errors"], summary["updated"])
+			if !ok {
+				return guidedCLIErrorForCommand(cmd, fmt.Sprintf("flow tests failed: %d failed, %d errors", summary["failed"], summary["errors"]), nil)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&flowFile, "flow-file", "", "Local flow source path (default: flows/<flow-slug>.clj)")
+	cmd.Flags().StringVar(&testsFile, "file", "", "Tests file (default: <slug>.tests.edn next to the flow file)")
+	cmd.Flags().StringArrayVar(&steps, "step", nil, "Only run cases for this step (repeatable)")
+	cmd.Flags().IntVar(&jobs, "jobs", 4, "Maximum step runs in flight")
+	cmd.Flags().BoolVar(&update, "update", false, "Record new or changed snapshots instead of failing")
+	cmd.Flags().StringVar(&junitPath, "junit", "", "Write a JUnit XML report to this path")
+	cmd.Flags().StringVar(&profileID, "profile-id", "", "Optional installation/profile id for step runs")
+	addLocalStepRunTimeoutFlag(cmd, &timeout)
+	return cmd
+}
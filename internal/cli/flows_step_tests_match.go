@@ -0,0 +1,343 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"olympos.io/encoding/edn"
+)
+
+// ednToJSONValue converts a decoded EDN value into the shape encoding/json
+// produces for the same data: keywords and symbols become strings without
+// the leading colon, maps get string keys, sets become sorted vectors, and
+// integers become float64.
+func ednToJSONValue(v any) (any, error) {
+	switch typed := v.(type) {
+	case nil, bool, string, float64:
+		return typed, nil
+	case int64:
+		return float64(typed), nil
+	case int32:
+		return string(rune(typed)), nil
+	case edn.Keyword:
+		return string(typed), nil
+	case edn.Symbol:
+		return string(typed), nil
+	case []any:
+		out := make([]any, 0, len(typed))
+		for _, item := range typed {
+			converted, err := ednToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, converted)
+		}
+		return out, nil
+	case map[any]any:
+		out := make(map[string]any, len(typed))
+		for key, val := range typed {
+			name, ok := ednKeyToString(key)
+			if !ok {
+				return nil, fmt.Errorf("unsupported map key %v (%T)", key, key)
+			}
+			converted, err := ednToJSONValue(val)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = converted
+		}
+		return out, nil
+	case map[any]bool:
+		out := make([]any, 0, len(typed))
+		for item := range typed {
+			converted, err := ednToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, converted)
+		}
+		sort.Slice(out, func(i, j int) bool { return fmt.Sprint(out[i]) < fmt.Sprint(out[j]) })
+		return out, nil
+	case edn.Tag:
+		return nil, fmt.Errorf("unsupported tagged literal #%s", typed.Tagname)
+	}
+	return nil, fmt.Errorf("unsupported value %v (%T)", v, v)
+}
+
+// normalizeJSONValue round-trips v through encoding/json so values decoded
+// from different sources compare with reflect.DeepEqual.
+func normalizeJSONValue(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func jsonValuesEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeJSONValue(a), normalizeJSONValue(b))
+}
+
+// jsonValueContains reports whether expected is a substring (strings), an
+// element (vectors), or a recursive submap (maps) of actual.
+func jsonValueContains(actual, expected any) bool {
+	switch a := actual.(type) {
+	case string:
+		s, ok := expected.(string)
+		return ok && strings.Contains(a, s)
+	case []any:
+		for _, item := range a {
+			if jsonValuesEqual(item, expected) {
+				return true
+			}
+			if _, isMap := expected.(map[string]any); isMap && jsonValueContains(item, expected) {
+				return true
+			}
+		}
+		return false
+	case map[string]any:
+		want, ok := expected.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, wantValue := range want {
+			got, exists := a[key]
+			if !exists {
+				return false
+			}
+			if _, nested := wantValue.(map[string]any); nested {
+				if !jsonValueContains(got, wantValue) {
+					return false
+				}
+				continue
+			}
+			if !jsonValuesEqual(got, wantValue) {
+				return false
+			}
+		}
+		return true
+	}
+	return jsonValuesEqual(actual, expected)
+}
+
+// compactJSONString renders v for failure messages.
+func compactJSONString(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const limit = 200
+	if len(b) > limit {
+		return string(b[:limit]) + "…"
+	}
+	return string(b)
+}
+
+// validateMalliValue checks value against a subset of Malli schema syntax:
+// :any :string :int :double :number :boolean :keyword :nil :map :vector,
+// [:map [k schema] [k {:optional true} schema] ...] (open maps),
+// [:map-of k v], [:vector s], [:sequential s], [:set s], [:maybe s],
+// [:or ...], [:and ...], [:enum ...], and [:= v]. It returns one message per
+// mismatch, prefixed with the value path.
+func validateMalliValue(schema, value any, path string) ([]string, error) {
+	if path == "" {
+		path = "$"
+	}
+	if kw, ok := schema.(edn.Keyword); ok {
+		return validateMalliLeaf(string(kw), value, path)
+	}
+	form, ok := schema.([]any)
+	if !ok || len(form) == 0 {
+		return nil, fmt.Errorf("unsupported schema %v", schema)
+	}
+	head, ok := form[0].(edn.Keyword)
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema %v", schema)
+	}
+	args := form[1:]
+	if len(args) > 0 {
+		if _, props := args[0].(map[any]any); props && head != "=" && head != "enum" {
+			args = args[1:]
+		}
+	}
+	mismatch := func(want string) []string {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, want, compactJSONString(value))}
+	}
+	switch head {
+	case "map":
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch("map"), nil
+		}
+		var failures []string
+		for _, entryAny := range args {
+			entry, ok := entryAny.([]any)
+			if !ok || len(entry) < 2 {
+				return nil, fmt.Errorf("unsupported :map entry %v", entryAny)
+			}
+			key, ok := ednKeyToString(entry[0])
+			if !ok {
+				return nil, fmt.Errorf("unsupported :map key %v", entry[0])
+			}
+			entrySchema := entry[len(entry)-1]
+			optional := false
+			if props, ok := entry[1].(map[any]any); ok && len(entry) == 3 {
+				optional, _ = props[edn.Keyword("optional")].(bool)
+			}
+			got, exists := m[key]
+			if !exists {
+				if !optional {
+					failures = append(failures, fmt.Sprintf("%s: missing key %q", path, key))
+				}
+				continue
+			}
+			more, err := validateMalliValue(entrySchema, got, path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			failures = append(failures, more...)
+		}
+		return failures, nil
+	case "map-of":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("[:map-of] needs key and value schemas")
+		}
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch("map"), nil
+		}
+		var failures []string
+		for _, key := range sortedMapKeys(m) {
+			more, err := validateMalliValue(args[1], m[key], path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			failures = append(failures, more...)
+		}
+		return failures, nil
+	case "vector", "sequential", "set":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("[:%s] needs one element schema", head)
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch(string(head)), nil
+		}
+		var failures []string
+		for i, item := range items {
+			more, err := validateMalliValue(args[0], item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			failures = append(failures, more...)
+		}
+		return failures, nil
+	case "maybe":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("[:maybe] needs one schema")
+		}
+		if value == nil {
+			return nil, nil
+		}
+		return validateMalliValue(args[0], value, path)
+	case "or":
+		for _, alt := range args {
+			more, err := validateMalliValue(alt, value, path)
+			if err != nil {
+				return nil, err
+			}
+			if len(more) == 0 {
+				return nil, nil
+			}
+		}
+		return mismatch("one of " + malliSchemaString(schema)), nil
+	case "and":
+		var failures []string
+		for _, part := range args {
+			more, err := validateMalliValue(part, value, path)
+			if err != nil {
+				return nil, err
+			}
+			failures = append(failures, more...)
+		}
+		return failures, nil
+	case "enum":
+		for _, option := range args {
+			converted, err := ednToJSONValue(option)
+			if err != nil {
+				return nil, err
+			}
+			if jsonValuesEqual(converted, value) {
+				return nil, nil
+			}
+		}
+		return mismatch(malliSchemaString(schema)), nil
+	case "=":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("[:=] needs one value")
+		}
+		converted, err := ednToJSONValue(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if jsonValuesEqual(converted, value) {
+			return nil, nil
+		}
+		return mismatch(compactJSONString(converted)), nil
+	}
+	return nil, fmt.Errorf("unsupported schema %v", schema)
+}
+
+func validateMalliLeaf(kind string, value any, path string) ([]string, error) {
+	ok := false
+	switch kind {
+	case "any":
+		ok = true
+	case "string", "keyword":
+		_, ok = value.(string)
+	case "int":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == math.Trunc(n)
+	case "double", "number":
+		_, ok = value.(float64)
+	case "boolean":
+		_, ok = value.(bool)
+	case "nil":
+		ok = value == nil
+	case "map":
+		_, ok = value.(map[string]any)
+	case "vector", "sequential":
+		_, ok = value.([]any)
+	default:
+		return nil, fmt.Errorf("unsupported schema :%s", kind)
+	}
+	if ok {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("%s: expected %s, got %s", path, kind, compactJSONString(value))}, nil
+}
+
+func malliSchemaString(schema any) string {
+	b, err := edn.Marshal(schema)
+	if err != nil {
+		return fmt.Sprint(schema)
+	}
+	return string(b)
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
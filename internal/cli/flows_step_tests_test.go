@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const stepTestsFlow = `{:slug :order-sync
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :steps [{:id :tools/fetch :type :http :defaults {:method :get :url "https://example.com"}}]
+ :flow '(flow/step :tools/fetch :fetch {})}
+`
+
+const stepTestsSuite = `{:timeout "5s"
+ :steps
+ {:tools/fetch
+  [{:name "open order"
+    :params {:order-id "o-1"}
+    :expect {:paths [{:path "status" :equals :open}
+                     {:path "items" :contains {:sku "A"}}
+                     {:path "id" :matches "^o-"}]
+             :schema [:map [:id :string] [:total :int] [:note {:optional true} :string]]}}
+   {:name "exact"
+    :params {:order-id "o-2"}
+    :expect {:equals {:id "o-2" :status "open" :total 3 :items [{:sku "A" :qty 1}]}}}
+   {:name "snapshot"
+    :params {:order-id "o-3"}}]}}
+`
+
+func writeStepTestsProject(t *testing.T, suite string) (string, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "flows")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	flowPath := filepath.Join(dir, "order-sync.clj")
+	if err := os.WriteFile(flowPath, []byte(stepTestsFlow), 0o644); err != nil {
+		t.Fatalf("write flow: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "order-sync.tests.edn"), []byte(suite), 0o644); err != nil {
+		t.Fatalf("write tests: %v", err)
+	}
+	return dir, flowPath
+}
+
+func newStepTestsServer(t *testing.T, total *int32, inFlight *int32, peak *int32) *App {
+	t.Helper()
+	var mu sync.Mutex
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["command"] != "steps.run" {
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": map[string]any{"message": "unexpected command"}})
+			return
+		}
+		atomic.AddInt32(total, 1)
+		now := atomic.AddInt32(inFlight, 1)
+		mu.Lock()
+		if now > *peak {
+			*peak = now
+		}
+		mu.Unlock()
+		defer atomic.AddInt32(inFlight, -1)
+		args, _ := body["args"].(map[string]any)
+		params, _ := args["params"].(map[string]any)
+		id, _ := params["order-id"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{
+			"stepId": "tools/fetch",
+			"result": map[string]any{"id": id, "status": "open", "total": 3, "items": []any{map[string]any{"sku": "A", "qty": 1}}},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+	return &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+}
+
+func runFlowsTest(t *testing.T, app *App, args ...string) (map[string]any, string, error) {
+	t.Helper()
+	cmd := newFlowsTestCmd(app)
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	var body map[string]any
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &body); decodeErr != nil {
+		t.Fatalf("decode: %v\n%s\n%s", decodeErr, stdout.String(), stderr.String())
+	}
+	return body, stderr.String(), err
+}
+
+func TestFlowsTestRunsMatchersRecordsSnapshotsAndWritesJUnit(t *testing.T) {
+	dir, flowPath := writeStepTestsProject(t, stepTestsSuite)
+	var total, inFlight, peak int32
+	app := newStepTestsServer(t, &total, &inFlight, &peak)
+
+	// The snapshot case fails until it is recorded.
+	body, _, err := runFlowsTest(t, app, "order-sync", "--flow-file", flowPath)
+	if err == nil {
+		t.Fatalf("expected a failure for the unrecorded snapshot: %#v", body)
+	}
+	if !strings.Contains(mustJSON(t, body), "rerun with --update") {
+		t.Fatalf("expected snapshot hint, got %s", mustJSON(t, body))
+	}
+
+	body, progress, err := runFlowsTest(t, app, "order-sync", "--flow-file", flowPath, "--update", "--jobs", "2")
+	if err != nil {
+		t.Fatalf("update run failed: %v\n%s", err, mustJSON(t, body))
+	}
+	summary := body["meta"].(map[string]any)["summary"].(map[string]any)
+	if summary["passed"] != float64(2) || summary["updated"] != float64(1) {
+		t.Fatalf("unexpected summary %#v", summary)
+	}
+	if !strings.Contains(progress, "3 cases: 2 passed, 0 failed, 0 errors, 1 snapshots updated") {
+		t.Fatalf("missing summary line: %s", progress)
+	}
+	if peak > 2 {
+		t.Fatalf("--jobs 2 must cap concurrent step runs, saw %d", peak)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "__snapshots__", "order-sync.steps.json")); err != nil {
+		t.Fatalf("expected snapshot file: %v", err)
+	}
+
+	junit := filepath.Join(t.TempDir(), "reports", "tests.xml")
+	if _, _, err := runFlowsTest(t, app, "order-sync", "--flow-file", flowPath, "--junit", junit); err != nil {
+		t.Fatalf("recorded snapshot should pass: %v", err)
+	}
+	xml, err := os.ReadFile(junit)
+	if err != nil {
+		t.Fatalf("read junit: %v", err)
+	}
+	if !strings.Contains(string(xml), `<testsuite name="order-sync/tools/fetch" tests="3" failures="0"`) {
+		t.Fatalf("unexpected junit report:\n%s", xml)
+	}
+}
+
+func TestFlowsTestReportsMatcherFailures(t *testing.T) {
+	suite := `{:steps {:tools/fetch [{:name "wrong"
+                              :params {:order-id "x-1"}
+                              :expect {:paths [{:path "id" :matches "^o-"}
+                                               {:path "missing" :equals 1}]
+                                       :schema [:map [:total :string]]}}]}}`
+	_, flowPath := writeStepTestsProject(t, suite)
+	var total, inFlight, peak int32
+	app := newStepTestsServer(t, &total, &inFlight, &peak)
+	body, _, err := runFlowsTest(t, app, "order-sync", "--flow-file", flowPath)
+	if err == nil {
+		t.Fatalf("expected matcher failures")
+	}
+	cases := body["data"].(map[string]any)["cases"].([]any)
+	failures := stringSlice(cases[0].(map[string]any)["failures"])
+	want := []string{
+		`id: expected to match /^o-/, got "x-1"`,
+		"missing: path not found",
+		`schema: $.total: expected string, got 3`,
+	}
+	if strings.Join(failures, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected failures:\n%s", strings.Join(failures, "\n"))
+	}
+}
+
+func TestParseFlowStepTestSuiteRejectsUnknownMatchers(t *testing.T) {
+	_, err := parseFlowStepTestSuite([]byte(`{:steps {:tools/fetch [{:expect {:equal 1}}]}}`))
+	if err == nil || !strings.Contains(err.Error(), "step tools/fetch case 1: :expect: unknown matcher :equal") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = parseFlowStepTestSuite([]byte(`{:steps {:collect-ids [{:params {}}]}}`))
+	if err == nil || !strings.Contains(err.Error(), "inline flow/step ids need a :type") {
+		t.Fatalf("expected a missing :type error, got %v", err)
+	}
+}
@@ -0,0 +1,519 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// localStepRenameSite is one occurrence of the old step id that rename
+// rewrites, located in the file that holds it (root or #flow/include).
+type localStepRenameSite struct {
+	Kind   string
+	Path   string
+	Offset int
+	Old    string
+	New    string
+}
+
+type localStepRenamePlan struct {
+	path      string
+	rootPath  string
+	sites     []localStepRenameSite
+	before    map[string]string
+	after     map[string]string
+	files     []string
+	remaining []map[string]any
+	warnings  []string
+}
+
+// planLocalStepRename finds every place the packaged step id is used — the
+// :steps entry, flow/step references in the :flow body, :tools {:steps [...]}
+// exposures, and :interfaces — across include files, and computes the
+// rewritten file contents. It refuses when the new id is already defined or
+// referenced, or when references cannot be inspected.
+func planLocalStepRename(path, source, oldID, newID string) (*localStepRenamePlan, error) {
+	oldID = strings.TrimPrefix(strings.TrimSpace(oldID), ":")
+	newID = strings.TrimPrefix(strings.TrimSpace(newID), ":")
+	if !localStepIDValid(oldID) {
+		return nil, fmt.Errorf("invalid step id %q", oldID)
+	}
+	if !localStepIDValid(newID) {
+		return nil, fmt.Errorf("invalid step id %q (use a qualified id such as tools/fetch-order)", newID)
+	}
+	if oldID == newID {
+		return nil, errors.New("old and new step ids are the same")
+	}
+	expanded, sourceMap, err := expandFlowSourceIncludesWithMap(path, source)
+	if err != nil {
+		return nil, fmt.Errorf("inspect local flow before renaming step: %w", err)
+	}
+	plan := &localStepRenamePlan{
+		path:      path,
+		rootPath:  flowLintRootPath(path),
+		before:    map[string]string{},
+		after:     map[string]string{},
+		remaining: []map[string]any{},
+	}
+	oldToken, newToken := ":"+oldID, ":"+newID
+	var sites []localStepRenameSite
+	add := func(kind string, offset int, oldText, newText string) {
+		sites = append(sites, localStepRenameSite{Kind: kind, Offset: offset, Old: oldText, New: newText})
+	}
+
+	stepsEntry, found, err := localTopLevelEntry(expanded, "steps")
+	if err != nil {
+		return nil, err
+	}
+	defined := false
+	if found {
+		spans, err := localFlowStepVector(expanded, stepsEntry)
+		if err != nil {
+			return nil, err
+		}
+		for _, span := range spans {
+			id, err := localStepIDFromMap(expanded, span)
+			if err != nil {
+				return nil, err
+			}
+			switch strings.TrimPrefix(id, ":") {
+			case newID:
+				return nil, fmt.Errorf("cannot rename to %q: a step with that id already exists", newID)
+			case oldID:
+				if defined {
+					return nil, fmt.Errorf("step %q is defined more than once", oldID)
+				}
+				defined = true
+				entries, _, err := parseClojureMapEntries(expanded, span.Start)
+				if err != nil {
+					return nil, err
+				}
+				idEntry, _ := mapEntryByKey(entries, "id")
+				start, _ := clojureActiveFormStart(expanded, idEntry.ValueStart)
+				if strings.HasPrefix(expanded[start:], "\"") {
+					add("steps", start, strconv.Quote(oldID), strconv.Quote(newID))
+				} else {
+					add("steps", start, oldToken, newToken)
+				}
+			}
+		}
+	}
+	if !defined {
+		return nil, fmt.Errorf("step %q not found", oldID)
+	}
+
+	references, err := localFlowStepReferences(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot inspect flow body references before renaming step: %w", err)
+	}
+	for _, reference := range references {
+		switch reference.StepID {
+		case newID:
+			return nil, fmt.Errorf("cannot rename to %q: the flow body already references that id", newID)
+		case oldID:
+			add("flow", reference.ByteOffset, oldToken, newToken)
+		}
+	}
+
+	exposed, allKnown := localToolsExposedStepOffsets(expanded)
+	if len(exposed[newID]) > 0 {
+		return nil, fmt.Errorf("cannot rename to %q: a :tools entry already exposes that id", newID)
+	}
+	for _, offset := range exposed[oldID] {
+		add("tools", offset, oldToken, newToken)
+	}
+	if !allKnown {
+		plan.warnings = append(plan.warnings, "Some :tools values could not be read statically; check them for the old step id by hand.")
+	}
+
+	if entry, ok, _ := localTopLevelEntry(expanded, "interfaces"); ok {
+		if len(clojureKeywordOffsets(expanded, entry.ValueStart, entry.ValueEnd, newToken)) > 0 {
+			return nil, fmt.Errorf("cannot rename to %q: :interfaces already mention that id", newID)
+		}
+		for _, offset := range clojureKeywordOffsets(expanded, entry.ValueStart, entry.ValueEnd, oldToken) {
+			add("interfaces", offset, oldToken, newToken)
+		}
+	}
+
+	claimed := map[int]bool{}
+	for _, site := range sites {
+		claimed[site.Offset] = true
+	}
+	for _, offset := range clojureKeywordOffsets(expanded, 0, len(expanded), oldToken) {
+		if claimed[offset] {
+			continue
+		}
+		file, fileOffset := plan.locate(sourceMap, offset)
+		line, column := sourceLineColumn(plan.contents(file, source), fileOffset)
+		plan.remaining = append(plan.remaining, map[string]any{"file": plan.display(file), "line": line, "column": column})
+	}
+
+	seen := map[string]bool{}
+	for _, site := range sites {
+		site.Path, site.Offset = plan.locate(sourceMap, site.Offset)
+		key := site.Path + "\x00" + strconv.Itoa(site.Offset)
+		if seen[key] {
+			// The same include can be expanded twice; edit it once.
+			continue
+		}
+		seen[key] = true
+		content := plan.contents(site.Path, source)
+		if site.Offset < 0 || site.Offset+len(site.Old) > len(content) || content[site.Offset:site.Offset+len(site.Old)] != site.Old {
+			return nil, fmt.Errorf("could not map %s reference to %s back to its source file", site.Kind, oldID)
+		}
+		plan.sites = append(plan.sites, site)
+	}
+	sort.SliceStable(plan.sites, func(i, j int) bool {
+		if plan.sites[i].Path != plan.sites[j].Path {
+			return plan.sites[i].Path < plan.sites[j].Path
+		}
+		return plan.sites[i].Offset > plan.sites[j].Offset
+	})
+	for _, site := range plan.sites {
+		updated, ok := plan.after[site.Path]
+		if !ok {
+			updated = plan.before[site.Path]
+			plan.files = append(plan.files, site.Path)
+		}
+		plan.after[site.Path] = updated[:site.Offset] + site.New + updated[site.Offset+len(site.Old):]
+	}
+	return plan, nil
+}
+
+// locate maps an expanded-source offset to the file and offset holding it.
+func (p *localStepRenamePlan) locate(sourceMap flowSourceMap, offset int) (string, int) {
+	if file, fileOffset, ok := sourceMap.Locate(offset); ok && file != "" {
+		return file, fileOffset
+	}
+	return p.rootPath, offset
+}
+
+func (p *localStepRenamePlan) contents(file, rootSource string) string {
+	if content, ok := p.before[file]; ok {
+		return content
+	}
+	content := rootSource
+	if file != p.rootPath {
+		// An unreadable include leaves content empty, so the site check in
+		// planLocalStepRename refuses the rename.
+		content = ""
+		if b, err := os.ReadFile(file); err == nil {
+			content = string(b)
+		}
+	}
+	p.before[file] = content
+	return content
+}
+
+// display renders a file path the way the user named the flow file, so
+// include paths read relative to it.
+func (p *localStepRenamePlan) display(file string) string {
+	if file == p.rootPath {
+		return p.path
+	}
+	if rel, err := filepath.Rel(filepath.Dir(p.rootPath), file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join(filepath.Dir(p.path), rel)
+	}
+	return file
+}
+
+func (p *localStepRenamePlan) displayFiles() []string {
+	files := make([]string, 0, len(p.files))
+	for _, file := range p.files {
+		files = append(files, p.display(file))
+	}
+	return files
+}
+
+func (p *localStepRenamePlan) diff() string {
+	var b strings.Builder
+	for _, file := range p.files {
+		label := p.display(file)
+		b.WriteString(unifiedSourceFileDiff(label, label, p.before[file], p.after[file]))
+	}
+	return b.String()
+}
+
+func (p *localStepRenamePlan) references() []map[string]any {
+	out := make([]map[string]any, 0, len(p.sites))
+	for i := len(p.sites) - 1; i >= 0; i-- {
+		site := p.sites[i]
+		line, column := sourceLineColumn(p.before[site.Path], site.Offset)
+		out = append(out, map[string]any{"kind": site.Kind, "file": p.display(site.Path), "line": line, "column": column})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i]["file"] != out[j]["file"] {
+			return out[i]["file"].(string) < out[j]["file"].(string)
+		}
+		return out[i]["line"].(int) < out[j]["line"].(int)
+	})
+	return out
+}
+
+// clojureKeywordOffsets returns the offsets of every standalone occurrence
+// of a keyword token in src[start:end], ignoring strings and comments.
+func clojureKeywordOffsets(src string, start, end int, token string) []int {
+	clean := stripClojureStringLiterals(src)
+	var offsets []int
+	for i := start; i < end; {
+		idx := strings.Index(clean[i:end], token)
+		if idx < 0 {
+			break
+		}
+		at := i + idx
+		boundaryBefore := at == 0 || isClojureWhitespaceOrComma(clean[at-1]) || strings.IndexByte("([{'`~@^#", clean[at-1]) >= 0
+		if boundaryBefore && readClojureTokenEnd(clean, at) == at+len(token) {
+			offsets = append(offsets, at)
+		}
+		i = at + len(token)
+	}
+	return offsets
+}
+
+// localStepTestSidecars holds the rewritten local step test files for a
+// rename: the flow's <slug>.tests.edn and its recorded snapshots. A nil
+// entry means the file does not exist or does not mention the step.
+type localStepTestSidecars struct {
+	testsPath     string
+	tests         []byte
+	snapshotsPath string
+	snapshots     []byte
+}
+
+// planLocalStepTestSidecars moves the step's cases in the local tests file
+// and its snapshot keys to the new id, refusing when the file already has
+// cases for the new id.
+func planLocalStepTestSidecars(flowPath, slug, oldID, newID string) (*localStepTestSidecars, error) {
+	out := &localStepTestSidecars{testsPath: defaultFlowStepTestsPath(flowPath, slug)}
+	out.snapshotsPath = flowStepSnapshotsPath(out.testsPath, slug)
+	b, err := os.ReadFile(out.testsPath) // #nosec G304 -- tests path is derived from the local flow file.
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	src := string(b)
+	stepsEntry, found, err := localTopLevelEntry(src, "steps")
+	if err != nil {
+		return nil, fmt.Errorf("inspect %s: %w", out.testsPath, err)
+	}
+	if found {
+		entries, _, err := parseClojureMapEntries(src, stepsEntry.ValueStart)
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s: %w", out.testsPath, err)
+		}
+		var at []int
+		for _, entry := range entries {
+			switch strings.TrimSpace(entry.KeyToken) {
+			case ":" + newID:
+				return nil, fmt.Errorf("%s already has cases for %q", out.testsPath, newID)
+			case ":" + oldID:
+				at = append(at, entry.KeyStart)
+			}
+		}
+		for i := len(at) - 1; i >= 0; i-- {
+			src = src[:at[i]] + ":" + newID + src[at[i]+len(oldID)+1:]
+		}
+		if len(at) > 0 {
+			out.tests = []byte(src)
+		}
+	}
+
+	snapshots, err := readFlowStepSnapshots(out.snapshotsPath)
+	if err != nil {
+		return nil, err
+	}
+	renamed := false
+	for key, value := range snapshots {
+		if name, ok := strings.CutPrefix(key, oldID+" "); ok {
+			delete(snapshots, key)
+			snapshots[newID+" "+name] = value
+			renamed = true
+		}
+	}
+	if renamed {
+		b, err := json.MarshalIndent(snapshots, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		out.snapshots = append(b, '\n')
+	}
+	return out, nil
+}
+
+// write saves the rewritten files and reports which ones changed.
+func (s *localStepTestSidecars) write() ([]string, error) {
+	written := []string{}
+	for _, file := range []struct {
+		path string
+		body []byte
+	}{{s.testsPath, s.tests}, {s.snapshotsPath, s.snapshots}} {
+		if file.body == nil {
+			continue
+		}
+		if err := atomicWriteFile(file.path, file.body, publicFileMode); err != nil {
+			return written, fmt.Errorf("write %s: %w", file.path, err)
+		}
+		written = append(written, file.path)
+	}
+	return written, nil
+}
+
+// copyStepSidecars re-records server-side step examples and tests under the
+// new step id. The API has no delete, so the old records stay in place.
+func copyStepSidecars(ctx context.Context, app *App, flowSlug, oldID, newID string) (map[string]any, error) {
+	client := apiClient(app)
+	result := map[string]any{}
+	for _, kind := range []struct{ list, add, key string }{
+		{"steps.examples.list", "steps.examples.add", "examples"},
+		{"steps.tests.list", "steps.tests.add", "tests"},
+	} {
+		out, status, err := client.DoCommand(ctx, kind.list, map[string]any{"flowSlug": flowSlug, "stepId": oldID})
+		if err != nil {
+			return result, err
+		}
+		if status >= 400 || !isOK(out) {
+			return result, fmt.Errorf("%s failed: %s", kind.list, formatAPIError(out))
+		}
+		data := mapStringAny(out["data"])
+		items := sliceAny(firstPresent(data, kind.key, "items"))
+		copied := 0
+		for _, item := range items {
+			record := mapStringAny(item)
+			if record == nil {
+				continue
+			}
+			payload := map[string]any{"flowSlug": flowSlug, "stepId": newID}
+			for _, field := range []string{"input", "output", "expected", "note", "name", "stepType", "traceId", "profileId"} {
+				if value, ok := record[field]; ok && value != nil {
+					payload[field] = value
+				}
+			}
+			addOut, addStatus, err := client.DoCommand(ctx, kind.add, payload)
+			if err != nil {
+				return result, err
+			}
+			if addStatus >= 400 || !isOK(addOut) {
+				return result, fmt.Errorf("%s failed: %s", kind.add, formatAPIError(addOut))
+			}
+			copied++
+		}
+		result[kind.key] = copied
+	}
+	return result, nil
+}
+
+func newFlowsStepsLocalRenameCmd(app *App) *cobra.Command {
+	var flowFile string
+	var dryRun, push, sidecars bool
+	cmd := &cobra.Command{
+		Use:   "rename <flow-slug> <old-step-id> <new-step-id>",
+		Short: "Rename a packaged step and every reference to it in the local flow source",
+		Long: strings.TrimSpace(`
+Rename a packaged step in the local flow source.
+
+The :steps entry, flow/step references in the :flow body, :tools {:steps [...]}
+exposures, and :interfaces mentions are rewritten in place, including inside
+#flow/include files. Rename refuses when the new id is already defined or
+referenced. Occurrences it cannot prove are step references (for example in
+quoted data) are left alone and listed in data.unchanged.
+
+Use --dry-run to print the diff without writing. --sidecars copies the step's
+server-side examples and tests to the new id (the old records are kept) and
+moves its cases and snapshots in the local <flow-slug>.tests.edn to the new id.
+`),
+		Example: strings.TrimSpace(`
+breyta flows steps rename order-sync tools/fetch tools/fetch-orders --dry-run
+breyta flows steps rename order-sync tools/fetch tools/fetch-orders --push --sidecars
+`),
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slug := strings.TrimSpace(args[0])
+			oldID := strings.TrimPrefix(strings.TrimSpace(args[1]), ":")
+			newID := strings.TrimPrefix(strings.TrimSpace(args[2]), ":")
+			if dryRun && (push || sidecars) {
+				return writeErr(cmd, errors.New("--dry-run cannot be combined with --push or --sidecars"))
+			}
+			path, source, err := readLocalFlowSource(slug, flowFile)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			plan, err := planLocalStepRename(path, source, oldID, newID)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			var testSidecars *localStepTestSidecars
+			if sidecars {
+				if err := requireAPI(app); err != nil {
+					return writeErr(cmd, err)
+				}
+				if testSidecars, err = planLocalStepTestSidecars(path, slug, oldID, newID); err != nil {
+					return writeErr(cmd, err)
+				}
+			}
+			result := map[string]any{
+				"flowSlug":   slug,
+				"from":       oldID,
+				"to":         newID,
+				"files":      plan.displayFiles(),
+				"references": plan.references(),
+				"unchanged":  plan.remaining,
+			}
+			if len(plan.warnings) > 0 {
+				result["warnings"] = plan.warnings
+			}
+			if dryRun {
+				result["dryRun"] = true
+				result["saved"] = false
+				result["diff"] = plan.diff()
+				return writeData(cmd, app, nil, result)
+			}
+			for _, file := range plan.files {
+				if err := atomicWriteFile(file, []byte(plan.after[file]), publicFileMode); err != nil {
+					return writeErr(cmd, fmt.Errorf("write %s: %w", file, err))
+				}
+			}
+			if testSidecars != nil {
+				written, err := testSidecars.write()
+				if err != nil {
+					return writeErr(cmd, fmt.Errorf("the local rename was saved, but rewriting step tests failed: %w", err))
+				}
+				copied, err := copyStepSidecars(cmd.Context(), app, slug, oldID, newID)
+				if err != nil {
+					return writeErr(cmd, fmt.Errorf("the local rename was saved, but copying step sidecars failed: %w", err))
+				}
+				copied["localFiles"] = written
+				result["sidecars"] = copied
+			}
+			if push {
+				updated := plan.after[plan.rootPath]
+				if updated == "" {
+					updated = source
+				}
+				remote, status, pushErr := pushLocalFlowLiteral(cmd, app, path, updated)
+				if pushErr != nil {
+					return writeErr(cmd, pushErr)
+				}
+				if status >= 400 || !isOK(remote) {
+					return writeAPIResult(cmd, app, remote, status)
+				}
+				return writeLocalAuthoringResult(cmd, app, path, remote, status, result)
+			}
+			return writeLocalAuthoringResult(cmd, app, path, nil, 0, result)
+		},
+	}
+	cmd.Flags().StringVar(&flowFile, "flow-file", "", "Local flow source path (default: flows/<flow-slug>.clj)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the diff without writing any file")
+	cmd.Flags().BoolVar(&push, "push", false, "Push the edited local source after saving")
+	cmd.Flags().BoolVar(&sidecars, "sidecars", false, "Copy server-side step examples and tests and move local step tests to the new id (requires API access)")
+	return cmd
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const renameRootFlow = `{:slug :order-sync
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]
+              :mcp [{:id :fetch-tool :invocation :default :step :tools/fetch}]}
+ :agents [{:id :review/helper :description "Helper" :tools {:steps [:tools/fetch]}}]
+ :steps [#flow/include "steps/fetch.edn"
+         {:id :tools/other :type :function :defaults {:code '(fn [input] input)}}]
+ :flow #flow/include "body.clj"}
+`
+
+const renameIncludedStep = `{:id :tools/fetch :type :http :defaults {:method :get :url "https://example.com"}}
+`
+
+const renameIncludedBody = `'(let [input (flow/input)
+        orders (flow/step :tools/fetch {})
+        note '(:tools/fetch is quoted data)]
+    (flow/step :tools/other {:orders orders}))
+`
+
+func writeRenameTestFlow(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"order-sync.clj":  renameRootFlow,
+		"steps/fetch.edn": renameIncludedStep,
+		"body.clj":        renameIncludedBody,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir, filepath.Join(dir, "order-sync.clj")
+}
+
+func readRenameTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestFlowsStepsRenameRewritesReferencesAcrossIncludes(t *testing.T) {
+	dir, path := writeRenameTestFlow(t)
+	body := executeLocalAuthoringJSON(t, newFlowsStepsLocalRenameCmd(&App{WorkspaceID: "ws-test"}),
+		"order-sync", "tools/fetch", "tools/fetch-orders", "--flow-file", path)
+
+	root := readRenameTestFile(t, dir, "order-sync.clj")
+	if !strings.Contains(root, ":step :tools/fetch-orders}") || !strings.Contains(root, ":tools {:steps [:tools/fetch-orders]}") {
+		t.Fatalf("root interfaces/tools not renamed:\n%s", root)
+	}
+	if step := readRenameTestFile(t, dir, "steps/fetch.edn"); !strings.HasPrefix(step, "{:id :tools/fetch-orders :type :http") {
+		t.Fatalf("included :steps entry not renamed:\n%s", step)
+	}
+	flowBody := readRenameTestFile(t, dir, "body.clj")
+	if !strings.Contains(flowBody, "(flow/step :tools/fetch-orders {})") {
+		t.Fatalf("included flow body reference not renamed:\n%s", flowBody)
+	}
+	if !strings.Contains(flowBody, "'(:tools/fetch is quoted data)") {
+		t.Fatalf("quoted data must be left alone:\n%s", flowBody)
+	}
+
+	data := body["data"].(map[string]any)
+	kinds := map[string]bool{}
+	for _, item := range data["references"].([]any) {
+		kinds[item.(map[string]any)["kind"].(string)] = true
+	}
+	for _, want := range []string{"steps", "flow", "tools", "interfaces"} {
+		if !kinds[want] {
+			t.Fatalf("missing %s reference in %#v", want, data["references"])
+		}
+	}
+	if unchanged := data["unchanged"].([]any); len(unchanged) != 1 {
+		t.Fatalf("expected the quoted occurrence to be reported as unchanged, got %#v", unchanged)
+	}
+	if files := data["files"].([]any); len(files) != 3 {
+		t.Fatalf("expected three edited files, got %#v", files)
+	}
+}
+
+func TestFlowsStepsRenameDryRunPrintsDiffWithoutWriting(t *testing.T) {
+	dir, path := writeRenameTestFlow(t)
+	body := executeLocalAuthoringJSON(t, newFlowsStepsLocalRenameCmd(&App{WorkspaceID: "ws-test"}),
+		"order-sync", "tools/fetch", "tools/fetch-orders", "--flow-file", path, "--dry-run")
+
+	if readRenameTestFile(t, dir, "body.clj") != renameIncludedBody || readRenameTestFile(t, dir, "order-sync.clj") != renameRootFlow {
+		t.Fatalf("dry run must not write files")
+	}
+	data := body["data"].(map[string]any)
+	diff, _ := data["diff"].(string)
+	if data["saved"] != false || !strings.Contains(diff, "+        orders (flow/step :tools/fetch-orders {})") {
+		t.Fatalf("unexpected dry-run output %#v", data)
+	}
+}
+
+func TestFlowsStepsRenameReportsEmptyUnchangedList(t *testing.T) {
+	_, path := writeRenameTestFlow(t)
+	body := executeLocalAuthoringJSON(t, newFlowsStepsLocalRenameCmd(&App{WorkspaceID: "ws-test"}),
+		"order-sync", "tools/other", "tools/transform", "--flow-file", path, "--dry-run")
+
+	unchanged, ok := body["data"].(map[string]any)["unchanged"].([]any)
+	if !ok || len(unchanged) != 0 {
+		t.Fatalf("expected an empty unchanged list, got %#v", body["data"])
+	}
+}
+
+func TestFlowsStepsRenameRefusesConflicts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args []string
+		want string
+	}{
+		"existing id":  {[]string{"tools/fetch", "tools/other"}, "already exists"},
+		"missing step": {[]string{"tools/missing", "tools/new"}, "not found"},
+		"invalid id":   {[]string{"tools/fetch", "fetch"}, "invalid step id"},
+		"same id":      {[]string{"tools/fetch", "tools/fetch"}, "the same"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, path := writeRenameTestFlow(t)
+			cmd := newFlowsStepsLocalRenameCmd(&App{WorkspaceID: "ws-test"})
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			cmd.SetArgs(append([]string{"order-sync"}, append(tc.args, "--flow-file", path)...))
+			if err := cmd.Execute(); err == nil || !strings.Contains(out.String(), tc.want) {
+				t.Fatalf("expected refusal mentioning %q, got err=%v\n%s", tc.want, err, out.String())
+			}
+			if readRenameTestFile(t, dir, "order-sync.clj") != renameRootFlow {
+				t.Fatalf("refused rename must not write files")
+			}
+		})
+	}
+}
+
+func TestFlowsStepsRenameRefusesWhenNewIDIsAlreadyReferenced(t *testing.T) {
+	dir, path := writeRenameTestFlow(t)
+	withDangling := strings.Replace(renameIncludedBody, "(flow/step :tools/other", "(flow/step :tools/renamed {})\n    (flow/step :tools/other", 1)
+	if err := os.WriteFile(filepath.Join(dir, "body.clj"), []byte(withDangling), 0o644); err != nil {
+		t.Fatalf("write body: %v", err)
+	}
+	if _, err := planLocalStepRename(path, renameRootFlow, "tools/fetch", "tools/renamed"); err == nil || !strings.Contains(err.Error(), "already references") {
+		t.Fatalf("expected body reference conflict, got %v", err)
+	}
+}
+
+func TestFlowsStepsRenameCopiesSidecarsToNewID(t *testing.T) {
+	dir, path := writeRenameTestFlow(t)
+	tests := `{:steps {:tools/fetch [{:name "open" :params {:step :tools/fetch}}]
+         :tools/other [{:name "noop" :params {}}]}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "order-sync.tests.edn"), []byte(tests), 0o644); err != nil {
+		t.Fatalf("write tests: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "__snapshots__"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	snapshots := `{"tools/fetch open": {"ok": true}, "tools/other noop": {}}`
+	if err := os.WriteFile(filepath.Join(dir, "__snapshots__", "order-sync.steps.json"), []byte(snapshots), 0o644); err != nil {
+		t.Fatalf("write snapshots: %v", err)
+	}
+	var added []map[string]any
+	srv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		args, _ := body["args"].(map[string]any)
+		switch body["command"] {
+		case "steps.examples.list":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"examples": []any{
+				map[string]any{"input": map[string]any{"id": 1}, "output": map[string]any{"ok": true}, "note": "happy path"},
+			}}})
+		case "steps.tests.list":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{"tests": []any{
+				map[string]any{"name": "fetches", "input": map[string]any{"id": 1}, "expected": map[string]any{"ok": true}},
+			}}})
+		case "steps.examples.add", "steps.tests.add":
+			args["command"] = body["command"]
+			added = append(added, args)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": map[string]any{}})
+		default:
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": map[string]any{"message": "unexpected command"}})
+		}
+	}))
+	defer srv.Close()
+
+	app := &App{WorkspaceID: "ws-test", APIURL: srv.URL, Token: "t", TokenExplicit: true}
+	body := executeLocalAuthoringJSON(t, newFlowsStepsLocalRenameCmd(app),
+		"order-sync", "tools/fetch", "tools/fetch-orders", "--flow-file", path, "--sidecars")
+
+	if len(added) != 2 {
+		t.Fatalf("expected one example and one test to be copied, got %#v", added)
+	}
+	for _, args := range added {
+		if args["stepId"] != "tools/fetch-orders" || args["flowSlug"] != "order-sync" {
+			t.Fatalf("sidecar copied to the wrong step: %#v", args)
+		}
+	}
+	if added[1]["name"] != "fetches" || added[0]["note"] != "happy path" {
+		t.Fatalf("sidecar fields not carried over: %#v", added)
+	}
+	sidecars := body["data"].(map[string]any)["sidecars"].(map[string]any)
+	if sidecars["examples"] != float64(1) || sidecars["tests"] != float64(1) || len(sidecars["localFiles"].([]any)) != 2 {
+		t.Fatalf("unexpected sidecar counts %#v", sidecars)
+	}
+	if got := readRenameTestFile(t, dir, "order-sync.tests.edn"); got != strings.Replace(tests, "{:steps {:tools/fetch [", "{:steps {:tools/fetch-orders [", 1) {
+		t.Fatalf("expected only the :steps key to be renamed:\n%s", got)
+	}
+	var renamed map[string]any
+	if err := json.Unmarshal([]byte(readRenameTestFile(t, dir, "__snapshots__/order-sync.steps.json")), &renamed); err != nil {
+		t.Fatalf("decode snapshots: %v", err)
+	}
+	if _, ok := renamed["tools/fetch-orders open"]; !ok || len(renamed) != 2 {
+		t.Fatalf("expected the snapshot key to move to the new id, got %#v", renamed)
+	}
+}
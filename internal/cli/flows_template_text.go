@@ -0,0 +1,378 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// flowTemplateNode is one piece of a parsed :templates string: literal text,
+// a {{variable}}, or an #if/#unless/#each/#with block.
+type flowTemplateNode struct {
+	Text string
+	// Path is the variable or block argument as written, e.g. "seed.page".
+	Path string
+	// Offset is the byte offset of the opening {{ within the string.
+	Offset  int
+	Block   string
+	Body    []flowTemplateNode
+	Inverse []flowTemplateNode
+}
+
+func (n flowTemplateNode) isText() bool {
+	return n.Path == "" && n.Block == ""
+}
+
+// parseFlowTemplateText parses the handlebars subset that runtime templates
+// use: {{path}}, {{{path}}}, {{! comments}}, and the #if, #unless, #each and
+// #with blocks with an optional {{else}}.
+func parseFlowTemplateText(text string) ([]flowTemplateNode, error) {
+	p := &flowTemplateParser{src: text}
+	nodes, closing, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if closing != "" {
+		return nil, fmt.Errorf("unexpected {{/%s}}", closing)
+	}
+	return nodes, nil
+}
+
+type flowTemplateParser struct {
+	src string
+	pos int
+}
+
+// parseNodes reads nodes up to the end of the text or a closing {{/name}},
+// whose name it returns. An {{else}} is kept as a marker node.
+func (p *flowTemplateParser) parseNodes() ([]flowTemplateNode, string, error) {
+	var nodes []flowTemplateNode
+	for p.pos < len(p.src) {
+		open := strings.Index(p.src[p.pos:], "{{")
+		if open < 0 {
+			nodes = appendFlowTemplateText(nodes, p.src[p.pos:])
+			p.pos = len(p.src)
+			break
+		}
+		nodes = appendFlowTemplateText(nodes, p.src[p.pos:p.pos+open])
+		offset := p.pos + open
+		tag, next, err := readFlowTemplateTag(p.src, offset)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w at byte %d", err, offset)
+		}
+		p.pos = next
+		switch {
+		case strings.HasPrefix(tag, "!"):
+		case strings.HasPrefix(tag, "#"):
+			block, arg, _ := strings.Cut(strings.TrimSpace(tag[1:]), " ")
+			arg = strings.TrimSpace(arg)
+			switch block {
+			case "if", "unless", "each", "with":
+			default:
+				return nil, "", fmt.Errorf("unsupported block helper #%s at byte %d", block, offset)
+			}
+			if arg == "" {
+				return nil, "", fmt.Errorf("#%s needs an argument at byte %d", block, offset)
+			}
+			body, closing, err := p.parseNodes()
+			if err != nil {
+				return nil, "", err
+			}
+			if closing != block {
+				return nil, "", fmt.Errorf("{{#%s}} at byte %d is not closed by {{/%s}}", block, offset, block)
+			}
+			node := flowTemplateNode{Block: block, Path: arg, Offset: offset}
+			node.Body, node.Inverse = splitFlowTemplateElse(body)
+			nodes = append(nodes, node)
+		case strings.HasPrefix(tag, "/"):
+			return nodes, strings.TrimSpace(tag[1:]), nil
+		case tag == "else":
+			nodes = append(nodes, flowTemplateNode{Block: "else", Offset: offset})
+		case tag == "":
+			return nil, "", fmt.Errorf("empty {{}} at byte %d", offset)
+		default:
+			nodes = append(nodes, flowTemplateNode{Path: tag, Offset: offset})
+		}
+	}
+	for _, node := range nodes {
+		if node.Block == "else" {
+			return nil, "", fmt.Errorf("{{else}} outside a block at byte %d", node.Offset)
+		}
+	}
+	return nodes, "", nil
+}
+
+// readFlowTemplateTag reads the {{...}} or {{{...}}} tag at offset and
+// returns its trimmed contents and the offset after it. Whitespace control
+// markers ({{~ ... ~}}) are accepted and ignored.
+func readFlowTemplateTag(src string, offset int) (string, int, error) {
+	start := offset + len("{{")
+	closer := "}}"
+	if strings.HasPrefix(src[start:], "{") {
+		start++
+		closer = "}}}"
+	}
+	if strings.HasPrefix(src[start:], "!--") {
+		end := strings.Index(src[start:], "--}}")
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated {{!-- comment")
+		}
+		return "!", start + end + len("--}}"), nil
+	}
+	end := strings.Index(src[start:], closer)
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated {{")
+	}
+	tag := strings.TrimSpace(src[start : start+end])
+	tag = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(tag, "~"), "~"))
+	return tag, start + end + len(closer), nil
+}
+
+func appendFlowTemplateText(nodes []flowTemplateNode, text string) []flowTemplateNode {
+	if text == "" {
+		return nodes
+	}
+	return append(nodes, flowTemplateNode{Text: text})
+}
+
+func splitFlowTemplateElse(nodes []flowTemplateNode) ([]flowTemplateNode, []flowTemplateNode) {
+	for i, node := range nodes {
+		if node.Block == "else" {
+			return nodes[:i], nodes[i+1:]
+		}
+	}
+	return nodes, nil
+}
+
+// flowTemplateRender renders parsed template nodes against data. Variables
+// that resolve to nothing render as "" and are passed to unresolved.
+type flowTemplateRender struct {
+	root       any
+	unresolved func(node flowTemplateNode)
+}
+
+type flowTemplateFrame struct {
+	value any
+	index int
+	key   string
+	first bool
+	last  bool
+}
+
+func (r flowTemplateRender) render(nodes []flowTemplateNode) string {
+	var b strings.Builder
+	r.renderInto(&b, nodes, []flowTemplateFrame{{value: r.root, index: -1}})
+	return b.String()
+}
+
+func (r flowTemplateRender) renderInto(b *strings.Builder, nodes []flowTemplateNode, stack []flowTemplateFrame) {
+	for _, node := range nodes {
+		switch node.Block {
+		case "":
+			if node.isText() {
+				b.WriteString(node.Text)
+				continue
+			}
+			value, ok := resolveFlowTemplatePath(node.Path, stack)
+			if !ok && r.unresolved != nil {
+				r.unresolved(node)
+			}
+			b.WriteString(flowTemplateString(value))
+		case "if", "unless":
+			value, _ := resolveFlowTemplatePath(node.Path, stack)
+			if flowTemplateTruthy(value) == (node.Block == "if") {
+				r.renderInto(b, node.Body, stack)
+			} else {
+				r.renderInto(b, node.Inverse, stack)
+			}
+		case "with":
+			value, ok := resolveFlowTemplatePath(node.Path, stack)
+			if !ok || !flowTemplateTruthy(value) {
+				r.renderInto(b, node.Inverse, stack)
+				continue
+			}
+			r.renderInto(b, node.Body, append(stack, flowTemplateFrame{value: value, index: -1}))
+		case "each":
+			value, _ := resolveFlowTemplatePath(node.Path, stack)
+			frames := flowTemplateEachFrames(value)
+			if len(frames) == 0 {
+				r.renderInto(b, node.Inverse, stack)
+				continue
+			}
+			for _, frame := range frames {
+				r.renderInto(b, node.Body, append(stack[:len(stack):len(stack)], frame))
+			}
+		}
+	}
+}
+
+func flowTemplateEachFrames(value any) []flowTemplateFrame {
+	var frames []flowTemplateFrame
+	switch typed := value.(type) {
+	case []any:
+		for i, item := range typed {
+			frames = append(frames, flowTemplateFrame{value: item, index: i, first: i == 0, last: i == len(typed)-1})
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			frames = append(frames, flowTemplateFrame{value: typed[key], index: i, key: key, first: i == 0, last: i == len(keys)-1})
+		}
+	}
+	return frames
+}
+
+// flowTemplatePathSegments splits a handlebars path into its scope prefix
+// (the number of ../ parents, or -1 for @root) and its key segments.
+func flowTemplatePathSegments(path string) (int, []string) {
+	up := 0
+	for strings.HasPrefix(path, "../") {
+		up++
+		path = path[len("../"):]
+	}
+	switch {
+	case path == "@root":
+		return -1, nil
+	case strings.HasPrefix(path, "@root."):
+		up, path = -1, path[len("@root."):]
+	case path == "this" || path == ".":
+		return up, nil
+	case strings.HasPrefix(path, "this.") || strings.HasPrefix(path, "this/"):
+		path = path[len("this."):]
+	case strings.HasPrefix(path, "./"):
+		path = path[len("./"):]
+	}
+	var segments []string
+	for path != "" {
+		if strings.HasPrefix(path, "[") {
+			end := strings.Index(path, "]")
+			if end < 0 {
+				segments = append(segments, path)
+				break
+			}
+			segments = append(segments, path[1:end])
+			path = strings.TrimLeft(path[end+1:], "./")
+			continue
+		}
+		end := strings.IndexAny(path, "./")
+		if end < 0 {
+			segments = append(segments, path)
+			break
+		}
+		segments = append(segments, path[:end])
+		path = path[end+1:]
+	}
+	return up, segments
+}
+
+func resolveFlowTemplatePath(path string, stack []flowTemplateFrame) (any, bool) {
+	frame := stack[len(stack)-1]
+	switch path {
+	case "@index":
+		return frame.index, frame.index >= 0
+	case "@key":
+		return frame.key, frame.key != ""
+	case "@first":
+		return frame.first, frame.index >= 0
+	case "@last":
+		return frame.last, frame.index >= 0
+	}
+	up, segments := flowTemplatePathSegments(path)
+	switch {
+	case up < 0:
+		frame = stack[0]
+	case up >= len(stack):
+		return nil, false
+	default:
+		frame = stack[len(stack)-1-up]
+	}
+	value := frame.value
+	for _, segment := range segments {
+		next, ok := flowTemplateLookup(value, segment)
+		if !ok {
+			return nil, false
+		}
+		value = next
+	}
+	return value, value != nil
+}
+
+func flowTemplateLookup(value any, key string) (any, bool) {
+	switch typed := value.(type) {
+	case map[string]any:
+		if v, ok := typed[key]; ok {
+			return v, true
+		}
+		v, ok := typed[":"+key]
+		return v, ok
+	case []any:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(typed) {
+			return nil, false
+		}
+		return typed[index], true
+	}
+	return nil, false
+}
+
+func flowTemplateTruthy(value any) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case string:
+		return typed != ""
+	case float64:
+		return typed != 0
+	case int:
+		return typed != 0
+	case []any:
+		return len(typed) > 0
+	}
+	return true
+}
+
+func flowTemplateString(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case map[string]any, []any:
+		b, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
+
+// flowTemplateRootVariables returns the variables of nodes that resolve
+// against the template's top-level data: plain variables and block
+// arguments, but nothing scoped to an #each or #with item.
+func flowTemplateRootVariables(nodes []flowTemplateNode) []flowTemplateNode {
+	var out []flowTemplateNode
+	for _, node := range nodes {
+		if node.isText() || strings.HasPrefix(node.Path, "@") && !strings.HasPrefix(node.Path, "@root.") {
+			continue
+		}
+		if node.Block == "" || node.Block == "each" || node.Block == "with" {
+			out = append(out, flowTemplateNode{Path: node.Path, Offset: node.Offset})
+		}
+		if node.Block == "if" || node.Block == "unless" {
+			out = append(out, flowTemplateRootVariables(node.Body)...)
+		}
+		out = append(out, flowTemplateRootVariables(node.Inverse)...)
+	}
+	return out
+}
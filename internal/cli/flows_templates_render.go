@@ -0,0 +1,529 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"olympos.io/encoding/edn"
+)
+
+// localFlowTemplate is one :templates entry found in a local flow source.
+type localFlowTemplate struct {
+	// Label is the id as written, e.g. ":fetch-user".
+	Label string
+	// Start and End delimit the template map in the flow source.
+	Start int
+	End   int
+}
+
+func (t localFlowTemplate) ID() string {
+	return strings.TrimPrefix(t.Label, ":")
+}
+
+// findLocalFlowTemplates reads :templates as a vector of {:id ...} maps.
+func findLocalFlowTemplates(src string) ([]localFlowTemplate, error) {
+	entry, found, err := localTopLevelEntry(src, "templates")
+	if err != nil || !found {
+		return nil, err
+	}
+	start, ok := clojureActiveFormStart(src, entry.ValueStart)
+	if !ok || start >= len(src) || src[start] != '[' {
+		return nil, nil
+	}
+	elements, _, err := parseClojureVectorElements(src, start)
+	if err != nil {
+		return nil, err
+	}
+	var out []localFlowTemplate
+	for _, element := range elements {
+		if element.Start >= len(src) || src[element.Start] != '{' {
+			continue
+		}
+		entries, _, err := parseClojureMapEntries(src, element.Start)
+		if err != nil {
+			return nil, err
+		}
+		idEntry, ok := mapEntryByKey(entries, "id")
+		if !ok {
+			continue
+		}
+		label := readFunctionLabel(src, idEntry.ValueStart, "")
+		if label == "" {
+			continue
+		}
+		out = append(out, localFlowTemplate{Label: label, Start: element.Start, End: element.End})
+	}
+	return out, nil
+}
+
+// localFlowTemplateValue reads a template map as plain data: keys and keyword
+// values become their names.
+func localFlowTemplateValue(src string, tmpl localFlowTemplate) (map[string]any, error) {
+	var raw any
+	if err := edn.Unmarshal([]byte(src[tmpl.Start:tmpl.End]), &raw); err != nil {
+		return nil, fmt.Errorf("read template %s: %w", tmpl.Label, err)
+	}
+	value, _ := plainEDNValue(raw).(map[string]any)
+	if value == nil {
+		return nil, fmt.Errorf("template %s is not a map", tmpl.Label)
+	}
+	return value, nil
+}
+
+// plainEDNValue converts decoded EDN into JSON-shaped data: map keys and
+// keyword or symbol values become their names, integers become float64.
+func plainEDNValue(v any) any {
+	switch typed := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(typed))
+		for key, value := range typed {
+			name, ok := ednKeyToString(key)
+			if !ok {
+				name = fmt.Sprint(key)
+			}
+			out[name] = plainEDNValue(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			out[i] = plainEDNValue(item)
+		}
+		return out
+	case edn.Keyword:
+		return string(typed)
+	case edn.Symbol:
+		return string(typed)
+	case int64:
+		return float64(typed)
+	}
+	return v
+}
+
+// flowTemplateStepConfig is the part of a (flow/step ...) config that uses a
+// template: its connection slot and the literal :data map, when there is one.
+type flowTemplateStepConfig struct {
+	// StepID is the step's display id, e.g. ":fetch".
+	StepID     string
+	Template   string
+	Connection string
+	// DataStart is the offset of the :data value, or -1 when the config has
+	// no :data.
+	DataStart int
+}
+
+// localFlowTemplateStepConfigs returns the literal step configs that name a
+// :template. unknown is true when some step config is not a literal map, or
+// a top-level :steps definition sets a template, so a step might use a
+// template without that being visible.
+func localFlowTemplateStepConfigs(src string) ([]flowTemplateStepConfig, bool, error) {
+	if steps, found, err := localTopLevelEntry(src, "steps"); err == nil && found && strings.Contains(src[steps.ValueStart:steps.ValueEnd], ":template") {
+		return nil, true, nil
+	}
+	references, err := localFlowStepReferences(src)
+	if err != nil {
+		return nil, true, err
+	}
+	var out []flowTemplateStepConfig
+	unknown := false
+	for _, reference := range references {
+		if reference.ConfigOffset <= 0 {
+			if reference.ElementCount > 3 {
+				unknown = true
+			}
+			continue
+		}
+		entries, _, err := parseClojureMapEntries(src, reference.ConfigOffset)
+		if err != nil {
+			unknown = true
+			continue
+		}
+		templateEntry, ok := mapEntryByKey(entries, "template")
+		if !ok {
+			continue
+		}
+		config := flowTemplateStepConfig{
+			StepID:    reference.PathID,
+			Template:  strings.TrimPrefix(readFunctionLabel(src, templateEntry.ValueStart, ""), ":"),
+			DataStart: -1,
+		}
+		if connection, ok := mapEntryByKey(entries, "connection"); ok {
+			config.Connection = strings.TrimPrefix(readFunctionLabel(src, connection.ValueStart, ""), ":")
+		}
+		if data, ok := mapEntryByKey(entries, "data"); ok {
+			config.DataStart = data.ValueStart
+		}
+		out = append(out, config)
+	}
+	return out, unknown, nil
+}
+
+// localFlowRequiresBaseURLs maps :requires slots to their :base-url.
+func localFlowRequiresBaseURLs(src string) map[string]string {
+	entry, found, err := localTopLevelEntry(src, "requires")
+	if err != nil || !found {
+		return nil
+	}
+	var raw any
+	if err := edn.Unmarshal([]byte(src[entry.ValueStart:entry.ValueEnd]), &raw); err != nil {
+		return nil
+	}
+	items, _ := plainEDNValue(raw).([]any)
+	out := map[string]string{}
+	for _, item := range items {
+		slot, _ := item.(map[string]any)
+		name := toString(slot["slot"])
+		if base := strings.TrimSpace(toString(firstPresentAny(slot["base-url"], slot["baseUrl"]))); name != "" && base != "" {
+			out[name] = base
+		}
+	}
+	return out
+}
+
+var flowTemplateSecretHeaderRe = regexp.MustCompile(`(?i)(authorization|api[-_]?key|token|secret|password|cookie|signature)`)
+
+// maskFlowTemplateHeader hides credential header values, keeping an auth
+// scheme such as "Bearer" so the shape of the header stays visible.
+func maskFlowTemplateHeader(name, value string) string {
+	if !flowTemplateSecretHeaderRe.MatchString(name) && len(scanFlowSecretValue(value)) == 0 {
+		return value
+	}
+	if value == "" {
+		return value
+	}
+	if scheme, rest, ok := strings.Cut(value, " "); ok && rest != "" && (strings.EqualFold(scheme, "bearer") || strings.EqualFold(scheme, "basic") || strings.EqualFold(scheme, "token")) {
+		return scheme + " ****"
+	}
+	return "****"
+}
+
+// maskFlowTemplateRenderedHeaders masks credential values in the rendered
+// :request :headers of an :http-request template, in place.
+func maskFlowTemplateRenderedHeaders(rendered map[string]any) {
+	request, _ := rendered["request"].(map[string]any)
+	headers, _ := request["headers"].(map[string]any)
+	for name, value := range headers {
+		headers[name] = maskFlowTemplateHeader(name, flowTemplateString(value))
+	}
+}
+
+type flowTemplateRendering struct {
+	data       any
+	unresolved []flowLintDiagnostic
+	err        error
+}
+
+// renderValue renders every string in v; field names the value in
+// unresolved-variable reports, e.g. "request.headers.Authorization".
+func (r *flowTemplateRendering) renderValue(field string, v any) any {
+	switch typed := v.(type) {
+	case string:
+		return r.renderString(field, typed)
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, value := range typed {
+			out[key] = r.renderValue(joinFlowTemplateField(field, key), value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			out[i] = r.renderValue(fmt.Sprintf("%s[%d]", field, i), item)
+		}
+		return out
+	}
+	return v
+}
+
+func (r *flowTemplateRendering) renderString(field, text string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	nodes, err := parseFlowTemplateText(text)
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("%s: %w", field, err)
+		}
+		return text
+	}
+	return flowTemplateRender{root: r.data, unresolved: func(node flowTemplateNode) {
+		r.unresolved = append(r.unresolved, flowLintDiagnostic{"field": field, "variable": node.Path})
+	}}.render(nodes)
+}
+
+func joinFlowTemplateField(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// flowTemplateHTTPRequest assembles the request an :http-request template
+// sends once rendered.
+func flowTemplateHTTPRequest(rendered map[string]any, baseURL string) map[string]any {
+	request, _ := rendered["request"].(map[string]any)
+	method := strings.ToUpper(firstNonEmpty(toString(request["method"]), "get"))
+	target := strings.TrimSpace(toString(request["url"]))
+	if target == "" {
+		path := toString(request["path"])
+		if baseURL != "" && path != "" && !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		target = strings.TrimRight(baseURL, "/") + path
+	}
+	if query, ok := request["query"].(map[string]any); ok && len(query) > 0 {
+		values := url.Values{}
+		for key, value := range query {
+			values.Set(key, flowTemplateString(value))
+		}
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + values.Encode()
+	}
+	headers := map[string]any{}
+	if raw, ok := request["headers"].(map[string]any); ok {
+		for name, value := range raw {
+			headers[name] = maskFlowTemplateHeader(name, flowTemplateString(value))
+		}
+	}
+	out := map[string]any{
+		"method":  method,
+		"url":     target,
+		"headers": headers,
+	}
+	// The request body is whichever of :body, :json, :form or :multipart the
+	// template sets; bodyType records which one.
+	for _, key := range []string{"body", "json", "form", "multipart"} {
+		if body, ok := request[key]; ok {
+			out["body"] = body
+			out["bodyType"] = key
+			break
+		}
+	}
+	return out
+}
+
+func newFlowsTemplatesRenderCmd(app *App) *cobra.Command {
+	var flowFile string
+	var inputFile string
+	var inputJSON string
+	var baseURL string
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "render <flow-slug> <template-id>",
+		Short: "Render one of a flow's :templates locally against a JSON input",
+		Long: strings.TrimSpace(`
+Render a :templates entry from the local flow source without running the flow.
+The input stands in for the step's :data: {{path}} variables resolve against it
+the same way they do at run time, including #if, #unless, #each and #with
+blocks. Variables that resolve to nothing render as empty text and are listed
+under unresolved (and warned about on stderr).
+
+An :http-request template shows the final request: method, URL (the :requires
+slot's :base-url plus :path and :query), headers with credential values
+masked, and body (from :body, :json, :form or :multipart, named by bodyType). An :llm-prompt template shows the rendered prompt text.
+Other template types render every string in the template.
+
+The base URL comes from --base-url, else from the :connection of the step that
+uses the template, else from the only :requires slot with a :base-url.
+`),
+		Example: strings.TrimSpace(`
+breyta flows templates render github-digest fetch-user --input-file fixtures/user.json
+breyta flows templates render github-digest :summarize --input '{"objective":"triage"}' --strict
+`),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			slug := strings.TrimSpace(args[0])
+			if !isAPIValidFlowSlug(slug) {
+				return writeErr(cmd, fmt.Errorf("invalid flow slug %q", slug))
+			}
+			templateID := strings.TrimPrefix(strings.TrimSpace(args[1]), ":")
+			if templateID == "" {
+				return writeErr(cmd, errors.New("template id is required"))
+			}
+			if strings.TrimSpace(inputFile) != "" && strings.TrimSpace(inputJSON) != "" {
+				return writeErr(cmd, errors.New("use either --input-file or --input, not both"))
+			}
+			input, err := readFlowTemplateInput(inputFile, inputJSON)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			flowPath, source, err := readLocalFlowSource(slug, flowFile)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			expanded, sourceMap, err := expandFlowSourceIncludesWithMap(flowPath, source)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+			templates, err := findLocalFlowTemplates(expanded)
+			if err != nil {
+				return writeErr(cmd, fmt.Errorf("read :templates in %s: %w", flowPath, err))
+			}
+			var tmpl *localFlowTemplate
+			ids := make([]string, 0, len(templates))
+			for i := range templates {
+				ids = append(ids, templates[i].ID())
+				if templates[i].ID() == templateID {
+					tmpl = &templates[i]
+				}
+			}
+			if tmpl == nil {
+				sort.Strings(ids)
+				available := "none"
+				if len(ids) > 0 {
+					available = strings.Join(ids, ", ")
+				}
+				return writeErr(cmd, fmt.Errorf("template %q not found in %s (available: %s)", templateID, flowPath, available))
+			}
+			value, err := localFlowTemplateValue(expanded, *tmpl)
+			if err != nil {
+				return writeErr(cmd, err)
+			}
+
+			templateType := toString(value["type"])
+			rendering := &flowTemplateRendering{data: input, unresolved: []flowLintDiagnostic{}}
+			renderedFields := map[string]any{}
+			for key, field := range value {
+				if key == "id" || key == "type" {
+					continue
+				}
+				renderedFields[key] = rendering.renderValue(key, field)
+			}
+			if rendering.err != nil {
+				return writeErr(cmd, fmt.Errorf("template %s: %w", tmpl.Label, rendering.err))
+			}
+			locateFlowTemplateVariables(rendering.unresolved, expanded, *tmpl)
+			newFlowLintLocator(flowPath, source, expanded, sourceMap).annotate(rendering.unresolved)
+			sort.SliceStable(rendering.unresolved, func(i, j int) bool {
+				return toString(rendering.unresolved[i]["field"]) < toString(rendering.unresolved[j]["field"])
+			})
+
+			meta := map[string]any{"flowFile": flowPath}
+			data := map[string]any{
+				"flowSlug":   slug,
+				"templateId": templateID,
+				"type":       templateType,
+				"rendered":   renderedFields,
+				"unresolved": rendering.unresolved,
+			}
+			switch templateType {
+			case "http-request":
+				base := strings.TrimSpace(baseURL)
+				if base == "" {
+					base = flowTemplateBaseURL(expanded, templateID)
+				}
+				if base != "" {
+					meta["baseUrl"] = base
+				}
+				data["request"] = flowTemplateHTTPRequest(renderedFields, base)
+				maskFlowTemplateRenderedHeaders(renderedFields)
+			case "llm-prompt":
+				prompt := map[string]any{}
+				for _, key := range []string{"system", "prompt", "messages"} {
+					if field, ok := renderedFields[key]; ok {
+						prompt[key] = field
+					}
+				}
+				data["prompt"] = prompt
+			}
+			for _, item := range rendering.unresolved {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s:%v:%v: {{%s}} in %s is unresolved\n", item["file"], item["line"], item["column"], item["variable"], item["field"])
+			}
+			if strict && len(rendering.unresolved) > 0 {
+				out := map[string]any{"ok": false, "workspaceId": app.WorkspaceID, "meta": meta, "data": data}
+				if err := writeOut(cmd, app, out); err != nil {
+					return err
+				}
+				return guidedCLIErrorForCommand(cmd, fmt.Sprintf("template %s has %d unresolved variable(s)", tmpl.Label, len(rendering.unresolved)), nil)
+			}
+			return writeData(cmd, app, meta, data)
+		},
+	}
+	cmd.Flags().StringVar(&flowFile, "flow-file", "", "Local flow file (default flows/<slug>.clj)")
+	cmd.Flags().StringVar(&inputFile, "input-file", "", "JSON file used as the template data")
+	cmd.Flags().StringVar(&inputJSON, "input", "", "Inline JSON used as the template data")
+	cmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL for :http-request templates (default: the connection's :base-url)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Exit non-zero when any variable is unresolved")
+	return cmd
+}
+
+func readFlowTemplateInput(inputFile, inputJSON string) (any, error) {
+	var b []byte
+	switch {
+	case strings.TrimSpace(inputFile) != "":
+		raw, err := readExplicitFile(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --input-file: %w", err)
+		}
+		b = raw
+	case strings.TrimSpace(inputJSON) != "":
+		b = []byte(inputJSON)
+	default:
+		return map[string]any{}, nil
+	}
+	var input any
+	if err := json.Unmarshal(b, &input); err != nil {
+		return nil, errors.New("template input must be valid JSON")
+	}
+	return input, nil
+}
+
+// flowTemplateBaseURL finds the :base-url of the connection used with the
+// template, falling back to the only :requires slot that has one.
+func flowTemplateBaseURL(src, templateID string) string {
+	bases := localFlowRequiresBaseURLs(src)
+	configs, _, _ := localFlowTemplateStepConfigs(src)
+	for _, config := range configs {
+		if config.Template == templateID && bases[config.Connection] != "" {
+			return bases[config.Connection]
+		}
+	}
+	if len(bases) == 1 {
+		for _, base := range bases {
+			return base
+		}
+	}
+	return ""
+}
+
+// locateFlowTemplateVariables sets byteOffset on each unresolved variable to
+// the first string literal in the template that uses it.
+func locateFlowTemplateVariables(unresolved []flowLintDiagnostic, src string, tmpl localFlowTemplate) {
+	offsets := map[string]int{}
+	forEachClojureStringLiteral(src[tmpl.Start:tmpl.End], func(offset int, value string) {
+		if !strings.Contains(value, "{{") {
+			return
+		}
+		nodes, err := parseFlowTemplateText(value)
+		if err != nil {
+			return
+		}
+		var visit func([]flowTemplateNode)
+		visit = func(nodes []flowTemplateNode) {
+			for _, node := range nodes {
+				if _, seen := offsets[node.Path]; !seen && node.Path != "" {
+					offsets[node.Path] = tmpl.Start + offset
+				}
+				visit(node.Body)
+				visit(node.Inverse)
+			}
+		}
+		visit(nodes)
+	})
+	for _, item := range unresolved {
+		if offset, ok := offsets[toString(item["variable"])]; ok {
+			item["byteOffset"] = offset
+		} else {
+			item["byteOffset"] = tmpl.Start
+		}
+	}
+}
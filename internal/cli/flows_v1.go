@@ -351,8 +351,12 @@ Public discover notes:
 	cmd.AddCommand(newFlowsPublicCmd(app))
 	cmd.AddCommand(newFlowsShowCmd(app))
 	cmd.AddCommand(newFlowsDiffCmd(app))
+	cmd.AddCommand(newFlowsGraphCmd(app))
+	cmd.AddCommand(newFlowsIncludesCmd(app))
+	cmd.AddCommand(newFlowsBundleCmd(app))
 	cmd.AddCommand(newFlowsCreateCmd(app))
 	cmd.AddCommand(newFlowsInitCmd(app))
+	cmd.AddCommand(newFlowsNewCmd(app))
 	cmd.AddCommand(newFlowsConfigureCmd(app))
 	cmd.AddCommand(newFlowsBindingsCmd(app))
 	cmd.AddCommand(newFlowsReleaseCmd(app))
@@ -370,6 +374,10 @@ Public discover notes:
 	cmd.AddCommand(newFlowsPullCmd(app))
 	cmd.AddCommand(newFlowsLintCmd(app))
 	cmd.AddCommand(newFlowsPushCmd(app))
+	cmd.AddCommand(newFlowsDevCmd(app))
+	cmd.AddCommand(newFlowsTestCmd(app))
+	cmd.AddCommand(newFlowsSnapshotCmd(app))
+	cmd.AddCommand(newFlowsFunctionsCmd(app))
 	cmd.AddCommand(newFlowsImportCmd(app))
 	cmd.AddCommand(newFlowsParenRepairCmd(app))
 	cmd.AddCommand(newFlowsParenCheckCmd(app))
@@ -387,10 +395,13 @@ Public discover notes:
 	steps.AddCommand(newFlowsStepsLocalCreateCmd(app))
 	steps.AddCommand(newFlowsStepsLocalUpdateCmd(app))
 	steps.AddCommand(newFlowsStepsLocalRemoveCmd(app))
+	steps.AddCommand(newFlowsStepsLocalRenameCmd(app))
 	steps.AddCommand(newFlowsStepsLocalRunCmd(app))
 	cmd.AddCommand(steps)
 	cmd.AddCommand(newFlowsSchedulesLocalCmd(app))
 	cmd.AddCommand(newFlowsComposeCmd(app))
+	cmd.AddCommand(newFlowsEditCmd(app))
+	cmd.AddCommand(newFlowsQueryCmd(app))
 
 	versions := &cobra.Command{Use: "versions", Short: "Manage flow versions"}
 	versions.AddCommand(newFlowsVersionsListCmd(app))
@@ -0,0 +1,608 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/breyta/breyta-cli/internal/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+func newLSPCmd(app *App) *cobra.Command {
+	var docsDir string
+	var offline bool
+
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a language server for flow files over stdio",
+		Long: strings.TrimSpace(`
+Run a Language Server Protocol server for Breyta flow files on stdin/stdout.
+
+Point your editor's LSP client at ` + "`breyta lsp`" + ` for .clj flow files. The server
+provides:
+- diagnostics from the local lint pipeline, published on open, change, and save
+- go-to-definition from step references to their :steps entry, across
+  #flow/include files, and from include paths to the included file
+- completion of step types and step ids
+- hover with step details, per-step docs, and ` + "`docs fields`" + ` tables for step types
+- document symbols for steps, schedules, and interfaces
+
+Hover docs are read from the ` + "`breyta docs sync`" + ` cache first (--docs-dir,
+relative to the workspace root) and fetched from the API only when the cache
+misses and you are logged in. Pass --offline to never call the API.
+`),
+		Example: strings.TrimSpace(`
+breyta docs sync --out .breyta-docs
+breyta lsp
+breyta lsp --offline --docs-dir ~/.cache/breyta-docs
+`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			docs := &lspDocsSource{dir: docsDir, app: app, offline: offline}
+			if !offline && requireAPI(app) != nil {
+				docs.offline = true
+			}
+			server := newLSPServer(cmd.OutOrStdout(), docs)
+			if err := server.serve(cmd.InOrStdin()); err != nil {
+				return writeErr(cmd, err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&docsDir, "docs-dir", ".breyta-docs", "Docs cache written by `breyta docs sync`")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Never call the API; hover uses only the local docs cache")
+	return cmd
+}
+
+// LSP wire types. Only the fields the server reads or writes are declared.
+
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	lspErrorParse          = -32700
+	lspErrorInvalidParams  = -32602
+	lspErrorMethodNotFound = -32601
+
+	lspSeverityError       = 1
+	lspSeverityWarning     = 2
+	lspSeverityInformation = 3
+
+	lspSymbolKindInterface = 11
+	lspSymbolKindFunction  = 12
+	lspSymbolKindEvent     = 24
+
+	lspCompletionKindKeyword   = 14
+	lspCompletionKindReference = 18
+)
+
+// readLSPMessage reads one Content-Length framed JSON-RPC message.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", strings.TrimSpace(value))
+			}
+			length = n
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("missing Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeLSPMessage(w io.Writer, message map[string]any) error {
+	message["jsonrpc"] = "2.0"
+	b, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// lspServer keeps open documents in memory and answers requests
+// synchronously in arrival order.
+type lspServer struct {
+	out       io.Writer
+	docs      *lspDocsSource
+	open      map[string]string
+	rootDir   string
+	published map[string]bool
+	shutdown  bool
+}
+
+func newLSPServer(out io.Writer, docs *lspDocsSource) *lspServer {
+	return &lspServer{
+		out:       out,
+		docs:      docs,
+		open:      map[string]string{},
+		published: map[string]bool{},
+	}
+}
+
+func (s *lspServer) serve(in io.Reader) error {
+	reader := bufio.NewReader(in)
+	for {
+		body, err := readLSPMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			if err := writeLSPMessage(s.out, map[string]any{"id": nil, "error": lspResponseError{Code: lspErrorParse, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("language client exited without shutdown")
+			}
+			return nil
+		}
+		result, rpcErr := s.handle(msg)
+		if len(msg.ID) == 0 {
+			continue
+		}
+		response := map[string]any{"id": msg.ID}
+		if rpcErr != nil {
+			response["error"] = rpcErr
+		} else {
+			response["result"] = result
+		}
+		if err := writeLSPMessage(s.out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lspServer) handle(msg lspMessage) (any, *lspResponseError) {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			RootURI  string `json:"rootUri"`
+			RootPath string `json:"rootPath"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		if params.RootURI != "" {
+			s.rootDir = lspURIToPath(params.RootURI)
+		} else {
+			s.rootDir = params.RootPath
+		}
+		if s.docs != nil && s.rootDir != "" && s.docs.dir != "" && !filepath.IsAbs(s.docs.dir) {
+			s.docs.dir = filepath.Join(s.rootDir, s.docs.dir)
+		}
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    1,
+					"save":      map[string]any{"includeText": false},
+				},
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{"triggerCharacters": []string{":"}},
+			},
+			"serverInfo": map[string]any{"name": "breyta", "version": buildinfo.DisplayVersion()},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspResponseError{Code: lspErrorInvalidParams, Message: err.Error()}
+		}
+		s.open[lspURIToPath(params.TextDocument.URI)] = params.TextDocument.Text
+		return nil, s.publishDiagnostics()
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspResponseError{Code: lspErrorInvalidParams, Message: err.Error()}
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.open[lspURIToPath(params.TextDocument.URI)] = params.ContentChanges[n-1].Text
+		}
+		return nil, s.publishDiagnostics()
+	case "textDocument/didSave":
+		return nil, s.publishDiagnostics()
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspResponseError{Code: lspErrorInvalidParams, Message: err.Error()}
+		}
+		delete(s.open, lspURIToPath(params.TextDocument.URI))
+		return nil, s.publishDiagnostics()
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		var params lspTextDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspResponseError{Code: lspErrorInvalidParams, Message: err.Error()}
+		}
+		path := lspURIToPath(params.TextDocument.URI)
+		text := s.documentText(path)
+		offset := lspOffsetAt(text, params.Position)
+		switch msg.Method {
+		case "textDocument/definition":
+			return s.definition(path, text, offset), nil
+		case "textDocument/hover":
+			return s.hover(path, text, offset), nil
+		default:
+			return s.completion(path, text, offset), nil
+		}
+	case "textDocument/documentSymbol":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspResponseError{Code: lspErrorInvalidParams, Message: err.Error()}
+		}
+		return s.documentSymbols(lspURIToPath(params.TextDocument.URI)), nil
+	}
+	if len(msg.ID) == 0 {
+		return nil, nil
+	}
+	return nil, &lspResponseError{Code: lspErrorMethodNotFound, Message: "method not supported: " + msg.Method}
+}
+
+// documentText prefers the editor buffer over the file on disk.
+func (s *lspServer) documentText(path string) string {
+	if text, ok := s.open[path]; ok {
+		return text
+	}
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (s *lspServer) notify(method string, params any) *lspResponseError {
+	if err := writeLSPMessage(s.out, map[string]any{"method": method, "params": params}); err != nil {
+		return &lspResponseError{Code: lspErrorParse, Message: err.Error()}
+	}
+	return nil
+}
+
+// publishDiagnostics lints every open flow root and publishes diagnostics per
+// file, including #flow/include files. URIs that had diagnostics before but
+// have none now receive an empty list so editors clear them.
+func (s *lspServer) publishDiagnostics() *lspResponseError {
+	byURI := map[string][]lspDiagnostic{}
+	for path, text := range s.open {
+		if !lspIsFlowRoot(text) {
+			continue
+		}
+		for file, diagnostics := range s.lintDiagnostics(path, text) {
+			uri := lspPathToURI(file)
+			byURI[uri] = append(byURI[uri], diagnostics...)
+		}
+	}
+	for path := range s.open {
+		if uri := lspPathToURI(path); byURI[uri] == nil {
+			byURI[uri] = []lspDiagnostic{}
+		}
+	}
+	for uri := range s.published {
+		if byURI[uri] == nil {
+			byURI[uri] = []lspDiagnostic{}
+		}
+	}
+	uris := make([]string, 0, len(byURI))
+	for uri := range byURI {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	s.published = map[string]bool{}
+	for _, uri := range uris {
+		if len(byURI[uri]) > 0 {
+			s.published[uri] = true
+		}
+		if err := s.notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": byURI[uri]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *lspServer) lintDiagnostics(path, text string) map[string][]lspDiagnostic {
+	out := map[string][]lspDiagnostic{}
+	local, err := runFlowLintLocalStage(path, text, "")
+	if err != nil {
+		out[path] = []lspDiagnostic{{Severity: lspSeverityError, Source: "breyta", Code: "lint_config_invalid", Message: err.Error()}}
+		return out
+	}
+	diagnostics := local.diagnostics
+	if local.scanned {
+		diagnostics = append(diagnostics, local.policy.apply(local.policy.unusedSuppressionDiagnostics(false))...)
+	}
+	locator := newFlowLintLocator(path, text, local.expandedLiteral, local.sourceMap)
+	for openPath, openText := range s.open {
+		locator.files[openPath] = openText
+	}
+	markFlowLintFixable(path, local, diagnostics)
+	for _, diag := range diagnostics {
+		file, offset := locator.resolve(diag)
+		src := locator.fileSource(file)
+		severity := lspSeverityInformation
+		switch flowLintDiagnosticString(diag, "severity") {
+		case "error":
+			severity = lspSeverityError
+		case "warning":
+			severity = lspSeverityWarning
+		}
+		message := flowLintDiagnosticString(diag, "message")
+		if hint := flowLintDiagnosticString(diag, "hint"); hint != "" {
+			message += "\n" + hint
+		}
+		if diag["fixable"] == true {
+			message += "\nFix with: breyta flows lint --file " + path + " --fix"
+		}
+		start, end := lspTokenBounds(src, offset)
+		out[file] = append(out[file], lspDiagnostic{
+			Range:    lspRange{Start: lspPositionAt(src, start), End: lspPositionAt(src, end)},
+			Severity: severity,
+			Code:     flowLintDiagnosticString(diag, "code"),
+			Source:   "breyta",
+			Message:  message,
+		})
+	}
+	return out
+}
+
+// indexesFor returns the flow indexes of every open root that is path itself
+// or includes it.
+func (s *lspServer) indexesFor(path string) []*lspFlowIndex {
+	roots := map[string]string{}
+	for openPath, text := range s.open {
+		roots[openPath] = text
+	}
+	if _, ok := roots[path]; !ok {
+		roots[path] = s.documentText(path)
+	}
+	paths := make([]string, 0, len(roots))
+	for root := range roots {
+		paths = append(paths, root)
+	}
+	sort.Strings(paths)
+	var out []*lspFlowIndex
+	for _, root := range paths {
+		if !lspIsFlowRoot(roots[root]) {
+			continue
+		}
+		index := buildLSPFlowIndex(root, roots[root])
+		if index != nil && index.covers(path) {
+			out = append(out, index)
+		}
+	}
+	return out
+}
+
+func (s *lspServer) location(path string, start, end int) lspLocation {
+	src := s.documentText(path)
+	return lspLocation{
+		URI:   lspPathToURI(path),
+		Range: lspRange{Start: lspPositionAt(src, start), End: lspPositionAt(src, end)},
+	}
+}
+
+func (s *lspServer) definition(path, text string, offset int) any {
+	start, end := lspTokenBounds(text, offset)
+	token := text[start:end]
+	if includePath, ok := lspIncludePathAt(text, start); ok {
+		target := filepath.Join(filepath.Dir(path), includePath)
+		if abs, err := filepath.Abs(target); err == nil {
+			target = abs
+		}
+		return []lspLocation{{URI: lspPathToURI(target)}}
+	}
+	if !strings.HasPrefix(token, ":") {
+		return nil
+	}
+	var locations []lspLocation
+	for _, index := range s.indexesFor(path) {
+		if step, ok := index.step(strings.TrimPrefix(token, ":")); ok {
+			file, fileStart := index.locate(step.NameStart)
+			_, fileEnd := index.locate(step.NameEnd)
+			locations = append(locations, s.location(file, fileStart, fileEnd))
+		}
+	}
+	if len(locations) == 0 {
+		return nil
+	}
+	return locations
+}
+
+func (s *lspServer) hover(path, text string, offset int) any {
+	start, end := lspTokenBounds(text, offset)
+	token := text[start:end]
+	if !strings.HasPrefix(token, ":") {
+		return nil
+	}
+	name := strings.TrimPrefix(token, ":")
+	var value string
+	for _, index := range s.indexesFor(path) {
+		if step, ok := index.step(name); ok {
+			value = lspStepHoverMarkdown(step, s.docs.stepDocs(index.slug, step.Name))
+			break
+		}
+	}
+	if value == "" && lspKnownStepType(name) {
+		value = s.docs.fieldsMarkdown(name)
+	}
+	if value == "" {
+		return nil
+	}
+	return map[string]any{
+		"contents": map[string]any{"kind": "markdown", "value": value},
+		"range":    lspRange{Start: lspPositionAt(text, start), End: lspPositionAt(text, end)},
+	}
+}
+
+func (s *lspServer) completion(path, text string, offset int) any {
+	start, _ := lspTokenBounds(text, offset)
+	prefix := text[start:offset]
+	head, argIndex := lspEnclosingCall(text, offset)
+	offerTypes := head == "flow/step" && argIndex == 1
+	if !offerTypes && !strings.HasPrefix(prefix, ":") {
+		return map[string]any{"isIncomplete": false, "items": []any{}}
+	}
+	items := []map[string]any{}
+	seen := map[string]bool{}
+	add := func(label string, kind int, detail string) {
+		if seen[label] || !strings.HasPrefix(label, prefix) {
+			return
+		}
+		seen[label] = true
+		items = append(items, map[string]any{"label": label, "kind": kind, "detail": detail})
+	}
+	for _, index := range s.indexesFor(path) {
+		for _, step := range index.steps {
+			detail := "step"
+			if step.Type != "" {
+				detail = "step " + step.Type
+			}
+			add(":"+step.Name, lspCompletionKindReference, detail)
+		}
+	}
+	if offerTypes || head == "" || lspCompletingStepType(text, start) {
+		for _, stepType := range docsStepTypeNames {
+			add(":"+stepType, lspCompletionKindKeyword, "step type")
+		}
+	}
+	return map[string]any{"isIncomplete": false, "items": items}
+}
+
+func (s *lspServer) documentSymbols(path string) any {
+	symbols := []map[string]any{}
+	for _, index := range s.indexesFor(path) {
+		for _, group := range [][]lspFlowSymbol{index.steps, index.schedules, index.interfaces} {
+			for _, symbol := range group {
+				file, fileStart := index.locate(symbol.Start)
+				_, fileEnd := index.locate(symbol.End)
+				_, nameStart := index.locate(symbol.NameStart)
+				_, nameEnd := index.locate(symbol.NameEnd)
+				if !sameLSPPath(file, path) {
+					continue
+				}
+				src := s.documentText(file)
+				symbols = append(symbols, map[string]any{
+					"name":           symbol.Name,
+					"detail":         symbol.Detail,
+					"kind":           symbol.Kind,
+					"range":          lspRange{Start: lspPositionAt(src, fileStart), End: lspPositionAt(src, fileEnd)},
+					"selectionRange": lspRange{Start: lspPositionAt(src, nameStart), End: lspPositionAt(src, nameEnd)},
+				})
+			}
+		}
+		break
+	}
+	return symbols
+}
+
+func lspURIToPath(uri string) string {
+	if parsed, err := url.Parse(uri); err == nil && parsed.Scheme == "file" {
+		return filepath.FromSlash(parsed.Path)
+	}
+	return filepath.FromSlash(strings.TrimPrefix(uri, "file://"))
+}
+
+func lspPathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
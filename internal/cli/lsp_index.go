@@ -0,0 +1,455 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/breyta/breyta-cli/internal/api"
+)
+
+// lspFlowSymbol is one step, schedule, or interface of a flow. Offsets are in
+// the include-expanded source of the flow root.
+type lspFlowSymbol struct {
+	Name        string
+	Kind        int
+	Detail      string
+	Type        string
+	Description string
+	Docs        string
+	Start       int
+	End         int
+	NameStart   int
+	NameEnd     int
+}
+
+// lspFlowIndex is the navigable outline of one flow root with its includes
+// expanded.
+type lspFlowIndex struct {
+	rootPath   string
+	slug       string
+	expanded   string
+	sourceMap  flowSourceMap
+	steps      []lspFlowSymbol
+	schedules  []lspFlowSymbol
+	interfaces []lspFlowSymbol
+}
+
+// lspIsFlowRoot reports whether text is a whole flow definition rather than an
+// included fragment.
+func lspIsFlowRoot(text string) bool {
+	entries, err := parseSingleTopLevelMapEntries(text)
+	if err != nil {
+		return false
+	}
+	_, hasFlow := mapEntryByKey(entries, "flow")
+	_, hasSlug := mapEntryByKey(entries, "slug")
+	return hasFlow || hasSlug
+}
+
+func buildLSPFlowIndex(rootPath, text string) *lspFlowIndex {
+	index := &lspFlowIndex{rootPath: rootPath, expanded: text}
+	if expanded, sourceMap, err := expandFlowSourceIncludesWithMap(rootPath, text); err == nil {
+		index.expanded = expanded
+		index.sourceMap = sourceMap
+	}
+	src := index.expanded
+	entries, err := parseSingleTopLevelMapEntries(src)
+	if err != nil {
+		return nil
+	}
+	if slugEntry, ok := mapEntryByKey(entries, "slug"); ok {
+		if slug, ok := clojureIdentifierFromForm(src, slugEntry.ValueStart); ok {
+			index.slug = slug
+		}
+	}
+	if stepsEntry, ok := mapEntryByKey(entries, "steps"); ok {
+		spans, _ := localFlowStepVector(src, stepsEntry)
+		for _, span := range spans {
+			if symbol, ok := lspNamedMapSymbol(src, span, lspSymbolKindFunction); ok {
+				symbol.Detail = "step"
+				if symbol.Type != "" {
+					symbol.Detail = "step " + symbol.Type
+				}
+				index.steps = append(index.steps, symbol)
+			}
+		}
+	}
+	if schedulesEntry, ok := mapEntryByKey(entries, "schedules"); ok {
+		spans, _ := localFlowScheduleVector(src, schedulesEntry)
+		for _, span := range spans {
+			if symbol, ok := lspNamedMapSymbol(src, span, lspSymbolKindEvent); ok {
+				symbol.Detail = "schedule"
+				index.schedules = append(index.schedules, symbol)
+			}
+		}
+	}
+	if interfacesEntry, ok := mapEntryByKey(entries, "interfaces"); ok && clojureFormStartsWith(src, interfacesEntry.ValueStart, '{') {
+		categories, _, _ := parseClojureMapEntries(src, interfacesEntry.ValueStart)
+		for _, category := range categories {
+			if !clojureFormStartsWith(src, category.ValueStart, '[') {
+				continue
+			}
+			items, _, err := parseClojureVectorElements(src, category.ValueStart)
+			if err != nil {
+				continue
+			}
+			for _, item := range items {
+				if symbol, ok := lspNamedMapSymbol(src, item, lspSymbolKindInterface); ok {
+					symbol.Name = category.KeyName + "/" + symbol.Name
+					symbol.Detail = category.KeyName + " interface"
+					index.interfaces = append(index.interfaces, symbol)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// lspNamedMapSymbol reads a map with an :id into a symbol. Ids are read like
+// step ids, so packaged ids such as :tools/fetch resolve.
+func lspNamedMapSymbol(src string, span clojureFormSpan, kind int) (lspFlowSymbol, bool) {
+	if !clojureFormStartsWith(src, span.Start, '{') {
+		return lspFlowSymbol{}, false
+	}
+	fields, _, err := parseClojureMapEntries(src, span.Start)
+	if err != nil {
+		return lspFlowSymbol{}, false
+	}
+	idEntry, ok := mapEntryByKey(fields, "id")
+	if !ok {
+		return lspFlowSymbol{}, false
+	}
+	name, err := localStepIDFromMap(src, span)
+	if err != nil || name == "" {
+		return lspFlowSymbol{}, false
+	}
+	nameStart := skipClojureWhitespaceCommaAndComments(src, idEntry.ValueStart)
+	symbol := lspFlowSymbol{
+		Name:      name,
+		Kind:      kind,
+		Start:     span.Start,
+		End:       span.End,
+		NameStart: nameStart,
+		NameEnd:   idEntry.ValueEnd,
+	}
+	if typeEntry, ok := mapEntryByKey(fields, "type"); ok {
+		symbol.Type = strings.TrimSpace(src[typeEntry.ValueStart:typeEntry.ValueEnd])
+	}
+	symbol.Description = lspMapStringField(src, fields, "description")
+	symbol.Docs = lspMapStringField(src, fields, "docs")
+	return symbol, true
+}
+
+func lspMapStringField(src string, fields []clojureMapEntry, key string) string {
+	entry, ok := mapEntryByKey(fields, key)
+	if !ok {
+		return ""
+	}
+	start := skipClojureWhitespaceCommaAndComments(src, entry.ValueStart)
+	if start >= len(src) || src[start] != '"' {
+		return ""
+	}
+	_, value, _, err := readClojureStringToken(src, start)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// covers reports whether path is the root or one of its included files.
+func (index *lspFlowIndex) covers(path string) bool {
+	if sameLSPPath(index.rootPath, path) {
+		return true
+	}
+	for _, segment := range index.sourceMap.Segments {
+		if sameLSPPath(segment.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (index *lspFlowIndex) step(id string) (lspFlowSymbol, bool) {
+	for _, step := range index.steps {
+		if step.Name == id {
+			return step, true
+		}
+	}
+	return lspFlowSymbol{}, false
+}
+
+// locate maps an expanded offset to the file and byte offset it came from.
+func (index *lspFlowIndex) locate(offset int) (string, int) {
+	if path, fileOffset, ok := index.sourceMap.Locate(offset); ok {
+		return path, fileOffset
+	}
+	return lspAbsPath(index.rootPath), offset
+}
+
+func lspAbsPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func sameLSPPath(a, b string) bool {
+	return lspAbsPath(a) == lspAbsPath(b)
+}
+
+func lspKnownStepType(name string) bool {
+	for _, stepType := range docsStepTypeNames {
+		if stepType == name {
+			return true
+		}
+	}
+	return false
+}
+
+func lspStepHoverMarkdown(step lspFlowSymbol, serverDocs string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**:%s**", step.Name)
+	if step.Type != "" {
+		fmt.Fprintf(&b, " `%s`", step.Type)
+	}
+	b.WriteString("\n")
+	if step.Description != "" {
+		b.WriteString("\n" + step.Description + "\n")
+	}
+	for _, docs := range []string{step.Docs, serverDocs} {
+		if strings.TrimSpace(docs) != "" {
+			b.WriteString("\n---\n\n" + strings.TrimSpace(docs) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// lspDocsSource serves hover docs from the `docs sync` cache, falling back to
+// the API when online. Results, including misses, are memoized per session.
+type lspDocsSource struct {
+	dir     string
+	app     *App
+	offline bool
+	pages   map[string]string
+	steps   map[string]string
+}
+
+func (d *lspDocsSource) page(slug string) string {
+	if d == nil {
+		return ""
+	}
+	if d.pages == nil {
+		d.pages = map[string]string{}
+	}
+	if content, ok := d.pages[slug]; ok {
+		return content
+	}
+	content := ""
+	if name, err := sanitizeDocSlug(slug); err == nil && d.dir != "" {
+		if b, err := os.ReadFile(filepath.Join(d.dir, "pages", name+".md")); err == nil {
+			content = string(b)
+		}
+	}
+	if content == "" && !d.offline && d.app != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		fetched, err := fetchDocsPageContent(ctx, api.Client{BaseURL: d.app.APIURL, Token: d.app.Token}, slug, "markdown")
+		cancel()
+		if err == nil {
+			content = fetched
+		}
+	}
+	d.pages[slug] = content
+	return content
+}
+
+// fieldsMarkdown renders the `docs fields <type>` table for a step type.
+func (d *lspDocsSource) fieldsMarkdown(stepType string) string {
+	slug := docsFieldsSlug(stepType)
+	content := d.page(slug)
+	if strings.TrimSpace(content) == "" {
+		return fmt.Sprintf("**:%s** step type\n\nRun `breyta docs sync` to enable offline field docs.", stepType)
+	}
+	rows := extractDocsConfigFieldRows(content, slug)
+	if len(rows) == 0 {
+		return content
+	}
+	var b strings.Builder
+	if err := writeDocsConfigFieldsMarkdown(&b, slug, rows); err != nil {
+		return content
+	}
+	return b.String()
+}
+
+// stepDocs returns the `steps docs get` markdown for a step when online.
+func (d *lspDocsSource) stepDocs(flowSlug, stepID string) string {
+	if d == nil || d.offline || d.app == nil || flowSlug == "" {
+		return ""
+	}
+	if d.steps == nil {
+		d.steps = map[string]string{}
+	}
+	key := flowSlug + "/" + stepID
+	if docs, ok := d.steps[key]; ok {
+		return docs
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	docs := ""
+	out, status, err := apiClient(d.app).DoCommand(ctx, "steps.docs.get", map[string]any{"flowSlug": flowSlug, "stepId": stepID})
+	if err == nil && status < 400 {
+		if data, ok := out["data"].(map[string]any); ok {
+			for _, key := range []string{"markdown", "docs"} {
+				if value, ok := data[key].(string); ok && strings.TrimSpace(value) != "" {
+					docs = value
+					break
+				}
+			}
+		}
+	}
+	d.steps[key] = docs
+	return docs
+}
+
+// lspPositionAt converts a byte offset to an LSP position, whose character
+// counts UTF-16 code units.
+func lspPositionAt(src string, offset int) lspPosition {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := strings.Count(src[:offset], "\n")
+	lineStart := strings.LastIndex(src[:offset], "\n") + 1
+	character := 0
+	for _, r := range src[lineStart:offset] {
+		character += utf16Len(r)
+	}
+	return lspPosition{Line: line, Character: character}
+}
+
+// lspOffsetAt converts an LSP position back to a byte offset, clamping to the
+// end of the line or document.
+func lspOffsetAt(src string, pos lspPosition) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(src[offset:], '\n')
+		if next < 0 {
+			return len(src)
+		}
+		offset += next + 1
+	}
+	character := 0
+	for offset < len(src) && src[offset] != '\n' && character < pos.Character {
+		r, size := utf8.DecodeRuneInString(src[offset:])
+		character += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func lspTokenDelimiter(ch byte) bool {
+	return isClojureWhitespaceOrComma(ch) || strings.IndexByte("()[]{}\";'`^@~", ch) >= 0
+}
+
+// lspTokenBounds returns the symbol or keyword token around offset. An empty
+// range at offset means the cursor is not on a token.
+func lspTokenBounds(src string, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	start, end := offset, offset
+	for start > 0 && !lspTokenDelimiter(src[start-1]) {
+		start--
+	}
+	for end < len(src) && !lspTokenDelimiter(src[end]) {
+		end++
+	}
+	return start, end
+}
+
+// lspIncludePathAt returns the include path when the token at start is the
+// string argument of #flow/include.
+func lspIncludePathAt(src string, start int) (string, bool) {
+	if start == 0 || src[start-1] != '"' {
+		return "", false
+	}
+	before := strings.TrimRight(src[:start-1], " \t\r\n,")
+	if !strings.HasSuffix(before, flowIncludeTag) {
+		return "", false
+	}
+	_, value, _, err := readClojureStringToken(src, start-1)
+	if err != nil || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return value, true
+}
+
+// lspEnclosingCall returns the head token of the innermost list open at offset
+// and the index of the argument the cursor is in (1 is the first argument).
+func lspEnclosingCall(src string, offset int) (string, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	var stack []int
+	for i := 0; i < offset; i++ {
+		switch ch := src[i]; ch {
+		case ';':
+			i = readCommentEnd(src, i) - 1
+		case '"':
+			end, err := readClojureRegexTokenEnd(src, i)
+			if err != nil || end > offset {
+				return "", 0
+			}
+			i = end - 1
+		case '\\':
+			if end, err := readClojureCharLiteralEnd(src, i); err == nil && end > i {
+				i = end - 1
+			}
+		case '(', '[', '{':
+			stack = append(stack, i)
+		case ')', ']', '}':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if len(stack) == 0 || src[stack[len(stack)-1]] != '(' {
+		return "", 0
+	}
+	head := ""
+	index := 0
+	for i := skipClojureWhitespaceCommaAndComments(src, stack[len(stack)-1]+1); i < offset; {
+		end, err := readClojureFormEnd(src, i)
+		if err != nil || end >= offset {
+			break
+		}
+		if index == 0 {
+			head = strings.TrimSpace(src[i:end])
+		}
+		index++
+		i = skipClojureWhitespaceCommaAndComments(src, end)
+	}
+	if index == 0 {
+		return "", 0
+	}
+	return head, index
+}
+
+// lspCompletingStepType reports whether the token at start is the value of a
+// :type key.
+func lspCompletingStepType(src string, start int) bool {
+	return strings.HasSuffix(strings.TrimRight(src[:start], " \t\r\n,"), ":type")
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const lspRootFlow = `{:slug :lsp-flow
+ :concurrency {:type :singleton :on-new-version :coexist}
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default}]}
+ :schedules [{:id :nightly :cron "0 2 * * *" :invocation :default}]
+ :steps [{:id :orders/fetch
+          :type :http
+          :description "Fetch open orders"
+          :defaults {:method :get :url "https://example.com/orders"}}]
+ :flow #flow/include "body.clj"}
+`
+
+const lspIncludedBody = `'(let [input (flow/input)]
+   (flow/step :orders/fetch :fetch-open {:limit 10})
+   (->> input :items))
+`
+
+type lspTestSession struct {
+	t   *testing.T
+	in  bytes.Buffer
+	seq int
+}
+
+func (s *lspTestSession) send(method string, params any, request bool) {
+	s.t.Helper()
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if request {
+		s.seq++
+		msg["id"] = s.seq
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		s.t.Fatalf("marshal: %v", err)
+	}
+	fmt.Fprintf(&s.in, "Content-Length: %d\r\n\r\n%s", len(b), b)
+}
+
+func (s *lspTestSession) run(docs *lspDocsSource) (map[int]map[string]any, []map[string]any) {
+	s.t.Helper()
+	var out bytes.Buffer
+	server := newLSPServer(&out, docs)
+	if err := server.serve(&s.in); err != nil {
+		s.t.Fatalf("serve: %v", err)
+	}
+	responses := map[int]map[string]any{}
+	var notifications []map[string]any
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := readLSPMessage(reader)
+		if err != nil {
+			break
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.t.Fatalf("decode message: %v", err)
+		}
+		if id, ok := msg["id"].(float64); ok {
+			responses[int(id)] = msg
+		} else {
+			notifications = append(notifications, msg)
+		}
+	}
+	return responses, notifications
+}
+
+func lspTestPosition(t *testing.T, src, marker string, delta int) lspPosition {
+	t.Helper()
+	idx := strings.Index(src, marker)
+	if idx < 0 {
+		t.Fatalf("marker %q not found", marker)
+	}
+	return lspPositionAt(src, idx+delta)
+}
+
+func writeLSPTestFlow(t *testing.T) (string, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "flow.clj")
+	body := filepath.Join(dir, "body.clj")
+	if err := os.WriteFile(root, []byte(lspRootFlow), 0o644); err != nil {
+		t.Fatalf("write root: %v", err)
+	}
+	if err := os.WriteFile(body, []byte(lspIncludedBody), 0o644); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+	return dir, root, body
+}
+
+func TestLSPServesDiagnosticsDefinitionHoverCompletionAndSymbols(t *testing.T) {
+	dir, root, body := writeLSPTestFlow(t)
+	docsDir := filepath.Join(dir, ".breyta-docs")
+	if err := os.MkdirAll(filepath.Join(docsDir, "pages"), 0o755); err != nil {
+		t.Fatalf("mkdir docs: %v", err)
+	}
+	page := "# HTTP step\n\n## Config\n\n| Field | Type | Required | Notes |\n| --- | --- | --- | --- |\n| `:url` | string | yes | Request URL |\n"
+	if err := os.WriteFile(filepath.Join(docsDir, "pages", "reference-step-http.md"), []byte(page), 0o644); err != nil {
+		t.Fatalf("write docs page: %v", err)
+	}
+
+	rootURI, bodyURI := lspPathToURI(root), lspPathToURI(body)
+	s := &lspTestSession{t: t}
+	s.send("initialize", map[string]any{"rootUri": lspPathToURI(dir)}, true)
+	s.send("initialized", map[string]any{}, false)
+	s.send("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": rootURI, "text": lspRootFlow}}, false)
+	s.send("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": bodyURI, "text": lspIncludedBody}}, false)
+	s.send("textDocument/definition", map[string]any{
+		"textDocument": map[string]any{"uri": bodyURI},
+		"position":     lspTestPosition(t, lspIncludedBody, ":orders/fetch", 9),
+	}, true)
+	s.send("textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": rootURI},
+		"position":     lspTestPosition(t, lspRootFlow, ":http", 2),
+	}, true)
+	s.send("textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": bodyURI},
+		"position":     lspTestPosition(t, lspIncludedBody, ":orders/fetch", 1),
+	}, true)
+	s.send("textDocument/completion", map[string]any{
+		"textDocument": map[string]any{"uri": bodyURI},
+		"position":     lspTestPosition(t, lspIncludedBody, ":orders/fetch", 4),
+	}, true)
+	s.send("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": rootURI}}, true)
+	s.send("shutdown", nil, true)
+	s.send("exit", nil, false)
+
+	responses, notifications := s.run(&lspDocsSource{dir: ".breyta-docs", offline: true})
+
+	if caps := responses[1]["result"].(map[string]any)["capabilities"].(map[string]any); caps["definitionProvider"] != true {
+		t.Fatalf("unexpected capabilities %#v", caps)
+	}
+
+	var bodyDiagnostics []any
+	for _, note := range notifications {
+		params := note["params"].(map[string]any)
+		if note["method"] == "textDocument/publishDiagnostics" && params["uri"] == bodyURI {
+			bodyDiagnostics = params["diagnostics"].([]any)
+		}
+	}
+	found := false
+	for _, diagAny := range bodyDiagnostics {
+		diag := diagAny.(map[string]any)
+		if diag["code"] == "unsupported_visual_flow_form" {
+			found = true
+			start := diag["range"].(map[string]any)["start"].(map[string]any)
+			if start["line"].(float64) != 2 || start["character"].(float64) != 4 {
+				t.Fatalf("unexpected diagnostic range %#v", diag["range"])
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected include diagnostics, got %#v", notifications)
+	}
+
+	locations := responses[2]["result"].([]any)
+	location := locations[0].(map[string]any)
+	if location["uri"] != rootURI {
+		t.Fatalf("definition should jump to the root :steps entry, got %#v", location)
+	}
+	wantStart := lspTestPosition(t, lspRootFlow, ":orders/fetch", 0)
+	start := location["range"].(map[string]any)["start"].(map[string]any)
+	if int(start["line"].(float64)) != wantStart.Line || int(start["character"].(float64)) != wantStart.Character {
+		t.Fatalf("unexpected definition range %#v want %#v", location["range"], wantStart)
+	}
+
+	typeHover := responses[3]["result"].(map[string]any)["contents"].(map[string]any)["value"].(string)
+	if !strings.Contains(typeHover, "Request URL") {
+		t.Fatalf("step type hover should show cached docs fields, got %q", typeHover)
+	}
+	stepHover := responses[4]["result"].(map[string]any)["contents"].(map[string]any)["value"].(string)
+	if !strings.Contains(stepHover, "Fetch open orders") || !strings.Contains(stepHover, ":http") {
+		t.Fatalf("unexpected step hover %q", stepHover)
+	}
+
+	items := responses[5]["result"].(map[string]any)["items"].([]any)
+	labels := map[string]bool{}
+	for _, item := range items {
+		labels[item.(map[string]any)["label"].(string)] = true
+	}
+	if !labels[":orders/fetch"] {
+		t.Fatalf("expected step id completion, got %#v", labels)
+	}
+
+	symbols := responses[6]["result"].([]any)
+	names := map[string]bool{}
+	for _, symbol := range symbols {
+		names[symbol.(map[string]any)["name"].(string)] = true
+	}
+	for _, want := range []string{"orders/fetch", "nightly", "manual/run"} {
+		if !names[want] {
+			t.Fatalf("missing symbol %q in %#v", want, names)
+		}
+	}
+}
+
+func TestLSPCompletionOffersStepTypesInStepTypePosition(t *testing.T) {
+	src := "'(let [x 1]\n   (flow/step :ht"
+	server := newLSPServer(&bytes.Buffer{}, &lspDocsSource{offline: true})
+	result := server.completion(filepath.Join(t.TempDir(), "frag.clj"), src, len(src)).(map[string]any)
+	items := result["items"].([]map[string]any)
+	if len(items) != 1 || items[0]["label"] != ":http" {
+		t.Fatalf("expected :http completion, got %#v", items)
+	}
+}
+
+func TestLSPPositionRoundTripCountsUTF16(t *testing.T) {
+	src := "a\n😀b"
+	offset := strings.Index(src, "b")
+	pos := lspPositionAt(src, offset)
+	if pos.Line != 1 || pos.Character != 2 {
+		t.Fatalf("unexpected position %#v", pos)
+	}
+	if got := lspOffsetAt(src, pos); got != offset {
+		t.Fatalf("round trip offset = %d, want %d", got, offset)
+	}
+}
+
+func TestLSPExitWithoutShutdownFails(t *testing.T) {
+	s := &lspTestSession{t: t}
+	s.send("exit", nil, false)
+	server := newLSPServer(&bytes.Buffer{}, &lspDocsSource{offline: true})
+	if err := server.serve(&s.in); err == nil {
+		t.Fatalf("expected exit without shutdown to fail")
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// projectConfigFileName is the optional per-project CLI config. It is looked up
+// from the flow file's directory upward, so one file at the repository root
+// covers every flow below it.
+const projectConfigFileName = ".breyta.json"
+
+type projectConfig struct {
+	Lint      projectLintConfig      `json:"lint"`
+	Templates projectTemplatesConfig `json:"templates"`
+
+	// path is the config file the values were read from; empty when no
+	// project config was found.
+	path string
+}
+
+type projectLintConfig struct {
+	Rules map[string]projectLintRuleConfig `json:"rules"`
+	// StepSchemas points local lint at a step-schemas.json bundle, relative
+	// to the config file. By default lint uses the nearest synced
+	// .breyta-docs bundle, then the user cache copy.
+	StepSchemas string `json:"stepSchemas,omitempty"`
+}
+
+// projectTemplatesConfig lists local flow scaffolding templates for flows new.
+// Dirs are scanned for template subdirectories; Paths names single template
+// directories. Both are relative to the config file.
+type projectTemplatesConfig struct {
+	Dirs  []string          `json:"dirs,omitempty"`
+	Paths map[string]string `json:"paths,omitempty"`
+}
+
+// projectLintRuleConfig accepts either a bare severity string ("off",
+// "info", "warning", "error") or an object with a severity and optional path
+// scoping globs relative to the config file.
+type projectLintRuleConfig struct {
+	Severity string   `json:"severity"`
+	Paths    []string `json:"paths,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+}
+
+func (r *projectLintRuleConfig) UnmarshalJSON(b []byte) error {
+	var severity string
+	if err := json.Unmarshal(b, &severity); err == nil {
+		r.Severity = severity
+		return nil
+	}
+	type plain projectLintRuleConfig
+	var decoded plain
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*r = projectLintRuleConfig(decoded)
+	return nil
+}
+
+func (c projectConfig) dir() string {
+	if c.path == "" {
+		return ""
+	}
+	return filepath.Dir(c.path)
+}
+
+// findProjectConfigPath walks from start (a file or directory) up to the
+// filesystem root looking for projectConfigFileName.
+func findProjectConfigPath(start string) (string, bool, error) {
+	absStart, err := filepath.Abs(strings.TrimSpace(start))
+	if err != nil {
+		return "", false, err
+	}
+	dir := absStart
+	if info, statErr := os.Stat(absStart); statErr != nil || !info.IsDir() {
+		dir = filepath.Dir(absStart)
+	}
+	for {
+		candidate := filepath.Join(dir, projectConfigFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true, nil
+		} else if err != nil && !os.IsNotExist(err) {
+			return "", false, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig reads an explicit config path, or discovers one from
+// start when explicit is empty. A missing discovered config is not an error.
+func loadProjectConfig(start, explicit string) (projectConfig, error) {
+	path := strings.TrimSpace(explicit)
+	if path == "" {
+		found, ok, err := findProjectConfigPath(start)
+		if err != nil || !ok {
+			return projectConfig{}, err
+		}
+		path = found
+	}
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return projectConfig{}, fmt.Errorf("read project config %s: %w", path, err)
+	}
+	var cfg projectConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return projectConfig{}, fmt.Errorf("parse project config %s: %w", path, err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return projectConfig{}, err
+	}
+	cfg.path = absPath
+	return cfg, nil
+}
+
+// projectPathMatches reports whether path matches any of the slash-separated
+// globs. Patterns are relative to baseDir; "**" matches across directories.
+func projectPathMatches(baseDir, path string, patterns []string) bool {
+	rel := path
+	if absPath, err := filepath.Abs(path); err == nil && baseDir != "" {
+		if r, err := filepath.Rel(baseDir, absPath); err == nil {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if projectGlobRegexp(pattern).MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func projectGlobRegexp(pattern string) *regexp.Regexp {
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	var out strings.Builder
+	out.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					out.WriteString("(?:.*/)?")
+				} else {
+					out.WriteString(".*")
+				}
+				continue
+			}
+			out.WriteString("[^/]*")
+		case '?':
+			out.WriteString("[^/]")
+		default:
+			out.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	out.WriteString("$")
+	return regexp.MustCompile(out.String())
+}
@@ -401,6 +401,7 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(newFeedbackCmd(app))
 	cmd.AddCommand(newAgentCmd(app))
 	cmd.AddCommand(newMCPCmd(app))
+	cmd.AddCommand(newLSPCmd(app))
 	cmd.AddCommand(newVersionCmd(app))
 	cmd.AddCommand(newUpgradeCmd(app))
 	cmd.AddCommand(newInternalCmd(app))
@@ -429,7 +430,7 @@ func commandShouldSkipBackgroundNetwork(cmd *cobra.Command) bool {
 	if topLevelCommandName(cmd) == "mcp" {
 		return true
 	}
-	return commandIsFlowsLintLocalOnly(cmd)
+	return commandIsFlowsLintLocalOnly(cmd) || commandIsFlowsDiffLocal(cmd) || commandIsFlowsGraphLocal(cmd) || commandIsFlowsLocalFileOnly(cmd)
 }
 
 func commandConsumesMCPTokenEnvCredential(cmd *cobra.Command) bool {
@@ -457,9 +458,57 @@ func commandIsFlowsLintLocalOnly(cmd *cobra.Command) bool {
 		return false
 	}
 	flag := cmd.Flags().Lookup("local-only")
+	if flag != nil && flag.Changed && strings.EqualFold(strings.TrimSpace(flag.Value.String()), "true") {
+		return true
+	}
+	// --all only calls the API when --server is passed.
+	all := cmd.Flags().Lookup("all")
+	server := cmd.Flags().Lookup("server")
+	return all != nil && all.Changed && strings.EqualFold(strings.TrimSpace(all.Value.String()), "true") &&
+		(server == nil || !strings.EqualFold(strings.TrimSpace(server.Value.String()), "true"))
+}
+
+func commandIsFlowsDiffLocal(cmd *cobra.Command) bool {
+	if cmd == nil || strings.TrimSpace(cmd.Name()) != "diff" {
+		return false
+	}
+	parent := cmd.Parent()
+	if parent == nil || strings.TrimSpace(parent.Name()) != "flows" {
+		return false
+	}
+	flag := cmd.Flags().Lookup("local")
 	return flag != nil && flag.Changed && strings.EqualFold(strings.TrimSpace(flag.Value.String()), "true")
 }
 
+// commandIsFlowsGraphLocal reports whether flows graph runs without --run and
+// therefore never needs the network.
+func commandIsFlowsGraphLocal(cmd *cobra.Command) bool {
+	if cmd == nil || strings.TrimSpace(cmd.Name()) != "graph" {
+		return false
+	}
+	parent := cmd.Parent()
+	if parent == nil || strings.TrimSpace(parent.Name()) != "flows" {
+		return false
+	}
+	flag := cmd.Flags().Lookup("run")
+	return flag == nil || strings.TrimSpace(flag.Value.String()) == ""
+}
+
+// commandIsFlowsLocalFileOnly reports whether cmd is a flows subcommand that
+// only reads and writes local files.
+func commandIsFlowsLocalFileOnly(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	switch strings.TrimSpace(cmd.Name()) {
+	case "includes", "bundle", "new":
+	default:
+		return false
+	}
+	parent := cmd.Parent()
+	return parent != nil && strings.TrimSpace(parent.Name()) == "flows"
+}
+
 func configAPIURLForMode(raw string, devMode bool) string {
 	apiURL := strings.TrimSpace(raw)
 	if apiURL == "" {
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/breyta/breyta-cli/internal/buildinfo"
+)
+
+// stepSchemaBundleFileName is written next to the synced docs pages and copied
+// into the user cache so local lint can validate step configs offline.
+const stepSchemaBundleFileName = "step-schemas.json"
+
+const stepSchemaBundleFormat = 1
+
+// stepSchemaBundle is the offline step-config schema derived from the
+// reference-step-* docs pages. Version is a hash of Steps, so two syncs of
+// the same docs produce the same version.
+type stepSchemaBundle struct {
+	Format      int                   `json:"format"`
+	Version     string                `json:"version"`
+	CLIVersion  string                `json:"cliVersion,omitempty"`
+	SourceAPI   string                `json:"sourceApi,omitempty"`
+	GeneratedAt string                `json:"generatedAt,omitempty"`
+	Steps       map[string]stepSchema `json:"steps"`
+}
+
+type stepSchema struct {
+	Fields []stepSchemaField `json:"fields"`
+}
+
+// stepSchemaField is one top-level config key. Types holds normalized value
+// kinds (string, keyword, number, boolean, map, vector); an empty Types means
+// the documented type was not recognized and values are not type checked.
+type stepSchemaField struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Types    []string `json:"types,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// buildStepSchemaBundle derives step schemas from reference-step-* markdown
+// pages keyed by slug.
+func buildStepSchemaBundle(pages map[string]string, sourceAPI string) stepSchemaBundle {
+	bundle := stepSchemaBundle{
+		Format:      stepSchemaBundleFormat,
+		CLIVersion:  buildinfo.DisplayVersion(),
+		SourceAPI:   sourceAPI,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Steps:       map[string]stepSchema{},
+	}
+	for slug, markdown := range pages {
+		if schema, ok := stepSchemaFromDocsPage(slug, markdown); ok {
+			bundle.Steps[slug] = schema
+		}
+	}
+	b, _ := json.Marshal(bundle.Steps)
+	sum := sha256.Sum256(b)
+	bundle.Version = hex.EncodeToString(sum[:8])
+	return bundle
+}
+
+func stepSchemaFromDocsPage(slug, markdown string) (stepSchema, bool) {
+	byName := map[string]*stepSchemaField{}
+	var order []string
+	for _, row := range extractDocsConfigFieldRows(markdown, slug) {
+		name := stepSchemaTopLevelKey(row.Field)
+		if name == "" {
+			continue
+		}
+		nested := name != normalizeDocsFieldName(row.Field)
+		field, ok := byName[name]
+		if !ok {
+			field = &stepSchemaField{Name: name}
+			byName[name] = field
+			order = append(order, name)
+			if !nested {
+				field.Types = stepSchemaTypes(row.Type)
+			}
+		} else if !nested && len(field.Types) > 0 {
+			// The same key documented twice (for example once per op) keeps
+			// every documented type; an unrecognized one disables checks.
+			more := stepSchemaTypes(row.Type)
+			if len(more) == 0 {
+				field.Types = nil
+			} else {
+				field.Types = mergeSortedStrings(field.Types, more)
+			}
+		}
+		for _, alias := range row.Aliases {
+			if alias := stepSchemaTopLevelKey(alias); alias != "" && alias != name && !slices.Contains(field.Aliases, alias) {
+				field.Aliases = append(field.Aliases, alias)
+			}
+		}
+		// Op tables are rendered as "<section> / <op>" sections; keys that
+		// only one op requires are not required for the step as a whole.
+		if !nested && stepSchemaRequired(row.Required) && !strings.Contains(row.Section, " / ") {
+			field.Required = true
+		}
+	}
+	if len(order) == 0 {
+		return stepSchema{}, false
+	}
+	schema := stepSchema{Fields: make([]stepSchemaField, 0, len(order))}
+	for _, name := range order {
+		schema.Fields = append(schema.Fields, *byName[name])
+	}
+	return schema, true
+}
+
+// stepSchemaTopLevelKey maps documented field names such as `:persist.type`
+// or `:headers[...]` to the top-level config key.
+func stepSchemaTopLevelKey(field string) string {
+	name := normalizeDocsFieldName(field)
+	if i := strings.IndexAny(name, ".[ "); i >= 0 {
+		name = name[:i]
+	}
+	return strings.Trim(name, ":")
+}
+
+// stepSchemaRequired only trusts an unqualified yes; "Yes*" and similar
+// footnoted cells mark conditional requirements.
+func stepSchemaRequired(cell string) bool {
+	switch strings.ToLower(cleanDocsTableCell(cell)) {
+	case "yes", "required", "true":
+		return true
+	}
+	return false
+}
+
+func stepSchemaTypes(cell string) []string {
+	cell = strings.ToLower(cleanDocsTableCell(cell))
+	if cell == "" {
+		return nil
+	}
+	cell = strings.ReplaceAll(cell, " or ", "/")
+	var types []string
+	for _, alt := range strings.FieldsFunc(cell, func(r rune) bool { return r == '/' || r == '|' || r == ',' }) {
+		word := strings.Fields(alt)
+		if len(word) == 0 {
+			continue
+		}
+		token := strings.Trim(word[0], ":`'\"")
+		if i := strings.IndexAny(token, "<[("); i > 0 {
+			token = token[:i]
+		}
+		kind := ""
+		switch token {
+		case "string", "str", "text", "url":
+			kind = "string"
+		case "keyword", "kw", "enum":
+			kind = "keyword"
+		case "int", "integer", "long", "number", "float", "double", "num":
+			kind = "number"
+		case "bool", "boolean":
+			kind = "boolean"
+		case "map", "object":
+			kind = "map"
+		case "vector", "vec", "list", "array", "seq", "coll", "collection":
+			kind = "vector"
+		default:
+			// Any type we cannot classify (any, ref, duration, expression)
+			// turns type checking off for the field.
+			return nil
+		}
+		types = append(types, kind)
+	}
+	sort.Strings(types)
+	return slices.Compact(types)
+}
+
+func mergeSortedStrings(a, b []string) []string {
+	out := append(append([]string{}, a...), b...)
+	sort.Strings(out)
+	return slices.Compact(out)
+}
+
+// writeStepSchemaBundle builds the bundle from the synced reference-step-*
+// pages under rootOut and writes it next to them. It returns false when the
+// docs had no step reference pages.
+func writeStepSchemaBundle(rootOut, sourceAPI string, pages []docsPageMeta) (stepSchemaBundle, bool, error) {
+	markdown := map[string]string{}
+	for _, page := range pages {
+		slug, err := sanitizeDocSlug(page.Slug)
+		if err != nil || !strings.HasPrefix(slug, "reference-step-") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(rootOut, "pages", slug+".md")) // #nosec G304 -- page was just written under the sync output dir.
+		if err != nil {
+			return stepSchemaBundle{}, false, err
+		}
+		markdown[slug] = string(b)
+	}
+	bundle := buildStepSchemaBundle(markdown, sourceAPI)
+	if len(bundle.Steps) == 0 {
+		return bundle, false, nil
+	}
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return stepSchemaBundle{}, false, fmt.Errorf("encode step schemas: %w", err)
+	}
+	if err := writeFile(filepath.Join(rootOut, stepSchemaBundleFileName), b); err != nil {
+		return stepSchemaBundle{}, false, err
+	}
+	if path, err := stepSchemaCachePath(); err == nil {
+		// The cache copy lets flows outside the synced project lint offline
+		// too; failing to write it is not a sync failure.
+		_ = atomicWriteFile(path, b, 0o600)
+	}
+	return bundle, true, nil
+}
+
+func stepSchemaCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(dir) == "" {
+		return "", errors.New("missing user cache dir")
+	}
+	return filepath.Join(dir, "breyta", stepSchemaBundleFileName), nil
+}
+
+// findStepSchemaBundlePath resolves the bundle local lint validates against:
+// lint.stepSchemas in the project config, then the nearest
+// .breyta-docs/step-schemas.json above the flow file, then the user cache
+// copy written by the last docs sync.
+func findStepSchemaBundlePath(file, configPath string) (string, bool) {
+	if cfg, err := loadProjectConfig(file, configPath); err == nil && strings.TrimSpace(cfg.Lint.StepSchemas) != "" {
+		path := strings.TrimSpace(cfg.Lint.StepSchemas)
+		if !filepath.IsAbs(path) && cfg.dir() != "" {
+			path = filepath.Join(cfg.dir(), path)
+		}
+		return path, true
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, ".breyta-docs", stepSchemaBundleFileName)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, true
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if path, err := stepSchemaCachePath(); err == nil {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func readStepSchemaBundle(path string) (stepSchemaBundle, error) {
+	b, err := readExplicitFile(path)
+	if err != nil {
+		return stepSchemaBundle{}, err
+	}
+	var bundle stepSchemaBundle
+	if err := json.Unmarshal(b, &bundle); err != nil {
+		return stepSchemaBundle{}, fmt.Errorf("parse step schemas %s: %w", path, err)
+	}
+	if bundle.Format != stepSchemaBundleFormat {
+		return stepSchemaBundle{}, fmt.Errorf("step schemas %s have format %d; rerun `breyta docs sync`", path, bundle.Format)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,64 @@
+{:slug :dates-crypto-child
+ :name "Receipt Pipeline"
+ :description "Imported from n8n JSON. TODO(n8n-import): review unsupported nodes and expression translations."
+ :icon :workflow
+ :tags [:n8n-import]
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :requires []
+ :templates []
+ :functions [{:id :format-day-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        parse-date (fn [v]\n                     (let [s (str v)]\n                       (if (re-find #\"T\" s)\n                         (java.time.OffsetDateTime/parse s)\n                         (.atStartOfDay (java.time.LocalDate/parse s) java.time.ZoneOffset/UTC))))\n        update-item (fn [input] (assoc input :day (.format (java.time.format.DateTimeFormatter/ofPattern \"yyyy-MM-dd\") (parse-date (get input :createdat)))))]\n    (if (sequential? (:items input))\n      (assoc input :items (mapv update-item items))\n      (update-item input))))"}
+  {:id :due-date-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        parse-date (fn [v]\n                     (let [s (str v)]\n                       (if (re-find #\"T\" s)\n                         (java.time.OffsetDateTime/parse s)\n                         (.atStartOfDay (java.time.LocalDate/parse s) java.time.ZoneOffset/UTC))))\n        update-item (fn [input] (assoc input :dueat (str (.plus (parse-date (get input :createdat)) 14 java.time.temporal.ChronoUnit/DAYS))))]\n    (if (sequential? (:items input))\n      (assoc input :items (mapv update-item items))\n      (update-item input))))"}
+  {:id :hash-email-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        digest (fn [input v]\n                 (let [bytes (.digest (java.security.MessageDigest/getInstance \"SHA-256\") (.getBytes (str v) \"UTF-8\"))]\n                   (apply str (map #(format \"%02x\" (bit-and % 0xff)) bytes))))\n        update-item (fn [input] (assoc input :emailhash (digest input (get input :email))))]\n    (if (sequential? (:items input))\n      (assoc input :items (mapv update-item items))\n      (update-item input))))"}
+  {:id :receipt-id-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        update-item (fn [input] (assoc input :receiptid (str (java.util.UUID/randomUUID))))]\n    (if (sequential? (:items input))\n      (assoc input :items (mapv update-item items))\n      (update-item input))))"}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default :enabled true}]}
+ :flow (quote (let [input (flow/input)
+        ;; n8n node: "Format Day" (n8n-nodes-base.dateTime)
+        format_day (if (:n8n-import/skipped input)
+                     input
+                     (flow/step :function :format-day
+                              {:title "Format Day"
+                               :ref :format-day-fn
+                               :input input}))
+        ;; n8n node: "Due Date" (n8n-nodes-base.dateTime)
+        due_date (if (:n8n-import/skipped format_day)
+                     format_day
+                     (flow/step :function :due-date
+                              {:title "Due Date"
+                               :ref :due-date-fn
+                               :input format_day}))
+        ;; n8n node: "Hash Email" (n8n-nodes-base.crypto)
+        hash_email (if (:n8n-import/skipped due_date)
+                     due_date
+                     (flow/step :function :hash-email
+                              {:title "Hash Email"
+                               :ref :hash-email-fn
+                               :input due_date}))
+        ;; n8n node: "Receipt Id" (n8n-nodes-base.crypto)
+        receipt_id (if (:n8n-import/skipped hash_email)
+                     hash_email
+                     (flow/step :function :receipt-id
+                              {:title "Receipt Id"
+                               :ref :receipt-id-fn
+                               :input hash_email}))
+        ;; n8n node: "Send Receipt" (n8n-nodes-base.executeWorkflow)
+        send_receipt (if (:n8n-import/skipped receipt_id)
+                     receipt_id
+                     (flow/step :fanout :send-receipt
+                              {:title "Send Receipt"
+                               :flow :send-receipt-email
+                               :items [receipt_id]}))
+        ;; n8n node: "Done" (n8n-nodes-base.noOp)
+        done (if (:n8n-import/skipped send_receipt)
+                     send_receipt
+                     send_receipt)
+        ]
+    done))}
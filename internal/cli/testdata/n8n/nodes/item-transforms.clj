@@ -0,0 +1,63 @@
+{:slug :item-transforms
+ :name "Paid Orders"
+ :description "Imported from n8n JSON. TODO(n8n-import): review unsupported nodes and expression translations."
+ :icon :workflow
+ :tags [:n8n-import]
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :requires []
+ :templates []
+ :functions [{:id :only-paid-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        keep? (fn [input] (= (get input :status) \"paid\"))\n        kept (filterv keep? items)]\n    (cond\n      (sequential? (:items input)) (assoc input :items kept)\n      (seq kept) input\n      :else (assoc input :n8n-import/skipped true))))"}
+  {:id :newest-first-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        by-fields (fn [a b]\n                    (or (first (remove zero? [(compare (get b :createdat) (get a :createdat))\n                                              (compare (get-in a [:customer :name]) (get-in b [:customer :name]))]))\n                        0))]\n    (assoc input :items (vec (sort by-fields items)))))"}
+  {:id :unique-customers-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))\n        item-key (fn [item] [(get-in item [:customer :id])])\n        unique (second (reduce (fn [[seen out] item]\n                                  (let [k (item-key item)]\n                                    (if (contains? seen k)\n                                      [seen out]\n                                      [(conj seen k) (conj out item)])))\n                                [#{} []]\n                                items))]\n    (assoc input :items unique)))"}
+  {:id :top-10-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))]\n    (assoc input :items (vec (take 10 items)))))"}
+  {:id :all-orders-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))]\n    {:orders items}))"}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default :enabled true}]}
+ :flow (quote (let [input (flow/input)
+        ;; n8n node: "Only Paid" (n8n-nodes-base.filter)
+        only_paid (if (:n8n-import/skipped input)
+                     input
+                     (flow/step :function :only-paid
+                              {:title "Only Paid"
+                               :ref :only-paid-fn
+                               :input input}))
+        ;; n8n node: "Newest First" (n8n-nodes-base.sort)
+        newest_first (if (:n8n-import/skipped only_paid)
+                     only_paid
+                     (flow/step :function :newest-first
+                              {:title "Newest First"
+                               :ref :newest-first-fn
+                               :input only_paid}))
+        ;; n8n node: "Unique Customers" (n8n-nodes-base.removeDuplicates)
+        unique_customers (if (:n8n-import/skipped newest_first)
+                     newest_first
+                     (flow/step :function :unique-customers
+                              {:title "Unique Customers"
+                               :ref :unique-customers-fn
+                               :input newest_first}))
+        ;; n8n node: "Top 10" (n8n-nodes-base.limit)
+        top_10 (if (:n8n-import/skipped unique_customers)
+                     unique_customers
+                     (flow/step :function :top-10
+                              {:title "Top 10"
+                               :ref :top-10-fn
+                               :input unique_customers}))
+        ;; n8n node: "All Orders" (n8n-nodes-base.aggregate)
+        all_orders (if (:n8n-import/skipped top_10)
+                     top_10
+                     (flow/step :function :all-orders
+                              {:title "All Orders"
+                               :ref :all-orders-fn
+                               :input top_10}))
+        ]
+    all_orders))}
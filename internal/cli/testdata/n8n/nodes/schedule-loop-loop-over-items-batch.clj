@@ -0,0 +1,18 @@
+{:slug :schedule-loop-loop-over-items-batch
+ :name "Nightly Batches / Loop Over Items"
+ :description "Imported from n8n JSON. TODO(n8n-import): review unsupported nodes and expression translations."
+ :icon :workflow
+ :tags [:n8n-import]
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :requires []
+ :templates []
+ :functions []
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default :enabled true}]}
+ :flow (quote (let [input (flow/input)
+        ;; n8n node: "Process Batch" (n8n-nodes-base.noOp)
+        process_batch (if (:n8n-import/skipped input)
+                     input
+                     input)
+        ]
+    process_batch))}
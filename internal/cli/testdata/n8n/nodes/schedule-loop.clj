@@ -0,0 +1,43 @@
+{:slug :schedule-loop
+ :name "Nightly Batches"
+ :description "Imported from n8n JSON. TODO(n8n-import): review unsupported nodes and expression translations."
+ :icon :workflow
+ :tags [:n8n-import]
+ :concurrency {:type :singleton :on-new-version :supersede}
+ :requires []
+ :templates []
+ :functions [{:id :collect-ids-fn
+  :language :clojure
+  :code "(fn [input]\n  (let [items (let [v (:items input)] (if (sequential? v) (vec v) [input]))]\n    {:processedids (vec (keep (fn [item] (get item :id)) items))}))"}]
+ :invocations {:default {:inputs []}}
+ :interfaces {:manual [{:id :run :label "Run" :invocation :default :enabled true}]}
+ :schedules [{:id :schedule-trigger
+  :label "Schedule Trigger"
+  :invocation :default
+  :enabled true
+  :cron "30 2 * * *"
+  :timezone "Europe/Oslo"}
+  {:id :schedule-trigger-2
+  :label "Schedule Trigger"
+  :invocation :default
+  :enabled true
+  :cron "0 12 * * 1-5"
+  :timezone "Europe/Oslo"}]
+ :flow (quote (let [input (flow/input)
+        ;; n8n node: "Loop Over Items" (n8n-nodes-base.splitInBatches)
+        loop_over_items (if (:n8n-import/skipped input)
+                     input
+                     (flow/step :fanout :loop-over-items
+                              {:title "Loop Over Items"
+                               :flow :schedule-loop-loop-over-items-batch
+                               :items (vec (for [batch (partition-all 25 (let [v (:items input)] (if (sequential? v) (vec v) [input])))]
+                                             {:items (vec batch)}))}))
+        ;; n8n node: "Collect Ids" (n8n-nodes-base.aggregate)
+        collect_ids (if (:n8n-import/skipped loop_over_items)
+                     loop_over_items
+                     (flow/step :function :collect-ids
+                              {:title "Collect Ids"
+                               :ref :collect-ids-fn
+                               :input loop_over_items}))
+        ]
+    collect_ids))}
@@ -29,6 +29,7 @@ func configureVisibility(root *cobra.Command, app *App) {
 		"run":        true, // alias
 		"resources":  true,
 		"docs":       true,
+		"lsp":        true,
 		"feedback":   true,
 		"agent":      true,
 		"auth":       true,
@@ -63,8 +64,16 @@ func configureVisibility(root *cobra.Command, app *App) {
 		"show":          true,
 		"create":        true,
 		"init":          true,
+		"new":           true,
+		"dev":           true,
+		"test":          true,
+		"snapshot":      true,
+		"functions":     true,
 		"configure":     true,
 		"diff":          true,
+		"graph":         true,
+		"includes":      true,
+		"bundle":        true,
 		"pull":          true,
 		"push":          true,
 		"update":        true,
@@ -72,6 +81,8 @@ func configureVisibility(root *cobra.Command, app *App) {
 		"lint":          true,
 		"paren-check":   true,
 		"compose":       true,
+		"edit":          true,
+		"query":         true,
 		"steps":         true,
 		"schedules":     true,
 		"templates":     true,
@@ -0,0 +1,585 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	posInf = math.Inf(1)
+	negInf = math.Inf(-1)
+	nan    = math.NaN()
+)
+
+// core holds the builtin functions by name. clojure.string and clojure.set
+// functions are registered under their full namespace; resolve maps the
+// usual aliases onto them.
+var core = map[string]any{}
+
+func def(name string, fn func(in *Interp, args []any) (any, error)) {
+	core[name] = &Builtin{Name: name, Fn: fn}
+}
+
+// defN registers a builtin that takes between min and max args; max < 0
+// means variadic.
+func defN(name string, min, max int, fn func(in *Interp, args []any) (any, error)) {
+	def(name, func(in *Interp, args []any) (any, error) {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return nil, arityError(len(args), name)
+		}
+		return fn(in, args)
+	})
+}
+
+func def1(name string, fn func(v any) (any, error)) {
+	defN(name, 1, 1, func(_ *Interp, args []any) (any, error) { return fn(args[0]) })
+}
+
+func defPred(name string, pred func(v any) bool) {
+	def1(name, func(v any) (any, error) { return pred(v), nil })
+}
+
+// reducedValue is the marker (reduced x) returns to stop a reduce early.
+type reducedValue struct{ v any }
+
+func init() {
+	initNumbers()
+	initPredicates()
+	initCollections()
+	initSeqs()
+	initFunctions()
+	initStrings()
+}
+
+// lookupKey is get without a default: maps by key, vectors and strings by
+// index, sets by membership.
+func lookupKey(coll, key any) (any, bool) {
+	switch c := coll.(type) {
+	case *Map:
+		return c.Get(key)
+	case Vector:
+		if i, ok := key.(int64); ok && i >= 0 && int(i) < len(c) {
+			return c[i], true
+		}
+	case *Set:
+		if c.Contains(key) {
+			return key, true
+		}
+	case string:
+		if i, ok := key.(int64); ok && i >= 0 {
+			runes := []rune(c)
+			if int(i) < len(runes) {
+				return string(runes[i]), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// seqItems returns the items (seq v) would walk; maps yield [k v] entries
+// and strings yield one-character strings.
+func seqItems(v any) ([]any, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case List:
+		return t, nil
+	case Vector:
+		return t, nil
+	case *Map:
+		return t.Entries(), nil
+	case *Set:
+		return t.Items(), nil
+	case string:
+		out := make([]any, 0, utf8.RuneCountInString(t))
+		for _, r := range t {
+			out = append(out, string(r))
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("don't know how to create a seq from %s", typeName(v))
+}
+
+func initNumbers() {
+	def("+", func(_ *Interp, args []any) (any, error) {
+		return arith("+", args, 0, addInt, func(a, b float64) float64 { return a + b })
+	})
+	def("*", func(_ *Interp, args []any) (any, error) {
+		return arith("*", args, 1, mulInt, func(a, b float64) float64 { return a * b })
+	})
+	defN("-", 1, -1, func(_ *Interp, args []any) (any, error) {
+		if len(args) == 1 {
+			args = []any{int64(0), args[0]}
+		}
+		return arith("-", args, 0, subInt, func(a, b float64) float64 { return a - b })
+	})
+	defN("/", 1, -1, func(_ *Interp, args []any) (any, error) {
+		if len(args) == 1 {
+			args = []any{int64(1), args[0]}
+		}
+		out := args[0]
+		if _, err := toFloat(out); err != nil {
+			return nil, err
+		}
+		for _, arg := range args[1:] {
+			var err error
+			if out, err = divide(out, arg); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	})
+	defN("quot", 2, 2, func(_ *Interp, args []any) (any, error) { return intDivision("quot", args[0], args[1]) })
+	defN("rem", 2, 2, func(_ *Interp, args []any) (any, error) { return intDivision("rem", args[0], args[1]) })
+	defN("mod", 2, 2, func(_ *Interp, args []any) (any, error) { return intDivision("mod", args[0], args[1]) })
+	def1("inc", func(v any) (any, error) { return arith("inc", []any{v, int64(1)}, 0, addInt, nil) })
+	def1("dec", func(v any) (any, error) { return arith("dec", []any{v, int64(1)}, 0, subInt, nil) })
+	defN("max", 1, -1, func(_ *Interp, args []any) (any, error) { return extreme(args, 1) })
+	defN("min", 1, -1, func(_ *Interp, args []any) (any, error) { return extreme(args, -1) })
+	def1("abs", func(v any) (any, error) {
+		switch n := v.(type) {
+		case int64:
+			if n < 0 {
+				return -n, nil
+			}
+			return n, nil
+		case float64:
+			return math.Abs(n), nil
+		}
+		return nil, notANumber(v)
+	})
+	defN("==", 1, -1, func(_ *Interp, args []any) (any, error) {
+		return compareChain(args, func(c int) bool { return c == 0 })
+	})
+	defN("<", 1, -1, func(_ *Interp, args []any) (any, error) {
+		return compareChain(args, func(c int) bool { return c < 0 })
+	})
+	defN(">", 1, -1, func(_ *Interp, args []any) (any, error) {
+		return compareChain(args, func(c int) bool { return c > 0 })
+	})
+	defN("<=", 1, -1, func(_ *Interp, args []any) (any, error) {
+		return compareChain(args, func(c int) bool { return c <= 0 })
+	})
+	defN(">=", 1, -1, func(_ *Interp, args []any) (any, error) {
+		return compareChain(args, func(c int) bool { return c >= 0 })
+	})
+	defN("=", 1, -1, func(_ *Interp, args []any) (any, error) {
+		for _, arg := range args[1:] {
+			if !Equal(args[0], arg) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	core["identical?"] = core["="]
+	defN("not=", 1, -1, func(_ *Interp, args []any) (any, error) {
+		for _, arg := range args[1:] {
+			if !Equal(args[0], arg) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	defN("compare", 2, 2, func(_ *Interp, args []any) (any, error) {
+		c, err := compareValues(args[0], args[1])
+		return int64(c), err
+	})
+	def1("int", toInt)
+	def1("long", toInt)
+	def1("double", func(v any) (any, error) { return toFloat(v) })
+	def1("parse-long", func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", typeName(v))
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		return n, nil
+	})
+	def1("parse-double", func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", typeName(v))
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, nil
+		}
+		return f, nil
+	})
+}
+
+func notANumber(v any) error {
+	return fmt.Errorf("%s is not a number", typeName(v))
+}
+
+func addInt(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (a >= 0) == (b >= 0) && (sum >= 0) != (a >= 0)
+}
+
+func subInt(a, b int64) (int64, bool) {
+	if b == math.MinInt64 {
+		return 0, true
+	}
+	return addInt(a, -b)
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, false
+	}
+	hi, lo := bits.Mul64(uint64(absInt(a)), uint64(absInt(b)))
+	if hi != 0 || lo > math.MaxInt64 {
+		return 0, true
+	}
+	if (a < 0) != (b < 0) {
+		return -int64(lo), false
+	}
+	return int64(lo), false
+}
+
+func absInt(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// arith folds args with integer math until a double shows up, then
+// continues in floating point. Integer overflow is an error, as in Clojure.
+func arith(name string, args []any, identity int64, intOp func(a, b int64) (int64, bool), floatOp func(a, b float64) float64) (any, error) {
+	var acc any = identity
+	if len(args) > 0 {
+		acc = args[0]
+		args = args[1:]
+	}
+	if _, err := toFloat(acc); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		a, aInt := acc.(int64)
+		b, bInt := arg.(int64)
+		if aInt && bInt {
+			out, overflow := intOp(a, b)
+			if overflow {
+				return nil, errors.New("integer overflow")
+			}
+			acc = out
+			continue
+		}
+		x, err := toFloat(acc)
+		if err != nil {
+			return nil, err
+		}
+		y, err := toFloat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if floatOp == nil {
+			return nil, fmt.Errorf("%s: unsupported operands", name)
+		}
+		acc = floatOp(x, y)
+	}
+	return acc, nil
+}
+
+func divide(a, b any) (any, error) {
+	x, xInt := a.(int64)
+	y, yInt := b.(int64)
+	if xInt && yInt {
+		if y == 0 {
+			return nil, errors.New("divide by zero")
+		}
+		if x%y == 0 {
+			return x / y, nil
+		}
+		return float64(x) / float64(y), nil
+	}
+	fx, err := toFloat(a)
+	if err != nil {
+		return nil, err
+	}
+	fy, err := toFloat(b)
+	if err != nil {
+		return nil, err
+	}
+	return fx / fy, nil
+}
+
+func intDivision(name string, a, b any) (any, error) {
+	x, xInt := a.(int64)
+	y, yInt := b.(int64)
+	if xInt && yInt {
+		if y == 0 {
+			return nil, errors.New("divide by zero")
+		}
+		switch name {
+		case "quot":
+			return x / y, nil
+		case "rem":
+			return x % y, nil
+		}
+		m := x % y
+		if m != 0 && (m < 0) != (y < 0) {
+			m += y
+		}
+		return m, nil
+	}
+	fx, err := toFloat(a)
+	if err != nil {
+		return nil, err
+	}
+	fy, err := toFloat(b)
+	if err != nil {
+		return nil, err
+	}
+	if fy == 0 {
+		return nil, errors.New("divide by zero")
+	}
+	switch name {
+	case "quot":
+		return math.Trunc(fx / fy), nil
+	case "rem":
+		return math.Mod(fx, fy), nil
+	}
+	m := math.Mod(fx, fy)
+	if m != 0 && (m < 0) != (fy < 0) {
+		m += fy
+	}
+	return m, nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+	return 0, notANumber(v)
+}
+
+func toInt(v any) (any, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("can't convert %s to an integer", formatDouble(n))
+		}
+		return int64(n), nil
+	case string:
+		if utf8.RuneCountInString(n) == 1 {
+			r, _ := utf8.DecodeRuneInString(n)
+			return int64(r), nil
+		}
+	}
+	return nil, notANumber(v)
+}
+
+func extreme(args []any, sign int) (any, error) {
+	best := args[0]
+	if _, err := toFloat(best); err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		c, err := compareNumbers(arg, best)
+		if err != nil {
+			return nil, err
+		}
+		if c*sign > 0 {
+			best = arg
+		}
+	}
+	return best, nil
+}
+
+func compareNumbers(a, b any) (int, error) {
+	x, xInt := a.(int64)
+	y, yInt := b.(int64)
+	if xInt && yInt {
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	fx, err := toFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	fy, err := toFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case fx < fy:
+		return -1, nil
+	case fx > fy:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func compareChain(args []any, ok func(int) bool) (any, error) {
+	if _, err := toFloat(args[0]); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(args); i++ {
+		c, err := compareNumbers(args[i-1], args[i])
+		if err != nil {
+			return nil, err
+		}
+		if !ok(c) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareValues implements compare: nil sorts first, numbers numerically,
+// strings, keywords and symbols lexically, vectors by length then item by
+// item.
+func compareValues(a, b any) (int, error) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, nil
+		case a == nil:
+			return -1, nil
+		}
+		return 1, nil
+	}
+	switch x := a.(type) {
+	case int64, float64:
+		return compareNumbers(a, b)
+	case string:
+		if y, ok := b.(string); ok {
+			return compareStrings(x, y), nil
+		}
+	case Keyword:
+		if y, ok := b.(Keyword); ok {
+			return compareStrings(string(x), string(y)), nil
+		}
+	case Symbol:
+		if y, ok := b.(Symbol); ok {
+			return compareStrings(string(x), string(y)), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, nil
+			case !x:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case Vector:
+		if y, ok := b.(Vector); ok {
+			if len(x) != len(y) {
+				return compareNumbers(int64(len(x)), int64(len(y)))
+			}
+			for i := range x {
+				c, err := compareValues(x[i], y[i])
+				if err != nil || c != 0 {
+					return c, err
+				}
+			}
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare %s to %s", typeName(a), typeName(b))
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func initPredicates() {
+	defPred("nil?", func(v any) bool { return v == nil })
+	defPred("some?", func(v any) bool { return v != nil })
+	defPred("true?", func(v any) bool { return v == true })
+	defPred("false?", func(v any) bool { return v == false })
+	defPred("not", func(v any) bool { return !truthy(v) })
+	defPred("boolean", truthy)
+	defPred("string?", func(v any) bool { _, ok := v.(string); return ok })
+	defPred("keyword?", func(v any) bool { _, ok := v.(Keyword); return ok })
+	defPred("symbol?", func(v any) bool { _, ok := v.(Symbol); return ok })
+	defPred("boolean?", func(v any) bool { _, ok := v.(bool); return ok })
+	defPred("number?", func(v any) bool { _, err := toFloat(v); return err == nil })
+	defPred("integer?", func(v any) bool { _, ok := v.(int64); return ok })
+	defPred("int?", func(v any) bool { _, ok := v.(int64); return ok })
+	defPred("double?", func(v any) bool { _, ok := v.(float64); return ok })
+	defPred("float?", func(v any) bool { _, ok := v.(float64); return ok })
+	defPred("map?", func(v any) bool { _, ok := v.(*Map); return ok })
+	defPred("vector?", func(v any) bool { _, ok := v.(Vector); return ok })
+	defPred("list?", func(v any) bool { _, ok := v.(List); return ok })
+	defPred("seq?", func(v any) bool { _, ok := v.(List); return ok })
+	defPred("set?", func(v any) bool { _, ok := v.(*Set); return ok })
+	defPred("fn?", func(v any) bool {
+		switch v.(type) {
+		case *Fn, *Builtin:
+			return true
+		}
+		return false
+	})
+	defPred("ifn?", isFn)
+	defPred("coll?", func(v any) bool {
+		switch v.(type) {
+		case List, Vector, *Map, *Set:
+			return true
+		}
+		return false
+	})
+	defPred("sequential?", func(v any) bool {
+		switch v.(type) {
+		case List, Vector:
+			return true
+		}
+		return false
+	})
+	defPred("seqable?", func(v any) bool { _, err := seqItems(v); return err == nil })
+	defPred("nat-int?", func(v any) bool { n, ok := v.(int64); return ok && n >= 0 })
+	defPred("pos-int?", func(v any) bool { n, ok := v.(int64); return ok && n > 0 })
+	defPred("neg-int?", func(v any) bool { n, ok := v.(int64); return ok && n < 0 })
+	defPred("NaN?", func(v any) bool { f, ok := v.(float64); return ok && math.IsNaN(f) })
+	numberPred := func(name string, pred func(float64) bool) {
+		def1(name, func(v any) (any, error) {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, err
+			}
+			return pred(f), nil
+		})
+	}
+	numberPred("zero?", func(f float64) bool { return f == 0 })
+	numberPred("pos?", func(f float64) bool { return f > 0 })
+	numberPred("neg?", func(f float64) bool { return f < 0 })
+	intPred := func(name string, pred func(int64) bool) {
+		def1(name, func(v any) (any, error) {
+			n, ok := v.(int64)
+			if !ok {
+				return nil, fmt.Errorf("argument must be an integer, got %s", typeName(v))
+			}
+			return pred(n), nil
+		})
+	}
+	intPred("even?", func(n int64) bool { return n%2 == 0 })
+	intPred("odd?", func(n int64) bool { return n%2 != 0 })
+}
@@ -0,0 +1,476 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+func initCollections() {
+	def1("count", func(v any) (any, error) {
+		switch t := v.(type) {
+		case string:
+			return int64(utf8.RuneCountInString(t)), nil
+		case *Map:
+			return int64(t.Len()), nil
+		}
+		items, err := seqItems(v)
+		return int64(len(items)), err
+	})
+	def1("empty?", func(v any) (any, error) {
+		items, err := seqItems(v)
+		return len(items) == 0, err
+	})
+	def1("not-empty", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return v, nil
+	})
+	def1("seq", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return List(items), nil
+	})
+	def1("first", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return items[0], nil
+	})
+	def1("second", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) < 2 {
+			return nil, err
+		}
+		return items[1], nil
+	})
+	def1("last", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return items[len(items)-1], nil
+	})
+	def1("rest", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) == 0 {
+			return List{}, err
+		}
+		return List(items[1:]), nil
+	})
+	def1("next", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) < 2 {
+			return nil, err
+		}
+		return List(items[1:]), nil
+	})
+	def1("butlast", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil || len(items) < 2 {
+			return nil, err
+		}
+		return List(items[:len(items)-1]), nil
+	})
+	defN("nth", 2, 3, func(_ *Interp, args []any) (any, error) {
+		items, err := seqItems(args[0])
+		if err != nil {
+			return nil, err
+		}
+		i, ok := args[1].(int64)
+		if !ok {
+			return nil, fmt.Errorf("index must be an integer, got %s", typeName(args[1]))
+		}
+		if i < 0 || int(i) >= len(items) {
+			if len(args) == 3 {
+				return args[2], nil
+			}
+			return nil, fmt.Errorf("index %d out of bounds for %d items", i, len(items))
+		}
+		return items[i], nil
+	})
+	defN("get", 2, 3, func(_ *Interp, args []any) (any, error) {
+		return getWithDefault(args[0], args[1], args[2:])
+	})
+	defN("get-in", 2, 3, func(_ *Interp, args []any) (any, error) {
+		path, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		v := args[0]
+		for _, key := range path {
+			next, ok := lookupKey(v, key)
+			if !ok {
+				if len(args) == 3 {
+					return args[2], nil
+				}
+				return nil, nil
+			}
+			v = next
+		}
+		return v, nil
+	})
+	defN("contains?", 2, 2, func(_ *Interp, args []any) (any, error) {
+		switch args[0].(type) {
+		case nil, *Map, *Set, Vector, string:
+			_, ok := lookupKey(args[0], args[1])
+			return ok, nil
+		}
+		return nil, fmt.Errorf("contains? not supported on %s", typeName(args[0]))
+	})
+	defN("assoc", 3, -1, func(_ *Interp, args []any) (any, error) {
+		if len(args)%2 != 1 {
+			return nil, errors.New("assoc expects key/value pairs")
+		}
+		out := args[0]
+		for i := 1; i < len(args); i += 2 {
+			var err error
+			if out, err = assoc(out, args[i], args[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	})
+	defN("assoc-in", 3, 3, func(_ *Interp, args []any) (any, error) {
+		path, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return updateIn(args[0], path, func(any) (any, error) { return args[2], nil })
+	})
+	defN("update", 3, -1, func(in *Interp, args []any) (any, error) {
+		return updateIn(args[0], []any{args[1]}, func(old any) (any, error) {
+			return in.apply(args[2], append([]any{old}, args[3:]...))
+		})
+	})
+	defN("update-in", 3, -1, func(in *Interp, args []any) (any, error) {
+		path, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return updateIn(args[0], path, func(old any) (any, error) {
+			return in.apply(args[2], append([]any{old}, args[3:]...))
+		})
+	})
+	defN("dissoc", 1, -1, func(_ *Interp, args []any) (any, error) {
+		switch m := args[0].(type) {
+		case nil:
+			return nil, nil
+		case *Map:
+			return m.Dissoc(args[1:]...), nil
+		}
+		return nil, fmt.Errorf("dissoc needs a map, got %s", typeName(args[0]))
+	})
+	defN("disj", 1, -1, func(_ *Interp, args []any) (any, error) {
+		switch s := args[0].(type) {
+		case nil:
+			return nil, nil
+		case *Set:
+			return s.Disj(args[1:]...), nil
+		}
+		return nil, fmt.Errorf("disj needs a set, got %s", typeName(args[0]))
+	})
+	def("merge", func(_ *Interp, args []any) (any, error) {
+		return mergeMaps(args, nil)
+	})
+	defN("merge-with", 1, -1, func(in *Interp, args []any) (any, error) {
+		return mergeMaps(args[1:], func(a, b any) (any, error) { return in.apply(args[0], []any{a, b}) })
+	})
+	defN("select-keys", 2, 2, func(_ *Interp, args []any) (any, error) {
+		keys, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for _, key := range keys {
+			if v, ok := lookupKey(args[0], key); ok {
+				out.set(key, v)
+			}
+		}
+		return out, nil
+	})
+	def1("keys", func(v any) (any, error) {
+		m, err := asMap(v)
+		if err != nil || m.Len() == 0 {
+			return nil, err
+		}
+		return List(m.Keys()), nil
+	})
+	def1("vals", func(v any) (any, error) {
+		m, err := asMap(v)
+		if err != nil || m.Len() == 0 {
+			return nil, err
+		}
+		return List(m.Vals()), nil
+	})
+	def1("key", func(v any) (any, error) {
+		if e, ok := v.(Vector); ok && len(e) == 2 {
+			return e[0], nil
+		}
+		return nil, fmt.Errorf("key needs a map entry, got %s", typeName(v))
+	})
+	def1("val", func(v any) (any, error) {
+		if e, ok := v.(Vector); ok && len(e) == 2 {
+			return e[1], nil
+		}
+		return nil, fmt.Errorf("val needs a map entry, got %s", typeName(v))
+	})
+	def("conj", func(_ *Interp, args []any) (any, error) {
+		if len(args) == 0 {
+			return Vector{}, nil
+		}
+		return conjAll(args[0], args[1:])
+	})
+	defN("into", 2, 2, func(_ *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return conjAll(args[0], items)
+	})
+	defN("cons", 2, 2, func(_ *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return append(List{args[0]}, items...), nil
+	})
+	def("concat", func(_ *Interp, args []any) (any, error) {
+		out := List{}
+		for _, arg := range args {
+			items, err := seqItems(arg)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, items...)
+		}
+		return out, nil
+	})
+	def1("vec", func(v any) (any, error) {
+		items, err := seqItems(v)
+		return append(Vector{}, items...), err
+	})
+	def("vector", func(_ *Interp, args []any) (any, error) { return append(Vector{}, args...), nil })
+	def("list", func(_ *Interp, args []any) (any, error) { return append(List{}, args...), nil })
+	def("hash-map", func(_ *Interp, args []any) (any, error) {
+		if len(args)%2 != 0 {
+			return nil, errors.New("hash-map expects key/value pairs")
+		}
+		out := NewMap()
+		for i := 0; i < len(args); i += 2 {
+			out.set(args[i], args[i+1])
+		}
+		return out, nil
+	})
+	core["array-map"] = core["hash-map"]
+	def1("set", func(v any) (any, error) {
+		items, err := seqItems(v)
+		return NewSet(items...), err
+	})
+	def("hash-set", func(_ *Interp, args []any) (any, error) { return NewSet(args...), nil })
+	defN("zipmap", 2, 2, func(_ *Interp, args []any) (any, error) {
+		keys, err := seqItems(args[0])
+		if err != nil {
+			return nil, err
+		}
+		vals, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for i := 0; i < len(keys) && i < len(vals); i++ {
+			out.set(keys[i], vals[i])
+		}
+		return out, nil
+	})
+	def1("frequencies", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for _, item := range items {
+			n, _ := out.Get(item)
+			count, _ := n.(int64)
+			out.set(item, count+1)
+		}
+		return out, nil
+	})
+	defN("update-keys", 2, 2, func(in *Interp, args []any) (any, error) {
+		m, err := asMap(args[0])
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for i, key := range m.Keys() {
+			k, err := in.apply(args[1], []any{key})
+			if err != nil {
+				return nil, err
+			}
+			out.set(k, m.vals[i])
+		}
+		return out, nil
+	})
+	defN("update-vals", 2, 2, func(in *Interp, args []any) (any, error) {
+		m, err := asMap(args[0])
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for i, key := range m.Keys() {
+			v, err := in.apply(args[1], []any{m.vals[i]})
+			if err != nil {
+				return nil, err
+			}
+			out.set(key, v)
+		}
+		return out, nil
+	})
+	defN("reduce-kv", 3, 3, func(in *Interp, args []any) (any, error) {
+		acc := args[1]
+		var pairs [][2]any
+		switch coll := args[2].(type) {
+		case nil:
+		case *Map:
+			for i, key := range coll.keys {
+				pairs = append(pairs, [2]any{key, coll.vals[i]})
+			}
+		case Vector:
+			for i, v := range coll {
+				pairs = append(pairs, [2]any{int64(i), v})
+			}
+		default:
+			return nil, fmt.Errorf("reduce-kv needs a map or vector, got %s", typeName(args[2]))
+		}
+		for _, pair := range pairs {
+			var err error
+			if acc, err = in.apply(args[0], []any{acc, pair[0], pair[1]}); err != nil {
+				return nil, err
+			}
+			if r, ok := acc.(*reducedValue); ok {
+				return r.v, nil
+			}
+		}
+		return acc, nil
+	})
+}
+
+func asMap(v any) (*Map, error) {
+	switch m := v.(type) {
+	case nil:
+		return NewMap(), nil
+	case *Map:
+		return m, nil
+	}
+	return nil, fmt.Errorf("expected a map, got %s", typeName(v))
+}
+
+func assoc(coll, key, val any) (any, error) {
+	switch c := coll.(type) {
+	case nil:
+		return NewMap().Assoc(key, val), nil
+	case *Map:
+		return c.Assoc(key, val), nil
+	case Vector:
+		i, ok := key.(int64)
+		if !ok || i < 0 || int(i) > len(c) {
+			return nil, fmt.Errorf("index %s out of bounds for vector of %d", PrStr(key), len(c))
+		}
+		out := append(Vector{}, c...)
+		if int(i) == len(c) {
+			return append(out, val), nil
+		}
+		out[i] = val
+		return out, nil
+	}
+	return nil, fmt.Errorf("assoc needs a map or vector, got %s", typeName(coll))
+}
+
+func updateIn(coll any, path []any, f func(old any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return f(coll)
+	}
+	child, _ := lookupKey(coll, path[0])
+	updated, err := updateIn(child, path[1:], f)
+	if err != nil {
+		return nil, err
+	}
+	return assoc(coll, path[0], updated)
+}
+
+func mergeMaps(args []any, combine func(a, b any) (any, error)) (any, error) {
+	var out *Map
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		m, ok := arg.(*Map)
+		if !ok {
+			return nil, fmt.Errorf("merge needs maps, got %s", typeName(arg))
+		}
+		if out == nil {
+			out = m.clone()
+			continue
+		}
+		for i, key := range m.keys {
+			v := m.vals[i]
+			if old, exists := out.Get(key); exists && combine != nil {
+				var err error
+				if v, err = combine(old, v); err != nil {
+					return nil, err
+				}
+			}
+			out.set(key, v)
+		}
+	}
+	if out == nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func conjAll(coll any, items []any) (any, error) {
+	switch c := coll.(type) {
+	case nil:
+		out := List{}
+		for i := len(items) - 1; i >= 0; i-- {
+			out = append(out, items[i])
+		}
+		return out, nil
+	case List:
+		out := make(List, 0, len(c)+len(items))
+		for i := len(items) - 1; i >= 0; i-- {
+			out = append(out, items[i])
+		}
+		return append(out, c...), nil
+	case Vector:
+		return append(append(Vector{}, c...), items...), nil
+	case *Set:
+		return c.Conj(items...), nil
+	case *Map:
+		out := c.clone()
+		for _, item := range items {
+			switch e := item.(type) {
+			case nil:
+			case Vector:
+				if len(e) != 2 {
+					return nil, errors.New("vector arg to map conj must be a pair")
+				}
+				out.set(e[0], e[1])
+			case *Map:
+				for i, key := range e.keys {
+					out.set(key, e.vals[i])
+				}
+			default:
+				return nil, fmt.Errorf("can't conj %s onto a map", typeName(item))
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("can't conj onto %s", typeName(coll))
+}
@@ -0,0 +1,713 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+func initSeqs() {
+	def("map", func(in *Interp, args []any) (any, error) {
+		if len(args) < 2 {
+			return nil, errors.New("transducers are not supported; pass a collection")
+		}
+		return mapColls(in, args[0], args[1:])
+	})
+	defN("mapv", 2, -1, func(in *Interp, args []any) (any, error) {
+		out, err := mapColls(in, args[0], args[1:])
+		return Vector(out), err
+	})
+	defN("map-indexed", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := make(List, len(items))
+		for i, item := range items {
+			if out[i], err = in.apply(args[0], []any{int64(i), item}); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	})
+	defN("mapcat", 2, -1, func(in *Interp, args []any) (any, error) {
+		mapped, err := mapColls(in, args[0], args[1:])
+		if err != nil {
+			return nil, err
+		}
+		out := List{}
+		for _, m := range mapped {
+			items, err := seqItems(m)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, items...)
+		}
+		return out, nil
+	})
+	defN("filter", 2, 2, func(in *Interp, args []any) (any, error) {
+		return filterColl(in, args[0], args[1], true)
+	})
+	defN("filterv", 2, 2, func(in *Interp, args []any) (any, error) {
+		out, err := filterColl(in, args[0], args[1], true)
+		return Vector(out), err
+	})
+	defN("remove", 2, 2, func(in *Interp, args []any) (any, error) {
+		return filterColl(in, args[0], args[1], false)
+	})
+	defN("keep", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := List{}
+		for _, item := range items {
+			v, err := in.apply(args[0], []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if v != nil {
+				out = append(out, v)
+			}
+		}
+		return out, nil
+	})
+	defN("reduce", 2, 3, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[len(args)-1])
+		if err != nil {
+			return nil, err
+		}
+		var acc any
+		if len(args) == 3 {
+			acc = args[1]
+		} else {
+			if len(items) == 0 {
+				return in.apply(args[0], nil)
+			}
+			acc, items = items[0], items[1:]
+		}
+		for _, item := range items {
+			if acc, err = in.apply(args[0], []any{acc, item}); err != nil {
+				return nil, err
+			}
+			if r, ok := acc.(*reducedValue); ok {
+				return r.v, nil
+			}
+		}
+		return acc, nil
+	})
+	def1("reduced", func(v any) (any, error) { return &reducedValue{v: v}, nil })
+	defN("every?", 2, 2, func(in *Interp, args []any) (any, error) {
+		found, err := findFirst(in, args[0], args[1], false)
+		return found == nil, err
+	})
+	defN("not-every?", 2, 2, func(in *Interp, args []any) (any, error) {
+		found, err := findFirst(in, args[0], args[1], false)
+		return found != nil, err
+	})
+	defN("some", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			v, err := in.apply(args[0], []any{item})
+			if err != nil || truthy(v) {
+				return v, err
+			}
+		}
+		return nil, nil
+	})
+	defN("not-any?", 2, 2, func(in *Interp, args []any) (any, error) {
+		found, err := findFirst(in, args[0], args[1], true)
+		return found == nil, err
+	})
+	defN("take", 2, 2, func(_ *Interp, args []any) (any, error) {
+		n, items, err := countAndItems(args)
+		if err != nil {
+			return nil, err
+		}
+		return List(items[:min(n, len(items))]), nil
+	})
+	defN("drop", 2, 2, func(_ *Interp, args []any) (any, error) {
+		n, items, err := countAndItems(args)
+		if err != nil {
+			return nil, err
+		}
+		return List(items[min(n, len(items)):]), nil
+	})
+	defN("take-last", 2, 2, func(_ *Interp, args []any) (any, error) {
+		n, items, err := countAndItems(args)
+		if err != nil || n == 0 || len(items) == 0 {
+			return nil, err
+		}
+		return List(items[len(items)-min(n, len(items)):]), nil
+	})
+	defN("drop-last", 1, 2, func(_ *Interp, args []any) (any, error) {
+		if len(args) == 1 {
+			args = []any{int64(1), args[0]}
+		}
+		n, items, err := countAndItems(args)
+		if err != nil {
+			return nil, err
+		}
+		return List(items[:len(items)-min(n, len(items))]), nil
+	})
+	defN("take-while", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, i, err := splitWhile(in, args[0], args[1])
+		return List(items[:i]), err
+	})
+	defN("drop-while", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, i, err := splitWhile(in, args[0], args[1])
+		return List(items[i:]), err
+	})
+	defN("split-at", 2, 2, func(_ *Interp, args []any) (any, error) {
+		n, items, err := countAndItems(args)
+		if err != nil {
+			return nil, err
+		}
+		n = min(n, len(items))
+		return Vector{List(items[:n]), List(items[n:])}, nil
+	})
+	defN("split-with", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, i, err := splitWhile(in, args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		return Vector{List(items[:i]), List(items[i:])}, nil
+	})
+	def1("reverse", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil {
+			return nil, err
+		}
+		out := make(List, len(items))
+		for i, item := range items {
+			out[len(items)-1-i] = item
+		}
+		return out, nil
+	})
+	def1("distinct", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil {
+			return nil, err
+		}
+		return List(NewSet(items...).Items()), nil
+	})
+	def1("dedupe", func(v any) (any, error) {
+		items, err := seqItems(v)
+		if err != nil {
+			return nil, err
+		}
+		out := List{}
+		for i, item := range items {
+			if i == 0 || !Equal(item, items[i-1]) {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	})
+	def1("flatten", func(v any) (any, error) {
+		out := List{}
+		var walk func(any)
+		walk = func(x any) {
+			switch t := x.(type) {
+			case List:
+				for _, item := range t {
+					walk(item)
+				}
+			case Vector:
+				for _, item := range t {
+					walk(item)
+				}
+			default:
+				out = append(out, x)
+			}
+		}
+		switch v.(type) {
+		case List, Vector:
+			walk(v)
+		}
+		return out, nil
+	})
+	defN("sort", 1, 2, func(in *Interp, args []any) (any, error) {
+		var cmp any
+		if len(args) == 2 {
+			cmp = args[0]
+		}
+		return sortItems(in, args[len(args)-1], nil, cmp)
+	})
+	defN("sort-by", 2, 3, func(in *Interp, args []any) (any, error) {
+		var cmp any
+		if len(args) == 3 {
+			cmp = args[1]
+		}
+		return sortItems(in, args[len(args)-1], args[0], cmp)
+	})
+	defN("group-by", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := NewMap()
+		for _, item := range items {
+			k, err := in.apply(args[0], []any{item})
+			if err != nil {
+				return nil, err
+			}
+			group, _ := out.Get(k)
+			g, _ := group.(Vector)
+			out.set(k, append(g, item))
+		}
+		return out, nil
+	})
+	defN("partition-by", 2, 2, func(in *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := List{}
+		var current List
+		var last any
+		for i, item := range items {
+			k, err := in.apply(args[0], []any{item})
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 && !Equal(k, last) {
+				out = append(out, current)
+				current = nil
+			}
+			current = append(current, item)
+			last = k
+		}
+		if len(current) > 0 {
+			out = append(out, current)
+		}
+		return out, nil
+	})
+	defN("partition", 2, 4, func(_ *Interp, args []any) (any, error) { return partition(args, false) })
+	defN("partition-all", 2, 3, func(_ *Interp, args []any) (any, error) { return partition(args, true) })
+	defN("range", 0, 3, func(in *Interp, args []any) (any, error) {
+		if len(args) == 0 {
+			return nil, errors.New("infinite (range) is not supported; pass an end")
+		}
+		nums := make([]any, 3)
+		nums[0], nums[1], nums[2] = int64(0), args[0], int64(1)
+		if len(args) >= 2 {
+			nums[0], nums[1] = args[0], args[1]
+		}
+		if len(args) == 3 {
+			nums[2] = args[2]
+		}
+		return numberRange(in, nums[0], nums[1], nums[2])
+	})
+	defN("repeat", 1, 2, func(in *Interp, args []any) (any, error) {
+		if len(args) == 1 {
+			return nil, errors.New("infinite (repeat x) is not supported; pass a count")
+		}
+		n, ok := args[0].(int64)
+		if !ok {
+			return nil, fmt.Errorf("count must be an integer, got %s", typeName(args[0]))
+		}
+		if err := in.tick(int(max(n, 0))); err != nil {
+			return nil, err
+		}
+		out := List{}
+		for i := int64(0); i < n; i++ {
+			out = append(out, args[1])
+		}
+		return out, nil
+	})
+	defN("interpose", 2, 2, func(_ *Interp, args []any) (any, error) {
+		items, err := seqItems(args[1])
+		if err != nil {
+			return nil, err
+		}
+		out := List{}
+		for i, item := range items {
+			if i > 0 {
+				out = append(out, args[0])
+			}
+			out = append(out, item)
+		}
+		return out, nil
+	})
+	defN("interleave", 1, -1, func(_ *Interp, args []any) (any, error) {
+		colls := make([][]any, len(args))
+		shortest := -1
+		for i, arg := range args {
+			items, err := seqItems(arg)
+			if err != nil {
+				return nil, err
+			}
+			colls[i] = items
+			if shortest < 0 || len(items) < shortest {
+				shortest = len(items)
+			}
+		}
+		out := List{}
+		for i := 0; i < shortest; i++ {
+			for _, items := range colls {
+				out = append(out, items[i])
+			}
+		}
+		return out, nil
+	})
+}
+
+func mapColls(in *Interp, f any, colls []any) (List, error) {
+	lists := make([][]any, len(colls))
+	shortest := -1
+	for i, coll := range colls {
+		items, err := seqItems(coll)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = items
+		if shortest < 0 || len(items) < shortest {
+			shortest = len(items)
+		}
+	}
+	out := make(List, shortest)
+	for i := 0; i < shortest; i++ {
+		args := make([]any, len(lists))
+		for j := range lists {
+			args[j] = lists[j][i]
+		}
+		v, err := in.apply(f, args)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func filterColl(in *Interp, pred, coll any, keep bool) (List, error) {
+	items, err := seqItems(coll)
+	if err != nil {
+		return nil, err
+	}
+	out := List{}
+	for _, item := range items {
+		v, err := in.apply(pred, []any{item})
+		if err != nil {
+			return nil, err
+		}
+		if truthy(v) == keep {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// findFirst returns the first item whose predicate result is want, wrapped
+// so a nil item is still distinguishable from "not found".
+func findFirst(in *Interp, pred, coll any, want bool) (*any, error) {
+	items, err := seqItems(coll)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		v, err := in.apply(pred, []any{item})
+		if err != nil {
+			return nil, err
+		}
+		if truthy(v) == want {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func countAndItems(args []any) (int, []any, error) {
+	n, ok := args[0].(int64)
+	if !ok {
+		return 0, nil, fmt.Errorf("count must be an integer, got %s", typeName(args[0]))
+	}
+	items, err := seqItems(args[1])
+	return int(max(n, 0)), items, err
+}
+
+func splitWhile(in *Interp, pred, coll any) ([]any, int, error) {
+	items, err := seqItems(coll)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, item := range items {
+		v, err := in.apply(pred, []any{item})
+		if err != nil {
+			return nil, 0, err
+		}
+		if !truthy(v) {
+			return items, i, nil
+		}
+	}
+	return items, len(items), nil
+}
+
+func partition(args []any, all bool) (any, error) {
+	n, ok := args[0].(int64)
+	if !ok || n <= 0 {
+		return nil, fmt.Errorf("partition size must be a positive integer, got %s", PrStr(args[0]))
+	}
+	step := n
+	var pad []any
+	rest := args[1:]
+	if len(rest) >= 2 {
+		if step, ok = rest[0].(int64); !ok || step <= 0 {
+			return nil, fmt.Errorf("partition step must be a positive integer, got %s", PrStr(rest[0]))
+		}
+		rest = rest[1:]
+	}
+	if len(rest) == 2 {
+		var err error
+		if pad, err = seqItems(rest[0]); err != nil {
+			return nil, err
+		}
+		rest = rest[1:]
+	}
+	items, err := seqItems(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	out := List{}
+	for start := 0; start < len(items); start += int(step) {
+		end := start + int(n)
+		if end <= len(items) {
+			out = append(out, List(items[start:end]))
+			continue
+		}
+		chunk := append(List{}, items[start:]...)
+		switch {
+		case all:
+			out = append(out, chunk)
+		case pad != nil:
+			for i := 0; len(chunk) < int(n) && i < len(pad); i++ {
+				chunk = append(chunk, pad[i])
+			}
+			out = append(out, chunk)
+		}
+		break
+	}
+	return out, nil
+}
+
+func numberRange(in *Interp, start, end, step any) (any, error) {
+	if s, ok := step.(float64); ok && s == 0 || step == int64(0) {
+		return nil, errors.New("range step must not be zero")
+	}
+	a, aInt := start.(int64)
+	b, bInt := end.(int64)
+	s, sInt := step.(int64)
+	if aInt && bInt && sInt {
+		count := (b - a + s - sign(s)) / s
+		if count <= 0 {
+			return List{}, nil
+		}
+		if err := in.tick(int(count)); err != nil {
+			return nil, err
+		}
+		out := make(List, 0, count)
+		for i := a; (s > 0 && i < b) || (s < 0 && i > b); i += s {
+			out = append(out, i)
+		}
+		return out, nil
+	}
+	fa, err := toFloat(start)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := toFloat(end)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := toFloat(step)
+	if err != nil {
+		return nil, err
+	}
+	out := List{}
+	for x := fa; (fs > 0 && x < fb) || (fs < 0 && x > fb); x += fs {
+		if err := in.tick(1); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+func sign(n int64) int64 {
+	if n < 0 {
+		return -1
+	}
+	return 1
+}
+
+// sortItems is a stable sort by keyFn (or the items themselves) using cmp,
+// which may return a number like compare or a boolean like <.
+func sortItems(in *Interp, coll, keyFn, cmp any) (any, error) {
+	items, err := seqItems(coll)
+	if err != nil {
+		return nil, err
+	}
+	keys := append([]any(nil), items...)
+	if keyFn != nil {
+		for i, item := range items {
+			if keys[i], err = in.apply(keyFn, []any{item}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	var sortErr error
+	sort.SliceStable(order, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		a, b := keys[order[i]], keys[order[j]]
+		if cmp == nil {
+			c, err := compareValues(a, b)
+			sortErr = err
+			return c < 0
+		}
+		v, err := in.apply(cmp, []any{a, b})
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		switch r := v.(type) {
+		case bool:
+			return r
+		case int64:
+			return r < 0
+		case float64:
+			return r < 0
+		}
+		sortErr = fmt.Errorf("comparator must return a number or boolean, got %s", typeName(v))
+		return false
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	out := make(List, len(items))
+	for i, idx := range order {
+		out[i] = items[idx]
+	}
+	return out, nil
+}
+
+func initFunctions() {
+	defN("apply", 2, -1, func(in *Interp, args []any) (any, error) {
+		last, err := seqItems(args[len(args)-1])
+		if err != nil {
+			return nil, err
+		}
+		callArgs := append(append([]any(nil), args[1:len(args)-1]...), last...)
+		return in.apply(args[0], callArgs)
+	})
+	def1("identity", func(v any) (any, error) { return v, nil })
+	def1("constantly", func(v any) (any, error) {
+		return &Builtin{Name: "constantly", Fn: func(*Interp, []any) (any, error) { return v, nil }}, nil
+	})
+	def("comp", func(_ *Interp, fns []any) (any, error) {
+		return &Builtin{Name: "comp", Fn: func(in *Interp, args []any) (any, error) {
+			if len(fns) == 0 {
+				if len(args) != 1 {
+					return nil, arityError(len(args), "comp")
+				}
+				return args[0], nil
+			}
+			v, err := in.apply(fns[len(fns)-1], args)
+			for i := len(fns) - 2; i >= 0 && err == nil; i-- {
+				v, err = in.apply(fns[i], []any{v})
+			}
+			return v, err
+		}}, nil
+	})
+	defN("partial", 1, -1, func(_ *Interp, fixed []any) (any, error) {
+		return &Builtin{Name: "partial", Fn: func(in *Interp, args []any) (any, error) {
+			return in.apply(fixed[0], append(append([]any(nil), fixed[1:]...), args...))
+		}}, nil
+	})
+	defN("juxt", 1, -1, func(_ *Interp, fns []any) (any, error) {
+		return &Builtin{Name: "juxt", Fn: func(in *Interp, args []any) (any, error) {
+			out := make(Vector, len(fns))
+			for i, f := range fns {
+				v, err := in.apply(f, args)
+				if err != nil {
+					return nil, err
+				}
+				out[i] = v
+			}
+			return out, nil
+		}}, nil
+	})
+	def1("complement", func(f any) (any, error) {
+		return &Builtin{Name: "complement", Fn: func(in *Interp, args []any) (any, error) {
+			v, err := in.apply(f, args)
+			return !truthy(v), err
+		}}, nil
+	})
+	defN("fnil", 2, 4, func(_ *Interp, fixed []any) (any, error) {
+		return &Builtin{Name: "fnil", Fn: func(in *Interp, args []any) (any, error) {
+			args = append([]any(nil), args...)
+			for i, d := range fixed[1:] {
+				if i < len(args) && args[i] == nil {
+					args[i] = d
+				}
+			}
+			return in.apply(fixed[0], args)
+		}}, nil
+	})
+	for _, name := range []string{"min-key", "max-key"} {
+		sign := 1
+		if name == "min-key" {
+			sign = -1
+		}
+		defN(name, 2, -1, func(in *Interp, args []any) (any, error) {
+			best := args[1]
+			bestKey, err := in.apply(args[0], []any{best})
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range args[2:] {
+				k, err := in.apply(args[0], []any{item})
+				if err != nil {
+					return nil, err
+				}
+				c, err := compareNumbers(k, bestKey)
+				if err != nil {
+					return nil, err
+				}
+				// Like Clojure, ties go to the last item.
+				if c*sign >= 0 {
+					best, bestKey = item, k
+				}
+			}
+			return best, nil
+		})
+	}
+	defN("ex-info", 2, 3, func(_ *Interp, args []any) (any, error) {
+		msg, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("ex-info message must be a string, got %s", typeName(args[0]))
+		}
+		return &ExInfo{Message: msg, Data: args[1]}, nil
+	})
+	def1("ex-message", func(v any) (any, error) {
+		if ex, ok := v.(*ExInfo); ok {
+			return ex.Message, nil
+		}
+		return nil, nil
+	})
+	def1("ex-data", func(v any) (any, error) {
+		if ex, ok := v.(*ExInfo); ok {
+			return ex.Data, nil
+		}
+		return nil, nil
+	})
+}
@@ -0,0 +1,507 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func initStrings() {
+	def("str", func(_ *Interp, args []any) (any, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			b.WriteString(Str(arg))
+		}
+		return b.String(), nil
+	})
+	def("pr-str", func(_ *Interp, args []any) (any, error) {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = PrStr(arg)
+		}
+		return strings.Join(parts, " "), nil
+	})
+	defN("subs", 2, 3, func(_ *Interp, args []any) (any, error) {
+		s, err := stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		runes := []rune(s)
+		start, ok := args[1].(int64)
+		end := int64(len(runes))
+		if len(args) == 3 {
+			var endOK bool
+			end, endOK = args[2].(int64)
+			ok = ok && endOK
+		}
+		if !ok || start < 0 || end > int64(len(runes)) || start > end {
+			return nil, fmt.Errorf("string index out of range for %q", s)
+		}
+		return string(runes[start:end]), nil
+	})
+	def1("name", func(v any) (any, error) {
+		switch t := v.(type) {
+		case string:
+			return t, nil
+		case Keyword:
+			return localName(string(t)), nil
+		case Symbol:
+			return localName(string(t)), nil
+		}
+		return nil, fmt.Errorf("name needs a string, keyword or symbol, got %s", typeName(v))
+	})
+	def1("namespace", func(v any) (any, error) {
+		var full string
+		switch t := v.(type) {
+		case Keyword:
+			full = string(t)
+		case Symbol:
+			full = string(t)
+		default:
+			return nil, fmt.Errorf("namespace needs a keyword or symbol, got %s", typeName(v))
+		}
+		if slash := strings.Index(full, "/"); slash > 0 && slash < len(full)-1 {
+			return full[:slash], nil
+		}
+		return nil, nil
+	})
+	defN("keyword", 1, 2, func(_ *Interp, args []any) (any, error) {
+		if len(args) == 2 {
+			ns, _ := args[0].(string)
+			name, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("keyword name must be a string, got %s", typeName(args[1]))
+			}
+			if ns == "" {
+				return Keyword(name), nil
+			}
+			return Keyword(ns + "/" + name), nil
+		}
+		switch t := args[0].(type) {
+		case nil:
+			return nil, nil
+		case Keyword:
+			return t, nil
+		case Symbol:
+			return Keyword(t), nil
+		case string:
+			return Keyword(t), nil
+		}
+		return nil, nil
+	})
+	defN("symbol", 1, 2, func(_ *Interp, args []any) (any, error) {
+		parts := make([]string, 0, 2)
+		for _, arg := range args {
+			s, err := stringArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, s)
+		}
+		return Symbol(strings.Join(parts, "/")), nil
+	})
+	defN("format", 1, -1, func(_ *Interp, args []any) (any, error) {
+		pattern, err := stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return formatJava(pattern, args[1:])
+	})
+	def1("re-pattern", func(v any) (any, error) {
+		switch t := v.(type) {
+		case *Regex:
+			return t, nil
+		case string:
+			re, err := regexp.Compile(t)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex: %v", err)
+			}
+			return &Regex{Regexp: re, Source: t}, nil
+		}
+		return nil, fmt.Errorf("re-pattern needs a string, got %s", typeName(v))
+	})
+	defN("re-find", 2, 2, func(_ *Interp, args []any) (any, error) {
+		re, s, err := regexArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return matchValue(s, re.FindStringSubmatchIndex(s)), nil
+	})
+	defN("re-matches", 2, 2, func(_ *Interp, args []any) (any, error) {
+		re, s, err := regexArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		anchored, err := regexp.Compile(`^(?:` + re.Source + `)$`)
+		if err != nil {
+			return nil, err
+		}
+		return matchValue(s, anchored.FindStringSubmatchIndex(s)), nil
+	})
+	defN("re-seq", 2, 2, func(_ *Interp, args []any) (any, error) {
+		re, s, err := regexArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		var out List
+		for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+			out = append(out, matchValue(s, loc))
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return out, nil
+	})
+
+	defN("clojure.string/join", 1, 2, func(_ *Interp, args []any) (any, error) {
+		sep := ""
+		if len(args) == 2 {
+			sep = Str(args[0])
+		}
+		items, err := seqItems(args[len(args)-1])
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = Str(item)
+		}
+		return strings.Join(parts, sep), nil
+	})
+	defN("clojure.string/split", 2, 3, func(_ *Interp, args []any) (any, error) {
+		s, err := stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		re, ok := args[1].(*Regex)
+		if !ok {
+			return nil, fmt.Errorf("split needs a regex, got %s", typeName(args[1]))
+		}
+		limit := -1
+		if len(args) == 3 {
+			n, ok := args[2].(int64)
+			if !ok {
+				return nil, errors.New("split limit must be an integer")
+			}
+			if n > 0 {
+				limit = int(n)
+			}
+		}
+		parts := re.Split(s, limit)
+		if limit < 0 {
+			// Java's split drops trailing empty strings.
+			for len(parts) > 1 && parts[len(parts)-1] == "" {
+				parts = parts[:len(parts)-1]
+			}
+		}
+		out := make(Vector, len(parts))
+		for i, part := range parts {
+			out[i] = part
+		}
+		return out, nil
+	})
+	defN("clojure.string/split-lines", 1, 1, func(_ *Interp, args []any) (any, error) {
+		s, err := stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		lines := regexp.MustCompile(`\r?\n`).Split(s, -1)
+		for len(lines) > 1 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		out := make(Vector, len(lines))
+		for i, line := range lines {
+			out[i] = line
+		}
+		return out, nil
+	})
+	stringFn := func(name string, fn func(string) any) {
+		defN("clojure.string/"+name, 1, 1, func(_ *Interp, args []any) (any, error) {
+			s, err := stringArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return fn(s), nil
+		})
+	}
+	stringFn("upper-case", func(s string) any { return strings.ToUpper(s) })
+	stringFn("lower-case", func(s string) any { return strings.ToLower(s) })
+	stringFn("trim", func(s string) any { return strings.TrimSpace(s) })
+	stringFn("triml", func(s string) any { return strings.TrimLeftFunc(s, unicode.IsSpace) })
+	stringFn("trimr", func(s string) any { return strings.TrimRightFunc(s, unicode.IsSpace) })
+	stringFn("trim-newline", func(s string) any { return strings.TrimRight(s, "\r\n") })
+	stringFn("reverse", func(s string) any {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	})
+	stringFn("capitalize", func(s string) any {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 {
+			return s
+		}
+		return string(unicode.ToUpper(r)) + strings.ToLower(s[size:])
+	})
+	defN("clojure.string/blank?", 1, 1, func(_ *Interp, args []any) (any, error) {
+		if args[0] == nil {
+			return true, nil
+		}
+		s, err := stringArg(args[0])
+		return strings.TrimSpace(s) == "", err
+	})
+	stringTest := func(name string, fn func(s, sub string) bool) {
+		defN("clojure.string/"+name, 2, 2, func(_ *Interp, args []any) (any, error) {
+			s, err := stringArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			sub, err := stringArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return fn(s, sub), nil
+		})
+	}
+	stringTest("includes?", strings.Contains)
+	stringTest("starts-with?", strings.HasPrefix)
+	stringTest("ends-with?", strings.HasSuffix)
+	defN("clojure.string/index-of", 2, 2, func(_ *Interp, args []any) (any, error) {
+		s, err := stringArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		i := strings.Index(s, Str(args[1]))
+		if i < 0 {
+			return nil, nil
+		}
+		return int64(utf8.RuneCountInString(s[:i])), nil
+	})
+	defN("clojure.string/replace", 3, 3, func(in *Interp, args []any) (any, error) {
+		return replaceString(in, args, -1)
+	})
+	defN("clojure.string/replace-first", 3, 3, func(in *Interp, args []any) (any, error) {
+		return replaceString(in, args, 1)
+	})
+
+	setOp := func(name string, combine func(a, b *Set) *Set) {
+		defN("clojure.set/"+name, 1, -1, func(_ *Interp, args []any) (any, error) {
+			sets := make([]*Set, len(args))
+			for i, arg := range args {
+				switch s := arg.(type) {
+				case nil:
+					sets[i] = NewSet()
+				case *Set:
+					sets[i] = s
+				default:
+					return nil, fmt.Errorf("%s needs sets, got %s", name, typeName(arg))
+				}
+			}
+			out := sets[0]
+			for _, s := range sets[1:] {
+				out = combine(out, s)
+			}
+			return out, nil
+		})
+	}
+	setOp("union", func(a, b *Set) *Set { return a.Conj(b.Items()...) })
+	setOp("difference", func(a, b *Set) *Set { return a.Disj(b.Items()...) })
+	setOp("intersection", func(a, b *Set) *Set {
+		out := NewSet()
+		for _, item := range a.Items() {
+			if b.Contains(item) {
+				out.add(item)
+			}
+		}
+		return out
+	})
+}
+
+func stringArg(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string, got %s", typeName(v))
+	}
+	return s, nil
+}
+
+func localName(full string) string {
+	if slash := strings.Index(full, "/"); slash > 0 && slash < len(full)-1 {
+		return full[slash+1:]
+	}
+	return full
+}
+
+func regexArgs(args []any) (*Regex, string, error) {
+	re, ok := args[0].(*Regex)
+	if !ok {
+		return nil, "", fmt.Errorf("expected a regex, got %s", typeName(args[0]))
+	}
+	s, err := stringArg(args[1])
+	return re, s, err
+}
+
+// matchValue is what re-find returns: the match alone, or a vector of the
+// match and its groups when the pattern has groups.
+func matchValue(s string, loc []int) any {
+	if loc == nil {
+		return nil
+	}
+	if len(loc) == 2 {
+		return s[loc[0]:loc[1]]
+	}
+	out := make(Vector, len(loc)/2)
+	for i := range out {
+		if loc[2*i] >= 0 {
+			out[i] = s[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return out
+}
+
+var javaGroupRef = regexp.MustCompile(`\$(\d+)`)
+
+func replaceString(in *Interp, args []any, limit int) (any, error) {
+	s, err := stringArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var re *regexp.Regexp
+	literal := false
+	switch m := args[1].(type) {
+	case string:
+		re = regexp.MustCompile(regexp.QuoteMeta(m))
+		literal = true
+	case *Regex:
+		re = m.Regexp
+	default:
+		return nil, fmt.Errorf("replace match must be a string or regex, got %s", typeName(args[1]))
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, limit) {
+		b.WriteString(s[last:loc[0]])
+		switch r := args[2].(type) {
+		case string:
+			if literal {
+				b.WriteString(r)
+			} else {
+				b.Write(re.ExpandString(nil, javaGroupRef.ReplaceAllString(r, "$${$1}"), s, loc))
+			}
+		default:
+			v, err := in.apply(r, []any{matchValue(s, loc)})
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(Str(v))
+		}
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+var javaFormatSpec = regexp.MustCompile(`%([-#+ 0,(]*)(\d+)?(\.\d+)?([a-zA-Z%])`)
+
+// formatJava implements the common subset of java.util.Formatter that format
+// calls use: %s, %d, %f, %e, %g, %x, %o, %b, %c, %n and %%.
+func formatJava(pattern string, args []any) (any, error) {
+	var b strings.Builder
+	last, next := 0, 0
+	for _, loc := range javaFormatSpec.FindAllStringSubmatchIndex(pattern, -1) {
+		b.WriteString(pattern[last:loc[0]])
+		last = loc[1]
+		flags := pattern[loc[2]:loc[3]]
+		width, precision := "", ""
+		if loc[4] >= 0 {
+			width = pattern[loc[4]:loc[5]]
+		}
+		if loc[6] >= 0 {
+			precision = pattern[loc[6]:loc[7]]
+		}
+		conv := pattern[loc[8]:loc[9]]
+		switch conv {
+		case "%":
+			b.WriteString("%")
+			continue
+		case "n":
+			b.WriteString("\n")
+			continue
+		}
+		if next >= len(args) {
+			return nil, fmt.Errorf("format specifier %%%s has no argument", conv)
+		}
+		arg := args[next]
+		next++
+		goFlags := strings.ReplaceAll(flags, ",", "")
+		verb := "%" + goFlags + width + precision
+		switch conv {
+		case "s", "S":
+			out := fmt.Sprintf(verb+"s", Str(arg))
+			if conv == "S" {
+				out = strings.ToUpper(out)
+			}
+			b.WriteString(out)
+		case "d", "x", "X", "o":
+			n, ok := arg.(int64)
+			if !ok {
+				return nil, fmt.Errorf("%%%s needs an integer, got %s", conv, typeName(arg))
+			}
+			out := fmt.Sprintf(verb+conv, n)
+			if strings.Contains(flags, ",") && conv == "d" {
+				out = groupThousands(out)
+			}
+			b.WriteString(out)
+		case "f", "e", "E", "g", "G":
+			f, err := toFloat(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%%%s needs a number, got %s", conv, typeName(arg))
+			}
+			if _, isInt := arg.(int64); isInt {
+				return nil, fmt.Errorf("%%%s needs a double, got long", conv)
+			}
+			if precision == "" && conv == "f" {
+				verb += ".6"
+			}
+			out := fmt.Sprintf(verb+conv, f)
+			if strings.Contains(flags, ",") && conv == "f" {
+				out = groupThousands(out)
+			}
+			b.WriteString(out)
+		case "b", "B":
+			b.WriteString(fmt.Sprintf(verb+"s", strconv.FormatBool(truthy(arg))))
+		case "c":
+			b.WriteString(fmt.Sprintf(verb+"s", Str(arg)))
+		default:
+			return nil, fmt.Errorf("unsupported format specifier %%%s", conv)
+		}
+	}
+	b.WriteString(pattern[last:])
+	return b.String(), nil
+}
+
+// groupThousands inserts commas into the integer part of a formatted number.
+func groupThousands(s string) string {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return s
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	digits := s[start:end]
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return s[:start] + b.String() + s[end:]
+}
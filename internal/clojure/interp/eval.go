@@ -0,0 +1,638 @@
+// Package interp evaluates the data-transformation subset of Clojure used in
+// flow function code: let, fn, loop/recur, the threading and conditional
+// macros, destructuring, and the core seq, map and string functions. There is
+// no host interop, no mutable state and no I/O, and every evaluation runs
+// under a step limit and a deadline.
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxSteps = 1_000_000
+	DefaultTimeout  = 5 * time.Second
+)
+
+// Options bound one evaluation. Zero values use the defaults.
+type Options struct {
+	MaxSteps int
+	Timeout  time.Duration
+}
+
+// Stats reports the work an evaluation did.
+type Stats struct {
+	Steps   int
+	Elapsed time.Duration
+}
+
+// Error is an evaluation failure located at the innermost form that was
+// being evaluated when it happened.
+type Error struct {
+	Message  string
+	Position *Position
+	// Data is the ex-data of a thrown ex-info.
+	Data any
+	// limit marks step-limit and timeout errors, which try/catch cannot
+	// intercept.
+	limit  bool
+	thrown bool
+}
+
+func (e *Error) Error() string {
+	if e.Position == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s at line %d, column %d", e.Message, e.Position.Line, e.Position.Column)
+}
+
+// ExInfo is the value of (ex-info msg data) and of the binding in a catch
+// clause.
+type ExInfo struct {
+	Message string
+	Data    any
+}
+
+// Interp holds the limits and counters for one evaluation.
+type Interp struct {
+	maxSteps  int
+	steps     int
+	start     time.Time
+	deadline  time.Time
+	positions map[*any]Position
+}
+
+// Env is a lexical scope.
+type Env struct {
+	vars   map[Symbol]any
+	parent *Env
+}
+
+func newEnv(parent *Env) *Env {
+	return &Env{vars: map[Symbol]any{}, parent: parent}
+}
+
+func (e *Env) lookup(s Symbol) (any, bool) {
+	for env := e; env != nil; env = env.parent {
+		if v, ok := env.vars[s]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// recurValue is what (recur ...) evaluates to; loop and fn bodies rebind
+// and repeat when their body returns one.
+type recurValue struct{ args []any }
+
+// literalForm wraps an already-evaluated value inside a generated form, as
+// cond-> and some-> do when threading intermediate results.
+type literalForm struct{ v any }
+
+func newInterp(opts Options, forms *Forms) *Interp {
+	in := &Interp{maxSteps: opts.MaxSteps, start: time.Now(), positions: forms.positions}
+	if in.maxSteps <= 0 {
+		in.maxSteps = DefaultMaxSteps
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	in.deadline = in.start.Add(timeout)
+	return in
+}
+
+func (in *Interp) stats() Stats {
+	return Stats{Steps: in.steps, Elapsed: time.Since(in.start)}
+}
+
+// EvalString evaluates every form in src and returns the last value.
+func EvalString(src string, opts Options) (any, Stats, error) {
+	forms, err := Read(src)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	in := newInterp(opts, forms)
+	var out any
+	for _, form := range forms.Forms {
+		if out, err = in.eval(form, nil); err != nil {
+			return nil, in.stats(), err
+		}
+	}
+	return out, in.stats(), nil
+}
+
+// EvalFunction reads code that must be a single fn form, such as
+// "(fn [input] ...)", and applies it to args.
+func EvalFunction(code string, args []any, opts Options) (any, Stats, error) {
+	forms, err := Read(code)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	if len(forms.Forms) != 1 {
+		return nil, Stats{}, fmt.Errorf("function code must be a single form, found %d", len(forms.Forms))
+	}
+	in := newInterp(opts, forms)
+	f, err := in.eval(forms.Forms[0], nil)
+	if err != nil {
+		return nil, in.stats(), err
+	}
+	switch f.(type) {
+	case *Fn, *Builtin:
+	default:
+		return nil, in.stats(), fmt.Errorf("function code must evaluate to a fn, got %s", typeName(f))
+	}
+	out, err := in.apply(f, args)
+	return out, in.stats(), err
+}
+
+func (in *Interp) tick(n int) error {
+	in.steps += n
+	if in.steps > in.maxSteps {
+		return &Error{Message: fmt.Sprintf("step limit of %d exceeded", in.maxSteps), limit: true}
+	}
+	if in.steps&0xff < n && time.Now().After(in.deadline) {
+		return &Error{Message: fmt.Sprintf("evaluation timed out after %s", in.deadline.Sub(in.start).Round(time.Millisecond)), limit: true}
+	}
+	return nil
+}
+
+func (in *Interp) eval(form any, env *Env) (any, error) {
+	if err := in.tick(1); err != nil {
+		return nil, err
+	}
+	switch f := form.(type) {
+	case Symbol:
+		return in.resolve(f, env)
+	case List:
+		if len(f) == 0 {
+			return List{}, nil
+		}
+		out, err := in.evalList(f, env)
+		if err != nil {
+			return nil, in.locate(err, f)
+		}
+		return out, nil
+	case Vector:
+		items, err := in.evalAll(f, env)
+		return Vector(items), err
+	case mapLiteral:
+		if len(f)%2 != 0 {
+			return nil, errors.New("map literal must contain an even number of forms")
+		}
+		m := NewMap()
+		for i := 0; i < len(f); i += 2 {
+			k, err := in.evalArg(f[i], env)
+			if err != nil {
+				return nil, err
+			}
+			v, err := in.evalArg(f[i+1], env)
+			if err != nil {
+				return nil, err
+			}
+			m.set(k, v)
+		}
+		return m, nil
+	case setLiteral:
+		items, err := in.evalAll(f, env)
+		return NewSet(items...), err
+	case literalForm:
+		return f.v, nil
+	}
+	return form, nil
+}
+
+// locate turns err into an *Error positioned at form unless an inner form
+// already claimed it.
+func (in *Interp) locate(err error, form List) error {
+	var evalErr *Error
+	if !errors.As(err, &evalErr) {
+		evalErr = &Error{Message: err.Error()}
+	}
+	if evalErr.Position == nil {
+		if pos, ok := in.positions[&form[0]]; ok {
+			evalErr.Position = &pos
+		}
+	}
+	return evalErr
+}
+
+var stringNamespaces = map[string]bool{"clojure.string": true, "str": true, "string": true, "s": true}
+var setNamespaces = map[string]bool{"clojure.set": true, "set": true}
+
+func (in *Interp) resolve(s Symbol, env *Env) (any, error) {
+	if v, ok := env.lookup(s); ok {
+		return v, nil
+	}
+	name := string(s)
+	if slash := strings.Index(name, "/"); slash > 0 && slash < len(name)-1 {
+		ns, local := name[:slash], name[slash+1:]
+		switch {
+		case ns == "clojure.core":
+			name = local
+		case stringNamespaces[ns]:
+			name = "clojure.string/" + local
+		case setNamespaces[ns]:
+			name = "clojure.set/" + local
+		case ns[0] >= 'A' && ns[0] <= 'Z' || strings.Contains(ns, "."):
+			return nil, fmt.Errorf("host interop %s is not supported", s)
+		}
+	}
+	if v, ok := core[name]; ok {
+		return v, nil
+	}
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return nil, fmt.Errorf("host interop %s is not supported", s)
+	}
+	if specialForms[name] != nil {
+		return nil, fmt.Errorf("can't take value of a macro or special form: %s", s)
+	}
+	return nil, fmt.Errorf("unable to resolve symbol: %s", s)
+}
+
+func (in *Interp) evalArg(form any, env *Env) (any, error) {
+	v, err := in.eval(form, env)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(*recurValue); ok {
+		return nil, errors.New("recur can only be used in tail position")
+	}
+	return v, nil
+}
+
+func (in *Interp) evalAll(forms []any, env *Env) ([]any, error) {
+	out := make([]any, len(forms))
+	for i, form := range forms {
+		v, err := in.evalArg(form, env)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (in *Interp) evalBody(body []any, env *Env) (any, error) {
+	var out any
+	for i, form := range body {
+		var err error
+		if i < len(body)-1 {
+			_, err = in.evalArg(form, env)
+		} else {
+			out, err = in.eval(form, env)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (in *Interp) evalList(form List, env *Env) (any, error) {
+	if head, ok := form[0].(Symbol); ok {
+		if _, shadowed := env.lookup(head); !shadowed {
+			if special := specialForms[string(head)]; special != nil {
+				return special(in, form, env)
+			}
+			if name := string(head); name != "." && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".")) {
+				return nil, fmt.Errorf("host interop (%s ...) is not supported", name)
+			}
+		}
+	}
+	f, err := in.evalArg(form[0], env)
+	if err != nil {
+		return nil, err
+	}
+	args, err := in.evalAll(form[1:], env)
+	if err != nil {
+		return nil, err
+	}
+	return in.apply(f, args)
+}
+
+func isFn(v any) bool {
+	switch v.(type) {
+	case *Fn, *Builtin, Keyword, *Map, *Set, Vector:
+		return true
+	}
+	return false
+}
+
+// apply calls anything Clojure can call: fns, keywords, maps, sets and
+// vectors.
+func (in *Interp) apply(f any, args []any) (any, error) {
+	switch fn := f.(type) {
+	case *Builtin:
+		out, err := fn.Fn(in, args)
+		if err != nil {
+			var evalErr *Error
+			if errors.As(err, &evalErr) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s: %w", fn.Name, err)
+		}
+		return out, nil
+	case *Fn:
+		return in.applyFn(fn, args)
+	case Keyword:
+		if len(args) < 1 || len(args) > 2 {
+			return nil, arityError(len(args), ":"+string(fn))
+		}
+		return getWithDefault(args[0], fn, args[1:])
+	case *Map:
+		if len(args) < 1 || len(args) > 2 {
+			return nil, arityError(len(args), "map")
+		}
+		return getWithDefault(fn, args[0], args[1:])
+	case *Set:
+		if len(args) != 1 {
+			return nil, arityError(len(args), "set")
+		}
+		if fn.Contains(args[0]) {
+			return args[0], nil
+		}
+		return nil, nil
+	case Vector:
+		if len(args) != 1 {
+			return nil, arityError(len(args), "vector")
+		}
+		i, ok := args[0].(int64)
+		if !ok || i < 0 || int(i) >= len(fn) {
+			return nil, fmt.Errorf("index %s out of bounds for vector of %d", PrStr(args[0]), len(fn))
+		}
+		return fn[i], nil
+	}
+	return nil, fmt.Errorf("%s cannot be called as a function", typeName(f))
+}
+
+func getWithDefault(coll, key any, def []any) (any, error) {
+	v, ok := lookupKey(coll, key)
+	if !ok && len(def) > 0 {
+		return def[0], nil
+	}
+	return v, nil
+}
+
+func arityError(n int, name string) error {
+	return fmt.Errorf("wrong number of args (%d) passed to %s", n, name)
+}
+
+func (in *Interp) applyFn(fn *Fn, args []any) (any, error) {
+	for {
+		arity := fn.selectArity(len(args))
+		if arity == nil {
+			return nil, arityError(len(args), firstNonEmpty(fn.name, "fn"))
+		}
+		env := newEnv(fn.env)
+		if fn.name != "" {
+			env.vars[Symbol(fn.name)] = fn
+		}
+		if err := in.bindParams(arity, args, env); err != nil {
+			return nil, err
+		}
+		out, err := in.evalBody(arity.body, env)
+		if err != nil {
+			return nil, err
+		}
+		recur, ok := out.(*recurValue)
+		if !ok {
+			return out, nil
+		}
+		args = recur.args
+		if arity.rest != nil {
+			// recur in a variadic fn passes the rest args as one seq.
+			if len(args) != len(arity.params)+1 {
+				return nil, fmt.Errorf("recur expects %d args, got %d", len(arity.params)+1, len(args))
+			}
+			rest, err := seqItems(args[len(args)-1])
+			if err != nil {
+				return nil, err
+			}
+			args = append(append([]any(nil), args[:len(args)-1]...), rest...)
+		}
+	}
+}
+
+func (fn *Fn) selectArity(n int) *fnArity {
+	for i := range fn.arities {
+		if len(fn.arities[i].params) == n {
+			return &fn.arities[i]
+		}
+	}
+	if fn.variadic != nil && n >= len(fn.variadic.params) {
+		return fn.variadic
+	}
+	return nil
+}
+
+func (in *Interp) bindParams(arity *fnArity, args []any, env *Env) error {
+	for i, param := range arity.params {
+		if err := in.bind(param, args[i], env); err != nil {
+			return err
+		}
+	}
+	if arity.rest != nil {
+		var rest any
+		if extra := args[len(arity.params):]; len(extra) > 0 {
+			rest = List(append([]any(nil), extra...))
+		}
+		return in.bind(arity.rest, rest, env)
+	}
+	return nil
+}
+
+// bind destructures value into pattern: symbols, vectors with & and :as, and
+// maps with :keys, :strs, :syms, :or, :as and explicit {sym key} pairs.
+func (in *Interp) bind(pattern, value any, env *Env) error {
+	switch p := pattern.(type) {
+	case Symbol:
+		if strings.Contains(string(p), "/") && p != "/" {
+			return fmt.Errorf("can't bind qualified symbol %s", p)
+		}
+		env.vars[p] = value
+		return nil
+	case Vector:
+		items, err := seqItems(value)
+		if err != nil {
+			return fmt.Errorf("can't destructure %s as a sequence", typeName(value))
+		}
+		for i := 0; i < len(p); i++ {
+			switch p[i] {
+			case Symbol("&"):
+				if i+1 >= len(p) {
+					return errors.New("missing binding after & in destructuring")
+				}
+				var rest any
+				if i < len(items) {
+					rest = List(append([]any(nil), items[i:]...))
+				}
+				if err := in.bind(p[i+1], rest, env); err != nil {
+					return err
+				}
+				i++
+			case Keyword("as"):
+				if i+1 >= len(p) {
+					return errors.New("missing binding after :as in destructuring")
+				}
+				if err := in.bind(p[i+1], value, env); err != nil {
+					return err
+				}
+				i++
+			default:
+				var item any
+				if i < len(items) {
+					item = items[i]
+				}
+				if err := in.bind(p[i], item, env); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case mapLiteral:
+		return in.bindMap(p, value, env)
+	}
+	return fmt.Errorf("unsupported binding form %s", PrStr(quoteValue(pattern)))
+}
+
+func (in *Interp) bindMap(p mapLiteral, value any, env *Env) error {
+	if len(p)%2 != 0 {
+		return errors.New("map binding form needs key/value pairs")
+	}
+	switch v := value.(type) {
+	case nil, *Map:
+	case List, Vector:
+		// Keyword-argument style: (fn [& {:keys [a]}] ...).
+		items, _ := seqItems(v)
+		if len(items)%2 != 0 {
+			return errors.New("keyword arguments need key/value pairs")
+		}
+		m := NewMap()
+		for i := 0; i < len(items); i += 2 {
+			m.set(items[i], items[i+1])
+		}
+		value = m
+	default:
+		return fmt.Errorf("can't destructure %s as a map", typeName(value))
+	}
+	var defaults mapLiteral
+	for i := 0; i < len(p); i += 2 {
+		if p[i] == Keyword("or") {
+			d, ok := p[i+1].(mapLiteral)
+			if !ok {
+				return errors.New(":or needs a map")
+			}
+			defaults = d
+		}
+	}
+	lookup := func(sym Symbol, key any) error {
+		v, ok := lookupKey(value, key)
+		if !ok {
+			for j := 0; j+1 < len(defaults); j += 2 {
+				if defaults[j] == sym {
+					d, err := in.evalArg(defaults[j+1], env)
+					if err != nil {
+						return err
+					}
+					v = d
+				}
+			}
+		}
+		env.vars[sym] = v
+		return nil
+	}
+	for i := 0; i < len(p); i += 2 {
+		key, target := p[i], p[i+1]
+		switch key {
+		case Keyword("or"):
+			continue
+		case Keyword("as"):
+			if err := in.bind(target, value, env); err != nil {
+				return err
+			}
+			continue
+		case Keyword("keys"), Keyword("strs"), Keyword("syms"):
+			names, ok := target.(Vector)
+			if !ok {
+				return fmt.Errorf("%s needs a vector", PrStr(key))
+			}
+			for _, nameForm := range names {
+				var full string
+				switch n := nameForm.(type) {
+				case Symbol:
+					full = string(n)
+				case Keyword:
+					full = string(n)
+				default:
+					return fmt.Errorf("%s entries must be symbols", PrStr(key))
+				}
+				local := full
+				if slash := strings.LastIndex(full, "/"); slash >= 0 {
+					local = full[slash+1:]
+				}
+				var lookupKeyValue any
+				switch key {
+				case Keyword("keys"):
+					lookupKeyValue = Keyword(full)
+				case Keyword("strs"):
+					lookupKeyValue = full
+				default:
+					lookupKeyValue = Symbol(full)
+				}
+				if err := lookup(Symbol(local), lookupKeyValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if sym, ok := key.(Symbol); ok {
+			if err := lookup(sym, quoteValue(target)); err != nil {
+				return err
+			}
+			continue
+		}
+		// Nested pattern: {[a b] :point} or {{:keys [x]} :inner}.
+		nested, _ := lookupKey(value, quoteValue(target))
+		if err := in.bind(key, nested, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteValue turns a read form into the value (quote form) produces.
+func quoteValue(form any) any {
+	switch f := form.(type) {
+	case List:
+		out := make(List, len(f))
+		for i, item := range f {
+			out[i] = quoteValue(item)
+		}
+		return out
+	case Vector:
+		out := make(Vector, len(f))
+		for i, item := range f {
+			out[i] = quoteValue(item)
+		}
+		return out
+	case mapLiteral:
+		m := NewMap()
+		for i := 0; i+1 < len(f); i += 2 {
+			m.set(quoteValue(f[i]), quoteValue(f[i+1]))
+		}
+		return m
+	case setLiteral:
+		items := make([]any, len(f))
+		for i, item := range f {
+			items[i] = quoteValue(item)
+		}
+		return NewSet(items...)
+	case literalForm:
+		return f.v
+	}
+	return form
+}
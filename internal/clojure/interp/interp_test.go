@@ -0,0 +1,164 @@
+package interp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func evalPr(t *testing.T, src string) string {
+	t.Helper()
+	v, _, err := EvalString(src, Options{})
+	if err != nil {
+		t.Fatalf("eval %q: %v", src, err)
+	}
+	return PrStr(v)
+}
+
+func TestEvalString_CoreSubset(t *testing.T) {
+	cases := []struct{ src, want string }{
+		{`(+ 1 2 3)`, `6`},
+		{`(/ 10 4)`, `2.5`},
+		{`(/ 10 5)`, `2`},
+		{`(mod -7 3)`, `2`},
+		{`(let [x 1 y (inc x)] [x y])`, `[1 2]`},
+		{`(-> {:a 1} (assoc :b 2) (update :a inc))`, `{:a 2, :b 2}`},
+		{`(->> (range 5) (map inc) (filter odd?) (reduce +))`, `9`},
+		{`(as-> 3 x (* x x) (- x 1))`, `8`},
+		{`(cond-> {:a 1} true (assoc :b 2) false (assoc :c 3))`, `{:a 1, :b 2}`},
+		{`(some-> {:a {:b 1}} :a :c inc)`, `nil`},
+		{`(let [{:keys [a b] :or {b 5} :as m} {:a 1}] [a b (count m)])`, `[1 5 1]`},
+		{`(let [[x & more :as all] [1 2 3]] [x more all])`, `[1 (2 3) [1 2 3]]`},
+		{`(let [{{:strs [id]} :user} {:user {"id" "u1"}}] id)`, `"u1"`},
+		{`(loop [i 0 acc []] (if (< i 3) (recur (inc i) (conj acc i)) acc))`, `[0 1 2]`},
+		{`((fn sum [n acc] (if (zero? n) acc (recur (dec n) (+ acc n)))) 100 0)`, `5050`},
+		{`((fn ([x] x) ([x y] (+ x y)) ([x y & zs] (apply + x y zs))) 1 2 3 4)`, `10`},
+		{`(map #(* % %) [1 2 3])`, `(1 4 9)`},
+		{`(letfn [(even2? [n] (if (zero? n) true (odd2? (dec n)))) (odd2? [n] (if (zero? n) false (even2? (dec n))))] (even2? 10))`, `true`},
+		{`(for [x [1 2 3] :let [y (* x 10)] :when (odd? x)] y)`, `(10 30)`},
+		{`(case :b :a 1 (:b :c) 2 3)`, `2`},
+		{`(cond (> 1 2) :no :else :yes)`, `:yes`},
+		{`(condp = 2 1 :one 2 :two :many)`, `:two`},
+		{`(if-let [x (:missing {})] x :none)`, `:none`},
+		{`(group-by :k [{:k 1 :v :a} {:k 2 :v :b} {:k 1 :v :c}])`, `{1 [{:k 1, :v :a} {:k 1, :v :c}], 2 [{:k 2, :v :b}]}`},
+		{`(sort-by :n > [{:n 1} {:n 3} {:n 2}])`, `({:n 3} {:n 2} {:n 1})`},
+		{`(frequencies "abca")`, `{"a" 2, "b" 1, "c" 1}`},
+		{`(partition-all 2 [1 2 3])`, `((1 2) (3))`},
+		{`(get-in {:a [{:b 1}]} [:a 0 :b])`, `1`},
+		{`(assoc-in {} [:a :b] 1)`, `{:a {:b 1}}`},
+		{`(update-vals {:a 1 :b 2} inc)`, `{:a 2, :b 3}`},
+		{`(merge-with + {:a 1} {:a 2 :b 3})`, `{:a 3, :b 3}`},
+		{`(into #{} [1 1 2])`, `#{1 2}`},
+		{`(str "a" 1 :k nil 2.0)`, `"a1:k2.0"`},
+		{`(format "%s has %d items costing %.2f" "cart" 3 9.5)`, `"cart has 3 items costing 9.50"`},
+		{`(clojure.string/join ", " (map name [:a :b]))`, `"a, b"`},
+		{`(str/split "a,b,,c,," #",")`, `["a" "b" "" "c"]`},
+		{`(str/replace "2024-01-05" #"(\d+)-(\d+)-(\d+)" "$3/$2/$1")`, `"05/01/2024"`},
+		{`(re-find #"(\w+)@(\w+)" "mail bob@example now")`, `["bob@example" "bob" "example"]`},
+		{`(set/union #{1} #{2})`, `#{1 2}`},
+		{`((juxt :a :b) {:a 1 :b 2})`, `[1 2]`},
+		{`((comp str inc) 1)`, `"2"`},
+		{`((fnil inc 0) nil)`, `1`},
+		{`(try (throw (ex-info "bad" {:code 7})) (catch ExceptionInfo e [(ex-message e) (ex-data e)]))`, `["bad" {:code 7}]`},
+		{`(try (/ 1 0) (catch Exception e (ex-message e)))`, `"/: divide by zero"`},
+		{`(reduce (fn [acc x] (if (> x 2) (reduced acc) (+ acc x))) 0 [1 2 3 4])`, `3`},
+		{`(#?(:clj inc :cljs dec) 1)`, `2`},
+	}
+	for _, tc := range cases {
+		if got := evalPr(t, tc.src); got != tc.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestEvalFunction_AppliesInputFromJSON(t *testing.T) {
+	input, err := DecodeJSON([]byte(`{"items":[{"sku":"a","qty":2,"price":1.5},{"sku":"b","qty":1,"price":4}]}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	code := `(fn [{:keys [items]}]
+  {:total (reduce + (map (fn [{:keys [qty price]}] (* qty price)) items))
+   :skus (mapv :sku items)})`
+	out, stats, err := EvalFunction(code, []any{input}, Options{})
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if stats.Steps == 0 {
+		t.Fatalf("expected steps to be counted")
+	}
+	plain, err := ToJSON(out)
+	if err != nil {
+		t.Fatalf("to json: %v", err)
+	}
+	b, _ := json.Marshal(plain)
+	if string(b) != `{"skus":["a","b"],"total":7}` {
+		t.Fatalf("unexpected result: %s", b)
+	}
+}
+
+func TestEvalFunction_RequiresFnForm(t *testing.T) {
+	_, _, err := EvalFunction(`{:a 1}`, nil, Options{})
+	if err == nil || !strings.Contains(err.Error(), "must evaluate to a fn") {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+}
+
+func TestEval_StepLimit(t *testing.T) {
+	_, stats, err := EvalString(`(loop [i 0] (recur (inc i)))`, Options{MaxSteps: 1000})
+	var evalErr *Error
+	if !errors.As(err, &evalErr) || !strings.Contains(evalErr.Message, "step limit of 1000 exceeded") {
+		t.Fatalf("expected step limit error, got %v", err)
+	}
+	if stats.Steps <= 1000 {
+		t.Fatalf("expected steps past the limit, got %d", stats.Steps)
+	}
+}
+
+func TestEval_Timeout(t *testing.T) {
+	_, _, err := EvalString(`(loop [i 0] (recur (inc i)))`, Options{MaxSteps: 1 << 40, Timeout: 20 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
+
+func TestEval_LimitErrorsAreNotCaught(t *testing.T) {
+	_, _, err := EvalString(`(try (loop [] (recur)) (catch Exception e :caught))`, Options{MaxSteps: 100})
+	if err == nil || !strings.Contains(err.Error(), "step limit") {
+		t.Fatalf("expected step limit error, got %v", err)
+	}
+}
+
+func TestEval_ErrorPositionIsInnermostForm(t *testing.T) {
+	_, _, err := EvalString("(let [x 1]\n  (+ x\n     (:a nil)\n     (inc \"s\")))", Options{})
+	var evalErr *Error
+	if !errors.As(err, &evalErr) || evalErr.Position == nil {
+		t.Fatalf("expected positioned error, got %v", err)
+	}
+	if evalErr.Position.Line != 4 || evalErr.Position.Column != 6 {
+		t.Fatalf("unexpected position %+v: %v", *evalErr.Position, err)
+	}
+	if !strings.Contains(evalErr.Message, "string is not a number") {
+		t.Fatalf("unexpected message: %s", evalErr.Message)
+	}
+}
+
+func TestEval_RejectsHostInterop(t *testing.T) {
+	for _, src := range []string{`(System/getenv "HOME")`, `(.toUpperCase "a")`, `(java.util.UUID/randomUUID)`, `(def x 1)`} {
+		if _, _, err := EvalString(src, Options{}); err == nil {
+			t.Errorf("expected %s to fail", src)
+		}
+	}
+}
+
+func TestRead_ReportsPosition(t *testing.T) {
+	_, err := Read("(fn [x]\n  (inc x)")
+	var readErr *ReadError
+	if !errors.As(err, &readErr) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	if readErr.Position.Line != 1 {
+		t.Fatalf("unexpected position: %+v", readErr.Position)
+	}
+}
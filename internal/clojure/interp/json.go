@@ -0,0 +1,140 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// DecodeJSON parses data into interpreter values: objects become maps with
+// keyword keys in document order, arrays become vectors, and integral
+// numbers become longs.
+func DecodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level JSON value")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := NewMap()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				m.set(Keyword(keyTok.(string)), v)
+			}
+			_, err := dec.Token()
+			return m, err
+		case '[':
+			out := Vector{}
+			for dec.More() {
+				v, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+			_, err := dec.Token()
+			return out, err
+		}
+		return nil, fmt.Errorf("unexpected %s in JSON", t)
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	}
+	return tok, nil
+}
+
+// ToJSON converts an interpreter value into plain Go values for
+// encoding/json. Keywords become their names ("ns/name" when qualified) and
+// map keys become strings; functions and non-finite doubles are errors.
+func ToJSON(v any) (any, error) {
+	switch t := v.(type) {
+	case nil, bool, int64, string:
+		return t, nil
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return nil, fmt.Errorf("%s can't be represented in JSON", formatDouble(t))
+		}
+		return t, nil
+	case Keyword:
+		return string(t), nil
+	case Symbol:
+		return string(t), nil
+	case List:
+		return itemsToJSON(t)
+	case Vector:
+		return itemsToJSON(t)
+	case *Set:
+		return itemsToJSON(t.Items())
+	case *Map:
+		out := make(map[string]any, t.Len())
+		for i, key := range t.keys {
+			name, err := jsonKey(key)
+			if err != nil {
+				return nil, err
+			}
+			val, err := ToJSON(t.vals[i])
+			if err != nil {
+				return nil, err
+			}
+			out[name] = val
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s can't be represented in JSON", typeName(v))
+}
+
+func itemsToJSON(items []any) ([]any, error) {
+	out := make([]any, len(items))
+	for i, item := range items {
+		v, err := ToJSON(item)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func jsonKey(key any) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case Keyword:
+		return string(k), nil
+	case Symbol:
+		return string(k), nil
+	case nil:
+		return "", errors.New("nil map key can't be represented in JSON")
+	case int64, float64, bool:
+		return Str(k), nil
+	}
+	return strings.TrimSpace(PrStr(key)), nil
+}
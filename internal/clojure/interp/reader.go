@@ -0,0 +1,492 @@
+package interp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column in the source passed to Read.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Forms is the result of Read: the top-level forms plus the source position
+// of every non-empty list, used to locate evaluation errors.
+type Forms struct {
+	Forms     []any
+	positions map[*any]Position
+}
+
+type reader struct {
+	src       string
+	pos       int
+	positions map[*any]Position
+	// fnArgs collects %, %1..%9 and %& while reading a #(...) body.
+	fnArgs *anonFnArgs
+}
+
+type anonFnArgs struct {
+	max  int
+	rest bool
+}
+
+// ReadError is a syntax error with its source position.
+type ReadError struct {
+	Position
+	Message string
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d", e.Message, e.Line, e.Column)
+}
+
+// Read parses every form in src. Reader features that need a host runtime
+// (syntax quote, deref, tagged literals, auto-resolved keywords) are errors.
+func Read(src string) (*Forms, error) {
+	r := &reader{src: src, positions: map[*any]Position{}}
+	var forms []any
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.src) {
+			return &Forms{Forms: forms, positions: r.positions}, nil
+		}
+		form, ok, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			forms = append(forms, form)
+		}
+	}
+}
+
+func (r *reader) position(at int) Position {
+	line := 1 + strings.Count(r.src[:at], "\n")
+	lineStart := strings.LastIndex(r.src[:at], "\n") + 1
+	return Position{Line: line, Column: 1 + utf8.RuneCountInString(r.src[lineStart:at])}
+}
+
+func (r *reader) errorf(at int, format string, args ...any) error {
+	return &ReadError{Position: r.position(at), Message: fmt.Sprintf(format, args...)}
+}
+
+func (r *reader) skipSpace() {
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case c == ';':
+			for r.pos < len(r.src) && r.src[r.pos] != '\n' {
+				r.pos++
+			}
+		case c == ',' || unicode.IsSpace(rune(c)):
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+// read returns one form; ok is false for forms the reader drops (#_ and
+// reader conditionals without a matching branch).
+func (r *reader) read() (any, bool, error) {
+	r.skipSpace()
+	if r.pos >= len(r.src) {
+		return nil, false, r.errorf(r.pos, "unexpected end of input")
+	}
+	start := r.pos
+	c := r.src[r.pos]
+	switch c {
+	case '(':
+		r.pos++
+		items, err := r.readSeq(')', start)
+		if err != nil {
+			return nil, false, err
+		}
+		list := List(items)
+		if len(list) > 0 {
+			r.positions[&list[0]] = r.position(start)
+		}
+		return list, true, nil
+	case '[':
+		r.pos++
+		items, err := r.readSeq(']', start)
+		return Vector(items), err == nil, err
+	case '{':
+		r.pos++
+		items, err := r.readSeq('}', start)
+		if err != nil {
+			return nil, false, err
+		}
+		return mapLiteral(items), true, nil
+	case ')', ']', '}':
+		return nil, false, r.errorf(start, "unmatched delimiter %c", c)
+	case '"':
+		s, err := r.readString()
+		return s, err == nil, err
+	case '\'':
+		r.pos++
+		form, err := r.readRequired(start)
+		return List{Symbol("quote"), form}, err == nil, err
+	case '`':
+		return nil, false, r.errorf(start, "syntax quote is not supported")
+	case '~':
+		return nil, false, r.errorf(start, "unquote is not supported")
+	case '@':
+		return nil, false, r.errorf(start, "deref (@) is not supported: there are no refs or atoms")
+	case '^':
+		r.pos++
+		if _, err := r.readRequired(start); err != nil {
+			return nil, false, err
+		}
+		form, err := r.readRequired(start)
+		return form, err == nil, err
+	case '\\':
+		s, err := r.readChar()
+		return s, err == nil, err
+	case '#':
+		return r.readDispatch()
+	}
+	token := r.readToken()
+	if token == "" {
+		return nil, false, r.errorf(start, "unexpected character %q", c)
+	}
+	v, err := r.parseToken(token, start)
+	return v, err == nil, err
+}
+
+func (r *reader) readRequired(start int) (any, error) {
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.src) {
+			return nil, r.errorf(start, "unexpected end of input")
+		}
+		form, ok, err := r.read()
+		if err != nil || ok {
+			return form, err
+		}
+	}
+}
+
+func (r *reader) readSeq(closer byte, start int) ([]any, error) {
+	items := []any{}
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.src) {
+			return nil, r.errorf(start, "unterminated %c", r.src[start])
+		}
+		if r.src[r.pos] == closer {
+			r.pos++
+			return items, nil
+		}
+		form, ok, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			items = append(items, form)
+		}
+	}
+}
+
+// mapLiteral and setLiteral are {...} and #{...} forms as read; their items
+// are evaluated in source order when the form is evaluated.
+type (
+	mapLiteral []any
+	setLiteral []any
+)
+
+func (r *reader) readDispatch() (any, bool, error) {
+	start := r.pos
+	if r.pos+1 >= len(r.src) {
+		return nil, false, r.errorf(start, "unexpected end of input after #")
+	}
+	c := r.src[r.pos+1]
+	switch c {
+	case '{':
+		r.pos += 2
+		items, err := r.readSeq('}', start)
+		return setLiteral(items), err == nil, err
+	case '(':
+		if r.fnArgs != nil {
+			return nil, false, r.errorf(start, "nested #() are not allowed")
+		}
+		r.pos += 2
+		r.fnArgs = &anonFnArgs{}
+		items, err := r.readSeq(')', start)
+		args := r.fnArgs
+		r.fnArgs = nil
+		if err != nil {
+			return nil, false, err
+		}
+		params := Vector{}
+		for i := 1; i <= args.max; i++ {
+			params = append(params, Symbol("%"+strconv.Itoa(i)))
+		}
+		if args.rest {
+			params = append(params, Symbol("&"), Symbol("%&"))
+		}
+		body := List(items)
+		if len(body) > 0 {
+			r.positions[&body[0]] = r.position(start)
+		}
+		form := List{Symbol("fn"), params, body}
+		r.positions[&form[0]] = r.position(start)
+		return form, true, nil
+	case '"':
+		r.pos++
+		pattern, err := r.readRawString()
+		if err != nil {
+			return nil, false, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, false, r.errorf(start, "invalid regex: %v", err)
+		}
+		return &Regex{Regexp: re, Source: pattern}, true, nil
+	case '_':
+		r.pos += 2
+		_, err := r.readRequired(start)
+		return nil, false, err
+	case '?':
+		return r.readConditional()
+	case '#':
+		r.pos += 2
+		token := r.readToken()
+		switch token {
+		case "Inf":
+			return posInf, true, nil
+		case "-Inf":
+			return negInf, true, nil
+		case "NaN":
+			return nan, true, nil
+		}
+		return nil, false, r.errorf(start, "unsupported symbolic value ##%s", token)
+	case '\'':
+		return nil, false, r.errorf(start, "var quote (#') is not supported")
+	case '=':
+		return nil, false, r.errorf(start, "reader eval (#=) is not supported")
+	}
+	return nil, false, r.errorf(start, "tagged literal #%s is not supported", r.peekToken(r.pos+1))
+}
+
+// readConditional picks the :clj branch, then :default, of #?(...).
+func (r *reader) readConditional() (any, bool, error) {
+	start := r.pos
+	r.pos += 2
+	if r.pos < len(r.src) && r.src[r.pos] == '@' {
+		return nil, false, r.errorf(start, "splicing reader conditionals (#?@) are not supported")
+	}
+	r.skipSpace()
+	if r.pos >= len(r.src) || r.src[r.pos] != '(' {
+		return nil, false, r.errorf(start, "reader conditional needs a list")
+	}
+	r.pos++
+	items, err := r.readSeq(')', start)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(items)%2 != 0 {
+		return nil, false, r.errorf(start, "reader conditional needs feature/form pairs")
+	}
+	var fallback any
+	hasFallback := false
+	for i := 0; i < len(items); i += 2 {
+		switch items[i] {
+		case Keyword("clj"):
+			return items[i+1], true, nil
+		case Keyword("default"):
+			fallback, hasFallback = items[i+1], true
+		}
+	}
+	return fallback, hasFallback, nil
+}
+
+func (r *reader) readString() (string, error) {
+	start := r.pos
+	raw, err := r.readRawString()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", r.errorf(start, "invalid escape at end of string")
+		}
+		switch raw[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\\':
+			b.WriteByte(raw[i])
+		case 'u':
+			if i+5 > len(raw) {
+				return "", r.errorf(start, "invalid unicode escape")
+			}
+			n, err := strconv.ParseUint(raw[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", r.errorf(start, "invalid unicode escape \\u%s", raw[i+1:i+5])
+			}
+			b.WriteRune(rune(n))
+			i += 4
+		default:
+			return "", r.errorf(start, "unsupported escape \\%c", raw[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// readRawString reads from the opening quote at r.pos to the closing quote
+// and returns the body with escapes left in place.
+func (r *reader) readRawString() (string, error) {
+	start := r.pos
+	for i := r.pos + 1; i < len(r.src); i++ {
+		switch r.src[i] {
+		case '\\':
+			i++
+		case '"':
+			r.pos = i + 1
+			return r.src[start+1 : i], nil
+		}
+	}
+	return "", r.errorf(start, "unterminated string")
+}
+
+var namedChars = map[string]string{
+	"newline": "\n", "space": " ", "tab": "\t", "return": "\r",
+	"backspace": "\b", "formfeed": "\f",
+}
+
+func (r *reader) readChar() (string, error) {
+	start := r.pos
+	r.pos++
+	if r.pos >= len(r.src) {
+		return "", r.errorf(start, "unexpected end of input after \\")
+	}
+	ch, size := utf8.DecodeRuneInString(r.src[r.pos:])
+	r.pos += size
+	rest := r.readToken()
+	if rest == "" {
+		return string(ch), nil
+	}
+	name := string(ch) + rest
+	if s, ok := namedChars[name]; ok {
+		return s, nil
+	}
+	if strings.HasPrefix(name, "u") && len(name) == 5 {
+		if n, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return string(rune(n)), nil
+		}
+	}
+	return "", r.errorf(start, "unsupported character literal \\%s", name)
+}
+
+func isTokenByte(c byte) bool {
+	switch c {
+	case '(', ')', '[', ']', '{', '}', '"', ';', ',', '\\', '^', '@', '`', '~':
+		return false
+	}
+	return !unicode.IsSpace(rune(c))
+}
+
+func (r *reader) readToken() string {
+	start := r.pos
+	for r.pos < len(r.src) && isTokenByte(r.src[r.pos]) {
+		r.pos++
+	}
+	return r.src[start:r.pos]
+}
+
+func (r *reader) peekToken(at int) string {
+	end := at
+	for end < len(r.src) && isTokenByte(r.src[end]) {
+		end++
+	}
+	return r.src[at:end]
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[+-]?\d+N?$`)
+	floatPattern = regexp.MustCompile(`^[+-]?\d+(\.\d*)?([eE][+-]?\d+)?M?$`)
+	ratioPattern = regexp.MustCompile(`^[+-]?\d+/\d+$`)
+)
+
+func (r *reader) parseToken(token string, start int) (any, error) {
+	switch token {
+	case "nil":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	c := token[0]
+	if unicode.IsDigit(rune(c)) || ((c == '+' || c == '-') && len(token) > 1 && unicode.IsDigit(rune(token[1]))) {
+		switch {
+		case intPattern.MatchString(token):
+			n, err := strconv.ParseInt(strings.TrimSuffix(token, "N"), 10, 64)
+			if err != nil {
+				return nil, r.errorf(start, "integer %s out of range", token)
+			}
+			return n, nil
+		case floatPattern.MatchString(token):
+			f, err := strconv.ParseFloat(strings.TrimSuffix(token, "M"), 64)
+			if err != nil {
+				return nil, r.errorf(start, "invalid number %s", token)
+			}
+			return f, nil
+		case ratioPattern.MatchString(token):
+			parts := strings.SplitN(token, "/", 2)
+			num, _ := strconv.ParseFloat(parts[0], 64)
+			den, _ := strconv.ParseFloat(parts[1], 64)
+			if den == 0 {
+				return nil, r.errorf(start, "divide by zero in ratio %s", token)
+			}
+			return num / den, nil
+		}
+		return nil, r.errorf(start, "invalid number %s", token)
+	}
+	if c == ':' {
+		if strings.HasPrefix(token, "::") {
+			return nil, r.errorf(start, "auto-resolved keyword %s is not supported", token)
+		}
+		if len(token) == 1 {
+			return nil, r.errorf(start, "invalid keyword :")
+		}
+		return Keyword(token[1:]), nil
+	}
+	if r.fnArgs != nil && strings.HasPrefix(token, "%") {
+		switch {
+		case token == "%":
+			r.fnArgs.max = max(r.fnArgs.max, 1)
+			return Symbol("%1"), nil
+		case token == "%&":
+			r.fnArgs.rest = true
+			return Symbol(token), nil
+		default:
+			n, err := strconv.Atoi(token[1:])
+			if err != nil || n < 1 || n > 20 {
+				return nil, r.errorf(start, "invalid anonymous fn argument %s", token)
+			}
+			r.fnArgs.max = max(r.fnArgs.max, n)
+			return Symbol(token), nil
+		}
+	}
+	return Symbol(token), nil
+}
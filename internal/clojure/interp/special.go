@@ -0,0 +1,658 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+)
+
+type specialForm func(in *Interp, form List, env *Env) (any, error)
+
+var specialForms map[string]specialForm
+
+func init() {
+	specialForms = map[string]specialForm{
+		"quote":    evalQuote,
+		"if":       evalIf,
+		"if-not":   evalIfNot,
+		"when":     evalWhen,
+		"when-not": evalWhenNot,
+		"do":       func(in *Interp, form List, env *Env) (any, error) { return in.evalBody(form[1:], env) },
+		"let":      evalLet,
+		"let*":     evalLet,
+		"loop":     evalLoop,
+		"recur":    evalRecur,
+		"fn":       evalFn,
+		"fn*":      evalFn,
+		"letfn":    evalLetfn,
+		"cond":     evalCond,
+		"condp":    evalCondp,
+		"case":     evalCase,
+		"and":      evalAnd,
+		"or":       evalOr,
+		"->":       func(in *Interp, form List, env *Env) (any, error) { return evalThread(in, form, env, false) },
+		"->>":      func(in *Interp, form List, env *Env) (any, error) { return evalThread(in, form, env, true) },
+		"as->":     evalAsThread,
+		"cond->":   func(in *Interp, form List, env *Env) (any, error) { return evalCondThread(in, form, env, false) },
+		"cond->>":  func(in *Interp, form List, env *Env) (any, error) { return evalCondThread(in, form, env, true) },
+		"some->":   func(in *Interp, form List, env *Env) (any, error) { return evalSomeThread(in, form, env, false) },
+		"some->>":  func(in *Interp, form List, env *Env) (any, error) { return evalSomeThread(in, form, env, true) },
+		"if-let":   func(in *Interp, form List, env *Env) (any, error) { return evalIfLet(in, form, env, false, true) },
+		"when-let": func(in *Interp, form List, env *Env) (any, error) { return evalIfLet(in, form, env, false, false) },
+		"if-some":  func(in *Interp, form List, env *Env) (any, error) { return evalIfLet(in, form, env, true, true) },
+		"when-some": func(in *Interp, form List, env *Env) (any, error) {
+			return evalIfLet(in, form, env, true, false)
+		},
+		"for":     evalFor,
+		"throw":   evalThrow,
+		"try":     evalTry,
+		"comment": func(*Interp, List, *Env) (any, error) { return nil, nil },
+		"def":     unsupportedForm("def is not supported in function code; use let"),
+		"defn":    unsupportedForm("defn is not supported in function code; use let or letfn"),
+		"doseq":   unsupportedForm("doseq is not supported: function code has no side effects; use for"),
+		"dotimes": unsupportedForm("dotimes is not supported: function code has no side effects"),
+		"set!":    unsupportedForm("set! is not supported"),
+		"new":     unsupportedForm("host interop (new ...) is not supported"),
+		".":       unsupportedForm("host interop (. ...) is not supported"),
+	}
+}
+
+func unsupportedForm(message string) specialForm {
+	return func(*Interp, List, *Env) (any, error) { return nil, errors.New(message) }
+}
+
+func evalQuote(_ *Interp, form List, _ *Env) (any, error) {
+	if len(form) != 2 {
+		return nil, errors.New("quote takes exactly one form")
+	}
+	return quoteValue(form[1]), nil
+}
+
+func evalIf(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 3 || len(form) > 4 {
+		return nil, errors.New("if needs a test, a then form and an optional else form")
+	}
+	test, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(test) {
+		return in.eval(form[2], env)
+	}
+	if len(form) == 4 {
+		return in.eval(form[3], env)
+	}
+	return nil, nil
+}
+
+func evalIfNot(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 3 || len(form) > 4 {
+		return nil, errors.New("if-not needs a test, a then form and an optional else form")
+	}
+	swapped := List{Symbol("if"), form[1], nil, form[2]}
+	if len(form) == 4 {
+		swapped[2] = form[3]
+	}
+	return evalIf(in, swapped, env)
+}
+
+func evalWhen(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 2 {
+		return nil, errors.New("when needs a test")
+	}
+	test, err := in.evalArg(form[1], env)
+	if err != nil || !truthy(test) {
+		return nil, err
+	}
+	return in.evalBody(form[2:], env)
+}
+
+func evalWhenNot(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 2 {
+		return nil, errors.New("when-not needs a test")
+	}
+	test, err := in.evalArg(form[1], env)
+	if err != nil || truthy(test) {
+		return nil, err
+	}
+	return in.evalBody(form[2:], env)
+}
+
+func bindingPairs(form List, name string) (Vector, error) {
+	if len(form) < 2 {
+		return nil, fmt.Errorf("%s needs a binding vector", name)
+	}
+	bindings, ok := form[1].(Vector)
+	if !ok || len(bindings)%2 != 0 {
+		return nil, fmt.Errorf("%s needs a vector with an even number of binding forms", name)
+	}
+	return bindings, nil
+}
+
+func evalLet(in *Interp, form List, env *Env) (any, error) {
+	bindings, err := bindingPairs(form, "let")
+	if err != nil {
+		return nil, err
+	}
+	scope := newEnv(env)
+	for i := 0; i < len(bindings); i += 2 {
+		v, err := in.evalArg(bindings[i+1], scope)
+		if err != nil {
+			return nil, err
+		}
+		if err := in.bind(bindings[i], v, scope); err != nil {
+			return nil, err
+		}
+	}
+	return in.evalBody(form[2:], scope)
+}
+
+func evalLoop(in *Interp, form List, env *Env) (any, error) {
+	bindings, err := bindingPairs(form, "loop")
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, 0, len(bindings)/2)
+	scope := newEnv(env)
+	for i := 0; i < len(bindings); i += 2 {
+		v, err := in.evalArg(bindings[i+1], scope)
+		if err != nil {
+			return nil, err
+		}
+		if err := in.bind(bindings[i], v, scope); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	for {
+		out, err := in.evalBody(form[2:], scope)
+		if err != nil {
+			return nil, err
+		}
+		recur, ok := out.(*recurValue)
+		if !ok {
+			return out, nil
+		}
+		if len(recur.args) != len(values) {
+			return nil, fmt.Errorf("recur expects %d args, got %d", len(values), len(recur.args))
+		}
+		scope = newEnv(env)
+		for i, v := range recur.args {
+			if err := in.bind(bindings[2*i], v, scope); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func evalRecur(in *Interp, form List, env *Env) (any, error) {
+	args, err := in.evalAll(form[1:], env)
+	if err != nil {
+		return nil, err
+	}
+	return &recurValue{args: args}, nil
+}
+
+func evalFn(in *Interp, form List, env *Env) (any, error) {
+	rest := form[1:]
+	fn := &Fn{env: env}
+	if len(rest) > 0 {
+		if name, ok := rest[0].(Symbol); ok {
+			fn.name = string(name)
+			rest = rest[1:]
+		}
+	}
+	if len(rest) == 0 {
+		return nil, errors.New("fn needs a parameter vector")
+	}
+	var clauses []List
+	if _, ok := rest[0].(Vector); ok {
+		clauses = []List{rest}
+	} else {
+		for _, clause := range rest {
+			list, ok := clause.(List)
+			if !ok || len(list) == 0 {
+				return nil, errors.New("fn arities must be lists like ([x] body)")
+			}
+			clauses = append(clauses, list)
+		}
+	}
+	for _, clause := range clauses {
+		params, ok := clause[0].(Vector)
+		if !ok {
+			return nil, errors.New("fn parameters must be a vector")
+		}
+		arity := fnArity{body: clause[1:]}
+		for i := 0; i < len(params); i++ {
+			if params[i] == Symbol("&") {
+				if i != len(params)-2 {
+					return nil, errors.New("& must be followed by exactly one parameter")
+				}
+				arity.rest = params[i+1]
+				break
+			}
+			arity.params = append(arity.params, params[i])
+		}
+		if arity.rest != nil {
+			if fn.variadic != nil {
+				return nil, errors.New("fn can only have one variadic arity")
+			}
+			fn.variadic = &arity
+			continue
+		}
+		fn.arities = append(fn.arities, arity)
+	}
+	return fn, nil
+}
+
+func evalLetfn(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 2 {
+		return nil, errors.New("letfn needs a binding vector")
+	}
+	specs, ok := form[1].(Vector)
+	if !ok {
+		return nil, errors.New("letfn needs a vector of (name [params] body) forms")
+	}
+	scope := newEnv(env)
+	for _, spec := range specs {
+		list, ok := spec.(List)
+		if !ok || len(list) < 2 {
+			return nil, errors.New("letfn bindings look like (name [params] body)")
+		}
+		name, ok := list[0].(Symbol)
+		if !ok {
+			return nil, errors.New("letfn binding names must be symbols")
+		}
+		fn, err := evalFn(in, append(List{Symbol("fn"), name}, list[1:]...), scope)
+		if err != nil {
+			return nil, err
+		}
+		scope.vars[name] = fn
+	}
+	return in.evalBody(form[2:], scope)
+}
+
+func evalCond(in *Interp, form List, env *Env) (any, error) {
+	clauses := form[1:]
+	if len(clauses)%2 != 0 {
+		return nil, errors.New("cond needs test/expression pairs")
+	}
+	for i := 0; i < len(clauses); i += 2 {
+		test := clauses[i]
+		if test != Keyword("else") {
+			v, err := in.evalArg(test, env)
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(v) {
+				continue
+			}
+		}
+		return in.eval(clauses[i+1], env)
+	}
+	return nil, nil
+}
+
+func evalCondp(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 3 {
+		return nil, errors.New("condp needs a predicate and an expression")
+	}
+	pred, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := in.evalArg(form[2], env)
+	if err != nil {
+		return nil, err
+	}
+	clauses := form[3:]
+	for i := 0; i+1 < len(clauses); i += 2 {
+		test, err := in.evalArg(clauses[i], env)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := in.apply(pred, []any{test, expr})
+		if err != nil {
+			return nil, err
+		}
+		if truthy(ok) {
+			return in.eval(clauses[i+1], env)
+		}
+	}
+	if len(clauses)%2 == 1 {
+		return in.eval(clauses[len(clauses)-1], env)
+	}
+	return nil, fmt.Errorf("no matching clause: %s", PrStr(expr))
+}
+
+func evalCase(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 2 {
+		return nil, errors.New("case needs an expression")
+	}
+	v, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	clauses := form[2:]
+	for i := 0; i+1 < len(clauses); i += 2 {
+		if group, ok := clauses[i].(List); ok {
+			for _, option := range group {
+				if Equal(quoteValue(option), v) {
+					return in.eval(clauses[i+1], env)
+				}
+			}
+			continue
+		}
+		if Equal(quoteValue(clauses[i]), v) {
+			return in.eval(clauses[i+1], env)
+		}
+	}
+	if len(clauses)%2 == 1 {
+		return in.eval(clauses[len(clauses)-1], env)
+	}
+	return nil, fmt.Errorf("no matching clause: %s", PrStr(v))
+}
+
+func evalAnd(in *Interp, form List, env *Env) (any, error) {
+	var out any = true
+	for i, f := range form[1:] {
+		var err error
+		if i == len(form)-2 {
+			out, err = in.eval(f, env)
+		} else {
+			out, err = in.evalArg(f, env)
+		}
+		if err != nil || !truthy(out) {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func evalOr(in *Interp, form List, env *Env) (any, error) {
+	var out any
+	for i, f := range form[1:] {
+		var err error
+		if i == len(form)-2 {
+			out, err = in.eval(f, env)
+		} else {
+			out, err = in.evalArg(f, env)
+		}
+		if err != nil || truthy(out) {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// threadForm inserts x into step as the second (->) or last (->>) item; a
+// bare symbol or keyword step becomes (step x).
+func threadForm(step, x any, last bool) any {
+	list, ok := step.(List)
+	if !ok || len(list) == 0 {
+		return List{step, x}
+	}
+	out := make(List, 0, len(list)+1)
+	if last {
+		out = append(append(out, list...), x)
+	} else {
+		out = append(append(append(out, list[0]), x), list[1:]...)
+	}
+	return out
+}
+
+func evalThread(in *Interp, form List, env *Env, last bool) (any, error) {
+	if len(form) < 2 {
+		return nil, fmt.Errorf("%s needs an initial expression", form[0])
+	}
+	x := form[1]
+	for _, step := range form[2:] {
+		x = threadForm(step, x, last)
+	}
+	return in.eval(x, env)
+}
+
+func evalAsThread(in *Interp, form List, env *Env) (any, error) {
+	if len(form) < 3 {
+		return nil, errors.New("as-> needs an expression and a name")
+	}
+	name, ok := form[2].(Symbol)
+	if !ok {
+		return nil, errors.New("as-> name must be a symbol")
+	}
+	scope := newEnv(env)
+	v, err := in.evalArg(form[1], scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range form[3:] {
+		scope.vars[name] = v
+		if v, err = in.evalArg(step, scope); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func evalCondThread(in *Interp, form List, env *Env, last bool) (any, error) {
+	if len(form) < 2 || len(form)%2 != 0 {
+		return nil, fmt.Errorf("%s needs an expression and test/form pairs", form[0])
+	}
+	v, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	for i := 2; i < len(form); i += 2 {
+		test, err := in.evalArg(form[i], env)
+		if err != nil {
+			return nil, err
+		}
+		if !truthy(test) {
+			continue
+		}
+		if v, err = in.evalArg(threadForm(form[i+1], literalForm{v}, last), env); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func evalSomeThread(in *Interp, form List, env *Env, last bool) (any, error) {
+	if len(form) < 2 {
+		return nil, fmt.Errorf("%s needs an initial expression", form[0])
+	}
+	v, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range form[2:] {
+		if v == nil {
+			return nil, nil
+		}
+		if v, err = in.evalArg(threadForm(step, literalForm{v}, last), env); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func evalIfLet(in *Interp, form List, env *Env, some bool, hasElse bool) (any, error) {
+	bindings, err := bindingPairs(form, string(form[0].(Symbol)))
+	if err != nil {
+		return nil, err
+	}
+	if len(bindings) != 2 {
+		return nil, fmt.Errorf("%s needs exactly one binding", form[0])
+	}
+	v, err := in.evalArg(bindings[1], env)
+	if err != nil {
+		return nil, err
+	}
+	matched := truthy(v)
+	if some {
+		matched = v != nil
+	}
+	if !matched {
+		if hasElse && len(form) > 3 {
+			return in.eval(form[3], env)
+		}
+		return nil, nil
+	}
+	scope := newEnv(env)
+	if err := in.bind(bindings[0], v, scope); err != nil {
+		return nil, err
+	}
+	if hasElse {
+		if len(form) < 3 || len(form) > 4 {
+			return nil, fmt.Errorf("%s needs a then form and an optional else form", form[0])
+		}
+		return in.eval(form[2], scope)
+	}
+	return in.evalBody(form[2:], scope)
+}
+
+// evalFor supports multiple bindings with :let, :when and :while.
+func evalFor(in *Interp, form List, env *Env) (any, error) {
+	if len(form) != 3 {
+		return nil, errors.New("for needs a binding vector and one body form")
+	}
+	bindings, ok := form[1].(Vector)
+	if !ok || len(bindings)%2 != 0 {
+		return nil, errors.New("for needs a vector with an even number of binding forms")
+	}
+	out := List{}
+	var step func(i int, scope *Env) (bool, error)
+	// step returns false when a :while stops the enclosing binding.
+	step = func(i int, scope *Env) (bool, error) {
+		if i >= len(bindings) {
+			v, err := in.evalArg(form[2], scope)
+			if err != nil {
+				return false, err
+			}
+			out = append(out, v)
+			return true, nil
+		}
+		switch bindings[i] {
+		case Keyword("let"):
+			pairs, ok := bindings[i+1].(Vector)
+			if !ok || len(pairs)%2 != 0 {
+				return false, errors.New("for :let needs a binding vector")
+			}
+			inner := newEnv(scope)
+			for j := 0; j < len(pairs); j += 2 {
+				v, err := in.evalArg(pairs[j+1], inner)
+				if err != nil {
+					return false, err
+				}
+				if err := in.bind(pairs[j], v, inner); err != nil {
+					return false, err
+				}
+			}
+			return step(i+2, inner)
+		case Keyword("when"):
+			v, err := in.evalArg(bindings[i+1], scope)
+			if err != nil || !truthy(v) {
+				return true, err
+			}
+			return step(i+2, scope)
+		case Keyword("while"):
+			v, err := in.evalArg(bindings[i+1], scope)
+			if err != nil || !truthy(v) {
+				return false, err
+			}
+			return step(i+2, scope)
+		}
+		coll, err := in.evalArg(bindings[i+1], scope)
+		if err != nil {
+			return false, err
+		}
+		items, err := seqItems(coll)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range items {
+			inner := newEnv(scope)
+			if err := in.bind(bindings[i], item, inner); err != nil {
+				return false, err
+			}
+			more, err := step(i+2, inner)
+			if err != nil {
+				return false, err
+			}
+			if !more {
+				break
+			}
+		}
+		return true, nil
+	}
+	if _, err := step(0, env); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func evalThrow(in *Interp, form List, env *Env) (any, error) {
+	if len(form) != 2 {
+		return nil, errors.New("throw takes one ex-info")
+	}
+	v, err := in.evalArg(form[1], env)
+	if err != nil {
+		return nil, err
+	}
+	ex, ok := v.(*ExInfo)
+	if !ok {
+		return nil, fmt.Errorf("throw needs an ex-info, got %s", typeName(v))
+	}
+	return nil, &Error{Message: ex.Message, Data: ex.Data, thrown: true}
+}
+
+// evalTry catches thrown ex-info values and runtime errors, but never step
+// limit or timeout errors. (catch ExceptionInfo e ...) only catches ex-info;
+// any other class name catches everything catchable.
+func evalTry(in *Interp, form List, env *Env) (any, error) {
+	var body []any
+	var catches []List
+	var finally List
+	for _, f := range form[1:] {
+		if list, ok := f.(List); ok && len(list) > 0 {
+			switch list[0] {
+			case Symbol("catch"):
+				if len(list) < 3 {
+					return nil, errors.New("catch needs a class and a binding")
+				}
+				catches = append(catches, list)
+				continue
+			case Symbol("finally"):
+				finally = list
+				continue
+			}
+		}
+		body = append(body, f)
+	}
+	out, err := in.evalBody(body, env)
+	if err != nil {
+		var evalErr *Error
+		if !errors.As(err, &evalErr) {
+			evalErr = &Error{Message: err.Error()}
+		}
+		if !evalErr.limit {
+			for _, c := range catches {
+				class, _ := c[1].(Symbol)
+				if (class == "ExceptionInfo" || class == "clojure.lang.ExceptionInfo") && !evalErr.thrown {
+					continue
+				}
+				scope := newEnv(env)
+				if err := in.bind(c[2], &ExInfo{Message: evalErr.Message, Data: evalErr.Data}, scope); err != nil {
+					return nil, err
+				}
+				out, err = in.evalBody(c[3:], scope)
+				break
+			}
+		}
+	}
+	if finally != nil {
+		if _, ferr := in.evalBody(finally[1:], env); ferr != nil {
+			return nil, ferr
+		}
+	}
+	return out, err
+}
@@ -0,0 +1,447 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Values are plain Go types where possible: nil, bool, int64, float64 and
+// string. Characters read as one-character strings. Collections are
+// immutable; every "modifying" operation returns a copy.
+type (
+	Keyword string // name without the leading colon, "ns/name" when qualified
+	Symbol  string
+	List    []any
+	Vector  []any
+)
+
+// Map keeps insertion order so printing and JSON output are stable.
+type Map struct {
+	keys  []any
+	vals  []any
+	index map[string]int
+}
+
+type Set struct {
+	items []any
+	index map[string]int
+}
+
+// Fn is a closure created by fn, #(...) or letfn.
+type Fn struct {
+	name     string
+	arities  []fnArity
+	variadic *fnArity
+	env      *Env
+}
+
+type fnArity struct {
+	params []any
+	rest   any
+	body   []any
+}
+
+// Builtin is a core function implemented in Go.
+type Builtin struct {
+	Name string
+	Fn   func(in *Interp, args []any) (any, error)
+}
+
+// Regex is a compiled #"..." literal; Source keeps the original pattern for
+// printing.
+type Regex struct {
+	*regexp.Regexp
+	Source string
+}
+
+func NewMap() *Map { return &Map{index: map[string]int{}} }
+
+func (m *Map) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.keys)
+}
+
+func (m *Map) Get(key any) (any, bool) {
+	if m == nil {
+		return nil, false
+	}
+	i, ok := m.index[keyString(key)]
+	if !ok {
+		return nil, false
+	}
+	return m.vals[i], true
+}
+
+func (m *Map) clone() *Map {
+	out := &Map{
+		keys:  append([]any(nil), m.keys...),
+		vals:  append([]any(nil), m.vals...),
+		index: make(map[string]int, len(m.index)+1),
+	}
+	for k, v := range m.index {
+		out.index[k] = v
+	}
+	return out
+}
+
+func (m *Map) Assoc(key, val any) *Map {
+	if m == nil {
+		m = NewMap()
+	}
+	out := m.clone()
+	out.set(key, val)
+	return out
+}
+
+// set mutates m; only call it on a map that has not been shared yet.
+func (m *Map) set(key, val any) {
+	k := keyString(key)
+	if i, ok := m.index[k]; ok {
+		m.vals[i] = val
+		return
+	}
+	m.index[k] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.vals = append(m.vals, val)
+}
+
+func (m *Map) Dissoc(keys ...any) *Map {
+	if m == nil {
+		return nil
+	}
+	drop := map[string]bool{}
+	for _, key := range keys {
+		drop[keyString(key)] = true
+	}
+	out := NewMap()
+	for i, key := range m.keys {
+		if !drop[keyString(key)] {
+			out.set(key, m.vals[i])
+		}
+	}
+	return out
+}
+
+// Entries returns [k v] vectors in insertion order.
+func (m *Map) Entries() []any {
+	if m == nil {
+		return nil
+	}
+	out := make([]any, len(m.keys))
+	for i, key := range m.keys {
+		out[i] = Vector{key, m.vals[i]}
+	}
+	return out
+}
+
+func (m *Map) Keys() []any {
+	if m == nil {
+		return nil
+	}
+	return append([]any(nil), m.keys...)
+}
+
+func (m *Map) Vals() []any {
+	if m == nil {
+		return nil
+	}
+	return append([]any(nil), m.vals...)
+}
+
+func NewSet(items ...any) *Set {
+	s := &Set{index: map[string]int{}}
+	for _, item := range items {
+		s.add(item)
+	}
+	return s
+}
+
+func (s *Set) add(item any) {
+	k := keyString(item)
+	if _, ok := s.index[k]; ok {
+		return
+	}
+	s.index[k] = len(s.items)
+	s.items = append(s.items, item)
+}
+
+func (s *Set) Contains(item any) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.index[keyString(item)]
+	return ok
+}
+
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.items)
+}
+
+func (s *Set) Conj(items ...any) *Set {
+	out := NewSet(s.Items()...)
+	for _, item := range items {
+		out.add(item)
+	}
+	return out
+}
+
+func (s *Set) Disj(items ...any) *Set {
+	drop := NewSet(items...)
+	out := NewSet()
+	for _, item := range s.Items() {
+		if !drop.Contains(item) {
+			out.add(item)
+		}
+	}
+	return out
+}
+
+func (s *Set) Items() []any {
+	if s == nil {
+		return nil
+	}
+	return append([]any(nil), s.items...)
+}
+
+// keyString is a canonical encoding used for map keys, set members and
+// equality: lists and vectors with equal items encode the same, maps and
+// sets encode independent of order.
+func keyString(v any) string {
+	var b strings.Builder
+	writeKeyString(&b, v)
+	return b.String()
+}
+
+func writeKeyString(b *strings.Builder, v any) {
+	switch t := v.(type) {
+	case nil:
+		b.WriteString("n")
+	case bool:
+		if t {
+			b.WriteString("T")
+		} else {
+			b.WriteString("F")
+		}
+	case int64:
+		b.WriteString("i")
+		b.WriteString(strconv.FormatInt(t, 10))
+	case float64:
+		b.WriteString("d")
+		b.WriteString(strconv.FormatFloat(t, 'g', -1, 64))
+	case string:
+		b.WriteString("s")
+		b.WriteString(strconv.Quote(t))
+	case Keyword:
+		b.WriteString("k")
+		b.WriteString(string(t))
+		b.WriteString(" ")
+	case Symbol:
+		b.WriteString("y")
+		b.WriteString(string(t))
+		b.WriteString(" ")
+	case List:
+		writeSeqKeyString(b, t)
+	case Vector:
+		writeSeqKeyString(b, t)
+	case *Map:
+		parts := make([]string, 0, t.Len())
+		for i, key := range t.keys {
+			parts = append(parts, keyString(key)+"="+keyString(t.vals[i]))
+		}
+		sort.Strings(parts)
+		b.WriteString("{")
+		b.WriteString(strings.Join(parts, ","))
+		b.WriteString("}")
+	case *Set:
+		parts := make([]string, 0, t.Len())
+		for _, item := range t.items {
+			parts = append(parts, keyString(item))
+		}
+		sort.Strings(parts)
+		b.WriteString("#{")
+		b.WriteString(strings.Join(parts, ","))
+		b.WriteString("}")
+	default:
+		fmt.Fprintf(b, "%T@%p", v, v)
+	}
+}
+
+func writeSeqKeyString(b *strings.Builder, items []any) {
+	b.WriteString("[")
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		writeKeyString(b, item)
+	}
+	b.WriteString("]")
+}
+
+func Equal(a, b any) bool {
+	return keyString(a) == keyString(b)
+}
+
+func truthy(v any) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return true
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int64:
+		return "long"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case Keyword:
+		return "keyword"
+	case Symbol:
+		return "symbol"
+	case List:
+		return "list"
+	case Vector:
+		return "vector"
+	case *Map:
+		return "map"
+	case *Set:
+		return "set"
+	case *Fn, *Builtin:
+		return "fn"
+	case *Regex:
+		return "regex"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// PrStr prints v the way pr-str does; Str prints it the way str does
+// (strings and characters unquoted, nil as "").
+func PrStr(v any) string {
+	var b strings.Builder
+	writeValue(&b, v, true)
+	return b.String()
+}
+
+func Str(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+	var b strings.Builder
+	writeValue(&b, v, false)
+	return b.String()
+}
+
+func writeValue(b *strings.Builder, v any, readably bool) {
+	switch t := v.(type) {
+	case nil:
+		b.WriteString("nil")
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case int64:
+		b.WriteString(strconv.FormatInt(t, 10))
+	case float64:
+		b.WriteString(formatDouble(t))
+	case string:
+		if readably {
+			b.WriteString(strconv.Quote(t))
+		} else {
+			b.WriteString(t)
+		}
+	case Keyword:
+		b.WriteString(":")
+		b.WriteString(string(t))
+	case Symbol:
+		b.WriteString(string(t))
+	case List:
+		writeItems(b, "(", ")", t, readably)
+	case Vector:
+		writeItems(b, "[", "]", t, readably)
+	case *Map:
+		b.WriteString("{")
+		for i, key := range t.keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeValue(b, key, readably)
+			b.WriteString(" ")
+			writeValue(b, t.vals[i], readably)
+		}
+		b.WriteString("}")
+	case *Set:
+		writeItems(b, "#{", "}", t.items, readably)
+	case *Fn:
+		b.WriteString("#function[")
+		b.WriteString(firstNonEmpty(t.name, "fn"))
+		b.WriteString("]")
+	case *Builtin:
+		b.WriteString("#function[clojure.core/")
+		b.WriteString(t.Name)
+		b.WriteString("]")
+	case *Regex:
+		b.WriteString(`#"`)
+		b.WriteString(t.Source)
+		b.WriteString(`"`)
+	default:
+		fmt.Fprint(b, v)
+	}
+}
+
+func writeItems(b *strings.Builder, open, close string, items []any, readably bool) {
+	b.WriteString(open)
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		writeValue(b, item, readably)
+	}
+	b.WriteString(close)
+}
+
+func formatDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "##NaN"
+	case math.IsInf(f, 1):
+		return "##Inf"
+	case math.IsInf(f, -1):
+		return "##-Inf"
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if math.Abs(f) >= 1e16 || (f != 0 && math.Abs(f) < 1e-4) {
+		s = strconv.FormatFloat(f, 'E', -1, 64)
+	}
+	if !strings.ContainsAny(s, ".EN") {
+		s += ".0"
+	}
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}